@@ -18,9 +18,30 @@
 package cluster
 
 import (
+	"strings"
+)
+
+import (
+	"github.com/apache/dubbo-go/common"
+	"github.com/apache/dubbo-go/common/constant"
 	"github.com/apache/dubbo-go/protocol"
 )
 
 type Cluster interface {
 	Join(Directory) protocol.Invoker
 }
+
+// GetClusterName returns the cluster of the consumer @url, it's chain if the url chains the clusters
+// like failover,failback or configures the cluster of any method
+func GetClusterName(url common.URL) string {
+	name := url.GetParam(constant.CLUSTER_KEY, constant.DEFAULT_CLUSTER)
+	if strings.Contains(name, ",") {
+		return constant.CHAIN_CLUSTER
+	}
+	for key := range url.Params {
+		if strings.HasPrefix(key, "methods.") && strings.HasSuffix(key, "."+constant.CLUSTER_KEY) {
+			return constant.CHAIN_CLUSTER
+		}
+	}
+	return name
+}
@@ -52,10 +52,11 @@ func (invoker *availableClusterInvoker) Invoke(invocation protocol.Invocation) p
 		return &protocol.RPCResult{Err: err}
 	}
 
+	// the first available one in the order of the directory, the load balance isn't used
 	for _, ivk := range invokers {
 		if ivk.IsAvailable() {
 			return ivk.Invoke(invocation)
 		}
 	}
-	return &protocol.RPCResult{Err: errors.New(fmt.Sprintf("no provider available in %v", invokers))}
+	return &protocol.RPCResult{Err: errors.New(fmt.Sprintf("no provider available for the service %v in %v", invoker.GetUrl().Service(), invokers))}
 }
@@ -19,6 +19,7 @@ package cluster_impl
 
 import (
 	"context"
+	"fmt"
 	"strings"
 	"testing"
 )
@@ -86,3 +87,29 @@ func TestAvailableClusterInvokerNoAvail(t *testing.T) {
 	assert.True(t, strings.Contains(result.Error().Error(), "no provider available"))
 	assert.Nil(t, result.Result())
 }
+
+func TestAvailableClusterInvokerOrder(t *testing.T) {
+	// the load balance isn't used, the unregistered one doesn't matter
+	invokers := []protocol.Invoker{}
+	for i := 0; i < 3; i++ {
+		url, _ := common.NewURL(context.TODO(), fmt.Sprintf("dubbo://192.168.1.%v:20000/com.ikurento.user.UserProvider?loadbalance=unregistered", i))
+		invokers = append(invokers, &delayedInvoker{MockInvoker: NewMockInvoker(url, 1), invoked: new(int32)})
+	}
+	clusterInvoker := NewAvailableCluster().Join(directory.NewStaticDirectory(invokers))
+
+	for i := 0; i < 10; i++ {
+		assert.Equal(t, "192.168.1.0:20000", clusterInvoker.Invoke(&invocation.RPCInvocation{}).Result())
+	}
+	invokers[0].(*delayedInvoker).available = false
+	assert.Equal(t, "192.168.1.1:20000", clusterInvoker.Invoke(&invocation.RPCInvocation{}).Result())
+	invokers[1].(*delayedInvoker).available = false
+	assert.Equal(t, "192.168.1.2:20000", clusterInvoker.Invoke(&invocation.RPCInvocation{}).Result())
+
+	invokers[2].(*delayedInvoker).available = false
+	result := clusterInvoker.Invoke(&invocation.RPCInvocation{})
+	assert.Error(t, result.Error())
+	assert.Contains(t, result.Error().Error(), "no provider available for the service com.ikurento.user.UserProvider")
+	for i, invoked := range []int32{10, 1, 1} {
+		assert.Equal(t, invoked, *invokers[i].(*delayedInvoker).invoked)
+	}
+}
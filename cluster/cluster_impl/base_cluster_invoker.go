@@ -17,6 +17,13 @@
 
 package cluster_impl
 
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
 import (
 	perrors "github.com/pkg/errors"
 	"go.uber.org/atomic"
@@ -24,24 +31,37 @@ import (
 
 import (
 	"github.com/apache/dubbo-go/cluster"
+	"github.com/apache/dubbo-go/cluster/router"
 	"github.com/apache/dubbo-go/common"
 	"github.com/apache/dubbo-go/common/constant"
 	"github.com/apache/dubbo-go/common/extension"
+	"github.com/apache/dubbo-go/common/logger"
 	"github.com/apache/dubbo-go/common/utils"
 	"github.com/apache/dubbo-go/protocol"
 )
 
+// errInvokePanicked is recorded by the circuit breaker and the health check router for the invocation panicking
+var errInvokePanicked = perrors.New("the invocation panicked")
+
 type baseClusterInvoker struct {
-	directory      cluster.Directory
-	availablecheck bool
-	destroyed      *atomic.Bool
+	directory       cluster.Directory
+	availablecheck  bool
+	destroyed       *atomic.Bool
+	outlierDetector *latencyOutlierDetector
+	circuitBreaker  *circuitBreaker
+	errorLog        *errorLogSampler
+	sticky          *stickyInvokers
 }
 
 func newBaseClusterInvoker(directory cluster.Directory) baseClusterInvoker {
 	return baseClusterInvoker{
-		directory:      directory,
-		availablecheck: true,
-		destroyed:      atomic.NewBool(false),
+		directory:       directory,
+		availablecheck:  true,
+		destroyed:       atomic.NewBool(false),
+		outlierDetector: newLatencyOutlierDetector(),
+		circuitBreaker:  newCircuitBreaker(),
+		errorLog:        newErrorLogSampler(),
+		sticky:          newStickyInvokers(),
 	}
 }
 func (invoker *baseClusterInvoker) GetUrl() common.URL {
@@ -83,7 +103,31 @@ func (invoker *baseClusterInvoker) checkWhetherDestroyed() error {
 }
 
 func (invoker *baseClusterInvoker) doSelect(lb cluster.LoadBalance, invocation protocol.Invocation, invokers []protocol.Invoker, invoked []protocol.Invoker) protocol.Invoker {
-	//todo:sticky connect
+	if ivk := selectForcedInvoker(invocation, invokers, invoked); ivk != nil {
+		return ivk
+	}
+	invokers = excludeDraining(invokers)
+	invokers = preferSerialization(invoker.GetUrl(), invokers)
+	invokers = invoker.outlierDetector.selectable(invokers)
+	if !circuitBypassed(invocation) {
+		invokers = invoker.circuitBreaker.selectable(invoker.GetUrl(), invokers)
+	}
+
+	methodName := invocation.MethodName()
+	if !invoker.GetUrl().GetMethodParamBool(methodName, constant.STICKY_KEY, false) {
+		return invoker.selectByLoadBalance(lb, invocation, invokers, invoked)
+	}
+	// the sticky invoker is reused if it's available, still among the candidates and not invoked yet
+	if ivk := invoker.sticky.get(methodName); ivk != nil && ivk.IsAvailable() &&
+		isInvoked(ivk, invokers) && !isInvoked(ivk, invoked) {
+		return ivk
+	}
+	selectedInvoker := invoker.selectByLoadBalance(lb, invocation, invokers, invoked)
+	invoker.sticky.set(methodName, selectedInvoker)
+	return selectedInvoker
+}
+
+func (invoker *baseClusterInvoker) selectByLoadBalance(lb cluster.LoadBalance, invocation protocol.Invocation, invokers []protocol.Invoker, invoked []protocol.Invoker) protocol.Invoker {
 	if len(invokers) == 1 {
 		return invokers[0]
 	}
@@ -115,6 +159,82 @@ func (invoker *baseClusterInvoker) doSelect(lb cluster.LoadBalance, invocation p
 
 }
 
+// doInvoke invokes the selected invoker and records its latency for the outlier detection,
+// and its result for the circuit breaker and the health check router if it's enabled
+func (invoker *baseClusterInvoker) doInvoke(ivk protocol.Invoker, invocation protocol.Invocation) (result protocol.Result) {
+	bypassed := circuitBypassed(invocation)
+	if !bypassed {
+		invoker.circuitBreaker.begin(invoker.GetUrl(), ivk)
+		// the trial of the half-open circuit ends even if it panics
+		defer func() {
+			err := errInvokePanicked
+			if result != nil {
+				err = result.Error()
+			}
+			invoker.circuitBreaker.record(invoker.GetUrl(), ivk, err)
+		}()
+	}
+	start := time.Now()
+	if invoker.GetUrl().GetParamBool(constant.HEALTH_CHECK_ENABLED_KEY, false) {
+		url := ivk.GetUrl()
+		router.BeginHealthCount(url)
+		defer func() {
+			err := errInvokePanicked
+			if result != nil {
+				err = result.Error()
+			}
+			router.EndHealthCount(url, time.Since(start), err)
+		}()
+	}
+	result = ivk.Invoke(invocation)
+	elapsed := time.Since(start)
+	invoker.outlierDetector.record(invoker.GetUrl(), ivk, elapsed)
+	if result.Error() != nil {
+		invoker.sticky.remove(invocation.MethodName(), ivk)
+	}
+	return result
+}
+
+// circuitBypassed returns true if the invocation bypasses the circuit breaker by the circuit.bypass attachment
+func circuitBypassed(invocation protocol.Invocation) bool {
+	return invocation.AttachmentsByKey(constant.CIRCUIT_BYPASS_KEY, "") == "true"
+}
+
+// selectForcedInvoker returns the invoker of the provider address specified by the force.address attachment,
+// nil is returned if the provider is absent, unavailable or already invoked, then the load balance is used.
+func selectForcedInvoker(invocation protocol.Invocation, invokers []protocol.Invoker, invoked []protocol.Invoker) protocol.Invoker {
+	address := invocation.AttachmentsByKey(constant.FORCE_ADDRESS_KEY, "")
+	if address == "" {
+		return nil
+	}
+	for _, ivk := range invokers {
+		if ivk.GetUrl().Location == address && ivk.IsAvailable() && !isInvoked(ivk, invoked) && !cluster.IsProviderDraining(address) {
+			return ivk
+		}
+	}
+	logger.Warnf("the forced provider %v of the method %v is not available, select by load balance.", address, invocation.MethodName())
+	return nil
+}
+
+// excludeDraining removes the draining providers from the selection, all the @invokers are returned
+// if all of them are draining, rather than failing the invocation.
+func excludeDraining(invokers []protocol.Invoker) []protocol.Invoker {
+	if !cluster.HasDrainingProviders() {
+		return invokers
+	}
+	selectable := make([]protocol.Invoker, 0, len(invokers))
+	for _, ivk := range invokers {
+		if !cluster.IsProviderDraining(ivk.GetUrl().Location) {
+			selectable = append(selectable, ivk)
+		}
+	}
+	if len(selectable) == 0 {
+		logger.Warnf("all the %v providers are draining, select among them.", len(invokers))
+		return invokers
+	}
+	return selectable
+}
+
 func isInvoked(selectedInvoker protocol.Invoker, invoked []protocol.Invoker) bool {
 	for _, i := range invoked {
 		if i == selectedInvoker {
@@ -124,9 +244,10 @@ func isInvoked(selectedInvoker protocol.Invoker, invoked []protocol.Invoker) boo
 	return false
 }
 
-func getLoadBalance(invoker protocol.Invoker, invocation protocol.Invocation) cluster.LoadBalance {
-	url := invoker.GetUrl()
-
+// getLoadBalance resolves the load balance of the invocation for all the cluster invokers:
+// the method level config first, then the service level one, and the DEFAULT_LOADBALANCE
+// if neither of them is specified.
+func getLoadBalance(url common.URL, invocation protocol.Invocation) cluster.LoadBalance {
 	methodName := invocation.MethodName()
 	//Get the service loadbalance config
 	lb := url.GetParam(constant.LOADBALANCE_KEY, constant.DEFAULT_LOADBALANCE)
@@ -137,3 +258,63 @@ func getLoadBalance(invoker protocol.Invoker, invocation protocol.Invocation) cl
 	}
 	return extension.GetLoadbalance(lb)
 }
+
+// getRetries resolves the retries of the invocation by its attachment, the method config, the service config
+// and then @defaultRetries in order, so a method can set 0 to disable the retries of the service.
+func getRetries(url common.URL, invocation protocol.Invocation, defaultRetries int64) int64 {
+	if v, err := strconv.ParseInt(invocation.AttachmentsByKey(constant.RETRIES_KEY, ""), 10, 64); err == nil {
+		return v
+	}
+	if v, err := strconv.ParseInt(url.GetMethodParam(invocation.MethodName(), constant.RETRIES_KEY, ""), 10, 64); err == nil {
+		return v
+	}
+	return url.GetParamInt(constant.RETRIES_KEY, defaultRetries)
+}
+
+// attachTimeout attaches the timeout of the method config to the invocation, so the protocol honors it,
+// unless the invocation has its own one.
+func attachTimeout(url common.URL, invocation protocol.Invocation) {
+	inv, ok := invocation.(interface{ SetAttachments(string, string) })
+	if !ok || len(invocation.AttachmentsByKey(constant.TIMEOUT_KEY, "")) > 0 {
+		return
+	}
+	if timeout := url.GetMethodParam(invocation.MethodName(), constant.TIMEOUT_KEY, ""); len(timeout) > 0 {
+		inv.SetAttachments(constant.TIMEOUT_KEY, timeout)
+	}
+}
+
+// isRetryable decides whether a failed invocation should be retried on another provider.
+// Errors carrying a status code are checked against the terminal.codes and retry.codes of the url,
+// the method level config has priority. Errors without status code are always retryable,
+// unless they're annotated as a RetryableError, which may be wrapped.
+func isRetryable(url common.URL, methodName string, err error) bool {
+	var retryableErr protocol.RetryableError
+	if errors.As(err, &retryableErr) {
+		return retryableErr.Retryable()
+	}
+	statusErr, ok := perrors.Cause(err).(protocol.StatusError)
+	if !ok {
+		return true
+	}
+	code := statusErr.StatusCode()
+
+	terminalCodes := url.GetMethodParam(methodName, constant.TERMINAL_CODES_KEY, url.GetParam(constant.TERMINAL_CODES_KEY, ""))
+	if containsCode(terminalCodes, code) {
+		return false
+	}
+
+	retryCodes := url.GetMethodParam(methodName, constant.RETRY_CODES_KEY, url.GetParam(constant.RETRY_CODES_KEY, ""))
+	if len(retryCodes) == 0 {
+		return true
+	}
+	return containsCode(retryCodes, code)
+}
+
+func containsCode(codes string, code string) bool {
+	for _, c := range strings.Split(codes, ",") {
+		if strings.TrimSpace(c) == code {
+			return true
+		}
+	}
+	return false
+}
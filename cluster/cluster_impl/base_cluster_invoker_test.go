@@ -0,0 +1,176 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cluster_impl
+
+import (
+	"context"
+	"fmt"
+	"testing"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/atomic"
+)
+
+import (
+	"github.com/apache/dubbo-go/cluster"
+	"github.com/apache/dubbo-go/cluster/directory"
+	"github.com/apache/dubbo-go/cluster/loadbalance"
+	"github.com/apache/dubbo-go/cluster/router"
+	"github.com/apache/dubbo-go/common"
+	"github.com/apache/dubbo-go/common/constant"
+	"github.com/apache/dubbo-go/common/extension"
+	"github.com/apache/dubbo-go/protocol"
+	"github.com/apache/dubbo-go/protocol/invocation"
+)
+
+func forceAddressInvokers() []protocol.Invoker {
+	invokers := []protocol.Invoker{}
+	for i := 0; i < 10; i++ {
+		url, _ := common.NewURL(context.TODO(), fmt.Sprintf("dubbo://192.168.1.%v:20000/com.ikurento.user.UserProvider", i))
+		invokers = append(invokers, NewMockInvoker(url, 1))
+	}
+	return invokers
+}
+
+func Test_DoSelectForceAddress(t *testing.T) {
+	invokers := forceAddressInvokers()
+	base := newBaseClusterInvoker(directory.NewStaticDirectory(invokers))
+	lb := loadbalance.NewRandomLoadBalance()
+
+	ivc := invocation.NewRPCInvocationWithOptions(invocation.WithAttachments(map[string]string{constant.FORCE_ADDRESS_KEY: "192.168.1.5:20000"}))
+	for i := 0; i < 10; i++ {
+		assert.Equal(t, invokers[5], base.doSelect(lb, ivc, invokers, nil))
+	}
+
+	// the forced provider has been invoked, so select another one by load balance
+	selected := base.doSelect(lb, ivc, invokers, []protocol.Invoker{invokers[5]})
+	assert.NotNil(t, selected)
+	assert.NotEqual(t, invokers[5], selected)
+}
+
+func Test_DoSelectForceAddressFallback(t *testing.T) {
+	invokers := forceAddressInvokers()
+	base := newBaseClusterInvoker(directory.NewStaticDirectory(invokers))
+	lb := loadbalance.NewRandomLoadBalance()
+
+	// the forced provider is absent
+	ivc := invocation.NewRPCInvocationWithOptions(invocation.WithAttachments(map[string]string{constant.FORCE_ADDRESS_KEY: "192.168.2.1:20000"}))
+	assert.NotNil(t, base.doSelect(lb, ivc, invokers, nil))
+
+	// the forced provider is unavailable
+	invokers[5].(*MockInvoker).available = false
+	ivc = invocation.NewRPCInvocationWithOptions(invocation.WithAttachments(map[string]string{constant.FORCE_ADDRESS_KEY: "192.168.1.5:20000"}))
+	for i := 0; i < 10; i++ {
+		selected := base.doSelect(lb, ivc, invokers, nil)
+		assert.NotNil(t, selected)
+		assert.NotEqual(t, invokers[5], selected)
+	}
+}
+
+// countingLoadBalance counts the selections, and selects the first invoker
+type countingLoadBalance struct {
+	count *atomic.Int32
+}
+
+func (lb *countingLoadBalance) Select(invokers []protocol.Invoker, invocation protocol.Invocation) protocol.Invoker {
+	lb.count.Inc()
+	return invokers[0]
+}
+
+func Test_DefaultLoadBalance(t *testing.T) {
+	selected := atomic.NewInt32(0)
+	extension.SetLoadbalance(constant.DEFAULT_LOADBALANCE, func() cluster.LoadBalance {
+		return &countingLoadBalance{count: selected}
+	})
+	defer extension.SetLoadbalance(constant.DEFAULT_LOADBALANCE, loadbalance.NewRandomLoadBalance)
+
+	clusters := map[string]cluster.Cluster{
+		"failback": NewFailbackCluster(),
+		"failfast": NewFailFastCluster(),
+		"failover": NewFailoverCluster(),
+		"failsafe": NewFailsafeCluster(),
+		"forking":  NewForkingCluster(),
+	}
+	for name, c := range clusters {
+		selected.Store(0)
+		clusterInvoker := c.Join(directory.NewStaticDirectory(forceAddressInvokers()))
+		clusterInvoker.Invoke(invocation.NewRPCInvocationWithOptions(invocation.WithMethodName("GetUser")))
+		assert.True(t, selected.Load() > 0, "%s cluster doesn't use the default load balance", name)
+	}
+}
+
+func Test_GetLoadBalance(t *testing.T) {
+	extension.SetLoadbalance(constant.DEFAULT_LOADBALANCE, loadbalance.NewRandomLoadBalance)
+	extension.SetLoadbalance(loadbalance.RoundRobin, loadbalance.NewRoundRobinLoadBalance)
+	extension.SetLoadbalance(loadbalance.LeastActive, loadbalance.NewLeastActiveLoadBalance)
+	ivc := invocation.NewRPCInvocationWithOptions(invocation.WithMethodName("GetUser"))
+
+	url, _ := common.NewURL(context.TODO(), "dubbo://192.168.1.1:20000/com.ikurento.user.UserProvider")
+	assert.IsType(t, loadbalance.NewRandomLoadBalance(), getLoadBalance(url, ivc))
+
+	url, _ = common.NewURL(context.TODO(), "dubbo://192.168.1.1:20000/com.ikurento.user.UserProvider?loadbalance=roundrobin")
+	assert.IsType(t, loadbalance.NewRoundRobinLoadBalance(), getLoadBalance(url, ivc))
+
+	// the method level config has priority
+	url, _ = common.NewURL(context.TODO(), "dubbo://192.168.1.1:20000/com.ikurento.user.UserProvider?loadbalance=roundrobin&"+
+		"methods.GetUser.loadbalance=leastactive")
+	assert.IsType(t, loadbalance.NewLeastActiveLoadBalance(), getLoadBalance(url, ivc))
+}
+
+// panickingInvoker panics on every invocation
+type panickingInvoker struct {
+	*MockInvoker
+}
+
+func (pi *panickingInvoker) Invoke(invocation protocol.Invocation) protocol.Result {
+	panic("invoke")
+}
+
+func healthCheckInvokers(service string, params string) []protocol.Invoker {
+	invokers := []protocol.Invoker{}
+	for i := 0; i < 2; i++ {
+		url, _ := common.NewURL(context.TODO(), fmt.Sprintf("dubbo://192.168.1.%v:20000/%v?%v", i+1, service, params))
+		invokers = append(invokers, &panickingInvoker{NewMockInvoker(url, 1)})
+	}
+	return invokers
+}
+
+func Test_DoInvokeHealthCount(t *testing.T) {
+	hcr := router.NewHealthCheckRouter()
+	routeUrl, _ := common.NewURL(context.TODO(), "consumer://192.168.1.10/com.foo.BarService?health.check.enabled=true&error.threshold=1")
+	ivc := invocation.NewRPCInvocationWithOptions(invocation.WithMethodName("GetUser"))
+
+	// the panicking invocation is counted as a failure
+	invokers := healthCheckInvokers("com.foo.HealthCountService", "health.check.enabled=true")
+	dir := directory.NewStaticDirectory(invokers)
+	base := newBaseClusterInvoker(dir)
+	assert.Panics(t, func() { base.doInvoke(invokers[0], ivc) })
+	assert.Equal(t, invokers[1:], hcr.Route(invokers, routeUrl, ivc))
+
+	// the health is removed with the destroyed invokers
+	dir.Destroy()
+	assert.Equal(t, invokers, hcr.Route(invokers, routeUrl, ivc))
+
+	// the invocations aren't counted if the health check is disabled
+	invokers = healthCheckInvokers("com.foo.NoHealthCountService", "")
+	base = newBaseClusterInvoker(directory.NewStaticDirectory(invokers))
+	assert.Panics(t, func() { base.doInvoke(invokers[0], ivc) })
+	assert.Equal(t, invokers, hcr.Route(invokers, routeUrl, ivc))
+}
@@ -17,8 +17,14 @@ limitations under the License.
 
 package cluster_impl
 
+import (
+	perrors "github.com/pkg/errors"
+)
+
 import (
 	"github.com/apache/dubbo-go/cluster"
+	"github.com/apache/dubbo-go/cluster/loadbalance"
+	"github.com/apache/dubbo-go/common/constant"
 	"github.com/apache/dubbo-go/common/logger"
 	"github.com/apache/dubbo-go/protocol"
 )
@@ -44,16 +50,58 @@ func (invoker *broadcastClusterInvoker) Invoke(invocation protocol.Invocation) p
 		return &protocol.RPCResult{Err: err}
 	}
 
-	var result protocol.Result
+	quorum := invoker.GetUrl().GetMethodParamInt(invocation.MethodName(), constant.BROADCAST_QUORUM_WEIGHT_KEY,
+		invoker.GetUrl().GetParamInt(constant.BROADCAST_QUORUM_WEIGHT_KEY, 0))
+	var (
+		result      protocol.Result
+		lastSuccess protocol.Result
+		failed      int
+		ackWeight   int64
+	)
 	for _, ivk := range invokers {
 		result = ivk.Invoke(invocation)
 		if result.Error() != nil {
-			logger.Warnf("broadcast invoker invoke err: %v when use invoker: %v\n", result.Error(), ivk)
+			invoker.errorLog.warnf(invoker.GetUrl(), invocation.MethodName(), "broadcast invoker invoke err: %v when use invoker: %v\n", result.Error(), ivk)
 			err = result.Error()
+			failed++
+		} else {
+			lastSuccess = result
+			if quorum > 0 {
+				ackWeight += loadbalance.GetWeight(ivk, invocation)
+			}
+		}
+	}
+	if quorum > 0 {
+		return quorumResult(invocation, quorum, ackWeight, lastSuccess, err)
+	}
+	if err == nil {
+		return result
+	}
+
+	// the partial failure below broadcast.fail.percent is tolerated
+	failPercent := invoker.GetUrl().GetMethodParamInt(invocation.MethodName(), constant.BROADCAST_FAIL_PERCENT_KEY,
+		invoker.GetUrl().GetParamInt(constant.BROADCAST_FAIL_PERCENT_KEY, 0))
+	if lastSuccess != nil && int64(failed)*100 < failPercent*int64(len(invokers)) {
+		logger.Warnf("the broadcast of the method %v failed on %v of %v providers, which is tolerated by %v %v%%",
+			invocation.MethodName(), failed, len(invokers), constant.BROADCAST_FAIL_PERCENT_KEY, failPercent)
+		return lastSuccess
+	}
+	return &protocol.RPCResult{Err: err}
+}
+
+// quorumResult returns the last successful result if the weight of the acknowledging providers reaches the quorum
+func quorumResult(invocation protocol.Invocation, quorum int64, ackWeight int64, lastSuccess protocol.Result, err error) protocol.Result {
+	if lastSuccess != nil && ackWeight >= quorum {
+		if err != nil {
+			logger.Warnf("the broadcast of the method %v is acknowledged by the weight %v, which reaches the quorum %v: %v",
+				invocation.MethodName(), ackWeight, quorum, err)
 		}
+		return lastSuccess
 	}
 	if err != nil {
-		return &protocol.RPCResult{Err: err}
+		return &protocol.RPCResult{Err: perrors.WithMessagef(err, "the broadcast of the method %v is acknowledged by the weight %v, "+
+			"less than the quorum %v", invocation.MethodName(), ackWeight, quorum)}
 	}
-	return result
+	return &protocol.RPCResult{Err: perrors.Errorf("the broadcast of the method %v is acknowledged by the weight %v, "+
+		"less than the quorum %v", invocation.MethodName(), ackWeight, quorum)}
 }
@@ -20,11 +20,15 @@ package cluster_impl
 import (
 	"context"
 	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
 	"testing"
 )
 
 import (
 	"github.com/golang/mock/gomock"
+	perrors "github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -32,6 +36,7 @@ import (
 	"github.com/apache/dubbo-go/cluster/directory"
 	"github.com/apache/dubbo-go/cluster/loadbalance"
 	"github.com/apache/dubbo-go/common"
+	"github.com/apache/dubbo-go/common/constant"
 	"github.com/apache/dubbo-go/common/extension"
 	"github.com/apache/dubbo-go/protocol"
 	"github.com/apache/dubbo-go/protocol/invocation"
@@ -107,3 +112,95 @@ func Test_BroadcastInvokeFailed(t *testing.T) {
 	result := clusterInvoker.Invoke(&invocation.RPCInvocation{})
 	assert.Equal(t, mockFailedResult.Err, result.Error())
 }
+
+// broadcastInvoke broadcasts to the @total providers, the first @failures ones fail
+func broadcastInvoke(failPercent string, total int, failures int) (protocol.Result, int32) {
+	urlParams := url.Values{}
+	urlParams.Set(constant.BROADCAST_FAIL_PERCENT_KEY, failPercent)
+	var invoked int32
+	invokers := []protocol.Invoker{}
+	for i := 0; i < total; i++ {
+		url, _ := common.NewURL(context.TODO(), fmt.Sprintf("dubbo://192.168.1.%v:20000/com.ikurento.user.UserProvider", i), common.WithParams(urlParams))
+		ivk := &delayedInvoker{MockInvoker: NewMockInvoker(url, 1), invoked: &invoked}
+		if i < failures {
+			ivk.err = perrors.Errorf("error %v", i)
+		}
+		invokers = append(invokers, ivk)
+	}
+	result := NewBroadcastCluster().Join(directory.NewStaticDirectory(invokers)).Invoke(&invocation.RPCInvocation{})
+	return result, invoked
+}
+
+func Test_BroadcastFailPercent(t *testing.T) {
+	// all success
+	result, invoked := broadcastInvoke("0", 10, 0)
+	assert.NoError(t, result.Error())
+	assert.Equal(t, int32(10), invoked)
+
+	// tolerated below the threshold
+	result, invoked = broadcastInvoke("30", 10, 2)
+	assert.NoError(t, result.Error())
+	assert.Equal(t, "192.168.1.9:20000", result.Result())
+	assert.Equal(t, int32(10), invoked)
+
+	// all the providers are invoked above the threshold, the last failure is returned
+	result, invoked = broadcastInvoke("20", 10, 2)
+	assert.EqualError(t, result.Error(), "error 1")
+	assert.Equal(t, int32(10), invoked)
+
+	// no failure is tolerated by default
+	result, _ = broadcastInvoke("", 10, 1)
+	assert.EqualError(t, result.Error(), "error 0")
+
+	// never tolerated if all fail
+	result, _ = broadcastInvoke("100", 3, 3)
+	assert.EqualError(t, result.Error(), "error 2")
+}
+
+func Test_BroadcastNoProvider(t *testing.T) {
+	registryUrl, _ := common.NewURL(context.TODO(), "registry://127.0.0.1:2181")
+	registryUrl.SubURL = &broadcastUrl
+	clusterInvoker := NewBroadcastCluster().Join(&changingDirectory{url: registryUrl})
+
+	result := clusterInvoker.Invoke(&invocation.RPCInvocation{})
+	assert.Error(t, result.Error())
+	assert.Contains(t, result.Error().Error(), "No provider available")
+}
+
+// quorumBroadcastInvoke broadcasts to the providers of the @weights, the ones of the negative weights fail
+func quorumBroadcastInvoke(quorum string, weights ...int) protocol.Result {
+	invokers := []protocol.Invoker{}
+	var invoked int32
+	for i, weight := range weights {
+		urlParams := url.Values{}
+		urlParams.Set(constant.BROADCAST_QUORUM_WEIGHT_KEY, quorum)
+		urlParams.Set(constant.WEIGHT_KEY, strconv.Itoa(weight))
+		if weight < 0 {
+			urlParams.Set(constant.WEIGHT_KEY, strconv.Itoa(-weight))
+		}
+		url, _ := common.NewURL(context.TODO(), fmt.Sprintf("dubbo://192.168.1.%v:20000/com.ikurento.user.UserProvider", i), common.WithParams(urlParams))
+		ivk := &delayedInvoker{MockInvoker: NewMockInvoker(url, 1), invoked: &invoked}
+		if weight < 0 {
+			ivk.err = perrors.Errorf("error %v", i)
+		}
+		invokers = append(invokers, ivk)
+	}
+	return NewBroadcastCluster().Join(directory.NewStaticDirectory(invokers)).Invoke(&invocation.RPCInvocation{})
+}
+
+func Test_BroadcastQuorum(t *testing.T) {
+	// the low weight provider fails, the quorum is reached
+	result := quorumBroadcastInvoke("300", 100, 100, -10, 100)
+	assert.NoError(t, result.Error())
+	assert.Equal(t, "192.168.1.3:20000", result.Result())
+
+	// the high weight provider fails
+	result = quorumBroadcastInvoke("300", 100, -100, 10, 100)
+	assert.Error(t, result.Error())
+	assert.Equal(t, "error 1", perrors.Cause(result.Error()).Error())
+	assert.Contains(t, result.Error().Error(), "acknowledged by the weight 210, less than the quorum 300")
+
+	// all succeed but the weights don't reach the quorum
+	result = quorumBroadcastInvoke("300", 100, 100)
+	assert.EqualError(t, result.Error(), "the broadcast of the method  is acknowledged by the weight 200, less than the quorum 300")
+}
@@ -0,0 +1,121 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package cluster_impl
+
+import (
+	"strings"
+	"sync"
+)
+
+import (
+	"github.com/apache/dubbo-go/cluster"
+	"github.com/apache/dubbo-go/common"
+	"github.com/apache/dubbo-go/common/constant"
+	"github.com/apache/dubbo-go/common/extension"
+	"github.com/apache/dubbo-go/protocol"
+)
+
+/**
+ * chainClusterInvoker invokes the calls of a method by its chain of clusters, methods.X.cluster or cluster,
+ * like failover,failback: the call is handled by the first cluster, and by the next one if it fails with a
+ * retryable error. The failback cluster next in the chain retries the failed call in background rather than
+ * invoking it again. The clusters join the same directory once, when the first call of them is invoked.
+ */
+type chainClusterInvoker struct {
+	directory cluster.Directory
+	lock      sync.Mutex
+	invokers  map[string]protocol.Invoker // cluster -> the invoker joining the directory
+}
+
+func newChainClusterInvoker(directory cluster.Directory) protocol.Invoker {
+	return &chainClusterInvoker{
+		directory: directory,
+		invokers:  make(map[string]protocol.Invoker),
+	}
+}
+
+func (invoker *chainClusterInvoker) GetUrl() common.URL {
+	return invoker.directory.GetUrl()
+}
+
+func (invoker *chainClusterInvoker) IsAvailable() bool {
+	return invoker.directory.IsAvailable()
+}
+
+func (invoker *chainClusterInvoker) Destroy() {
+	invoker.lock.Lock()
+	defer invoker.lock.Unlock()
+	for _, clusterInvoker := range invoker.invokers {
+		clusterInvoker.Destroy()
+	}
+	invoker.directory.Destroy()
+}
+
+func (invoker *chainClusterInvoker) Invoke(invocation protocol.Invocation) protocol.Result {
+	url := invoker.consumerUrl()
+	var result protocol.Result
+	for i, name := range invoker.chain(invocation.MethodName()) {
+		clusterInvoker := invoker.clusterInvoker(name)
+		if failback, ok := clusterInvoker.(*failbackClusterInvoker); ok && i > 0 {
+			result = failback.failbackFailed(invocation, result)
+		} else {
+			result = clusterInvoker.Invoke(invocation)
+		}
+		if result.Error() == nil || !isRetryable(url, invocation.MethodName(), result.Error()) {
+			return result
+		}
+	}
+	return result
+}
+
+// consumerUrl returns the consumer url rather than the registry one of the directory
+func (invoker *chainClusterInvoker) consumerUrl() common.URL {
+	url := invoker.directory.GetUrl()
+	if url.SubURL != nil {
+		url = *url.SubURL
+	}
+	return url
+}
+
+// chain returns the clusters of the method by the consumer url
+func (invoker *chainClusterInvoker) chain(methodName string) []string {
+	url := invoker.consumerUrl()
+	serviceCluster := url.GetParam(constant.CLUSTER_KEY, constant.DEFAULT_CLUSTER)
+	chain := make([]string, 0, 2)
+	for _, name := range strings.Split(url.GetMethodParam(methodName, constant.CLUSTER_KEY, serviceCluster), ",") {
+		// the clusters can't be chained recursively
+		if name = strings.TrimSpace(name); name != "" && name != constant.CHAIN_CLUSTER {
+			chain = append(chain, name)
+		}
+	}
+	if len(chain) == 0 {
+		chain = append(chain, constant.DEFAULT_CLUSTER)
+	}
+	return chain
+}
+
+// clusterInvoker returns the invoker of the cluster @name joining the directory, it's joined once
+func (invoker *chainClusterInvoker) clusterInvoker(name string) protocol.Invoker {
+	invoker.lock.Lock()
+	defer invoker.lock.Unlock()
+	clusterInvoker, ok := invoker.invokers[name]
+	if !ok {
+		clusterInvoker = extension.GetCluster(name).Join(invoker.directory)
+		invoker.invokers[name] = clusterInvoker
+	}
+	return clusterInvoker
+}
@@ -0,0 +1,117 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package cluster_impl
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+)
+
+import (
+	perrors "github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+import (
+	"github.com/apache/dubbo-go/cluster"
+	"github.com/apache/dubbo-go/cluster/directory"
+	"github.com/apache/dubbo-go/cluster/loadbalance"
+	"github.com/apache/dubbo-go/common"
+	"github.com/apache/dubbo-go/common/constant"
+	"github.com/apache/dubbo-go/common/extension"
+	"github.com/apache/dubbo-go/protocol"
+	"github.com/apache/dubbo-go/protocol/invocation"
+)
+
+func newTestChainClusterInvoker(t *testing.T, params string, invoked *int32) *chainClusterInvoker {
+	extension.SetLoadbalance("random", loadbalance.NewRandomLoadBalance)
+	url, err := common.NewURL(context.TODO(), "dubbo://192.168.1.1:20000/com.ikurento.user.UserProvider?"+params)
+	assert.NoError(t, err)
+	ivk := &delayedInvoker{MockInvoker: NewMockInvoker(url, 1), err: perrors.New("error"), invoked: invoked}
+	return NewChainCluster().Join(directory.NewStaticDirectory([]protocol.Invoker{ivk})).(*chainClusterInvoker)
+}
+
+func TestChainCluster_FailoverFailback(t *testing.T) {
+	var invoked int32
+	clusterInvoker := newTestChainClusterInvoker(t, "cluster=failover,failback&retries=2&failback.retry.period=60000", &invoked)
+	defer clusterInvoker.Destroy()
+
+	// failback retries the call in background once failover exhausts its retries, without invoking it again
+	result := clusterInvoker.Invoke(invocation.NewRPCInvocationWithOptions(invocation.WithMethodName("GetUser")))
+	assert.NoError(t, result.Error())
+	assert.Equal(t, int32(2), atomic.LoadInt32(&invoked))
+	failback := clusterInvoker.invokers["failback"].(*failbackClusterInvoker)
+	assert.Equal(t, int64(1), failback.taskList.Len())
+}
+
+func TestChainCluster_MethodChain(t *testing.T) {
+	var invoked int32
+	clusterInvoker := newTestChainClusterInvoker(t, "cluster=failfast&retries=2&failback.retry.period=60000&"+
+		"methods.GetUser.cluster=failover,failback", &invoked)
+	defer clusterInvoker.Destroy()
+
+	// the methods without a chain are invoked by the cluster of the service
+	result := clusterInvoker.Invoke(invocation.NewRPCInvocationWithOptions(invocation.WithMethodName("GetUser0")))
+	assert.EqualError(t, result.Error(), "error")
+	assert.Equal(t, int32(1), atomic.LoadInt32(&invoked))
+
+	result = clusterInvoker.Invoke(invocation.NewRPCInvocationWithOptions(invocation.WithMethodName("GetUser")))
+	assert.NoError(t, result.Error())
+	assert.Equal(t, int32(1+2), atomic.LoadInt32(&invoked))
+	assert.Len(t, clusterInvoker.invokers, 3)
+}
+
+func TestChainCluster_NotRetryable(t *testing.T) {
+	extension.SetLoadbalance("random", loadbalance.NewRandomLoadBalance)
+	var invoked int32
+	url, _ := common.NewURL(context.TODO(), "dubbo://192.168.1.1:20000/com.ikurento.user.UserProvider?"+
+		"cluster=failfast,failback&terminal.codes=404&failback.retry.period=60000")
+	ivk := &delayedInvoker{MockInvoker: NewMockInvoker(url, 1), err: protocol.NewStatusError("404", "not found"), invoked: &invoked}
+	clusterInvoker := NewChainCluster().Join(directory.NewStaticDirectory([]protocol.Invoker{ivk})).(*chainClusterInvoker)
+	defer clusterInvoker.Destroy()
+
+	// the next cluster isn't tried for the error not retryable
+	result := clusterInvoker.Invoke(invocation.NewRPCInvocationWithOptions(invocation.WithMethodName("GetUser")))
+	assert.Error(t, result.Error())
+	assert.Equal(t, int32(1), atomic.LoadInt32(&invoked))
+	assert.Len(t, clusterInvoker.invokers, 1)
+}
+
+func TestChainCluster_Success(t *testing.T) {
+	extension.SetLoadbalance("random", loadbalance.NewRandomLoadBalance)
+	url, _ := common.NewURL(context.TODO(), "dubbo://192.168.1.1:20000/com.ikurento.user.UserProvider?cluster=failover,failback")
+	clusterInvoker := NewChainCluster().Join(directory.NewStaticDirectory([]protocol.Invoker{NewMockInvoker(url, 1)})).(*chainClusterInvoker)
+	defer clusterInvoker.Destroy()
+
+	// the next cluster isn't joined unless the call fails
+	result := clusterInvoker.Invoke(invocation.NewRPCInvocationWithOptions(invocation.WithMethodName("GetUser")))
+	assert.NoError(t, result.Error())
+	assert.Len(t, clusterInvoker.invokers, 1)
+}
+
+func TestGetClusterName(t *testing.T) {
+	url, _ := common.NewURL(context.TODO(), "dubbo://192.168.1.1:20000/com.ikurento.user.UserProvider")
+	assert.Equal(t, constant.DEFAULT_CLUSTER, cluster.GetClusterName(url))
+	url.Params.Set(constant.CLUSTER_KEY, "failfast")
+	assert.Equal(t, "failfast", cluster.GetClusterName(url))
+	url.Params.Set("methods.GetUser."+constant.CLUSTER_KEY, "failover")
+	assert.Equal(t, constant.CHAIN_CLUSTER, cluster.GetClusterName(url))
+	url.Params.Del("methods.GetUser." + constant.CLUSTER_KEY)
+	url.Params.Set(constant.CLUSTER_KEY, "failover,failback")
+	assert.Equal(t, constant.CHAIN_CLUSTER, cluster.GetClusterName(url))
+}
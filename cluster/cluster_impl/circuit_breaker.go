@@ -0,0 +1,194 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cluster_impl
+
+import (
+	"sync"
+	"time"
+)
+
+import (
+	"github.com/apache/dubbo-go/common"
+	"github.com/apache/dubbo-go/common/constant"
+	"github.com/apache/dubbo-go/common/logger"
+	"github.com/apache/dubbo-go/protocol"
+)
+
+const (
+	circuitClosed = iota
+	circuitOpen
+	circuitHalfOpen
+)
+
+/**
+ * circuitBreaker opens the circuit of a provider after circuit.failure.threshold consecutive failures, the
+ * provider is excluded from the selection for circuit.open.interval. Then the circuit is half-open, and the
+ * provider is selected by one trial invocation at a time. A failed trial opens the circuit again. A successful
+ * trial closes the circuit at once if circuit.halfopen.restore is true, so the capacity of the provider is
+ * recovered quickly, otherwise the circuit is closed after the next interval without a failed trial.
+ */
+type circuitBreaker struct {
+	lock     sync.Mutex
+	circuits map[string]*circuit // provider url key -> circuit
+}
+
+type circuit struct {
+	state     int
+	failures  int
+	openUntil time.Time
+	// a trial invocation is in flight when the circuit is half-open
+	probing bool
+	// the half-open circuit is closed at the time if it isn't restored by the successful trial
+	closeAt time.Time
+}
+
+type circuitConfig struct {
+	threshold int
+	interval  time.Duration
+	restore   bool
+}
+
+func newCircuitBreaker() *circuitBreaker {
+	return &circuitBreaker{circuits: make(map[string]*circuit)}
+}
+
+func getCircuitConfig(url common.URL) circuitConfig {
+	return circuitConfig{
+		threshold: int(url.GetParamInt(constant.CIRCUIT_FAILURE_THRESHOLD_KEY, 0)),
+		interval:  time.Duration(url.GetParamInt(constant.CIRCUIT_OPEN_INTERVAL_KEY, constant.DEFAULT_CIRCUIT_OPEN_INTERVAL)) * time.Millisecond,
+		restore:   url.GetParamBool(constant.CIRCUIT_HALFOPEN_RESTORE_KEY, true),
+	}
+}
+
+// selectable filters out the providers whose circuit is open, or half-open with a trial in flight,
+// all the invokers are returned if none of them is selectable
+func (b *circuitBreaker) selectable(url common.URL, invokers []protocol.Invoker) []protocol.Invoker {
+	if getCircuitConfig(url).threshold <= 0 {
+		return invokers
+	}
+	now := time.Now()
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if len(b.circuits) == 0 {
+		return invokers
+	}
+	selectable := make([]protocol.Invoker, 0, len(invokers))
+	for _, ivk := range invokers {
+		if c, ok := b.circuits[ivk.GetUrl().Key()]; ok {
+			c.refresh(now)
+			if c.state == circuitOpen || (c.state == circuitHalfOpen && c.probing) {
+				continue
+			}
+		}
+		selectable = append(selectable, ivk)
+	}
+	if len(selectable) == 0 {
+		return invokers
+	}
+	return selectable
+}
+
+// begin marks the trial in flight if the circuit of the provider is half-open
+func (b *circuitBreaker) begin(url common.URL, ivk protocol.Invoker) {
+	if getCircuitConfig(url).threshold <= 0 {
+		return
+	}
+	now := time.Now()
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if c, ok := b.circuits[ivk.GetUrl().Key()]; ok {
+		c.refresh(now)
+		if c.state == circuitHalfOpen {
+			c.probing = true
+		}
+	}
+}
+
+// record the result of an invocation on the provider, the circuit breaker is configured by the cluster @url
+func (b *circuitBreaker) record(url common.URL, ivk protocol.Invoker, err error) {
+	conf := getCircuitConfig(url)
+	if conf.threshold <= 0 {
+		return
+	}
+
+	key := ivk.GetUrl().Key()
+	now := time.Now()
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	c, ok := b.circuits[key]
+	if !ok {
+		if err == nil {
+			return
+		}
+		c = &circuit{}
+		b.circuits[key] = c
+	}
+	c.refresh(now)
+
+	switch c.state {
+	case circuitOpen:
+		// the late result of the invocation before the circuit is opened
+	case circuitHalfOpen:
+		c.probing = false
+		if err != nil {
+			logger.Warnf("the trial invocation on the provider %v failed, its circuit is opened for %v: %v", key, conf.interval, err)
+			c.open(now, conf.interval)
+			return
+		}
+		if conf.restore {
+			logger.Infof("the trial invocation on the provider %v succeeded, its circuit is closed", key)
+			delete(b.circuits, key)
+			return
+		}
+		if c.closeAt.IsZero() {
+			c.closeAt = now.Add(conf.interval)
+		}
+	default:
+		if err == nil {
+			delete(b.circuits, key)
+			return
+		}
+		c.failures++
+		if c.failures >= conf.threshold {
+			logger.Warnf("the provider %v failed %v times in a row, its circuit is opened for %v: %v", key, c.failures, conf.interval, err)
+			c.open(now, conf.interval)
+		}
+	}
+}
+
+func (c *circuit) open(now time.Time, interval time.Duration) {
+	c.state = circuitOpen
+	c.failures = 0
+	c.openUntil = now.Add(interval)
+	c.closeAt = time.Time{}
+}
+
+// refresh turns the open circuit to half-open after the open interval, and closes the half-open circuit at closeAt
+func (c *circuit) refresh(now time.Time) {
+	if c.state == circuitOpen && !now.Before(c.openUntil) {
+		c.state = circuitHalfOpen
+		c.probing = false
+	}
+	if c.state == circuitHalfOpen && !c.closeAt.IsZero() && !now.Before(c.closeAt) {
+		c.state = circuitClosed
+		c.closeAt = time.Time{}
+	}
+}
@@ -0,0 +1,161 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cluster_impl
+
+import (
+	"errors"
+	"net/url"
+	"testing"
+	"time"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+import (
+	"github.com/apache/dubbo-go/cluster/directory"
+	"github.com/apache/dubbo-go/cluster/loadbalance"
+	"github.com/apache/dubbo-go/common/constant"
+	"github.com/apache/dubbo-go/common/extension"
+	"github.com/apache/dubbo-go/protocol/invocation"
+)
+
+var errCircuitTest = errors.New("invoke failed")
+
+func circuitParams(restore string) url.Values {
+	urlParams := url.Values{}
+	urlParams.Set(constant.CIRCUIT_FAILURE_THRESHOLD_KEY, "2")
+	urlParams.Set(constant.CIRCUIT_OPEN_INTERVAL_KEY, "100")
+	urlParams.Set(constant.CIRCUIT_HALFOPEN_RESTORE_KEY, restore)
+	return urlParams
+}
+
+func Test_CircuitBreakerHalfOpenRestore(t *testing.T) {
+	invokers := outlierInvokers(circuitParams("true"))
+	clusterUrl := invokers[0].GetUrl()
+	breaker := newCircuitBreaker()
+
+	breaker.record(clusterUrl, invokers[2], errCircuitTest)
+	assert.Len(t, breaker.selectable(clusterUrl, invokers), 3)
+	breaker.record(clusterUrl, invokers[2], errCircuitTest)
+	assert.Len(t, breaker.selectable(clusterUrl, invokers), 2)
+
+	// half-open, the provider is selected by one trial at a time
+	time.Sleep(150 * time.Millisecond)
+	assert.Len(t, breaker.selectable(clusterUrl, invokers), 3)
+	breaker.begin(clusterUrl, invokers[2])
+	assert.Len(t, breaker.selectable(clusterUrl, invokers), 2)
+
+	// restored to the normal selection right after the successful trial
+	breaker.record(clusterUrl, invokers[2], nil)
+	assert.Len(t, breaker.selectable(clusterUrl, invokers), 3)
+	breaker.begin(clusterUrl, invokers[2])
+	assert.Len(t, breaker.selectable(clusterUrl, invokers), 3)
+}
+
+func Test_CircuitBreakerHalfOpenWithoutRestore(t *testing.T) {
+	invokers := outlierInvokers(circuitParams("false"))
+	clusterUrl := invokers[0].GetUrl()
+	breaker := newCircuitBreaker()
+
+	breaker.record(clusterUrl, invokers[2], errCircuitTest)
+	breaker.record(clusterUrl, invokers[2], errCircuitTest)
+	time.Sleep(150 * time.Millisecond)
+	breaker.begin(clusterUrl, invokers[2])
+	breaker.record(clusterUrl, invokers[2], nil)
+
+	// still selected by one trial at a time until the next interval passes
+	breaker.begin(clusterUrl, invokers[2])
+	assert.Len(t, breaker.selectable(clusterUrl, invokers), 2)
+	breaker.record(clusterUrl, invokers[2], nil)
+
+	time.Sleep(150 * time.Millisecond)
+	breaker.begin(clusterUrl, invokers[2])
+	assert.Len(t, breaker.selectable(clusterUrl, invokers), 3)
+}
+
+func Test_CircuitBreakerTrialFailed(t *testing.T) {
+	invokers := outlierInvokers(circuitParams("true"))
+	clusterUrl := invokers[0].GetUrl()
+	breaker := newCircuitBreaker()
+
+	breaker.record(clusterUrl, invokers[2], errCircuitTest)
+	breaker.record(clusterUrl, invokers[2], errCircuitTest)
+	time.Sleep(150 * time.Millisecond)
+	breaker.begin(clusterUrl, invokers[2])
+	breaker.record(clusterUrl, invokers[2], errCircuitTest)
+	assert.Len(t, breaker.selectable(clusterUrl, invokers), 2)
+
+	// all the invokers are returned if none of them is selectable
+	assert.Len(t, breaker.selectable(clusterUrl, invokers[2:]), 1)
+}
+
+func Test_CircuitBreakerTrialPanicked(t *testing.T) {
+	invokers := healthCheckInvokers("com.foo.CircuitPanicService", circuitParams("true").Encode())
+	base := newBaseClusterInvoker(directory.NewStaticDirectory(invokers))
+	clusterUrl := base.GetUrl()
+	ivc := invocation.NewRPCInvocationWithOptions(invocation.WithMethodName("GetUser"))
+
+	base.circuitBreaker.record(clusterUrl, invokers[0], errCircuitTest)
+	base.circuitBreaker.record(clusterUrl, invokers[0], errCircuitTest)
+	time.Sleep(150 * time.Millisecond)
+
+	// the panicking trial opens the circuit again rather than leaving the trial in flight forever
+	assert.Panics(t, func() { base.doInvoke(invokers[0], ivc) })
+	assert.Len(t, base.circuitBreaker.selectable(clusterUrl, invokers), 1)
+	time.Sleep(150 * time.Millisecond)
+	assert.Len(t, base.circuitBreaker.selectable(clusterUrl, invokers), 2)
+}
+
+func Test_CircuitBreakerDisabled(t *testing.T) {
+	invokers := outlierInvokers(url.Values{})
+	clusterUrl := invokers[0].GetUrl()
+	breaker := newCircuitBreaker()
+
+	for i := 0; i < 10; i++ {
+		breaker.record(clusterUrl, invokers[2], errCircuitTest)
+	}
+	assert.Len(t, breaker.selectable(clusterUrl, invokers), 3)
+}
+
+func Test_CircuitBreakerBypass(t *testing.T) {
+	extension.SetLoadbalance("random", loadbalance.NewRandomLoadBalance)
+	invokers := outlierInvokers(circuitParams("true"))
+	clusterInvoker := NewFailoverCluster().Join(directory.NewStaticDirectory(invokers)).(*failoverClusterInvoker)
+	clusterUrl := invokers[0].GetUrl()
+	clusterInvoker.circuitBreaker.record(clusterUrl, invokers[2], errCircuitTest)
+	clusterInvoker.circuitBreaker.record(clusterUrl, invokers[2], errCircuitTest)
+
+	lb := loadbalance.NewRandomLoadBalance()
+	normal := invocation.NewRPCInvocationWithOptions(invocation.WithMethodName("GetUser"))
+	bypass := invocation.NewRPCInvocationWithOptions(invocation.WithMethodName("HealthCheck"),
+		invocation.WithAttachments(map[string]string{constant.CIRCUIT_BYPASS_KEY: "true"}))
+	reached := false
+	for i := 0; i < 100; i++ {
+		assert.NotEqual(t, invokers[2], clusterInvoker.doSelect(lb, normal, invokers, nil))
+		if clusterInvoker.doSelect(lb, bypass, invokers, nil) == invokers[2] {
+			reached = true
+		}
+	}
+	assert.True(t, reached)
+
+	// the bypassed invocation doesn't close the open circuit
+	assert.NoError(t, clusterInvoker.doInvoke(invokers[2], bypass).Error())
+	assert.Len(t, clusterInvoker.circuitBreaker.selectable(clusterUrl, invokers), 2)
+}
@@ -0,0 +1,148 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cluster_impl
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+)
+
+import (
+	perrors "github.com/pkg/errors"
+)
+
+import (
+	"github.com/apache/dubbo-go/common"
+	"github.com/apache/dubbo-go/common/constant"
+	"github.com/apache/dubbo-go/protocol"
+)
+
+/**
+ * coalescingInvoker wraps the cluster invoker of a reference, the concurrent identical invocations
+ * (same method and arguments) of the methods configured coalesce=true share one in-flight request
+ * and its result. Only the idempotent methods should be coalesced.
+ * The methods configured dedup.window keep the result of the completed call for the window, so the
+ * duplicate invocations, e.g. from the retry loops of the clients, don't reach the providers either.
+ */
+type coalescingInvoker struct {
+	protocol.Invoker
+	url   common.URL
+	lock  sync.Mutex
+	calls map[string]*coalescedCall
+}
+
+type coalescedCall struct {
+	done   sync.WaitGroup
+	result protocol.Result
+	reply  interface{}
+	// the time the result expires, zero while the call is in flight
+	expiry time.Time
+}
+
+// NewCoalescingInvoker wraps @invoker, @url is the reference url configuring the coalesce of the methods
+func NewCoalescingInvoker(invoker protocol.Invoker, url common.URL) protocol.Invoker {
+	return &coalescingInvoker{
+		Invoker: invoker,
+		url:     url,
+		calls:   make(map[string]*coalescedCall),
+	}
+}
+
+func (invoker *coalescingInvoker) Invoke(invocation protocol.Invocation) protocol.Result {
+	key, window, ok := invoker.coalesceKey(invocation)
+	if !ok {
+		return invoker.Invoker.Invoke(invocation)
+	}
+
+	invoker.lock.Lock()
+	if call, ok := invoker.calls[key]; ok && (call.expiry.IsZero() || time.Now().Before(call.expiry)) {
+		invoker.lock.Unlock()
+		call.done.Wait()
+		return call.share(invocation)
+	}
+	call := &coalescedCall{}
+	call.done.Add(1)
+	invoker.calls[key] = call
+	invoker.lock.Unlock()
+
+	// the waiters are released even if the invocation panics, the panicked call isn't kept
+	defer func() {
+		panicked := call.result == nil
+		if panicked {
+			call.result = &protocol.RPCResult{Err: perrors.Errorf("the coalesced invocation of the method %v panicked",
+				invocation.MethodName())}
+		}
+		invoker.lock.Lock()
+		if window > 0 && !panicked {
+			call.expiry = time.Now().Add(window)
+			time.AfterFunc(window, func() {
+				invoker.lock.Lock()
+				if invoker.calls[key] == call {
+					delete(invoker.calls, key)
+				}
+				invoker.lock.Unlock()
+			})
+		} else {
+			delete(invoker.calls, key)
+		}
+		invoker.lock.Unlock()
+		call.done.Done()
+	}()
+	call.result = invoker.Invoker.Invoke(invocation)
+	call.reply = invocation.Reply()
+	return call.result
+}
+
+// coalesceKey returns false if the invocation should not be coalesced, the window is the time to keep the result
+// of the completed call
+func (invoker *coalescingInvoker) coalesceKey(invocation protocol.Invocation) (string, time.Duration, bool) {
+	methodName := invocation.MethodName()
+	window := time.Duration(invoker.url.GetMethodParamInt64(methodName, constant.DEDUP_WINDOW_KEY, 0)) * time.Millisecond
+	if window <= 0 && !invoker.url.GetParamBool(constant.COALESCE_KEY, false) &&
+		invoker.url.GetMethodParam(methodName, constant.COALESCE_KEY, "") != "true" {
+		return "", 0, false
+	}
+	if invocation.AttachmentsByKey(constant.ASYNC_KEY, "false") == "true" {
+		return "", 0, false
+	}
+
+	args, err := json.Marshal(invocation.Arguments())
+	if err != nil {
+		return "", 0, false
+	}
+	return fmt.Sprintf("%s:%T:%s", methodName, invocation.Reply(), args), window, true
+}
+
+// share copies the result of the in-flight request into the reply of @invocation
+func (call *coalescedCall) share(invocation protocol.Invocation) protocol.Result {
+	reply := invocation.Reply()
+	rest := call.result.Result()
+	if reply != nil && call.reply != nil && reply != call.reply {
+		dst, src := reflect.ValueOf(reply), reflect.ValueOf(call.reply)
+		if dst.Kind() == reflect.Ptr && dst.Type() == src.Type() && !dst.IsNil() && !src.IsNil() {
+			dst.Elem().Set(src.Elem())
+			if rest == call.reply {
+				rest = reply
+			}
+		}
+	}
+	return &protocol.RPCResult{Err: call.result.Error(), Rest: rest, Attrs: call.result.Attachments()}
+}
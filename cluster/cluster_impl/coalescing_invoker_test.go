@@ -0,0 +1,184 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cluster_impl
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/atomic"
+)
+
+import (
+	"github.com/apache/dubbo-go/common"
+	"github.com/apache/dubbo-go/protocol"
+	"github.com/apache/dubbo-go/protocol/invocation"
+)
+
+// slowInvoker replies the first argument slowly
+type slowInvoker struct {
+	*MockInvoker
+	invoked *atomic.Int32
+}
+
+func (si *slowInvoker) Invoke(inv protocol.Invocation) protocol.Result {
+	si.invoked.Inc()
+	time.Sleep(100 * time.Millisecond)
+	*inv.Reply().(*string) = inv.Arguments()[0].(string)
+	return &protocol.RPCResult{Rest: inv.Reply()}
+}
+
+func coalesceInvoke(t *testing.T, urlString string, args ...string) ([]string, int32) {
+	url, err := common.NewURL(context.TODO(), urlString)
+	assert.NoError(t, err)
+	invoked := atomic.NewInt32(0)
+	invoker := NewCoalescingInvoker(&slowInvoker{MockInvoker: NewMockInvoker(url, 1), invoked: invoked}, url)
+
+	replies := make([]string, len(args))
+	var wg sync.WaitGroup
+	for i, arg := range args {
+		wg.Add(1)
+		go func(i int, arg string) {
+			defer wg.Done()
+			result := invoker.Invoke(invocation.NewRPCInvocationWithOptions(invocation.WithMethodName("GetUser"),
+				invocation.WithArguments([]interface{}{arg}), invocation.WithReply(&replies[i])))
+			assert.NoError(t, result.Error())
+			assert.Equal(t, &replies[i], result.Result())
+		}(i, arg)
+	}
+	wg.Wait()
+	return replies, invoked.Load()
+}
+
+func Test_CoalescingInvoke(t *testing.T) {
+	args := make([]string, 50)
+	for i := range args {
+		args[i] = "A001"
+	}
+
+	replies, invoked := coalesceInvoke(t, "dubbo://192.168.1.1:20000/com.ikurento.user.UserProvider?coalesce=true", args...)
+	assert.Equal(t, int32(1), invoked)
+	for _, reply := range replies {
+		assert.Equal(t, "A001", reply)
+	}
+
+	// the method level config
+	_, invoked = coalesceInvoke(t, "dubbo://192.168.1.1:20000/com.ikurento.user.UserProvider?methods.GetUser.coalesce=true", args...)
+	assert.Equal(t, int32(1), invoked)
+
+	// not coalesced by default
+	_, invoked = coalesceInvoke(t, "dubbo://192.168.1.1:20000/com.ikurento.user.UserProvider", args...)
+	assert.Equal(t, int32(50), invoked)
+}
+
+func Test_CoalescingInvokeDifferentArgs(t *testing.T) {
+	replies, invoked := coalesceInvoke(t, "dubbo://192.168.1.1:20000/com.ikurento.user.UserProvider?coalesce=true",
+		"A001", "A002", "A001", "A002")
+	assert.Equal(t, int32(2), invoked)
+	assert.Equal(t, []string{"A001", "A002", "A001", "A002"}, replies)
+}
+
+func Test_DedupInvoke(t *testing.T) {
+	url, err := common.NewURL(context.TODO(), "dubbo://192.168.1.1:20000/com.ikurento.user.UserProvider?dedup.window=500")
+	assert.NoError(t, err)
+	invoked := atomic.NewInt32(0)
+	invoker := NewCoalescingInvoker(&slowInvoker{MockInvoker: NewMockInvoker(url, 1), invoked: invoked}, url)
+	invoke := func(arg string) string {
+		var reply string
+		result := invoker.Invoke(invocation.NewRPCInvocationWithOptions(invocation.WithMethodName("GetUser"),
+			invocation.WithArguments([]interface{}{arg}), invocation.WithReply(&reply)))
+		assert.NoError(t, result.Error())
+		assert.Equal(t, &reply, result.Result())
+		return reply
+	}
+
+	// the rapid duplicate calls after the first one completes get its result
+	for i := 0; i < 10; i++ {
+		assert.Equal(t, "A001", invoke("A001"))
+	}
+	assert.Equal(t, int32(1), invoked.Load())
+	assert.Equal(t, "A002", invoke("A002"))
+	assert.Equal(t, int32(2), invoked.Load())
+
+	// the result expires after the window
+	time.Sleep(500 * time.Millisecond)
+	assert.Equal(t, "A001", invoke("A001"))
+	assert.Equal(t, int32(3), invoked.Load())
+}
+
+func Test_DedupInvokeConcurrent(t *testing.T) {
+	args := make([]string, 50)
+	for i := range args {
+		args[i] = "A001"
+	}
+
+	replies, invoked := coalesceInvoke(t, "dubbo://192.168.1.1:20000/com.ikurento.user.UserProvider?methods.GetUser.dedup.window=1000", args...)
+	assert.Equal(t, int32(1), invoked)
+	for _, reply := range replies {
+		assert.Equal(t, "A001", reply)
+	}
+
+	// the other methods aren't deduplicated
+	_, invoked = coalesceInvoke(t, "dubbo://192.168.1.1:20000/com.ikurento.user.UserProvider?methods.GetUser0.dedup.window=1000", args...)
+	assert.Equal(t, int32(50), invoked)
+}
+
+type panicInvoker struct {
+	*MockInvoker
+	started chan struct{}
+}
+
+func (pi *panicInvoker) Invoke(inv protocol.Invocation) protocol.Result {
+	close(pi.started)
+	time.Sleep(50 * time.Millisecond)
+	panic("provider panicked")
+}
+
+func Test_CoalescingInvokePanic(t *testing.T) {
+	url, err := common.NewURL(context.TODO(), "dubbo://192.168.1.1:20000/com.ikurento.user.UserProvider?methods.GetUser.dedup.window=1000")
+	assert.NoError(t, err)
+	started := make(chan struct{})
+	invoker := NewCoalescingInvoker(&panicInvoker{MockInvoker: NewMockInvoker(url, 1), started: started}, url)
+	newInvocation := func() protocol.Invocation {
+		var reply string
+		return invocation.NewRPCInvocationWithOptions(invocation.WithMethodName("GetUser"),
+			invocation.WithArguments([]interface{}{"A001"}), invocation.WithReply(&reply))
+	}
+
+	go func() {
+		defer func() {
+			assert.NotNil(t, recover())
+		}()
+		invoker.Invoke(newInvocation())
+	}()
+
+	// the waiter is released with an error rather than blocked forever
+	<-started
+	result := invoker.Invoke(newInvocation())
+	assert.Error(t, result.Error())
+
+	// and the panicked call isn't kept for the dedup window
+	invoker.(*coalescingInvoker).lock.Lock()
+	assert.Empty(t, invoker.(*coalescingInvoker).calls)
+	invoker.(*coalescingInvoker).lock.Unlock()
+}
@@ -0,0 +1,116 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster_impl
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+import (
+	"github.com/apache/dubbo-go/cluster"
+	"github.com/apache/dubbo-go/cluster/directory"
+	"github.com/apache/dubbo-go/cluster/loadbalance"
+	"github.com/apache/dubbo-go/common"
+	"github.com/apache/dubbo-go/common/constant"
+	"github.com/apache/dubbo-go/common/extension"
+	"github.com/apache/dubbo-go/protocol"
+	"github.com/apache/dubbo-go/protocol/invocation"
+)
+
+func drainingCluster(delay time.Duration, invoked *int32) protocol.Invoker {
+	extension.SetLoadbalance("random", loadbalance.NewRandomLoadBalance)
+	invokers := []protocol.Invoker{}
+	for i := 0; i < 3; i++ {
+		url, _ := common.NewURL(context.TODO(), fmt.Sprintf("dubbo://192.168.1.%v:20000/com.ikurento.user.UserProvider", i))
+		invokers = append(invokers, &delayedInvoker{MockInvoker: NewMockInvoker(url, 1), delay: delay, invoked: invoked})
+	}
+	return NewFailFastCluster().Join(directory.NewStaticDirectory(invokers))
+}
+
+func Test_DrainingExcluded(t *testing.T) {
+	var invoked int32
+	clusterInvoker := drainingCluster(0, &invoked)
+
+	cluster.DrainProvider("192.168.1.1:20000")
+	defer cluster.ResumeProvider("192.168.1.1:20000")
+	assert.Equal(t, []string{"192.168.1.1:20000"}, cluster.DrainingProviders())
+
+	selected := make(map[interface{}]int)
+	for i := 0; i < 50; i++ {
+		result := clusterInvoker.Invoke(&invocation.RPCInvocation{})
+		assert.NoError(t, result.Error())
+		selected[result.Result()]++
+	}
+	assert.Equal(t, 0, selected["192.168.1.1:20000"])
+	assert.Equal(t, 50, selected["192.168.1.0:20000"]+selected["192.168.1.2:20000"])
+
+	// the forced provider is draining too
+	inv := invocation.NewRPCInvocation("", nil, map[string]string{constant.FORCE_ADDRESS_KEY: "192.168.1.1:20000"})
+	assert.NotEqual(t, "192.168.1.1:20000", clusterInvoker.Invoke(inv).Result())
+
+	cluster.ResumeProvider("192.168.1.1:20000")
+	assert.False(t, cluster.HasDrainingProviders())
+	assert.Equal(t, "192.168.1.1:20000", clusterInvoker.Invoke(inv).Result())
+}
+
+func Test_DrainingInFlight(t *testing.T) {
+	var invoked int32
+	clusterInvoker := drainingCluster(200*time.Millisecond, &invoked)
+	defer cluster.ResumeProvider("192.168.1.0:20000")
+
+	done := make(chan protocol.Result)
+	go func() {
+		inv := invocation.NewRPCInvocation("", nil, map[string]string{constant.FORCE_ADDRESS_KEY: "192.168.1.0:20000"})
+		done <- clusterInvoker.Invoke(inv)
+	}()
+	for atomic.LoadInt32(&invoked) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	cluster.DrainProvider("192.168.1.0:20000")
+
+	for i := 0; i < 5; i++ {
+		result := clusterInvoker.Invoke(&invocation.RPCInvocation{})
+		assert.NoError(t, result.Error())
+		assert.NotEqual(t, "192.168.1.0:20000", result.Result())
+	}
+	// the invocation in progress completes on the draining provider
+	result := <-done
+	assert.NoError(t, result.Error())
+	assert.Equal(t, "192.168.1.0:20000", result.Result())
+}
+
+func Test_DrainingAll(t *testing.T) {
+	var invoked int32
+	clusterInvoker := drainingCluster(0, &invoked)
+	for i := 0; i < 3; i++ {
+		cluster.DrainProvider(fmt.Sprintf("192.168.1.%v:20000", i))
+		defer cluster.ResumeProvider(fmt.Sprintf("192.168.1.%v:20000", i))
+	}
+
+	result := clusterInvoker.Invoke(&invocation.RPCInvocation{})
+	assert.NoError(t, result.Error())
+	assert.Equal(t, int32(1), atomic.LoadInt32(&invoked))
+}
@@ -0,0 +1,74 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cluster_impl
+
+import (
+	"sync"
+)
+
+import (
+	"github.com/apache/dubbo-go/common"
+	"github.com/apache/dubbo-go/common/constant"
+	"github.com/apache/dubbo-go/common/logger"
+)
+
+/**
+ * errorLogSampler bounds the logs of the failures in all the cluster invokers during an outage. The failures are
+ * counted by service and method, the first error.log.first ones are logged, and then one in every error.log.sample.
+ */
+type errorLogSampler struct {
+	lock   sync.Mutex
+	counts map[string]int64 // service.method -> errors
+}
+
+func newErrorLogSampler() *errorLogSampler {
+	return &errorLogSampler{counts: make(map[string]int64)}
+}
+
+// errorf logs the error of the method in the service of the cluster @url if it's sampled
+func (s *errorLogSampler) errorf(url common.URL, methodName string, format string, args ...interface{}) {
+	if s.sampled(url, methodName) {
+		logger.Errorf(format, args...)
+	}
+}
+
+// warnf logs the failure of the method in the service of the cluster @url at the warn level if it's sampled
+func (s *errorLogSampler) warnf(url common.URL, methodName string, format string, args ...interface{}) {
+	if s.sampled(url, methodName) {
+		logger.Warnf(format, args...)
+	}
+}
+
+func (s *errorLogSampler) sampled(url common.URL, methodName string) bool {
+	first := url.GetParamInt(constant.ERROR_LOG_FIRST_KEY, 0)
+	sample := url.GetParamInt(constant.ERROR_LOG_SAMPLE_KEY, 0)
+	if first <= 0 && sample <= 0 {
+		return true
+	}
+
+	key := url.Service() + "." + methodName
+	s.lock.Lock()
+	s.counts[key]++
+	n := s.counts[key]
+	s.lock.Unlock()
+
+	if n <= first {
+		return true
+	}
+	return sample > 0 && (n-first)%sample == 0
+}
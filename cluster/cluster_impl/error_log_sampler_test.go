@@ -0,0 +1,116 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cluster_impl
+
+import (
+	"context"
+	"net/url"
+	"sync"
+	"testing"
+)
+
+import (
+	perrors "github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+import (
+	"github.com/apache/dubbo-go/cluster/directory"
+	"github.com/apache/dubbo-go/cluster/loadbalance"
+	"github.com/apache/dubbo-go/common"
+	"github.com/apache/dubbo-go/common/constant"
+	"github.com/apache/dubbo-go/common/extension"
+	"github.com/apache/dubbo-go/common/logger"
+	"github.com/apache/dubbo-go/protocol"
+	"github.com/apache/dubbo-go/protocol/invocation"
+)
+
+// errorCountLogger counts the error and warn logs
+type errorCountLogger struct {
+	logger.Logger
+	lock   sync.Mutex
+	errors int
+	warns  int
+}
+
+func (l *errorCountLogger) Warnf(format string, args ...interface{}) {
+	l.lock.Lock()
+	l.warns++
+	l.lock.Unlock()
+}
+
+func (l *errorCountLogger) Errorf(format string, args ...interface{}) {
+	l.lock.Lock()
+	l.errors++
+	l.lock.Unlock()
+}
+
+func failsafeErrorLogs(t *testing.T, urlParams url.Values, methods ...string) int {
+	extension.SetLoadbalance("random", loadbalance.NewRandomLoadBalance)
+	log := &errorCountLogger{Logger: logger.GetLogger()}
+	logger.SetLogger(log)
+	defer logger.SetLogger(log.Logger)
+
+	providerUrl, _ := common.NewURL(context.TODO(), "dubbo://192.168.1.1:20000/com.ikurento.user.UserProvider",
+		common.WithParams(urlParams))
+	ivk := &errInvoker{MockInvoker: NewMockInvoker(providerUrl, 1), err: perrors.New("connection refused")}
+	clusterInvoker := NewFailsafeCluster().Join(directory.NewStaticDirectory([]protocol.Invoker{ivk}))
+	for _, method := range methods {
+		for i := 0; i < 1000; i++ {
+			result := clusterInvoker.Invoke(invocation.NewRPCInvocationWithOptions(invocation.WithMethodName(method)))
+			assert.NoError(t, result.Error())
+		}
+	}
+	return log.errors
+}
+
+func Test_ErrorLogSampled(t *testing.T) {
+	urlParams := url.Values{}
+	urlParams.Set(constant.ERROR_LOG_FIRST_KEY, "10")
+	urlParams.Set(constant.ERROR_LOG_SAMPLE_KEY, "100")
+	// the first 10, then the 110th, 210th ... 910th error
+	assert.Equal(t, 19, failsafeErrorLogs(t, urlParams, "GetUser"))
+	// sampled per method
+	assert.Equal(t, 38, failsafeErrorLogs(t, urlParams, "GetUser", "GetUsers"))
+
+	urlParams.Del(constant.ERROR_LOG_SAMPLE_KEY)
+	assert.Equal(t, 10, failsafeErrorLogs(t, urlParams, "GetUser"))
+}
+
+func Test_ErrorLogNotSampled(t *testing.T) {
+	assert.Equal(t, 1000, failsafeErrorLogs(t, url.Values{}, "GetUser"))
+}
+
+func Test_ErrorLogSampledBroadcast(t *testing.T) {
+	log := &errorCountLogger{Logger: logger.GetLogger()}
+	logger.SetLogger(log)
+	defer logger.SetLogger(log.Logger)
+
+	urlParams := url.Values{}
+	urlParams.Set(constant.ERROR_LOG_FIRST_KEY, "10")
+	urlParams.Set(constant.ERROR_LOG_SAMPLE_KEY, "100")
+	providerUrl, _ := common.NewURL(context.TODO(), "dubbo://192.168.1.1:20000/com.ikurento.user.UserProvider",
+		common.WithParams(urlParams))
+	ivk := &errInvoker{MockInvoker: NewMockInvoker(providerUrl, 1), err: perrors.New("connection refused")}
+	clusterInvoker := NewBroadcastCluster().Join(directory.NewStaticDirectory([]protocol.Invoker{ivk}))
+	for i := 0; i < 1000; i++ {
+		result := clusterInvoker.Invoke(invocation.NewRPCInvocationWithOptions(invocation.WithMethodName("GetUser")))
+		assert.Error(t, result.Error())
+	}
+	assert.Equal(t, 19, log.warns)
+}
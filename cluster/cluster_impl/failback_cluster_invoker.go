@@ -23,13 +23,13 @@ import (
 )
 
 import (
-	"github.com/Workiva/go-datastructures/queue"
+	perrors "github.com/pkg/errors"
 )
 
 import (
 	"github.com/apache/dubbo-go/cluster"
+	"github.com/apache/dubbo-go/common"
 	"github.com/apache/dubbo-go/common/constant"
-	"github.com/apache/dubbo-go/common/extension"
 	"github.com/apache/dubbo-go/common/logger"
 	"github.com/apache/dubbo-go/protocol"
 )
@@ -44,15 +44,34 @@ type failbackClusterInvoker struct {
 	baseClusterInvoker
 
 	once          sync.Once
-	ticker        *time.Ticker
+	done          chan struct{}
+	stopOnce      sync.Once
 	maxRetries    int64
 	failbackTasks int64
-	taskList      *queue.Queue
+	concurrency   int64
+	taskList      *retryTaskQueue
+	// the due tasks are retried by failback.concurrency workers
+	dueTasks chan *retryTimerTask
+	// guards the putting of the tasks, so the length check and the put are atomic
+	taskLock       sync.Mutex
+	overflowPolicy string
+	retryPeriod    time.Duration
+	maxRetryPeriod time.Duration
+	// the tasks older than it are abandoned, 0 if they aren't
+	maxAge time.Duration
+	// return the origin error to the caller on the first failure rather than an empty result
+	firstCallError bool
+	// defer the retry finding no provider rather than counting it as a failed one
+	deferOnEmpty bool
 }
 
 func newFailbackClusterInvoker(directory cluster.Directory) protocol.Invoker {
 	invoker := &failbackClusterInvoker{
 		baseClusterInvoker: newBaseClusterInvoker(directory),
+		done:               make(chan struct{}),
+		dueTasks:           make(chan *retryTimerTask),
+		// created along with the invoker, so it's disposed by Destroy even if the retries are never started
+		taskList: newRetryTaskQueue(),
 	}
 	retriesConfig := invoker.GetUrl().GetParamInt(constant.RETRIES_KEY, constant.DEFAULT_FAILBACK_TIMES)
 	if retriesConfig <= 0 {
@@ -62,142 +81,408 @@ func newFailbackClusterInvoker(directory cluster.Directory) protocol.Invoker {
 	if failbackTasksConfig <= 0 {
 		failbackTasksConfig = constant.DEFAULT_FAILBACK_TASKS
 	}
+	concurrencyConfig := invoker.GetUrl().GetParamInt(constant.FAIL_BACK_CONCURRENCY_KEY, constant.DEFAULT_FAILBACK_CONCURRENCY)
+	if concurrencyConfig <= 0 {
+		concurrencyConfig = constant.DEFAULT_FAILBACK_CONCURRENCY
+	}
 	invoker.maxRetries = retriesConfig
 	invoker.failbackTasks = failbackTasksConfig
+	invoker.concurrency = concurrencyConfig
+	invoker.firstCallError = invoker.GetUrl().GetParamBool(constant.FAIL_BACK_FIRST_CALL_ERROR_KEY, false)
+	invoker.deferOnEmpty = invoker.GetUrl().GetParamBool(constant.FAIL_BACK_DEFER_ON_EMPTY_KEY, true)
+	invoker.overflowPolicy = invoker.GetUrl().GetParam(constant.FAIL_BACK_OVERFLOW_POLICY_KEY, constant.FAIL_BACK_OVERFLOW_DISCARD)
+	retryPeriodConfig := invoker.GetUrl().GetParamInt(constant.FAIL_BACK_RETRY_PERIOD_KEY, constant.DEFAULT_FAILBACK_RETRY_PERIOD)
+	if retryPeriodConfig <= 0 {
+		retryPeriodConfig = constant.DEFAULT_FAILBACK_RETRY_PERIOD
+	}
+	invoker.retryPeriod = time.Duration(retryPeriodConfig) * time.Millisecond
+	invoker.maxRetryPeriod = time.Duration(invoker.GetUrl().GetParamInt(constant.FAIL_BACK_RETRY_MAX_PERIOD_KEY, 0)) * time.Millisecond
+	invoker.maxAge = time.Duration(invoker.GetUrl().GetParamInt(constant.FAIL_BACK_MAX_AGE_KEY, 0)) * time.Millisecond
 	return invoker
 }
 
+// retryInterval is the interval before the retry of a task failed @retries times
+func (invoker *failbackClusterInvoker) retryInterval(retries int64) time.Duration {
+	interval := invoker.retryPeriod
+	for i := int64(0); i < retries && interval < invoker.maxRetryPeriod; i++ {
+		interval *= 2
+	}
+	if invoker.maxRetryPeriod > invoker.retryPeriod && interval > invoker.maxRetryPeriod {
+		interval = invoker.maxRetryPeriod
+	}
+	return interval
+}
+
+// start starts the scheduling of the retries and the workers, once
+func (invoker *failbackClusterInvoker) start() {
+	invoker.once.Do(func() {
+		for i := int64(0); i < invoker.concurrency; i++ {
+			go invoker.work()
+		}
+		go invoker.process()
+	})
+}
+
+// process hands the tasks to the workers when they're due, and sleeps until the next one is due
+// or an earlier one is put.
 func (invoker *failbackClusterInvoker) process() {
-	invoker.ticker = time.NewTicker(time.Second * 1)
-	for range invoker.ticker.C {
-		// check each timeout task and re-run
-		for {
-			value, err := invoker.taskList.Peek()
-			if err == queue.ErrDisposed {
-				return
-			}
-			if err == queue.ErrEmptyQueue {
-				break
-			}
-
-			retryTask := value.(*retryTimerTask)
-			if time.Since(retryTask.lastT).Seconds() < 5 {
-				break
-			}
-
-			// ignore return. the get must success.
-			_, err = invoker.taskList.Get(1)
-			if err != nil {
-				logger.Warnf("get task found err: %v\n", err)
-				break
-			}
-
-			go func(retryTask *retryTimerTask) {
-				invoked := make([]protocol.Invoker, 0)
-				invoked = append(invoked, retryTask.lastInvoker)
-
-				retryInvoker := invoker.doSelect(retryTask.loadbalance, retryTask.invocation, retryTask.invokers, invoked)
-				var result protocol.Result
-				result = retryInvoker.Invoke(retryTask.invocation)
-				if result.Error() != nil {
-					retryTask.lastInvoker = retryInvoker
-					invoker.checkRetry(retryTask, result.Error())
-				}
-			}(retryTask)
-
-		}
-	}
-}
-
-func (invoker *failbackClusterInvoker) checkRetry(retryTask *retryTimerTask, err error) {
-	logger.Errorf("Failed retry to invoke the method %v in the service %v, wait again. The exception: %v.\n",
-		retryTask.invocation.MethodName(), invoker.GetUrl().Service(), err.Error())
+	for {
+		next, ok := invoker.processDueTasks()
+		if !ok {
+			return
+		}
+
+		var (
+			timer   *time.Timer
+			timeout <-chan time.Time
+		)
+		if !next.IsZero() {
+			timer = time.NewTimer(time.Until(next))
+			timeout = timer.C
+		}
+		select {
+		case <-invoker.done:
+			return
+		case <-invoker.taskList.wakeup:
+		case <-timeout:
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+	}
+}
+
+// processDueTasks hands all the due tasks to the workers, and returns the due time of the next task,
+// which is zero if there isn't any. false is returned if the task list is disposed.
+func (invoker *failbackClusterInvoker) processDueTasks() (time.Time, bool) {
+	for {
+		retryTask, next, err := invoker.taskList.takeDue(time.Now())
+		if err != nil {
+			return time.Time{}, false
+		}
+		if retryTask == nil {
+			return next, true
+		}
+		if invoker.expired(retryTask, time.Now()) {
+			logger.Warnf("the failback task exceeds the max age %v, We have to abandon, invocation-> %v.\n",
+				invoker.maxAge, retryTask.invocation)
+			retryTask.finish(&protocol.RPCResult{Err: perrors.New("the failback task is abandoned as it exceeds the max age")})
+			continue
+		}
+		select {
+		case invoker.dueTasks <- retryTask:
+		case <-invoker.done:
+			retryTask.abandon()
+			return time.Time{}, false
+		}
+	}
+}
+
+func (invoker *failbackClusterInvoker) work() {
+	for {
+		select {
+		case <-invoker.done:
+			return
+		case retryTask := <-invoker.dueTasks:
+			invoker.retry(retryTask)
+		}
+	}
+}
+
+// retry re-runs the task on the current providers of the directory, the providers gone since the task
+// was enqueued are never selected. The last failed provider is excluded unless it's the only one left.
+// The retry is deferred if there isn't any provider, unless failback.empty.defer is false.
+func (invoker *failbackClusterInvoker) retry(retryTask *retryTimerTask) {
+	invokers := invoker.directory.List(retryTask.invocation)
+	if err := invoker.checkInvokers(invokers, retryTask.invocation); err != nil {
+		if invoker.deferOnEmpty {
+			invoker.deferRetry(retryTask, &protocol.RPCResult{Err: err})
+		} else {
+			invoker.checkRetry(retryTask, &protocol.RPCResult{Err: err})
+		}
+		return
+	}
+
+	invoked := []protocol.Invoker{retryTask.lastInvoker}
+	retryInvoker := invoker.doSelect(retryTask.loadbalance, retryTask.invocation, invokers, invoked)
+	if retryInvoker == nil {
+		// the last failed provider may be gone, so it's reselected among the current ones
+		retryInvoker = invoker.doSelect(retryTask.loadbalance, retryTask.invocation, invokers, nil)
+	}
+	if retryInvoker == nil {
+		invoker.checkRetry(retryTask, &protocol.RPCResult{Err: perrors.Errorf("Failed to retry the method %v in the service %v, no available provider",
+			retryTask.invocation.MethodName(), invoker.GetUrl().Service())})
+		return
+	}
+	result := retryInvoker.Invoke(retryTask.invocation)
+	if result.Error() != nil {
+		retryTask.lastInvoker = retryInvoker
+		invoker.checkRetry(retryTask, result)
+		return
+	}
+	retryTask.finish(result)
+}
+
+// enqueue puts the @task to the task list without blocking the caller, it's due after the retry interval.
+// false is returned if the list is full and the task is discarded, unless failback.overflow.policy is evict,
+// then the oldest task is discarded for it.
+func (invoker *failbackClusterInvoker) enqueue(task *retryTimerTask) bool {
+	invoker.taskLock.Lock()
+	defer invoker.taskLock.Unlock()
+
+	if invoker.taskList.Len() >= invoker.failbackTasks {
+		if invoker.overflowPolicy != constant.FAIL_BACK_OVERFLOW_EVICT {
+			return false
+		}
+		evictedTask := invoker.taskList.evictOldest()
+		if evictedTask == nil {
+			return false
+		}
+		logger.Warnf("tasklist is too full, the oldest task is evicted, invocation-> %v.\n", evictedTask.invocation)
+		evictedTask.finish(&protocol.RPCResult{Err: perrors.New("the failback task is evicted as the tasklist is too full")})
+	}
+	task.due = task.lastT.Add(invoker.retryInterval(task.retries))
+	return invoker.taskList.Put(task) == nil
+}
+
+// expired checks whether the task is older than failback.max.age at @now
+func (invoker *failbackClusterInvoker) expired(retryTask *retryTimerTask, now time.Time) bool {
+	return invoker.maxAge > 0 && now.Sub(retryTask.firstT) >= invoker.maxAge
+}
+
+// taskMaxRetries returns the max retries of the task, which are the ones of the invoker unless specified
+func (invoker *failbackClusterInvoker) taskMaxRetries(retryTask *retryTimerTask) int64 {
+	if retryTask.maxRetries > 0 {
+		return retryTask.maxRetries
+	}
+	return invoker.maxRetries
+}
+
+// checkRetry re-queues the task failed with the @result, or gives it up
+func (invoker *failbackClusterInvoker) checkRetry(retryTask *retryTimerTask, result protocol.Result) {
+	err := result.Error()
+	url := invoker.GetUrl()
+	methodName := retryTask.invocation.MethodName()
+	invoker.errorLog.errorf(url, methodName, "Failed retry to invoke the method %v in the service %v, wait again. The exception: %v.\n",
+		methodName, url.Service(), err.Error())
 	retryTask.retries++
 	retryTask.lastT = time.Now()
-	if retryTask.retries > invoker.maxRetries {
-		logger.Errorf("Failed retry times exceed threshold (%v), We have to abandon, invocation-> %v.\n",
+	if retryTask.retries > invoker.taskMaxRetries(retryTask) {
+		invoker.errorLog.errorf(url, methodName, "Failed retry times exceed threshold (%v), We have to abandon, invocation-> %v.\n",
 			retryTask.retries, retryTask.invocation)
+	} else if !retryTask.retryPredicate.ShouldRetry(err, retryTask.invocation, int(retryTask.retries)+2) {
+		invoker.errorLog.errorf(url, methodName, "Failed retry is not retryable any more, We have to abandon, invocation-> %v.\n",
+			retryTask.invocation)
 	} else {
-		invoker.taskList.Put(retryTask)
+		invoker.requeue(retryTask, result)
+		return
 	}
+	retryTask.finish(result)
+}
+
+// deferRetry re-queues the task whose retry finds no provider without consuming its retries,
+// it's given up with the @result once it's deferred more times than its max retries.
+func (invoker *failbackClusterInvoker) deferRetry(retryTask *retryTimerTask, result protocol.Result) {
+	url := invoker.GetUrl()
+	methodName := retryTask.invocation.MethodName()
+	retryTask.deferrals++
+	retryTask.lastT = time.Now()
+	if retryTask.deferrals > invoker.taskMaxRetries(retryTask) {
+		invoker.errorLog.errorf(url, methodName, "No provider to retry for %v times, We have to abandon, invocation-> %v.\n",
+			retryTask.deferrals, retryTask.invocation)
+		retryTask.finish(result)
+		return
+	}
+	logger.Infof("No provider to retry the method %v in the service %v, the retry is deferred.\n", methodName, url.Service())
+	invoker.requeue(retryTask, result)
+}
+
+// requeue puts the task back to the task list, or gives it up with the @result if it exceeds the max age,
+// the invoker is destroyed or the task list is full.
+func (invoker *failbackClusterInvoker) requeue(retryTask *retryTimerTask, result protocol.Result) {
+	url := invoker.GetUrl()
+	methodName := retryTask.invocation.MethodName()
+	// the task is abandoned with its last error if the next retry is due after the max age
+	if invoker.expired(retryTask, retryTask.lastT.Add(invoker.retryInterval(retryTask.retries))) {
+		invoker.errorLog.errorf(url, methodName, "Failed retry exceeds the max age (%v), We have to abandon, invocation-> %v.\n",
+			invoker.maxAge, retryTask.invocation)
+	} else if invoker.taskList.Disposed() {
+		logger.Warnf("the failback invoker is destroyed, We have to abandon, invocation-> %v.\n", retryTask.invocation)
+	} else if !invoker.enqueue(retryTask) {
+		logger.Warnf("tasklist is too full > %d, We have to abandon, invocation-> %v.\n",
+			invoker.failbackTasks, retryTask.invocation)
+	} else {
+		return
+	}
+	retryTask.finish(result)
 }
 
 func (invoker *failbackClusterInvoker) Invoke(invocation protocol.Invocation) protocol.Result {
 	invokers := invoker.directory.List(invocation)
 	err := invoker.checkInvokers(invokers, invocation)
 	if err != nil {
-		logger.Errorf("Failed to invoke the method %v in the service %v, wait for retry in background. Ignored exception: %v.\n",
-			invocation.MethodName(), invoker.GetUrl().Service(), err)
+		url := invoker.GetUrl()
+		invoker.errorLog.errorf(url, invocation.MethodName(), "Failed to invoke the method %v in the service %v, wait for retry in background. Ignored exception: %v.\n",
+			invocation.MethodName(), url.Service(), err)
 		return &protocol.RPCResult{}
 	}
 	url := invokers[0].GetUrl()
-	methodName := invocation.MethodName()
-	//Get the service loadbalance config
-	lb := url.GetParam(constant.LOADBALANCE_KEY, constant.DEFAULT_LOADBALANCE)
-
-	//Get the service method loadbalance config if have
-	if v := url.GetMethodParam(methodName, constant.LOADBALANCE_KEY, ""); v != "" {
-		lb = v
-	}
-	loadbalance := extension.GetLoadbalance(lb)
+	loadbalance := getLoadBalance(url, invocation)
+	attachTimeout(url, invocation)
 
 	invoked := make([]protocol.Invoker, 0, len(invokers))
 	var result protocol.Result
 
 	ivk := invoker.doSelect(loadbalance, invocation, invokers, invoked)
 	//DO INVOKE
-	result = ivk.Invoke(invocation)
+	result = invoker.doInvoke(ivk, invocation)
 	if result.Error() != nil {
-		invoker.once.Do(func() {
-			invoker.taskList = queue.New(invoker.failbackTasks)
-			go invoker.process()
-		})
+		return invoker.failback(url, loadbalance, invocation, ivk, result)
+	}
 
-		taskLen := invoker.taskList.Len()
-		if taskLen >= invoker.failbackTasks {
-			logger.Warnf("tasklist is too full > %d.\n", taskLen)
-			return &protocol.RPCResult{}
-		}
+	return result
+}
+
+// failbackFailed hands the call failed with @result by the previous cluster of a chain to the retries in
+// background, without invoking it again
+func (invoker *failbackClusterInvoker) failbackFailed(invocation protocol.Invocation, result protocol.Result) protocol.Result {
+	invokers := invoker.directory.List(invocation)
+	if err := invoker.checkInvokers(invokers, invocation); err != nil {
+		url := invoker.GetUrl()
+		invoker.errorLog.errorf(url, invocation.MethodName(), "Failed to invoke the method %v in the service %v, wait for retry in background. Ignored exception: %v.\n",
+			invocation.MethodName(), url.Service(), err)
+		return &protocol.RPCResult{}
+	}
+	url := invokers[0].GetUrl()
+	attachTimeout(url, invocation)
+	return invoker.failback(url, getLoadBalance(url, invocation), invocation, nil, result)
+}
 
-		timerTask := newRetryTimerTask(loadbalance, invocation, invokers, ivk)
-		invoker.taskList.Put(timerTask)
+// failback enqueues the task retrying the call failed with @result by @lastInvoker, unless it isn't retryable
+func (invoker *failbackClusterInvoker) failback(url common.URL, loadbalance cluster.LoadBalance, invocation protocol.Invocation,
+	lastInvoker protocol.Invoker, result protocol.Result) protocol.Result {
+	invoker.start()
+	methodName := invocation.MethodName()
 
-		logger.Errorf("Failback to invoke the method %v in the service %v, wait for retry in background. Ignored exception: %v.\n",
+	maxRetries := getRetries(url, invocation, invoker.maxRetries)
+	if maxRetries <= 0 {
+		invoker.errorLog.errorf(url, methodName, "Failback to invoke the method %v in the service %v, the retries are disabled: %v.\n",
 			methodName, url.Service(), result.Error().Error())
-		// ignore
-		return &protocol.RPCResult{}
+		notifyFailbackCallback(getFailbackCallback(invocation), invocation, result)
+		return invoker.failedResult(result)
 	}
 
-	return result
+	retryPredicate := getRetryPredicate(url, invocation)
+	if !retryPredicate.ShouldRetry(result.Error(), invocation, 2) {
+		invoker.errorLog.errorf(url, methodName, "Failback to invoke the method %v in the service %v, the exception is not retryable: %v.\n",
+			methodName, url.Service(), result.Error().Error())
+		notifyFailbackCallback(getFailbackCallback(invocation), invocation, result)
+		return invoker.failedResult(result)
+	}
+
+	timerTask := newRetryTimerTask(loadbalance, retryPredicate, invocation, lastInvoker)
+	timerTask.maxRetries = maxRetries
+	if !invoker.enqueue(timerTask) {
+		if invoker.taskList.Disposed() {
+			logger.Warnf("the failback invoker is destroyed, invocation-> %v.\n", invocation)
+		} else {
+			logger.Warnf("tasklist is too full > %d.\n", invoker.failbackTasks)
+		}
+		timerTask.finish(result)
+		return invoker.failedResult(result)
+	}
+
+	invoker.errorLog.errorf(url, methodName, "Failback to invoke the method %v in the service %v, wait for retry in background. Ignored exception: %v.\n",
+		methodName, url.Service(), result.Error().Error())
+	return invoker.failedResult(result)
+}
+
+// failedResult returns the result of the first failed call, it's empty unless failback.firstcall.error is true.
+func (invoker *failbackClusterInvoker) failedResult(result protocol.Result) protocol.Result {
+	if invoker.firstCallError {
+		return &protocol.RPCResult{Err: result.Error()}
+	}
+	// ignore
+	return &protocol.RPCResult{}
 }
 
 func (invoker *failbackClusterInvoker) Destroy() {
 	invoker.baseClusterInvoker.Destroy()
 
-	// stop ticker
-	if invoker.ticker != nil {
-		invoker.ticker.Stop()
-	}
-
-	_ = invoker.taskList.Dispose()
+	// stop the process goroutine and the workers, the pending tasks are abandoned
+	invoker.stopOnce.Do(func() {
+		close(invoker.done)
+		for _, task := range invoker.taskList.Dispose() {
+			task.abandon()
+		}
+	})
 }
 
 type retryTimerTask struct {
-	loadbalance cluster.LoadBalance
-	invocation  protocol.Invocation
-	invokers    []protocol.Invoker
-	lastInvoker protocol.Invoker
-	retries     int64
-	lastT       time.Time
+	loadbalance    cluster.LoadBalance
+	retryPredicate cluster.RetryPredicate
+	invocation     protocol.Invocation
+	lastInvoker    protocol.Invoker
+	callback       cluster.FailbackCallback
+	retries        int64
+	// the times the retry is deferred as there isn't any provider
+	deferrals int64
+	// the max retries of the invocation, 0 refers to the ones of the invoker
+	maxRetries int64
+	// the time of the first failure and the last one
+	firstT time.Time
+	lastT  time.Time
+	// the time of the next retry, and the order of the put and the index in the task list
+	due   time.Time
+	seq   int64
+	index int
 }
 
-func newRetryTimerTask(loadbalance cluster.LoadBalance, invocation protocol.Invocation, invokers []protocol.Invoker,
+func newRetryTimerTask(loadbalance cluster.LoadBalance, retryPredicate cluster.RetryPredicate, invocation protocol.Invocation,
 	lastInvoker protocol.Invoker) *retryTimerTask {
+	now := time.Now()
 	return &retryTimerTask{
-		loadbalance: loadbalance,
-		invocation:  invocation,
-		invokers:    invokers,
-		lastInvoker: lastInvoker,
-		lastT:       time.Now(),
+		loadbalance:    loadbalance,
+		retryPredicate: retryPredicate,
+		invocation:     invocation,
+		lastInvoker:    lastInvoker,
+		callback:       getFailbackCallback(invocation),
+		firstT:         now,
+		lastT:          now,
 	}
 }
+
+// finish notifies the callback of the task of its final @result
+func (task *retryTimerTask) finish(result protocol.Result) {
+	notifyFailbackCallback(task.callback, task.invocation, result)
+}
+
+// abandon notifies the callback of the task that it isn't retried any more as the invoker is destroyed
+func (task *retryTimerTask) abandon() {
+	task.finish(&protocol.RPCResult{Err: perrors.New("the failback task is abandoned as the invoker is destroyed")})
+}
+
+// getFailbackCallback returns the callback referred by the attachment failback.callback of the @invocation, or nil
+func getFailbackCallback(invocation protocol.Invocation) cluster.FailbackCallback {
+	key := invocation.AttachmentsByKey(constant.FAILBACK_CALLBACK_KEY, "")
+	if key == "" {
+		return nil
+	}
+	callback := cluster.GetFailbackCallback(key)
+	if callback == nil {
+		logger.Warnf("the failback callback %v of the method %v is not registered", key, invocation.MethodName())
+	}
+	return callback
+}
+
+func notifyFailbackCallback(callback cluster.FailbackCallback, invocation protocol.Invocation, result protocol.Result) {
+	if callback == nil {
+		return
+	}
+	defer func() {
+		if e := recover(); e != nil {
+			logger.Warnf("the failback callback of the method %v, panic: %v", invocation.MethodName(), e)
+		}
+	}()
+	callback(invocation, result)
+}
@@ -19,7 +19,9 @@ package cluster_impl
 
 import (
 	"context"
+	"runtime"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 )
@@ -31,9 +33,11 @@ import (
 )
 
 import (
+	"github.com/apache/dubbo-go/cluster"
 	"github.com/apache/dubbo-go/cluster/directory"
 	"github.com/apache/dubbo-go/cluster/loadbalance"
 	"github.com/apache/dubbo-go/common"
+	"github.com/apache/dubbo-go/common/constant"
 	"github.com/apache/dubbo-go/common/extension"
 	"github.com/apache/dubbo-go/protocol"
 	"github.com/apache/dubbo-go/protocol/invocation"
@@ -240,3 +244,691 @@ func Test_FailbackOutOfLimit(t *testing.T) {
 	invoker.EXPECT().Destroy().Return()
 	clusterInvoker.Destroy()
 }
+
+// failed firstly, the origin error is returned to caller and the task is still retried in background.
+func Test_FailbackFirstCallError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	invoker := mock.NewMockInvoker(ctrl)
+	clusterInvoker := registerFailback(t, invoker).(*failbackClusterInvoker)
+	assert.False(t, clusterInvoker.firstCallError)
+	clusterInvoker.firstCallError = true
+
+	invoker.EXPECT().GetUrl().Return(failbackUrl).AnyTimes()
+
+	// failed at first
+	mockFailedResult := &protocol.RPCResult{Err: perrors.New("error")}
+	invoker.EXPECT().Invoke(gomock.Any()).Return(mockFailedResult)
+
+	// success second
+	var wg sync.WaitGroup
+	wg.Add(1)
+	mockSuccResult := &protocol.RPCResult{Rest: rest{tried: 0, success: true}}
+	invoker.EXPECT().Invoke(gomock.Any()).DoAndReturn(func(invocation protocol.Invocation) protocol.Result {
+		wg.Done()
+		return mockSuccResult
+	})
+
+	result := clusterInvoker.Invoke(&invocation.RPCInvocation{})
+	assert.Equal(t, mockFailedResult.Err, result.Error())
+	assert.Equal(t, int64(1), clusterInvoker.taskList.Len())
+
+	wg.Wait()
+	assert.Equal(t, int64(0), clusterInvoker.taskList.Len())
+
+	invoker.EXPECT().Destroy().Return()
+	clusterInvoker.Destroy()
+}
+
+func Test_FailbackFirstCallErrorConfig(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	url, _ := common.NewURL(context.TODO(), "dubbo://192.168.1.1:20000/com.ikurento.user.UserProvider?failback.firstcall.error=true")
+	invoker := mock.NewMockInvoker(ctrl)
+	invoker.EXPECT().GetUrl().Return(url).AnyTimes()
+
+	staticDir := directory.NewStaticDirectory([]protocol.Invoker{invoker})
+	clusterInvoker := NewFailbackCluster().Join(staticDir).(*failbackClusterInvoker)
+	assert.True(t, clusterInvoker.firstCallError)
+}
+
+// concurrencyInvoker records the peak of the concurrent invocations
+type concurrencyInvoker struct {
+	*MockInvoker
+	lock    sync.Mutex
+	running int
+	peak    int
+	calls   int
+}
+
+func (ci *concurrencyInvoker) Invoke(invocation protocol.Invocation) protocol.Result {
+	ci.lock.Lock()
+	ci.running++
+	ci.calls++
+	if ci.running > ci.peak {
+		ci.peak = ci.running
+	}
+	ci.lock.Unlock()
+
+	time.Sleep(20 * time.Millisecond)
+
+	ci.lock.Lock()
+	ci.running--
+	ci.lock.Unlock()
+	return &protocol.RPCResult{}
+}
+
+func Test_FailbackProcessDueTasksBounded(t *testing.T) {
+	url, _ := common.NewURL(context.TODO(), "dubbo://192.168.1.1:20000/com.ikurento.user.UserProvider?failback.concurrency=4")
+	invoker := &concurrencyInvoker{MockInvoker: NewMockInvoker(url, 1)}
+	clusterInvoker := NewFailbackCluster().Join(directory.NewStaticDirectory([]protocol.Invoker{invoker})).(*failbackClusterInvoker)
+	assert.Equal(t, int64(4), clusterInvoker.concurrency)
+
+	// the backlog of the due tasks
+	clusterInvoker.start()
+	lb := loadbalance.NewRandomLoadBalance()
+	for i := 0; i < 40; i++ {
+		task := newRetryTimerTask(lb, getRetryPredicate(url, &invocation.RPCInvocation{}), &invocation.RPCInvocation{}, invoker)
+		task.lastT = time.Now().Add(-10 * time.Second)
+		assert.True(t, clusterInvoker.enqueue(task))
+	}
+
+	// all the due tasks are processed once, by at most 4 workers
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		invoker.lock.Lock()
+		calls := invoker.calls
+		invoker.lock.Unlock()
+		if calls >= 40 {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	invoker.lock.Lock()
+	assert.Equal(t, 40, invoker.calls)
+	assert.True(t, invoker.peak <= 4)
+	invoker.lock.Unlock()
+	assert.Equal(t, int64(0), clusterInvoker.taskList.Len())
+
+	clusterInvoker.Destroy()
+	_, ok := clusterInvoker.processDueTasks()
+	assert.False(t, ok)
+}
+
+// changingDirectory lists the providers replaced at runtime
+type changingDirectory struct {
+	lock     sync.Mutex
+	url      common.URL
+	invokers []protocol.Invoker
+}
+
+func (dir *changingDirectory) GetUrl() common.URL {
+	return dir.url
+}
+
+func (dir *changingDirectory) IsAvailable() bool {
+	return true
+}
+
+func (dir *changingDirectory) Destroy() {}
+
+func (dir *changingDirectory) List(invocation protocol.Invocation) []protocol.Invoker {
+	dir.lock.Lock()
+	defer dir.lock.Unlock()
+	return dir.invokers
+}
+
+func (dir *changingDirectory) set(invokers ...protocol.Invoker) {
+	dir.lock.Lock()
+	defer dir.lock.Unlock()
+	dir.invokers = invokers
+}
+
+// failbackDirectoryUrl returns the registry url of the directory referring to the service @url
+func failbackDirectoryUrl(url common.URL) common.URL {
+	dirUrl := url
+	dirUrl.SubURL = &url
+	return dirUrl
+}
+
+func Test_FailbackRetryCurrentProviders(t *testing.T) {
+	extension.SetLoadbalance("random", loadbalance.NewRandomLoadBalance)
+	gone := &concurrencyInvoker{MockInvoker: NewMockInvoker(failbackUrl, 1)}
+	dir := &changingDirectory{url: failbackDirectoryUrl(failbackUrl)}
+	dir.set(gone)
+	clusterInvoker := NewFailbackCluster().Join(dir).(*failbackClusterInvoker)
+	clusterInvoker.taskList = newRetryTaskQueue()
+
+	inv := &invocation.RPCInvocation{}
+	task := newRetryTimerTask(loadbalance.NewRandomLoadBalance(), getRetryPredicate(failbackUrl, inv), inv, gone)
+
+	// the captured provider is gone, the fresh ones are retried
+	fresh1 := &concurrencyInvoker{MockInvoker: NewMockInvoker(failbackUrl, 1)}
+	fresh2 := &concurrencyInvoker{MockInvoker: NewMockInvoker(failbackUrl, 1)}
+	dir.set(fresh1, fresh2)
+	for i := 0; i < 10; i++ {
+		clusterInvoker.retry(task)
+	}
+	assert.Equal(t, 0, gone.calls)
+	assert.Equal(t, 10, fresh1.calls+fresh2.calls)
+	assert.Equal(t, int64(0), clusterInvoker.taskList.Len())
+
+	// no provider at all, the task waits for the next retry
+	dir.set()
+	clusterInvoker.retry(task)
+	assert.Equal(t, int64(0), task.retries)
+	assert.Equal(t, int64(1), task.deferrals)
+	assert.Equal(t, int64(1), clusterInvoker.taskList.Len())
+}
+
+func Test_FailbackRetryNoneAvailable(t *testing.T) {
+	extension.SetLoadbalance("random", loadbalance.NewRandomLoadBalance)
+	gone := &concurrencyInvoker{MockInvoker: NewMockInvoker(failbackUrl, 1)}
+	dir := &changingDirectory{url: failbackDirectoryUrl(failbackUrl)}
+	clusterInvoker := NewFailbackCluster().Join(dir).(*failbackClusterInvoker)
+	clusterInvoker.taskList = newRetryTaskQueue()
+
+	inv := &invocation.RPCInvocation{}
+	task := newRetryTimerTask(loadbalance.NewRandomLoadBalance(), getRetryPredicate(failbackUrl, inv), inv, gone)
+
+	// none of the current providers is available, the gone one isn't invoked instead
+	fresh1 := &concurrencyInvoker{MockInvoker: NewMockInvoker(failbackUrl, 1)}
+	fresh2 := &concurrencyInvoker{MockInvoker: NewMockInvoker(failbackUrl, 1)}
+	fresh1.Destroy()
+	fresh2.Destroy()
+	dir.set(fresh1, fresh2)
+	clusterInvoker.retry(task)
+	assert.Equal(t, 0, gone.calls+fresh1.calls+fresh2.calls)
+	assert.Equal(t, int64(1), task.retries)
+	assert.Equal(t, int64(1), clusterInvoker.taskList.Len())
+
+	// the last failed provider is reselected if it's the only available one
+	fresh3 := &concurrencyInvoker{MockInvoker: NewMockInvoker(failbackUrl, 1)}
+	task.lastInvoker = fresh3
+	dir.set(fresh1, fresh3)
+	clusterInvoker.retry(task)
+	assert.Equal(t, 1, fresh3.calls)
+}
+
+func Test_FailbackRetryDeferredOnEmpty(t *testing.T) {
+	results := make(chan protocol.Result, 1)
+	cluster.SetFailbackCallback("failback_callback_empty", func(invocation protocol.Invocation, result protocol.Result) {
+		results <- result
+	})
+	defer cluster.SetFailbackCallback("failback_callback_empty", nil)
+
+	extension.SetLoadbalance("random", loadbalance.NewRandomLoadBalance)
+	last := &concurrencyInvoker{MockInvoker: NewMockInvoker(failbackUrl, 1)}
+	dir := &changingDirectory{url: failbackDirectoryUrl(failbackUrl)}
+	clusterInvoker := NewFailbackCluster().Join(dir).(*failbackClusterInvoker)
+	clusterInvoker.taskList = newRetryTaskQueue()
+
+	inv := invocation.NewRPCInvocationWithOptions(invocation.WithMethodName("Notify"),
+		invocation.WithAttachments(map[string]string{constant.FAILBACK_CALLBACK_KEY: "failback_callback_empty"}))
+	task := newRetryTimerTask(loadbalance.NewRandomLoadBalance(), getRetryPredicate(failbackUrl, inv), inv, last)
+
+	// the directory is empty at the retry time, the task is re-queued without a doomed invocation
+	for i := int64(1); i <= constant.DEFAULT_FAILBACK_TIMES; i++ {
+		clusterInvoker.retry(task)
+		assert.Equal(t, int64(0), task.retries)
+		assert.Equal(t, i, task.deferrals)
+		assert.Equal(t, 0, last.calls)
+		taken, _, err := clusterInvoker.taskList.takeDue(time.Now().Add(time.Hour))
+		assert.NoError(t, err)
+		assert.True(t, taken == task)
+	}
+	select {
+	case <-results:
+		assert.Fail(t, "the deferred task is finished")
+	default:
+	}
+
+	// the task is given up once it's deferred more than the max retries
+	clusterInvoker.retry(task)
+	assert.Equal(t, int64(0), clusterInvoker.taskList.Len())
+	result := failbackCallbackResult(t, results)
+	assert.Contains(t, result.Error().Error(), "No provider available")
+}
+
+func Test_FailbackRetryNotDeferredOnEmpty(t *testing.T) {
+	extension.SetLoadbalance("random", loadbalance.NewRandomLoadBalance)
+	url, _ := common.NewURL(context.TODO(), "dubbo://192.168.1.1:20000/com.ikurento.user.UserProvider?failback.empty.defer=false")
+	last := &concurrencyInvoker{MockInvoker: NewMockInvoker(url, 1)}
+	dir := &changingDirectory{url: failbackDirectoryUrl(url)}
+	clusterInvoker := NewFailbackCluster().Join(dir).(*failbackClusterInvoker)
+	clusterInvoker.taskList = newRetryTaskQueue()
+
+	inv := &invocation.RPCInvocation{}
+	task := newRetryTimerTask(loadbalance.NewRandomLoadBalance(), getRetryPredicate(url, inv), inv, last)
+
+	// the empty directory is counted as a failed retry
+	clusterInvoker.retry(task)
+	assert.Equal(t, int64(1), task.retries)
+	assert.Equal(t, int64(0), task.deferrals)
+	assert.Equal(t, 0, last.calls)
+	assert.Equal(t, int64(1), clusterInvoker.taskList.Len())
+}
+
+func failbackOverflowInvoker(t *testing.T, policy string) *failbackClusterInvoker {
+	extension.SetLoadbalance("random", loadbalance.NewRandomLoadBalance)
+	url, _ := common.NewURL(context.TODO(), "dubbo://192.168.1.1:20000/com.ikurento.user.UserProvider?failbacktasks=2&failback.overflow.policy="+policy)
+	ivk := &errInvoker{MockInvoker: NewMockInvoker(url, 1), err: perrors.New("error")}
+	clusterInvoker := NewFailbackCluster().Join(directory.NewStaticDirectory([]protocol.Invoker{ivk})).(*failbackClusterInvoker)
+
+	// the task list is full
+	for i := 0; i < 2; i++ {
+		clusterInvoker.Invoke(invocation.NewRPCInvocationWithOptions(invocation.WithMethodName("Old")))
+	}
+	assert.Equal(t, int64(2), clusterInvoker.taskList.Len())
+	return clusterInvoker
+}
+
+func failbackOverflowInvoke(t *testing.T, clusterInvoker *failbackClusterInvoker) {
+	var wg sync.WaitGroup
+	start := time.Now()
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			clusterInvoker.Invoke(invocation.NewRPCInvocationWithOptions(invocation.WithMethodName("New")))
+		}()
+	}
+	wg.Wait()
+	assert.True(t, time.Since(start) < time.Second)
+	assert.Equal(t, int64(2), clusterInvoker.taskList.Len())
+}
+
+func Test_FailbackOverflowDiscard(t *testing.T) {
+	clusterInvoker := failbackOverflowInvoker(t, "discard")
+	defer clusterInvoker.Destroy()
+	failbackOverflowInvoke(t, clusterInvoker)
+
+	// the new tasks are discarded
+	for _, task := range clusterInvoker.taskList.tasks {
+		assert.Equal(t, "Old", task.invocation.MethodName())
+	}
+}
+
+func Test_FailbackOverflowEvict(t *testing.T) {
+	clusterInvoker := failbackOverflowInvoker(t, "evict")
+	defer clusterInvoker.Destroy()
+	failbackOverflowInvoke(t, clusterInvoker)
+
+	// the old tasks are evicted
+	for _, task := range clusterInvoker.taskList.tasks {
+		assert.Equal(t, "New", task.invocation.MethodName())
+	}
+}
+
+// timingInvoker fails and records the time of each invocation
+type timingInvoker struct {
+	*MockInvoker
+	lock  sync.Mutex
+	times []time.Time
+}
+
+func (ti *timingInvoker) Invoke(invocation protocol.Invocation) protocol.Result {
+	ti.lock.Lock()
+	ti.times = append(ti.times, time.Now())
+	ti.lock.Unlock()
+	return &protocol.RPCResult{Err: perrors.New("error")}
+}
+
+// gaps waits for @n invocations and returns the gaps between them
+func (ti *timingInvoker) gaps(t *testing.T, n int) []time.Duration {
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		ti.lock.Lock()
+		times := ti.times
+		ti.lock.Unlock()
+		if len(times) >= n {
+			gaps := make([]time.Duration, 0, n-1)
+			for i := 1; i < n; i++ {
+				gaps = append(gaps, times[i].Sub(times[i-1]))
+			}
+			return gaps
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.Fail(t, "the retries are not done in time")
+	return nil
+}
+
+func failbackTimingInvoke(t *testing.T, urlParams string) (*failbackClusterInvoker, *timingInvoker) {
+	extension.SetLoadbalance("random", loadbalance.NewRandomLoadBalance)
+	url, _ := common.NewURL(context.TODO(), "dubbo://192.168.1.1:20000/com.ikurento.user.UserProvider?"+urlParams)
+	ti := &timingInvoker{MockInvoker: NewMockInvoker(url, 1)}
+	clusterInvoker := NewFailbackCluster().Join(directory.NewStaticDirectory([]protocol.Invoker{ti})).(*failbackClusterInvoker)
+	clusterInvoker.Invoke(&invocation.RPCInvocation{})
+	return clusterInvoker, ti
+}
+
+func Test_FailbackRetryInterval(t *testing.T) {
+	url, _ := common.NewURL(context.TODO(), "dubbo://192.168.1.1:20000/com.ikurento.user.UserProvider")
+	clusterInvoker := NewFailbackCluster().Join(directory.NewStaticDirectory([]protocol.Invoker{NewMockInvoker(url, 1)})).(*failbackClusterInvoker)
+	assert.Equal(t, 5*time.Second, clusterInvoker.retryInterval(0))
+	assert.Equal(t, 5*time.Second, clusterInvoker.retryInterval(3))
+
+	clusterInvoker.retryPeriod = 100 * time.Millisecond
+	clusterInvoker.maxRetryPeriod = 500 * time.Millisecond
+	intervals := []time.Duration{100, 200, 400, 500, 500}
+	for i, interval := range intervals {
+		assert.Equal(t, interval*time.Millisecond, clusterInvoker.retryInterval(int64(i)))
+	}
+}
+
+func Test_FailbackRetryPeriod(t *testing.T) {
+	clusterInvoker, ti := failbackTimingInvoke(t, "failback.retry.period=100&retries=3")
+	defer clusterInvoker.Destroy()
+
+	// the first call and 4 retries, then the task is abandoned
+	for _, gap := range ti.gaps(t, 5) {
+		assert.True(t, gap >= 100*time.Millisecond, gap)
+		assert.True(t, gap < 400*time.Millisecond, gap)
+	}
+}
+
+func Test_FailbackRetryBackoff(t *testing.T) {
+	clusterInvoker, ti := failbackTimingInvoke(t, "failback.retry.period=100&failback.retry.max.period=400&retries=3")
+	defer clusterInvoker.Destroy()
+
+	intervals := []time.Duration{100, 200, 400, 400}
+	for i, gap := range ti.gaps(t, 5) {
+		assert.True(t, gap >= intervals[i]*time.Millisecond, gap)
+		assert.True(t, gap < (intervals[i]+300)*time.Millisecond, gap)
+	}
+}
+
+func Test_FailbackDestroyStopsRetry(t *testing.T) {
+	clusterInvoker, ti := failbackTimingInvoke(t, "failback.retry.period=100&retries=10")
+	ti.gaps(t, 2)
+	clusterInvoker.Destroy()
+	// destroyed twice safely
+	clusterInvoker.Destroy()
+
+	select {
+	case <-clusterInvoker.done:
+	default:
+		assert.Fail(t, "the process goroutine isn't stopped")
+	}
+	ti.lock.Lock()
+	calls := len(ti.times)
+	ti.lock.Unlock()
+	time.Sleep(300 * time.Millisecond)
+	ti.lock.Lock()
+	assert.True(t, len(ti.times) <= calls+1)
+	ti.lock.Unlock()
+}
+
+// flakyInvoker fails the first @failures invocations
+type flakyInvoker struct {
+	*MockInvoker
+	lock     sync.Mutex
+	failures int
+	calls    int
+}
+
+func (fi *flakyInvoker) Invoke(invocation protocol.Invocation) protocol.Result {
+	fi.lock.Lock()
+	defer fi.lock.Unlock()
+	fi.calls++
+	if fi.calls <= fi.failures {
+		return &protocol.RPCResult{Err: perrors.Errorf("error %v", fi.calls)}
+	}
+	return &protocol.RPCResult{Rest: fi.calls}
+}
+
+func failbackCallbackInvoke(t *testing.T, failures int, key string) (chan protocol.Result, *flakyInvoker, protocol.Result) {
+	extension.SetLoadbalance("random", loadbalance.NewRandomLoadBalance)
+	results := make(chan protocol.Result, 10)
+	cluster.SetFailbackCallback(key, func(inv protocol.Invocation, result protocol.Result) {
+		assert.Equal(t, "Notify", inv.MethodName())
+		results <- result
+	})
+	url, _ := common.NewURL(context.TODO(), "dubbo://192.168.1.1:20000/com.ikurento.user.UserProvider?failback.retry.period=100&retries=3")
+	fi := &flakyInvoker{MockInvoker: NewMockInvoker(url, 1), failures: failures}
+	clusterInvoker := NewFailbackCluster().Join(directory.NewStaticDirectory([]protocol.Invoker{fi}))
+
+	inv := invocation.NewRPCInvocationWithOptions(invocation.WithMethodName("Notify"),
+		invocation.WithAttachments(map[string]string{constant.FAILBACK_CALLBACK_KEY: key}))
+	return results, fi, clusterInvoker.Invoke(inv)
+}
+
+func failbackCallbackResult(t *testing.T, results chan protocol.Result) protocol.Result {
+	select {
+	case result := <-results:
+		return result
+	case <-time.After(5 * time.Second):
+		assert.Fail(t, "the failback callback isn't notified")
+		return nil
+	}
+}
+
+func Test_FailbackCallbackSuccess(t *testing.T) {
+	results, fi, result := failbackCallbackInvoke(t, 3, "failback_callback_success")
+	defer cluster.SetFailbackCallback("failback_callback_success", nil)
+	assert.NoError(t, result.Error())
+
+	// succeeded by the 3rd retry
+	result = failbackCallbackResult(t, results)
+	assert.NoError(t, result.Error())
+	assert.Equal(t, 4, result.Result())
+	assert.Equal(t, 4, fi.calls)
+}
+
+func Test_FailbackCallbackExhausted(t *testing.T) {
+	results, fi, _ := failbackCallbackInvoke(t, 100, "failback_callback_exhausted")
+	defer cluster.SetFailbackCallback("failback_callback_exhausted", nil)
+
+	// the first call and 4 retries
+	result := failbackCallbackResult(t, results)
+	assert.EqualError(t, result.Error(), "error 5")
+	assert.Equal(t, 5, fi.calls)
+
+	// notified once
+	select {
+	case <-results:
+		assert.Fail(t, "the failback callback is notified again")
+	case <-time.After(300 * time.Millisecond):
+	}
+}
+
+func Test_FailbackNoCallback(t *testing.T) {
+	inv := invocation.NewRPCInvocationWithOptions(invocation.WithMethodName("Notify"))
+	assert.Nil(t, newRetryTimerTask(nil, nil, inv, nil).callback)
+	inv.SetAttachments(constant.FAILBACK_CALLBACK_KEY, "failback_callback_unregistered")
+	task := newRetryTimerTask(nil, nil, inv, nil)
+	assert.Nil(t, task.callback)
+	// no-op
+	task.finish(&protocol.RPCResult{})
+}
+
+// orderInvoker records the methods in the order they're invoked
+type orderInvoker struct {
+	*MockInvoker
+	lock    sync.Mutex
+	methods []string
+}
+
+func (oi *orderInvoker) Invoke(invocation protocol.Invocation) protocol.Result {
+	oi.lock.Lock()
+	defer oi.lock.Unlock()
+	oi.methods = append(oi.methods, invocation.MethodName())
+	return &protocol.RPCResult{}
+}
+
+func Test_FailbackRetryDueOrder(t *testing.T) {
+	extension.SetLoadbalance("random", loadbalance.NewRandomLoadBalance)
+	url, _ := common.NewURL(context.TODO(), "dubbo://192.168.1.1:20000/com.ikurento.user.UserProvider?failback.retry.period=100")
+	oi := &orderInvoker{MockInvoker: NewMockInvoker(url, 1)}
+	clusterInvoker := NewFailbackCluster().Join(directory.NewStaticDirectory([]protocol.Invoker{oi})).(*failbackClusterInvoker)
+	defer clusterInvoker.Destroy()
+	clusterInvoker.start()
+
+	// the task put first is due the last, it doesn't hold back the ones put later
+	now := time.Now()
+	offsets := map[string]time.Duration{"Third": 400, "First": 0, "Second": 200}
+	for _, method := range []string{"Third", "First", "Second"} {
+		inv := invocation.NewRPCInvocationWithOptions(invocation.WithMethodName(method))
+		task := newRetryTimerTask(loadbalance.NewRandomLoadBalance(), getRetryPredicate(url, inv), inv, oi)
+		task.lastT = now.Add(offsets[method] * time.Millisecond)
+		assert.True(t, clusterInvoker.enqueue(task))
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		oi.lock.Lock()
+		retried := len(oi.methods)
+		oi.lock.Unlock()
+		if retried >= 3 {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	oi.lock.Lock()
+	assert.Equal(t, []string{"First", "Second", "Third"}, oi.methods)
+	oi.lock.Unlock()
+	assert.Equal(t, int64(0), clusterInvoker.taskList.Len())
+}
+
+func Test_FailbackDestroyBeforeStart(t *testing.T) {
+	extension.SetLoadbalance("random", loadbalance.NewRandomLoadBalance)
+	results := make(chan protocol.Result, 1)
+	cluster.SetFailbackCallback("failback_callback_destroy_start", func(inv protocol.Invocation, result protocol.Result) {
+		results <- result
+	})
+	defer cluster.SetFailbackCallback("failback_callback_destroy_start", nil)
+
+	url, _ := common.NewURL(context.TODO(), "dubbo://192.168.1.1:20000/com.ikurento.user.UserProvider")
+	ivk := &errInvoker{MockInvoker: NewMockInvoker(url, 1), err: perrors.New("error")}
+	clusterInvoker := NewFailbackCluster().Join(directory.NewStaticDirectory([]protocol.Invoker{ivk})).(*failbackClusterInvoker)
+
+	// the task list is disposed even if the retries are never started, so the call failed after that isn't
+	// stranded in it
+	clusterInvoker.Destroy()
+	inv := invocation.NewRPCInvocationWithOptions(invocation.WithMethodName("Notify"),
+		invocation.WithAttachments(map[string]string{constant.FAILBACK_CALLBACK_KEY: "failback_callback_destroy_start"}))
+	clusterInvoker.failback(url, loadbalance.NewRandomLoadBalance(), inv, ivk, ivk.Invoke(inv))
+	assert.Equal(t, int64(0), clusterInvoker.taskList.Len())
+	assert.EqualError(t, failbackCallbackResult(t, results).Error(), "error")
+}
+
+func Test_FailbackDestroyPendingTasks(t *testing.T) {
+	extension.SetLoadbalance("random", loadbalance.NewRandomLoadBalance)
+	results := make(chan protocol.Result, 10)
+	cluster.SetFailbackCallback("failback_callback_destroy", func(inv protocol.Invocation, result protocol.Result) {
+		results <- result
+	})
+	defer cluster.SetFailbackCallback("failback_callback_destroy", nil)
+
+	goroutines := runtime.NumGoroutine()
+	url, _ := common.NewURL(context.TODO(), "dubbo://192.168.1.1:20000/com.ikurento.user.UserProvider?failback.concurrency=4")
+	ivk := &errInvoker{MockInvoker: NewMockInvoker(url, 1), err: perrors.New("error")}
+	clusterInvoker := NewFailbackCluster().Join(directory.NewStaticDirectory([]protocol.Invoker{ivk})).(*failbackClusterInvoker)
+	for i := 0; i < 3; i++ {
+		inv := invocation.NewRPCInvocationWithOptions(invocation.WithMethodName("Notify"),
+			invocation.WithAttachments(map[string]string{constant.FAILBACK_CALLBACK_KEY: "failback_callback_destroy"}))
+		clusterInvoker.Invoke(inv)
+	}
+	assert.Equal(t, int64(3), clusterInvoker.taskList.Len())
+
+	// the pending tasks are abandoned, and their callbacks are notified
+	clusterInvoker.Destroy()
+	assert.Equal(t, int64(0), clusterInvoker.taskList.Len())
+	for i := 0; i < 3; i++ {
+		result := failbackCallbackResult(t, results)
+		assert.EqualError(t, result.Error(), "the failback task is abandoned as the invoker is destroyed")
+	}
+	assert.False(t, clusterInvoker.enqueue(newRetryTimerTask(nil, nil, &invocation.RPCInvocation{}, ivk)))
+
+	// the process goroutine and the workers exit
+	deadline := time.Now().Add(time.Second)
+	for runtime.NumGoroutine() > goroutines && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.True(t, runtime.NumGoroutine() <= goroutines)
+}
+
+func Test_FailbackRetriesDisabled(t *testing.T) {
+	results := make(chan protocol.Result, 1)
+	cluster.SetFailbackCallback("failback_callback_disabled", func(invocation protocol.Invocation, result protocol.Result) {
+		results <- result
+	})
+	defer cluster.SetFailbackCallback("failback_callback_disabled", nil)
+
+	url, _ := common.NewURL(context.TODO(), "dubbo://192.168.1.1:20000/com.ikurento.user.UserProvider?retries=3&methods.Save.retries=0")
+	ivk := &errInvoker{MockInvoker: NewMockInvoker(url, 1), err: perrors.New("error")}
+	clusterInvoker := NewFailbackCluster().Join(directory.NewStaticDirectory([]protocol.Invoker{ivk})).(*failbackClusterInvoker)
+	defer clusterInvoker.Destroy()
+
+	// the method disables the retries
+	inv := invocation.NewRPCInvocationWithOptions(invocation.WithMethodName("Save"),
+		invocation.WithAttachments(map[string]string{constant.FAILBACK_CALLBACK_KEY: "failback_callback_disabled"}))
+	clusterInvoker.Invoke(inv)
+	assert.EqualError(t, failbackCallbackResult(t, results).Error(), "error")
+	assert.Equal(t, int64(0), clusterInvoker.taskList.Len())
+
+	// the others keep the retries of the service
+	clusterInvoker.Invoke(invocation.NewRPCInvocationWithOptions(invocation.WithMethodName("Get")))
+	assert.Equal(t, int64(1), clusterInvoker.taskList.Len())
+
+	// the attachment enables the retries of the method
+	inv = invocation.NewRPCInvocationWithOptions(invocation.WithMethodName("Save"),
+		invocation.WithAttachments(map[string]string{constant.RETRIES_KEY: "1"}))
+	clusterInvoker.Invoke(inv)
+	assert.Equal(t, int64(2), clusterInvoker.taskList.Len())
+}
+
+func Test_FailbackMaxAge(t *testing.T) {
+	results := make(chan protocol.Result, 1)
+	cluster.SetFailbackCallback("failback_callback_max_age", func(invocation protocol.Invocation, result protocol.Result) {
+		results <- result
+	})
+	defer cluster.SetFailbackCallback("failback_callback_max_age", nil)
+
+	var invoked int32
+	url, _ := common.NewURL(context.TODO(), "dubbo://192.168.1.1:20000/com.ikurento.user.UserProvider?retries=1000&failback.retry.period=10&failback.max.age=200")
+	ivk := &delayedInvoker{MockInvoker: NewMockInvoker(url, 1), err: perrors.New("error"), invoked: &invoked}
+	clusterInvoker := NewFailbackCluster().Join(directory.NewStaticDirectory([]protocol.Invoker{ivk})).(*failbackClusterInvoker)
+	defer clusterInvoker.Destroy()
+
+	start := time.Now()
+	inv := invocation.NewRPCInvocationWithOptions(invocation.WithMethodName("Notify"),
+		invocation.WithAttachments(map[string]string{constant.FAILBACK_CALLBACK_KEY: "failback_callback_max_age"}))
+	clusterInvoker.Invoke(inv)
+
+	// the task is abandoned with the last error once its next retry is due after the max age, though it has
+	// retries left
+	result := failbackCallbackResult(t, results)
+	assert.EqualError(t, result.Error(), "error")
+	assert.True(t, time.Since(start) >= 100*time.Millisecond)
+	assert.True(t, time.Since(start) < time.Second)
+	assert.True(t, atomic.LoadInt32(&invoked) > 2)
+	assert.True(t, atomic.LoadInt32(&invoked) < 1000)
+	assert.Equal(t, int64(0), clusterInvoker.taskList.Len())
+}
+
+func Test_FailbackMaxAgeBeforeRetry(t *testing.T) {
+	results := make(chan protocol.Result, 1)
+	cluster.SetFailbackCallback("failback_callback_max_age_due", func(invocation protocol.Invocation, result protocol.Result) {
+		results <- result
+	})
+	defer cluster.SetFailbackCallback("failback_callback_max_age_due", nil)
+
+	var invoked int32
+	url, _ := common.NewURL(context.TODO(), "dubbo://192.168.1.1:20000/com.ikurento.user.UserProvider?failback.retry.period=300&failback.max.age=100")
+	ivk := &delayedInvoker{MockInvoker: NewMockInvoker(url, 1), err: perrors.New("error"), invoked: &invoked}
+	clusterInvoker := NewFailbackCluster().Join(directory.NewStaticDirectory([]protocol.Invoker{ivk})).(*failbackClusterInvoker)
+	defer clusterInvoker.Destroy()
+
+	inv := invocation.NewRPCInvocationWithOptions(invocation.WithMethodName("Notify"),
+		invocation.WithAttachments(map[string]string{constant.FAILBACK_CALLBACK_KEY: "failback_callback_max_age_due"}))
+	clusterInvoker.Invoke(inv)
+
+	// the task is due after the max age, so it's abandoned without a retry
+	result := failbackCallbackResult(t, results)
+	assert.EqualError(t, result.Error(), "the failback task is abandoned as it exceeds the max age")
+	assert.Equal(t, int32(1), atomic.LoadInt32(&invoked))
+}
@@ -17,8 +17,14 @@ limitations under the License.
 
 package cluster_impl
 
+import (
+	perrors "github.com/pkg/errors"
+)
+
 import (
 	"github.com/apache/dubbo-go/cluster"
+	"github.com/apache/dubbo-go/common"
+	"github.com/apache/dubbo-go/common/constant"
 	"github.com/apache/dubbo-go/protocol"
 )
 
@@ -39,7 +45,8 @@ func (invoker *failfastClusterInvoker) Invoke(invocation protocol.Invocation) pr
 		return &protocol.RPCResult{Err: err}
 	}
 
-	loadbalance := getLoadBalance(invokers[0], invocation)
+	url := invokers[0].GetUrl()
+	loadbalance := getLoadBalance(url, invocation)
 
 	err = invoker.checkWhetherDestroyed()
 	if err != nil {
@@ -47,5 +54,31 @@ func (invoker *failfastClusterInvoker) Invoke(invocation protocol.Invocation) pr
 	}
 
 	ivk := invoker.doSelect(loadbalance, invocation, invokers, nil)
-	return ivk.Invoke(invocation)
+	result := invoker.doInvoke(ivk, invocation)
+	if err := result.Error(); err != nil {
+		// the failure isn't retried here, the error tells the callers whether it's worth retrying
+		return &protocol.RPCResult{
+			Err:   protocol.NewRetryableError(err, isFailfastRetryable(url, invocation, err)),
+			Rest:  result.Result(),
+			Attrs: result.Attachments(),
+		}
+	}
+	return result
+}
+
+// isFailfastRetryable classifies the failure: the timeouts are retryable, the business errors of the provider
+// aren't unless their status codes are in the retry.codes, and the others are decided by the retry predicate of the url.
+func isFailfastRetryable(url common.URL, invocation protocol.Invocation, err error) bool {
+	cause := perrors.Cause(err)
+	if timeoutErr, ok := cause.(interface{ Timeout() bool }); ok && timeoutErr.Timeout() {
+		return true
+	}
+	if remoteErr, ok := cause.(*protocol.RemoteError); ok {
+		retryCodes := url.GetMethodParam(invocation.MethodName(), constant.RETRY_CODES_KEY, url.GetParam(constant.RETRY_CODES_KEY, ""))
+		return remoteErr.Code != "" && containsCode(retryCodes, remoteErr.Code)
+	}
+	if _, ok := cause.(protocol.ContextError); ok {
+		return false
+	}
+	return getRetryPredicate(url, invocation).ShouldRetry(err, invocation, 0)
 }
@@ -19,6 +19,7 @@ package cluster_impl
 
 import (
 	"context"
+	"errors"
 	"testing"
 )
 
@@ -95,3 +96,48 @@ func Test_FailfastInvokeFail(t *testing.T) {
 	assert.Equal(t, "error", result.Error().Error())
 	assert.Nil(t, result.Result())
 }
+
+func failfastInvokeError(t *testing.T, err error) error {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	invoker := mock.NewMockInvoker(ctrl)
+	clusterInvoker := registerFailfast(t, invoker)
+	invoker.EXPECT().GetUrl().Return(failfastUrl)
+	invoker.EXPECT().Invoke(gomock.Any()).Return(&protocol.RPCResult{Err: err})
+
+	result := clusterInvoker.Invoke(invocation.NewRPCInvocation("GetUser", nil, nil))
+	assert.Equal(t, err.Error(), result.Error().Error())
+	return result.Error()
+}
+
+func Test_FailfastRetryableTimeout(t *testing.T) {
+	err := failfastInvokeError(t, perrors.WithStack(context.DeadlineExceeded))
+	retryableErr, ok := err.(protocol.RetryableError)
+	assert.True(t, ok)
+	assert.True(t, retryableErr.Retryable())
+	// the upstream clusters honor the annotation, though it's wrapped
+	assert.True(t, isRetryable(failfastUrl, "GetUser", err))
+	assert.Equal(t, context.DeadlineExceeded, perrors.Cause(err))
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+}
+
+func Test_FailfastNotRetryableBusinessError(t *testing.T) {
+	err := failfastInvokeError(t, &protocol.RemoteError{Message: "insufficient balance"})
+	retryableErr, ok := err.(protocol.RetryableError)
+	assert.True(t, ok)
+	assert.False(t, retryableErr.Retryable())
+	assert.False(t, isRetryable(failfastUrl, "GetUser", err))
+	assert.False(t, isRetryable(failfastUrl, "GetUser", perrors.WithMessage(err, "failed")))
+}
+
+func Test_FailfastRetryableBusinessErrorCode(t *testing.T) {
+	url := failfastUrl
+	failfastUrl, _ = common.NewURL(context.TODO(), "dubbo://192.168.1.1:20000/com.ikurento.user.UserProvider?retry.codes=503")
+	defer func() { failfastUrl = url }()
+
+	err := failfastInvokeError(t, &protocol.RemoteError{Code: "503", Message: "busy"})
+	assert.True(t, err.(protocol.RetryableError).Retryable())
+	err = failfastInvokeError(t, &protocol.RemoteError{Code: "400", Message: "bad request"})
+	assert.False(t, err.(protocol.RetryableError).Retryable())
+}
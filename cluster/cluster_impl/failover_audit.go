@@ -0,0 +1,94 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cluster_impl
+
+import (
+	"net"
+	"strings"
+)
+
+import (
+	perrors "github.com/pkg/errors"
+)
+
+import (
+	"github.com/apache/dubbo-go/common"
+	"github.com/apache/dubbo-go/common/constant"
+	"github.com/apache/dubbo-go/common/logger"
+	"github.com/apache/dubbo-go/protocol"
+)
+
+// failoverAudit logs the providers tried by a failover and why each failed, so that the failover path
+// can be reconstructed after an incident. the nil audit logs nothing.
+type failoverAudit struct {
+	logf    func(fmt string, args ...interface{})
+	method  string
+	attempt int
+}
+
+// newFailoverAudit returns the audit at the level of failover.audit of the @url, nil if it's off
+func newFailoverAudit(url common.URL, method string) *failoverAudit {
+	var logf func(fmt string, args ...interface{})
+	switch strings.ToLower(url.GetMethodParam(method, constant.FAILOVER_AUDIT_KEY, url.GetParam(constant.FAILOVER_AUDIT_KEY, ""))) {
+	case "debug":
+		logf = logger.Debugf
+	case "info":
+		logf = logger.Infof
+	case "warn":
+		logf = logger.Warnf
+	default:
+		return nil
+	}
+	return &failoverAudit{logf: logf, method: method}
+}
+
+// record logs the attempt on the @ivk, @err is nil if the attempt succeeded
+func (a *failoverAudit) record(ivk protocol.Invoker, err error) {
+	if a == nil {
+		return
+	}
+	a.attempt++
+	provider := ivk.GetUrl().Key()
+	if err == nil {
+		if a.attempt > 1 {
+			a.logf("failover audit: the method %v succeeded on the provider %v at the attempt %d", a.method, provider, a.attempt)
+		}
+		return
+	}
+	a.logf("failover audit: the method %v failed on the provider %v at the attempt %d, reason: %v, error: %v",
+		a.method, provider, a.attempt, failureReason(err), err)
+}
+
+// failureReason classifies @err into timeout, refused, business error or error
+func failureReason(err error) string {
+	cause := perrors.Cause(err)
+	if netErr, ok := cause.(net.Error); ok && netErr.Timeout() {
+		return "timeout"
+	}
+	if _, ok := cause.(protocol.StatusError); ok {
+		return "business error"
+	}
+	msg := strings.ToLower(err.Error())
+	switch {
+	case strings.Contains(msg, "timeout"):
+		return "timeout"
+	case strings.Contains(msg, "connection refused"):
+		return "refused"
+	}
+	return "error"
+}
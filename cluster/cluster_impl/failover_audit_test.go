@@ -0,0 +1,138 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cluster_impl
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+)
+
+import (
+	perrors "github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+import (
+	"github.com/apache/dubbo-go/cluster/directory"
+	"github.com/apache/dubbo-go/cluster/loadbalance"
+	"github.com/apache/dubbo-go/common"
+	"github.com/apache/dubbo-go/common/constant"
+	"github.com/apache/dubbo-go/common/extension"
+	"github.com/apache/dubbo-go/common/logger"
+	"github.com/apache/dubbo-go/protocol"
+	"github.com/apache/dubbo-go/protocol/invocation"
+)
+
+// auditLogger keeps the info logs
+type auditLogger struct {
+	logger.Logger
+	lock sync.Mutex
+	logs []string
+}
+
+func (l *auditLogger) Infof(format string, args ...interface{}) {
+	l.lock.Lock()
+	l.logs = append(l.logs, fmt.Sprintf(format, args...))
+	l.lock.Unlock()
+}
+
+func (l *auditLogger) auditLogs() []string {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	logs := []string{}
+	for _, log := range l.logs {
+		if strings.HasPrefix(log, "failover audit:") {
+			logs = append(logs, log)
+		}
+	}
+	return logs
+}
+
+type errInvoker struct {
+	*MockInvoker
+	err error
+}
+
+func (ei *errInvoker) Invoke(invocation protocol.Invocation) protocol.Result {
+	return &protocol.RPCResult{Err: ei.err}
+}
+
+func auditInvoke(urlParams url.Values) (protocol.Result, map[string]string) {
+	extension.SetLoadbalance("random", loadbalance.NewRandomLoadBalance)
+	errs := []error{
+		perrors.New("client read timeout"),
+		perrors.New("dial tcp 192.168.1.1:20000: connect: connection refused"),
+		perrors.WithStack(protocol.NewStatusError("INVALID_ORDER", "the order is invalid")),
+	}
+	reasons := []string{"timeout", "refused", "business error"}
+
+	providerReasons := map[string]string{}
+	invokers := []protocol.Invoker{}
+	for i, err := range errs {
+		url, _ := common.NewURL(context.TODO(), fmt.Sprintf("dubbo://192.168.1.%v:20000/com.ikurento.user.UserProvider", i), common.WithParams(urlParams))
+		invokers = append(invokers, &errInvoker{MockInvoker: NewMockInvoker(url, 1), err: err})
+		providerReasons[url.Key()] = reasons[i]
+	}
+	clusterInvoker := NewFailoverCluster().Join(directory.NewStaticDirectory(invokers))
+	return clusterInvoker.Invoke(invocation.NewRPCInvocationWithOptions(invocation.WithMethodName("GetUser"))), providerReasons
+}
+
+func Test_FailoverAudit(t *testing.T) {
+	log := &auditLogger{Logger: logger.GetLogger()}
+	logger.SetLogger(log)
+	defer logger.SetLogger(log.Logger)
+
+	urlParams := url.Values{}
+	urlParams.Set(constant.RETRIES_KEY, "3")
+	urlParams.Set(constant.FAILOVER_AUDIT_KEY, "info")
+	result, providerReasons := auditInvoke(urlParams)
+	assert.Error(t, result.Error())
+
+	logs := log.auditLogs()
+	assert.Len(t, logs, 3)
+	for i, log := range logs {
+		assert.Contains(t, log, fmt.Sprintf("at the attempt %d,", i+1))
+		found := false
+		for provider, reason := range providerReasons {
+			if strings.Contains(log, provider+" ") {
+				assert.Contains(t, log, "reason: "+reason+",")
+				delete(providerReasons, provider)
+				found = true
+			}
+		}
+		assert.True(t, found, log)
+	}
+	// every provider is recorded
+	assert.Empty(t, providerReasons)
+}
+
+func Test_FailoverAuditOff(t *testing.T) {
+	log := &auditLogger{Logger: logger.GetLogger()}
+	logger.SetLogger(log)
+	defer logger.SetLogger(log.Logger)
+
+	urlParams := url.Values{}
+	urlParams.Set(constant.RETRIES_KEY, "3")
+	result, _ := auditInvoke(urlParams)
+	assert.Error(t, result.Error())
+	assert.Empty(t, log.auditLogs())
+}
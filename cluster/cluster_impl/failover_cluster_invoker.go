@@ -17,17 +17,31 @@
 
 package cluster_impl
 
+import (
+	"math/rand"
+	"time"
+)
+
 import (
 	perrors "github.com/pkg/errors"
+	"go.uber.org/atomic"
 )
 
 import (
 	"github.com/apache/dubbo-go/cluster"
+	"github.com/apache/dubbo-go/common"
 	"github.com/apache/dubbo-go/common/constant"
 	"github.com/apache/dubbo-go/common/utils"
 	"github.com/apache/dubbo-go/protocol"
 )
 
+var (
+	// the failover retries in progress across the consumer
+	failoverRetries = atomic.NewInt64(0)
+	// replaceable in test to record the delays of the retries
+	failoverSleep = time.Sleep
+)
+
 type failoverClusterInvoker struct {
 	baseClusterInvoker
 }
@@ -47,18 +61,20 @@ func (invoker *failoverClusterInvoker) Invoke(invocation protocol.Invocation) pr
 		return &protocol.RPCResult{Err: err}
 	}
 
-	loadbalance := getLoadBalance(invokers[0], invocation)
+	loadbalance := getLoadBalance(invokers[0].GetUrl(), invocation)
 
 	methodName := invocation.MethodName()
 	url := invokers[0].GetUrl()
 
-	//get reties
-	retries := url.GetParamInt(constant.RETRIES_KEY, constant.DEFAULT_RETRIES)
-
-	//Get the service method loadbalance config if have
-	if v := url.GetMethodParamInt(methodName, constant.RETRIES_KEY, 0); v != 0 {
-		retries = v
+	// the invocation is tried once at least, the retries <= 1 disable the failover
+	retries := getRetries(url, invocation, constant.DEFAULT_RETRIES)
+	if retries < 1 {
+		retries = 1
 	}
+	attachTimeout(url, invocation)
+	retryPredicate := getRetryPredicate(url, invocation)
+	maxRetries := url.GetParamInt(constant.FAILOVER_CONCURRENCY_MAX_KEY, 0)
+	audit := newFailoverAudit(url, methodName)
 	invoked := []protocol.Invoker{}
 	providers := []string{}
 	var result protocol.Result
@@ -66,6 +82,9 @@ func (invoker *failoverClusterInvoker) Invoke(invocation protocol.Invocation) pr
 		//Reselect before retry to avoid a change of candidate `invokers`.
 		//NOTE: if `invokers` changed, then `invoked` also lose accuracy.
 		if i > 0 {
+			if delay := failoverDelay(url, methodName); delay > 0 {
+				failoverSleep(delay)
+			}
 			err := invoker.checkWhetherDestroyed()
 			if err != nil {
 				return &protocol.RPCResult{Err: err}
@@ -75,21 +94,67 @@ func (invoker *failoverClusterInvoker) Invoke(invocation protocol.Invocation) pr
 			if err != nil {
 				return &protocol.RPCResult{Err: err}
 			}
+			if !acquireFailoverRetry(maxRetries) {
+				invoker.errorLog.warnf(invoker.GetUrl(), methodName, "the failover retries in progress reach the max %v, the method %v fails fast", maxRetries, methodName)
+				break
+			}
 		}
 		ivk := invoker.doSelect(loadbalance, invocation, invokers, invoked)
 		invoked = append(invoked, ivk)
 		//DO INVOKE
-		result = ivk.Invoke(invocation)
+		result = invoker.doInvoke(ivk, invocation)
+		if i > 0 {
+			releaseFailoverRetry(maxRetries)
+		}
+		audit.record(ivk, result.Error())
 		if result.Error() != nil {
 			providers = append(providers, ivk.GetUrl().Key())
+			if !retryPredicate.ShouldRetry(result.Error(), invocation, int(i)+2) {
+				break
+			}
 			continue
 		} else {
 			return result
 		}
 	}
 	ip, _ := utils.GetLocalIP()
-	return &protocol.RPCResult{Err: perrors.Errorf("Failed to invoke the method %v in the service %v. Tried %v times of "+
-		"the providers %v (%v/%v)from the registry %v on the consumer %v using the dubbo version %v. Last error is %v.",
-		methodName, invoker.GetUrl().Service(), retries, providers, len(providers), len(invokers), invoker.directory.GetUrl(), ip, constant.Version, result.Error().Error(),
+	// the last error is kept as the cause, e.g. for the chain cluster to tell whether it's retryable
+	return &protocol.RPCResult{Err: perrors.WithMessagef(result.Error(), "Failed to invoke the method %v in the service %v. Tried %v times of "+
+		"the providers %v (%v/%v)from the registry %v on the consumer %v using the dubbo version %v. Last error is",
+		methodName, invoker.GetUrl().Service(), len(invoked), providers, len(providers), len(invokers), invoker.directory.GetUrl(), ip, constant.Version,
 	)}
 }
+
+// failoverDelay is the delay before a retry, failover.delay plus a random jitter in [0, failover.delay.jitter]
+func failoverDelay(url common.URL, methodName string) time.Duration {
+	delay := url.GetMethodParamInt64(methodName, constant.FAILOVER_DELAY_KEY, 0)
+	if delay <= 0 {
+		return 0
+	}
+	if jitter := url.GetMethodParamInt64(methodName, constant.FAILOVER_DELAY_JITTER_KEY, 0); jitter > 0 {
+		delay += rand.Int63n(jitter + 1)
+	}
+	return time.Duration(delay) * time.Millisecond
+}
+
+// acquireFailoverRetry takes a slot of the failover retries in progress, false is returned if they reach @max
+func acquireFailoverRetry(max int64) bool {
+	if max <= 0 {
+		return true
+	}
+	for {
+		n := failoverRetries.Load()
+		if n >= max {
+			return false
+		}
+		if failoverRetries.CAS(n, n+1) {
+			return true
+		}
+	}
+}
+
+func releaseFailoverRetry(max int64) {
+	if max > 0 {
+		failoverRetries.Dec()
+	}
+}
@@ -21,14 +21,18 @@ import (
 	"context"
 	"fmt"
 	"net/url"
+	"sync"
 	"testing"
+	"time"
 )
 import (
 	perrors "github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
+	"go.uber.org/atomic"
 )
 
 import (
+	"github.com/apache/dubbo-go/cluster"
 	"github.com/apache/dubbo-go/cluster/directory"
 	"github.com/apache/dubbo-go/cluster/loadbalance"
 	"github.com/apache/dubbo-go/common"
@@ -78,15 +82,15 @@ type rest struct {
 }
 
 func (bi *MockInvoker) Invoke(invocation protocol.Invocation) protocol.Result {
-	count++
+	tried := int(count.Inc())
 	var success bool
 	var err error = nil
-	if count >= bi.successCount {
+	if tried >= bi.successCount {
 		success = true
 	} else {
 		err = perrors.New("error")
 	}
-	result := &protocol.RPCResult{Err: err, Rest: rest{tried: count, success: success}}
+	result := &protocol.RPCResult{Err: err, Rest: rest{tried: tried, success: success}}
 
 	return result
 }
@@ -97,7 +101,8 @@ func (bi *MockInvoker) Destroy() {
 	bi.available = false
 }
 
-var count int
+// the invocations of the mock invokers, the forked ones are concurrent
+var count atomic.Int32
 
 func normalInvoke(t *testing.T, successCount int, urlParam url.Values, invocations ...*invocation.RPCInvocation) protocol.Result {
 	extension.SetLoadbalance("random", loadbalance.NewRandomLoadBalance)
@@ -120,14 +125,14 @@ func Test_FailoverInvokeSuccess(t *testing.T) {
 	urlParams := url.Values{}
 	result := normalInvoke(t, 2, urlParams)
 	assert.NoError(t, result.Error())
-	count = 0
+	count.Store(0)
 }
 
 func Test_FailoverInvokeFail(t *testing.T) {
 	urlParams := url.Values{}
 	result := normalInvoke(t, 3, urlParams)
 	assert.Errorf(t, result.Error(), "error")
-	count = 0
+	count.Store(0)
 }
 
 func Test_FailoverInvoke1(t *testing.T) {
@@ -135,7 +140,7 @@ func Test_FailoverInvoke1(t *testing.T) {
 	urlParams.Set(constant.RETRIES_KEY, "3")
 	result := normalInvoke(t, 3, urlParams)
 	assert.NoError(t, result.Error())
-	count = 0
+	count.Store(0)
 }
 
 func Test_FailoverInvoke2(t *testing.T) {
@@ -146,7 +151,7 @@ func Test_FailoverInvoke2(t *testing.T) {
 	ivc := invocation.NewRPCInvocationWithOptions(invocation.WithMethodName("test"))
 	result := normalInvoke(t, 3, urlParams, ivc)
 	assert.NoError(t, result.Error())
-	count = 0
+	count.Store(0)
 }
 
 func Test_FailoverDestroy(t *testing.T) {
@@ -164,8 +169,259 @@ func Test_FailoverDestroy(t *testing.T) {
 	assert.Equal(t, true, clusterInvoker.IsAvailable())
 	result := clusterInvoker.Invoke(&invocation.RPCInvocation{})
 	assert.NoError(t, result.Error())
-	count = 0
+	count.Store(0)
 	clusterInvoker.Destroy()
 	assert.Equal(t, false, clusterInvoker.IsAvailable())
 
 }
+
+func statusCodeInvoke(t *testing.T, code string, urlParam url.Values) (protocol.Result, []int) {
+	extension.SetLoadbalance("random", loadbalance.NewRandomLoadBalance)
+	failoverCluster := NewFailoverCluster()
+
+	called := make([]int, 2)
+	invokers := []protocol.Invoker{}
+	for i := 0; i < 2; i++ {
+		url, _ := common.NewURL(context.TODO(), fmt.Sprintf("jsonrpc://192.168.1.%v:20000/com.ikurento.user.UserProvider", i), common.WithParams(urlParam))
+		invoker := NewMockInvoker(url, 1)
+		invokers = append(invokers, &statusInvoker{MockInvoker: invoker, code: code, called: &called[i]})
+	}
+
+	staticDir := directory.NewStaticDirectory(invokers)
+	clusterInvoker := failoverCluster.Join(staticDir)
+	return clusterInvoker.Invoke(&invocation.RPCInvocation{}), called
+}
+
+type statusInvoker struct {
+	*MockInvoker
+	code   string
+	called *int
+}
+
+func (si *statusInvoker) Invoke(invocation protocol.Invocation) protocol.Result {
+	*si.called++
+	return &protocol.RPCResult{Err: perrors.WithStack(protocol.NewStatusError(si.code, "error"))}
+}
+
+func Test_FailoverRetryStatusCode(t *testing.T) {
+	urlParams := url.Values{}
+	urlParams.Set(constant.RETRY_CODES_KEY, "503,UNAVAILABLE")
+	urlParams.Set(constant.TERMINAL_CODES_KEY, "400,INVALID_ARGUMENT")
+
+	result, called := statusCodeInvoke(t, "503", urlParams)
+	assert.Error(t, result.Error())
+	// 503 is retried on the other provider
+	assert.Equal(t, []int{1, 1}, called)
+
+	result, called = statusCodeInvoke(t, "400", urlParams)
+	assert.Error(t, result.Error())
+	// 400 is terminal
+	assert.Equal(t, 1, called[0]+called[1])
+
+	// the code is neither retry code nor terminal code
+	result, called = statusCodeInvoke(t, "500", urlParams)
+	assert.Error(t, result.Error())
+	assert.Equal(t, 1, called[0]+called[1])
+}
+
+func Test_FailoverRetryStatusCodeDefault(t *testing.T) {
+	// without config, all the status codes are retryable
+	result, called := statusCodeInvoke(t, "400", url.Values{})
+	assert.Error(t, result.Error())
+	assert.Equal(t, []int{1, 1}, called)
+}
+
+// evenRetryPredicate only retries the even-numbered attempts
+type evenRetryPredicate struct {
+	attempts []int
+}
+
+func (p *evenRetryPredicate) ShouldRetry(err error, invocation protocol.Invocation, attempt int) bool {
+	p.attempts = append(p.attempts, attempt)
+	return attempt%2 == 0
+}
+
+func Test_FailoverRetryPredicate(t *testing.T) {
+	predicate := &evenRetryPredicate{}
+	extension.SetRetryPredicate("even", func(url common.URL) cluster.RetryPredicate {
+		return predicate
+	})
+	extension.SetLoadbalance("random", loadbalance.NewRandomLoadBalance)
+
+	urlParams := url.Values{}
+	urlParams.Set(constant.RETRIES_KEY, "5")
+	urlParams.Set(constant.RETRY_PREDICATE_KEY, "even")
+
+	called := 0
+	invokers := []protocol.Invoker{}
+	for i := 0; i < 10; i++ {
+		url, _ := common.NewURL(context.TODO(), fmt.Sprintf("dubbo://192.168.1.%v:20000/com.ikurento.user.UserProvider", i), common.WithParams(urlParams))
+		invokers = append(invokers, &statusInvoker{MockInvoker: NewMockInvoker(url, 1), code: "500", called: &called})
+	}
+	clusterInvoker := NewFailoverCluster().Join(directory.NewStaticDirectory(invokers))
+
+	result := clusterInvoker.Invoke(&invocation.RPCInvocation{})
+	assert.Error(t, result.Error())
+	// the attempt 2 is retried, the attempt 3 is refused
+	assert.Equal(t, 2, called)
+	assert.Equal(t, []int{2, 3}, predicate.attempts)
+}
+
+// slowFailInvoker fails after a while, and records the peak of the failover retries in progress
+type slowFailInvoker struct {
+	*MockInvoker
+	calls *atomic.Int64
+	peak  *atomic.Int64
+}
+
+func (si *slowFailInvoker) Invoke(invocation protocol.Invocation) protocol.Result {
+	si.calls.Inc()
+	for {
+		peak, n := si.peak.Load(), failoverRetries.Load()
+		if n <= peak || si.peak.CAS(peak, n) {
+			break
+		}
+	}
+	time.Sleep(50 * time.Millisecond)
+	return &protocol.RPCResult{Err: perrors.New("error")}
+}
+
+func Test_FailoverConcurrencyMax(t *testing.T) {
+	extension.SetLoadbalance("random", loadbalance.NewRandomLoadBalance)
+
+	urlParams := url.Values{}
+	urlParams.Set(constant.RETRIES_KEY, "3")
+	urlParams.Set(constant.FAILOVER_CONCURRENCY_MAX_KEY, "2")
+
+	calls, peak := atomic.NewInt64(0), atomic.NewInt64(0)
+	invokers := []protocol.Invoker{}
+	for i := 0; i < 10; i++ {
+		url, _ := common.NewURL(context.TODO(), fmt.Sprintf("dubbo://192.168.1.%v:20000/com.ikurento.user.UserProvider", i), common.WithParams(urlParams))
+		invokers = append(invokers, &slowFailInvoker{MockInvoker: NewMockInvoker(url, 1), calls: calls, peak: peak})
+	}
+	clusterInvoker := NewFailoverCluster().Join(directory.NewStaticDirectory(invokers))
+
+	const callNum = 20
+	var wg sync.WaitGroup
+	wg.Add(callNum)
+	for i := 0; i < callNum; i++ {
+		go func() {
+			defer wg.Done()
+			assert.Error(t, clusterInvoker.Invoke(&invocation.RPCInvocation{}).Error())
+		}()
+	}
+	wg.Wait()
+
+	// the retries are capped across the calls, the others fail fast after the first attempt
+	assert.True(t, peak.Load() > 0)
+	assert.True(t, peak.Load() <= 2)
+	assert.True(t, calls.Load() < callNum*3)
+	assert.Equal(t, int64(0), failoverRetries.Load())
+}
+
+func Test_FailoverDelayJitter(t *testing.T) {
+	var delays []time.Duration
+	failoverSleep = func(d time.Duration) {
+		delays = append(delays, d)
+	}
+	defer func() {
+		failoverSleep = time.Sleep
+	}()
+
+	urlParams := url.Values{}
+	// every one of the 10 providers is tried once
+	urlParams.Set(constant.RETRIES_KEY, "10")
+	urlParams.Set(constant.FAILOVER_DELAY_KEY, "10")
+	urlParams.Set(constant.FAILOVER_DELAY_JITTER_KEY, "20")
+	result := normalInvoke(t, 40, urlParams)
+	assert.Error(t, result.Error())
+	count.Store(0)
+
+	assert.Equal(t, 9, len(delays))
+	varied := false
+	for _, d := range delays {
+		assert.True(t, d >= 10*time.Millisecond && d <= 30*time.Millisecond, "delay %v out of range", d)
+		varied = varied || d != delays[0]
+	}
+	assert.True(t, varied)
+}
+
+func Test_FailoverNoDelay(t *testing.T) {
+	sleeps := 0
+	failoverSleep = func(time.Duration) {
+		sleeps++
+	}
+	defer func() {
+		failoverSleep = time.Sleep
+	}()
+
+	urlParams := url.Values{}
+	urlParams.Set(constant.RETRIES_KEY, "3")
+	urlParams.Set(constant.FAILOVER_DELAY_JITTER_KEY, "20")
+	result := normalInvoke(t, 5, urlParams)
+	assert.Error(t, result.Error())
+	count.Store(0)
+	assert.Equal(t, 0, sleeps)
+}
+
+func Test_FailoverRetriesPrecedence(t *testing.T) {
+	invokeTimes := func(urlParams url.Values, attachments map[string]string) int {
+		defer func() {
+			count.Store(0)
+		}()
+		ivc := invocation.NewRPCInvocationWithOptions(invocation.WithMethodName("test"), invocation.WithAttachments(attachments))
+		result := normalInvoke(t, 100, urlParams, ivc)
+		assert.Error(t, result.Error())
+		return int(count.Load())
+	}
+
+	// the constant default
+	urlParams := url.Values{}
+	assert.Equal(t, int(constant.DEFAULT_RETRIES), invokeTimes(urlParams, nil))
+
+	// the service config
+	urlParams.Set(constant.RETRIES_KEY, "4")
+	assert.Equal(t, 4, invokeTimes(urlParams, nil))
+
+	// the method config, 0 disables the retries
+	urlParams.Set("methods.test."+constant.RETRIES_KEY, "3")
+	assert.Equal(t, 3, invokeTimes(urlParams, nil))
+	urlParams.Set("methods.test."+constant.RETRIES_KEY, "0")
+	assert.Equal(t, 1, invokeTimes(urlParams, nil))
+
+	// the invocation attachment
+	assert.Equal(t, 5, invokeTimes(urlParams, map[string]string{constant.RETRIES_KEY: "5"}))
+}
+
+func Test_GetRetries(t *testing.T) {
+	inv := invocation.NewRPCInvocationWithOptions(invocation.WithMethodName("save"))
+	u, _ := common.NewURL(context.TODO(), "dubbo://192.168.1.1:20000/com.ikurento.user.UserProvider")
+	assert.Equal(t, int64(2), getRetries(u, inv, 2))
+
+	u.AddParam(constant.RETRIES_KEY, "3")
+	assert.Equal(t, int64(3), getRetries(u, inv, 2))
+	// the other methods keep the service config
+	u.AddParam("methods.save."+constant.RETRIES_KEY, "0")
+	assert.Equal(t, int64(0), getRetries(u, inv, 2))
+	assert.Equal(t, int64(3), getRetries(u, invocation.NewRPCInvocationWithOptions(invocation.WithMethodName("get")), 2))
+
+	inv.SetAttachments(constant.RETRIES_KEY, "1")
+	assert.Equal(t, int64(1), getRetries(u, inv, 2))
+}
+
+func Test_AttachTimeout(t *testing.T) {
+	u, _ := common.NewURL(context.TODO(), "dubbo://192.168.1.1:20000/com.ikurento.user.UserProvider?methods.save.timeout=500")
+	inv := invocation.NewRPCInvocationWithOptions(invocation.WithMethodName("get"))
+	attachTimeout(u, inv)
+	assert.Equal(t, "", inv.AttachmentsByKey(constant.TIMEOUT_KEY, ""))
+
+	inv = invocation.NewRPCInvocationWithOptions(invocation.WithMethodName("save"))
+	attachTimeout(u, inv)
+	assert.Equal(t, "500", inv.AttachmentsByKey(constant.TIMEOUT_KEY, ""))
+
+	// the timeout of the invocation has priority
+	inv = invocation.NewRPCInvocationWithOptions(invocation.WithMethodName("save"),
+		invocation.WithAttachments(map[string]string{constant.TIMEOUT_KEY: "100"}))
+	attachTimeout(u, inv)
+	assert.Equal(t, "100", inv.AttachmentsByKey(constant.TIMEOUT_KEY, ""))
+}
@@ -19,9 +19,6 @@ package cluster_impl
 
 import (
 	"github.com/apache/dubbo-go/cluster"
-	"github.com/apache/dubbo-go/common/constant"
-	"github.com/apache/dubbo-go/common/extension"
-	"github.com/apache/dubbo-go/common/logger"
 	"github.com/apache/dubbo-go/protocol"
 )
 
@@ -50,25 +47,17 @@ func (invoker *failsafeClusterInvoker) Invoke(invocation protocol.Invocation) pr
 		return &protocol.RPCResult{}
 	}
 
-	url := invokers[0].GetUrl()
-	methodName := invocation.MethodName()
-	//Get the service loadbalance config
-	lb := url.GetParam(constant.LOADBALANCE_KEY, constant.DEFAULT_LOADBALANCE)
-	//Get the service method loadbalance config if have
-	if v := url.GetMethodParam(methodName, constant.LOADBALANCE_KEY, ""); v != "" {
-		lb = v
-	}
-	loadbalance := extension.GetLoadbalance(lb)
+	loadbalance := getLoadBalance(invokers[0].GetUrl(), invocation)
 
 	invoked := make([]protocol.Invoker, 0)
 	var result protocol.Result
 
 	ivk := invoker.doSelect(loadbalance, invocation, invokers, invoked)
 	//DO INVOKE
-	result = ivk.Invoke(invocation)
+	result = invoker.doInvoke(ivk, invocation)
 	if result.Error() != nil {
 		// ignore
-		logger.Errorf("Failsafe ignore exception: %v.\n", result.Error().Error())
+		invoker.errorLog.errorf(invoker.GetUrl(), invocation.MethodName(), "Failsafe ignore exception: %v.\n", result.Error().Error())
 		return &protocol.RPCResult{}
 	}
 	return result
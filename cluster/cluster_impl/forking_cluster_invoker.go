@@ -63,7 +63,7 @@ func (invoker *forkingClusterInvoker) Invoke(invocation protocol.Invocation) pro
 		selected = invokers
 	} else {
 		selected = make([]protocol.Invoker, 0)
-		loadbalance := getLoadBalance(invokers[0], invocation)
+		loadbalance := getLoadBalance(invokers[0].GetUrl(), invocation)
 		for i := 0; i < forks; i++ {
 			ivk := invoker.doSelect(loadbalance, invocation, invokers, selected)
 			if ivk != nil {
@@ -72,28 +72,142 @@ func (invoker *forkingClusterInvoker) Invoke(invocation protocol.Invocation) pro
 		}
 	}
 
-	resultQ := queue.New(1)
+	if wait := invoker.GetUrl().GetParamInt(constant.FORKS_WAIT_KEY, 0); wait > 0 {
+		return invoker.progressiveInvoke(invocation, invokers, selected,
+			time.Millisecond*time.Duration(wait), time.Millisecond*time.Duration(timeouts))
+	}
+
+	resultQ := queue.New(int64(len(selected)))
+	defer resultQ.Dispose()
 	for _, ivk := range selected {
-		go func(k protocol.Invoker) {
-			result := k.Invoke(invocation)
-			err := resultQ.Put(result)
-			if err != nil {
-				logger.Errorf("resultQ put failed with exception: %v.\n", err)
-			}
-		}(ivk)
+		invoker.fork(resultQ, ivk, invocation)
 	}
+	return invoker.firstSuccess(resultQ, selected, time.Millisecond*time.Duration(timeouts))
+}
 
-	rsps, err := resultQ.Poll(1, time.Millisecond*time.Duration(timeouts))
-	if err != nil {
-		return &protocol.RPCResult{
-			Err: errors.New(fmt.Sprintf("failed to forking invoke provider %v, but no luck to perform the invocation. Last error is: %s", selected, err.Error()))}
+// fork invokes @ivk in a goroutine and puts its result to @resultQ. The result is discarded if the queue is disposed,
+// i.e. the forking invocation has returned, so the slow forks exit once their invocations return.
+func (invoker *forkingClusterInvoker) fork(resultQ *queue.Queue, ivk protocol.Invoker, invocation protocol.Invocation) {
+	go func() {
+		result := invoker.doInvoke(ivk, invocation)
+		if err := resultQ.Put(result); err != nil && err != queue.ErrDisposed {
+			logger.Errorf("resultQ put failed with exception: %v.\n", err)
+		}
+	}()
+}
+
+// firstSuccess returns the first successful result of the @selected forks, or the last failure if all of them fail.
+// An error is returned if none of them returns in the @timeout.
+func (invoker *forkingClusterInvoker) firstSuccess(resultQ *queue.Queue, selected []protocol.Invoker, timeout time.Duration) protocol.Result {
+	var lastResult protocol.Result
+	deadline := time.Now().Add(timeout)
+	for failed := 0; failed < len(selected); failed++ {
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			break
+		}
+		rsps, err := resultQ.Poll(1, remaining)
+		if err == queue.ErrTimeout {
+			break
+		}
+		if err != nil {
+			return &protocol.RPCResult{
+				Err: errors.New(fmt.Sprintf("failed to forking invoke provider %v, but no luck to perform the invocation. Last error is: %s", selected, err.Error()))}
+		}
+		if len(rsps) == 0 {
+			return &protocol.RPCResult{Err: errors.New(fmt.Sprintf("failed to forking invoke provider %v, but no resp", selected))}
+		}
+		result, ok := rsps[0].(protocol.Result)
+		if !ok {
+			return &protocol.RPCResult{Err: errors.New(fmt.Sprintf("failed to forking invoke provider %v, but not legal resp", selected))}
+		}
+		if result.Error() == nil {
+			return result
+		}
+		lastResult = result
 	}
-	if len(rsps) == 0 {
-		return &protocol.RPCResult{Err: errors.New(fmt.Sprintf("failed to forking invoke provider %v, but no resp", selected))}
+
+	if lastResult != nil {
+		return lastResult
+	}
+	return &protocol.RPCResult{
+		Err: errors.New(fmt.Sprintf("failed to forking invoke provider %v, but no luck to perform the invocation. Last error is: %s", selected, queue.ErrTimeout.Error()))}
+}
+
+// progressiveInvoke spawns one more fork every @wait until a fork succeeds, at most forks.max providers are invoked.
+// The first successful result is returned, or the last failure if all the forks fail.
+func (invoker *forkingClusterInvoker) progressiveInvoke(invocation protocol.Invocation, invokers []protocol.Invoker,
+	selected []protocol.Invoker, wait time.Duration, timeout time.Duration) protocol.Result {
+
+	maxForks := int(invoker.GetUrl().GetParamInt(constant.FORKS_MAX_KEY, int64(len(invokers))))
+	if maxForks > len(invokers) {
+		maxForks = len(invokers)
 	}
-	result, ok := rsps[0].(protocol.Result)
-	if !ok {
-		return &protocol.RPCResult{Err: errors.New(fmt.Sprintf("failed to forking invoke provider %v, but not legal resp", selected))}
+	loadbalance := getLoadBalance(invokers[0].GetUrl(), invocation)
+
+	resultQ := queue.New(int64(len(invokers)))
+	defer resultQ.Dispose()
+	// spawn one more fork, returns false if there is no provider left
+	forkMore := func() bool {
+		if len(selected) >= maxForks {
+			return false
+		}
+		ivk := invoker.doSelect(loadbalance, invocation, invokers, selected)
+		if ivk == nil || isInvoked(ivk, selected) {
+			return false
+		}
+		selected = append(selected, ivk)
+		invoker.fork(resultQ, ivk, invocation)
+		return true
+	}
+	for _, ivk := range selected {
+		invoker.fork(resultQ, ivk, invocation)
+	}
+
+	var lastResult protocol.Result
+	failed := 0
+	deadline := time.Now().Add(timeout)
+	for {
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			break
+		}
+		pollTimeout := remaining
+		if len(selected) < maxForks && wait < pollTimeout {
+			pollTimeout = wait
+		}
+
+		rsps, err := resultQ.Poll(1, pollTimeout)
+		if err == queue.ErrTimeout {
+			// no fork succeeds in the wait window
+			forkMore()
+			continue
+		}
+		if err != nil {
+			return &protocol.RPCResult{
+				Err: errors.New(fmt.Sprintf("failed to forking invoke provider %v, but no luck to perform the invocation. Last error is: %s", selected, err.Error()))}
+		}
+		if len(rsps) == 0 {
+			return &protocol.RPCResult{Err: errors.New(fmt.Sprintf("failed to forking invoke provider %v, but no resp", selected))}
+		}
+		result, ok := rsps[0].(protocol.Result)
+		if !ok {
+			return &protocol.RPCResult{Err: errors.New(fmt.Sprintf("failed to forking invoke provider %v, but not legal resp", selected))}
+		}
+		if result.Error() == nil {
+			return result
+		}
+		lastResult = result
+		failed++
+		// all the spawned forks failed
+		if failed == len(selected) && !forkMore() {
+			return lastResult
+		}
+	}
+
+	if lastResult != nil {
+		return lastResult
 	}
-	return result
+	return &protocol.RPCResult{
+		Err: errors.New(fmt.Sprintf("failed to forking invoke provider %v, but no luck to perform the invocation. Last error is: %s", selected, queue.ErrTimeout.Error()))}
 }
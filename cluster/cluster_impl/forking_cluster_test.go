@@ -19,14 +19,19 @@ package cluster_impl
 
 import (
 	"context"
+	"fmt"
+	"net/url"
+	"runtime"
 	"strconv"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 )
 
 import (
 	"github.com/golang/mock/gomock"
+	perrors "github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -41,11 +46,13 @@ import (
 	"github.com/apache/dubbo-go/protocol/mock"
 )
 
-var (
-	forkingUrl, _ = common.NewURL(context.TODO(), "dubbo://192.168.1.1:20000/com.ikurento.user.UserProvider")
-)
+// newForkingUrl returns the url of a test, the forked invocations of the previous test may still read theirs
+func newForkingUrl() common.URL {
+	forkingUrl, _ := common.NewURL(context.TODO(), "dubbo://192.168.1.1:20000/com.ikurento.user.UserProvider")
+	return forkingUrl
+}
 
-func registerForking(t *testing.T, mockInvokers ...*mock.MockInvoker) protocol.Invoker {
+func registerForking(t *testing.T, forkingUrl common.URL, mockInvokers ...*mock.MockInvoker) protocol.Invoker {
 	extension.SetLoadbalance(loadbalance.RoundRobin, loadbalance.NewRoundRobinLoadBalance)
 
 	invokers := []protocol.Invoker{}
@@ -69,6 +76,7 @@ func Test_ForkingInvokeSuccess(t *testing.T) {
 	invokers := make([]*mock.MockInvoker, 0)
 
 	mockResult := &protocol.RPCResult{Rest: rest{tried: 0, success: true}}
+	forkingUrl := newForkingUrl()
 	forkingUrl.AddParam(constant.FORKS_KEY, strconv.Itoa(3))
 	//forkingUrl.AddParam(constant.TIMEOUT_KEY, strconv.Itoa(constant.DEFAULT_TIMEOUT))
 
@@ -85,7 +93,7 @@ func Test_ForkingInvokeSuccess(t *testing.T) {
 			})
 	}
 
-	clusterInvoker := registerForking(t, invokers...)
+	clusterInvoker := registerForking(t, forkingUrl, invokers...)
 
 	result := clusterInvoker.Invoke(&invocation.RPCInvocation{})
 	assert.Equal(t, mockResult, result)
@@ -99,6 +107,7 @@ func Test_ForkingInvokeTimeout(t *testing.T) {
 	invokers := make([]*mock.MockInvoker, 0)
 
 	mockResult := &protocol.RPCResult{Rest: rest{tried: 0, success: true}}
+	forkingUrl := newForkingUrl()
 	forkingUrl.AddParam(constant.FORKS_KEY, strconv.Itoa(3))
 
 	var wg sync.WaitGroup
@@ -115,7 +124,7 @@ func Test_ForkingInvokeTimeout(t *testing.T) {
 			})
 	}
 
-	clusterInvoker := registerForking(t, invokers...)
+	clusterInvoker := registerForking(t, forkingUrl, invokers...)
 
 	result := clusterInvoker.Invoke(&invocation.RPCInvocation{})
 	assert.NotNil(t, result)
@@ -130,6 +139,7 @@ func Test_ForkingInvokeHalfTimeout(t *testing.T) {
 	invokers := make([]*mock.MockInvoker, 0)
 
 	mockResult := &protocol.RPCResult{Rest: rest{tried: 0, success: true}}
+	forkingUrl := newForkingUrl()
 	forkingUrl.AddParam(constant.FORKS_KEY, strconv.Itoa(3))
 
 	var wg sync.WaitGroup
@@ -154,9 +164,142 @@ func Test_ForkingInvokeHalfTimeout(t *testing.T) {
 		}
 	}
 
-	clusterInvoker := registerForking(t, invokers...)
+	clusterInvoker := registerForking(t, forkingUrl, invokers...)
 
 	result := clusterInvoker.Invoke(&invocation.RPCInvocation{})
 	assert.Equal(t, mockResult, result)
 	wg.Wait()
 }
+
+type delayedInvoker struct {
+	*MockInvoker
+	delay   time.Duration
+	err     error
+	invoked *int32
+}
+
+func (ivk *delayedInvoker) Invoke(invocation protocol.Invocation) protocol.Result {
+	atomic.AddInt32(ivk.invoked, 1)
+	time.Sleep(ivk.delay)
+	return &protocol.RPCResult{Err: ivk.err, Rest: ivk.GetUrl().Location}
+}
+
+func progressiveForking(delay time.Duration, err error, invoked *int32) protocol.Invoker {
+	extension.SetLoadbalance("random", loadbalance.NewRandomLoadBalance)
+
+	urlParams := url.Values{}
+	urlParams.Set(constant.FORKS_KEY, "1")
+	urlParams.Set(constant.FORKS_WAIT_KEY, "100")
+	urlParams.Set(constant.FORKS_MAX_KEY, "2")
+	urlParams.Set(constant.TIMEOUT_KEY, "3000")
+	invokers := []protocol.Invoker{}
+	for i := 0; i < 3; i++ {
+		url, _ := common.NewURL(context.TODO(), fmt.Sprintf("dubbo://192.168.1.%v:20000/com.ikurento.user.UserProvider", i), common.WithParams(urlParams))
+		invokers = append(invokers, &delayedInvoker{MockInvoker: NewMockInvoker(url, 1), delay: delay, err: err, invoked: invoked})
+	}
+	return NewForkingCluster().Join(directory.NewStaticDirectory(invokers))
+}
+
+func Test_ForkingProgressiveSlowFork(t *testing.T) {
+	var invoked int32
+	clusterInvoker := progressiveForking(500*time.Millisecond, nil, &invoked)
+
+	start := time.Now()
+	result := clusterInvoker.Invoke(&invocation.RPCInvocation{})
+	assert.NoError(t, result.Error())
+	assert.NotNil(t, result.Result())
+	// one more fork is spawned after the wait window, and capped by forks.max
+	assert.Equal(t, int32(2), atomic.LoadInt32(&invoked))
+	assert.True(t, time.Since(start) < time.Second)
+}
+
+func Test_ForkingProgressiveFastFork(t *testing.T) {
+	var invoked int32
+	clusterInvoker := progressiveForking(10*time.Millisecond, nil, &invoked)
+
+	result := clusterInvoker.Invoke(&invocation.RPCInvocation{})
+	assert.NoError(t, result.Error())
+	time.Sleep(200 * time.Millisecond)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&invoked))
+}
+
+func Test_ForkingProgressiveAllFail(t *testing.T) {
+	var invoked int32
+	clusterInvoker := progressiveForking(10*time.Millisecond, perrors.New("error"), &invoked)
+
+	result := clusterInvoker.Invoke(&invocation.RPCInvocation{})
+	assert.EqualError(t, result.Error(), "error")
+	// the failed fork is replaced at once, up to forks.max
+	assert.Equal(t, int32(2), atomic.LoadInt32(&invoked))
+}
+
+// forkingInvokers are delayed by the @delays, the ones of the negative delays fail
+func forkingInvokers(forks int, invoked *int32, delays ...time.Duration) protocol.Invoker {
+	extension.SetLoadbalance("random", loadbalance.NewRandomLoadBalance)
+
+	urlParams := url.Values{}
+	urlParams.Set(constant.FORKS_KEY, strconv.Itoa(forks))
+	urlParams.Set(constant.TIMEOUT_KEY, "3000")
+	invokers := []protocol.Invoker{}
+	for i, delay := range delays {
+		url, _ := common.NewURL(context.TODO(), fmt.Sprintf("dubbo://192.168.1.%v:20000/com.ikurento.user.UserProvider", i), common.WithParams(urlParams))
+		ivk := &delayedInvoker{MockInvoker: NewMockInvoker(url, 1), delay: delay, invoked: invoked}
+		if delay < 0 {
+			ivk.delay = -delay
+			ivk.err = perrors.Errorf("error %v", i)
+		}
+		invokers = append(invokers, ivk)
+	}
+	return NewForkingCluster().Join(directory.NewStaticDirectory(invokers))
+}
+
+func Test_ForkingFirstSuccess(t *testing.T) {
+	var invoked int32
+	// the fast one fails, the slow one is the loser
+	clusterInvoker := forkingInvokers(3, &invoked, -10*time.Millisecond, 100*time.Millisecond, time.Second)
+
+	start := time.Now()
+	result := clusterInvoker.Invoke(&invocation.RPCInvocation{})
+	assert.NoError(t, result.Error())
+	assert.Equal(t, "192.168.1.1:20000", result.Result())
+	assert.True(t, time.Since(start) < 500*time.Millisecond)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&invoked))
+}
+
+func Test_ForkingAllFail(t *testing.T) {
+	var invoked int32
+	clusterInvoker := forkingInvokers(2, &invoked, -10*time.Millisecond, -20*time.Millisecond)
+
+	// the failure is returned once all the forks fail, rather than waiting for the timeout
+	start := time.Now()
+	result := clusterInvoker.Invoke(&invocation.RPCInvocation{})
+	assert.EqualError(t, result.Error(), "error 1")
+	assert.True(t, time.Since(start) < time.Second)
+}
+
+func Test_ForkingMoreThanProviders(t *testing.T) {
+	var invoked int32
+	clusterInvoker := forkingInvokers(5, &invoked, 10*time.Millisecond, 10*time.Millisecond)
+
+	result := clusterInvoker.Invoke(&invocation.RPCInvocation{})
+	assert.NoError(t, result.Error())
+	time.Sleep(100 * time.Millisecond)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&invoked))
+}
+
+func Test_ForkingLosersExit(t *testing.T) {
+	var invoked int32
+	clusterInvoker := forkingInvokers(3, &invoked, 10*time.Millisecond, 300*time.Millisecond, 300*time.Millisecond)
+
+	before := runtime.NumGoroutine()
+	result := clusterInvoker.Invoke(&invocation.RPCInvocation{})
+	assert.NoError(t, result.Error())
+	assert.Equal(t, "192.168.1.0:20000", result.Result())
+
+	// the losers exit once their invocations return
+	deadline := time.Now().Add(2 * time.Second)
+	for runtime.NumGoroutine() > before && time.Now().Before(deadline) {
+		time.Sleep(50 * time.Millisecond)
+	}
+	assert.True(t, runtime.NumGoroutine() <= before)
+}
@@ -0,0 +1,249 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cluster_impl
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+import (
+	perrors "github.com/pkg/errors"
+)
+
+import (
+	"github.com/apache/dubbo-go/cluster"
+	"github.com/apache/dubbo-go/common/constant"
+	"github.com/apache/dubbo-go/common/logger"
+	"github.com/apache/dubbo-go/protocol"
+)
+
+/**
+ * Invoke all the providers(or groups) and merge their numeric results by the reducer configured by merge,
+ * like sum, avg, min and max. Useful for distributed counters or metrics fan-in.
+ */
+type mergeableClusterInvoker struct {
+	baseClusterInvoker
+}
+
+func newMergeableClusterInvoker(directory cluster.Directory) protocol.Invoker {
+	return &mergeableClusterInvoker{
+		baseClusterInvoker: newBaseClusterInvoker(directory),
+	}
+}
+
+func (invoker *mergeableClusterInvoker) Invoke(invocation protocol.Invocation) protocol.Result {
+	err := invoker.checkWhetherDestroyed()
+	if err != nil {
+		return &protocol.RPCResult{Err: err}
+	}
+
+	invokers := invoker.directory.List(invocation)
+	err = invoker.checkInvokers(invokers, invocation)
+	if err != nil {
+		return &protocol.RPCResult{Err: err}
+	}
+
+	url := invokers[0].GetUrl()
+	merge := url.GetMethodParam(invocation.MethodName(), constant.MERGE_KEY, url.GetParam(constant.MERGE_KEY, ""))
+	if merge == "" || merge == "false" {
+		// no merger, invoke the first available provider
+		for _, ivk := range invokers {
+			if ivk.IsAvailable() {
+				return ivk.Invoke(invocation)
+			}
+		}
+		return invokers[0].Invoke(invocation)
+	}
+
+	if !numberMerges[merge] {
+		return &protocol.RPCResult{Err: perrors.Errorf("unsupported merge %v of the method %v, the supported merges are sum, avg, min and max",
+			merge, invocation.MethodName())}
+	}
+
+	results := make([]protocol.Result, len(invokers))
+	var wg sync.WaitGroup
+	for i, ivk := range invokers {
+		wg.Add(1)
+		go func(i int, ivk protocol.Invoker) {
+			defer wg.Done()
+			results[i] = ivk.Invoke(invocation)
+		}(i, ivk)
+	}
+	wg.Wait()
+
+	values := make([]interface{}, 0, len(results))
+	for i, result := range results {
+		if result.Error() != nil {
+			invoker.errorLog.warnf(invoker.GetUrl(), invocation.MethodName(), "mergeable invoker invoke err: %v when use invoker: %v\n", result.Error(), invokers[i])
+			continue
+		}
+		values = append(values, result.Result())
+	}
+	if len(values) == 0 {
+		// no data may be legitimate for the callers, they could configure the zero value to be returned
+		if url.GetMethodParam(invocation.MethodName(), constant.MERGE_EMPTY_KEY,
+			url.GetParam(constant.MERGE_EMPTY_KEY, constant.MERGE_EMPTY_ERROR)) == constant.MERGE_EMPTY_ZERO {
+			logger.Warnf("all the %v providers of the method %v failed, the zero value is returned", len(invokers), invocation.MethodName())
+			return &protocol.RPCResult{Rest: zeroNumber(merge, invocation)}
+		}
+		return &protocol.RPCResult{Err: perrors.Errorf("failed to merge the results of the method %v, all the %v providers failed",
+			invocation.MethodName(), len(invokers))}
+	}
+
+	merged, err := mergeNumbers(merge, values)
+	if err != nil {
+		return &protocol.RPCResult{Err: err}
+	}
+	return &protocol.RPCResult{Rest: merged}
+}
+
+// the supported merges of the numeric results
+var numberMerges = map[string]bool{"sum": true, "avg": true, "min": true, "max": true}
+
+// zeroNumber returns the zero value of the type of the reply, avg or no reply is typed float64 like mergeNumbers.
+func zeroNumber(merge string, invocation protocol.Invocation) interface{} {
+	if merge != "avg" && invocation.Reply() != nil {
+		return reflect.Zero(reflect.Indirect(reflect.ValueOf(invocation.Reply())).Type()).Interface()
+	}
+	return float64(0)
+}
+
+// mergeNumbers reduces the numeric @values. The merged value has the type of the first value,
+// except avg which is always float64. The integers are reduced in int64 or uint64 so that no precision is lost.
+func mergeNumbers(merge string, values []interface{}) (interface{}, error) {
+	numbers := make([]reflect.Value, 0, len(values))
+	for _, v := range values {
+		rv := reflect.Indirect(reflect.ValueOf(v))
+		switch rv.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+			reflect.Float32, reflect.Float64:
+			numbers = append(numbers, rv)
+		default:
+			return nil, perrors.New(fmt.Sprintf("can not merge the result %v of type %T, it's not a number", v, v))
+		}
+	}
+
+	var merged interface{}
+	switch typ := numbers[0].Type(); typ.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		ints := make([]int64, len(numbers))
+		for i, rv := range numbers {
+			ints[i] = toInt64(rv)
+		}
+		merged = reduceInts(merge, ints)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		uints := make([]uint64, len(numbers))
+		for i, rv := range numbers {
+			uints[i] = toUint64(rv)
+		}
+		merged = reduceUints(merge, uints)
+	default:
+		floats := make([]float64, len(numbers))
+		for i, rv := range numbers {
+			floats[i] = toFloat64(rv)
+		}
+		merged = reduceFloats(merge, floats)
+	}
+	if merge == "avg" {
+		return merged, nil
+	}
+	return reflect.ValueOf(merged).Convert(numbers[0].Type()).Interface(), nil
+}
+
+func reduceInts(merge string, values []int64) interface{} {
+	merged := values[0]
+	for _, v := range values[1:] {
+		switch {
+		case merge == "sum" || merge == "avg":
+			merged += v
+		case merge == "min" && v < merged, merge == "max" && v > merged:
+			merged = v
+		}
+	}
+	if merge == "avg" {
+		n := int64(len(values))
+		return float64(merged/n) + float64(merged%n)/float64(n)
+	}
+	return merged
+}
+
+func reduceUints(merge string, values []uint64) interface{} {
+	merged := values[0]
+	for _, v := range values[1:] {
+		switch {
+		case merge == "sum" || merge == "avg":
+			merged += v
+		case merge == "min" && v < merged, merge == "max" && v > merged:
+			merged = v
+		}
+	}
+	if merge == "avg" {
+		n := uint64(len(values))
+		return float64(merged/n) + float64(merged%n)/float64(n)
+	}
+	return merged
+}
+
+func reduceFloats(merge string, values []float64) interface{} {
+	merged := values[0]
+	for _, v := range values[1:] {
+		switch {
+		case merge == "sum" || merge == "avg":
+			merged += v
+		case merge == "min" && v < merged, merge == "max" && v > merged:
+			merged = v
+		}
+	}
+	if merge == "avg" {
+		return merged / float64(len(values))
+	}
+	return merged
+}
+
+func toInt64(rv reflect.Value) int64 {
+	switch rv.Kind() {
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return int64(rv.Uint())
+	case reflect.Float32, reflect.Float64:
+		return int64(rv.Float())
+	}
+	return rv.Int()
+}
+
+func toUint64(rv reflect.Value) uint64 {
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return uint64(rv.Int())
+	case reflect.Float32, reflect.Float64:
+		return uint64(rv.Float())
+	}
+	return rv.Uint()
+}
+
+func toFloat64(rv reflect.Value) float64 {
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(rv.Uint())
+	}
+	return rv.Float()
+}
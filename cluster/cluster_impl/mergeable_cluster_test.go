@@ -0,0 +1,166 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cluster_impl
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+import (
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+import (
+	"github.com/apache/dubbo-go/cluster/directory"
+	"github.com/apache/dubbo-go/common"
+	"github.com/apache/dubbo-go/common/constant"
+	"github.com/apache/dubbo-go/protocol"
+	"github.com/apache/dubbo-go/protocol/invocation"
+	"github.com/apache/dubbo-go/protocol/mock"
+)
+
+func registerMergeable(t *testing.T, merge string, mockInvokers ...*mock.MockInvoker) protocol.Invoker {
+	mergeableUrl, _ := common.NewURL(context.TODO(), "dubbo://192.168.1.1:20000/com.ikurento.user.UserProvider")
+	mergeableUrl.AddParam(constant.MERGE_KEY, merge)
+
+	invokers := []protocol.Invoker{}
+	for _, ivk := range mockInvokers {
+		invokers = append(invokers, ivk)
+		ivk.EXPECT().GetUrl().Return(mergeableUrl).AnyTimes()
+	}
+	staticDir := directory.NewStaticDirectory(invokers)
+
+	mergeableCluster := NewMergeableCluster()
+	clusterInvoker := mergeableCluster.Join(staticDir)
+	return clusterInvoker
+}
+
+func mergeableInvokers(ctrl *gomock.Controller, results ...protocol.Result) []*mock.MockInvoker {
+	invokers := make([]*mock.MockInvoker, 0)
+	for _, result := range results {
+		invoker := mock.NewMockInvoker(ctrl)
+		invoker.EXPECT().Invoke(gomock.Any()).Return(result)
+		invokers = append(invokers, invoker)
+	}
+	return invokers
+}
+
+func Test_MergeableInvokeReducers(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	one, three := 1, 3
+	expected := map[string]interface{}{
+		"sum": 9,
+		"avg": float64(3),
+		"min": 1,
+		"max": 5,
+	}
+	for merge, value := range expected {
+		// pointer result is supported too
+		invokers := mergeableInvokers(ctrl, &protocol.RPCResult{Rest: &one}, &protocol.RPCResult{Rest: &three}, &protocol.RPCResult{Rest: 5})
+		clusterInvoker := registerMergeable(t, merge, invokers...)
+
+		result := clusterInvoker.Invoke(&invocation.RPCInvocation{})
+		assert.NoError(t, result.Error())
+		assert.Equal(t, value, result.Result(), merge)
+	}
+}
+
+func Test_MergeableInvokePartialFailed(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	invokers := mergeableInvokers(ctrl, &protocol.RPCResult{Rest: int64(2)}, &protocol.RPCResult{Err: errors.New("just failed")}, &protocol.RPCResult{Rest: int64(5)})
+	clusterInvoker := registerMergeable(t, "sum", invokers...)
+
+	result := clusterInvoker.Invoke(&invocation.RPCInvocation{})
+	assert.NoError(t, result.Error())
+	assert.Equal(t, int64(7), result.Result())
+}
+
+func Test_MergeableInvokeLargeIntegers(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// not representable by float64
+	large := int64(1)<<62 + 1
+	invokers := mergeableInvokers(ctrl, &protocol.RPCResult{Rest: large}, &protocol.RPCResult{Rest: int64(2)})
+	clusterInvoker := registerMergeable(t, "sum", invokers...)
+
+	result := clusterInvoker.Invoke(&invocation.RPCInvocation{})
+	assert.NoError(t, result.Error())
+	assert.Equal(t, large+2, result.Result())
+
+	invokers = mergeableInvokers(ctrl, &protocol.RPCResult{Rest: uint64(1)<<63 + 1}, &protocol.RPCResult{Rest: uint64(1)<<63 + 3})
+	clusterInvoker = registerMergeable(t, "min", invokers...)
+
+	result = clusterInvoker.Invoke(&invocation.RPCInvocation{})
+	assert.NoError(t, result.Error())
+	assert.Equal(t, uint64(1)<<63+1, result.Result())
+}
+
+func Test_MergeableInvokeIllegal(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	invokers := mergeableInvokers(ctrl, &protocol.RPCResult{Rest: "1"}, &protocol.RPCResult{Rest: 2})
+	clusterInvoker := registerMergeable(t, "sum", invokers...)
+	result := clusterInvoker.Invoke(&invocation.RPCInvocation{})
+	assert.Error(t, result.Error())
+
+	clusterInvoker = registerMergeable(t, "median", mock.NewMockInvoker(ctrl))
+	result = clusterInvoker.Invoke(&invocation.RPCInvocation{})
+	assert.Error(t, result.Error())
+}
+
+func Test_MergeableInvokeAllFailed(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	for _, empty := range []string{"", constant.MERGE_EMPTY_ERROR, constant.MERGE_EMPTY_ZERO} {
+		invokers := mergeableInvokers(ctrl, &protocol.RPCResult{Err: errors.New("just failed")}, &protocol.RPCResult{Err: errors.New("just failed")})
+		clusterInvoker := registerMergeable(t, "sum", invokers...)
+		if empty != "" {
+			url := invokers[0].GetUrl()
+			url.AddParam(constant.MERGE_EMPTY_KEY, empty)
+		}
+
+		var reply int32
+		result := clusterInvoker.Invoke(invocation.NewRPCInvocationWithOptions(invocation.WithReply(&reply)))
+		if empty == constant.MERGE_EMPTY_ZERO {
+			assert.NoError(t, result.Error())
+			assert.Equal(t, int32(0), result.Result())
+		} else {
+			assert.Error(t, result.Error(), empty)
+			assert.Nil(t, result.Result())
+		}
+	}
+
+	// avg or no reply is typed float64
+	invokers := mergeableInvokers(ctrl, &protocol.RPCResult{Err: errors.New("just failed")})
+	clusterInvoker := registerMergeable(t, "avg", invokers...)
+	url := invokers[0].GetUrl()
+	url.AddParam(constant.MERGE_EMPTY_KEY, constant.MERGE_EMPTY_ZERO)
+	result := clusterInvoker.Invoke(&invocation.RPCInvocation{})
+	assert.NoError(t, result.Error())
+	assert.Equal(t, float64(0), result.Result())
+}
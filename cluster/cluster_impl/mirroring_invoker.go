@@ -0,0 +1,93 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cluster_impl
+
+import (
+	"math/rand"
+	"reflect"
+	"strconv"
+)
+
+import (
+	"github.com/apache/dubbo-go/common"
+	"github.com/apache/dubbo-go/common/constant"
+	"github.com/apache/dubbo-go/common/logger"
+	"github.com/apache/dubbo-go/protocol"
+	"github.com/apache/dubbo-go/protocol/invocation"
+)
+
+/**
+ * mirroringInvoker wraps the cluster invoker of a reference, and mirrors a copy of the sampled invocations
+ * to the shadow providers tagged by mirror.tag asynchronously. The results of the copies are discarded, the
+ * real response is served by the primary providers, which the tag router isolates from the shadow ones.
+ */
+type mirroringInvoker struct {
+	protocol.Invoker
+	tag  string
+	rate float64
+}
+
+// NewMirroringInvoker wraps @invoker if @url, the reference url, configures the mirror.tag
+func NewMirroringInvoker(invoker protocol.Invoker, url common.URL) protocol.Invoker {
+	tag := url.GetParam(constant.MIRROR_TAG_KEY, "")
+	if tag == "" {
+		return invoker
+	}
+	rate, err := strconv.ParseFloat(url.GetParam(constant.MIRROR_RATE_KEY, "1"), 64)
+	if err != nil {
+		logger.Warnf("illegal %v of the url %v: %v", constant.MIRROR_RATE_KEY, url.Key(), err)
+		rate = 0
+	}
+	return &mirroringInvoker{Invoker: invoker, tag: tag, rate: rate}
+}
+
+func (invoker *mirroringInvoker) Invoke(inv protocol.Invocation) protocol.Result {
+	// the tagged invocation is routed to the tagged providers already
+	if inv.AttachmentsByKey(constant.TAG_KEY, "") == "" && rand.Float64() < invoker.rate {
+		go invoker.mirror(inv)
+	}
+	return invoker.Invoker.Invoke(inv)
+}
+
+// mirror invokes the shadow providers with a copy of @inv, which has its own reply and attachments
+func (invoker *mirroringInvoker) mirror(inv protocol.Invocation) {
+	defer func() {
+		if e := recover(); e != nil {
+			logger.Warnf("mirror the invocation of the method %v, panic: %v", inv.MethodName(), e)
+		}
+	}()
+
+	attachments := make(map[string]string, len(inv.Attachments())+2)
+	for k, v := range inv.Attachments() {
+		attachments[k] = v
+	}
+	// the copy never falls back to the primary providers
+	attachments[constant.TAG_KEY] = invoker.tag
+	attachments[constant.FORCE_TAG_KEY] = "true"
+
+	var reply interface{}
+	if t := reflect.TypeOf(inv.Reply()); t != nil && t.Kind() == reflect.Ptr {
+		reply = reflect.New(t.Elem()).Interface()
+	}
+	shadow := invocation.NewRPCInvocationWithOptions(invocation.WithMethodName(inv.MethodName()),
+		invocation.WithParameterTypes(inv.ParameterTypes()), invocation.WithArguments(inv.Arguments()),
+		invocation.WithReply(reply), invocation.WithAttachments(attachments))
+	if err := invoker.Invoker.Invoke(shadow).Error(); err != nil {
+		logger.Debugf("the mirrored invocation of the method %v failed: %v", inv.MethodName(), err)
+	}
+}
@@ -0,0 +1,103 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cluster_impl
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+import (
+	perrors "github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+import (
+	"github.com/apache/dubbo-go/common"
+	"github.com/apache/dubbo-go/common/constant"
+	"github.com/apache/dubbo-go/protocol"
+	"github.com/apache/dubbo-go/protocol/invocation"
+)
+
+// taggedInvoker plays the primary providers and the shadow ones, the shadow fails slowly
+type taggedInvoker struct {
+	*MockInvoker
+	shadow chan protocol.Invocation
+}
+
+func (ti *taggedInvoker) Invoke(inv protocol.Invocation) protocol.Result {
+	if inv.AttachmentsByKey(constant.TAG_KEY, "") != "" {
+		time.Sleep(50 * time.Millisecond)
+		*inv.Reply().(*string) = "shadow"
+		ti.shadow <- inv
+		return &protocol.RPCResult{Err: perrors.New("shadow error")}
+	}
+	*inv.Reply().(*string) = "primary"
+	return &protocol.RPCResult{Rest: inv.Reply()}
+}
+
+func mirrorInvoke(t *testing.T, urlString string) (*taggedInvoker, protocol.Invocation, protocol.Result) {
+	url, err := common.NewURL(context.TODO(), urlString)
+	assert.NoError(t, err)
+	ti := &taggedInvoker{MockInvoker: NewMockInvoker(url, 1), shadow: make(chan protocol.Invocation, 1)}
+	invoker := NewMirroringInvoker(ti, url)
+
+	var reply string
+	inv := invocation.NewRPCInvocationWithOptions(invocation.WithMethodName("GetUser"),
+		invocation.WithArguments([]interface{}{"A001"}), invocation.WithReply(&reply),
+		invocation.WithAttachments(map[string]string{"trace": "1"}))
+	return ti, inv, invoker.Invoke(inv)
+}
+
+func Test_MirroringInvoke(t *testing.T) {
+	ti, inv, result := mirrorInvoke(t, "dubbo://192.168.1.1:20000/com.ikurento.user.UserProvider?mirror.tag=shadow")
+
+	// the primary response isn't affected by the shadow
+	assert.NoError(t, result.Error())
+	assert.Equal(t, "primary", *result.Result().(*string))
+
+	select {
+	case shadow := <-ti.shadow:
+		assert.Equal(t, "GetUser", shadow.MethodName())
+		assert.Equal(t, []interface{}{"A001"}, shadow.Arguments())
+		assert.Equal(t, "shadow", shadow.AttachmentsByKey(constant.TAG_KEY, ""))
+		assert.Equal(t, "true", shadow.AttachmentsByKey(constant.FORCE_TAG_KEY, ""))
+		assert.Equal(t, "1", shadow.AttachmentsByKey("trace", ""))
+	case <-time.After(time.Second):
+		assert.Fail(t, "the invocation isn't mirrored")
+	}
+	assert.Equal(t, "primary", *inv.Reply().(*string))
+	assert.Equal(t, "", inv.AttachmentsByKey(constant.TAG_KEY, ""))
+}
+
+func Test_MirroringInvokeSampling(t *testing.T) {
+	ti, _, result := mirrorInvoke(t, "dubbo://192.168.1.1:20000/com.ikurento.user.UserProvider?mirror.tag=shadow&mirror.rate=0")
+	assert.NoError(t, result.Error())
+
+	select {
+	case <-ti.shadow:
+		assert.Fail(t, "the invocation isn't sampled")
+	case <-time.After(200 * time.Millisecond):
+	}
+
+	// not mirrored without the mirror.tag
+	url, _ := common.NewURL(context.TODO(), "dubbo://192.168.1.1:20000/com.ikurento.user.UserProvider")
+	invoker := NewMockInvoker(url, 1)
+	assert.Equal(t, invoker, NewMirroringInvoker(invoker, url))
+}
@@ -0,0 +1,208 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cluster_impl
+
+import (
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+import (
+	"github.com/apache/dubbo-go/common"
+	"github.com/apache/dubbo-go/common/constant"
+	"github.com/apache/dubbo-go/common/logger"
+	"github.com/apache/dubbo-go/protocol"
+)
+
+/**
+ * latencyOutlierDetector ejects the providers whose p95 latency exceeds outlier.latency.factor times
+ * the median p95 latency of the other providers. An ejected provider is excluded from the selection
+ * for outlier.ejection.interval, then it's probed by the normal traffic again with its samples reset,
+ * and ejected again if it's still slow. The p95 of a provider is computed once its samples are enough,
+ * then once every window of samples.
+ */
+type latencyOutlierDetector struct {
+	lock      sync.Mutex
+	stats     map[protocol.Invoker]*latencyStats
+	lastPrune time.Time
+}
+
+type latencyStats struct {
+	samples      []time.Duration // ring buffer of the latest latencies
+	next         int
+	added        int // the samples added since the p95 is computed
+	p95          time.Duration
+	computed     bool
+	ejectedUntil time.Time
+}
+
+type outlierConfig struct {
+	factor     float64
+	window     int
+	minSamples int
+	interval   time.Duration
+}
+
+func newLatencyOutlierDetector() *latencyOutlierDetector {
+	return &latencyOutlierDetector{stats: make(map[protocol.Invoker]*latencyStats)}
+}
+
+func getOutlierConfig(url common.URL) outlierConfig {
+	factor, err := strconv.ParseFloat(url.GetParam(constant.OUTLIER_LATENCY_FACTOR_KEY, "0"), 64)
+	if err != nil {
+		logger.Warnf("illegal %v of the url %v: %v", constant.OUTLIER_LATENCY_FACTOR_KEY, url.Key(), err)
+		factor = 0
+	}
+	conf := outlierConfig{
+		factor:     factor,
+		window:     int(url.GetParamInt(constant.OUTLIER_LATENCY_WINDOW_KEY, constant.DEFAULT_OUTLIER_LATENCY_WINDOW)),
+		minSamples: int(url.GetParamInt(constant.OUTLIER_LATENCY_MIN_SAMPLES_KEY, constant.DEFAULT_OUTLIER_LATENCY_MIN_SAMPLES)),
+		interval:   time.Duration(url.GetParamInt(constant.OUTLIER_EJECTION_INTERVAL_KEY, constant.DEFAULT_OUTLIER_EJECTION_INTERVAL)) * time.Millisecond,
+	}
+	if conf.window < 1 {
+		conf.window = constant.DEFAULT_OUTLIER_LATENCY_WINDOW
+	}
+	if conf.minSamples < 1 || conf.minSamples > conf.window {
+		conf.minSamples = conf.window
+	}
+	return conf
+}
+
+// record the latency of an invocation on the provider and eject the provider if it's an outlier,
+// the detection is configured by the cluster @url
+func (d *latencyOutlierDetector) record(url common.URL, ivk protocol.Invoker, latency time.Duration) {
+	conf := getOutlierConfig(url)
+	if conf.factor <= 0 {
+		return
+	}
+
+	now := time.Now()
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	d.prune(now, conf.interval)
+
+	stats, ok := d.stats[ivk]
+	if !ok {
+		stats = &latencyStats{}
+		d.stats[ivk] = stats
+	}
+	if now.Before(stats.ejectedUntil) {
+		// the late response of an ejected provider
+		return
+	}
+	if !stats.add(latency, conf.window, conf.minSamples) {
+		return
+	}
+
+	median, ok := d.medianP95(ivk, now)
+	if !ok {
+		return
+	}
+	if float64(stats.p95) > conf.factor*float64(median) {
+		logger.Warnf("the provider %v is ejected for %v, its p95 latency %v exceeds %v times of the median %v",
+			ivk.GetUrl().Key(), conf.interval, stats.p95, conf.factor, median)
+		stats.ejectedUntil = now.Add(conf.interval)
+		stats.reset()
+	}
+}
+
+// prune drops the stats of the unavailable providers, including the destroyed ones, once an ejection interval
+func (d *latencyOutlierDetector) prune(now time.Time, interval time.Duration) {
+	if now.Sub(d.lastPrune) < interval {
+		return
+	}
+	d.lastPrune = now
+	for ivk := range d.stats {
+		if !ivk.IsAvailable() {
+			delete(d.stats, ivk)
+		}
+	}
+}
+
+// medianP95 returns the median p95 latency of the providers except @ivk whose p95 is computed
+func (d *latencyOutlierDetector) medianP95(ivk protocol.Invoker, now time.Time) (time.Duration, bool) {
+	var p95s []time.Duration
+	for other, stats := range d.stats {
+		if other == ivk || now.Before(stats.ejectedUntil) || !stats.computed {
+			continue
+		}
+		p95s = append(p95s, stats.p95)
+	}
+	if len(p95s) == 0 {
+		return 0, false
+	}
+	sort.Slice(p95s, func(i, j int) bool { return p95s[i] < p95s[j] })
+	if len(p95s)%2 == 1 {
+		return p95s[len(p95s)/2], true
+	}
+	return (p95s[len(p95s)/2-1] + p95s[len(p95s)/2]) / 2, true
+}
+
+// selectable filters the ejected providers out, all the invokers are returned if all of them are ejected
+func (d *latencyOutlierDetector) selectable(invokers []protocol.Invoker) []protocol.Invoker {
+	now := time.Now()
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	if len(d.stats) == 0 {
+		return invokers
+	}
+	selectable := make([]protocol.Invoker, 0, len(invokers))
+	for _, ivk := range invokers {
+		if stats, ok := d.stats[ivk]; ok && now.Before(stats.ejectedUntil) {
+			continue
+		}
+		selectable = append(selectable, ivk)
+	}
+	if len(selectable) == 0 {
+		return invokers
+	}
+	return selectable
+}
+
+// add the @latency to the samples, it returns true if the p95 is computed again
+func (s *latencyStats) add(latency time.Duration, window int, minSamples int) bool {
+	if len(s.samples) < window {
+		s.samples = append(s.samples, latency)
+	} else {
+		s.samples[s.next%window] = latency
+		s.next = (s.next + 1) % window
+	}
+	s.added++
+	if len(s.samples) < minSamples || (s.computed && s.added < window) {
+		return false
+	}
+
+	sorted := make([]time.Duration, len(s.samples))
+	copy(sorted, s.samples)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	s.p95 = sorted[(len(sorted)*95+99)/100-1]
+	s.computed = true
+	s.added = 0
+	return true
+}
+
+func (s *latencyStats) reset() {
+	s.samples = s.samples[:0]
+	s.next = 0
+	s.added = 0
+	s.p95 = 0
+	s.computed = false
+}
@@ -0,0 +1,122 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cluster_impl
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"testing"
+	"time"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+import (
+	"github.com/apache/dubbo-go/common"
+	"github.com/apache/dubbo-go/common/constant"
+	"github.com/apache/dubbo-go/protocol"
+)
+
+func outlierInvokers(urlParams url.Values) []protocol.Invoker {
+	invokers := []protocol.Invoker{}
+	for i := 0; i < 3; i++ {
+		url, _ := common.NewURL(context.TODO(), fmt.Sprintf("dubbo://192.168.1.%v:20000/com.ikurento.user.UserProvider", i), common.WithParams(urlParams))
+		invokers = append(invokers, NewMockInvoker(url, 1))
+	}
+	return invokers
+}
+
+func recordLatency(detector *latencyOutlierDetector, ivk protocol.Invoker, latency time.Duration, times int) {
+	for i := 0; i < times; i++ {
+		detector.record(ivk.GetUrl(), ivk, latency)
+	}
+}
+
+func Test_LatencyOutlierEjectAndReinstate(t *testing.T) {
+	urlParams := url.Values{}
+	urlParams.Set(constant.OUTLIER_LATENCY_FACTOR_KEY, "3")
+	urlParams.Set(constant.OUTLIER_LATENCY_WINDOW_KEY, "10")
+	urlParams.Set(constant.OUTLIER_LATENCY_MIN_SAMPLES_KEY, "5")
+	urlParams.Set(constant.OUTLIER_EJECTION_INTERVAL_KEY, "200")
+	invokers := outlierInvokers(urlParams)
+	detector := newLatencyOutlierDetector()
+
+	recordLatency(detector, invokers[0], 10*time.Millisecond, 5)
+	recordLatency(detector, invokers[1], 12*time.Millisecond, 5)
+	recordLatency(detector, invokers[2], 100*time.Millisecond, 5)
+	selectable := detector.selectable(invokers)
+	assert.Equal(t, []protocol.Invoker{invokers[0], invokers[1]}, selectable)
+
+	// the provider is probed after the ejection interval, and stays when its latency is normal
+	time.Sleep(250 * time.Millisecond)
+	assert.Len(t, detector.selectable(invokers), 3)
+	recordLatency(detector, invokers[2], 11*time.Millisecond, 10)
+	assert.Len(t, detector.selectable(invokers), 3)
+
+	// ejected again if it's still slow
+	recordLatency(detector, invokers[2], 100*time.Millisecond, 10)
+	assert.Len(t, detector.selectable(invokers), 2)
+}
+
+func Test_LatencyOutlierDisabled(t *testing.T) {
+	invokers := outlierInvokers(url.Values{})
+	detector := newLatencyOutlierDetector()
+
+	recordLatency(detector, invokers[0], 10*time.Millisecond, 20)
+	recordLatency(detector, invokers[1], 10*time.Millisecond, 20)
+	recordLatency(detector, invokers[2], time.Second, 20)
+	assert.Len(t, detector.selectable(invokers), 3)
+}
+
+func Test_LatencyOutlierNeverEjectAll(t *testing.T) {
+	urlParams := url.Values{}
+	urlParams.Set(constant.OUTLIER_LATENCY_FACTOR_KEY, "2")
+	urlParams.Set(constant.OUTLIER_LATENCY_MIN_SAMPLES_KEY, "1")
+	invokers := outlierInvokers(urlParams)
+	detector := newLatencyOutlierDetector()
+
+	detector.record(invokers[0].GetUrl(), invokers[0], 10*time.Millisecond)
+	detector.record(invokers[1].GetUrl(), invokers[1], 100*time.Millisecond)
+	assert.Len(t, detector.selectable(invokers[:2]), 1)
+	// only the ejected provider is left
+	assert.Equal(t, []protocol.Invoker{invokers[1]}, detector.selectable(invokers[1:2]))
+}
+
+func Test_LatencyOutlierPruneDestroyed(t *testing.T) {
+	urlParams := url.Values{}
+	urlParams.Set(constant.OUTLIER_LATENCY_FACTOR_KEY, "3")
+	urlParams.Set(constant.OUTLIER_LATENCY_MIN_SAMPLES_KEY, "1")
+	urlParams.Set(constant.OUTLIER_EJECTION_INTERVAL_KEY, "50")
+	invokers := outlierInvokers(urlParams)
+	detector := newLatencyOutlierDetector()
+
+	for _, ivk := range invokers {
+		detector.record(ivk.GetUrl(), ivk, 10*time.Millisecond)
+	}
+	assert.Len(t, detector.stats, 3)
+
+	// the stats of the destroyed provider are dropped once the ejection interval elapses
+	invokers[2].Destroy()
+	time.Sleep(60 * time.Millisecond)
+	detector.record(invokers[0].GetUrl(), invokers[0], 10*time.Millisecond)
+	assert.Len(t, detector.stats, 2)
+	assert.NotContains(t, detector.stats, invokers[2])
+}
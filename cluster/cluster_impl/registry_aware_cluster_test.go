@@ -47,7 +47,7 @@ func Test_RegAwareInvokeSuccess(t *testing.T) {
 	clusterInvoker := regAwareCluster.Join(staticDir)
 	result := clusterInvoker.Invoke(&invocation.RPCInvocation{})
 	assert.NoError(t, result.Error())
-	count = 0
+	count.Store(0)
 }
 
 func TestDestroy(t *testing.T) {
@@ -64,7 +64,7 @@ func TestDestroy(t *testing.T) {
 	assert.Equal(t, true, clusterInvoker.IsAvailable())
 	result := clusterInvoker.Invoke(&invocation.RPCInvocation{})
 	assert.NoError(t, result.Error())
-	count = 0
+	count.Store(0)
 	clusterInvoker.Destroy()
 	assert.Equal(t, false, clusterInvoker.IsAvailable())
 
@@ -0,0 +1,63 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cluster_impl
+
+import (
+	"github.com/apache/dubbo-go/common/logger"
+	"github.com/apache/dubbo-go/protocol"
+)
+
+// ResultCallback post-processes the successful @result of the @invocation, e.g. enriches the metrics
+type ResultCallback func(invocation protocol.Invocation, result protocol.Result)
+
+/**
+ * resultCallbackInvoker wraps the cluster invoker of a reference, and runs the result callbacks of the
+ * reference on each successful result in the order they are registered. The callbacks run after the filter
+ * chains of the providers, a panic of them is logged and doesn't fail the invocation.
+ */
+type resultCallbackInvoker struct {
+	protocol.Invoker
+	callbacks []ResultCallback
+}
+
+// NewResultCallbackInvoker wraps @invoker if there is any callback
+func NewResultCallbackInvoker(invoker protocol.Invoker, callbacks []ResultCallback) protocol.Invoker {
+	if len(callbacks) == 0 {
+		return invoker
+	}
+	return &resultCallbackInvoker{Invoker: invoker, callbacks: callbacks}
+}
+
+func (invoker *resultCallbackInvoker) Invoke(invocation protocol.Invocation) protocol.Result {
+	result := invoker.Invoker.Invoke(invocation)
+	if result.Error() == nil {
+		for _, callback := range invoker.callbacks {
+			invoker.callback(callback, invocation, result)
+		}
+	}
+	return result
+}
+
+func (invoker *resultCallbackInvoker) callback(callback ResultCallback, invocation protocol.Invocation, result protocol.Result) {
+	defer func() {
+		if e := recover(); e != nil {
+			logger.Warnf("the result callback of the method %v, panic: %v", invocation.MethodName(), e)
+		}
+	}()
+	callback(invocation, result)
+}
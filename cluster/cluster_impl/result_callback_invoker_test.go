@@ -0,0 +1,78 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cluster_impl
+
+import (
+	"context"
+	"testing"
+)
+
+import (
+	perrors "github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+import (
+	"github.com/apache/dubbo-go/common"
+	"github.com/apache/dubbo-go/protocol"
+	"github.com/apache/dubbo-go/protocol/invocation"
+)
+
+// echoInvoker returns the first argument, the method Fail fails
+type echoInvoker struct {
+	*MockInvoker
+}
+
+func (ei *echoInvoker) Invoke(inv protocol.Invocation) protocol.Result {
+	if inv.MethodName() == "Fail" {
+		return &protocol.RPCResult{Err: perrors.New("error")}
+	}
+	return &protocol.RPCResult{Rest: inv.Arguments()[0]}
+}
+
+func Test_ResultCallbackInvoke(t *testing.T) {
+	url, _ := common.NewURL(context.TODO(), "dubbo://192.168.1.1:20000/com.ikurento.user.UserProvider")
+	ei := &echoInvoker{MockInvoker: NewMockInvoker(url, 1)}
+	assert.Equal(t, protocol.Invoker(ei), NewResultCallbackInvoker(ei, nil))
+
+	observed := []string{}
+	invoker := NewResultCallbackInvoker(ei, []ResultCallback{
+		func(inv protocol.Invocation, result protocol.Result) {
+			observed = append(observed, inv.MethodName()+":"+inv.AttachmentsByKey("trace", "")+":"+result.Result().(string))
+		},
+		func(inv protocol.Invocation, result protocol.Result) {
+			panic("callback panic")
+		},
+		func(inv protocol.Invocation, result protocol.Result) {
+			observed = append(observed, "last")
+		},
+	})
+
+	for _, id := range []string{"A001", "A002"} {
+		inv := invocation.NewRPCInvocationWithOptions(invocation.WithMethodName("GetUser"),
+			invocation.WithArguments([]interface{}{id}), invocation.WithAttachments(map[string]string{"trace": id}))
+		result := invoker.Invoke(inv)
+		assert.NoError(t, result.Error())
+		assert.Equal(t, id, result.Result())
+	}
+	// the failed result isn't observed
+	result := invoker.Invoke(invocation.NewRPCInvocationWithOptions(invocation.WithMethodName("Fail")))
+	assert.Error(t, result.Error())
+
+	assert.Equal(t, []string{"GetUser:A001:A001", "last", "GetUser:A002:A002", "last"}, observed)
+}
@@ -0,0 +1,49 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cluster_impl
+
+import (
+	"github.com/apache/dubbo-go/cluster"
+	"github.com/apache/dubbo-go/common"
+	"github.com/apache/dubbo-go/common/constant"
+	"github.com/apache/dubbo-go/common/extension"
+	"github.com/apache/dubbo-go/protocol"
+)
+
+func init() {
+	extension.SetRetryPredicate(constant.DEFAULT_KEY, NewDefaultRetryPredicate)
+}
+
+// defaultRetryPredicate retries all the errors except the ones carrying a terminal status code,
+// see isRetryable. The number of attempts is limited by the cluster invokers.
+type defaultRetryPredicate struct {
+	url common.URL
+}
+
+func NewDefaultRetryPredicate(url common.URL) cluster.RetryPredicate {
+	return &defaultRetryPredicate{url: url}
+}
+
+func (p *defaultRetryPredicate) ShouldRetry(err error, invocation protocol.Invocation, attempt int) bool {
+	return isRetryable(p.url, invocation.MethodName(), err)
+}
+
+func getRetryPredicate(url common.URL, invocation protocol.Invocation) cluster.RetryPredicate {
+	name := url.GetMethodParam(invocation.MethodName(), constant.RETRY_PREDICATE_KEY, url.GetParam(constant.RETRY_PREDICATE_KEY, constant.DEFAULT_KEY))
+	return extension.GetRetryPredicate(name, url)
+}
@@ -0,0 +1,159 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cluster_impl
+
+import (
+	"container/heap"
+	"sync"
+	"time"
+)
+
+import (
+	perrors "github.com/pkg/errors"
+)
+
+var errRetryTaskQueueDisposed = perrors.New("the failback task queue is disposed")
+
+// retryTaskQueue keeps the failback tasks ordered by their due time, so each task is taken when it's due
+// regardless of the order they're put.
+type retryTaskQueue struct {
+	lock     sync.Mutex
+	tasks    retryTaskHeap
+	seq      int64
+	disposed bool
+	// notified when a task due earlier than all the others is put
+	wakeup chan struct{}
+}
+
+func newRetryTaskQueue() *retryTaskQueue {
+	return &retryTaskQueue{
+		wakeup: make(chan struct{}, 1),
+	}
+}
+
+func (q *retryTaskQueue) Len() int64 {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	return int64(len(q.tasks))
+}
+
+// Put adds the @task due at its due time
+func (q *retryTaskQueue) Put(task *retryTimerTask) error {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	if q.disposed {
+		return errRetryTaskQueueDisposed
+	}
+	q.seq++
+	task.seq = q.seq
+	heap.Push(&q.tasks, task)
+	if q.tasks[0] == task {
+		select {
+		case q.wakeup <- struct{}{}:
+		default:
+		}
+	}
+	return nil
+}
+
+// takeDue takes the earliest task if it's due at @now. Otherwise nil is returned with the due time of the
+// earliest task, which is zero if the queue is empty.
+func (q *retryTaskQueue) takeDue(now time.Time) (*retryTimerTask, time.Time, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	if q.disposed {
+		return nil, time.Time{}, errRetryTaskQueueDisposed
+	}
+	if len(q.tasks) == 0 {
+		return nil, time.Time{}, nil
+	}
+	if task := q.tasks[0]; task.due.After(now) {
+		return nil, task.due, nil
+	}
+	return heap.Pop(&q.tasks).(*retryTimerTask), time.Time{}, nil
+}
+
+// evictOldest removes the task put the earliest, nil is returned if the queue is empty
+func (q *retryTaskQueue) evictOldest() *retryTimerTask {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	if len(q.tasks) == 0 {
+		return nil
+	}
+	oldest := q.tasks[0]
+	for _, task := range q.tasks {
+		if task.seq < oldest.seq {
+			oldest = task
+		}
+	}
+	return heap.Remove(&q.tasks, oldest.index).(*retryTimerTask)
+}
+
+// Dispose rejects the tasks put later and returns the pending ones
+func (q *retryTaskQueue) Dispose() []*retryTimerTask {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	if q.disposed {
+		return nil
+	}
+	q.disposed = true
+	pending := q.tasks
+	q.tasks = nil
+	return pending
+}
+
+func (q *retryTaskQueue) Disposed() bool {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	return q.disposed
+}
+
+// retryTaskHeap implements heap.Interface, the task due the earliest is on the top
+type retryTaskHeap []*retryTimerTask
+
+func (h retryTaskHeap) Len() int {
+	return len(h)
+}
+
+func (h retryTaskHeap) Less(i, j int) bool {
+	if h[i].due.Equal(h[j].due) {
+		return h[i].seq < h[j].seq
+	}
+	return h[i].due.Before(h[j].due)
+}
+
+func (h retryTaskHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+
+func (h *retryTaskHeap) Push(x interface{}) {
+	task := x.(*retryTimerTask)
+	task.index = len(*h)
+	*h = append(*h, task)
+}
+
+func (h *retryTaskHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	task := old[n-1]
+	old[n-1] = nil
+	*h = old[:n-1]
+	return task
+}
@@ -0,0 +1,123 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cluster_impl
+
+import (
+	"testing"
+	"time"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+import (
+	"github.com/apache/dubbo-go/protocol/invocation"
+)
+
+func dueTask(method string, due time.Time) *retryTimerTask {
+	task := newRetryTimerTask(nil, nil, invocation.NewRPCInvocationWithOptions(invocation.WithMethodName(method)), nil)
+	task.due = due
+	return task
+}
+
+func TestRetryTaskQueueDueOrder(t *testing.T) {
+	q := newRetryTaskQueue()
+	now := time.Now()
+	assert.NoError(t, q.Put(dueTask("C", now.Add(3*time.Second))))
+	assert.NoError(t, q.Put(dueTask("A", now.Add(time.Second))))
+	assert.NoError(t, q.Put(dueTask("B", now.Add(2*time.Second))))
+	assert.NoError(t, q.Put(dueTask("B2", now.Add(2*time.Second))))
+	assert.Equal(t, int64(4), q.Len())
+
+	// nothing is due yet
+	task, next, err := q.takeDue(now)
+	assert.NoError(t, err)
+	assert.Nil(t, task)
+	assert.Equal(t, now.Add(time.Second), next)
+
+	var methods []string
+	for {
+		task, _, err := q.takeDue(now.Add(5 * time.Second))
+		assert.NoError(t, err)
+		if task == nil {
+			break
+		}
+		methods = append(methods, task.invocation.MethodName())
+	}
+	// the tasks due at the same time are taken in the order they're put
+	assert.Equal(t, []string{"A", "B", "B2", "C"}, methods)
+
+	task, next, err = q.takeDue(now)
+	assert.NoError(t, err)
+	assert.Nil(t, task)
+	assert.True(t, next.IsZero())
+}
+
+func TestRetryTaskQueueWakeup(t *testing.T) {
+	q := newRetryTaskQueue()
+	now := time.Now()
+	assert.NoError(t, q.Put(dueTask("A", now.Add(time.Second))))
+	<-q.wakeup
+
+	// the task due later doesn't wake up the scheduling
+	assert.NoError(t, q.Put(dueTask("B", now.Add(2*time.Second))))
+	select {
+	case <-q.wakeup:
+		assert.Fail(t, "woken up by the task due later")
+	default:
+	}
+
+	assert.NoError(t, q.Put(dueTask("C", now)))
+	select {
+	case <-q.wakeup:
+	default:
+		assert.Fail(t, "not woken up by the task due earlier")
+	}
+}
+
+func TestRetryTaskQueueEvictOldest(t *testing.T) {
+	q := newRetryTaskQueue()
+	assert.Nil(t, q.evictOldest())
+
+	now := time.Now()
+	assert.NoError(t, q.Put(dueTask("Old", now.Add(3*time.Second))))
+	assert.NoError(t, q.Put(dueTask("New", now.Add(time.Second))))
+	assert.Equal(t, "Old", q.evictOldest().invocation.MethodName())
+
+	task, _, err := q.takeDue(now.Add(5 * time.Second))
+	assert.NoError(t, err)
+	assert.Equal(t, "New", task.invocation.MethodName())
+	assert.Equal(t, int64(0), q.Len())
+}
+
+func TestRetryTaskQueueDispose(t *testing.T) {
+	q := newRetryTaskQueue()
+	now := time.Now()
+	assert.NoError(t, q.Put(dueTask("A", now)))
+	assert.NoError(t, q.Put(dueTask("B", now)))
+
+	assert.Equal(t, 2, len(q.Dispose()))
+	assert.True(t, q.Disposed())
+	assert.Equal(t, int64(0), q.Len())
+	assert.Nil(t, q.Dispose())
+
+	assert.Equal(t, errRetryTaskQueueDisposed, q.Put(dueTask("C", now)))
+	_, _, err := q.takeDue(now)
+	assert.Equal(t, errRetryTaskQueueDisposed, err)
+}
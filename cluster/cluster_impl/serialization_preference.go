@@ -0,0 +1,89 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cluster_impl
+
+import (
+	"strings"
+)
+
+import (
+	"github.com/apache/dubbo-go/common"
+	"github.com/apache/dubbo-go/common/constant"
+	"github.com/apache/dubbo-go/protocol"
+)
+
+const (
+	// the provider supports the first serialization preferred by the consumer, or it doesn't advertise its serializations
+	serializationExact = iota
+	// the provider supports one of the other serializations preferred by the consumer, which is negotiated
+	serializationNegotiated
+	serializationIncompatible
+)
+
+// preferSerialization returns the providers supporting the first serialization preferred by the consumer, or the
+// ones supporting the other preferred serializations if there isn't any, so the pool of the providers advertising
+// different serializations is selected gracefully. All the providers are returned if none of them matches, or the
+// preference is disabled by serialization.prefer of the cluster @url.
+func preferSerialization(url common.URL, invokers []protocol.Invoker) []protocol.Invoker {
+	if len(invokers) <= 1 || !url.GetParamBool(constant.SERIALIZATION_PREFER_KEY, true) {
+		return invokers
+	}
+
+	var exact, negotiated []protocol.Invoker
+	for _, ivk := range invokers {
+		switch serializationCompatibility(ivk.GetUrl()) {
+		case serializationExact:
+			exact = append(exact, ivk)
+		case serializationNegotiated:
+			negotiated = append(negotiated, ivk)
+		}
+	}
+	if len(exact) == len(invokers) {
+		return invokers
+	}
+	if len(exact) > 0 {
+		return exact
+	}
+	if len(negotiated) > 0 {
+		return negotiated
+	}
+	return invokers
+}
+
+// serializationCompatibility of the provider @url, which is merged with the reference url
+func serializationCompatibility(url common.URL) int {
+	preferred := url.GetParam(constant.PREFERRED_SERIALIZATION_KEY, "")
+	supported := url.GetParam(constant.SERIALIZATIONS_KEY, "")
+	if preferred == "" || supported == "" {
+		return serializationExact
+	}
+
+	supports := make(map[string]bool)
+	for _, s := range strings.Split(supported, ",") {
+		supports[strings.TrimSpace(s)] = true
+	}
+	for i, serialization := range strings.Split(preferred, ",") {
+		if supports[strings.TrimSpace(serialization)] {
+			if i == 0 {
+				return serializationExact
+			}
+			return serializationNegotiated
+		}
+	}
+	return serializationIncompatible
+}
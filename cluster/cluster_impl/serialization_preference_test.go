@@ -0,0 +1,83 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cluster_impl
+
+import (
+	"context"
+	"fmt"
+	"testing"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+import (
+	"github.com/apache/dubbo-go/cluster/directory"
+	"github.com/apache/dubbo-go/cluster/loadbalance"
+	"github.com/apache/dubbo-go/common"
+	"github.com/apache/dubbo-go/common/extension"
+	"github.com/apache/dubbo-go/protocol"
+	"github.com/apache/dubbo-go/protocol/invocation"
+)
+
+// serializationInvokers advertise the @serializations, the consumer prefers protobuf then hessian2
+func serializationInvokers(serializations ...string) []protocol.Invoker {
+	invokers := []protocol.Invoker{}
+	for i, s := range serializations {
+		url, _ := common.NewURL(context.TODO(), fmt.Sprintf("dubbo://192.168.1.%v:20000/com.ikurento.user.UserProvider?"+
+			"serialization.preferred=protobuf,hessian2&serializations=%v", i, s))
+		invokers = append(invokers, NewMockInvoker(url, 1))
+	}
+	return invokers
+}
+
+func Test_PreferSerialization(t *testing.T) {
+	invokers := serializationInvokers("json", "hessian2,json", "protobuf", "protobuf,hessian2")
+	url := invokers[0].GetUrl()
+	assert.Equal(t, invokers[2:], preferSerialization(url, invokers))
+
+	// negotiated if none supports the first preferred one
+	invokers = serializationInvokers("json", "hessian2,json", "json,hessian2")
+	assert.Equal(t, invokers[1:], preferSerialization(url, invokers))
+
+	// all the providers if none matches
+	invokers = serializationInvokers("json", "jsonrpc")
+	assert.Equal(t, invokers, preferSerialization(url, invokers))
+
+	// the providers not advertising the serializations are compatible
+	invokers = serializationInvokers("json", "")
+	assert.Equal(t, invokers[1:], preferSerialization(url, invokers))
+
+	// disabled
+	invokers = serializationInvokers("json", "protobuf")
+	url.AddParam("serialization.prefer", "false")
+	assert.Equal(t, invokers, preferSerialization(url, invokers))
+}
+
+func Test_PreferSerializationMixedPool(t *testing.T) {
+	extension.SetLoadbalance("random", loadbalance.NewRandomLoadBalance)
+	invokers := serializationInvokers("json", "hessian2", "protobuf,json", "json", "protobuf")
+	clusterInvoker := NewFailFastCluster().Join(directory.NewStaticDirectory(invokers)).(*failfastClusterInvoker)
+
+	lb := loadbalance.NewRandomLoadBalance()
+	for i := 0; i < 100; i++ {
+		selected := clusterInvoker.doSelect(lb, &invocation.RPCInvocation{}, invokers, nil)
+		assert.Contains(t, []protocol.Invoker{invokers[2], invokers[4]}, selected)
+	}
+}
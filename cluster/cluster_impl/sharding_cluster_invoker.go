@@ -0,0 +1,156 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cluster_impl
+
+import (
+	"fmt"
+	"hash/crc32"
+	"strconv"
+	"strings"
+)
+
+import (
+	perrors "github.com/pkg/errors"
+)
+
+import (
+	"github.com/apache/dubbo-go/cluster"
+	"github.com/apache/dubbo-go/common"
+	"github.com/apache/dubbo-go/common/constant"
+	"github.com/apache/dubbo-go/protocol"
+)
+
+// shardingClusterInvoker invokes one of the providers owning the shard of the invocation, the ownership of the
+// shards is explicit, so the invocation fails instead of spilling over if no provider owns the shard.
+type shardingClusterInvoker struct {
+	baseClusterInvoker
+}
+
+func newShardingClusterInvoker(directory cluster.Directory) protocol.Invoker {
+	return &shardingClusterInvoker{
+		baseClusterInvoker: newBaseClusterInvoker(directory),
+	}
+}
+
+func (invoker *shardingClusterInvoker) Invoke(invocation protocol.Invocation) protocol.Result {
+	invokers := invoker.directory.List(invocation)
+	err := invoker.checkInvokers(invokers, invocation)
+	if err != nil {
+		return &protocol.RPCResult{Err: err}
+	}
+
+	url := invokers[0].GetUrl()
+	shard, err := shardOf(url, invocation)
+	if err != nil {
+		return &protocol.RPCResult{Err: err}
+	}
+	owners := shardOwners(url, invokers, shard)
+	if len(owners) == 0 {
+		return &protocol.RPCResult{Err: perrors.Errorf("no provider owns the shard %v of the method %v of the service %v",
+			shard, invocation.MethodName(), url.Service())}
+	}
+
+	loadbalance := getLoadBalance(url, invocation)
+	err = invoker.checkWhetherDestroyed()
+	if err != nil {
+		return &protocol.RPCResult{Err: err}
+	}
+	ivk := invoker.doSelect(loadbalance, invocation, owners, nil)
+	return invoker.doInvoke(ivk, invocation)
+}
+
+// shardOf returns the shard of the arguments at the shard.arguments indexes, they are joined by colons
+func shardOf(url common.URL, invocation protocol.Invocation) (string, error) {
+	methodName := invocation.MethodName()
+	indexes := url.GetMethodParam(methodName, constant.SHARD_ARGUMENTS_KEY,
+		url.GetParam(constant.SHARD_ARGUMENTS_KEY, constant.DEFAULT_SHARD_ARGUMENTS))
+	arguments := invocation.Arguments()
+
+	keys := make([]string, 0, 1)
+	for _, index := range strings.Split(indexes, ",") {
+		i, err := strconv.Atoi(strings.TrimSpace(index))
+		if err != nil || i < 0 || i >= len(arguments) {
+			return "", perrors.Errorf("the shard argument %v of the method %v is out of the %v arguments",
+				index, methodName, len(arguments))
+		}
+		keys = append(keys, fmt.Sprint(arguments[i]))
+	}
+	key := strings.Join(keys, ":")
+
+	if count := url.GetMethodParamInt64(methodName, constant.SHARD_COUNT_KEY, 0); count > 0 {
+		return strconv.FormatInt(int64(crc32.ChecksumIEEE([]byte(key)))%count, 10), nil
+	}
+	return key, nil
+}
+
+// shardOwners returns the invokers owning the shard by the shard.map of the reference, or their shards params
+func shardOwners(url common.URL, invokers []protocol.Invoker, shard string) []protocol.Invoker {
+	var owners []protocol.Invoker
+	if shardMap := url.GetParam(constant.SHARD_MAP_KEY, ""); shardMap != "" {
+		addresses := make(map[string]struct{})
+		for _, entry := range strings.Split(shardMap, ";") {
+			kv := strings.SplitN(entry, "=", 2)
+			if len(kv) == 2 && matchShard(kv[0], shard) {
+				addresses[strings.TrimSpace(kv[1])] = struct{}{}
+			}
+		}
+		for _, ivk := range invokers {
+			_, byLocation := addresses[ivk.GetUrl().Location]
+			_, byIp := addresses[ivk.GetUrl().Ip]
+			if byLocation || byIp {
+				owners = append(owners, ivk)
+			}
+		}
+		return owners
+	}
+
+	for _, ivk := range invokers {
+		if matchShard(ivk.GetUrl().GetParam(constant.SHARDS_KEY, ""), shard) {
+			owners = append(owners, ivk)
+		}
+	}
+	return owners
+}
+
+// matchShard returns true if the shard is in @shards, the comma separated shards or numeric ranges like 0-3
+func matchShard(shards string, shard string) bool {
+	if shards == "" {
+		return false
+	}
+	n, err := strconv.ParseInt(shard, 10, 64)
+	numeric := err == nil
+	for _, s := range strings.Split(shards, ",") {
+		s = strings.TrimSpace(s)
+		if s == shard {
+			return true
+		}
+		if !numeric {
+			continue
+		}
+		bounds := strings.SplitN(s, "-", 2)
+		if len(bounds) != 2 {
+			continue
+		}
+		low, errLow := strconv.ParseInt(strings.TrimSpace(bounds[0]), 10, 64)
+		high, errHigh := strconv.ParseInt(strings.TrimSpace(bounds[1]), 10, 64)
+		if errLow == nil && errHigh == nil && low <= n && n <= high {
+			return true
+		}
+	}
+	return false
+}
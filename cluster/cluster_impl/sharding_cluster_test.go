@@ -0,0 +1,115 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cluster_impl
+
+import (
+	"context"
+	"fmt"
+	"hash/crc32"
+	"testing"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+import (
+	"github.com/apache/dubbo-go/cluster/directory"
+	"github.com/apache/dubbo-go/cluster/loadbalance"
+	"github.com/apache/dubbo-go/common"
+	"github.com/apache/dubbo-go/common/extension"
+	"github.com/apache/dubbo-go/protocol"
+	"github.com/apache/dubbo-go/protocol/invocation"
+)
+
+// shardingInvoker returns the sharding cluster invoker of the providers with the params, which reply their address
+func shardingInvoker(params ...string) protocol.Invoker {
+	extension.SetLoadbalance("random", loadbalance.NewRandomLoadBalance)
+	var invoked int32
+	invokers := make([]protocol.Invoker, 0, len(params))
+	for i, param := range params {
+		url, _ := common.NewURL(context.TODO(), fmt.Sprintf("dubbo://192.168.1.%v:20000/com.ikurento.user.UserProvider?%v", i, param))
+		invokers = append(invokers, &delayedInvoker{MockInvoker: NewMockInvoker(url, 1), invoked: &invoked})
+	}
+	return NewShardingCluster().Join(directory.NewStaticDirectory(invokers))
+}
+
+func shardingInvoke(invoker protocol.Invoker, args ...interface{}) protocol.Result {
+	return invoker.Invoke(invocation.NewRPCInvocation("GetUser", args, nil))
+}
+
+func TestShardingByProviderShards(t *testing.T) {
+	invoker := shardingInvoker("shards=cn,jp", "shards=us", "shards=eu")
+
+	assert.Equal(t, "192.168.1.1:20000", shardingInvoke(invoker, "us", 1).Result())
+	assert.Equal(t, "192.168.1.0:20000", shardingInvoke(invoker, "jp", 1).Result())
+	assert.Equal(t, "192.168.1.2:20000", shardingInvoke(invoker, "eu", 1).Result())
+
+	// the shard isn't owned by any provider
+	result := shardingInvoke(invoker, "br", 1)
+	assert.EqualError(t, result.Error(), "no provider owns the shard br of the method GetUser of the service com.ikurento.user.UserProvider")
+	assert.Nil(t, result.Result())
+
+	// the shard argument is missing
+	assert.Error(t, shardingInvoke(invoker).Error())
+}
+
+func TestShardingByShardCount(t *testing.T) {
+	invoker := shardingInvoker("shard.count=8&shards=0-3", "shard.count=8&shards=4-6", "shard.count=8&shards=7")
+	owners := []string{"192.168.1.0:20000", "192.168.1.0:20000", "192.168.1.0:20000", "192.168.1.0:20000",
+		"192.168.1.1:20000", "192.168.1.1:20000", "192.168.1.1:20000", "192.168.1.2:20000"}
+
+	for i := 0; i < 100; i++ {
+		key := fmt.Sprintf("user%v", i)
+		shard := crc32.ChecksumIEEE([]byte(key)) % 8
+		result := shardingInvoke(invoker, key)
+		assert.NoError(t, result.Error())
+		assert.Equal(t, owners[shard], result.Result(), key)
+	}
+}
+
+func TestShardingByShardMap(t *testing.T) {
+	// the shard map of the reference overrides the shards of the providers, its ";" is escaped twice as
+	// common.NewURL unescapes the url before parsing its query
+	shardMap := "shard.map=cn=192.168.1.2:20000%253Bus,eu=192.168.1.0%253Bus=192.168.1.1:20000&shards=cn"
+	invoker := shardingInvoker(shardMap, shardMap, shardMap)
+
+	assert.Equal(t, "192.168.1.2:20000", shardingInvoke(invoker, "cn").Result())
+	assert.Equal(t, "192.168.1.0:20000", shardingInvoke(invoker, "eu").Result())
+
+	// the replicas of the shard
+	replicas := make(map[interface{}]struct{})
+	for i := 0; i < 100; i++ {
+		replicas[shardingInvoke(invoker, "us").Result()] = struct{}{}
+	}
+	assert.Equal(t, map[interface{}]struct{}{"192.168.1.0:20000": {}, "192.168.1.1:20000": {}}, replicas)
+}
+
+func TestShardingByArguments(t *testing.T) {
+	params := "shard.arguments=1,2&methods.GetUser0.shard.arguments=0&shards="
+	invoker := shardingInvoker(params+"cn:1", params+"us:1,us:2", params+"eu")
+
+	assert.Equal(t, "192.168.1.0:20000", shardingInvoke(invoker, "A001", "cn", 1).Result())
+	assert.Equal(t, "192.168.1.1:20000", shardingInvoke(invoker, "A001", "us", 2).Result())
+	assert.Error(t, shardingInvoke(invoker, "A001", "us", 3).Error())
+
+	// the method level arguments
+	result := invoker.Invoke(invocation.NewRPCInvocation("GetUser0", []interface{}{"eu", "us", 2}, nil))
+	assert.NoError(t, result.Error())
+	assert.Equal(t, "192.168.1.2:20000", result.Result())
+}
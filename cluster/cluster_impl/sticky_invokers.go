@@ -0,0 +1,64 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cluster_impl
+
+import (
+	"sync"
+)
+
+import (
+	"github.com/apache/dubbo-go/protocol"
+)
+
+// stickyInvokers keeps the last selected invoker of each method configured sticky
+type stickyInvokers struct {
+	lock     sync.RWMutex
+	invokers map[string]protocol.Invoker
+}
+
+func newStickyInvokers() *stickyInvokers {
+	return &stickyInvokers{
+		invokers: make(map[string]protocol.Invoker),
+	}
+}
+
+func (s *stickyInvokers) get(methodName string) protocol.Invoker {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	return s.invokers[methodName]
+}
+
+// set sticks the method to the @ivk, the sticky invoker is cleared if it's nil
+func (s *stickyInvokers) set(methodName string, ivk protocol.Invoker) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if ivk == nil {
+		delete(s.invokers, methodName)
+		return
+	}
+	s.invokers[methodName] = ivk
+}
+
+// remove clears the sticky invoker of the method if it's still the @ivk, which failed
+func (s *stickyInvokers) remove(methodName string, ivk protocol.Invoker) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.invokers[methodName] == ivk {
+		delete(s.invokers, methodName)
+	}
+}
@@ -0,0 +1,113 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cluster_impl
+
+import (
+	"context"
+	"fmt"
+	"testing"
+)
+
+import (
+	perrors "github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+import (
+	"github.com/apache/dubbo-go/cluster/loadbalance"
+	"github.com/apache/dubbo-go/common"
+	"github.com/apache/dubbo-go/common/extension"
+	"github.com/apache/dubbo-go/protocol"
+	"github.com/apache/dubbo-go/protocol/invocation"
+)
+
+func stickyCluster(sticky string) (*failfastClusterInvoker, *changingDirectory, []*delayedInvoker) {
+	extension.SetLoadbalance("random", loadbalance.NewRandomLoadBalance)
+	url, _ := common.NewURL(context.TODO(), "dubbo://192.168.1.1:20000/com.ikurento.user.UserProvider?methods.GetUser.sticky="+sticky)
+	var invoked int32
+	providers := []*delayedInvoker{}
+	invokers := []protocol.Invoker{}
+	for i := 0; i < 5; i++ {
+		providerUrl, _ := common.NewURL(context.TODO(), fmt.Sprintf("dubbo://192.168.1.%v:20000/com.ikurento.user.UserProvider", i))
+		provider := &delayedInvoker{MockInvoker: NewMockInvoker(providerUrl, 1), invoked: &invoked}
+		providers = append(providers, provider)
+		invokers = append(invokers, provider)
+	}
+	dir := &changingDirectory{url: url}
+	dir.set(invokers...)
+	return NewFailFastCluster().Join(dir).(*failfastClusterInvoker), dir, providers
+}
+
+func stickyInvocation() protocol.Invocation {
+	return invocation.NewRPCInvocation("GetUser", nil, nil)
+}
+
+func Test_StickySameProvider(t *testing.T) {
+	clusterInvoker, _, _ := stickyCluster("true")
+
+	first := clusterInvoker.Invoke(stickyInvocation()).Result()
+	for i := 0; i < 20; i++ {
+		assert.Equal(t, first, clusterInvoker.Invoke(stickyInvocation()).Result())
+	}
+}
+
+func Test_StickyProviderGone(t *testing.T) {
+	clusterInvoker, dir, providers := stickyCluster("true")
+
+	first := clusterInvoker.Invoke(stickyInvocation()).Result()
+	remaining := []protocol.Invoker{}
+	for _, provider := range providers {
+		if provider.GetUrl().Location != first {
+			remaining = append(remaining, provider)
+		}
+	}
+	dir.set(remaining...)
+
+	second := clusterInvoker.Invoke(stickyInvocation()).Result()
+	assert.NotEqual(t, first, second)
+	for i := 0; i < 20; i++ {
+		assert.Equal(t, second, clusterInvoker.Invoke(stickyInvocation()).Result())
+	}
+}
+
+func Test_StickyProviderFails(t *testing.T) {
+	clusterInvoker, _, providers := stickyCluster("true")
+
+	first := clusterInvoker.Invoke(stickyInvocation()).Result()
+	sticky := clusterInvoker.sticky.get("GetUser")
+	assert.Equal(t, first, sticky.GetUrl().Location)
+
+	for _, provider := range providers {
+		if provider == sticky {
+			provider.err = perrors.New("error")
+		}
+	}
+	assert.Error(t, clusterInvoker.Invoke(stickyInvocation()).Error())
+	assert.Nil(t, clusterInvoker.sticky.get("GetUser"))
+}
+
+func Test_StickyOff(t *testing.T) {
+	clusterInvoker, _, _ := stickyCluster("false")
+
+	selected := make(map[interface{}]bool)
+	for i := 0; i < 50; i++ {
+		selected[clusterInvoker.Invoke(stickyInvocation()).Result()] = true
+	}
+	assert.True(t, len(selected) > 1)
+	assert.Nil(t, clusterInvoker.sticky.get("GetUser"))
+}
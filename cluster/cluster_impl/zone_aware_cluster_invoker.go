@@ -0,0 +1,93 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cluster_impl
+
+import (
+	"strconv"
+)
+
+import (
+	perrors "github.com/pkg/errors"
+)
+
+import (
+	"github.com/apache/dubbo-go/cluster"
+	"github.com/apache/dubbo-go/common/constant"
+	"github.com/apache/dubbo-go/protocol"
+)
+
+// zoneAwareClusterInvoker selects among the invokers of the registries, each of them is the cluster invoker
+// of a registry which is configured by the reference, e.g. failover.
+type zoneAwareClusterInvoker struct {
+	baseClusterInvoker
+}
+
+func newZoneAwareClusterInvoker(directory cluster.Directory) protocol.Invoker {
+	return &zoneAwareClusterInvoker{
+		baseClusterInvoker: newBaseClusterInvoker(directory),
+	}
+}
+
+func (invoker *zoneAwareClusterInvoker) Invoke(invocation protocol.Invocation) protocol.Result {
+	invokers := invoker.directory.List(invocation)
+
+	// the preferred registry wins regardless of the zone
+	for _, ivk := range invokers {
+		if ivk.IsAvailable() && ivk.GetUrl().GetParamBool(constant.PREFERRED_KEY, false) {
+			return ivk.Invoke(invocation)
+		}
+	}
+
+	// the registry in the same zone
+	if zone := invoker.zoneParam(invocation, constant.REGISTRY_ZONE_KEY); zone != "" {
+		for _, ivk := range invokers {
+			if ivk.IsAvailable() && ivk.GetUrl().GetParam(constant.REGISTRY_ZONE_KEY, "") == zone {
+				return ivk.Invoke(invocation)
+			}
+		}
+		if force, _ := strconv.ParseBool(invoker.zoneParam(invocation, constant.ZONE_FORCE_KEY)); force {
+			return &protocol.RPCResult{Err: perrors.Errorf("no registry available in the zone %v for the method %v, "+
+				"and the zone is forced", zone, invocation.MethodName())}
+		}
+	}
+
+	// spill over to the default registry, or the first available one
+	for _, ivk := range invokers {
+		if ivk.IsAvailable() && ivk.GetUrl().GetParamBool(constant.REGISTRY_DEFAULT_KEY, false) {
+			return ivk.Invoke(invocation)
+		}
+	}
+	for _, ivk := range invokers {
+		if ivk.IsAvailable() {
+			return ivk.Invoke(invocation)
+		}
+	}
+	return &protocol.RPCResult{Err: perrors.Errorf("no registry available for the method %v in %v",
+		invocation.MethodName(), invokers)}
+}
+
+// zoneParam returns the attachment @key of the invocation, or the param of the reference
+func (invoker *zoneAwareClusterInvoker) zoneParam(invocation protocol.Invocation, key string) string {
+	if value := invocation.AttachmentsByKey(key, ""); value != "" {
+		return value
+	}
+	if reference := invoker.GetUrl().SubURL; reference != nil {
+		return reference.GetParam(key, "")
+	}
+	return ""
+}
@@ -0,0 +1,128 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cluster_impl
+
+import (
+	"context"
+	"fmt"
+	"testing"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+import (
+	"github.com/apache/dubbo-go/cluster/directory"
+	"github.com/apache/dubbo-go/common"
+	"github.com/apache/dubbo-go/common/constant"
+	"github.com/apache/dubbo-go/protocol"
+	"github.com/apache/dubbo-go/protocol/invocation"
+)
+
+// zoneInvokers returns the invokers of the registries with the params, the consumer is in the @zone
+func zoneInvokers(zone string, params ...string) []*delayedInvoker {
+	var (
+		invoked   int32
+		invokers  []*delayedInvoker
+		reference *common.URL
+	)
+	if zone != "" {
+		u, _ := common.NewURL(context.TODO(), "dubbo://127.0.0.1:20000/com.ikurento.user.UserProvider?registry.zone="+zone)
+		reference = &u
+	}
+	for i, param := range params {
+		url, _ := common.NewURL(context.TODO(), fmt.Sprintf("registry://192.168.1.%v:2181?%v", i, param))
+		url.SubURL = reference
+		invokers = append(invokers, &delayedInvoker{MockInvoker: NewMockInvoker(url, 1), invoked: &invoked})
+	}
+	return invokers
+}
+
+func zoneInvoke(invokers []*delayedInvoker, attachments map[string]string) protocol.Result {
+	ivks := make([]protocol.Invoker, 0, len(invokers))
+	for _, ivk := range invokers {
+		ivks = append(ivks, ivk)
+	}
+	clusterInvoker := NewZoneAwareCluster().Join(directory.NewStaticDirectory(ivks))
+	return clusterInvoker.Invoke(invocation.NewRPCInvocation("GetUser", nil, attachments))
+}
+
+func TestZoneAwareSameZone(t *testing.T) {
+	invokers := zoneInvokers("", "registry.zone=az1&registry.default=true", "registry.zone=az2")
+
+	result := zoneInvoke(invokers, map[string]string{constant.REGISTRY_ZONE_KEY: "az2"})
+	assert.NoError(t, result.Error())
+	assert.Equal(t, "192.168.1.1:2181", result.Result())
+
+	// the zone of the reference
+	invokers = zoneInvokers("az2", "registry.zone=az1&registry.default=true", "registry.zone=az2")
+	assert.Equal(t, "192.168.1.1:2181", zoneInvoke(invokers, nil).Result())
+
+	// the attachment has priority over the reference
+	assert.Equal(t, "192.168.1.0:2181", zoneInvoke(invokers, map[string]string{constant.REGISTRY_ZONE_KEY: "az1"}).Result())
+}
+
+func TestZoneAwareSpillover(t *testing.T) {
+	invokers := zoneInvokers("az2", "registry.zone=az1", "registry.zone=az3&registry.default=true", "registry.zone=az2")
+	invokers[2].available = false
+
+	// the default registry is selected if the zone has no available registry
+	result := zoneInvoke(invokers, nil)
+	assert.NoError(t, result.Error())
+	assert.Equal(t, "192.168.1.1:2181", result.Result())
+
+	// the first available one if there isn't any default
+	invokers[1].available = false
+	assert.Equal(t, "192.168.1.0:2181", zoneInvoke(invokers, nil).Result())
+
+	invokers[0].available = false
+	assert.Error(t, zoneInvoke(invokers, nil).Error())
+}
+
+func TestZoneAwareForced(t *testing.T) {
+	invokers := zoneInvokers("", "registry.zone=az1&registry.default=true", "registry.zone=az2")
+	invokers[1].available = false
+
+	result := zoneInvoke(invokers, map[string]string{constant.REGISTRY_ZONE_KEY: "az2", constant.ZONE_FORCE_KEY: "true"})
+	assert.EqualError(t, result.Error(), "no registry available in the zone az2 for the method GetUser, and the zone is forced")
+	assert.Nil(t, result.Result())
+
+	// forced by the reference
+	invokers = zoneInvokers("az2", "registry.zone=az1", "registry.zone=az2")
+	invokers[0].url.SubURL.AddParam(constant.ZONE_FORCE_KEY, "true")
+	invokers[1].available = false
+	assert.Error(t, zoneInvoke(invokers, nil).Error())
+
+	// the registry in the zone is available
+	invokers[1].available = true
+	assert.Equal(t, "192.168.1.1:2181", zoneInvoke(invokers, nil).Result())
+}
+
+func TestZoneAwarePreferred(t *testing.T) {
+	invokers := zoneInvokers("az1", "registry.zone=az1&registry.default=true", "registry.zone=az2&preferred=true")
+
+	// the preferred registry wins over the zone and the default one
+	result := zoneInvoke(invokers, map[string]string{constant.REGISTRY_ZONE_KEY: "az1", constant.ZONE_FORCE_KEY: "true"})
+	assert.NoError(t, result.Error())
+	assert.Equal(t, "192.168.1.1:2181", result.Result())
+
+	// unless it's unavailable
+	invokers[1].available = false
+	assert.Equal(t, "192.168.1.0:2181", zoneInvoke(invokers, nil).Result())
+}
@@ -18,6 +18,7 @@
 package directory
 
 import (
+	"github.com/apache/dubbo-go/cluster/router"
 	"github.com/apache/dubbo-go/common"
 	"github.com/apache/dubbo-go/protocol"
 )
@@ -61,6 +62,7 @@ func (dir *staticDirectory) Destroy() {
 	dir.BaseDirectory.Destroy(func() {
 		for _, ivk := range dir.invokers {
 			ivk.Destroy()
+			router.RemoveHealth(ivk.GetUrl())
 		}
 		dir.invokers = []protocol.Invoker{}
 	})
@@ -0,0 +1,72 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cluster
+
+import (
+	"sort"
+	"sync"
+)
+
+// the addresses of the draining providers, they receive no new invocations from any reference,
+// while the invocations in progress on them complete as usual
+var (
+	drainingProviders     = make(map[string]struct{})
+	drainingProvidersLock sync.RWMutex
+)
+
+// DrainProvider marks the provider of the @address (ip:port) as draining across all the references,
+// it's used to take the provider out of service for maintenance.
+func DrainProvider(address string) {
+	drainingProvidersLock.Lock()
+	defer drainingProvidersLock.Unlock()
+	drainingProviders[address] = struct{}{}
+}
+
+// ResumeProvider puts the draining provider of the @address back into service
+func ResumeProvider(address string) {
+	drainingProvidersLock.Lock()
+	defer drainingProvidersLock.Unlock()
+	delete(drainingProviders, address)
+}
+
+// IsProviderDraining returns true if the provider of the @address is draining
+func IsProviderDraining(address string) bool {
+	drainingProvidersLock.RLock()
+	defer drainingProvidersLock.RUnlock()
+	_, ok := drainingProviders[address]
+	return ok
+}
+
+// HasDrainingProviders returns true if any provider is draining
+func HasDrainingProviders() bool {
+	drainingProvidersLock.RLock()
+	defer drainingProvidersLock.RUnlock()
+	return len(drainingProviders) > 0
+}
+
+// DrainingProviders returns the sorted addresses of the draining providers
+func DrainingProviders() []string {
+	drainingProvidersLock.RLock()
+	defer drainingProvidersLock.RUnlock()
+	addresses := make([]string, 0, len(drainingProviders))
+	for address := range drainingProviders {
+		addresses = append(addresses, address)
+	}
+	sort.Strings(addresses)
+	return addresses
+}
@@ -0,0 +1,54 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cluster
+
+import (
+	"sync"
+)
+
+import (
+	"github.com/apache/dubbo-go/protocol"
+)
+
+// FailbackCallback is notified of the final result of an invocation retried in background by the failback
+// cluster, it's the result of the successful retry, or the last failed one when the retries are given up.
+type FailbackCallback func(invocation protocol.Invocation, result protocol.Result)
+
+var (
+	failbackCallbacks     = make(map[string]FailbackCallback)
+	failbackCallbacksLock sync.RWMutex
+)
+
+// SetFailbackCallback registers the @callback by the @key, which the invocations refer to by the attachment
+// failback.callback. The callback is removed if it's nil.
+func SetFailbackCallback(key string, callback FailbackCallback) {
+	failbackCallbacksLock.Lock()
+	defer failbackCallbacksLock.Unlock()
+	if callback == nil {
+		delete(failbackCallbacks, key)
+		return
+	}
+	failbackCallbacks[key] = callback
+}
+
+// GetFailbackCallback returns the callback registered by the @key, nil if there isn't any
+func GetFailbackCallback(key string) FailbackCallback {
+	failbackCallbacksLock.RLock()
+	defer failbackCallbacksLock.RUnlock()
+	return failbackCallbacks[key]
+}
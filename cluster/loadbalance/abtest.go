@@ -0,0 +1,154 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package loadbalance
+
+import (
+	"fmt"
+	"hash/crc32"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+import (
+	"github.com/apache/dubbo-go/cluster"
+	"github.com/apache/dubbo-go/common"
+	"github.com/apache/dubbo-go/common/constant"
+	"github.com/apache/dubbo-go/common/extension"
+	"github.com/apache/dubbo-go/protocol"
+)
+
+const (
+	ABTest = "abtest"
+
+	// the arms of the A/B test, the B one takes the abtest.percentage of the requests
+	ABTestArmA = "a"
+	ABTestArmB = "b"
+)
+
+// the statistics of the arms of the A/B tests
+var abTestCounters sync.Map // [string]*abTestCounter
+
+func init() {
+	extension.SetLoadbalance(ABTest, NewABTestLoadBalance)
+}
+
+// ABTestStats are the statistics of an arm of the A/B test of a method
+type ABTestStats struct {
+	Loadbalance    string
+	Requests       int64
+	Failures       int64
+	AverageElapsed time.Duration
+}
+
+type abTestCounter struct {
+	requests int64
+	failures int64
+	elapsed  int64
+}
+
+// abTestLoadBalance runs an A/B test between the two load balances of the method, the requests are split between
+// them by the hash of their request keys, so the same key always goes to the same load balance.
+// The statistics of each of them are recorded by the abtest filter for comparison.
+type abTestLoadBalance struct {
+}
+
+func NewABTestLoadBalance() cluster.LoadBalance {
+	return &abTestLoadBalance{}
+}
+
+func (lb *abTestLoadBalance) Select(invokers []protocol.Invoker, invocation protocol.Invocation) protocol.Invoker {
+	if len(invokers) == 0 {
+		return nil
+	}
+	url := invokers[0].GetUrl()
+	return extension.GetLoadbalance(abTestLoadbalance(url, invocation.MethodName(), ABTestArm(url, invocation))).
+		Select(invokers, invocation)
+}
+
+// ABTestArm returns the arm of the A/B test the @invocation goes to by the hash of its request key
+func ABTestArm(url common.URL, invocation protocol.Invocation) string {
+	percentage := url.GetMethodParamInt64(invocation.MethodName(), constant.ABTEST_PERCENTAGE_KEY, constant.DEFAULT_ABTEST_PERCENTAGE)
+	if int64(crc32.ChecksumIEEE([]byte(abTestRequestKey(url, invocation)))%100) < percentage {
+		return ABTestArmB
+	}
+	return ABTestArmA
+}
+
+func abTestRequestKey(url common.URL, invocation protocol.Invocation) string {
+	methodName := invocation.MethodName()
+	if name := url.GetMethodParam(methodName, constant.ABTEST_KEY_KEY, url.GetParam(constant.ABTEST_KEY_KEY, "")); name != "" {
+		return invocation.AttachmentsByKey(name, "")
+	}
+	return fmt.Sprint(invocation.Arguments()...)
+}
+
+// abTestLoadbalance returns the load balance of the @arm, the default one if it isn't configured
+func abTestLoadbalance(url common.URL, methodName string, arm string) string {
+	loadbalances := strings.Split(url.GetMethodParam(methodName, constant.ABTEST_LOADBALANCES_KEY,
+		url.GetParam(constant.ABTEST_LOADBALANCES_KEY, "")), ",")
+	index := 0
+	if arm == ABTestArmB {
+		index = 1
+	}
+	if index >= len(loadbalances) || strings.TrimSpace(loadbalances[index]) == "" {
+		return constant.DEFAULT_LOADBALANCE
+	}
+	return strings.TrimSpace(loadbalances[index])
+}
+
+func isABTest(url common.URL, methodName string) bool {
+	return url.GetMethodParam(methodName, constant.LOADBALANCE_KEY, url.GetParam(constant.LOADBALANCE_KEY, "")) == ABTest
+}
+
+func abTestCounterKey(url common.URL, methodName, arm string) string {
+	return url.ServiceKey() + "." + methodName + "." + arm + "." + abTestLoadbalance(url, methodName, arm)
+}
+
+// RecordABTest records the result of the @invocation to the statistics of its arm if its method is in an A/B test
+func RecordABTest(url common.URL, invocation protocol.Invocation, elapsed time.Duration, err error) {
+	methodName := invocation.MethodName()
+	if !isABTest(url, methodName) {
+		return
+	}
+	key := abTestCounterKey(url, methodName, ABTestArm(url, invocation))
+	value, _ := abTestCounters.LoadOrStore(key, &abTestCounter{})
+	counter := value.(*abTestCounter)
+	atomic.AddInt64(&counter.requests, 1)
+	atomic.AddInt64(&counter.elapsed, int64(elapsed))
+	if err != nil {
+		atomic.AddInt64(&counter.failures, 1)
+	}
+}
+
+// GetABTestStats returns the statistics of the @arm of the A/B test of the method
+func GetABTestStats(url common.URL, methodName string, arm string) ABTestStats {
+	stats := ABTestStats{Loadbalance: abTestLoadbalance(url, methodName, arm)}
+	value, ok := abTestCounters.Load(abTestCounterKey(url, methodName, arm))
+	if !ok {
+		return stats
+	}
+	counter := value.(*abTestCounter)
+	stats.Requests = atomic.LoadInt64(&counter.requests)
+	stats.Failures = atomic.LoadInt64(&counter.failures)
+	if stats.Requests > 0 {
+		stats.AverageElapsed = time.Duration(atomic.LoadInt64(&counter.elapsed) / stats.Requests)
+	}
+	return stats
+}
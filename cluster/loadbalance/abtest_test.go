@@ -0,0 +1,116 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package loadbalance
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+)
+
+import (
+	perrors "github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+import (
+	"github.com/apache/dubbo-go/cluster"
+	"github.com/apache/dubbo-go/common"
+	"github.com/apache/dubbo-go/common/extension"
+	"github.com/apache/dubbo-go/protocol"
+	"github.com/apache/dubbo-go/protocol/invocation"
+)
+
+// indexLoadBalance always selects the invoker of its index
+type indexLoadBalance int
+
+func (lb indexLoadBalance) Select(invokers []protocol.Invoker, invocation protocol.Invocation) protocol.Invoker {
+	return invokers[int(lb)]
+}
+
+func init() {
+	extension.SetLoadbalance("first", func() cluster.LoadBalance { return indexLoadBalance(0) })
+	extension.SetLoadbalance("second", func() cluster.LoadBalance { return indexLoadBalance(1) })
+}
+
+func abTestInvokers(params string) []protocol.Invoker {
+	var invokers []protocol.Invoker
+	for i := 0; i < 2; i++ {
+		url, _ := common.NewURL(context.TODO(), fmt.Sprintf("dubbo://192.168.1.%v:20000/org.apache.demo.HelloService?loadbalance=abtest&%s", i, params))
+		invokers = append(invokers, protocol.NewBaseInvoker(url))
+	}
+	return invokers
+}
+
+func TestABTestSplit(t *testing.T) {
+	invokers := abTestInvokers("abtest.loadbalances=first,second&abtest.percentage=20&abtest.key=uid")
+	loadBalance := NewABTestLoadBalance()
+
+	second := 0
+	for i := 0; i < 10000; i++ {
+		inv := invocation.NewRPCInvocation("getUser", nil, map[string]string{"uid": fmt.Sprintf("user%d", i)})
+		selected := loadBalance.Select(invokers, inv)
+		if selected == invokers[1] {
+			second++
+			assert.Equal(t, ABTestArmB, ABTestArm(invokers[0].GetUrl(), inv))
+		} else {
+			assert.Equal(t, ABTestArmA, ABTestArm(invokers[0].GetUrl(), inv))
+		}
+		// the same request key always goes to the same arm
+		assert.Equal(t, selected, loadBalance.Select(invokers, inv))
+	}
+	assert.InDelta(t, 2000, second, 200)
+
+	// the split by the arguments without the request key
+	invokers = abTestInvokers("abtest.loadbalances=first,second&methods.getUser.abtest.percentage=100")
+	assert.Equal(t, invokers[1], loadBalance.Select(invokers, invocation.NewRPCInvocation("getUser", []interface{}{"1"}, nil)))
+	invokers = abTestInvokers("abtest.loadbalances=first,second&abtest.percentage=0")
+	assert.Equal(t, invokers[0], loadBalance.Select(invokers, invocation.NewRPCInvocation("getUser", []interface{}{"1"}, nil)))
+}
+
+func TestABTestStats(t *testing.T) {
+	url := abTestInvokers("abtest.loadbalances=first,second&abtest.percentage=50&abtest.key=uid")[0].GetUrl()
+
+	var keys = map[string]string{}
+	for i := 0; len(keys) < 2; i++ {
+		inv := invocation.NewRPCInvocation("listUsers", nil, map[string]string{"uid": fmt.Sprintf("user%d", i)})
+		if _, ok := keys[ABTestArm(url, inv)]; !ok {
+			keys[ABTestArm(url, inv)] = fmt.Sprintf("user%d", i)
+		}
+	}
+	record := func(arm string, elapsed time.Duration, err error) {
+		inv := invocation.NewRPCInvocation("listUsers", nil, map[string]string{"uid": keys[arm]})
+		RecordABTest(url, inv, elapsed, err)
+	}
+	record(ABTestArmA, 10*time.Millisecond, nil)
+	record(ABTestArmA, 30*time.Millisecond, perrors.New("error"))
+	record(ABTestArmB, 50*time.Millisecond, nil)
+
+	assert.Equal(t, ABTestStats{Loadbalance: "first", Requests: 2, Failures: 1, AverageElapsed: 20 * time.Millisecond},
+		GetABTestStats(url, "listUsers", ABTestArmA))
+	assert.Equal(t, ABTestStats{Loadbalance: "second", Requests: 1, AverageElapsed: 50 * time.Millisecond},
+		GetABTestStats(url, "listUsers", ABTestArmB))
+
+	// the methods out of the A/B test aren't recorded
+	randomUrl, err := common.NewURL(context.TODO(), "dubbo://192.168.1.0:20000/org.apache.demo.HelloService?"+
+		"methods.listUsers.loadbalance=random&abtest.loadbalances=first,second&abtest.key=uid")
+	assert.NoError(t, err)
+	RecordABTest(randomUrl, invocation.NewRPCInvocation("listUsers", nil, map[string]string{"uid": keys[ABTestArmA]}), time.Second, nil)
+	assert.Equal(t, int64(2), GetABTestStats(url, "listUsers", ABTestArmA).Requests)
+}
@@ -0,0 +1,220 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package loadbalance
+
+import (
+	"crypto/md5"
+	"encoding/binary"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+import (
+	"github.com/apache/dubbo-go/cluster"
+	"github.com/apache/dubbo-go/common"
+	"github.com/apache/dubbo-go/common/constant"
+	"github.com/apache/dubbo-go/common/extension"
+	"github.com/apache/dubbo-go/protocol"
+)
+
+const (
+	ConsistentHash = "consistenthash"
+	// the max rings of a method for the different invoker sets, e.g. of the references to different registries
+	maxSelectorsPerMethod = 4
+)
+
+var (
+	// the rings of the methods, the load balances are created for every invocation so they're shared
+	selectors sync.Map // [string][]*consistentHashSelector, the recently built first
+)
+
+func init() {
+	extension.SetLoadbalance(ConsistentHash, NewConsistentHashLoadBalance)
+}
+
+// consistentHashLoadBalance selects the same provider for the same arguments, each provider has hash.nodes
+// virtual nodes on a ring, so only about 1/n of the arguments are remapped when a provider joins or leaves.
+type consistentHashLoadBalance struct {
+}
+
+func NewConsistentHashLoadBalance() cluster.LoadBalance {
+	return &consistentHashLoadBalance{}
+}
+
+func (lb *consistentHashLoadBalance) Select(invokers []protocol.Invoker, invocation protocol.Invocation) protocol.Invoker {
+	count := len(invokers)
+	if count == 0 {
+		return nil
+	}
+	if count == 1 {
+		return invokers[0]
+	}
+
+	key := selectorKey(invokers[0].GetUrl(), invocation.MethodName())
+	// the selectors are immutable, the ring is built only for the invoker set not cached
+	var cached []*consistentHashSelector
+	if value, ok := selectors.Load(key); ok {
+		cached = value.([]*consistentHashSelector)
+	}
+	for _, selector := range cached {
+		if selector.sameInvokers(invokers) {
+			return selector.selectByArguments(invocation.Arguments())
+		}
+	}
+	selector := newConsistentHashSelector(invokers, invocation.MethodName())
+	pruneSelectors()
+	updated := append([]*consistentHashSelector{selector}, liveSelectors(cached)...)
+	if len(updated) > maxSelectorsPerMethod {
+		updated = updated[:maxSelectorsPerMethod]
+	}
+	selectors.Store(key, updated)
+	return selector.selectByArguments(invocation.Arguments())
+}
+
+// pruneSelectors drops the rings with the destroyed invokers, e.g. of the destroyed references or the providers
+// left. It's done when a ring is built, which is rare.
+func pruneSelectors() {
+	selectors.Range(func(key, value interface{}) bool {
+		cached := value.([]*consistentHashSelector)
+		if live := liveSelectors(cached); len(live) == 0 {
+			selectors.Delete(key)
+		} else if len(live) < len(cached) {
+			selectors.Store(key, live)
+		}
+		return true
+	})
+}
+
+func liveSelectors(cached []*consistentHashSelector) []*consistentHashSelector {
+	live := make([]*consistentHashSelector, 0, len(cached))
+	for _, selector := range cached {
+		if !selector.destroyed() {
+			live = append(live, selector)
+		}
+	}
+	return live
+}
+
+// selectorKey is the key of the selector of the method in the service of the @url
+func selectorKey(url common.URL, methodName string) string {
+	return url.ServiceKey() + "." + methodName
+}
+
+type consistentHashSelector struct {
+	members         map[protocol.Invoker]struct{}
+	ring            []uint32
+	nodes           map[uint32]protocol.Invoker
+	argumentIndexes []int
+}
+
+func newConsistentHashSelector(invokers []protocol.Invoker, methodName string) *consistentHashSelector {
+	url := invokers[0].GetUrl()
+	replicas := url.GetMethodParamInt64(methodName, constant.HASH_NODES_KEY, constant.DEFAULT_HASH_NODES)
+	if replicas <= 0 {
+		replicas = constant.DEFAULT_HASH_NODES
+	}
+	arguments := url.GetMethodParam(methodName, constant.HASH_ARGUMENTS_KEY,
+		url.GetParam(constant.HASH_ARGUMENTS_KEY, constant.DEFAULT_HASH_ARGUMENTS))
+
+	selector := &consistentHashSelector{
+		members: make(map[protocol.Invoker]struct{}, len(invokers)),
+		nodes:   make(map[uint32]protocol.Invoker, len(invokers)*int(replicas)),
+	}
+	for _, index := range strings.Split(arguments, ",") {
+		if i, err := strconv.Atoi(strings.TrimSpace(index)); err == nil && i >= 0 {
+			selector.argumentIndexes = append(selector.argumentIndexes, i)
+		}
+	}
+
+	// the virtual nodes are placed by the address, so they don't depend on the order of the invokers
+	for _, invoker := range sortInvokers(invokers) {
+		selector.members[invoker] = struct{}{}
+		address := invoker.GetUrl().Location
+		// each digest makes 4 virtual nodes
+		for i := int64(0); i < (replicas+3)/4; i++ {
+			digest := md5.Sum([]byte(address + strconv.FormatInt(i, 10)))
+			for j := 0; j < 4; j++ {
+				hash := binary.LittleEndian.Uint32(digest[j*4:])
+				if _, ok := selector.nodes[hash]; !ok {
+					selector.ring = append(selector.ring, hash)
+				}
+				selector.nodes[hash] = invoker
+			}
+		}
+	}
+	sort.Slice(selector.ring, func(i, j int) bool {
+		return selector.ring[i] < selector.ring[j]
+	})
+	return selector
+}
+
+// destroyed returns true if any of the invokers of the ring is destroyed
+func (s *consistentHashSelector) destroyed() bool {
+	for invoker := range s.members {
+		if d, ok := invoker.(interface{ IsDestroyed() bool }); ok && d.IsDestroyed() {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *consistentHashSelector) sameInvokers(invokers []protocol.Invoker) bool {
+	if len(invokers) != len(s.members) {
+		return false
+	}
+	for _, invoker := range invokers {
+		if _, ok := s.members[invoker]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *consistentHashSelector) selectByArguments(arguments []interface{}) protocol.Invoker {
+	return s.selectByHash(hashArguments(s.hashKey(arguments)))
+}
+
+// selectByHash returns the invoker of the first virtual node clockwise from @hash
+func (s *consistentHashSelector) selectByHash(hash uint32) protocol.Invoker {
+	i := sort.Search(len(s.ring), func(i int) bool {
+		return s.ring[i] >= hash
+	})
+	if i == len(s.ring) {
+		i = 0
+	}
+	return s.nodes[s.ring[i]]
+}
+
+// hashKey joins the arguments selected by hash.arguments
+func (s *consistentHashSelector) hashKey(arguments []interface{}) string {
+	var key strings.Builder
+	for _, i := range s.argumentIndexes {
+		if i < len(arguments) {
+			key.WriteString(fmt.Sprint(arguments[i]))
+		}
+	}
+	return key.String()
+}
+
+func hashArguments(key string) uint32 {
+	digest := md5.Sum([]byte(key))
+	return binary.LittleEndian.Uint32(digest[:4])
+}
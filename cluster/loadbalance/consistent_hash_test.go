@@ -0,0 +1,192 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package loadbalance
+
+import (
+	"context"
+	"fmt"
+	"testing"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+import (
+	"github.com/apache/dubbo-go/common"
+	"github.com/apache/dubbo-go/protocol"
+	"github.com/apache/dubbo-go/protocol/invocation"
+)
+
+func consistentHashInvokers(n int, params string) []protocol.Invoker {
+	var invokers []protocol.Invoker
+	for i := 0; i < n; i++ {
+		url, _ := common.NewURL(context.TODO(), fmt.Sprintf("dubbo://192.168.1.%v:20000/org.apache.demo.HelloService%v", i, params))
+		invokers = append(invokers, protocol.NewBaseInvoker(url))
+	}
+	return invokers
+}
+
+func hashInvocation(arguments ...interface{}) protocol.Invocation {
+	return invocation.NewRPCInvocation("getUser", arguments, nil)
+}
+
+func TestConsistentHashSticky(t *testing.T) {
+	loadBalance := NewConsistentHashLoadBalance()
+	invokers := consistentHashInvokers(10, "")
+
+	for i := 0; i < 100; i++ {
+		key := fmt.Sprintf("user%v", i)
+		selected := loadBalance.Select(invokers, hashInvocation(key, i))
+		for j := 0; j < 5; j++ {
+			// only the first argument is hashed by default
+			assert.Equal(t, selected, loadBalance.Select(invokers, hashInvocation(key, j)))
+		}
+	}
+}
+
+func TestConsistentHashArguments(t *testing.T) {
+	loadBalance := NewConsistentHashLoadBalance()
+	invokers := consistentHashInvokers(10, "?hash.arguments=1")
+
+	selected := loadBalance.Select(invokers, hashInvocation("user", "key"))
+	different := false
+	for i := 0; i < 20; i++ {
+		assert.Equal(t, selected, loadBalance.Select(invokers, hashInvocation(fmt.Sprintf("user%v", i), "key")))
+		different = different || selected != loadBalance.Select(invokers, hashInvocation("user", fmt.Sprintf("key%v", i)))
+	}
+	assert.True(t, different)
+}
+
+func TestConsistentHashDistribution(t *testing.T) {
+	invokers := consistentHashInvokers(5, "?hash.nodes=40")
+	selector := newConsistentHashSelector(invokers, "getUser")
+	assert.Equal(t, 5*40, len(selector.ring))
+
+	nodes := make(map[protocol.Invoker]int)
+	for _, invoker := range selector.nodes {
+		nodes[invoker]++
+	}
+	for _, invoker := range invokers {
+		assert.Equal(t, 40, nodes[invoker])
+	}
+
+	selected := make(map[protocol.Invoker]int)
+	for i := 0; i < 10000; i++ {
+		selected[selector.selectByArguments([]interface{}{fmt.Sprintf("user%v", i)})]++
+	}
+	for _, invoker := range invokers {
+		assert.True(t, selected[invoker] > 1000, "%v selected %v times", invoker.GetUrl().Location, selected[invoker])
+	}
+}
+
+func TestConsistentHashRebuild(t *testing.T) {
+	lb := NewConsistentHashLoadBalance()
+	invokers := consistentHashInvokers(10, "")
+
+	before := make([]protocol.Invoker, 1000)
+	for i := range before {
+		before[i] = lb.Select(invokers, hashInvocation(fmt.Sprintf("user%v", i)))
+	}
+	cached := cachedSelector(invokers)
+
+	// the ring is shared by the load balances of the service
+	assert.Equal(t, before[0], NewConsistentHashLoadBalance().Select(invokers, hashInvocation("user0")))
+	assert.True(t, cached == cachedSelector(invokers))
+
+	// the ring is kept for the same invokers in another order
+	reversed := make([]protocol.Invoker, len(invokers))
+	for i, invoker := range invokers {
+		reversed[len(invokers)-1-i] = invoker
+	}
+	lb.Select(reversed, hashInvocation("user0"))
+	assert.True(t, cached == cachedSelector(invokers))
+
+	// the provider leaves, only the keys on it are remapped
+	removed := invokers[3]
+	left := append(append([]protocol.Invoker{}, invokers[:3]...), invokers[4:]...)
+	remapped := 0
+	for i := range before {
+		selected := lb.Select(left, hashInvocation(fmt.Sprintf("user%v", i)))
+		assert.NotEqual(t, removed, selected)
+		if before[i] != removed {
+			assert.Equal(t, before[i], selected)
+		} else {
+			remapped++
+		}
+	}
+	assert.True(t, remapped > 0 && remapped < 200, "remapped %v", remapped)
+	assert.NotNil(t, cachedSelector(left))
+
+	// the provider joins again, the keys are mapped back by the ring kept
+	for i := range before {
+		assert.Equal(t, before[i], lb.Select(invokers, hashInvocation(fmt.Sprintf("user%v", i))))
+	}
+	assert.True(t, cached == cachedSelector(invokers))
+}
+
+func TestConsistentHashInvokerSets(t *testing.T) {
+	lb := NewConsistentHashLoadBalance()
+	invokers := consistentHashInvokers(6, "?group=sets")
+	// the references of the same service to the different registries
+	zone1, zone2 := invokers[:3], invokers[3:]
+
+	lb.Select(zone1, hashInvocation("user0"))
+	lb.Select(zone2, hashInvocation("user0"))
+	cached1, cached2 := cachedSelector(zone1), cachedSelector(zone2)
+	assert.NotNil(t, cached1)
+	assert.NotNil(t, cached2)
+
+	// the rings of the invoker sets don't overwrite each other
+	for i := 0; i < 10; i++ {
+		lb.Select(zone1, hashInvocation(fmt.Sprintf("user%v", i)))
+		lb.Select(zone2, hashInvocation(fmt.Sprintf("user%v", i)))
+	}
+	assert.True(t, cached1 == cachedSelector(zone1))
+	assert.True(t, cached2 == cachedSelector(zone2))
+
+	// the ring of the destroyed reference is dropped when a ring is built
+	for _, invoker := range zone2 {
+		invoker.Destroy()
+	}
+	lb.Select(invokers[:2], hashInvocation("user0"))
+	assert.Nil(t, cachedSelector(zone2))
+	assert.True(t, cached1 == cachedSelector(zone1))
+
+	// the rings of a method are limited
+	for i := 1; i <= maxSelectorsPerMethod; i++ {
+		lb.Select(zone1[:1+i%3], hashInvocation("user0"))
+		lb.Select(append([]protocol.Invoker{}, zone1[i%3], zone1[(i+1)%3]), hashInvocation("user0"))
+	}
+	cached, _ := selectors.Load(selectorKey(zone1[0].GetUrl(), "getUser"))
+	assert.True(t, len(cached.([]*consistentHashSelector)) <= maxSelectorsPerMethod)
+}
+
+// cachedSelector returns the cached ring of the @invokers, or nil
+func cachedSelector(invokers []protocol.Invoker) *consistentHashSelector {
+	cached, ok := selectors.Load(selectorKey(invokers[0].GetUrl(), "getUser"))
+	if !ok {
+		return nil
+	}
+	for _, selector := range cached.([]*consistentHashSelector) {
+		if selector.sameInvokers(invokers) {
+			return selector
+		}
+	}
+	return nil
+}
@@ -19,11 +19,14 @@
 package loadbalance
 
 import (
-	"math/rand"
+	"sort"
+	"sync"
+	"sync/atomic"
 )
 
 import (
 	"github.com/apache/dubbo-go/cluster"
+	"github.com/apache/dubbo-go/common/constant"
 	"github.com/apache/dubbo-go/common/extension"
 	"github.com/apache/dubbo-go/protocol"
 )
@@ -32,6 +35,9 @@ const (
 	LeastActive = "leastactive"
 )
 
+// the round robin sequences of the tied providers by service and method
+var tieBreakSequences sync.Map // service.method -> *uint64
+
 func init() {
 	extension.SetLoadbalance(LeastActive, NewLeastActiveLoadBalance)
 }
@@ -87,20 +93,35 @@ func (lb *leastActiveLoadBalance) Select(invokers []protocol.Invoker, invocation
 	}
 
 	if leastCount == 1 {
-		return invokers[0]
+		return invokers[leastIndexes[0]]
+	}
+
+	// break the tie by the order of address rather than the order of invokers
+	leastIndexes = leastIndexes[:leastCount]
+	ranks := getTieBreakOrder(invokers).ranks
+	sort.Slice(leastIndexes, func(i, j int) bool {
+		return ranks[invokers[leastIndexes[i]]] < ranks[invokers[leastIndexes[j]]]
+	})
+
+	url := invokers[0].GetUrl()
+	tieBreak := url.GetMethodParam(invocation.MethodName(), constant.LEAST_ACTIVE_TIE_BREAK_KEY,
+		url.GetParam(constant.LEAST_ACTIVE_TIE_BREAK_KEY, constant.DEFAULT_LOADBALANCE))
+	if tieBreak == RoundRobin {
+		sequence, _ := tieBreakSequences.LoadOrStore(url.Path+"."+invocation.MethodName(), new(uint64))
+		return invokers[leastIndexes[(atomic.AddUint64(sequence.(*uint64), 1)-1)%uint64(leastCount)]]
 	}
 
 	if !sameWeight && totalWeight > 0 {
-		offsetWeight := rand.Int63n(totalWeight) + 1
+		offsetWeight := randInt63n(totalWeight) + 1
 		for i := 0; i < leastCount; i++ {
 			leastIndex := leastIndexes[i]
-			offsetWeight -= GetWeight(invokers[i], invocation)
+			offsetWeight -= GetWeight(invokers[leastIndex], invocation)
 			if offsetWeight <= 0 {
 				return invokers[leastIndex]
 			}
 		}
 	}
 
-	index := leastIndexes[rand.Intn(leastCount)]
+	index := leastIndexes[randIntn(leastCount)]
 	return invokers[index]
 }
@@ -20,6 +20,7 @@ package loadbalance
 import (
 	"context"
 	"fmt"
+	"math/rand"
 	"testing"
 )
 
@@ -81,3 +82,76 @@ func TestLeastActiveByWeight(t *testing.T) {
 
 	assert.Equal(t, firstCount+secondCount, loop)
 }
+
+func TestLeastActiveTieBreak(t *testing.T) {
+	defer func() {
+		randIntn = rand.Intn
+	}()
+	loadBalance := NewLeastActiveLoadBalance()
+	invokers, reversed := tieInvokers()
+
+	inv := invocation.NewRPCInvocationWithOptions(invocation.WithMethodName("tie"))
+	for i := 0; i < len(invokers); i++ {
+		randIntn = func(int) int { return i }
+		assert.Equal(t, loadBalance.Select(invokers, inv), loadBalance.Select(reversed, inv))
+	}
+
+	// the least active one is selected rather than the first one
+	protocol.BeginCount(invokers[0].GetUrl(), "least")
+	for _, ivk := range invokers[2:] {
+		protocol.BeginCount(ivk.GetUrl(), "least")
+	}
+	inv = invocation.NewRPCInvocationWithOptions(invocation.WithMethodName("least"))
+	assert.Equal(t, invokers[1], loadBalance.Select(invokers, inv))
+}
+
+func tiedInvokers(params string, weights ...int) []protocol.Invoker {
+	var invokers []protocol.Invoker
+	for i, weight := range weights {
+		url, _ := common.NewURL(context.TODO(), fmt.Sprintf("dubbo://192.168.2.%v:20000/com.ikurento.user.TieProvider?weight=%v&%v", i, weight, params))
+		invokers = append(invokers, protocol.NewBaseInvoker(url))
+	}
+	return invokers
+}
+
+func TestLeastActiveTieBreakRoundRobin(t *testing.T) {
+	loadBalance := NewLeastActiveLoadBalance()
+	invokers := tiedInvokers("leastactive.tiebreak=roundrobin", 100, 100, 100, 100)
+	url, _ := common.NewURL(context.TODO(), "dubbo://192.168.2.9:20000/com.ikurento.user.TieProvider")
+	busy := protocol.NewBaseInvoker(url)
+	protocol.BeginCount(busy.GetUrl(), "roundrobin")
+	defer protocol.EndCount(busy.GetUrl(), "roundrobin")
+	invokers = append(invokers, busy)
+
+	// the tied providers are selected in turn, the busy one is never selected
+	inv := invocation.NewRPCInvocationWithOptions(invocation.WithMethodName("roundrobin"))
+	counts := map[string]int{}
+	var previous protocol.Invoker
+	for i := 0; i < 400; i++ {
+		selected := loadBalance.Select(invokers, inv)
+		assert.NotEqual(t, previous, selected)
+		previous = selected
+		counts[selected.GetUrl().Ip]++
+	}
+	assert.Len(t, counts, 4)
+	for _, count := range counts {
+		assert.Equal(t, 100, count)
+	}
+}
+
+func TestLeastActiveTieBreakWeightedRandom(t *testing.T) {
+	loadBalance := NewLeastActiveLoadBalance()
+	// the method config overrides the service one
+	invokers := tiedInvokers("leastactive.tiebreak=roundrobin&methods.random.leastactive.tiebreak=random", 100, 200, 300)
+
+	inv := invocation.NewRPCInvocationWithOptions(invocation.WithMethodName("random"))
+	counts := map[string]int{}
+	loop := 60000
+	for i := 0; i < loop; i++ {
+		counts[loadBalance.Select(invokers, inv).GetUrl().Ip]++
+	}
+	for i, weight := range []int{100, 200, 300} {
+		expected := loop * weight / 600
+		assert.InDelta(t, expected, counts[fmt.Sprintf("192.168.2.%v", i)], float64(expected)/10)
+	}
+}
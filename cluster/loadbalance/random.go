@@ -17,10 +17,6 @@
 
 package loadbalance
 
-import (
-	"math/rand"
-)
-
 import (
 	"github.com/apache/dubbo-go/cluster"
 	"github.com/apache/dubbo-go/common/extension"
@@ -47,6 +43,7 @@ func (lb *randomLoadBalance) Select(invokers []protocol.Invoker, invocation prot
 	if length = len(invokers); length == 1 {
 		return invokers[0]
 	}
+	invokers = sortInvokers(invokers)
 	sameWeight := true
 	weights := make([]int64, length)
 
@@ -66,7 +63,7 @@ func (lb *randomLoadBalance) Select(invokers []protocol.Invoker, invocation prot
 
 	if totalWeight > 0 && !sameWeight {
 		// If (not every invoker has the same weight & at least one invoker's weight>0), select randomly based on totalWeight.
-		offset := rand.Int63n(totalWeight)
+		offset := randInt63n(totalWeight)
 
 		for i := 0; i < length; i++ {
 			offset -= weights[i]
@@ -76,5 +73,5 @@ func (lb *randomLoadBalance) Select(invokers []protocol.Invoker, invocation prot
 		}
 	}
 	// If all invokers have the same weight value or totalWeight=0, return evenly.
-	return invokers[rand.Intn(length)]
+	return invokers[randIntn(length)]
 }
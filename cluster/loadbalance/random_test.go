@@ -20,6 +20,7 @@ package loadbalance
 import (
 	"context"
 	"fmt"
+	"math/rand"
 	"net/url"
 	"strconv"
 	"testing"
@@ -113,3 +114,29 @@ func Test_RandomlbSelectWarmup(t *testing.T) {
 		return selected/10000 < 0.1
 	})
 }
+
+func tieInvokers() ([]protocol.Invoker, []protocol.Invoker) {
+	var invokers, reversed []protocol.Invoker
+	for i := 0; i < 10; i++ {
+		url, _ := common.NewURL(context.TODO(), fmt.Sprintf("dubbo://192.168.1.%v:20000/com.ikurento.user.UserProvider", i))
+		invokers = append(invokers, protocol.NewBaseInvoker(url))
+	}
+	for i := len(invokers) - 1; i >= 0; i-- {
+		reversed = append(reversed, invokers[i])
+	}
+	return invokers, reversed
+}
+
+func Test_RandomlbSelectTieBreak(t *testing.T) {
+	defer func() {
+		randIntn = rand.Intn
+	}()
+	randomlb := NewRandomLoadBalance()
+	invokers, reversed := tieInvokers()
+
+	for i := 0; i < len(invokers); i++ {
+		randIntn = func(int) int { return i }
+		// the same random number selects the same invoker whatever the order of invokers is
+		assert.Equal(t, randomlb.Select(invokers, &invocation.RPCInvocation{}), randomlb.Select(reversed, &invocation.RPCInvocation{}))
+	}
+}
@@ -73,6 +73,7 @@ func (lb *roundRobinLoadBalance) Select(invokers []protocol.Invoker, invocation
 		now                 = time.Now()
 		selectedInvoker     protocol.Invoker
 		selectedWeightRobin *weightedRoundRobin
+		ranks               = getTieBreakOrder(invokers).ranks
 	)
 
 	for _, invoker := range invokers {
@@ -95,7 +96,9 @@ func (lb *roundRobinLoadBalance) Select(invokers []protocol.Invoker, invocation
 		currentWeight := weightRobin.increaseCurrent()
 		weightRobin.lastUpdate = &now
 
-		if currentWeight > maxCurrentWeight {
+		// break the tie by the order of address rather than the order of invokers
+		if currentWeight > maxCurrentWeight ||
+			(currentWeight == maxCurrentWeight && selectedInvoker != nil && ranks[invoker] < ranks[selectedInvoker]) {
 			maxCurrentWeight = currentWeight
 			selectedInvoker = invoker
 			selectedWeightRobin = weightRobin
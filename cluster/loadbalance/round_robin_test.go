@@ -57,3 +57,15 @@ func TestRoundRobinByWeight(t *testing.T) {
 		assert.True(t, selected[i] == w)
 	}
 }
+
+func TestRoundRobinTieBreak(t *testing.T) {
+	loadBalance := NewRoundRobinLoadBalance()
+	invokers, reversed := tieInvokers()
+
+	// all the invokers have the same weight, the selections from a fresh state are the same
+	for i := 0; i < len(invokers); i++ {
+		assert.Equal(t,
+			loadBalance.Select(invokers, invocation.NewRPCInvocationWithOptions(invocation.WithMethodName("tie1"))),
+			loadBalance.Select(reversed, invocation.NewRPCInvocationWithOptions(invocation.WithMethodName("tie2"))))
+	}
+}
@@ -0,0 +1,115 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package loadbalance
+
+import (
+	"github.com/apache/dubbo-go/cluster"
+	"github.com/apache/dubbo-go/common/constant"
+	"github.com/apache/dubbo-go/common/extension"
+	"github.com/apache/dubbo-go/protocol"
+)
+
+const (
+	Score = "score"
+
+	// the built-in scorers
+	WeightScorer      = "weight"
+	LeastActiveScorer = "leastactive"
+	LatencyScorer     = "latency"
+	FailureRateScorer = "failurerate"
+)
+
+func init() {
+	extension.SetLoadbalance(Score, NewScoreLoadBalance)
+
+	// all the providers are tied, they're selected randomly by their weights as the random load balance
+	extension.SetScorer(WeightScorer, newScorer(func(stats cluster.ProviderStats) float64 {
+		return 0
+	}))
+	// the same as the least active load balance
+	extension.SetScorer(LeastActiveScorer, newScorer(func(stats cluster.ProviderStats) float64 {
+		return -float64(stats.Active)
+	}))
+	extension.SetScorer(LatencyScorer, newScorer(func(stats cluster.ProviderStats) float64 {
+		return -float64(stats.AverageElapsed)
+	}))
+	extension.SetScorer(FailureRateScorer, newScorer(func(stats cluster.ProviderStats) float64 {
+		return -stats.FailureRate
+	}))
+}
+
+func newScorer(score func(stats cluster.ProviderStats) float64) func() cluster.Scorer {
+	scorer := cluster.ScorerFunc(func(_ protocol.Invoker, _ protocol.Invocation, stats cluster.ProviderStats) float64 {
+		return score(stats)
+	})
+	return func() cluster.Scorer {
+		return scorer
+	}
+}
+
+// scoreLoadBalance selects the provider of the highest score by the scorer configured by the method,
+// the tied providers are selected randomly by their weights.
+type scoreLoadBalance struct {
+}
+
+func NewScoreLoadBalance() cluster.LoadBalance {
+	return &scoreLoadBalance{}
+}
+
+func (lb *scoreLoadBalance) Select(invokers []protocol.Invoker, invocation protocol.Invocation) protocol.Invoker {
+	count := len(invokers)
+	if count == 0 {
+		return nil
+	}
+	if count == 1 {
+		return invokers[0]
+	}
+
+	url := invokers[0].GetUrl()
+	scorer := extension.GetScorer(url.GetMethodParam(invocation.MethodName(), constant.SCORER_KEY,
+		url.GetParam(constant.SCORER_KEY, constant.DEFAULT_SCORER)))
+	var (
+		bestScore float64
+		best      = make([]protocol.Invoker, 0, count)
+	)
+	for i, ivk := range invokers {
+		score := scorer.Score(ivk, invocation, GetProviderStats(ivk, invocation))
+		if i == 0 || score > bestScore {
+			bestScore = score
+			best = append(best[:0], ivk)
+		} else if score == bestScore {
+			best = append(best, ivk)
+		}
+	}
+
+	if len(best) == 1 {
+		return best[0]
+	}
+	return NewRandomLoadBalance().Select(best, invocation)
+}
+
+// GetProviderStats collects the statistics of the provider for the method of the invocation
+func GetProviderStats(invoker protocol.Invoker, invocation protocol.Invocation) cluster.ProviderStats {
+	status := protocol.GetStatus(invoker.GetUrl(), invocation.MethodName())
+	return cluster.ProviderStats{
+		Weight:         GetWeight(invoker, invocation),
+		Active:         status.GetActive(),
+		AverageElapsed: status.GetAverageElapsed(),
+		FailureRate:    status.GetFailureRate(),
+	}
+}
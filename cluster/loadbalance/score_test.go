@@ -0,0 +1,122 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package loadbalance
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"testing"
+	"time"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+import (
+	"github.com/apache/dubbo-go/cluster"
+	"github.com/apache/dubbo-go/common"
+	"github.com/apache/dubbo-go/common/extension"
+	"github.com/apache/dubbo-go/protocol"
+	"github.com/apache/dubbo-go/protocol/invocation"
+)
+
+// scoreInvokers are weighted 1, 2, 3 ...
+func scoreInvokers(scorer string, count int) []protocol.Invoker {
+	var invokers []protocol.Invoker
+	for i := 1; i <= count; i++ {
+		url, _ := common.NewURL(context.TODO(), fmt.Sprintf("dubbo://192.168.1.%v:20000/com.ikurento.user.UserProvider?weight=%v&scorer=%v", i, i, scorer))
+		invokers = append(invokers, protocol.NewBaseInvoker(url))
+	}
+	return invokers
+}
+
+func Test_ScoreCustomScorer(t *testing.T) {
+	// the lighter the better, the weight 2 and 3 are tied
+	extension.SetScorer("score_test", func() cluster.Scorer {
+		return cluster.ScorerFunc(func(_ protocol.Invoker, _ protocol.Invocation, stats cluster.ProviderStats) float64 {
+			if stats.Weight <= 3 {
+				return 0
+			}
+			return -float64(stats.Weight)
+		})
+	})
+	loadBalance := NewScoreLoadBalance()
+	invokers := scoreInvokers("score_test", 5)
+	inv := invocation.NewRPCInvocationWithOptions(invocation.WithMethodName("custom"))
+
+	selected := map[protocol.Invoker]int{}
+	for i := 0; i < 1000; i++ {
+		selected[loadBalance.Select(invokers, inv)]++
+	}
+	assert.Equal(t, 3, len(selected))
+	assert.Equal(t, 1000, selected[invokers[0]]+selected[invokers[1]]+selected[invokers[2]])
+
+	assert.Nil(t, loadBalance.Select(nil, inv))
+}
+
+func Test_ScoreBuiltinScorers(t *testing.T) {
+	defer func() {
+		randIntn = rand.Intn
+		randInt63n = rand.Int63n
+	}()
+	loadBalance := NewScoreLoadBalance()
+	inv := invocation.NewRPCInvocationWithOptions(invocation.WithMethodName("builtin"))
+
+	// the weight scorer reproduces the random load balance
+	invokers := scoreInvokers(WeightScorer, 5)
+	for i := int64(0); i < 15; i++ {
+		randInt63n = func(n int64) int64 { return i % n }
+		assert.Equal(t, NewRandomLoadBalance().Select(invokers, inv), loadBalance.Select(invokers, inv))
+	}
+
+	// the least active scorer reproduces the least active load balance, the first two are the least active
+	invokers = scoreInvokers(LeastActiveScorer, 5)
+	for _, ivk := range invokers[2:] {
+		protocol.BeginCount(ivk.GetUrl(), inv.MethodName())
+	}
+	for i := int64(0); i < 3; i++ {
+		randInt63n = func(n int64) int64 { return i % n }
+		selected := loadBalance.Select(invokers, inv)
+		assert.Equal(t, NewLeastActiveLoadBalance().Select(invokers, inv), selected)
+		assert.Contains(t, invokers[:2], selected)
+	}
+}
+
+func Test_ScoreLatencyAndFailureRate(t *testing.T) {
+	loadBalance := NewScoreLoadBalance()
+	inv := invocation.NewRPCInvocationWithOptions(invocation.WithMethodName("stats"))
+
+	invokers := scoreInvokers(LatencyScorer, 3)
+	for i, ivk := range invokers {
+		protocol.RecordElapsed(ivk.GetUrl(), inv.MethodName(), time.Duration(10-i)*time.Millisecond, true)
+	}
+	assert.Equal(t, invokers[2], loadBalance.Select(invokers, inv))
+	assert.Equal(t, 8*time.Millisecond, GetProviderStats(invokers[2], inv).AverageElapsed)
+
+	inv = invocation.NewRPCInvocationWithOptions(invocation.WithMethodName("failures"))
+	invokers = scoreInvokers(FailureRateScorer, 3)
+	for i, ivk := range invokers {
+		for j := 0; j < 4; j++ {
+			protocol.RecordElapsed(ivk.GetUrl(), inv.MethodName(), time.Millisecond, j >= i)
+		}
+	}
+	assert.Equal(t, invokers[0], loadBalance.Select(invokers, inv))
+	assert.Equal(t, 0.5, GetProviderStats(invokers[2], inv).FailureRate)
+}
@@ -18,32 +18,177 @@
 package loadbalance
 
 import (
+	"hash/fnv"
+	"math/rand"
+	"sort"
+	"strconv"
+	"sync"
 	"time"
 )
 
 import (
+	"github.com/apache/dubbo-go/common"
 	"github.com/apache/dubbo-go/common/constant"
+	"github.com/apache/dubbo-go/common/logger"
 	"github.com/apache/dubbo-go/protocol"
 )
 
+// the times the providers of the start timestamps in the future are seen first, they're warmed up from then
+var skewedStarts sync.Map // [string]time.Time
+
 func GetWeight(invoker protocol.Invoker, invocation protocol.Invocation) int64 {
 	url := invoker.GetUrl()
 	weight := url.GetMethodParamInt64(invocation.MethodName(), constant.WEIGHT_KEY, constant.DEFAULT_WEIGHT)
 
 	if weight > 0 {
+		configured := weight
 		//get service register time an do warm up time
-		now := time.Now().Unix()
-		timestamp := url.GetParamInt(constant.REMOTE_TIMESTAMP_KEY, now)
-		if uptime := now - timestamp; uptime > 0 {
-			warmup := url.GetParamInt(constant.WARMUP_KEY, constant.DEFAULT_WARMUP)
-			if uptime < warmup {
-				if ww := float64(uptime) / float64(warmup) / float64(weight); ww < 1 {
-					weight = 1
-				} else if int64(ww) <= weight {
-					weight = int64(ww)
-				}
+		warmup := time.Duration(url.GetParamInt(constant.WARMUP_KEY, constant.DEFAULT_WARMUP)) * time.Second
+		if uptime, ok := providerUptime(url, warmup); ok {
+			weight = warmupWeight(configured, uptime, warmup)
+		}
+		// the provider reconnected after a blip is warmed up again from the reconnection like a fresh start
+		if reconnected := protocol.GetReconnectTime(url.Location); !reconnected.IsZero() {
+			// 0 disables the warmup rather than referring to the default
+			warmup, err := strconv.ParseInt(url.GetParam(constant.RECONNECT_WARMUP_KEY, ""), 10, 64)
+			if err != nil {
+				warmup = constant.DEFAULT_RECONNECT_WARMUP
+			}
+			if ww := warmupWeight(configured, time.Since(reconnected), time.Duration(warmup)*time.Second); ww < weight {
+				weight = ww
 			}
 		}
 	}
 	return weight
 }
+
+// providerUptime returns the uptime of the provider of the @url by its start timestamp, it's not ok without the
+// timestamp. The start timestamp in the future by the clock skew is clamped to the time it's seen first, so the
+// warmup remaining is in [0, @warmup].
+func providerUptime(url common.URL, warmup time.Duration) (time.Duration, bool) {
+	timestamp, err := strconv.ParseInt(url.GetParam(constant.REMOTE_TIMESTAMP_KEY, ""), 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	now := timeNow()
+	uptime := now.Sub(time.Unix(timestamp, 0))
+	key := url.Location + "@" + strconv.FormatInt(timestamp, 10)
+	if uptime >= 0 {
+		if uptime >= warmup {
+			skewedStarts.Delete(key)
+		} else if first, ok := skewedStarts.Load(key); ok && now.Sub(first.(time.Time)) > uptime {
+			// keep warming up from the time it's seen first after the clock catches up
+			uptime = now.Sub(first.(time.Time))
+		}
+		return uptime, uptime > 0
+	}
+
+	if url.GetParam(constant.WARMUP_SKEW_KEY, constant.DEFAULT_WARMUP_SKEW) == constant.WARMUP_SKEW_IGNORE {
+		return 0, false
+	}
+	first, loaded := skewedStarts.LoadOrStore(key, now)
+	if !loaded {
+		logger.Warnf("the start timestamp of the provider %s is %v ahead of the clock, it's warmed up from now",
+			url.Location, -uptime)
+	}
+	return now.Sub(first.(time.Time)), true
+}
+
+// warmupWeight ramps the @weight up linearly by the @uptime in the @warmup, it's 1 at least
+func warmupWeight(weight int64, uptime time.Duration, warmup time.Duration) int64 {
+	if uptime < 0 || uptime >= warmup {
+		return weight
+	}
+	if ww := int64(float64(weight) * float64(uptime) / float64(warmup)); ww > 1 {
+		return ww
+	}
+	return 1
+}
+
+var (
+	// replaceable in test to make the random selection reproducible
+	randInt63n = rand.Int63n
+	randIntn   = rand.Intn
+	// replaceable in test to move the clock
+	timeNow = time.Now
+)
+
+// the tie break orders of the invokers of every service, they're recomputed only when the invokers change
+var tieBreakOrders sync.Map // service key -> *tieBreakOrder
+
+// tieBreakOrder orders the invokers by the hash of their address, the order of the invokers passed to
+// load balance comes from map iteration, so the selection among the tied invokers must not depend on it.
+type tieBreakOrder struct {
+	sorted []protocol.Invoker
+	ranks  map[protocol.Invoker]int
+}
+
+func newTieBreakOrder(invokers []protocol.Invoker) *tieBreakOrder {
+	order := &tieBreakOrder{
+		sorted: make([]protocol.Invoker, len(invokers)),
+		ranks:  make(map[protocol.Invoker]int, len(invokers)),
+	}
+	hashes := make(map[protocol.Invoker]uint32, len(invokers))
+	keys := make(map[protocol.Invoker]string, len(invokers))
+	for i, invoker := range invokers {
+		keys[invoker] = invoker.GetUrl().Key()
+		hashes[invoker] = addressHash(keys[invoker])
+		order.sorted[i] = invoker
+	}
+	sort.SliceStable(order.sorted, func(i, j int) bool {
+		a, b := order.sorted[i], order.sorted[j]
+		if hashes[a] != hashes[b] {
+			return hashes[a] < hashes[b]
+		}
+		return keys[a] < keys[b]
+	})
+	for i, invoker := range order.sorted {
+		order.ranks[invoker] = i
+	}
+	return order
+}
+
+// contains reports whether all the @invokers are ordered
+func (o *tieBreakOrder) contains(invokers []protocol.Invoker) bool {
+	for _, invoker := range invokers {
+		if _, ok := o.ranks[invoker]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// getTieBreakOrder returns the order of the service of the @invokers, which covers all the @invokers.
+// The routed invokers are mostly a subset of the ones ordered last time, so the order is reused.
+func getTieBreakOrder(invokers []protocol.Invoker) *tieBreakOrder {
+	key := invokers[0].GetUrl().ServiceKey()
+	if value, ok := tieBreakOrders.Load(key); ok && value.(*tieBreakOrder).contains(invokers) {
+		return value.(*tieBreakOrder)
+	}
+	order := newTieBreakOrder(invokers)
+	tieBreakOrders.Store(key, order)
+	return order
+}
+
+func addressHash(key string) uint32 {
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	return h.Sum32()
+}
+
+// sortInvokers returns the invokers in the tie break order, the result must not be modified
+func sortInvokers(invokers []protocol.Invoker) []protocol.Invoker {
+	if len(invokers) == 0 {
+		return invokers
+	}
+	order := getTieBreakOrder(invokers)
+	if len(invokers) == len(order.sorted) {
+		return order.sorted
+	}
+	sorted := make([]protocol.Invoker, len(invokers))
+	copy(sorted, invokers)
+	sort.Slice(sorted, func(i, j int) bool {
+		return order.ranks[sorted[i]] < order.ranks[sorted[j]]
+	})
+	return sorted
+}
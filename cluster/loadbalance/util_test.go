@@ -0,0 +1,117 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package loadbalance
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+import (
+	"github.com/apache/dubbo-go/common"
+	"github.com/apache/dubbo-go/protocol"
+	"github.com/apache/dubbo-go/protocol/invocation"
+)
+
+func TestGetWeightReconnectWarmup(t *testing.T) {
+	url, _ := common.NewURL(context.TODO(), "dubbo://192.168.2.1:20000/com.ikurento.user.UserProvider?reconnect.warmup=30")
+	invoker := protocol.NewBaseInvoker(url)
+	inv := invocation.NewRPCInvocationWithOptions(invocation.WithMethodName("GetUser"))
+	assert.Equal(t, int64(100), GetWeight(invoker, inv))
+
+	// the weight ramps back up gradually since the reconnection
+	var weights []int64
+	for _, since := range []time.Duration{0, 6 * time.Second, 15 * time.Second, 24 * time.Second, 30 * time.Second} {
+		protocol.SetReconnectTime(url.Location, time.Now().Add(-since))
+		weights = append(weights, GetWeight(invoker, inv))
+	}
+	assert.Equal(t, int64(1), weights[0])
+	for i := 1; i < len(weights); i++ {
+		assert.True(t, weights[i] > weights[i-1])
+	}
+	assert.InDelta(t, 50, weights[2], 1)
+	assert.Equal(t, int64(100), weights[4])
+}
+
+func TestGetWeightReconnectWarmupDisabled(t *testing.T) {
+	url, _ := common.NewURL(context.TODO(), "dubbo://192.168.2.2:20000/com.ikurento.user.UserProvider?reconnect.warmup=0")
+	invoker := protocol.NewBaseInvoker(url)
+	protocol.SetReconnectTime(url.Location, time.Now())
+	assert.Equal(t, int64(100), GetWeight(invoker, invocation.NewRPCInvocationWithOptions(invocation.WithMethodName("GetUser"))))
+}
+
+func TestGetWeightClockSkew(t *testing.T) {
+	defer func() { timeNow = time.Now }()
+	now := time.Now()
+	timeNow = func() time.Time { return now }
+
+	// the provider starts 5 minutes ahead of the clock of the consumer
+	url, _ := common.NewURL(context.TODO(), fmt.Sprintf("dubbo://192.168.2.3:20000/com.ikurento.user.UserProvider?warmup=60&remote.timestamp=%d",
+		now.Add(5*time.Minute).Unix()))
+	invoker := protocol.NewBaseInvoker(url)
+	inv := invocation.NewRPCInvocationWithOptions(invocation.WithMethodName("GetUser"))
+
+	// it's warmed up from the time it's seen first, rather than at full weight or until the clock catches up
+	assert.Equal(t, int64(1), GetWeight(invoker, inv))
+	var weights []int64
+	for _, since := range []time.Duration{15 * time.Second, 30 * time.Second, 45 * time.Second, 60 * time.Second} {
+		timeNow = func() time.Time { return now.Add(since) }
+		weights = append(weights, GetWeight(invoker, inv))
+	}
+	assert.InDelta(t, 25, weights[0], 1)
+	assert.InDelta(t, 50, weights[1], 1)
+	assert.InDelta(t, 75, weights[2], 1)
+	assert.Equal(t, int64(100), weights[3])
+
+	// the warmup doesn't restart once the clock catches up
+	timeNow = func() time.Time { return now.Add(5*time.Minute + time.Second) }
+	assert.Equal(t, int64(100), GetWeight(invoker, inv))
+}
+
+func TestGetWeightClockSkewIgnored(t *testing.T) {
+	url, _ := common.NewURL(context.TODO(), fmt.Sprintf("dubbo://192.168.2.4:20000/com.ikurento.user.UserProvider?warmup.skew=ignore&remote.timestamp=%d",
+		time.Now().Add(time.Hour).Unix()))
+	invoker := protocol.NewBaseInvoker(url)
+	assert.Equal(t, int64(100), GetWeight(invoker, invocation.NewRPCInvocationWithOptions(invocation.WithMethodName("GetUser"))))
+}
+
+func TestTieBreakOrder(t *testing.T) {
+	invokers, reversed := tieInvokers()
+	sorted := sortInvokers(invokers)
+	assert.Equal(t, sorted, sortInvokers(reversed))
+
+	// the order is computed once for the invokers and reused by their subsets
+	order := getTieBreakOrder(invokers)
+	assert.True(t, order == getTieBreakOrder(reversed[3:]))
+	subset := sortInvokers(reversed[3:])
+	assert.Len(t, subset, len(invokers)-3)
+	for i := 1; i < len(subset); i++ {
+		assert.True(t, order.ranks[subset[i-1]] < order.ranks[subset[i]])
+	}
+
+	// and recomputed when the invokers change
+	url, _ := common.NewURL(context.TODO(), "dubbo://192.168.1.100:20000/com.ikurento.user.UserProvider")
+	changed := append([]protocol.Invoker{protocol.NewBaseInvoker(url)}, invokers[1:]...)
+	assert.False(t, order == getTieBreakOrder(changed))
+	assert.Len(t, sortInvokers(changed), len(invokers))
+}
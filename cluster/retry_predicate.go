@@ -0,0 +1,29 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cluster
+
+import (
+	"github.com/apache/dubbo-go/protocol"
+)
+
+// RetryPredicate decides whether a failed invocation is worth retrying by the cluster invokers.
+type RetryPredicate interface {
+	// ShouldRetry is called with the last error, @attempt is the number of the coming attempt,
+	// it's 2 for the first retry as the first call is the attempt 1.
+	ShouldRetry(err error, invocation protocol.Invocation, attempt int) bool
+}
@@ -32,10 +32,18 @@ type Router interface {
 	Route([]protocol.Invoker, common.URL, protocol.Invocation) []protocol.Invoker
 }
 
+// RouterChain routes the invokers by the routers in order, so the routers compose with each other
 type RouterChain struct {
 	routers []Router
 }
 
-func NewRouterChain(url common.URL) {
+func NewRouterChain(routers ...Router) *RouterChain {
+	return &RouterChain{routers: routers}
+}
 
+func (c *RouterChain) Route(invokers []protocol.Invoker, url common.URL, invocation protocol.Invocation) []protocol.Invoker {
+	for _, r := range c.routers {
+		invokers = r.Route(invokers, url, invocation)
+	}
+	return invokers
 }
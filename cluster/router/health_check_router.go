@@ -0,0 +1,202 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package router
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+import (
+	"go.uber.org/atomic"
+)
+
+import (
+	"github.com/apache/dubbo-go/common"
+	"github.com/apache/dubbo-go/common/constant"
+	"github.com/apache/dubbo-go/protocol"
+)
+
+const HEALTH_CHECK = "health_check"
+
+var (
+	invokersHealth sync.Map // provider url key -> *invokerHealth
+)
+
+// HealthCheckRouter excludes the unhealthy providers of the references configured health.check.enabled=true.
+// A provider is unhealthy after error.threshold consecutive failures, it's excluded for recovery.interval, then
+// it's half-open: one probe invocation at a time is routed to it until one succeeds. If all the providers are
+// unhealthy, the least bad ones are returned instead of none.
+// The invocations are counted by the cluster invokers only if the router is enabled, and the health of a provider
+// is removed by RemoveHealth once its invokers are destroyed.
+type HealthCheckRouter struct{}
+
+func NewHealthCheckRouter() *HealthCheckRouter {
+	return &HealthCheckRouter{}
+}
+
+// invokerHealth is the outcomes of the invocations of a provider
+type invokerHealth struct {
+	lock        sync.Mutex
+	active      int
+	failures    int // the consecutive failures
+	lastFailure time.Time
+	// the time in nanoseconds the probe of the half-open provider is routed, 0 if no probe is in progress.
+	// it's claimed by CAS, and expires after the recovery interval in case the probe isn't invoked at all.
+	probe atomic.Int64
+	// the sliding window of the response times
+	elapsed []time.Duration
+	next    int
+}
+
+// healthSnapshot is used to rank the unhealthy providers
+type healthSnapshot struct {
+	invoker     protocol.Invoker
+	failures    int
+	active      int
+	avgResponse time.Duration
+}
+
+func getInvokerHealth(url common.URL) *invokerHealth {
+	health, ok := invokersHealth.Load(url.Key())
+	if !ok {
+		health, _ = invokersHealth.LoadOrStore(url.Key(), &invokerHealth{})
+	}
+	return health.(*invokerHealth)
+}
+
+// RemoveHealth removes the health of the provider of @url, once its invokers are destroyed
+func RemoveHealth(url common.URL) {
+	invokersHealth.Delete(url.Key())
+}
+
+// BeginHealthCount counts the invocation of the provider of @url in progress
+func BeginHealthCount(url common.URL) {
+	health := getInvokerHealth(url)
+	health.lock.Lock()
+	health.active++
+	health.lock.Unlock()
+}
+
+// EndHealthCount ends the invocation counted by BeginHealthCount, and records its @elapsed time and error
+func EndHealthCount(url common.URL, elapsed time.Duration, err error) {
+	health := getInvokerHealth(url)
+	health.lock.Lock()
+	defer health.lock.Unlock()
+
+	health.active--
+	health.probe.Store(0)
+	if err != nil {
+		health.failures++
+		health.lastFailure = time.Now()
+	} else {
+		health.failures = 0
+	}
+	if len(health.elapsed) < constant.DEFAULT_HEALTH_CHECK_WINDOW {
+		health.elapsed = append(health.elapsed, elapsed)
+		return
+	}
+	health.elapsed[health.next] = elapsed
+	health.next = (health.next + 1) % len(health.elapsed)
+}
+
+func (r *HealthCheckRouter) Route(invokers []protocol.Invoker, url common.URL, invocation protocol.Invocation) []protocol.Invoker {
+	if !url.GetParamBool(constant.HEALTH_CHECK_ENABLED_KEY, false) || len(invokers) == 0 {
+		return invokers
+	}
+	threshold := int(url.GetParamInt(constant.ERROR_THRESHOLD_KEY, constant.DEFAULT_ERROR_THRESHOLD))
+	recovery := time.Duration(url.GetParamInt(constant.RECOVERY_INTERVAL_KEY, constant.DEFAULT_RECOVERY_INTERVAL)) * time.Millisecond
+
+	now := time.Now()
+	healthy := make([]protocol.Invoker, 0, len(invokers))
+	for _, ivk := range invokers {
+		if getInvokerHealth(ivk.GetUrl()).healthy(now, threshold, recovery) {
+			healthy = append(healthy, ivk)
+		}
+	}
+	if len(healthy) == len(invokers) {
+		return invokers
+	}
+	if len(healthy) > 0 {
+		return healthy
+	}
+	return leastBad(invokers)
+}
+
+// healthy returns false if the provider is excluded, or half-open with a probe in progress.
+// Only one of the concurrent routings claims the probe of the half-open provider.
+func (health *invokerHealth) healthy(now time.Time, threshold int, recovery time.Duration) bool {
+	health.lock.Lock()
+	failures, lastFailure := health.failures, health.lastFailure
+	health.lock.Unlock()
+
+	if failures < threshold {
+		return true
+	}
+	if now.Before(lastFailure.Add(recovery)) {
+		return false
+	}
+	probe := health.probe.Load()
+	if probe != 0 && now.UnixNano()-probe < int64(recovery) {
+		return false
+	}
+	return health.probe.CAS(probe, now.UnixNano())
+}
+
+func (health *invokerHealth) snapshot(ivk protocol.Invoker) healthSnapshot {
+	health.lock.Lock()
+	defer health.lock.Unlock()
+
+	s := healthSnapshot{invoker: ivk, failures: health.failures, active: health.active}
+	if len(health.elapsed) > 0 {
+		var total time.Duration
+		for _, elapsed := range health.elapsed {
+			total += elapsed
+		}
+		s.avgResponse = total / time.Duration(len(health.elapsed))
+	}
+	return s
+}
+
+// leastBad returns the providers of the fewest consecutive failures, ordered by the active invocations and
+// the average response time
+func leastBad(invokers []protocol.Invoker) []protocol.Invoker {
+	snapshots := make([]healthSnapshot, 0, len(invokers))
+	for _, ivk := range invokers {
+		snapshots = append(snapshots, getInvokerHealth(ivk.GetUrl()).snapshot(ivk))
+	}
+	sort.SliceStable(snapshots, func(i, j int) bool {
+		if snapshots[i].failures != snapshots[j].failures {
+			return snapshots[i].failures < snapshots[j].failures
+		}
+		if snapshots[i].active != snapshots[j].active {
+			return snapshots[i].active < snapshots[j].active
+		}
+		return snapshots[i].avgResponse < snapshots[j].avgResponse
+	})
+
+	result := make([]protocol.Invoker, 0, len(snapshots))
+	for _, s := range snapshots {
+		if s.failures != snapshots[0].failures {
+			break
+		}
+		result = append(result, s.invoker)
+	}
+	return result
+}
@@ -0,0 +1,177 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package router
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/atomic"
+)
+
+import (
+	"github.com/apache/dubbo-go/cluster"
+	"github.com/apache/dubbo-go/common"
+	"github.com/apache/dubbo-go/common/constant"
+	"github.com/apache/dubbo-go/protocol"
+	"github.com/apache/dubbo-go/protocol/invocation"
+)
+
+var errInvoke = errors.New("invoke error")
+
+// healthInvokers returns the invokers of the service unique to the test, so their health is isolated
+func healthInvokers(service string, n int) []protocol.Invoker {
+	invokers := make([]protocol.Invoker, 0, n)
+	for i := 0; i < n; i++ {
+		url, _ := common.NewURL(context.TODO(), fmt.Sprintf("dubbo://192.168.1.%v:20000/%v", i+1, service))
+		invokers = append(invokers, NewMockInvoker(url, 1))
+	}
+	return invokers
+}
+
+func healthConsumerUrl(params string) common.URL {
+	url, _ := common.NewURL(context.TODO(), "consumer://192.168.1.10/com.foo.BarService?"+params)
+	return url
+}
+
+func invokeHealth(ivk protocol.Invoker, elapsed time.Duration, err error) {
+	BeginHealthCount(ivk.GetUrl())
+	EndHealthCount(ivk.GetUrl(), elapsed, err)
+}
+
+func TestHealthCheckRouterExclusion(t *testing.T) {
+	invokers := healthInvokers("com.foo.ExclusionService", 3)
+	router := NewHealthCheckRouter()
+	url := healthConsumerUrl("health.check.enabled=true&error.threshold=3")
+
+	invokeHealth(invokers[0], time.Millisecond, errInvoke)
+	invokeHealth(invokers[0], time.Millisecond, errInvoke)
+	assert.Equal(t, invokers, router.Route(invokers, url, &invocation.RPCInvocation{}))
+
+	invokeHealth(invokers[0], time.Millisecond, errInvoke)
+	assert.Equal(t, invokers[1:], router.Route(invokers, url, &invocation.RPCInvocation{}))
+
+	// disabled by default
+	assert.Equal(t, invokers, router.Route(invokers, healthConsumerUrl("error.threshold=3"), &invocation.RPCInvocation{}))
+
+	// a success resets the consecutive failures
+	invokeHealth(invokers[1], time.Millisecond, errInvoke)
+	invokeHealth(invokers[1], time.Millisecond, errInvoke)
+	invokeHealth(invokers[1], time.Millisecond, nil)
+	invokeHealth(invokers[1], time.Millisecond, errInvoke)
+	assert.Equal(t, invokers[1:], router.Route(invokers, url, &invocation.RPCInvocation{}))
+}
+
+func TestHealthCheckRouterHalfOpen(t *testing.T) {
+	invokers := healthInvokers("com.foo.HalfOpenService", 2)
+	router := NewHealthCheckRouter()
+	url := healthConsumerUrl("health.check.enabled=true&error.threshold=2&recovery.interval=100")
+
+	invokeHealth(invokers[0], time.Millisecond, errInvoke)
+	invokeHealth(invokers[0], time.Millisecond, errInvoke)
+	assert.Equal(t, invokers[1:], router.Route(invokers, url, &invocation.RPCInvocation{}))
+
+	// half-open after the recovery interval, one probe at a time
+	time.Sleep(150 * time.Millisecond)
+	assert.Equal(t, invokers, router.Route(invokers, url, &invocation.RPCInvocation{}))
+	BeginHealthCount(invokers[0].GetUrl())
+	assert.Equal(t, invokers[1:], router.Route(invokers, url, &invocation.RPCInvocation{}))
+
+	// the failed probe excludes it again
+	EndHealthCount(invokers[0].GetUrl(), time.Millisecond, errInvoke)
+	assert.Equal(t, invokers[1:], router.Route(invokers, url, &invocation.RPCInvocation{}))
+
+	// the successful probe recovers it
+	time.Sleep(150 * time.Millisecond)
+	invokeHealth(invokers[0], time.Millisecond, nil)
+	assert.Equal(t, invokers, router.Route(invokers, url, &invocation.RPCInvocation{}))
+}
+
+func TestHealthCheckRouterProbe(t *testing.T) {
+	invokers := healthInvokers("com.foo.ProbeService", 2)
+	router := NewHealthCheckRouter()
+	url := healthConsumerUrl("health.check.enabled=true&error.threshold=1&recovery.interval=100")
+
+	invokeHealth(invokers[0], time.Millisecond, errInvoke)
+	time.Sleep(150 * time.Millisecond)
+
+	// only one of the concurrent routings gets the probe
+	var (
+		wg     sync.WaitGroup
+		probes atomic.Int32
+	)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if len(router.Route(invokers, url, &invocation.RPCInvocation{})) == len(invokers) {
+				probes.Inc()
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(1), probes.Load())
+
+	// the probe routed but not invoked expires after the recovery interval
+	time.Sleep(150 * time.Millisecond)
+	assert.Equal(t, invokers, router.Route(invokers, url, &invocation.RPCInvocation{}))
+
+	// the removed health is reset
+	invokeHealth(invokers[0], time.Millisecond, errInvoke)
+	RemoveHealth(invokers[0].GetUrl())
+	assert.Equal(t, invokers, router.Route(invokers, url, &invocation.RPCInvocation{}))
+}
+
+func TestHealthCheckRouterNeverEmpty(t *testing.T) {
+	invokers := healthInvokers("com.foo.NeverEmptyService", 3)
+	router := NewHealthCheckRouter()
+	url := healthConsumerUrl("health.check.enabled=true&error.threshold=1")
+
+	for i := 0; i < 3; i++ {
+		invokeHealth(invokers[0], time.Millisecond, errInvoke)
+	}
+	invokeHealth(invokers[1], 50*time.Millisecond, errInvoke)
+	invokeHealth(invokers[2], 10*time.Millisecond, errInvoke)
+
+	// the ones of the fewest failures, the faster first
+	assert.Equal(t, []protocol.Invoker{invokers[2], invokers[1]}, router.Route(invokers, url, &invocation.RPCInvocation{}))
+	assert.Equal(t, invokers[:1], router.Route(invokers[:1], url, &invocation.RPCInvocation{}))
+	assert.Len(t, router.Route(nil, url, &invocation.RPCInvocation{}), 0)
+}
+
+func TestHealthCheckRouterChain(t *testing.T) {
+	invokers := tagInvokers()
+	chain := cluster.NewRouterChain(NewTagRouter(), NewHealthCheckRouter())
+	url := healthConsumerUrl("health.check.enabled=true&error.threshold=1&recovery.interval=60000")
+
+	invokeHealth(invokers[0], time.Millisecond, errInvoke)
+	assert.Equal(t, invokers[1:2], chain.Route(invokers, url, &invocation.RPCInvocation{}))
+
+	// the shadow provider is still isolated though the only healthy one is excluded
+	invokeHealth(invokers[1], time.Millisecond, errInvoke)
+	assert.Equal(t, invokers[:2], chain.Route(invokers, url, &invocation.RPCInvocation{}))
+	inv := invocation.NewRPCInvocationWithOptions(invocation.WithAttachments(map[string]string{constant.TAG_KEY: "shadow"}))
+	assert.Equal(t, invokers[2:], chain.Route(invokers, url, inv))
+}
@@ -25,6 +25,8 @@ import (
 
 func init() {
 	extension.SetRouterFactory("condition", NewConditionRouterFactory)
+	extension.SetRouterFactory("tag", NewTagRouterFactory)
+	extension.SetRouterFactory(HEALTH_CHECK, NewHealthCheckRouterFactory)
 }
 
 type ConditionRouterFactory struct{}
@@ -35,3 +37,21 @@ func NewConditionRouterFactory() cluster.RouterFactory {
 func (c ConditionRouterFactory) Router(url *common.URL) (cluster.Router, error) {
 	return newConditionRouter(url)
 }
+
+type TagRouterFactory struct{}
+
+func NewTagRouterFactory() cluster.RouterFactory {
+	return TagRouterFactory{}
+}
+func (c TagRouterFactory) Router(url *common.URL) (cluster.Router, error) {
+	return NewTagRouter(), nil
+}
+
+type HealthCheckRouterFactory struct{}
+
+func NewHealthCheckRouterFactory() cluster.RouterFactory {
+	return HealthCheckRouterFactory{}
+}
+func (c HealthCheckRouterFactory) Router(url *common.URL) (cluster.Router, error) {
+	return NewHealthCheckRouter(), nil
+}
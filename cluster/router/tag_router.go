@@ -0,0 +1,145 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package router
+
+import (
+	"strings"
+	"sync"
+)
+
+import (
+	"github.com/apache/dubbo-go/common"
+	"github.com/apache/dubbo-go/common/config"
+	"github.com/apache/dubbo-go/common/constant"
+	"github.com/apache/dubbo-go/common/logger"
+	"github.com/apache/dubbo-go/config_center"
+	"github.com/apache/dubbo-go/protocol"
+	"github.com/apache/dubbo-go/remoting"
+)
+
+// TagRouter routes the invocation tagged by the dubbo.tag attachment to the providers of the same tag, such as
+// the gray instances. The tag of a provider is set by the tag rule of the service in the config center, or by the
+// tag param of its url. The tagged invocation falls back to the untagged providers if no provider has the tag,
+// unless dubbo.force.tag is true or the tag is isolated, like the shadow and test ones by default. The untagged
+// invocation is never routed to the tagged providers.
+type TagRouter struct {
+	subscribed sync.Map // service key -> struct{}
+	lock       sync.RWMutex
+	rules      map[string]*config_center.TagRule // service key -> the tag rule
+}
+
+func NewTagRouter() *TagRouter {
+	return &TagRouter{rules: make(map[string]*config_center.TagRule)}
+}
+
+func (r *TagRouter) Route(invokers []protocol.Invoker, url common.URL, invocation protocol.Invocation) []protocol.Invoker {
+	serviceKey := url.ServiceKey()
+	r.subscribe(serviceKey)
+	r.lock.RLock()
+	rule := r.rules[serviceKey]
+	r.lock.RUnlock()
+
+	tag := invocation.AttachmentsByKey(constant.TAG_KEY, "")
+	result := filterByTag(invokers, rule, tag)
+	if tag == "" || len(result) > 0 {
+		if len(result) == len(invokers) {
+			return invokers
+		}
+		return result
+	}
+
+	if invocation.AttachmentsByKey(constant.FORCE_TAG_KEY, "") == "true" ||
+		url.GetParamBool(constant.FORCE_TAG_KEY, false) || rule.Forced() || isolatedTag(url, tag) {
+		return result
+	}
+	return filterByTag(invokers, rule, "")
+}
+
+// isolatedTag returns true if the @tag is in the isolated tags of the reference @url
+func isolatedTag(url common.URL, tag string) bool {
+	for _, isolated := range strings.Split(url.GetParam(constant.ISOLATED_TAGS_KEY, constant.DEFAULT_ISOLATED_TAGS), ",") {
+		if strings.TrimSpace(isolated) == tag {
+			return true
+		}
+	}
+	return false
+}
+
+func filterByTag(invokers []protocol.Invoker, rule *config_center.TagRule, tag string) []protocol.Invoker {
+	result := make([]protocol.Invoker, 0, len(invokers))
+	for _, invoker := range invokers {
+		if providerTag(invoker.GetUrl(), rule) == tag {
+			result = append(result, invoker)
+		}
+	}
+	return result
+}
+
+// providerTag returns the tag of the provider by the tag rule, or the tag param of the url
+func providerTag(providerUrl common.URL, rule *config_center.TagRule) string {
+	if tag, ok := rule.Tag(providerUrl); ok {
+		return tag
+	}
+	return providerUrl.GetParam(constant.PROVIDER_TAG_KEY, providerUrl.GetParam(constant.TAG_KEY, ""))
+}
+
+// subscribe the tag rule of the service from the config center once
+func (r *TagRouter) subscribe(serviceKey string) {
+	if _, loaded := r.subscribed.LoadOrStore(serviceKey, struct{}{}); loaded {
+		return
+	}
+	dynamicConfig := config.GetEnvInstance().GetDynamicConfiguration()
+	if dynamicConfig == nil {
+		return
+	}
+
+	key := serviceKey + constant.TAG_ROUTER_SUFFIX
+	dynamicConfig.AddListener(key, r, config_center.WithGroup(config_center.DEFAULT_GROUP))
+	content, err := dynamicConfig.GetConfig(key, config_center.WithGroup(config_center.DEFAULT_GROUP))
+	if err != nil {
+		logger.Debugf("Get tag rule {%s} error, error message is %v", key, err)
+		return
+	}
+	if content != "" {
+		r.Process(&remoting.ConfigChangeEvent{Key: key, Value: content, ConfigType: remoting.EventTypeAdd})
+	}
+}
+
+// Process applies the tag rule pushed by the config center, the routing after it follows the new rule
+func (r *TagRouter) Process(event *remoting.ConfigChangeEvent) {
+	logger.Infof("tag rule changed, event: %v", event)
+	serviceKey := strings.TrimSuffix(event.Key, constant.TAG_ROUTER_SUFFIX)
+
+	var rule *config_center.TagRule
+	if event.ConfigType != remoting.EventTypeDel {
+		content, _ := event.Value.(string)
+		var err error
+		if rule, err = config_center.ParseTagRule(content); err != nil {
+			logger.Errorf("Parse tag rule error, the rule is ignored, error message is %v", err)
+			return
+		}
+	}
+
+	r.lock.Lock()
+	if rule == nil {
+		delete(r.rules, serviceKey)
+	} else {
+		r.rules[serviceKey] = rule
+	}
+	r.lock.Unlock()
+}
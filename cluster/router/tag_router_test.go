@@ -0,0 +1,174 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package router
+
+import (
+	"context"
+	"testing"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+import (
+	"github.com/apache/dubbo-go/common"
+	"github.com/apache/dubbo-go/common/config"
+	"github.com/apache/dubbo-go/common/constant"
+	"github.com/apache/dubbo-go/config_center"
+	"github.com/apache/dubbo-go/protocol"
+	"github.com/apache/dubbo-go/protocol/invocation"
+	"github.com/apache/dubbo-go/remoting"
+)
+
+func tagInvokers() []protocol.Invoker {
+	url1, _ := common.NewURL(context.TODO(), "dubbo://192.168.1.1:20000/com.foo.BarService")
+	url2, _ := common.NewURL(context.TODO(), "dubbo://192.168.1.2:20000/com.foo.BarService")
+	shadow, _ := common.NewURL(context.TODO(), "dubbo://192.168.1.3:20000/com.foo.BarService?dubbo.tag=shadow")
+	return []protocol.Invoker{NewMockInvoker(url1, 1), NewMockInvoker(url2, 1), NewMockInvoker(shadow, 1)}
+}
+
+func TestTagRouterRoute(t *testing.T) {
+	invokers := tagInvokers()
+	consumerUrl, _ := common.NewURL(context.TODO(), "consumer://192.168.1.10/com.foo.BarService")
+	router := NewTagRouter()
+
+	// the tagged invocation hits the shadow provider only
+	inv := invocation.NewRPCInvocationWithOptions(invocation.WithAttachments(map[string]string{constant.TAG_KEY: "shadow"}))
+	assert.Equal(t, []protocol.Invoker{invokers[2]}, router.Route(invokers, consumerUrl, inv))
+
+	// the untagged invocation never hits the shadow provider
+	assert.Equal(t, invokers[:2], router.Route(invokers, consumerUrl, &invocation.RPCInvocation{}))
+	assert.Len(t, router.Route(invokers[2:], consumerUrl, &invocation.RPCInvocation{}), 0)
+}
+
+func TestTagRouterFallback(t *testing.T) {
+	invokers := tagInvokers()
+	consumerUrl, _ := common.NewURL(context.TODO(), "consumer://192.168.1.10/com.foo.BarService")
+
+	// fall back to the untagged providers if no provider has the tag
+	inv := invocation.NewRPCInvocationWithOptions(invocation.WithAttachments(map[string]string{constant.TAG_KEY: "canary"}))
+	assert.Equal(t, invokers[:2], NewTagRouter().Route(invokers, consumerUrl, inv))
+}
+
+func TestTagRouterStrictIsolation(t *testing.T) {
+	invokers := tagInvokers()
+	consumerUrl, _ := common.NewURL(context.TODO(), "consumer://192.168.1.10/com.foo.BarService")
+
+	// the shadow and test invocations never fall back to the untagged providers
+	for _, tag := range []string{"shadow", "test"} {
+		inv := invocation.NewRPCInvocationWithOptions(invocation.WithAttachments(map[string]string{constant.TAG_KEY: tag}))
+		assert.Len(t, NewTagRouter().Route(invokers[:2], consumerUrl, inv), 0)
+	}
+
+	// the isolated tags are configured by the reference url
+	isolatedUrl, _ := common.NewURL(context.TODO(), "consumer://192.168.1.10/com.foo.BarService?dubbo.isolated.tags=canary,perf")
+	inv := invocation.NewRPCInvocationWithOptions(invocation.WithAttachments(map[string]string{constant.TAG_KEY: "canary"}))
+	assert.Len(t, NewTagRouter().Route(invokers, isolatedUrl, inv), 0)
+	inv = invocation.NewRPCInvocationWithOptions(invocation.WithAttachments(map[string]string{constant.TAG_KEY: "test"}))
+	assert.Equal(t, invokers[:2], NewTagRouter().Route(invokers, isolatedUrl, inv))
+}
+
+func TestTagRouterForce(t *testing.T) {
+	invokers := tagInvokers()
+	consumerUrl, _ := common.NewURL(context.TODO(), "consumer://192.168.1.10/com.foo.BarService")
+
+	// no fallback if it's forced by the attachment
+	inv := invocation.NewRPCInvocationWithOptions(invocation.WithAttachments(map[string]string{
+		constant.TAG_KEY: "canary", constant.FORCE_TAG_KEY: "true"}))
+	assert.Len(t, NewTagRouter().Route(invokers, consumerUrl, inv), 0)
+
+	// or by the reference url
+	forcedUrl, _ := common.NewURL(context.TODO(), "consumer://192.168.1.10/com.foo.BarService?dubbo.force.tag=true")
+	inv = invocation.NewRPCInvocationWithOptions(invocation.WithAttachments(map[string]string{constant.TAG_KEY: "canary"}))
+	assert.Len(t, NewTagRouter().Route(invokers, forcedUrl, inv), 0)
+}
+
+func TestTagRouterStaticTag(t *testing.T) {
+	url1, _ := common.NewURL(context.TODO(), "dubbo://192.168.1.1:20000/com.foo.BarService")
+	gray, _ := common.NewURL(context.TODO(), "dubbo://192.168.1.2:20000/com.foo.BarService?tag=gray")
+	invokers := []protocol.Invoker{NewMockInvoker(url1, 1), NewMockInvoker(gray, 1)}
+	consumerUrl, _ := common.NewURL(context.TODO(), "consumer://192.168.1.10/com.foo.BarService")
+	router := NewTagRouter()
+
+	inv := invocation.NewRPCInvocationWithOptions(invocation.WithAttachments(map[string]string{constant.TAG_KEY: "gray"}))
+	assert.Equal(t, invokers[1:], router.Route(invokers, consumerUrl, inv))
+	assert.Equal(t, invokers[:1], router.Route(invokers, consumerUrl, &invocation.RPCInvocation{}))
+}
+
+// tagRuleConfiguration is the config center holding the tag rules
+type tagRuleConfiguration struct {
+	config_center.DynamicConfiguration
+	rules     map[string]string
+	listeners map[string]remoting.ConfigurationListener
+}
+
+func (c *tagRuleConfiguration) AddListener(key string, listener remoting.ConfigurationListener, opts ...config_center.Option) {
+	c.listeners[key] = listener
+}
+
+func (c *tagRuleConfiguration) GetConfig(key string, opts ...config_center.Option) (string, error) {
+	return c.rules[key], nil
+}
+
+func TestTagRouterDynamicRule(t *testing.T) {
+	const grayRule = `
+key: com.foo.BarService
+tags:
+  - name: gray
+    addresses: [192.168.1.2:20000]
+`
+	dc := &tagRuleConfiguration{
+		rules:     map[string]string{"com.foo.BarService.tag-router": grayRule},
+		listeners: make(map[string]remoting.ConfigurationListener),
+	}
+	config.GetEnvInstance().SetDynamicConfiguration(dc)
+	defer config.GetEnvInstance().SetDynamicConfiguration(nil)
+
+	invokers := tagInvokers()
+	consumerUrl, _ := common.NewURL(context.TODO(), "consumer://192.168.1.10/com.foo.BarService")
+	router := NewTagRouter()
+	grayInv := invocation.NewRPCInvocationWithOptions(invocation.WithAttachments(map[string]string{constant.TAG_KEY: "gray"}))
+
+	// the rule tags the provider by the address
+	assert.Equal(t, invokers[1:2], router.Route(invokers, consumerUrl, grayInv))
+	// the untagged invocation excludes the providers of the tag groups in the rule, and the static tagged one
+	assert.Equal(t, invokers[:1], router.Route(invokers, consumerUrl, &invocation.RPCInvocation{}))
+	assert.NotNil(t, dc.listeners["com.foo.BarService.tag-router"])
+
+	// hot update: the gray group moves to another provider, and it's forced
+	dc.listeners["com.foo.BarService.tag-router"].Process(&remoting.ConfigChangeEvent{
+		Key: "com.foo.BarService.tag-router",
+		Value: `
+force: true
+tags:
+  - name: gray
+    addresses: [192.168.1.1]
+`,
+		ConfigType: remoting.EvnetTypeUpdate,
+	})
+	assert.Equal(t, invokers[:1], router.Route(invokers, consumerUrl, grayInv))
+	assert.Equal(t, invokers[1:2], router.Route(invokers, consumerUrl, &invocation.RPCInvocation{}))
+	canaryInv := invocation.NewRPCInvocationWithOptions(invocation.WithAttachments(map[string]string{constant.TAG_KEY: "canary"}))
+	assert.Len(t, router.Route(invokers, consumerUrl, canaryInv), 0)
+
+	// the static tags apply after the rule is deleted
+	router.Process(&remoting.ConfigChangeEvent{Key: "com.foo.BarService.tag-router", ConfigType: remoting.EventTypeDel})
+	assert.Equal(t, invokers[:2], router.Route(invokers, consumerUrl, grayInv))
+	assert.Equal(t, invokers[:2], router.Route(invokers, consumerUrl, &invocation.RPCInvocation{}))
+}
@@ -0,0 +1,48 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cluster
+
+import (
+	"time"
+)
+
+import (
+	"github.com/apache/dubbo-go/protocol"
+)
+
+// ProviderStats are the statistics of a provider for the method of an invocation
+type ProviderStats struct {
+	// the weight in warmup
+	Weight         int64
+	Active         int32
+	AverageElapsed time.Duration
+	FailureRate    float64
+}
+
+// Scorer scores the providers for the load balance by score, the provider of the highest score is selected,
+// and the tied ones are selected randomly by their weights.
+type Scorer interface {
+	Score(invoker protocol.Invoker, invocation protocol.Invocation, stats ProviderStats) float64
+}
+
+// ScorerFunc adapts a function to the Scorer
+type ScorerFunc func(invoker protocol.Invoker, invocation protocol.Invocation, stats ProviderStats) float64
+
+func (f ScorerFunc) Score(invoker protocol.Invoker, invocation protocol.Invocation, stats ProviderStats) float64 {
+	return f(invoker, invocation, stats)
+}
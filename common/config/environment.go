@@ -23,15 +23,20 @@ import (
 	"sync"
 )
 
+import (
+	"github.com/apache/dubbo-go/config_center"
+)
+
 // There is dubbo.properties file and application level config center configuration which higner than normal config center in java. So in java the
 // configuration sequence will be config center > application level config center > dubbo.properties > spring bean configuration.
 // But in go, neither the dubbo.properties file or application level config center configuration will not support for the time being.
 // We just have config center configuration which can override configuration in consumer.yaml & provider.yaml.
 // But for add these features in future ,I finish the environment struct following Environment class in java.
 type Environment struct {
-	configCenterFirst bool
-	externalConfigs   sync.Map
-	externalConfigMap sync.Map
+	configCenterFirst    bool
+	externalConfigs      sync.Map
+	externalConfigMap    sync.Map
+	dynamicConfiguration config_center.DynamicConfiguration
 }
 
 var (
@@ -60,6 +65,16 @@ func (env *Environment) UpdateExternalConfigMap(externalMap map[string]string) {
 	}
 }
 
+// SetDynamicConfiguration keeps the config center started by the config loader, which is subscribed for the dynamic rules
+func (env *Environment) SetDynamicConfiguration(dc config_center.DynamicConfiguration) {
+	env.dynamicConfiguration = dc
+}
+
+// GetDynamicConfiguration returns nil if there is no config center configured
+func (env *Environment) GetDynamicConfiguration() config_center.DynamicConfiguration {
+	return env.dynamicConfiguration
+}
+
 func (env *Environment) Configuration() *list.List {
 	list := list.New()
 	memConf := newInmemoryConfiguration()
@@ -111,3 +126,19 @@ func (conf *InmemoryConfiguration) GetSubProperty(subKey string) map[string]stru
 
 	return properties
 }
+
+// GetProperties returns the properties with the @prefix, keyed by the rest of their keys
+func (conf *InmemoryConfiguration) GetProperties(prefix string) map[string]string {
+	properties := make(map[string]string)
+	if conf.store == nil {
+		return properties
+	}
+
+	conf.store.Range(func(key, value interface{}) bool {
+		if k := key.(string); strings.HasPrefix(k, prefix) && len(k) > len(prefix) {
+			properties[k[len(prefix):]] = value.(string)
+		}
+		return true
+	})
+	return properties
+}
@@ -50,3 +50,11 @@ func TestInmemoryConfiguration_GetSubProperty(t *testing.T) {
 
 	assert.Equal(t, struct{}{}, m["123"])
 }
+
+func TestInmemoryConfiguration_GetProperties(t *testing.T) {
+	GetEnvInstance().UpdateExternalConfigMap(map[string]string{"dubbo.a.timeout": "2000", "dubbo.a.methods.m.retries": "1", "dubbo.b": "3"})
+	list := GetEnvInstance().Configuration()
+	m := list.Front().Value.(*InmemoryConfiguration).GetProperties("dubbo.a.")
+
+	assert.Equal(t, map[string]string{"timeout": "2000", "methods.m.retries": "1"}, m)
+}
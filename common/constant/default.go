@@ -23,6 +23,41 @@ const (
 const (
 	DEFAULT_WEIGHT = 100     //
 	DEFAULT_WARMUP = 10 * 60 // in java here is 10*60*1000 because of System.currentTimeMillis() is measured in milliseconds & in go time.Unix() is second
+	// in seconds, like warmup
+	DEFAULT_RECONNECT_WARMUP = 30
+	WARMUP_SKEW_CLAMP        = "clamp"
+	WARMUP_SKEW_IGNORE       = "ignore"
+	DEFAULT_WARMUP_SKEW      = WARMUP_SKEW_CLAMP
+)
+
+const (
+	DEFAULT_SCORER = "leastactive"
+)
+
+const (
+	DEFAULT_ABTEST_PERCENTAGE = 50
+)
+
+const (
+	DEFAULT_HASH_NODES     = 160
+	DEFAULT_HASH_ARGUMENTS = "0"
+)
+
+const (
+	DEFAULT_SHARD_ARGUMENTS = "0"
+)
+
+const (
+	// milliseconds
+	DEFAULT_FAILBACK_RETRY_PERIOD = 5000
+)
+
+const (
+	DEFAULT_FAILBACK_CONCURRENCY = 10
+)
+
+const (
+	DEFAULT_NACOS_CHECK_INTERVAL = 10000
 )
 
 const (
@@ -33,18 +68,122 @@ const (
 	DEFAULT_CLUSTER        = "failover"
 	DEFAULT_FAILBACK_TIMES = 3
 	DEFAULT_FAILBACK_TASKS = 100
+	DEFAULT_SERIALIZATION  = "hessian2"
 )
 
 const (
 	DEFAULT_KEY               = "default"
 	PREFIX_DEFAULT_KEY        = "default."
-	DEFAULT_SERVICE_FILTERS   = "echo"
+	DEFAULT_SERVICE_FILTERS   = "echo,graceful_shutdown"
 	DEFAULT_REFERENCE_FILTERS = ""
 	GENERIC_REFERENCE_FILTERS = "generic"
+	TOKEN_FILTER              = "token"
+	METRICS_FILTER            = "metrics"
+	ABTEST_FILTER             = "abtest"
 	GENERIC                   = "$invoke"
 	ECHO                      = "$echo"
+	STREAM                    = "$stream"
+)
+
+const (
+	ANY_VALUE     = "*"
+	ANYHOST_VALUE = "0.0.0.0"
+)
+
+const (
+	ATTACHMENT_POLICY_REJECT   = "reject"
+	ATTACHMENT_POLICY_TRUNCATE = "truncate"
+)
+
+const (
+	DEFAULT_SHUTDOWN_TIMEOUT = "60s"
+)
+
+const (
+	DEFAULT_STREAM_CHUNK_SIZE = 64 * 1024 // in bytes
+)
+
+const (
+	DEFAULT_REPLAY_RETRIES = 2
+)
+
+const (
+	DEFAULT_ADAPTIVE_TIMEOUT_MIN = 10 // in milliseconds
+	// the adaptive timeout applies after the method has this many latency samples
+	DEFAULT_ADAPTIVE_TIMEOUT_SAMPLES = 20
+)
+
+const (
+	DEFAULT_GRPC_MAX_MESSAGE_SIZE = 4 * 1024 * 1024 // in bytes, the default of gRPC
+)
+
+const (
+	DEFAULT_REST_READ_HEADER_TIMEOUT = 10000  // in milliseconds
+	DEFAULT_REST_READ_TIMEOUT        = 60000  // in milliseconds
+	DEFAULT_REST_IDLE_TIMEOUT        = 120000 // in milliseconds
+	DEFAULT_REST_MAX_BODY_SIZE       = 4 * 1024 * 1024
+)
+
+const (
+	DEFAULT_CIRCUIT_OPEN_INTERVAL = 10000 // in milliseconds
+)
+
+const (
+	DEFAULT_OUTLIER_LATENCY_WINDOW      = 100
+	DEFAULT_OUTLIER_LATENCY_MIN_SAMPLES = 10
+	DEFAULT_OUTLIER_EJECTION_INTERVAL   = 30000 // in milliseconds
+)
+
+const (
+	DEFAULT_DEPRECATED_LOG_INTERVAL = 60000 // in milliseconds
+)
+
+const (
+	DEFAULT_CACHE      = "lru"
+	DEFAULT_CACHE_SIZE = 1000
+	DEFAULT_CACHE_TTL  = 180000 // in milliseconds
+)
+
+const (
+	DEFAULT_PROVIDER_CACHE_TTL  = 60000 // in milliseconds
+	DEFAULT_PROVIDER_CACHE_SIZE = 1000
+)
+
+const (
+	DEFAULT_CONNECT_RETRIES     = 0
+	DEFAULT_CONNECT_BACKOFF     = 100  // in milliseconds
+	DEFAULT_CONNECT_MAX_BACKOFF = 3000 // in milliseconds
+)
+
+const (
+	DEFAULT_ERROR_THRESHOLD     = 5
+	DEFAULT_RECOVERY_INTERVAL   = 30000 // in milliseconds
+	DEFAULT_HEALTH_CHECK_WINDOW = 10    // the response times sampled for the average
+)
+
+const (
+	// the tagged providers are isolated from the untagged invocations, and the unhealthy providers are excluded
+	// if the health check is enabled
+	DEFAULT_ROUTERS = "tag,health_check"
+	// the shadow and test invocations never fall back to the production providers
+	DEFAULT_ISOLATED_TAGS = "shadow,test"
+)
+
+const (
+	DEFAULT_TPS_LIMIT_INTERVAL = 60000 // in milliseconds
+	DEFAULT_TPS_LIMIT_STRATEGY = "fixedWindow"
+)
+
+const (
+	DEFAULT_CONSUMER_TPS_LIMIT_RATE     = -1    // no limit
+	DEFAULT_CONSUMER_TPS_LIMIT_INTERVAL = 60000 // in milliseconds
+)
+
+const (
+	DEFAULT_REGISTRY_CACHE_EXPIRE = 60000 // in milliseconds
 )
 
 const (
-	ANY_VALUE = "*"
+	DEFAULT_METRICS_PORT = "9090"
+	DEFAULT_METRICS_PATH = "/metrics"
 )
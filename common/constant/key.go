@@ -33,11 +33,156 @@ const (
 	GENERIC_KEY   = "generic"
 )
 
+const (
+	// the serializations preferred by the consumer, kept in the provider urls merged with the reference url
+	PREFERRED_SERIALIZATION_KEY = "serialization.preferred"
+	// the providers supporting the serializations preferred by the consumer are selected first, it's true by default
+	SERIALIZATION_PREFER_KEY = "serialization.prefer"
+)
+
+const (
+	SERIALIZATION_KEY = "serialization"
+	// the serializations supported by the provider, separated by comma
+	SERIALIZATIONS_KEY = "serializations"
+)
+
+const (
+	// the size in bytes of the chunks which the io.Reader arguments are streamed to the provider in
+	STREAM_CHUNK_SIZE_KEY = "stream.chunk.size"
+)
+
+const (
+	// the token of the provider, which is compared with the token attachment of the invocations by the token filter,
+	// true or default means a random token generated on export
+	TOKEN_KEY = "token"
+)
+
+const (
+	// the max calls of a method executing at the same time on the provider, 0 means unlimited
+	EXECUTE_LIMIT_KEY = "execute.limit"
+	// the alias of execute.limit, like dubbo of java
+	EXECUTES_KEY = "executes"
+	// the max calls of a method active at the same time on the consumer, the call over it waits for the ending
+	// of an active one until its timeout, 0 means unlimited
+	ACTIVES_KEY = "actives"
+)
+
+const (
+	// the max total size in bytes of the keys and values of the attachments, 0 means unlimited
+	ATTACHMENT_MAX_SIZE_KEY = "attachment.max.size"
+	// the policy of the oversized attachments, reject or truncate
+	ATTACHMENT_OVERSIZE_POLICY_KEY = "attachment.oversize.policy"
+)
+
+const (
+	// the idempotent calls are replayed on a new connection if the connection drops before the response
+	IDEMPOTENT_KEY     = "idempotent"
+	REPLAY_RETRIES_KEY = "replay.retries"
+)
+
+const (
+	// the broadcast succeeds if the percent of the failed providers is below it, 0 means no failure is tolerated
+	BROADCAST_FAIL_PERCENT_KEY = "broadcast.fail.percent"
+	// the broadcast succeeds if the sum of the weights of the acknowledging providers reaches it, 0 means disabled,
+	// it has priority over broadcast.fail.percent
+	BROADCAST_QUORUM_WEIGHT_KEY = "broadcast.quorum.weight"
+)
+
+const (
+	// the invocations of the method stick to the last selected provider as long as it's available
+	STICKY_KEY = "sticky"
+)
+
+const (
+	// the virtual nodes of each provider on the ring of the consistent hash load balance
+	HASH_NODES_KEY = "hash.nodes"
+	// the comma separated indexes of the arguments hashed by the consistent hash load balance
+	HASH_ARGUMENTS_KEY = "hash.arguments"
+)
+
+const (
+	// the scorer of the providers for the load balance by score, it's configured by method
+	SCORER_KEY = "scorer"
+)
+
+const (
+	// the abtest load balance splits the requests between the two comma separated abtest.loadbalances,
+	// abtest.percentage of them go to the second one. The split is by the hash of the request key, which is
+	// the attachment named by abtest.key, or the arguments if it's not set.
+	ABTEST_LOADBALANCES_KEY = "abtest.loadbalances"
+	ABTEST_PERCENTAGE_KEY   = "abtest.percentage"
+	ABTEST_KEY_KEY          = "abtest.key"
+)
+
+const (
+	// the failures of a method logged by any cluster invoker are sampled: the first error.log.first ones are
+	// logged, then one in every error.log.sample. All the failures are logged if both are 0
+	ERROR_LOG_FIRST_KEY  = "error.log.first"
+	ERROR_LOG_SAMPLE_KEY = "error.log.sample"
+)
+
+const (
+	// the log level of the audit of the providers tried by a failover and why each failed,
+	// one of debug, info and warn, the audit is off if it's empty
+	FAILOVER_AUDIT_KEY = "failover.audit"
+)
+
+const (
+	// the address the transport listens on and dials instead of the ip:port of the url,
+	// e.g. unix:///tmp/dubbo.sock for the unix domain socket
+	TRANSPORT_ADDRESS_KEY = "transport.address"
+)
+
+const (
+	// the deadline of a call is the factor times the recent p99 latency of the method, 0 disables it
+	ADAPTIVE_TIMEOUT_FACTOR_KEY = "timeout.adaptive.factor"
+	ADAPTIVE_TIMEOUT_MIN_KEY    = "timeout.adaptive.min" // in milliseconds
+	ADAPTIVE_TIMEOUT_MAX_KEY    = "timeout.adaptive.max" // in milliseconds
+)
+
 const (
 	SERVICE_FILTER_KEY   = "service.filter"
 	REFERENCE_FILTER_KEY = "reference.filter"
 )
 
+const (
+	// the priority of the request, the provider serves the queued requests of higher priority first
+	PRIORITY_KEY = "priority"
+)
+
+const (
+	// the ip of the consumer, it's set into the attachments of invocation by the provider
+	REMOTE_IP_KEY = "remote.ip"
+)
+
+const (
+	// the max requests to the service or the method in tps.interval milliseconds, no limit if it's not positive
+	TPS_LIMIT_RATE_KEY     = "tps"
+	TPS_LIMIT_INTERVAL_KEY = "tps.interval"
+	// the name of the tps limit strategy, fixedWindow, slidingWindow or tokenBucket
+	TPS_LIMIT_STRATEGY_KEY = "tps.limit.strategy"
+)
+
+const (
+	CONSUMER_TPS_LIMIT_RATE_KEY     = "consumer.tps.limit.rate"
+	CONSUMER_TPS_LIMIT_INTERVAL_KEY = "consumer.tps.limit.interval"
+)
+
+const (
+	// how the least active load balance selects among the providers tied for the fewest active requests,
+	// random(weighted by default) or roundrobin
+	LEAST_ACTIVE_TIE_BREAK_KEY = "leastactive.tiebreak"
+)
+
+const (
+	// the provider reconnected after its connections were lost is warmed up again for reconnect.warmup seconds,
+	// 0 means it takes the full weight at once
+	RECONNECT_WARMUP_KEY = "reconnect.warmup"
+	// how the warmup handles the start timestamp of the provider in the future by the clock skew, clamp warms it up
+	// from the time it's seen first, ignore gives it the full weight at once
+	WARMUP_SKEW_KEY = "warmup.skew"
+)
+
 const (
 	TIMESTAMP_KEY        = "timestamp"
 	REMOTE_TIMESTAMP_KEY = "remote.timestamp"
@@ -46,6 +191,8 @@ const (
 	WEIGHT_KEY           = "weight"
 	WARMUP_KEY           = "warmup"
 	RETRIES_KEY          = "retries"
+	RETRY_CODES_KEY      = "retry.codes"
+	TERMINAL_CODES_KEY   = "terminal.codes"
 	BEAN_NAME            = "bean.name"
 	FAIL_BACK_TASKS_KEY  = "failbacktasks"
 	FORKS_KEY            = "forks"
@@ -53,6 +200,182 @@ const (
 	DEFAULT_TIMEOUT      = 1000
 )
 
+const (
+	// the cluster joining the directory by the clusters of every method, methods.X.cluster or cluster, which
+	// may be a chain like failover,failback: the next cluster handles the call the previous one fails
+	CHAIN_CLUSTER = "chain"
+)
+
+const (
+	FAIL_BACK_FIRST_CALL_ERROR_KEY = "failback.firstcall.error"
+	// the max number of the failback tasks retried concurrently
+	FAIL_BACK_CONCURRENCY_KEY = "failback.concurrency"
+	// the attachment of the invocation refers to the callback notified of the final result of its failback retries
+	FAILBACK_CALLBACK_KEY = "failback.callback"
+	// the period in milliseconds before a failed failback task is retried, it's doubled after each failed retry
+	// up to failback.retry.max.period if the max is larger
+	FAIL_BACK_RETRY_PERIOD_KEY     = "failback.retry.period"
+	FAIL_BACK_RETRY_MAX_PERIOD_KEY = "failback.retry.max.period"
+	// the new failed task is discarded when the failback task list is full, or the oldest task is evicted for it
+	FAIL_BACK_OVERFLOW_POLICY_KEY = "failback.overflow.policy"
+	FAIL_BACK_OVERFLOW_DISCARD    = "discard"
+	FAIL_BACK_OVERFLOW_EVICT      = "evict"
+	// the failback task is abandoned after failback.max.age milliseconds since the first failure, even if it has retries left
+	FAIL_BACK_MAX_AGE_KEY = "failback.max.age"
+	// the retry finding no provider is deferred without consuming a retry, up to the max retries of the task,
+	// or it's counted as a failed retry if false
+	FAIL_BACK_DEFER_ON_EMPTY_KEY = "failback.empty.defer"
+)
+
+const (
+	MERGE_KEY       = "merge"
+	MERGE_EMPTY_KEY = "merge.empty"
+	// the empty merge returns error or the zero value of the result
+	MERGE_EMPTY_ERROR = "error"
+	MERGE_EMPTY_ZERO  = "zero"
+)
+
+const (
+	RETRY_PREDICATE_KEY = "retry.predicate"
+)
+
+const (
+	// the consumer talks to a stable subset of subset.size providers chosen by its subset.id
+	SUBSET_SIZE_KEY = "subset.size"
+	SUBSET_ID_KEY   = "subset.id"
+)
+
+const (
+	// the concurrent identical invocations of the idempotent method share one in-flight request if it's true
+	COALESCE_KEY = "coalesce"
+	// the identical invocations of the idempotent method within the window in milliseconds after a call completes
+	// get its result instead of reaching the providers, 0 means disabled
+	DEDUP_WINDOW_KEY = "dedup.window"
+)
+
+const (
+	// the max number of the failover retries in progress across the consumer, the failover invocation fails fast
+	// instead of retrying beyond it. 0 means unlimited
+	FAILOVER_CONCURRENCY_MAX_KEY = "failover.concurrency.max"
+	// the delay in milliseconds before each failover retry, 0 means no delay. A random jitter in
+	// [0, failover.delay.jitter] milliseconds is added to it, so the retries of the consumers aren't synchronized
+	FAILOVER_DELAY_KEY        = "failover.delay"
+	FAILOVER_DELAY_JITTER_KEY = "failover.delay.jitter"
+)
+
+const (
+	// the circuit of the provider is opened after the consecutive failures, 0 means disabled
+	CIRCUIT_FAILURE_THRESHOLD_KEY = "circuit.failure.threshold"
+	CIRCUIT_OPEN_INTERVAL_KEY     = "circuit.open.interval"
+	// the provider is restored to the normal selection right after a successful half-open trial if it's true,
+	// otherwise it's selected by one trial at a time until the next interval passes
+	CIRCUIT_HALFOPEN_RESTORE_KEY = "circuit.halfopen.restore"
+	// the invocation attached circuit.bypass=true reaches the providers of the open circuits, e.g. the health checks,
+	// and its result doesn't affect the circuits
+	CIRCUIT_BYPASS_KEY = "circuit.bypass"
+)
+
+const (
+	// the provider is ejected if its p95 latency exceeds the factor times of the median of the others, 0 means disabled
+	OUTLIER_LATENCY_FACTOR_KEY      = "outlier.latency.factor"
+	OUTLIER_LATENCY_WINDOW_KEY      = "outlier.latency.window"
+	OUTLIER_LATENCY_MIN_SAMPLES_KEY = "outlier.latency.min.samples"
+	OUTLIER_EJECTION_INTERVAL_KEY   = "outlier.ejection.interval"
+)
+
+const (
+	// the method returns the raw serialized bytes of the response if it can't be decoded into the declared type
+	DECODE_FALLBACK_RAW_KEY = "decode.fallback.raw"
+)
+
+const (
+	// the forking invoker forks to one more provider every forks.wait milliseconds until a fork succeeds,
+	// at most forks.max providers are invoked. 0 means all the forks are spawned upfront.
+	FORKS_WAIT_KEY = "forks.wait"
+	FORKS_MAX_KEY  = "forks.max"
+)
+
+const (
+	// attachment of invocation to pin the call to the provider of the address, like 192.168.1.1:20000
+	FORCE_ADDRESS_KEY = "force.address"
+)
+
+const (
+	// the results of the method are cached by the consumer for the calls of the same arguments, by the cache
+	// of the type, like lru or expiring, true refers to lru. lru caches up to cache.size results of the method,
+	// and expiring caches them for cache.ttl milliseconds
+	CACHE_KEY      = "cache"
+	CACHE_SIZE_KEY = "cache.size"
+	CACHE_TTL_KEY  = "cache.ttl"
+	LRU_CACHE      = "lru"
+	EXPIRING_CACHE = "expiring"
+	// the comma separated methods whose cached results are invalidated once the method is invoked,
+	// e.g. methods.UpdateUser.cache.invalidate=GetUser,ListUsers
+	CACHE_INVALIDATE_KEY = "cache.invalidate"
+)
+
+const (
+	// the results of the method are cached by the provider for the requests of the same arguments from any consumer,
+	// for provider.cache.ttl milliseconds and up to provider.cache.size results of the method
+	PROVIDER_CACHE_KEY      = "provider.cache"
+	PROVIDER_CACHE_TTL_KEY  = "provider.cache.ttl"
+	PROVIDER_CACHE_SIZE_KEY = "provider.cache.size"
+)
+
+const (
+	// the invocations are logged by the accesslog filter to the default logger if it's true, or to the file it refers to
+	ACCESS_LOG_KEY = "accesslog"
+)
+
+const (
+	// the provider passes the nil arguments as nil, or as the allocated zero values if it's zero
+	NIL_ARGUMENT_KEY  = "nil.argument"
+	NIL_ARGUMENT_ZERO = "zero"
+)
+
+const (
+	// the provider serializes the error results with the status code and context if it's structured
+	ERROR_SERIALIZATION_KEY        = "error.serialization"
+	STRUCTURED_ERROR_SERIALIZATION = "structured"
+)
+
+const (
+	// the attachments of the invocation or result mapped to the http response headers, like trace_id:X-Trace-Id,tenant
+	RESPONSE_HEADERS_KEY = "response.headers"
+	// prefix of the result attachments which are written as the http response headers
+	RESPONSE_HEADER_ATTACHMENT_PREFIX = "response.header."
+)
+
+const (
+	// the method of the provider is deprecated, the value is true or the migration message.
+	// it's also the attachment of the result warning the consumer.
+	DEPRECATED_KEY              = "deprecated"
+	DEPRECATED_LOG_INTERVAL_KEY = "deprecated.log.interval"
+)
+
+const (
+	// the tcp socket options of the connections of the providers and consumers, they override the getty session
+	// config. The keepalive period is in milliseconds, the buffer sizes in bytes
+	TCP_NODELAY_KEY          = "tcp.nodelay"
+	TCP_KEEPALIVE_KEY        = "tcp.keepalive"
+	TCP_KEEPALIVE_PERIOD_KEY = "tcp.keepalive.period"
+	TCP_SEND_BUFFER_KEY      = "tcp.send.buffer"
+	TCP_RECV_BUFFER_KEY      = "tcp.recv.buffer"
+	TCP_REUSEADDR_KEY        = "tcp.reuseaddr"
+)
+
+const (
+	// retry the initial connection to the provider with exponential backoff, the backoffs are in milliseconds
+	CONNECT_RETRIES_KEY     = "connect.retries"
+	CONNECT_BACKOFF_KEY     = "connect.backoff"
+	CONNECT_MAX_BACKOFF_KEY = "connect.max.backoff"
+)
+
+const (
+	// the provider is removed from selection by the configurator rules if it's true
+	DISABLED_KEY = "disabled"
+)
+
 const (
 	DUBBOGO_CTX_KEY = "dubbogo-ctx"
 )
@@ -65,6 +388,82 @@ const (
 	REGISTRY_TIMEOUT_KEY = "registry.timeout"
 )
 
+const (
+	// the zone of the registry, and the zone preferred by the consumer by the attachment or the reference param
+	REGISTRY_ZONE_KEY = "registry.zone"
+	// the invocation fails rather than spilling over to the other zones if no registry is available in the zone
+	ZONE_FORCE_KEY = "zone.force"
+	// the preferred registry is selected regardless of the zones
+	PREFERRED_KEY = "preferred"
+)
+
+const (
+	// the service events older than the last applied one of the provider are ignored by the registry directory,
+	// it's true by default
+	REGISTRY_ORDERED_NOTIFY_KEY = "registry.ordered.notify"
+)
+
+const (
+	// the provider urls fetched from the registry are cached in the file, the cached providers are referred at once
+	// on startup, and removed if the registry doesn't confirm them in registry.cache.expire milliseconds
+	REGISTRY_CACHE_FILE_KEY   = "registry.cache.file"
+	REGISTRY_CACHE_EXPIRE_KEY = "registry.cache.expire"
+)
+
+const (
+	// the name of the registry data serializer, which encodes the urls in the registry nodes
+	REGISTRY_DATA_SERIALIZER_KEY = "registry.data.serializer"
+)
+
+const (
+	// a copy of the invocations sampled by mirror.rate(0~1, default 1) is sent to the providers of the mirror.tag,
+	// whose results are discarded
+	MIRROR_TAG_KEY  = "mirror.tag"
+	MIRROR_RATE_KEY = "mirror.rate"
+)
+
+const (
+	// the serializable subset of the jsonrpc result is responded with a warning if it's true,
+	// instead of failing the whole response because of the unsupported fields
+	SERIALIZE_PARTIAL_KEY = "serialize.partial"
+)
+
+const (
+	// the sharding cluster routes the invocation to the providers owning the shard of the arguments at the
+	// shard.arguments indexes. The shard is the arguments joined by colons, or their hash modulo shard.count if
+	// it's set.
+	// The providers own the shards listed by their shards param, e.g. 0-3,8, or by the shard.map of the
+	// reference, e.g. 0-3=192.168.1.1:20000;4-7=192.168.1.2:20000
+	SHARD_ARGUMENTS_KEY = "shard.arguments"
+	SHARD_COUNT_KEY     = "shard.count"
+	SHARDS_KEY          = "shards"
+	SHARD_MAP_KEY       = "shard.map"
+)
+
+const (
+	// the health check router excludes the providers of error.threshold consecutive failures for recovery.interval
+	// in milliseconds, then routes one probe invocation at a time to them until one succeeds
+	HEALTH_CHECK_ENABLED_KEY = "health.check.enabled"
+	ERROR_THRESHOLD_KEY      = "error.threshold"
+	RECOVERY_INTERVAL_KEY    = "recovery.interval"
+)
+
+const (
+	// the tag of the invocation attachment, or of the provider url. The tagged invocation is routed to the
+	// providers of the same tag, and the untagged invocation never to the tagged providers
+	TAG_KEY = "dubbo.tag"
+	// the tag of the provider url, or dubbo.tag. The tag rule in the config center overrides it by the addresses
+	PROVIDER_TAG_KEY = "tag"
+	// the tagged invocation falls back to the untagged providers if no provider has the tag, unless the
+	// dubbo.force.tag of the invocation attachment, the reference url or the tag rule is true
+	FORCE_TAG_KEY = "dubbo.force.tag"
+	// the tags of the reference url separated by commas, e.g. of the shadow or test providers, which are always
+	// forced, so the invocations of them are strictly isolated from the other providers
+	ISOLATED_TAGS_KEY = "dubbo.isolated.tags"
+	// the routers of the reference in order, by the names of their router factories separated by commas
+	ROUTER_KEY = "router"
+)
+
 const (
 	APPLICATION_KEY  = "application"
 	ORGANIZATION_KEY = "organization"
@@ -78,6 +477,42 @@ const (
 	RULE_KEY         = "rule"
 )
 
+const (
+	CONFIGURATORS_SUFFIX = ".configurators"
+	// the key of the execute limits of a service in the config center is the service key with the suffix
+	EXECUTE_LIMIT_SUFFIX = ".execute.limit"
+	// the key of the tag rule of a service in the config center
+	TAG_ROUTER_SUFFIX = ".tag-router"
+)
+
+const (
+	// the reporters of the metrics like prometheus, separated by commas
+	METRICS_KEY      = "metrics"
+	METRICS_PORT_KEY = "metrics.port"
+	METRICS_PATH_KEY = "metrics.path"
+)
+
+const (
+	// the grpc protocol serves and calls with TLS if grpc.tls is true, the provider by the certificate and key files
+	// of grpc.tls.cert and grpc.tls.key, the consumer verifies the provider by the CA certificate file of grpc.tls.cert,
+	// or the system ones if it's not set
+	GRPC_TLS_KEY           = "grpc.tls"
+	GRPC_TLS_CERT_FILE_KEY = "grpc.tls.cert"
+	GRPC_TLS_KEY_FILE_KEY  = "grpc.tls.key"
+	// the max size in bytes of the messages sent and received by the grpc protocol
+	GRPC_MAX_MESSAGE_SIZE_KEY = "grpc.max_message_size"
+)
+
+const (
+	// the timeouts in milliseconds of the rest server reading the headers and the whole requests, and keeping
+	// the idle connections
+	REST_READ_HEADER_TIMEOUT_KEY = "rest.read_header_timeout"
+	REST_READ_TIMEOUT_KEY        = "rest.read_timeout"
+	REST_IDLE_TIMEOUT_KEY        = "rest.idle_timeout"
+	// the max size in bytes of the request bodies of the rest server
+	REST_MAX_BODY_SIZE_KEY = "rest.max_body_size"
+)
+
 const (
 	CONFIG_NAMESPACE_KEY = "config.namespace"
 	CONFIG_TIMEOUT_KET   = "config.timeout"
@@ -101,4 +536,7 @@ const (
 	NACOS_CATEGORY_KEY           = "category"
 	NACOS_PROTOCOL_KEY           = "protocol"
 	NACOS_PATH_KEY               = "path"
+	NACOS_NAMESPACE_ID           = "namespaceId"
+	// interval in milliseconds to check that registered instances survived a nacos restart
+	NACOS_CHECK_INTERVAL_KEY = "nacos.check.interval"
 )
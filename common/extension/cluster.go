@@ -17,21 +17,57 @@
 
 package extension
 
+import (
+	"sync"
+)
+
+import (
+	perrors "github.com/pkg/errors"
+)
+
 import (
 	"github.com/apache/dubbo-go/cluster"
 )
 
 var (
-	clusters = make(map[string]func() cluster.Cluster)
+	clusters     = make(map[string]func() cluster.Cluster)
+	clustersLock sync.RWMutex
 )
 
 func SetCluster(name string, fcn func() cluster.Cluster) {
+	clustersLock.Lock()
+	defer clustersLock.Unlock()
 	clusters[name] = fcn
 }
 
+// RegisterCluster registers the custom cluster by name at runtime, it's selected by the cluster config
+// of the references. An error is returned if the name is registered already.
+func RegisterCluster(name string, fcn func() cluster.Cluster) error {
+	if name == "" || fcn == nil {
+		return perrors.New("the name and the constructor of the cluster can not be empty")
+	}
+	clustersLock.Lock()
+	defer clustersLock.Unlock()
+	if _, ok := clusters[name]; ok {
+		return perrors.Errorf("cluster %v is registered already", name)
+	}
+	clusters[name] = fcn
+	return nil
+}
+
+func HasCluster(name string) bool {
+	clustersLock.RLock()
+	defer clustersLock.RUnlock()
+	_, ok := clusters[name]
+	return ok
+}
+
 func GetCluster(name string) cluster.Cluster {
-	if clusters[name] == nil {
+	clustersLock.RLock()
+	fcn := clusters[name]
+	clustersLock.RUnlock()
+	if fcn == nil {
 		panic("cluster for " + name + " is not existing, make sure you have import the package.")
 	}
-	return clusters[name]()
+	return fcn()
 }
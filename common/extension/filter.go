@@ -29,6 +29,11 @@ func SetFilter(name string, v func() filter.Filter) {
 	filters[name] = v
 }
 
+// HasFilter returns true if the filter named name is set
+func HasFilter(name string) bool {
+	return filters[name] != nil
+}
+
 func GetFilter(name string) filter.Filter {
 	if filters[name] == nil {
 		panic("filter for " + name + " is not existing, make sure you have import the package.")
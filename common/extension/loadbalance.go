@@ -17,22 +17,58 @@
 
 package extension
 
+import (
+	"sync"
+)
+
+import (
+	perrors "github.com/pkg/errors"
+)
+
 import (
 	"github.com/apache/dubbo-go/cluster"
 )
 
 var (
-	loadbalances = make(map[string]func() cluster.LoadBalance)
+	loadbalances     = make(map[string]func() cluster.LoadBalance)
+	loadbalancesLock sync.RWMutex
 )
 
 func SetLoadbalance(name string, fcn func() cluster.LoadBalance) {
+	loadbalancesLock.Lock()
+	defer loadbalancesLock.Unlock()
 	loadbalances[name] = fcn
 }
 
+// RegisterLoadbalance registers the custom load balance by name at runtime, it's selected by the loadbalance
+// config of the references and methods. An error is returned if the name is registered already.
+func RegisterLoadbalance(name string, fcn func() cluster.LoadBalance) error {
+	if name == "" || fcn == nil {
+		return perrors.New("the name and the constructor of the loadbalance can not be empty")
+	}
+	loadbalancesLock.Lock()
+	defer loadbalancesLock.Unlock()
+	if _, ok := loadbalances[name]; ok {
+		return perrors.Errorf("loadbalance %v is registered already", name)
+	}
+	loadbalances[name] = fcn
+	return nil
+}
+
+func HasLoadbalance(name string) bool {
+	loadbalancesLock.RLock()
+	defer loadbalancesLock.RUnlock()
+	_, ok := loadbalances[name]
+	return ok
+}
+
 func GetLoadbalance(name string) cluster.LoadBalance {
-	if loadbalances[name] == nil {
+	loadbalancesLock.RLock()
+	fcn := loadbalances[name]
+	loadbalancesLock.RUnlock()
+	if fcn == nil {
 		panic("loadbalance for " + name + " is not existing, make sure you have import the package.")
 	}
 
-	return loadbalances[name]()
+	return fcn()
 }
@@ -0,0 +1,53 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package extension
+
+import (
+	"sync"
+)
+
+import (
+	"github.com/apache/dubbo-go/common"
+	"github.com/apache/dubbo-go/metrics"
+)
+
+var (
+	metricReporters         = make(map[string]func(url common.URL) metrics.Reporter)
+	metricReporterInstances = make(map[string]metrics.Reporter)
+	metricReporterLock      sync.Mutex
+)
+
+// SetMetricReporter sets the factory of the metric reporter @name, it creates the reporter by the @url
+func SetMetricReporter(name string, fcn func(url common.URL) metrics.Reporter) {
+	metricReporters[name] = fcn
+}
+
+// GetMetricReporter returns the metric reporter @name, it's shared by all the invokers and created by the first @url
+func GetMetricReporter(name string, url common.URL) metrics.Reporter {
+	metricReporterLock.Lock()
+	defer metricReporterLock.Unlock()
+	if reporter, ok := metricReporterInstances[name]; ok {
+		return reporter
+	}
+	if metricReporters[name] == nil {
+		panic("metric reporter for " + name + " is not existing, make sure you have import the package.")
+	}
+	reporter := metricReporters[name](url)
+	metricReporterInstances[name] = reporter
+	return reporter
+}
@@ -29,6 +29,11 @@ func SetProtocol(name string, v func() protocol.Protocol) {
 	protocols[name] = v
 }
 
+// HasProtocol returns true if the protocol named name is set
+func HasProtocol(name string) bool {
+	return protocols[name] != nil
+}
+
 func GetProtocol(name string) protocol.Protocol {
 	if protocols[name] == nil {
 		panic("protocol for " + name + " is not existing, make sure you have import the package.")
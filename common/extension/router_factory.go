@@ -19,3 +19,7 @@ func GetRouterFactory(name string) cluster.RouterFactory {
 	return routers[name]()
 
 }
+
+func HasRouterFactory(name string) bool {
+	return routers[name] != nil
+}
@@ -0,0 +1,46 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package extension
+
+import (
+	"time"
+)
+
+import (
+	"github.com/apache/dubbo-go/filter"
+)
+
+var (
+	tpsLimitStrategies = make(map[string]func(rate int64, interval time.Duration) filter.TpsLimitStrategy)
+)
+
+func SetTpsLimitStrategy(name string, fcn func(rate int64, interval time.Duration) filter.TpsLimitStrategy) {
+	tpsLimitStrategies[name] = fcn
+}
+
+// HasTpsLimitStrategy returns true if the tps limit strategy named name is set
+func HasTpsLimitStrategy(name string) bool {
+	return tpsLimitStrategies[name] != nil
+}
+
+func GetTpsLimitStrategy(name string, rate int64, interval time.Duration) filter.TpsLimitStrategy {
+	if tpsLimitStrategies[name] == nil {
+		panic("tps limit strategy for " + name + " is not existing, make sure you have import the package.")
+	}
+	return tpsLimitStrategies[name](rate, interval)
+}
@@ -0,0 +1,169 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package common
+
+import (
+	"reflect"
+	"strings"
+)
+
+import (
+	perrors "github.com/pkg/errors"
+)
+
+// Generalize converts the @obj to the generalized form of the generic invocations, the structs become the maps
+// of their fields named by the tag m or the lower camel case field names, the slices become []interface{}.
+func Generalize(obj interface{}) interface{} {
+	if obj == nil {
+		return obj
+	}
+	t := reflect.TypeOf(obj)
+	v := reflect.ValueOf(obj)
+	switch t.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			return nil
+		}
+		return Generalize(v.Elem().Interface())
+	case reflect.Struct:
+		result := make(map[string]interface{}, t.NumField())
+		for i := 0; i < t.NumField(); i++ {
+			if v.Field(i).CanInterface() {
+				result[fieldKey(t.Field(i))] = Generalize(v.Field(i).Interface())
+			}
+		}
+		return result
+	case reflect.Slice, reflect.Array:
+		if t.Kind() == reflect.Slice && v.IsNil() {
+			return nil
+		}
+		result := make([]interface{}, 0, v.Len())
+		for i := 0; i < v.Len(); i++ {
+			result = append(result, Generalize(v.Index(i).Interface()))
+		}
+		return result
+	case reflect.Map:
+		if v.IsNil() {
+			return nil
+		}
+		result := make(map[interface{}]interface{}, v.Len())
+		for _, k := range v.MapKeys() {
+			result[k.Interface()] = Generalize(v.MapIndex(k).Interface())
+		}
+		return result
+	default:
+		return obj
+	}
+}
+
+// Realize converts the generalized @obj back to a value of the type @typ, it's the reverse of Generalize.
+func Realize(obj interface{}, typ reflect.Type) (reflect.Value, error) {
+	if obj == nil {
+		return reflect.Zero(typ), nil
+	}
+	v := reflect.ValueOf(obj)
+	if v.Type().AssignableTo(typ) {
+		return v, nil
+	}
+	switch typ.Kind() {
+	case reflect.Ptr:
+		elem, err := Realize(obj, typ.Elem())
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		ptr := reflect.New(typ.Elem())
+		ptr.Elem().Set(elem)
+		return ptr, nil
+	case reflect.Struct:
+		if v.Kind() != reflect.Map {
+			break
+		}
+		result := reflect.New(typ).Elem()
+		for i := 0; i < typ.NumField(); i++ {
+			field := typ.Field(i)
+			if field.PkgPath != "" {
+				continue
+			}
+			value := v.MapIndex(reflect.ValueOf(fieldKey(field)))
+			if !value.IsValid() {
+				value = v.MapIndex(reflect.ValueOf(field.Name))
+			}
+			if !value.IsValid() {
+				continue
+			}
+			fv, err := Realize(value.Interface(), field.Type)
+			if err != nil {
+				return reflect.Value{}, perrors.WithMessagef(err, "field %s of %v", field.Name, typ)
+			}
+			result.Field(i).Set(fv)
+		}
+		return result, nil
+	case reflect.Slice:
+		if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+			break
+		}
+		result := reflect.MakeSlice(typ, v.Len(), v.Len())
+		for i := 0; i < v.Len(); i++ {
+			elem, err := Realize(v.Index(i).Interface(), typ.Elem())
+			if err != nil {
+				return reflect.Value{}, err
+			}
+			result.Index(i).Set(elem)
+		}
+		return result, nil
+	case reflect.Map:
+		if v.Kind() != reflect.Map {
+			break
+		}
+		result := reflect.MakeMapWithSize(typ, v.Len())
+		for _, k := range v.MapKeys() {
+			key, err := Realize(k.Interface(), typ.Key())
+			if err != nil {
+				return reflect.Value{}, err
+			}
+			value, err := Realize(v.MapIndex(k).Interface(), typ.Elem())
+			if err != nil {
+				return reflect.Value{}, err
+			}
+			result.SetMapIndex(key, value)
+		}
+		return result, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		switch v.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+			reflect.Float32, reflect.Float64:
+			return v.Convert(typ), nil
+		}
+	default:
+		if v.Type().ConvertibleTo(typ) && v.Kind() == typ.Kind() {
+			return v.Convert(typ), nil
+		}
+	}
+	return reflect.Value{}, perrors.Errorf("cannot realize %v as %v", v.Type(), typ)
+}
+
+// fieldKey is the key of the struct field in the generalized map
+func fieldKey(field reflect.StructField) string {
+	if tag := field.Tag.Get("m"); tag != "" {
+		return tag
+	}
+	return strings.ToLower(field.Name[:1]) + field.Name[1:]
+}
@@ -0,0 +1,69 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package proxy
+
+import (
+	"sync"
+)
+
+import (
+	invocation_impl "github.com/apache/dubbo-go/protocol/invocation"
+)
+
+// BatchCall is a call of the batch submitted by InvokeBatch, @Reply receives the result of the call
+type BatchCall struct {
+	Method string
+	Args   []interface{}
+	Reply  interface{}
+}
+
+// InvokeBatch dispatches the @calls through the invoker of the proxy with at most @concurrency calls
+// in flight, all of the calls are in flight if @concurrency isn't positive. the calls of a batch share
+// the connections of the invoker, and the dubbo protocol pipelines them on a connection.
+// it returns after all the calls are done, the errors are in the order of the calls, nil for the
+// succeeded ones, so that the failure of a call doesn't fail the others.
+func (p *Proxy) InvokeBatch(calls []*BatchCall, concurrency int) []error {
+	errs := make([]error, len(calls))
+	if concurrency <= 0 || concurrency > len(calls) {
+		concurrency = len(calls)
+	}
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, concurrency)
+	for i, call := range calls {
+		inv, err := invocation_impl.NewInvocationBuilder(call.Method).Arguments(call.Args...).Reply(call.Reply).
+			CallBack(p.callBack).Attachments(p.attachments).Build()
+		if err != nil {
+			errs[i] = err
+			continue
+		}
+
+		sem <- struct{}{}
+		wg.Add(1)
+		go func(i int) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			errs[i] = p.invoke.Invoke(inv).Error()
+		}(i)
+	}
+	wg.Wait()
+
+	return errs
+}
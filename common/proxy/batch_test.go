@@ -0,0 +1,83 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package proxy
+
+import (
+	"sync"
+	"testing"
+)
+
+import (
+	perrors "github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/atomic"
+)
+
+import (
+	"github.com/apache/dubbo-go/common"
+	"github.com/apache/dubbo-go/common/constant"
+	"github.com/apache/dubbo-go/protocol"
+)
+
+// batchInvoker echoes the first argument to the reply, and fails the method "Fail"
+type batchInvoker struct {
+	protocol.BaseInvoker
+	lock        sync.Mutex
+	inflight    atomic.Int32
+	maxInflight int32
+}
+
+func (ivk *batchInvoker) Invoke(invocation protocol.Invocation) protocol.Result {
+	n := ivk.inflight.Inc()
+	defer ivk.inflight.Dec()
+	ivk.lock.Lock()
+	if n > ivk.maxInflight {
+		ivk.maxInflight = n
+	}
+	ivk.lock.Unlock()
+
+	if invocation.MethodName() == "Fail" {
+		return &protocol.RPCResult{Err: perrors.New("fail")}
+	}
+	*(invocation.Reply().(*string)) = invocation.Arguments()[0].(string) + "@" + invocation.AttachmentsByKey(constant.VERSION_KEY, "")
+	return &protocol.RPCResult{}
+}
+
+func TestProxy_InvokeBatch(t *testing.T) {
+	invoker := &batchInvoker{BaseInvoker: *protocol.NewBaseInvoker(common.URL{})}
+	p := NewProxy(invoker, nil, map[string]string{constant.VERSION_KEY: "1.0"})
+
+	var replies [20]string
+	calls := make([]*BatchCall, 0, len(replies)+2)
+	for i := range replies {
+		calls = append(calls, &BatchCall{Method: "Echo", Args: []interface{}{string(rune('a' + i))}, Reply: &replies[i]})
+	}
+	calls = append(calls, &BatchCall{Method: "Fail", Args: []interface{}{"x"}, Reply: new(string)})
+	// the invalid call is reported without being invoked
+	calls = append(calls, &BatchCall{Method: "Echo", Args: []interface{}{"y"}, Reply: ""})
+
+	errs := p.InvokeBatch(calls, 4)
+	assert.Len(t, errs, len(calls))
+	for i := range replies {
+		assert.NoError(t, errs[i])
+		assert.Equal(t, string(rune('a'+i))+"@1.0", replies[i])
+	}
+	assert.EqualError(t, errs[len(replies)], "fail")
+	assert.Error(t, errs[len(replies)+1])
+	assert.True(t, invoker.maxInflight <= 4)
+}
@@ -18,6 +18,7 @@
 package proxy
 
 import (
+	"context"
 	"reflect"
 	"sync"
 )
@@ -93,9 +94,14 @@ func (p *Proxy) Implement(v common.RPCService) {
 
 			start := 0
 			end := len(in)
+			ctx := context.Background()
 			if end > 0 {
 				if in[0].Type().String() == "context.Context" {
 					start += 1
+					// the deadline of the context overrides the configured timeout
+					if c, ok := in[0].Interface().(context.Context); ok && c != nil {
+						ctx = c
+					}
 				}
 				if len(outs) == 1 && in[end-1].Type().Kind() == reflect.Ptr {
 					end -= 1
@@ -116,25 +122,17 @@ func (p *Proxy) Implement(v common.RPCService) {
 				}
 			}
 
-			inv = invocation_impl.NewRPCInvocationWithOptions(invocation_impl.WithMethodName(methodName),
-				invocation_impl.WithArguments(inArr), invocation_impl.WithReply(reply.Interface()),
-				invocation_impl.WithCallBack(p.callBack))
-
-			for k, value := range p.attachments {
-				inv.SetAttachments(k, value)
+			inv, err = invocation_impl.NewInvocationBuilder(methodName).Arguments(inArr...).Reply(reply.Interface()).
+				CallBack(p.callBack).Attachments(p.attachments).Context(ctx).Build()
+			if err != nil {
+				return proxyResult(outs, reply, err)
 			}
 
 			result := p.invoke.Invoke(inv)
 
 			err = result.Error()
 			logger.Infof("[makeDubboCallProxy] result: %v, err: %v", result.Result(), err)
-			if len(outs) == 1 {
-				return []reflect.Value{reflect.ValueOf(&err).Elem()}
-			}
-			if len(outs) == 2 && outs[0].Kind() != reflect.Ptr {
-				return []reflect.Value{reply.Elem(), reflect.ValueOf(&err).Elem()}
-			}
-			return []reflect.Value{reply, reflect.ValueOf(&err).Elem()}
+			return proxyResult(outs, reply, err)
 		}
 	}
 
@@ -178,6 +176,17 @@ func (p *Proxy) Implement(v common.RPCService) {
 
 }
 
+// proxyResult returns the reply and err by the out parameters of the method
+func proxyResult(outs []reflect.Type, reply reflect.Value, err error) []reflect.Value {
+	if len(outs) == 1 {
+		return []reflect.Value{reflect.ValueOf(&err).Elem()}
+	}
+	if len(outs) == 2 && outs[0].Kind() != reflect.Ptr {
+		return []reflect.Value{reply.Elem(), reflect.ValueOf(&err).Elem()}
+	}
+	return []reflect.Value{reply, reflect.ValueOf(&err).Elem()}
+}
+
 func (p *Proxy) Get() common.RPCService {
 	return p.rpc
 }
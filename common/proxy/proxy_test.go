@@ -21,6 +21,7 @@ import (
 	"context"
 	"reflect"
 	"testing"
+	"time"
 )
 
 import (
@@ -32,6 +33,7 @@ import (
 	"github.com/apache/dubbo-go/common"
 	"github.com/apache/dubbo-go/common/constant"
 	"github.com/apache/dubbo-go/protocol"
+	"github.com/apache/dubbo-go/protocol/invocation"
 )
 
 type TestService struct {
@@ -120,3 +122,34 @@ func TestProxy_Implement(t *testing.T) {
 	assert.Nil(t, s3.MethodOne)
 
 }
+
+// contextInvoker records the context of the last invocation
+type contextInvoker struct {
+	*protocol.BaseInvoker
+	ctx context.Context
+}
+
+func (ci *contextInvoker) Invoke(inv protocol.Invocation) protocol.Result {
+	ci.ctx = inv.(*invocation.RPCInvocation).Context()
+	return &protocol.RPCResult{}
+}
+
+func TestProxy_ImplementContext(t *testing.T) {
+	invoker := &contextInvoker{BaseInvoker: protocol.NewBaseInvoker(common.URL{})}
+	p := NewProxy(invoker, nil, nil)
+	s := &TestService{}
+	p.Implement(s)
+
+	// the context of the call is passed to the invocation
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	assert.NoError(t, s.MethodOne(ctx, 0, false, nil))
+	assert.Equal(t, ctx, invoker.ctx)
+
+	// the background context if there isn't any
+	assert.NoError(t, s.MethodOne(nil, 0, false, nil))
+	_, ok := invoker.ctx.Deadline()
+	assert.False(t, ok)
+	assert.NoError(t, s.MethodTwo(nil))
+	assert.Equal(t, context.Background(), invoker.ctx)
+}
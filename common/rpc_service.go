@@ -86,6 +86,19 @@ func (m *MethodType) SuiteContext(ctx context.Context) reflect.Value {
 	return reflect.Zero(m.ctxType)
 }
 
+// SuiteArgument keeps the nil argument i as the nil (or zero) value of its type,
+// or allocates a zero value for it if nilAsZero.
+func (m *MethodType) SuiteArgument(i int, arg interface{}, nilAsZero bool) reflect.Value {
+	if arg != nil {
+		return reflect.ValueOf(arg)
+	}
+	at := m.argsType[i]
+	if nilAsZero && at.Kind() == reflect.Ptr {
+		return reflect.New(at.Elem())
+	}
+	return reflect.Zero(at)
+}
+
 //////////////////////////
 // info of service interface
 //////////////////////////
@@ -129,6 +129,22 @@ func TestMethodType_SuiteContext(t *testing.T) {
 	assert.Equal(t, reflect.Zero(mt.ctxType), mt.SuiteContext(nil))
 }
 
+func TestMethodType_SuiteArgument(t *testing.T) {
+	mt := &MethodType{argsType: []reflect.Type{reflect.TypeOf(&TestService{}), reflect.TypeOf(map[string]string{}), reflect.TypeOf("")}}
+
+	// the nil arguments are the nil or zero values of the argument types
+	assert.True(t, mt.SuiteArgument(0, nil, false).IsNil())
+	assert.True(t, mt.SuiteArgument(1, nil, false).IsNil())
+	assert.Equal(t, "", mt.SuiteArgument(2, nil, false).Interface())
+
+	// the zero values are allocated for the nil pointer arguments
+	assert.Equal(t, &TestService{}, mt.SuiteArgument(0, nil, true).Interface())
+	assert.True(t, mt.SuiteArgument(1, nil, true).IsNil())
+
+	s := &TestService{}
+	assert.Equal(t, s, mt.SuiteArgument(0, s, false).Interface())
+}
+
 func TestSuiteMethod(t *testing.T) {
 
 	s := &TestService{}
@@ -36,6 +36,7 @@ import (
 
 import (
 	"github.com/apache/dubbo-go/common/constant"
+	"github.com/apache/dubbo-go/common/logger"
 )
 
 /////////////////////////////////
@@ -371,6 +372,15 @@ func (c URL) GetMethodParam(method string, key string, d string) string {
 	return r
 }
 
+// GetMethodParamBool returns the method param, or the service param if the method one isn't specified
+func (c URL) GetMethodParamBool(method string, key string, d bool) bool {
+	r, err := strconv.ParseBool(c.Params.Get("methods." + method + "." + key))
+	if err != nil {
+		return c.GetParamBool(key, d)
+	}
+	return r
+}
+
 // ToMap transfer URL to Map
 func (c URL) ToMap() map[string]string {
 
@@ -443,6 +453,22 @@ func MergeUrl(serviceUrl URL, referenceUrl *URL) URL {
 		}
 	})
 
+	//serialization negotiated by the preference order of the reference and the serializations the provider supports
+	if v := referenceUrl.Params.Get(constant.SERIALIZATION_KEY); v != "" {
+		mergedUrl.Params.Set(constant.SERIALIZATION_KEY, negotiateSerialization(serviceUrl, v, serviceUrl.Params.Get(constant.SERIALIZATIONS_KEY)))
+		mergedUrl.Params.Set(constant.PREFERRED_SERIALIZATION_KEY, v)
+	}
+	methodConfigMergeFcn = append(methodConfigMergeFcn, func(method string) {
+		preferred := referenceUrl.Params.Get(method + "." + constant.SERIALIZATION_KEY)
+		if preferred == "" {
+			preferred = referenceUrl.Params.Get(constant.SERIALIZATION_KEY)
+		}
+		supported := serviceUrl.Params.Get(method + "." + constant.SERIALIZATIONS_KEY)
+		if preferred != "" && supported != "" {
+			mergedUrl.Params.Set(method+"."+constant.SERIALIZATION_KEY, negotiateSerialization(serviceUrl, preferred, supported))
+		}
+	})
+
 	//remote timestamp
 	if v := serviceUrl.Params.Get(constant.TIMESTAMP_KEY); v != "" {
 		mergedUrl.Params.Set(constant.REMOTE_TIMESTAMP_KEY, v)
@@ -458,3 +484,24 @@ func MergeUrl(serviceUrl URL, referenceUrl *URL) URL {
 
 	return mergedUrl
 }
+
+// negotiateSerialization returns the first serialization of the @preferred list that the provider @supported,
+// the first preferred one is returned if the provider doesn't advertise its serializations or none of them matches.
+func negotiateSerialization(serviceUrl URL, preferred string, supported string) string {
+	preferences := strings.Split(preferred, ",")
+	for i := range preferences {
+		preferences[i] = strings.TrimSpace(preferences[i])
+	}
+	if supported == "" {
+		return preferences[0]
+	}
+	for _, serialization := range preferences {
+		for _, s := range strings.Split(supported, ",") {
+			if serialization == strings.TrimSpace(s) {
+				return serialization
+			}
+		}
+	}
+	logger.Warnf("none of the serializations %v is supported by the provider %v, which supports %v", preferred, serviceUrl.Key(), supported)
+	return preferences[0]
+}
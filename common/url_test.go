@@ -157,6 +157,18 @@ func TestURL_GetParamBool(t *testing.T) {
 	assert.Equal(t, false, v)
 }
 
+func TestURL_GetMethodParamBool(t *testing.T) {
+	params := url.Values{}
+	params.Set("sticky", "true")
+	params.Set("methods.GetValue.sticky", "false")
+	u := URL{baseUrl: baseUrl{Params: params}}
+	assert.Equal(t, false, u.GetMethodParamBool("GetValue", "sticky", true))
+	assert.Equal(t, true, u.GetMethodParamBool("GetName", "sticky", false))
+
+	u = URL{}
+	assert.Equal(t, true, u.GetMethodParamBool("GetValue", "sticky", true))
+}
+
 func TestURL_GetParamAndDecoded(t *testing.T) {
 	rule := "host = 2.2.2.2,1.1.1.1,3.3.3.3 & host !=1.1.1.1 => host = 1.2.3.4"
 	params := url.Values{}
@@ -232,3 +244,27 @@ func TestMergeUrl(t *testing.T) {
 	assert.Equal(t, "1", mergedUrl.GetParam("test2", ""))
 	assert.Equal(t, "1", mergedUrl.GetParam("test3", ""))
 }
+
+func TestMergeUrlSerialization(t *testing.T) {
+	referenceUrlParams := url.Values{}
+	referenceUrlParams.Set(constant.SERIALIZATION_KEY, "protobuf, json, hessian2")
+	referenceUrlParams.Set("methods.GetUser."+constant.SERIALIZATION_KEY, "protobuf,hessian2")
+	serviceUrlParams := url.Values{}
+	serviceUrlParams.Set(constant.SERIALIZATIONS_KEY, "hessian2,json")
+	serviceUrlParams.Set("methods.GetUser."+constant.SERIALIZATIONS_KEY, "hessian2")
+	referenceUrl, _ := NewURL(context.TODO(), "mock1://127.0.0.1:1111", WithParams(referenceUrlParams),
+		WithMethods([]string{"GetUser", "GetUsers"}))
+	serviceUrl, _ := NewURL(context.TODO(), "mock2://127.0.0.1:20000", WithParams(serviceUrlParams))
+
+	// the preferred protobuf is unsupported by the provider, the mutually supported json is chosen
+	mergedUrl := MergeUrl(serviceUrl, &referenceUrl)
+	assert.Equal(t, "json", mergedUrl.GetParam(constant.SERIALIZATION_KEY, ""))
+	assert.Equal(t, "hessian2", mergedUrl.GetMethodParam("GetUser", constant.SERIALIZATION_KEY, ""))
+	assert.Equal(t, "json", mergedUrl.GetMethodParam("GetUsers", constant.SERIALIZATION_KEY, "json"))
+	assert.Equal(t, "protobuf, json, hessian2", mergedUrl.GetParam(constant.PREFERRED_SERIALIZATION_KEY, ""))
+
+	// the first preferred one if the provider doesn't advertise its serializations
+	serviceUrl, _ = NewURL(context.TODO(), "mock2://127.0.0.1:20000")
+	mergedUrl = MergeUrl(serviceUrl, &referenceUrl)
+	assert.Equal(t, "protobuf", mergedUrl.GetParam(constant.SERIALIZATION_KEY, ""))
+}
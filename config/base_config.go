@@ -74,6 +74,7 @@ func (c *BaseConfig) prepareEnvironment() error {
 		return perrors.WithStack(err)
 	}
 	config.GetEnvInstance().UpdateExternalConfigMap(mapContent)
+	config.GetEnvInstance().SetDynamicConfiguration(dynamicConfig)
 	return nil
 }
 
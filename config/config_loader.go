@@ -129,6 +129,10 @@ func Load() {
 			}
 		}
 	}
+
+	if providerConfig == nil || !providerConfig.DisableShutdownSignals {
+		handleShutdownSignals()
+	}
 }
 
 // get rpc service for consumer
@@ -18,9 +18,16 @@
 package config
 
 import (
+	"crypto/rand"
+	"fmt"
 	"regexp"
 	"strings"
 )
+
+import (
+	perrors "github.com/pkg/errors"
+)
+
 import (
 	"github.com/apache/dubbo-go/common/constant"
 )
@@ -76,3 +83,14 @@ func mergeValue(str1, str2, def string) string {
 	str = reg.ReplaceAllString(strings.Join(strArr, ","), ",")
 	return strings.Trim(str, ",")
 }
+
+// newToken generates a random UUID as the token of the provider
+func newToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", perrors.WithMessage(err, "generate the token")
+	}
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:]), nil
+}
@@ -48,6 +48,14 @@ type ConsumerConfig struct {
 	RequestTimeout  time.Duration
 	ProxyFactory    string `yaml:"proxy_factory" default:"default" json:"proxy_factory,omitempty" property:"proxy_factory"`
 	Check           *bool  `yaml:"check"  json:"check,omitempty" property:"check"`
+	// default serialization of all the references, it can be overridden by the reference config
+	Serialization string `yaml:"serialization" json:"serialization,omitempty" property:"serialization"`
+	// the max number of the failover retries in progress across the consumer, 0 means unlimited
+	FailoverConcurrencyMax int `yaml:"failover_concurrency_max" json:"failover_concurrency_max,omitempty" property:"failover_concurrency_max"`
+	// the reporters of the metrics like prometheus separated by commas, and the port and path they're served on
+	Metrics     string `yaml:"metrics" json:"metrics,omitempty" property:"metrics"`
+	MetricsPort string `yaml:"metrics_port" json:"metrics_port,omitempty" property:"metrics.port"`
+	MetricsPath string `yaml:"metrics_path" json:"metrics_path,omitempty" property:"metrics.path"`
 
 	Registries   map[string]*RegistryConfig  `yaml:"registries" json:"registries,omitempty" property:"registries"`
 	References   map[string]*ReferenceConfig `yaml:"references" json:"references,omitempty" property:"references"`
@@ -0,0 +1,202 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package config
+
+import (
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+)
+
+import (
+	"github.com/apache/dubbo-go/common/constant"
+	"github.com/apache/dubbo-go/common/extension"
+	"github.com/apache/dubbo-go/common/logger"
+)
+
+const (
+	gracefulShutdownFilterName = "graceful_shutdown"
+	accessLogFilterName        = "accesslog"
+	activeInvocationsCheckTick = 10 * time.Millisecond
+)
+
+var (
+	shutdownOnce       sync.Once
+	shutdownSignalOnce sync.Once
+	beforeShutdownLock sync.Mutex
+	beforeShutdownHook []func()
+	// exit is replaced by the tests
+	exit = os.Exit
+	// the time for destroying the protocols and references after waiting for the active invocations, the exit is
+	// forced if the graceful shutdown doesn't end by then
+	shutdownExitGrace = 10 * time.Second
+)
+
+// shutdownFilter is implemented by the graceful shutdown filter of the providers
+type shutdownFilter interface {
+	RejectNewInvocations()
+	ActiveInvocations() int64
+}
+
+// filterCloser is implemented by the filters writing in the background, like the access log filter
+type filterCloser interface {
+	Close()
+}
+
+// registriesDestroyer is implemented by the registry protocol
+type registriesDestroyer interface {
+	DestroyRegistries()
+}
+
+// BeforeShutdown adds a hook which is called at the end of the graceful shutdown, after all the protocols
+// and references are destroyed
+func BeforeShutdown(hook func()) {
+	beforeShutdownLock.Lock()
+	beforeShutdownHook = append(beforeShutdownHook, hook)
+	beforeShutdownLock.Unlock()
+}
+
+// GracefulShutdown unregisters the providers from the registries, rejects the new invocations, waits for the
+// active invocations to complete at most the shutdown timeout, then destroys the protocols and references,
+// flushes the access logs and calls the BeforeShutdown hooks. Only the first call takes effect.
+func GracefulShutdown() {
+	shutdownOnce.Do(func() {
+		logger.Infof("graceful shutdown begins")
+		if extension.HasProtocol(constant.REGISTRY_PROTOCOL) {
+			if rp, ok := extension.GetProtocol(constant.REGISTRY_PROTOCOL).(registriesDestroyer); ok {
+				rp.DestroyRegistries()
+			}
+		}
+
+		waitForActiveInvocations()
+
+		destroyProviders()
+		destroyConsumers()
+		closeAccessLog()
+
+		beforeShutdownLock.Lock()
+		hooks := beforeShutdownHook
+		beforeShutdownLock.Unlock()
+		for _, hook := range hooks {
+			hook()
+		}
+		logger.Infof("graceful shutdown ends")
+	})
+}
+
+func waitForActiveInvocations() {
+	if !extension.HasFilter(gracefulShutdownFilterName) {
+		return
+	}
+	sf, ok := extension.GetFilter(gracefulShutdownFilterName).(shutdownFilter)
+	if !ok {
+		return
+	}
+	sf.RejectNewInvocations()
+
+	deadline := time.Now().Add(shutdownTimeout())
+	for sf.ActiveInvocations() > 0 {
+		if time.Now().After(deadline) {
+			logger.Warnf("graceful shutdown timed out with %v active invocations", sf.ActiveInvocations())
+			return
+		}
+		time.Sleep(activeInvocationsCheckTick)
+	}
+}
+
+func shutdownTimeout() time.Duration {
+	timeout := constant.DEFAULT_SHUTDOWN_TIMEOUT
+	if providerConfig != nil && providerConfig.ShutdownTimeout != "" {
+		timeout = providerConfig.ShutdownTimeout
+	}
+	d, err := time.ParseDuration(timeout)
+	if err != nil {
+		logger.Errorf("invalid shutdown timeout %v, use the default %v", timeout, constant.DEFAULT_SHUTDOWN_TIMEOUT)
+		d, _ = time.ParseDuration(constant.DEFAULT_SHUTDOWN_TIMEOUT)
+	}
+	return d
+}
+
+func destroyProviders() {
+	if providerConfig == nil {
+		return
+	}
+	for _, svs := range providerConfig.Services {
+		svs.Unexport()
+	}
+	destroyed := make(map[string]struct{})
+	for _, proto := range providerConfig.Protocols {
+		if _, ok := destroyed[proto.Name]; ok || !extension.HasProtocol(proto.Name) {
+			continue
+		}
+		destroyed[proto.Name] = struct{}{}
+		extension.GetProtocol(proto.Name).Destroy()
+	}
+}
+
+func destroyConsumers() {
+	if consumerConfig == nil {
+		return
+	}
+	for _, ref := range consumerConfig.References {
+		if ref.invoker != nil {
+			ref.invoker.Destroy()
+		}
+	}
+}
+
+// closeAccessLog flushes the buffered entries of the access log filter and closes its files
+func closeAccessLog() {
+	if !extension.HasFilter(accessLogFilterName) {
+		return
+	}
+	if fc, ok := extension.GetFilter(accessLogFilterName).(filterCloser); ok {
+		fc.Close()
+	}
+}
+
+// handleShutdownSignals calls GracefulShutdown and exits once SIGTERM or SIGINT is received
+func handleShutdownSignals() {
+	shutdownSignalOnce.Do(func() {
+		signals := make(chan os.Signal, 1)
+		signal.Notify(signals, syscall.SIGTERM, syscall.SIGINT)
+		go shutdownOnSignal(signals)
+	})
+}
+
+// shutdownOnSignal shuts down gracefully once a signal is received from @signals and exits after that. The exit is
+// forced if the graceful shutdown doesn't end in the shutdown timeout and the grace, or another signal is received.
+func shutdownOnSignal(signals <-chan os.Signal) {
+	sig := <-signals
+	logger.Infof("get signal %v, graceful shutdown", sig)
+	deadline := shutdownTimeout() + shutdownExitGrace
+	forced := time.AfterFunc(deadline, func() {
+		logger.Warnf("graceful shutdown doesn't end in %v, exit forcibly", deadline)
+		exit(1)
+	})
+	go func() {
+		// a second signal exits immediately
+		<-signals
+		exit(1)
+	}()
+	GracefulShutdown()
+	forced.Stop()
+	exit(0)
+}
@@ -0,0 +1,239 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package config
+
+import (
+	"context"
+	"os"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+import (
+	"github.com/apache/dubbo-go/common"
+	"github.com/apache/dubbo-go/common/extension"
+	"github.com/apache/dubbo-go/filter"
+	"github.com/apache/dubbo-go/filter/impl"
+	"github.com/apache/dubbo-go/protocol"
+	"github.com/apache/dubbo-go/protocol/invocation"
+)
+
+type slowInvoker struct {
+	protocol.BaseInvoker
+	started chan struct{}
+	release chan struct{}
+}
+
+func (si *slowInvoker) Invoke(invocation protocol.Invocation) protocol.Result {
+	si.started <- struct{}{}
+	<-si.release
+	return &protocol.RPCResult{Rest: "done"}
+}
+
+func newSlowInvoker() *slowInvoker {
+	url, _ := common.NewURL(context.TODO(), "dubbo://192.168.1.1:20000/com.ikurento.user.UserProvider")
+	return &slowInvoker{
+		BaseInvoker: *protocol.NewBaseInvoker(url),
+		started:     make(chan struct{}),
+		release:     make(chan struct{}),
+	}
+}
+
+// setupGracefulShutdown resets the shutdown and returns a fresh filter
+func setupGracefulShutdown(timeout string) *impl.GracefulShutdownFilter {
+	shutdownOnce = sync.Once{}
+	beforeShutdownHook = nil
+	providerConfig = &ProviderConfig{ShutdownTimeout: timeout}
+	consumerConfig = nil
+	f := impl.NewGracefulShutdownFilter()
+	extension.SetFilter(gracefulShutdownFilterName, func() filter.Filter {
+		return f
+	})
+	return f
+}
+
+func waitForRejecting(t *testing.T, f *impl.GracefulShutdownFilter) protocol.Result {
+	inv := invocation.NewRPCInvocation("GetUser", []interface{}{"1"}, nil)
+	for i := 0; i < 100; i++ {
+		// the rejected invocations don't reach the invoker
+		if result := f.Invoke(&protocol.BaseInvoker{}, inv); result.Error() != nil {
+			return result
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("the new invocations are not rejected")
+	return nil
+}
+
+func TestGracefulShutdown(t *testing.T) {
+	f := setupGracefulShutdown("5s")
+	defer func() { providerConfig = nil }()
+	var hooked bool
+	BeforeShutdown(func() { hooked = true })
+
+	invoker := newSlowInvoker()
+	inv := invocation.NewRPCInvocation("GetUser", []interface{}{"1"}, nil)
+	results := make(chan protocol.Result, 1)
+	go func() {
+		results <- f.Invoke(invoker, inv)
+	}()
+	<-invoker.started
+
+	done := make(chan struct{})
+	go func() {
+		GracefulShutdown()
+		close(done)
+	}()
+
+	// the new invocations are rejected while the long-running one is in progress
+	result := waitForRejecting(t, f)
+	statusErr, ok := result.Error().(protocol.StatusError)
+	assert.True(t, ok)
+	assert.Equal(t, impl.SHUTTING_DOWN_CODE, statusErr.StatusCode())
+	select {
+	case <-done:
+		t.Fatal("the shutdown doesn't wait for the active invocations")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	// the long-running invocation completes and the shutdown ends
+	close(invoker.release)
+	result = <-results
+	assert.NoError(t, result.Error())
+	assert.Equal(t, "done", result.Result())
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("the shutdown doesn't end after the active invocations complete")
+	}
+	assert.True(t, hooked)
+	assert.Equal(t, int64(0), f.ActiveInvocations())
+}
+
+// closingFilter records whether it's closed
+type closingFilter struct {
+	filter.Filter
+	closed bool
+}
+
+func (cf *closingFilter) Close() {
+	cf.closed = true
+}
+
+func TestGracefulShutdown_AccessLog(t *testing.T) {
+	setupGracefulShutdown("100ms")
+	defer func() {
+		providerConfig = nil
+		extension.SetFilter(accessLogFilterName, impl.GetAccessLogFilter)
+	}()
+	cf := &closingFilter{}
+	extension.SetFilter(accessLogFilterName, func() filter.Filter {
+		return cf
+	})
+
+	GracefulShutdown()
+	assert.True(t, cf.closed)
+}
+
+func TestGracefulShutdown_Timeout(t *testing.T) {
+	f := setupGracefulShutdown("100ms")
+	defer func() { providerConfig = nil }()
+	var hooked bool
+	BeforeShutdown(func() { hooked = true })
+
+	invoker := newSlowInvoker()
+	defer close(invoker.release)
+	go f.Invoke(invoker, invocation.NewRPCInvocation("GetUser", []interface{}{"1"}, nil))
+	<-invoker.started
+
+	start := time.Now()
+	GracefulShutdown()
+	assert.True(t, time.Since(start) >= 100*time.Millisecond)
+	assert.True(t, time.Since(start) < time.Second)
+	assert.True(t, hooked)
+	assert.Equal(t, int64(1), f.ActiveInvocations())
+}
+
+func TestGracefulShutdown_Signal(t *testing.T) {
+	f := setupGracefulShutdown("100ms")
+	defer func() {
+		providerConfig = nil
+		exit = os.Exit
+	}()
+	codes := make(chan int, 1)
+	exit = func(code int) {
+		codes <- code
+	}
+
+	// the timeout forces the exit
+	invoker := newSlowInvoker()
+	defer close(invoker.release)
+	go f.Invoke(invoker, invocation.NewRPCInvocation("GetUser", []interface{}{"1"}, nil))
+	<-invoker.started
+
+	handleShutdownSignals()
+	assert.NoError(t, syscall.Kill(os.Getpid(), syscall.SIGTERM))
+	select {
+	case code := <-codes:
+		assert.Equal(t, 0, code)
+	case <-time.After(time.Second):
+		t.Fatal("no exit after SIGTERM")
+	}
+}
+
+func TestGracefulShutdown_SignalForcedExit(t *testing.T) {
+	setupGracefulShutdown("100ms")
+	grace := shutdownExitGrace
+	shutdownExitGrace = 100 * time.Millisecond
+	codes := make(chan int, 2)
+	exit = func(code int) {
+		codes <- code
+	}
+	release := make(chan struct{})
+	BeforeShutdown(func() { <-release })
+	signals := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	go func() {
+		shutdownOnSignal(signals)
+		close(done)
+	}()
+	defer func() {
+		close(release)
+		<-done
+		providerConfig = nil
+		exit = os.Exit
+		shutdownExitGrace = grace
+	}()
+
+	// the graceful shutdown hanging in the hook is forced to exit after the timeout and the grace
+	start := time.Now()
+	signals <- syscall.SIGTERM
+	select {
+	case code := <-codes:
+		assert.Equal(t, 1, code)
+		assert.True(t, time.Since(start) >= 200*time.Millisecond)
+	case <-time.After(time.Second):
+		t.Fatal("no forced exit after the shutdown timeout")
+	}
+}
@@ -27,6 +27,10 @@ type MethodConfig struct {
 	Retries       int64  `yaml:"retries"  json:"retries,omitempty" property:"retries"`
 	Loadbalance   string `yaml:"loadbalance"  json:"loadbalance,omitempty" property:"loadbalance"`
 	Weight        int64  `yaml:"weight"  json:"weight,omitempty" property:"weight"`
+	// the timeout of the method calls in milliseconds
+	Timeout string `yaml:"timeout"  json:"timeout,omitempty" property:"timeout"`
+	// the cluster of the method, or the chain of the clusters like failover,failback
+	Cluster string `yaml:"cluster"  json:"cluster,omitempty" property:"cluster"`
 }
 
 func (c *MethodConfig) Prefix() string {
@@ -40,6 +40,14 @@ type ProviderConfig struct {
 	BaseConfig   `yaml:",inline"`
 	Filter       string `yaml:"filter" json:"filter,omitempty" property:"filter"`
 	ProxyFactory string `yaml:"proxy_factory" default:"default" json:"proxy_factory,omitempty" property:"proxy_factory"`
+	// the max time to wait for the active invocations on shutdown, 60s by default
+	ShutdownTimeout string `yaml:"shutdown_timeout" json:"shutdown_timeout,omitempty" property:"shutdown_timeout"`
+	// don't shut down gracefully and exit once SIGTERM or SIGINT is received, the application handles them by itself
+	DisableShutdownSignals bool `yaml:"disable_shutdown_signals" json:"disable_shutdown_signals,omitempty" property:"disable_shutdown_signals"`
+	// the reporters of the metrics like prometheus separated by commas, and the port and path they're served on
+	Metrics     string `yaml:"metrics" json:"metrics,omitempty" property:"metrics"`
+	MetricsPort string `yaml:"metrics_port" json:"metrics_port,omitempty" property:"metrics.port"`
+	MetricsPath string `yaml:"metrics_path" json:"metrics_path,omitempty" property:"metrics.path"`
 
 	ApplicationConfig *ApplicationConfig         `yaml:"application_config" json:"application_config,omitempty" property:"application_config"`
 	Registries        map[string]*RegistryConfig `yaml:"registries" json:"registries,omitempty" property:"registries"`
@@ -22,17 +22,25 @@ import (
 	"fmt"
 	"net/url"
 	"strconv"
+	"strings"
 	"time"
 )
 
 import (
+	"github.com/apache/dubbo-go/cluster"
+	"github.com/apache/dubbo-go/cluster/cluster_impl"
 	"github.com/apache/dubbo-go/cluster/directory"
+	"github.com/apache/dubbo-go/cluster/loadbalance"
 	"github.com/apache/dubbo-go/common"
+	"github.com/apache/dubbo-go/common/config"
 	"github.com/apache/dubbo-go/common/constant"
 	"github.com/apache/dubbo-go/common/extension"
+	"github.com/apache/dubbo-go/common/logger"
 	"github.com/apache/dubbo-go/common/proxy"
 	"github.com/apache/dubbo-go/common/utils"
+	"github.com/apache/dubbo-go/filter"
 	"github.com/apache/dubbo-go/protocol"
+	"github.com/apache/dubbo-go/protocol/protocolwrapper"
 )
 
 type ReferenceConfig struct {
@@ -42,8 +50,10 @@ type ReferenceConfig struct {
 	InterfaceName string            `required:"true"  yaml:"interface"  json:"interface,omitempty" property:"interface"`
 	Check         *bool             `yaml:"check"  json:"check,omitempty" property:"check"`
 	Url           string            `yaml:"url"  json:"url,omitempty" property:"url"`
+	ServiceName   string            `yaml:"service_name"  json:"service_name,omitempty" property:"service_name"`
 	Filter        string            `yaml:"filter" json:"filter,omitempty" property:"filter"`
 	Protocol      string            `yaml:"protocol"  json:"protocol,omitempty" property:"protocol"`
+	Serialization string            `yaml:"serialization"  json:"serialization,omitempty" property:"serialization"`
 	Registry      string            `yaml:"registry"  json:"registry,omitempty"  property:"registry"`
 	Cluster       string            `yaml:"cluster"  json:"cluster,omitempty" property:"cluster"`
 	Loadbalance   string            `yaml:"loadbalance"  json:"loadbalance,omitempty" property:"loadbalance"`
@@ -51,10 +61,13 @@ type ReferenceConfig struct {
 	Group         string            `yaml:"group"  json:"group,omitempty" property:"group"`
 	Version       string            `yaml:"version"  json:"version,omitempty" property:"version"`
 	Methods       []*MethodConfig   `yaml:"methods"  json:"methods,omitempty" property:"methods"`
-	async         bool              `yaml:"async"  json:"async,omitempty" property:"async"`
+	Async         bool              `yaml:"async"  json:"async,omitempty" property:"async"`
 	Params        map[string]string `yaml:"params"  json:"params,omitempty" property:"params"`
 	invoker       protocol.Invoker
 	urls          []*common.URL
+	filters       []filter.Filter
+	callbacks     []cluster_impl.ResultCallback
+	asyncHandler  protocol.AsyncHandler
 	Generic       bool `yaml:"generic"  json:"generic,omitempty" property:"generic"`
 }
 
@@ -79,7 +92,15 @@ func (refconfig *ReferenceConfig) UnmarshalYAML(unmarshal func(interface{}) erro
 }
 
 func (refconfig *ReferenceConfig) Refer() {
+	//0. resolve the logical service name to the physical routing
+	if refconfig.ServiceName != "" && referenceResolver != nil {
+		if err := referenceResolver.Resolve(refconfig.ServiceName, refconfig); err != nil {
+			panic(fmt.Sprintf("reference service name %v resolve error, error message is %v ", refconfig.ServiceName, err.Error()))
+		}
+	}
+
 	url := common.NewURLWithOptions(common.WithPath(refconfig.id), common.WithProtocol(refconfig.Protocol), common.WithParams(refconfig.getUrlMap()))
+	refconfig.checkLoadbalances()
 
 	//1. user specified URL, could be peer-to-peer address, or register center's address.
 	if refconfig.Url != "" {
@@ -123,16 +144,40 @@ func (refconfig *ReferenceConfig) Refer() {
 			}
 		}
 		if regUrl != nil {
-			cluster := extension.GetCluster("registryAware")
-			refconfig.invoker = cluster.Join(directory.NewStaticDirectory(invokers))
+			clusterExtension := extension.GetCluster(multiRegistryCluster(refconfig.urls))
+			refconfig.invoker = clusterExtension.Join(directory.NewStaticDirectory(invokers))
 		} else {
-			cluster := extension.GetCluster(refconfig.Cluster)
-			refconfig.invoker = cluster.Join(directory.NewStaticDirectory(invokers))
+			clusterExtension := extension.GetCluster(cluster.GetClusterName(*url))
+			refconfig.invoker = clusterExtension.Join(directory.NewStaticDirectory(invokers))
 		}
 	}
 
+	if refconfig.invoker != nil {
+		refconfig.invoker = cluster_impl.NewMirroringInvoker(refconfig.invoker, *url)
+		refconfig.invoker = cluster_impl.NewCoalescingInvoker(refconfig.invoker, *url)
+		refconfig.invoker = cluster_impl.NewResultCallbackInvoker(refconfig.invoker, refconfig.callbacks)
+		refconfig.invoker = protocolwrapper.BuildFilterChain(refconfig.invoker, refconfig.filters...)
+	}
+
 	//create proxy
-	refconfig.pxy = extension.GetProxyFactory(consumerConfig.ProxyFactory).GetProxy(refconfig.invoker, url)
+	if refconfig.asyncHandler != nil {
+		refconfig.pxy = extension.GetProxyFactory(consumerConfig.ProxyFactory).GetAsyncProxy(refconfig.invoker, refconfig.asyncHandler, url)
+	} else {
+		refconfig.pxy = extension.GetProxyFactory(consumerConfig.ProxyFactory).GetProxy(refconfig.invoker, url)
+	}
+}
+
+// checkLoadbalances warns the loadbalances which aren't registered, the custom ones may be registered later
+// before the first invocation.
+func (refconfig *ReferenceConfig) checkLoadbalances() {
+	if refconfig.Loadbalance != "" && !extension.HasLoadbalance(refconfig.Loadbalance) {
+		logger.Warnf("the loadbalance %v of the reference %v is not registered yet", refconfig.Loadbalance, refconfig.id)
+	}
+	for _, method := range refconfig.Methods {
+		if method.Loadbalance != "" && !extension.HasLoadbalance(method.Loadbalance) {
+			logger.Warnf("the loadbalance %v of the method %v.%v is not registered yet", method.Loadbalance, refconfig.id, method.Name)
+		}
+	}
 }
 
 // @v is service provider implemented RPCService
@@ -144,6 +189,53 @@ func (refconfig *ReferenceConfig) GetRPCService() common.RPCService {
 	return refconfig.pxy.Get()
 }
 
+// AddFilter attaches the filter instance @f to this reference only, e.g. a closure-based one. The attached filters
+// wrap the cluster invoker of the reference in the order they are added, so they run once per invocation before
+// the filters configured by name, which run on the selected provider. it must be called before Refer.
+func (refconfig *ReferenceConfig) AddFilter(f filter.Filter) {
+	refconfig.filters = append(refconfig.filters, f)
+}
+
+// OnResult registers the @callback run on each successful result of this reference, after the filter chain returns.
+// it must be called before Refer.
+func (refconfig *ReferenceConfig) OnResult(callback cluster_impl.ResultCallback) {
+	refconfig.callbacks = append(refconfig.callbacks, callback)
+}
+
+// isABTest returns whether the reference or any of its methods runs the A/B test of the abtest load balance
+func (refconfig *ReferenceConfig) isABTest() bool {
+	if refconfig.Loadbalance == loadbalance.ABTest {
+		return true
+	}
+	for _, method := range refconfig.Methods {
+		if method.Loadbalance == loadbalance.ABTest {
+			return true
+		}
+	}
+	return false
+}
+
+// SetAsyncHandler sets the @handler of the results of the async invocations of this reference, the responses
+// are delivered to it later while the invocations return immediately. it must be called before Refer.
+func (refconfig *ReferenceConfig) SetAsyncHandler(handler protocol.AsyncHandler) {
+	refconfig.asyncHandler = handler
+}
+
+// InvokeBatch invokes the @calls together, see proxy.Proxy.InvokeBatch
+func (refconfig *ReferenceConfig) InvokeBatch(calls []*proxy.BatchCall, concurrency int) []error {
+	return refconfig.pxy.InvokeBatch(calls, concurrency)
+}
+
+// multiRegistryCluster selects the registries by the zone aware cluster if any of them is tagged with a zone or preferred
+func multiRegistryCluster(urls []*common.URL) string {
+	for _, u := range urls {
+		if u.GetParam(constant.REGISTRY_ZONE_KEY, "") != "" || u.GetParamBool(constant.PREFERRED_KEY, false) {
+			return "zoneAware"
+		}
+	}
+	return "registryAware"
+}
+
 func (refconfig *ReferenceConfig) getUrlMap() url.Values {
 	urlMap := url.Values{}
 	//first set user params
@@ -159,8 +251,20 @@ func (refconfig *ReferenceConfig) getUrlMap() url.Values {
 	urlMap.Set(constant.VERSION_KEY, refconfig.Version)
 	urlMap.Set(constant.GENERIC_KEY, strconv.FormatBool(refconfig.Generic))
 	urlMap.Set(constant.ROLE_KEY, strconv.Itoa(common.CONSUMER))
+	//serialization of reference has priority over the consumer default
+	serialization := refconfig.Serialization
+	if serialization == "" {
+		serialization = consumerConfig.Serialization
+	}
+	if serialization == "" {
+		serialization = constant.DEFAULT_SERIALIZATION
+	}
+	urlMap.Set(constant.SERIALIZATION_KEY, serialization)
+	if consumerConfig.FailoverConcurrencyMax > 0 {
+		urlMap.Set(constant.FAILOVER_CONCURRENCY_MAX_KEY, strconv.Itoa(consumerConfig.FailoverConcurrencyMax))
+	}
 	//getty invoke async or sync
-	urlMap.Set(constant.ASYNC_KEY, strconv.FormatBool(refconfig.async))
+	urlMap.Set(constant.ASYNC_KEY, strconv.FormatBool(refconfig.Async))
 
 	//application info
 	urlMap.Set(constant.APPLICATION_KEY, consumerConfig.ApplicationConfig.Name)
@@ -171,21 +275,59 @@ func (refconfig *ReferenceConfig) getUrlMap() url.Values {
 	urlMap.Set(constant.OWNER_KEY, consumerConfig.ApplicationConfig.Owner)
 	urlMap.Set(constant.ENVIRONMENT_KEY, consumerConfig.ApplicationConfig.Environment)
 
-	//filter
+	//metrics reported by the reporters of the consumer
+	if consumerConfig.Metrics != "" {
+		urlMap.Set(constant.METRICS_KEY, consumerConfig.Metrics)
+		urlMap.Set(constant.METRICS_PORT_KEY, consumerConfig.MetricsPort)
+		urlMap.Set(constant.METRICS_PATH_KEY, consumerConfig.MetricsPath)
+	}
+
+	//filter, the metrics filter is enabled by default if the metrics are
 	var defaultReferenceFilter = constant.DEFAULT_REFERENCE_FILTERS
 	if refconfig.Generic {
 		defaultReferenceFilter = constant.GENERIC_REFERENCE_FILTERS + defaultReferenceFilter
 	}
+	if consumerConfig.Metrics != "" {
+		defaultReferenceFilter = strings.Trim(constant.METRICS_FILTER+","+defaultReferenceFilter, ",")
+	}
+	if refconfig.isABTest() {
+		defaultReferenceFilter = strings.Trim(constant.ABTEST_FILTER+","+defaultReferenceFilter, ",")
+	}
 	urlMap.Set(constant.REFERENCE_FILTER_KEY, mergeValue(consumerConfig.Filter, refconfig.Filter, defaultReferenceFilter))
 
 	for _, v := range refconfig.Methods {
 		urlMap.Set("methods."+v.Name+"."+constant.LOADBALANCE_KEY, v.Loadbalance)
 		urlMap.Set("methods."+v.Name+"."+constant.RETRIES_KEY, strconv.FormatInt(v.Retries, 10))
+		if len(v.Timeout) > 0 {
+			urlMap.Set("methods."+v.Name+"."+constant.TIMEOUT_KEY, v.Timeout)
+		}
+		if len(v.Cluster) > 0 {
+			urlMap.Set("methods."+v.Name+"."+constant.CLUSTER_KEY, v.Cluster)
+		}
 	}
 
 	return urlMap
 
 }
+
+// envParams returns the properties of the reference in the environment, keyed by the params they override,
+// e.g. dubbo.reference.{interface}.{id}.methods.{method}.timeout. The properties of the other references of
+// the interface are excluded.
+func (refconfig *ReferenceConfig) envParams() map[string]string {
+	prefix := refconfig.Prefix()
+	if refconfig.id != "" {
+		prefix += refconfig.id + "."
+	}
+	env := config.GetEnvInstance().Configuration().Front().Value.(*config.InmemoryConfiguration)
+	params := env.GetProperties(prefix)
+	for k := range params {
+		if strings.Contains(k, ".") && !strings.HasPrefix(k, "methods.") {
+			delete(params, k)
+		}
+	}
+	return params
+}
+
 func (refconfig *ReferenceConfig) GenericLoad(id string) {
 	genericService := NewGenericService(refconfig.id)
 	SetConsumerService(genericService)
@@ -18,6 +18,7 @@
 package config
 
 import (
+	"context"
 	"sync"
 	"testing"
 
@@ -25,14 +26,17 @@ import (
 )
 
 import (
+	perrors "github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
 )
 
 import (
+	"github.com/apache/dubbo-go/cluster"
 	"github.com/apache/dubbo-go/cluster/cluster_impl"
 	"github.com/apache/dubbo-go/common"
 	"github.com/apache/dubbo-go/common/extension"
 	"github.com/apache/dubbo-go/protocol"
+	"github.com/apache/dubbo-go/protocol/invocation"
 )
 
 var regProtocol protocol.Protocol
@@ -210,6 +214,187 @@ func Test_Forking(t *testing.T) {
 	consumerConfig = nil
 }
 
+func Test_ReferSerialization(t *testing.T) {
+	doInit()
+	extension.SetProtocol("registry", GetProtocol)
+	extension.SetCluster("registryAware", cluster_impl.NewRegistryAwareCluster)
+
+	m := consumerConfig.References["MockService"]
+	m.Refer()
+	assert.Equal(t, constant.DEFAULT_SERIALIZATION, m.invoker.GetUrl().SubURL.GetParam(constant.SERIALIZATION_KEY, ""))
+
+	// inherit the consumer default
+	consumerConfig.Serialization = "json"
+	m.Refer()
+	assert.Equal(t, "json", m.invoker.GetUrl().SubURL.GetParam(constant.SERIALIZATION_KEY, ""))
+
+	// the reference config overrides the consumer default
+	m.Serialization = "protobuf"
+	m.Refer()
+	assert.Equal(t, "protobuf", m.invoker.GetUrl().SubURL.GetParam(constant.SERIALIZATION_KEY, ""))
+	consumerConfig = nil
+}
+
+type mockReferenceResolver struct {
+	addresses map[string]string
+}
+
+func (r *mockReferenceResolver) Resolve(serviceName string, refconfig *ReferenceConfig) error {
+	address, ok := r.addresses[serviceName]
+	if !ok {
+		return perrors.Errorf("unknown service name %v", serviceName)
+	}
+	refconfig.Url = address
+	return nil
+}
+
+func Test_ReferWithResolver(t *testing.T) {
+	doInit()
+	extension.SetProtocol("dubbo", GetProtocol)
+	SetReferenceResolver(&mockReferenceResolver{addresses: map[string]string{"user-center": "dubbo://127.0.0.1:20000"}})
+	defer SetReferenceResolver(nil)
+
+	m := consumerConfig.References["MockService"]
+	m.ServiceName = "user-center"
+	m.Refer()
+	assert.Equal(t, "127.0.0.1:20000", m.invoker.GetUrl().Location)
+
+	m.ServiceName = "unknown"
+	assert.Panics(t, func() { m.Refer() })
+	consumerConfig = nil
+}
+
+// customLoadBalance always selects the last invoker
+type customLoadBalance struct{}
+
+func (*customLoadBalance) Select(invokers []protocol.Invoker, invocation protocol.Invocation) protocol.Invoker {
+	return invokers[len(invokers)-1]
+}
+
+func Test_ReferCustomLoadbalance(t *testing.T) {
+	doInit()
+	extension.SetProtocol("dubbo", GetProtocol)
+	newCustomLoadBalance := func() cluster.LoadBalance {
+		return &customLoadBalance{}
+	}
+	assert.NoError(t, extension.RegisterLoadbalance("reference_config_test", newCustomLoadBalance))
+	assert.Error(t, extension.RegisterLoadbalance("reference_config_test", newCustomLoadBalance))
+	assert.Error(t, extension.RegisterLoadbalance("", newCustomLoadBalance))
+
+	m := consumerConfig.References["MockService"]
+	m.Url = "dubbo://127.0.0.1:20000"
+	m.Loadbalance = "reference_config_test"
+	m.Refer()
+	assert.NotNil(t, m.invoker)
+	lb := extension.GetLoadbalance(m.urls[0].GetParam(constant.LOADBALANCE_KEY, ""))
+	assert.IsType(t, &customLoadBalance{}, lb)
+	consumerConfig = nil
+}
+
+// countFilter counts the invocations passing it
+type countFilter struct {
+	count int
+}
+
+func (f *countFilter) Invoke(invoker protocol.Invoker, invocation protocol.Invocation) protocol.Result {
+	f.count++
+	return invoker.Invoke(invocation)
+}
+
+func (f *countFilter) OnResponse(result protocol.Result, invoker protocol.Invoker, invocation protocol.Invocation) protocol.Result {
+	return result
+}
+
+func Test_ReferAddFilter(t *testing.T) {
+	doInit()
+	extension.SetProtocol("dubbo", GetProtocol)
+	m := consumerConfig.References["MockService"]
+	m.Url = "dubbo://127.0.0.1:20000"
+	consumerConfig.References["MockService1"] = &ReferenceConfig{
+		InterfaceName: "com.MockService1",
+		Protocol:      "dubbo",
+		Url:           "dubbo://127.0.0.1:20001",
+	}
+
+	f := &countFilter{}
+	m.AddFilter(f)
+	for _, reference := range consumerConfig.References {
+		reference.Refer()
+		assert.NotNil(t, reference.invoker)
+	}
+
+	// the filter wraps the invoker of its own reference only
+	ivc := invocation.NewRPCInvocationWithOptions(invocation.WithMethodName("GetUser"))
+	m.invoker.Invoke(ivc)
+	assert.Equal(t, 1, f.count)
+	other := consumerConfig.References["MockService1"]
+	assert.Equal(t, "", other.urls[0].GetParam(constant.REFERENCE_FILTER_KEY, ""))
+	other.invoker.Invoke(ivc)
+	assert.Equal(t, 1, f.count)
+	consumerConfig = nil
+}
+
+// lastInvokerCluster always invokes the last provider, and counts the invocations
+type lastInvokerCluster struct {
+	invoked *int
+}
+
+func (c *lastInvokerCluster) Join(directory cluster.Directory) protocol.Invoker {
+	return &lastInvokerClusterInvoker{Invoker: protocol.NewBaseInvoker(directory.GetUrl()), directory: directory, invoked: c.invoked}
+}
+
+type lastInvokerClusterInvoker struct {
+	protocol.Invoker
+	directory cluster.Directory
+	invoked   *int
+}
+
+func (ivk *lastInvokerClusterInvoker) Invoke(invocation protocol.Invocation) protocol.Result {
+	*ivk.invoked++
+	invokers := ivk.directory.List(invocation)
+	return invokers[len(invokers)-1].Invoke(invocation)
+}
+
+func Test_ReferCustomCluster(t *testing.T) {
+	doInit()
+	extension.SetProtocol("dubbo", GetProtocol)
+	invoked := 0
+	newCustomCluster := func() cluster.Cluster {
+		return &lastInvokerCluster{invoked: &invoked}
+	}
+	assert.NoError(t, extension.RegisterCluster("reference_config_test", newCustomCluster))
+	assert.Error(t, extension.RegisterCluster("reference_config_test", newCustomCluster))
+	assert.Error(t, extension.RegisterCluster("", newCustomCluster))
+	assert.True(t, extension.HasCluster("reference_config_test"))
+
+	m := consumerConfig.References["MockService"]
+	m.Url = "dubbo://127.0.0.1:20000;dubbo://127.0.0.2:20000"
+	m.Cluster = "reference_config_test"
+	m.Refer()
+	assert.NotNil(t, m.invoker)
+	m.invoker.Invoke(invocation.NewRPCInvocationWithOptions(invocation.WithMethodName("GetUser")))
+	assert.Equal(t, 1, invoked)
+	consumerConfig = nil
+}
+
+func Test_ReferOnResult(t *testing.T) {
+	doInit()
+	extension.SetProtocol("dubbo", GetProtocol)
+	m := consumerConfig.References["MockService"]
+	m.Url = "dubbo://127.0.0.1:20000"
+	methods := []string{}
+	m.OnResult(func(inv protocol.Invocation, result protocol.Result) {
+		assert.NoError(t, result.Error())
+		methods = append(methods, inv.MethodName())
+	})
+	m.Refer()
+	assert.NotNil(t, m.invoker)
+	m.invoker.Invoke(invocation.NewRPCInvocationWithOptions(invocation.WithMethodName("GetUser")))
+	m.invoker.Invoke(invocation.NewRPCInvocationWithOptions(invocation.WithMethodName("GetUsers")))
+	assert.Equal(t, []string{"GetUser", "GetUsers"}, methods)
+	consumerConfig = nil
+}
+
 func GetProtocol() protocol.Protocol {
 	if regProtocol != nil {
 		return regProtocol
@@ -232,3 +417,40 @@ func (*mockRegistryProtocol) Export(invoker protocol.Invoker) protocol.Exporter
 }
 
 func (*mockRegistryProtocol) Destroy() {}
+
+func Test_MultiRegistryCluster(t *testing.T) {
+	url1, _ := common.NewURL(context.TODO(), "registry://127.0.0.1:2181")
+	url2, _ := common.NewURL(context.TODO(), "registry://127.0.0.2:2181")
+	assert.Equal(t, "registryAware", multiRegistryCluster([]*common.URL{&url1, &url2}))
+
+	// the registries tagged with zones
+	url2.AddParam(constant.REGISTRY_ZONE_KEY, "az2")
+	assert.Equal(t, "zoneAware", multiRegistryCluster([]*common.URL{&url1, &url2}))
+
+	url3, _ := common.NewURL(context.TODO(), "registry://127.0.0.3:2181?preferred=true")
+	assert.Equal(t, "zoneAware", multiRegistryCluster([]*common.URL{&url1, &url3}))
+}
+
+func Test_ReferMetrics(t *testing.T) {
+	doInit()
+	defer func() { consumerConfig = nil }()
+	m := consumerConfig.References["MockService"]
+
+	assert.Equal(t, "", m.getUrlMap().Get(constant.METRICS_KEY))
+
+	consumerConfig.Metrics = "prometheus"
+	urlMap := m.getUrlMap()
+	assert.Equal(t, "prometheus", urlMap.Get(constant.METRICS_KEY))
+	assert.Equal(t, "metrics", urlMap.Get(constant.REFERENCE_FILTER_KEY))
+}
+
+func Test_ReferABTest(t *testing.T) {
+	doInit()
+	defer func() { consumerConfig = nil }()
+	m := consumerConfig.References["MockService"]
+	assert.False(t, m.isABTest())
+
+	m.Methods[0].Loadbalance = "abtest"
+	assert.True(t, m.isABTest())
+	assert.Equal(t, "abtest", m.getUrlMap().Get(constant.REFERENCE_FILTER_KEY))
+}
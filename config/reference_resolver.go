@@ -0,0 +1,39 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package config
+
+var (
+	referenceResolver ReferenceResolver
+)
+
+// ReferenceResolver maps the logical service name of a reference to its physical routing,
+// it is consulted by Refer() before the urls of the reference are assembled.
+type ReferenceResolver interface {
+	// Resolve fills the routing fields of @refconfig, such as Url, Registry, Protocol or Params,
+	// according to the logical @serviceName.
+	Resolve(serviceName string, refconfig *ReferenceConfig) error
+}
+
+// SetReferenceResolver is called by init() of implement of ReferenceResolver
+func SetReferenceResolver(resolver ReferenceResolver) {
+	referenceResolver = resolver
+}
+
+func GetReferenceResolver() ReferenceResolver {
+	return referenceResolver
+}
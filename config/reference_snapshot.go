@@ -0,0 +1,136 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package config
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+import (
+	"github.com/apache/dubbo-go/common"
+	"github.com/apache/dubbo-go/common/config"
+	"github.com/apache/dubbo-go/common/constant"
+	"github.com/apache/dubbo-go/common/logger"
+	"github.com/apache/dubbo-go/config_center"
+)
+
+// ReferenceSnapshot is the effective configuration of a reference, for debugging why a call behaves the way it does
+type ReferenceSnapshot struct {
+	Interface     string
+	Protocol      string
+	Group         string
+	Version       string
+	Cluster       string
+	Loadbalance   string
+	Retries       int64
+	Timeout       time.Duration // 0 means the default of the protocol
+	Serialization string
+	Filters       []string
+	Methods       map[string]*MethodSnapshot
+	// all the params of the consumer url the snapshot is resolved from
+	Params map[string]string
+}
+
+// MethodSnapshot is the effective configuration of a method of the reference
+type MethodSnapshot struct {
+	Cluster     string
+	Loadbalance string
+	Retries     int64
+	Timeout     time.Duration
+}
+
+// Snapshot resolves the effective configuration of the reference: the config with the defaults and the method
+// overrides, then the overrides of the environment under dubbo.reference.{interface}.{id}. and the configurator
+// rule of the config center for all the providers. It's resolved the way the cluster invokers resolve them on
+// every invocation.
+func (refconfig *ReferenceConfig) Snapshot() *ReferenceSnapshot {
+	urlMap := refconfig.getUrlMap()
+	for k, v := range refconfig.envParams() {
+		urlMap.Set(k, v)
+	}
+	url := *common.NewURLWithOptions(common.WithPath(refconfig.id), common.WithProtocol(refconfig.Protocol), common.WithParams(urlMap))
+	url = configureSnapshotUrl(url)
+
+	snapshot := &ReferenceSnapshot{
+		Interface:     url.GetParam(constant.INTERFACE_KEY, ""),
+		Protocol:      url.Protocol,
+		Group:         url.GetParam(constant.GROUP_KEY, ""),
+		Version:       url.GetParam(constant.VERSION_KEY, ""),
+		Cluster:       url.GetParam(constant.CLUSTER_KEY, constant.DEFAULT_CLUSTER),
+		Loadbalance:   url.GetParam(constant.LOADBALANCE_KEY, constant.DEFAULT_LOADBALANCE),
+		Retries:       url.GetParamInt(constant.RETRIES_KEY, constant.DEFAULT_RETRIES),
+		Timeout:       consumerConfig.RequestTimeout,
+		Serialization: url.GetParam(constant.SERIALIZATION_KEY, ""),
+		Methods:       make(map[string]*MethodSnapshot),
+		Params:        make(map[string]string, len(url.Params)),
+	}
+	if filters := url.GetParam(constant.REFERENCE_FILTER_KEY, ""); filters != "" {
+		snapshot.Filters = strings.Split(filters, ",")
+	}
+	for k := range url.Params {
+		snapshot.Params[k] = url.Params.Get(k)
+		if strings.HasPrefix(k, "methods.") {
+			if i := strings.LastIndex(k, "."); i > len("methods.") {
+				snapshot.Methods[k[len("methods."):i]] = nil
+			}
+		}
+	}
+	for _, method := range refconfig.Methods {
+		snapshot.Methods[method.Name] = nil
+	}
+
+	for methodName := range snapshot.Methods {
+		method := &MethodSnapshot{
+			Cluster:     url.GetMethodParam(methodName, constant.CLUSTER_KEY, snapshot.Cluster),
+			Loadbalance: url.GetMethodParam(methodName, constant.LOADBALANCE_KEY, snapshot.Loadbalance),
+			Retries:     snapshot.Retries,
+			Timeout:     snapshot.Timeout,
+		}
+		if v, err := strconv.ParseInt(url.GetMethodParam(methodName, constant.RETRIES_KEY, ""), 10, 64); err == nil {
+			method.Retries = v
+		}
+		if v, err := strconv.ParseInt(url.GetMethodParam(methodName, constant.TIMEOUT_KEY, ""), 10, 64); err == nil && v > 0 {
+			method.Timeout = time.Duration(v) * time.Millisecond
+		}
+		snapshot.Methods[methodName] = method
+	}
+	return snapshot
+}
+
+// configureSnapshotUrl overrides the @url by the configurator rule of the service in the config center,
+// only the configs for all the providers are applied.
+func configureSnapshotUrl(url common.URL) common.URL {
+	dynamicConfig := config.GetEnvInstance().GetDynamicConfiguration()
+	if dynamicConfig == nil {
+		return url
+	}
+	key := url.ServiceKey() + constant.CONFIGURATORS_SUFFIX
+	content, err := dynamicConfig.GetConfig(key, config_center.WithGroup(config_center.DEFAULT_GROUP))
+	if err != nil || content == "" {
+		return url
+	}
+	rule, err := config_center.ParseConfiguratorRule(content)
+	if err != nil {
+		logger.Warnf("Parse configurator rule {%s} error, the rule is ignored, error message is %v", key, err)
+		return url
+	}
+	configured, _ := rule.Configure(url)
+	return configured
+}
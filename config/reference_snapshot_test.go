@@ -0,0 +1,107 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package config
+
+import (
+	"testing"
+	"time"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+import (
+	"github.com/apache/dubbo-go/common/config"
+	"github.com/apache/dubbo-go/common/constant"
+	"github.com/apache/dubbo-go/config_center"
+)
+
+// ruleDynamicConfiguration serves the configurator rules by their keys
+type ruleDynamicConfiguration struct {
+	config_center.DynamicConfiguration
+	rules map[string]string
+}
+
+func (c *ruleDynamicConfiguration) GetConfig(key string, opts ...config_center.Option) (string, error) {
+	return c.rules[key], nil
+}
+
+func Test_ReferenceSnapshot(t *testing.T) {
+	doInit()
+	defer func() { consumerConfig = nil }()
+	consumerConfig.RequestTimeout = 3 * time.Second
+	m := consumerConfig.References["MockService"]
+	m.id = "SnapshotService"
+	m.Methods[0].Loadbalance = "roundrobin"
+	config.GetEnvInstance().UpdateExternalConfigMap(map[string]string{
+		"dubbo.reference.com.MockService.SnapshotService.methods.GetUser.timeout": "2000",
+	})
+
+	snapshot := m.Snapshot()
+	assert.Equal(t, "com.MockService", snapshot.Interface)
+	assert.Equal(t, "huadong_idc", snapshot.Group)
+	assert.Equal(t, "1.0.0", snapshot.Version)
+	assert.Equal(t, "failover", snapshot.Cluster)
+	assert.Equal(t, "random", snapshot.Loadbalance)
+	assert.Equal(t, int64(3), snapshot.Retries)
+	assert.Equal(t, 3*time.Second, snapshot.Timeout)
+	assert.Equal(t, constant.DEFAULT_SERIALIZATION, snapshot.Serialization)
+	assert.Equal(t, "5", snapshot.Params["forks"])
+
+	// the timeout overridden by the environment
+	assert.Equal(t, 2*time.Second, snapshot.Methods["GetUser"].Timeout)
+	assert.Equal(t, 3*time.Second, snapshot.Methods["GetUser1"].Timeout)
+	// the loadbalance overridden by the method
+	assert.Equal(t, "roundrobin", snapshot.Methods["GetUser"].Loadbalance)
+	assert.Equal(t, "random", snapshot.Methods["GetUser1"].Loadbalance)
+	assert.Equal(t, int64(2), snapshot.Methods["GetUser"].Retries)
+	assert.Equal(t, "failover", snapshot.Methods["GetUser"].Cluster)
+
+	// the environment doesn't override the url the reference refers with
+	assert.Equal(t, "", m.getUrlMap().Get("methods.GetUser.timeout"))
+
+	// the properties of the other references of the interface are excluded
+	m.id = ""
+	_, ok := m.Snapshot().Params["SnapshotService.methods.GetUser.timeout"]
+	assert.False(t, ok)
+}
+
+func Test_ReferenceSnapshotConfigurator(t *testing.T) {
+	doInit()
+	defer func() { consumerConfig = nil }()
+	m := consumerConfig.References["MockService"]
+
+	config.GetEnvInstance().SetDynamicConfiguration(&ruleDynamicConfiguration{rules: map[string]string{
+		"huadong_idc/com.MockService:1.0.0" + constant.CONFIGURATORS_SUFFIX: `
+configs:
+  - addresses: [0.0.0.0]
+    parameters:
+      loadbalance: leastactive
+  - addresses: [192.168.1.1:20000]
+    parameters:
+      retries: 0
+`,
+	}})
+	defer config.GetEnvInstance().SetDynamicConfiguration(nil)
+
+	snapshot := m.Snapshot()
+	assert.Equal(t, "leastactive", snapshot.Loadbalance)
+	// the config of a single provider doesn't apply to the reference
+	assert.Equal(t, int64(3), snapshot.Retries)
+}
@@ -55,6 +55,7 @@ type ServiceConfig struct {
 	Methods       []*MethodConfig   `yaml:"methods"  json:"methods,omitempty" property:"methods"`
 	Warmup        string            `yaml:"warmup"  json:"warmup,omitempty"  property:"warmup"`
 	Retries       int64             `yaml:"retries"  json:"retries,omitempty" property:"retries"`
+	Token         string            `yaml:"token"  json:"token,omitempty" property:"token"`
 	Params        map[string]string `yaml:"params"  json:"params,omitempty" property:"params"`
 	unexported    *atomic.Bool
 	exported      *atomic.Bool
@@ -95,7 +96,12 @@ func (srvconfig *ServiceConfig) Export() error {
 	}
 
 	regUrls := loadRegistries(srvconfig.Registry, providerConfig.Registries, common.PROVIDER)
-	urlMap := srvconfig.getUrlMap()
+	urlMap, err := srvconfig.getUrlMap()
+	if err != nil {
+		err = perrors.Errorf("The service %v export error! Error message is %v .", srvconfig.InterfaceName, err.Error())
+		logger.Errorf(err.Error())
+		return err
+	}
 
 	for _, proto := range loadProtocol(srvconfig.Protocol, providerConfig.Protocols) {
 		//registry the service reflect
@@ -145,11 +151,25 @@ func (srvconfig *ServiceConfig) Export() error {
 
 }
 
+// Unexport unexports the service from all the protocols, the service can't be exported again
+func (srvconfig *ServiceConfig) Unexport() {
+	if srvconfig.unexported != nil && !srvconfig.unexported.CAS(false, true) {
+		return
+	}
+	for _, exporter := range srvconfig.exporters {
+		exporter.Unexport()
+	}
+	srvconfig.exporters = nil
+	if srvconfig.exported != nil {
+		srvconfig.exported.Store(false)
+	}
+}
+
 func (srvconfig *ServiceConfig) Implement(s common.RPCService) {
 	srvconfig.rpcService = s
 }
 
-func (srvconfig *ServiceConfig) getUrlMap() url.Values {
+func (srvconfig *ServiceConfig) getUrlMap() (url.Values, error) {
 	urlMap := url.Values{}
 	//first set user params
 	for k, v := range srvconfig.Params {
@@ -173,8 +193,34 @@ func (srvconfig *ServiceConfig) getUrlMap() url.Values {
 	urlMap.Set(constant.OWNER_KEY, providerConfig.ApplicationConfig.Owner)
 	urlMap.Set(constant.ENVIRONMENT_KEY, providerConfig.ApplicationConfig.Environment)
 
-	//filter
-	urlMap.Set(constant.SERVICE_FILTER_KEY, mergeValue(providerConfig.Filter, srvconfig.Filter, constant.DEFAULT_SERVICE_FILTERS))
+	//token, true or default means a random one
+	if srvconfig.Token != "" {
+		urlMap.Set(constant.TOKEN_KEY, srvconfig.Token)
+	}
+	if token := urlMap.Get(constant.TOKEN_KEY); token == "true" || token == constant.DEFAULT_KEY {
+		token, err := newToken()
+		if err != nil {
+			return nil, err
+		}
+		urlMap.Set(constant.TOKEN_KEY, token)
+	}
+
+	//metrics reported by the reporters of the provider
+	if providerConfig.Metrics != "" {
+		urlMap.Set(constant.METRICS_KEY, providerConfig.Metrics)
+		urlMap.Set(constant.METRICS_PORT_KEY, providerConfig.MetricsPort)
+		urlMap.Set(constant.METRICS_PATH_KEY, providerConfig.MetricsPath)
+	}
+
+	//filter, the token filter is enabled by default if the token is set, so is the metrics filter if the metrics are
+	defaultFilters := constant.DEFAULT_SERVICE_FILTERS
+	if urlMap.Get(constant.TOKEN_KEY) != "" {
+		defaultFilters += "," + constant.TOKEN_FILTER
+	}
+	if providerConfig.Metrics != "" {
+		defaultFilters = constant.METRICS_FILTER + "," + defaultFilters
+	}
+	urlMap.Set(constant.SERVICE_FILTER_KEY, mergeValue(providerConfig.Filter, srvconfig.Filter, defaultFilters))
 
 	for _, v := range srvconfig.Methods {
 		urlMap.Set("methods."+v.Name+"."+constant.LOADBALANCE_KEY, v.Loadbalance)
@@ -182,6 +228,16 @@ func (srvconfig *ServiceConfig) getUrlMap() url.Values {
 		urlMap.Set("methods."+v.Name+"."+constant.WEIGHT_KEY, strconv.FormatInt(v.Weight, 10))
 	}
 
-	return urlMap
+	//tps limit strategies of the service and its methods, the unknown ones fail the export rather than the invocations
+	for k := range urlMap {
+		if k != constant.TPS_LIMIT_STRATEGY_KEY && !strings.HasSuffix(k, "."+constant.TPS_LIMIT_STRATEGY_KEY) {
+			continue
+		}
+		if strategy := urlMap.Get(k); !extension.HasTpsLimitStrategy(strategy) {
+			return nil, perrors.Errorf("the tps limit strategy %v of %v is not existing, make sure you have import the package", strategy, k)
+		}
+	}
+
+	return urlMap, nil
 
 }
@@ -18,7 +18,9 @@
 package config
 
 import (
+	"net/url"
 	"testing"
+	"time"
 )
 
 import (
@@ -26,7 +28,9 @@ import (
 )
 
 import (
+	"github.com/apache/dubbo-go/common/constant"
 	"github.com/apache/dubbo-go/common/extension"
+	"github.com/apache/dubbo-go/filter"
 )
 
 func doinit() {
@@ -122,3 +126,62 @@ func Test_Export(t *testing.T) {
 	}
 	providerConfig = nil
 }
+
+func serviceUrlMap(t *testing.T, service *ServiceConfig) url.Values {
+	urlMap, err := service.getUrlMap()
+	assert.NoError(t, err)
+	return urlMap
+}
+
+func Test_ServiceToken(t *testing.T) {
+	doinit()
+	defer func() { providerConfig = nil }()
+	service := providerConfig.Services["MockService"]
+
+	urlMap := serviceUrlMap(t, service)
+	assert.Equal(t, "", urlMap.Get(constant.TOKEN_KEY))
+	assert.Equal(t, "echo,graceful_shutdown", urlMap.Get(constant.SERVICE_FILTER_KEY))
+
+	service.Token = "abc"
+	urlMap = serviceUrlMap(t, service)
+	assert.Equal(t, "abc", urlMap.Get(constant.TOKEN_KEY))
+	assert.Equal(t, "echo,graceful_shutdown,token", urlMap.Get(constant.SERVICE_FILTER_KEY))
+
+	// a random token is generated for true
+	service.Token = "true"
+	token := serviceUrlMap(t, service).Get(constant.TOKEN_KEY)
+	assert.Regexp(t, "^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$", token)
+	assert.NotEqual(t, token, serviceUrlMap(t, service).Get(constant.TOKEN_KEY))
+}
+
+func Test_ServiceTpsLimitStrategy(t *testing.T) {
+	doinit()
+	defer func() { providerConfig = nil }()
+	service := providerConfig.Services["MockService"]
+	extension.SetTpsLimitStrategy("mockStrategy", func(rate int64, interval time.Duration) filter.TpsLimitStrategy {
+		return nil
+	})
+
+	service.Params = map[string]string{"methods.GetUser." + constant.TPS_LIMIT_STRATEGY_KEY: "mockStrategy"}
+	serviceUrlMap(t, service)
+
+	// the unknown strategy fails the export
+	service.Params = map[string]string{"methods.GetUser." + constant.TPS_LIMIT_STRATEGY_KEY: "unknown"}
+	_, err := service.getUrlMap()
+	assert.Error(t, err)
+	service.Implement(&MockService{})
+	assert.Error(t, service.Export())
+}
+
+func Test_ServiceMetrics(t *testing.T) {
+	doinit()
+	defer func() { providerConfig = nil }()
+	service := providerConfig.Services["MockService"]
+
+	providerConfig.Metrics = "prometheus"
+	providerConfig.MetricsPort = "9091"
+	urlMap := serviceUrlMap(t, service)
+	assert.Equal(t, "prometheus", urlMap.Get(constant.METRICS_KEY))
+	assert.Equal(t, "9091", urlMap.Get(constant.METRICS_PORT_KEY))
+	assert.Equal(t, "metrics,echo,graceful_shutdown", urlMap.Get(constant.SERVICE_FILTER_KEY))
+}
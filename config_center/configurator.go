@@ -0,0 +1,121 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package config_center
+
+import (
+	"net/url"
+)
+
+import (
+	perrors "github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+)
+
+import (
+	"github.com/apache/dubbo-go/common"
+	"github.com/apache/dubbo-go/common/constant"
+)
+
+const (
+	CONSUMER_SIDE = "consumer"
+	PROVIDER_SIDE = "provider"
+)
+
+// ConfiguratorRule is the override rule of a service in the config center, e.g. pushed by the admin console
+// to disable an instance:
+//
+//	scope: service
+//	key: com.ikurento.user.UserProvider
+//	configs:
+//	  - addresses: [192.168.1.1:20000]
+//	    side: consumer
+//	    parameters:
+//	      disabled: true
+type ConfiguratorRule struct {
+	ConfigVersion string             `yaml:"configVersion"`
+	Scope         string             `yaml:"scope"`
+	Key           string             `yaml:"key"`
+	Enabled       bool               `yaml:"enabled"`
+	Configs       []ConfiguratorItem `yaml:"configs"`
+}
+
+type ConfiguratorItem struct {
+	// the addresses of the providers, 0.0.0.0 or empty means all the providers
+	Addresses  []string          `yaml:"addresses"`
+	Side       string            `yaml:"side"`
+	Parameters map[string]string `yaml:"parameters"`
+}
+
+func ParseConfiguratorRule(content string) (*ConfiguratorRule, error) {
+	rule := &ConfiguratorRule{Enabled: true}
+	if err := yaml.Unmarshal([]byte(content), rule); err != nil {
+		return nil, perrors.WithMessagef(err, "parse configurator rule {%v}", content)
+	}
+	return rule, nil
+}
+
+// Configure returns the provider url overridden by the matched configs of the rule on the consumer side,
+// the bool is false and the url itself is returned if there is no config matched.
+func (rule *ConfiguratorRule) Configure(providerUrl common.URL) (common.URL, bool) {
+	if rule == nil || !rule.Enabled {
+		return providerUrl, false
+	}
+
+	var params url.Values
+	for _, item := range rule.Configs {
+		if (item.Side != "" && item.Side != CONSUMER_SIDE) || !item.matchAddress(providerUrl) {
+			continue
+		}
+		if params == nil {
+			params = url.Values{}
+			for k, v := range providerUrl.Params {
+				params[k] = append([]string(nil), v...)
+			}
+		}
+		for k, v := range item.Parameters {
+			params.Set(k, v)
+		}
+	}
+	if params == nil {
+		return providerUrl, false
+	}
+
+	return *common.NewURLWithOptions(
+		common.WithProtocol(providerUrl.Protocol),
+		common.WithUsername(providerUrl.Username),
+		common.WithPassword(providerUrl.Password),
+		common.WithIp(providerUrl.Ip),
+		common.WithPort(providerUrl.Port),
+		common.WithPath(providerUrl.Path),
+		common.WithMethods(providerUrl.Methods),
+		common.WithParams(params),
+	), true
+}
+
+func (item *ConfiguratorItem) matchAddress(providerUrl common.URL) bool {
+	if len(item.Addresses) == 0 {
+		return true
+	}
+	for _, address := range item.Addresses {
+		if address == constant.ANYHOST_VALUE || address == constant.ANY_VALUE ||
+			address == providerUrl.Location || address == providerUrl.Ip {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,66 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package config_center
+
+import (
+	"context"
+	"testing"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+import (
+	"github.com/apache/dubbo-go/common"
+	"github.com/apache/dubbo-go/common/constant"
+)
+
+func TestConfiguratorRule_Configure(t *testing.T) {
+	rule, err := ParseConfiguratorRule(`
+scope: service
+key: com.ikurento.user.UserProvider
+configs:
+  - addresses: [192.168.1.1:20000]
+    side: consumer
+    parameters:
+      disabled: true
+  - addresses: [0.0.0.0]
+    side: provider
+    parameters:
+      weight: 200
+`)
+	assert.NoError(t, err)
+	assert.True(t, rule.Enabled)
+
+	url, _ := common.NewURL(context.TODO(), "dubbo://192.168.1.1:20000/com.ikurento.user.UserProvider?weight=100")
+	configured, ok := rule.Configure(url)
+	assert.True(t, ok)
+	assert.True(t, configured.GetParamBool(constant.DISABLED_KEY, false))
+	// the provider side config is not applied by the consumer
+	assert.Equal(t, int64(100), configured.GetParamInt(constant.WEIGHT_KEY, 0))
+	// the origin url is not changed
+	assert.False(t, url.GetParamBool(constant.DISABLED_KEY, false))
+
+	url, _ = common.NewURL(context.TODO(), "dubbo://192.168.1.2:20000/com.ikurento.user.UserProvider")
+	_, ok = rule.Configure(url)
+	assert.False(t, ok)
+
+	_, err = ParseConfiguratorRule("configs: {")
+	assert.Error(t, err)
+}
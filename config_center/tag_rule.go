@@ -0,0 +1,76 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package config_center
+
+import (
+	perrors "github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+)
+
+import (
+	"github.com/apache/dubbo-go/common"
+)
+
+// TagRule tags the providers of a service by their addresses, e.g. the gray instances:
+//
+//	force: false
+//	enabled: true
+//	key: com.ikurento.user.UserProvider
+//	tags:
+//	  - name: gray
+//	    addresses: [192.168.1.1:20000, 192.168.1.2]
+type TagRule struct {
+	Key     string `yaml:"key"`
+	Enabled bool   `yaml:"enabled"`
+	// the tagged invocations don't fall back to the untagged providers if it's true
+	Force bool  `yaml:"force"`
+	Tags  []Tag `yaml:"tags"`
+}
+
+type Tag struct {
+	Name      string   `yaml:"name"`
+	Addresses []string `yaml:"addresses"`
+}
+
+func ParseTagRule(content string) (*TagRule, error) {
+	rule := &TagRule{Enabled: true}
+	if err := yaml.Unmarshal([]byte(content), rule); err != nil {
+		return nil, perrors.WithMessagef(err, "parse tag rule {%v}", content)
+	}
+	return rule, nil
+}
+
+// Tag returns the tag of the provider by the address, the bool is false if the provider is not in any tag
+func (rule *TagRule) Tag(providerUrl common.URL) (string, bool) {
+	if rule == nil || !rule.Enabled {
+		return "", false
+	}
+	for _, tag := range rule.Tags {
+		for _, address := range tag.Addresses {
+			if address == providerUrl.Location || address == providerUrl.Ip {
+				return tag.Name, true
+			}
+		}
+	}
+	return "", false
+}
+
+// Forced returns true if the tagged invocations are forced to the tagged providers
+func (rule *TagRule) Forced() bool {
+	return rule != nil && rule.Enabled && rule.Force
+}
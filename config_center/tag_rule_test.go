@@ -0,0 +1,70 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package config_center
+
+import (
+	"context"
+	"testing"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+import (
+	"github.com/apache/dubbo-go/common"
+)
+
+func TestTagRule_Tag(t *testing.T) {
+	rule, err := ParseTagRule(`
+force: true
+key: com.ikurento.user.UserProvider
+tags:
+  - name: gray
+    addresses: [192.168.1.1:20000, 192.168.1.2]
+  - name: blue
+    addresses: [192.168.1.3:20000]
+`)
+	assert.NoError(t, err)
+	assert.True(t, rule.Forced())
+
+	url1, _ := common.NewURL(context.TODO(), "dubbo://192.168.1.1:20000/com.ikurento.user.UserProvider")
+	url2, _ := common.NewURL(context.TODO(), "dubbo://192.168.1.2:20001/com.ikurento.user.UserProvider")
+	url3, _ := common.NewURL(context.TODO(), "dubbo://192.168.1.3:20000/com.ikurento.user.UserProvider")
+	url4, _ := common.NewURL(context.TODO(), "dubbo://192.168.1.3:20001/com.ikurento.user.UserProvider")
+	for url, expected := range map[*common.URL]string{&url1: "gray", &url2: "gray", &url3: "blue"} {
+		tag, ok := rule.Tag(*url)
+		assert.True(t, ok)
+		assert.Equal(t, expected, tag)
+	}
+	_, ok := rule.Tag(url4)
+	assert.False(t, ok)
+
+	// the disabled rule tags nothing
+	rule.Enabled = false
+	_, ok = rule.Tag(url1)
+	assert.False(t, ok)
+	assert.False(t, rule.Forced())
+
+	var nilRule *TagRule
+	_, ok = nilRule.Tag(url1)
+	assert.False(t, ok)
+
+	_, err = ParseTagRule("tags: [")
+	assert.Error(t, err)
+}
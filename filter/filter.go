@@ -18,6 +18,7 @@
 package filter
 
 import (
+	"github.com/apache/dubbo-go/common"
 	"github.com/apache/dubbo-go/protocol"
 )
 
@@ -26,3 +27,10 @@ type Filter interface {
 	Invoke(protocol.Invoker, protocol.Invocation) protocol.Result
 	OnResponse(protocol.Result, protocol.Invoker, protocol.Invocation) protocol.Result
 }
+
+// ChainFilter is the filter prepared for the invoker its chain is built for, on export or refer
+type ChainFilter interface {
+	Filter
+	// Prepare is called once the filter is created for the invoker of the @url on the @role side
+	Prepare(url common.URL, role common.RoleType)
+}
@@ -0,0 +1,55 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package impl
+
+import (
+	"time"
+)
+
+import (
+	"github.com/apache/dubbo-go/cluster/loadbalance"
+	"github.com/apache/dubbo-go/common/extension"
+	"github.com/apache/dubbo-go/filter"
+	"github.com/apache/dubbo-go/protocol"
+)
+
+const ABTEST = "abtest"
+
+func init() {
+	extension.SetFilter(ABTEST, GetABTestFilter)
+}
+
+// ABTestFilter records the response time and the errors of the invocations to the arms of the A/B tests of the
+// abtest load balance. It's added to the filters of the reference with the abtest load balance.
+type ABTestFilter struct {
+}
+
+func (f *ABTestFilter) Invoke(invoker protocol.Invoker, invocation protocol.Invocation) protocol.Result {
+	start := time.Now()
+	result := invoker.Invoke(invocation)
+	loadbalance.RecordABTest(invoker.GetUrl(), invocation, time.Since(start), result.Error())
+	return result
+}
+
+func (f *ABTestFilter) OnResponse(result protocol.Result, invoker protocol.Invoker, invocation protocol.Invocation) protocol.Result {
+	return result
+}
+
+func GetABTestFilter() filter.Filter {
+	return &ABTestFilter{}
+}
@@ -0,0 +1,48 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package impl
+
+import (
+	"testing"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+import (
+	"github.com/apache/dubbo-go/cluster/loadbalance"
+	"github.com/apache/dubbo-go/protocol/invocation"
+)
+
+func TestABTestFilter_Invoke(t *testing.T) {
+	f := GetABTestFilter()
+	invoker := cacheInvoker(t, "loadbalance=abtest&abtest.loadbalances=random,leastactive&abtest.percentage=100")
+	url := invoker.GetUrl()
+
+	result := f.Invoke(invoker, invocation.NewRPCInvocation("GetUser", []interface{}{"ok"}, nil))
+	assert.NoError(t, result.Error())
+	result = f.Invoke(invoker, invocation.NewRPCInvocation("GetUser", []interface{}{"error"}, nil))
+	assert.Error(t, result.Error())
+
+	stats := loadbalance.GetABTestStats(url, "GetUser", loadbalance.ABTestArmB)
+	assert.Equal(t, "leastactive", stats.Loadbalance)
+	assert.Equal(t, int64(2), stats.Requests)
+	assert.Equal(t, int64(1), stats.Failures)
+	assert.Equal(t, int64(0), loadbalance.GetABTestStats(url, "GetUser", loadbalance.ABTestArmA).Requests)
+}
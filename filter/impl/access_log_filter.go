@@ -0,0 +1,286 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package impl
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+)
+
+import (
+	"go.uber.org/atomic"
+)
+
+import (
+	"github.com/apache/dubbo-go/common/constant"
+	"github.com/apache/dubbo-go/common/extension"
+	"github.com/apache/dubbo-go/common/logger"
+	"github.com/apache/dubbo-go/common/utils"
+	"github.com/apache/dubbo-go/filter"
+	"github.com/apache/dubbo-go/protocol"
+)
+
+const (
+	ACCESS_LOG = "accesslog"
+
+	// the buffered entries are flushed every interval, or once they reach the flush size.
+	// the new entries are dropped if the buffer is full.
+	accessLogBufferSize    = 5000
+	accessLogFlushSize     = 500
+	accessLogFlushInterval = 3 * time.Second
+
+	accessLogTimeLayout = "2006-01-02 15:04:05.000"
+	accessLogDateLayout = "2006-01-02"
+)
+
+var (
+	accessLogFilter     *AccessLogFilter
+	accessLogFilterOnce sync.Once
+)
+
+func init() {
+	extension.SetFilter(ACCESS_LOG, GetAccessLogFilter)
+}
+
+// AccessLogFilter logs the invocations of the urls with the accesslog param, which is true for the default logger
+// or the path of the log file. The file is rolled daily, the one of the past day is renamed with its date suffix.
+// The entries are buffered and written by a background goroutine, so the invocations are never blocked.
+type AccessLogFilter struct {
+	lock    sync.Mutex
+	entries []*accessLogEntry
+	closed  bool
+	// the entries dropped since the last flush, they're logged in a summary rather than one by one
+	dropped atomic.Int64
+
+	bufferSize    int
+	flushSize     int
+	flushInterval time.Duration
+	flush         chan struct{}
+	done          chan struct{}
+	stopped       chan struct{}
+	closeOnce     sync.Once
+
+	// the writers of the log files, by the paths, they're only used by the background goroutine
+	writers map[string]*accessLogWriter
+}
+
+type accessLogEntry struct {
+	accessLog string
+	time      time.Time
+	message   string
+}
+
+func newAccessLogFilter(bufferSize, flushSize int, flushInterval time.Duration) *AccessLogFilter {
+	f := &AccessLogFilter{
+		bufferSize:    bufferSize,
+		flushSize:     flushSize,
+		flushInterval: flushInterval,
+		flush:         make(chan struct{}, 1),
+		done:          make(chan struct{}),
+		stopped:       make(chan struct{}),
+		writers:       make(map[string]*accessLogWriter),
+	}
+	go f.run()
+	return f
+}
+
+func (f *AccessLogFilter) Invoke(invoker protocol.Invoker, invocation protocol.Invocation) protocol.Result {
+	if accessLog := invoker.GetUrl().GetParam(constant.ACCESS_LOG_KEY, ""); len(accessLog) > 0 && accessLog != "false" {
+		now := time.Now()
+		f.append(&accessLogEntry{accessLog: accessLog, time: now, message: accessLogMessage(now, invoker, invocation)})
+	}
+	return invoker.Invoke(invocation)
+}
+
+func (f *AccessLogFilter) OnResponse(result protocol.Result, invoker protocol.Invoker, invocation protocol.Invocation) protocol.Result {
+	return result
+}
+
+// Close stops the background goroutine after the buffered entries are flushed, and closes the log files.
+// The entries after it are dropped.
+func (f *AccessLogFilter) Close() {
+	f.closeOnce.Do(func() {
+		f.lock.Lock()
+		f.closed = true
+		f.lock.Unlock()
+		close(f.done)
+	})
+	<-f.stopped
+}
+
+// append buffers the @entry, it's dropped if the buffer is full
+func (f *AccessLogFilter) append(entry *accessLogEntry) {
+	f.lock.Lock()
+	if f.closed || len(f.entries) >= f.bufferSize {
+		f.lock.Unlock()
+		f.dropped.Inc()
+		return
+	}
+	f.entries = append(f.entries, entry)
+	full := len(f.entries) >= f.flushSize
+	f.lock.Unlock()
+
+	if full {
+		select {
+		case f.flush <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// take returns the buffered entries and empties the buffer
+func (f *AccessLogFilter) take() []*accessLogEntry {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	entries := f.entries
+	f.entries = nil
+	return entries
+}
+
+func (f *AccessLogFilter) run() {
+	ticker := time.NewTicker(f.flushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+		case <-f.flush:
+		case <-f.done:
+			f.write(f.take())
+			f.logDropped()
+			for path, w := range f.writers {
+				w.close()
+				delete(f.writers, path)
+			}
+			close(f.stopped)
+			return
+		}
+		f.write(f.take())
+		f.logDropped()
+	}
+}
+
+// logDropped logs the number of the entries dropped since the last time
+func (f *AccessLogFilter) logDropped() {
+	if dropped := f.dropped.Swap(0); dropped > 0 {
+		logger.Warnf("the access log buffer is full or closed, %d entries are dropped", dropped)
+	}
+}
+
+// write writes the @entries to the default logger or their log files
+func (f *AccessLogFilter) write(entries []*accessLogEntry) {
+	for _, entry := range entries {
+		if entry.accessLog == "true" || entry.accessLog == "default" {
+			logger.Info(entry.message)
+			continue
+		}
+		w, ok := f.writers[entry.accessLog]
+		if !ok {
+			w = &accessLogWriter{path: entry.accessLog}
+			f.writers[entry.accessLog] = w
+		}
+		if err := w.write(entry); err != nil {
+			logger.Warnf("failed to write the access log %s: %v", entry.accessLog, err)
+		}
+	}
+}
+
+// accessLogMessage is the entry of the @invocation: time, caller ip, service, method, argument types and values
+func accessLogMessage(now time.Time, invoker protocol.Invoker, invocation protocol.Invocation) string {
+	ip := invocation.AttachmentsByKey(constant.REMOTE_IP_KEY, "")
+	if len(ip) == 0 {
+		ip, _ = utils.GetLocalIP()
+	}
+	url := invoker.GetUrl()
+	service := url.GetParam(constant.INTERFACE_KEY, url.Service())
+	if version := url.GetParam(constant.VERSION_KEY, ""); len(version) > 0 {
+		service += ":" + version
+	}
+
+	types := make([]string, 0, len(invocation.Arguments()))
+	for i, arg := range invocation.Arguments() {
+		if i < len(invocation.ParameterTypes()) {
+			types = append(types, invocation.ParameterTypes()[i].String())
+		} else if arg == nil {
+			types = append(types, "nil")
+		} else {
+			types = append(types, reflect.TypeOf(arg).String())
+		}
+	}
+	return fmt.Sprintf("[%s] %s %s %s(%s) %+v", now.Format(accessLogTimeLayout), ip, service,
+		invocation.MethodName(), strings.Join(types, ","), invocation.Arguments())
+}
+
+// accessLogWriter appends the entries to the log file, the file of the past day is renamed with its date suffix
+type accessLogWriter struct {
+	path string
+	file *os.File
+	date string
+}
+
+func (w *accessLogWriter) write(entry *accessLogEntry) error {
+	date := entry.time.Format(accessLogDateLayout)
+	if w.file != nil && w.date != date {
+		w.close()
+		if err := os.Rename(w.path, w.path+"."+w.date); err != nil {
+			return err
+		}
+	}
+	if w.file == nil {
+		if err := w.open(date); err != nil {
+			return err
+		}
+	}
+	_, err := w.file.WriteString(entry.message + "\n")
+	return err
+}
+
+// open opens the log file of the @date, the existing file of another day is rolled first
+func (w *accessLogWriter) open(date string) error {
+	if info, err := os.Stat(w.path); err == nil {
+		if modified := info.ModTime().Format(accessLogDateLayout); modified != date {
+			if err := os.Rename(w.path, w.path+"."+modified); err != nil {
+				return err
+			}
+		}
+	}
+	file, err := os.OpenFile(w.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	w.file, w.date = file, date
+	return nil
+}
+
+func (w *accessLogWriter) close() {
+	if w.file != nil {
+		w.file.Close()
+		w.file = nil
+	}
+}
+
+// GetAccessLogFilter returns the access log filter shared by the urls, so their entries are written by one goroutine
+func GetAccessLogFilter() filter.Filter {
+	accessLogFilterOnce.Do(func() {
+		accessLogFilter = newAccessLogFilter(accessLogBufferSize, accessLogFlushSize, accessLogFlushInterval)
+	})
+	return accessLogFilter
+}
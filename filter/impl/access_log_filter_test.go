@@ -0,0 +1,165 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package impl
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+import (
+	"github.com/apache/dubbo-go/common"
+	"github.com/apache/dubbo-go/common/constant"
+	"github.com/apache/dubbo-go/protocol"
+	"github.com/apache/dubbo-go/protocol/invocation"
+)
+
+func accessLogInvoker(t *testing.T, accessLog string) protocol.Invoker {
+	url, err := common.NewURL(context.TODO(), "dubbo://192.168.1.1:20000/com.ikurento.user.UserProvider?interface=com.ikurento.user.UserProvider&version=1.0.0&"+
+		constant.ACCESS_LOG_KEY+"="+accessLog)
+	assert.NoError(t, err)
+	return protocol.NewBaseInvoker(url)
+}
+
+func accessLogLines(t *testing.T, path string) []string {
+	content, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	assert.NoError(t, err)
+	return strings.Split(strings.TrimSuffix(string(content), "\n"), "\n")
+}
+
+func accessLogDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "accesslog")
+	assert.NoError(t, err)
+	return dir
+}
+
+func TestAccessLogFilter_Buffering(t *testing.T) {
+	dir := accessLogDir(t)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "access.log")
+	f := newAccessLogFilter(10, 3, time.Hour)
+	defer f.Close()
+	invoker := accessLogInvoker(t, path)
+
+	inv := invocation.NewRPCInvocation("GetUser", []interface{}{"1", nil}, map[string]string{constant.REMOTE_IP_KEY: "10.0.0.1"})
+	f.Invoke(invoker, inv)
+	f.Invoke(invoker, inv)
+	// the entries are buffered until the flush size
+	time.Sleep(100 * time.Millisecond)
+	assert.Nil(t, accessLogLines(t, path))
+
+	f.Invoke(invoker, inv)
+	deadline := time.Now().Add(time.Second)
+	for len(accessLogLines(t, path)) < 3 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	lines := accessLogLines(t, path)
+	assert.Equal(t, 3, len(lines))
+	assert.Contains(t, lines[0], "10.0.0.1 com.ikurento.user.UserProvider:1.0.0 GetUser(string,nil) [1 <nil>]")
+}
+
+func TestAccessLogFilter_FlushInterval(t *testing.T) {
+	dir := accessLogDir(t)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "access.log")
+	f := newAccessLogFilter(10, 100, 50*time.Millisecond)
+	defer f.Close()
+
+	f.Invoke(accessLogInvoker(t, path), invocation.NewRPCInvocation("GetUser", []interface{}{"1"}, nil))
+	deadline := time.Now().Add(time.Second)
+	for len(accessLogLines(t, path)) < 1 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.Equal(t, 1, len(accessLogLines(t, path)))
+}
+
+func TestAccessLogFilter_FlushOnClose(t *testing.T) {
+	dir := accessLogDir(t)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "access.log")
+	f := newAccessLogFilter(10, 100, time.Hour)
+	invoker := accessLogInvoker(t, path)
+
+	inv := invocation.NewRPCInvocation("GetUser", []interface{}{"1"}, nil)
+	f.Invoke(invoker, inv)
+	f.Invoke(invoker, inv)
+	assert.Nil(t, accessLogLines(t, path))
+
+	f.Close()
+	assert.Equal(t, 2, len(accessLogLines(t, path)))
+
+	// the entries after the close are dropped, the invocations aren't affected
+	assert.NoError(t, f.Invoke(invoker, inv).Error())
+	f.Close()
+	assert.Equal(t, 2, len(accessLogLines(t, path)))
+}
+
+func TestAccessLogFilter_DropWhenFull(t *testing.T) {
+	dir := accessLogDir(t)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "access.log")
+	f := newAccessLogFilter(3, 100, time.Hour)
+	invoker := accessLogInvoker(t, path)
+
+	for i := 0; i < 5; i++ {
+		assert.NoError(t, f.Invoke(invoker, invocation.NewRPCInvocation("GetUser", []interface{}{i}, nil)).Error())
+	}
+	// the dropped entries are counted, and logged at the next flush
+	assert.Equal(t, int64(2), f.dropped.Load())
+	f.Close()
+	assert.Equal(t, int64(0), f.dropped.Load())
+	lines := accessLogLines(t, path)
+	assert.Equal(t, 3, len(lines))
+	assert.True(t, strings.HasSuffix(lines[2], "[2]"))
+}
+
+func TestAccessLogFilter_Disabled(t *testing.T) {
+	f := newAccessLogFilter(10, 100, time.Hour)
+	defer f.Close()
+	f.Invoke(accessLogInvoker(t, "false"), invocation.NewRPCInvocation("GetUser", nil, nil))
+	f.Invoke(protocol.NewBaseInvoker(common.URL{}), invocation.NewRPCInvocation("GetUser", nil, nil))
+	assert.Equal(t, 0, len(f.take()))
+}
+
+func TestAccessLogWriter_Roll(t *testing.T) {
+	dir := accessLogDir(t)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "access.log")
+	w := &accessLogWriter{path: path}
+	defer w.close()
+
+	yesterday := time.Now().Add(-24 * time.Hour)
+	assert.NoError(t, w.write(&accessLogEntry{time: yesterday, message: "yesterday"}))
+	assert.NoError(t, w.write(&accessLogEntry{time: time.Now(), message: "today"}))
+
+	// the file of the past day is renamed with its date suffix
+	assert.Equal(t, []string{"yesterday"}, accessLogLines(t, path+"."+yesterday.Format(accessLogDateLayout)))
+	assert.Equal(t, []string{"today"}, accessLogLines(t, path))
+}
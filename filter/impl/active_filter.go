@@ -19,6 +19,17 @@
 package impl
 
 import (
+	"strconv"
+	"time"
+)
+
+import (
+	perrors "github.com/pkg/errors"
+)
+
+import (
+	"github.com/apache/dubbo-go/common"
+	"github.com/apache/dubbo-go/common/constant"
 	"github.com/apache/dubbo-go/common/extension"
 	"github.com/apache/dubbo-go/common/logger"
 	"github.com/apache/dubbo-go/filter"
@@ -27,23 +38,61 @@ import (
 
 const active = "active"
 
+// errActivesExceeded is the cause of the calls rejected as the active ones are over the limit, they aren't counted
+var errActivesExceeded = perrors.New("actives exceeded")
+
 func init() {
 	extension.SetFilter(active, GetActiveFilter)
 }
 
+// ActiveFilter counts the active calls of every method on the consumer, and limits them by actives.
+// The call over the limit waits for the ending of an active one until its timeout.
 type ActiveFilter struct {
 }
 
 func (ef *ActiveFilter) Invoke(invoker protocol.Invoker, invocation protocol.Invocation) protocol.Result {
 	logger.Infof("invoking active filter. %v,%v", invocation.MethodName(), len(invocation.Arguments()))
 
-	protocol.BeginCount(invoker.GetUrl(), invocation.MethodName())
-	return invoker.Invoke(invocation)
+	url := invoker.GetUrl()
+	methodName := invocation.MethodName()
+	if actives := url.GetMethodParamInt64(methodName, constant.ACTIVES_KEY, 0); actives > 0 {
+		timeout := activeTimeout(url, invocation)
+		if !protocol.BeginCountWithLimit(url, methodName, int32(actives), timeout) {
+			return &protocol.RPCResult{Err: perrors.Wrapf(errActivesExceeded, "waiting for the active calls of the method %v of service %v "+
+				"timed out after %v, over the limit %v", methodName, url.ServiceKey(), timeout, actives)}
+		}
+	} else {
+		protocol.BeginCount(url, methodName)
+	}
+
+	// the active call is ended by OnResponse, or here if the invoker panics
+	defer func() {
+		if e := recover(); e != nil {
+			protocol.EndCount(url, methodName)
+			panic(e)
+		}
+	}()
+	start := time.Now()
+	result := invoker.Invoke(invocation)
+	// the elapsed time and the result are recorded for the load balance by score
+	protocol.RecordElapsed(url, methodName, time.Since(start), result.Error() == nil)
+	return result
+}
+
+// activeTimeout returns the timeout of the @invocation in milliseconds, which is its attachment
+// or the one of the @url, to wait for an active slot
+func activeTimeout(url common.URL, invocation protocol.Invocation) time.Duration {
+	timeout, err := strconv.ParseInt(invocation.AttachmentsByKey(constant.TIMEOUT_KEY, ""), 10, 64)
+	if err != nil || timeout <= 0 {
+		timeout = url.GetMethodParamInt64(invocation.MethodName(), constant.TIMEOUT_KEY, constant.DEFAULT_TIMEOUT)
+	}
+	return time.Duration(timeout) * time.Millisecond
 }
 
 func (ef *ActiveFilter) OnResponse(result protocol.Result, invoker protocol.Invoker, invocation protocol.Invocation) protocol.Result {
-
-	protocol.EndCount(invoker.GetUrl(), invocation.MethodName())
+	if perrors.Cause(result.Error()) != errActivesExceeded {
+		protocol.EndCount(invoker.GetUrl(), invocation.MethodName())
+	}
 	return result
 }
 
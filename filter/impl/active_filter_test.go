@@ -0,0 +1,161 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package impl
+
+import (
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+import (
+	perrors "github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+import (
+	"github.com/apache/dubbo-go/common"
+	"github.com/apache/dubbo-go/common/constant"
+	"github.com/apache/dubbo-go/filter"
+	"github.com/apache/dubbo-go/protocol"
+	"github.com/apache/dubbo-go/protocol/invocation"
+)
+
+// peakInvoker records the peak of its concurrent calls
+type peakInvoker struct {
+	*protocol.BaseInvoker
+	running int32
+	peak    int32
+	err     error
+}
+
+func (ivk *peakInvoker) Invoke(invocation protocol.Invocation) protocol.Result {
+	running := atomic.AddInt32(&ivk.running, 1)
+	defer atomic.AddInt32(&ivk.running, -1)
+	for {
+		peak := atomic.LoadInt32(&ivk.peak)
+		if running <= peak || atomic.CompareAndSwapInt32(&ivk.peak, peak, running) {
+			break
+		}
+	}
+	time.Sleep(time.Millisecond)
+	return &protocol.RPCResult{Err: ivk.err}
+}
+
+func activeUrl(service string, params url.Values) *common.URL {
+	params.Set(constant.INTERFACE_KEY, service)
+	return common.NewURLWithOptions(common.WithPath(service), common.WithParams(params))
+}
+
+// invokeFilter invokes the @invoker through the filter @f, as the filter chain does
+func invokeFilter(f filter.Filter, invoker protocol.Invoker, invocation protocol.Invocation) protocol.Result {
+	return f.OnResponse(f.Invoke(invoker, invocation), invoker, invocation)
+}
+
+func TestActiveFilter_Count(t *testing.T) {
+	invokerUrl := activeUrl("com.ikurento.user.ActiveCountProvider", url.Values{})
+	invoker := &peakInvoker{BaseInvoker: protocol.NewBaseInvoker(*invokerUrl)}
+	f := GetActiveFilter()
+
+	invokeFilter(f, invoker, invocation.NewRPCInvocation("GetUser", nil, nil))
+	invoker.err = perrors.New("error")
+	invokeFilter(f, invoker, invocation.NewRPCInvocation("GetUser", nil, nil))
+
+	status := protocol.GetStatus(*invokerUrl, "GetUser")
+	assert.Equal(t, int32(0), status.GetActive())
+	assert.Equal(t, int64(2), status.GetTotal())
+	assert.Equal(t, int64(1), status.GetFailed())
+	assert.Equal(t, int64(1), status.GetSucceeded())
+	assert.True(t, status.GetSucceededAverageElapsed() >= time.Millisecond)
+}
+
+func TestActiveFilter_Actives(t *testing.T) {
+	params := url.Values{}
+	params.Set("methods.GetUser."+constant.ACTIVES_KEY, "3")
+	params.Set(constant.TIMEOUT_KEY, "5000")
+	invokerUrl := activeUrl("com.ikurento.user.ActivesProvider", params)
+	invoker := &peakInvoker{BaseInvoker: protocol.NewBaseInvoker(*invokerUrl)}
+	f := GetActiveFilter()
+
+	// the calls over the limit wait for the active ones rather than failing
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 10; j++ {
+				assert.NoError(t, invokeFilter(f, invoker, invocation.NewRPCInvocation("GetUser", nil, nil)).Error())
+			}
+		}()
+	}
+	wg.Wait()
+	assert.True(t, atomic.LoadInt32(&invoker.peak) <= 3)
+	assert.Equal(t, int32(0), protocol.GetStatus(*invokerUrl, "GetUser").GetActive())
+}
+
+func TestActiveFilter_ActivesTimeout(t *testing.T) {
+	params := url.Values{}
+	params.Set(constant.ACTIVES_KEY, "1")
+	invokerUrl := activeUrl("com.ikurento.user.ActivesTimeoutProvider", params)
+	invoker := &blockingInvoker{BaseInvoker: protocol.NewBaseInvoker(*invokerUrl), release: make(chan struct{})}
+	status := protocol.GetStatus(*invokerUrl, "GetUser")
+	f := GetActiveFilter()
+
+	done := make(chan struct{})
+	go func() {
+		invokeFilter(f, invoker, invocation.NewRPCInvocation("GetUser", nil, nil))
+		close(done)
+	}()
+	for i := 0; i < 100 && status.GetActive() != 1; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// the call fails after waiting for the timeout of its attachment
+	start := time.Now()
+	result := invokeFilter(f, invoker, invocation.NewRPCInvocation("GetUser", nil, map[string]string{constant.TIMEOUT_KEY: "100"}))
+	assert.Error(t, result.Error())
+	assert.True(t, time.Since(start) >= 100*time.Millisecond)
+
+	// the call waiting for a slot goes on once the active one ends
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		invoker.release <- struct{}{}
+		invoker.release <- struct{}{}
+	}()
+	result = invokeFilter(f, invoker, invocation.NewRPCInvocation("GetUser", nil, map[string]string{constant.TIMEOUT_KEY: "1000"}))
+	assert.NoError(t, result.Error())
+	<-done
+	assert.Equal(t, int32(0), status.GetActive())
+}
+
+func TestActiveFilter_Panic(t *testing.T) {
+	params := url.Values{}
+	params.Set(constant.ACTIVES_KEY, "1")
+	invokerUrl := activeUrl("com.ikurento.user.ActivesPanicProvider", params)
+	invoker := &panicInvoker{BaseInvoker: protocol.NewBaseInvoker(*invokerUrl)}
+	f := GetActiveFilter()
+
+	for i := 0; i < 2; i++ {
+		assert.Panics(t, func() {
+			invokeFilter(f, invoker, invocation.NewRPCInvocation("GetUser", nil, nil))
+		})
+	}
+	// the active slot is released, so the next call isn't blocked
+	assert.Equal(t, int32(0), protocol.GetStatus(*invokerUrl, "GetUser").GetActive())
+}
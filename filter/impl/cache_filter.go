@@ -0,0 +1,210 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package impl
+
+import (
+	"reflect"
+	"strings"
+	"sync"
+)
+
+import (
+	"github.com/apache/dubbo-go/common"
+	"github.com/apache/dubbo-go/common/constant"
+	"github.com/apache/dubbo-go/common/extension"
+	"github.com/apache/dubbo-go/filter"
+	"github.com/apache/dubbo-go/protocol"
+)
+
+const CACHE = "cache"
+
+// the caches of the references by their service keys, they're shared by the filter chains of all the providers
+// of a reference, so the write served by one provider invalidates the reads cached from the others
+var referenceCaches sync.Map // service key -> *referenceCache
+
+func init() {
+	extension.SetFilter(CACHE, GetCacheFilter)
+}
+
+// CacheFilter caches the successful results of the methods with cache on the consumer, keyed by the arguments,
+// so the repeated identical calls are served by the cache rather than the providers. The cache of a method is
+// created by the cache extension of its type. The write methods invalidate the caches of the read methods in their
+// cache.invalidate, so the reads after the mutations are fetched again.
+type CacheFilter struct{}
+
+func (f *CacheFilter) Invoke(invoker protocol.Invoker, invocation protocol.Invocation) protocol.Result {
+	url := invoker.GetUrl()
+	methodName := invocation.MethodName()
+	caches := getReferenceCache(url)
+	if invalidated := url.GetMethodParam(methodName, constant.CACHE_INVALIDATE_KEY, ""); invalidated != "" {
+		// the write may take effect even if it fails, e.g. timeout
+		defer caches.invalidate(strings.Split(invalidated, ","))
+	}
+	cacheType := url.GetMethodParam(methodName, constant.CACHE_KEY, url.GetParam(constant.CACHE_KEY, ""))
+	if cacheType == "" || cacheType == "false" {
+		return invoker.Invoke(invocation)
+	}
+	if cacheType == "true" {
+		cacheType = constant.DEFAULT_CACHE
+	}
+
+	cache := caches.cache(cacheType, url, methodName)
+	key := argumentsCacheKey(invocation.Arguments())
+	if value, ok := cache.Get(key); ok {
+		// the cached value is shared, so the caller gets its copy, and the result and its attachments aren't shared
+		return &protocol.RPCResult{Rest: cachedReply(invocation, value)}
+	}
+	result := invoker.Invoke(invocation)
+	if result.Error() == nil && result.Result() != nil {
+		// the reply is owned by the caller, it may be modified after the call
+		cache.Put(key, deepCopy(result.Result()))
+	}
+	return result
+}
+
+func (f *CacheFilter) OnResponse(result protocol.Result, invoker protocol.Invoker, invocation protocol.Invocation) protocol.Result {
+	return result
+}
+
+func GetCacheFilter() filter.Filter {
+	return &CacheFilter{}
+}
+
+// referenceCache holds the caches of the methods of a reference
+type referenceCache struct {
+	methods sync.Map // method -> filter.Cache
+}
+
+func getReferenceCache(url common.URL) *referenceCache {
+	if caches, ok := referenceCaches.Load(url.ServiceKey()); ok {
+		return caches.(*referenceCache)
+	}
+	caches, _ := referenceCaches.LoadOrStore(url.ServiceKey(), &referenceCache{})
+	return caches.(*referenceCache)
+}
+
+// cache returns the cache of the method, it's created by the cache extension of @cacheType once
+func (c *referenceCache) cache(cacheType string, url common.URL, methodName string) filter.Cache {
+	if cache, ok := c.methods.Load(methodName); ok {
+		return cache.(filter.Cache)
+	}
+	cache, _ := c.methods.LoadOrStore(methodName, extension.GetCache(cacheType, url, methodName))
+	return cache.(filter.Cache)
+}
+
+// invalidate discards the caches of the @methodNames, they're created again by the next calls
+func (c *referenceCache) invalidate(methodNames []string) {
+	for _, methodName := range methodNames {
+		if methodName = strings.TrimSpace(methodName); methodName != "" {
+			c.methods.Delete(methodName)
+		}
+	}
+}
+
+// cachedReply copies the cached @value into the reply of the @invocation, the reply is returned as the result like the
+// invokers do. The copy of the @value is returned if it doesn't fit the reply.
+func cachedReply(invocation protocol.Invocation, value interface{}) interface{} {
+	value = deepCopy(value)
+	reply := reflect.ValueOf(invocation.Reply())
+	if reply.Kind() != reflect.Ptr || reply.IsNil() {
+		return value
+	}
+	v := reflect.ValueOf(value)
+	switch {
+	case v.Type() == reply.Type() && !v.IsNil():
+		reply.Elem().Set(v.Elem())
+	case v.Type().AssignableTo(reply.Type().Elem()):
+		reply.Elem().Set(v)
+	default:
+		return value
+	}
+	return invocation.Reply()
+}
+
+// copiedPointer is the pointer copied already, the cyclic and shared pointers are copied once
+type copiedPointer struct {
+	typ     reflect.Type
+	pointer uintptr
+}
+
+// deepCopy copies the @value with all the values referred by its pointers, slices, maps and exported fields
+func deepCopy(value interface{}) interface{} {
+	if value == nil {
+		return nil
+	}
+	return copyValue(reflect.ValueOf(value), make(map[copiedPointer]reflect.Value)).Interface()
+}
+
+func copyValue(v reflect.Value, copied map[copiedPointer]reflect.Value) reflect.Value {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			return v
+		}
+		key := copiedPointer{typ: v.Type(), pointer: v.Pointer()}
+		if c, ok := copied[key]; ok {
+			return c
+		}
+		c := reflect.New(v.Type().Elem())
+		copied[key] = c
+		c.Elem().Set(copyValue(v.Elem(), copied))
+		return c
+	case reflect.Interface:
+		if v.IsNil() {
+			return v
+		}
+		c := reflect.New(v.Type()).Elem()
+		c.Set(copyValue(v.Elem(), copied))
+		return c
+	case reflect.Struct:
+		// the unexported fields are copied shallowly
+		c := reflect.New(v.Type()).Elem()
+		c.Set(v)
+		for i := 0; i < c.NumField(); i++ {
+			if field := c.Field(i); field.CanSet() {
+				field.Set(copyValue(v.Field(i), copied))
+			}
+		}
+		return c
+	case reflect.Slice:
+		if v.IsNil() {
+			return v
+		}
+		c := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		for i := 0; i < v.Len(); i++ {
+			c.Index(i).Set(copyValue(v.Index(i), copied))
+		}
+		return c
+	case reflect.Array:
+		c := reflect.New(v.Type()).Elem()
+		for i := 0; i < v.Len(); i++ {
+			c.Index(i).Set(copyValue(v.Index(i), copied))
+		}
+		return c
+	case reflect.Map:
+		if v.IsNil() {
+			return v
+		}
+		c := reflect.MakeMapWithSize(v.Type(), v.Len())
+		for _, k := range v.MapKeys() {
+			c.SetMapIndex(k, copyValue(v.MapIndex(k), copied))
+		}
+		return c
+	default:
+		return v
+	}
+}
@@ -0,0 +1,292 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package impl
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+import (
+	perrors "github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+import (
+	"github.com/apache/dubbo-go/common"
+	"github.com/apache/dubbo-go/common/extension"
+	"github.com/apache/dubbo-go/common/proxy"
+	"github.com/apache/dubbo-go/filter"
+	"github.com/apache/dubbo-go/protocol"
+	"github.com/apache/dubbo-go/protocol/invocation"
+)
+
+// echoInvoker returns its argument as the result, or the error if the argument is "error"
+type echoInvoker struct {
+	protocol.BaseInvoker
+	calls int32
+}
+
+func (ei *echoInvoker) Invoke(invocation protocol.Invocation) protocol.Result {
+	atomic.AddInt32(&ei.calls, 1)
+	arg := invocation.Arguments()[0]
+	if arg == "error" {
+		return &protocol.RPCResult{Err: perrors.New("error")}
+	}
+	return &protocol.RPCResult{Rest: arg, Attrs: map[string]string{"calls": "1"}}
+}
+
+// cacheInvoker is the provider of the reference of the @params, the group in them isolates the caches of the tests
+func cacheInvoker(t *testing.T, params string) *echoInvoker {
+	return cacheInvokerAt(t, "192.168.1.1:20000", params)
+}
+
+func cacheInvokerAt(t *testing.T, address string, params string) *echoInvoker {
+	url, err := common.NewURL(context.TODO(), "dubbo://"+address+"/com.ikurento.user.UserProvider?"+params)
+	assert.NoError(t, err)
+	return &echoInvoker{BaseInvoker: *protocol.NewBaseInvoker(url)}
+}
+
+func TestCacheFilter_HitAndMiss(t *testing.T) {
+	f := GetCacheFilter()
+	invoker := cacheInvoker(t, "group=hit&cache=lru")
+
+	result := f.Invoke(invoker, invocation.NewRPCInvocation("GetUser", []interface{}{"1"}, nil))
+	assert.Equal(t, "1", result.Result())
+	result = f.Invoke(invoker, invocation.NewRPCInvocation("GetUser", []interface{}{"1"}, nil))
+	assert.Equal(t, "1", result.Result())
+	assert.Equal(t, int32(1), invoker.calls)
+
+	// the cached result doesn't share the attachments
+	assert.Empty(t, result.Attachments())
+
+	// the other arguments and methods miss
+	f.Invoke(invoker, invocation.NewRPCInvocation("GetUser", []interface{}{"2"}, nil))
+	f.Invoke(invoker, invocation.NewRPCInvocation("GetUser0", []interface{}{"1"}, nil))
+	assert.Equal(t, int32(3), invoker.calls)
+}
+
+func TestCacheFilter_Disabled(t *testing.T) {
+	f := GetCacheFilter()
+	invoker := cacheInvoker(t, "group=disabled&methods.GetUser.cache=true")
+
+	f.Invoke(invoker, invocation.NewRPCInvocation("GetUser", []interface{}{"1"}, nil))
+	f.Invoke(invoker, invocation.NewRPCInvocation("GetUser", []interface{}{"1"}, nil))
+	assert.Equal(t, int32(1), invoker.calls)
+
+	// the method without cache isn't cached
+	f.Invoke(invoker, invocation.NewRPCInvocation("GetUser0", []interface{}{"1"}, nil))
+	f.Invoke(invoker, invocation.NewRPCInvocation("GetUser0", []interface{}{"1"}, nil))
+	assert.Equal(t, int32(3), invoker.calls)
+}
+
+func TestCacheFilter_Error(t *testing.T) {
+	f := GetCacheFilter()
+	invoker := cacheInvoker(t, "group=error&cache=true")
+
+	// the errors pass through rather than being cached
+	for i := 0; i < 2; i++ {
+		result := f.Invoke(invoker, invocation.NewRPCInvocation("GetUser", []interface{}{"error"}, nil))
+		assert.EqualError(t, result.Error(), "error")
+	}
+	assert.Equal(t, int32(2), invoker.calls)
+}
+
+type mapCache struct {
+	sync.Map
+}
+
+func (c *mapCache) Get(key string) (interface{}, bool) {
+	return c.Load(key)
+}
+
+func (c *mapCache) Put(key string, value interface{}) {
+	c.Store(key, value)
+}
+
+func TestCacheFilter_Extension(t *testing.T) {
+	cache := &mapCache{}
+	extension.SetCache("map", func(url common.URL, methodName string) filter.Cache {
+		return cache
+	})
+	f := GetCacheFilter()
+	invoker := cacheInvoker(t, "group=extension&cache=map")
+
+	f.Invoke(invoker, invocation.NewRPCInvocation("GetUser", []interface{}{"1"}, nil))
+	_, ok := cache.Load(argumentsCacheKey([]interface{}{"1"}))
+	assert.True(t, ok)
+}
+
+func TestCacheFilter_Invalidate(t *testing.T) {
+	f := GetCacheFilter()
+	invoker := cacheInvoker(t, "group=invalidate&cache=true&methods.UpdateUser.cache.invalidate=GetUser, ListUsers")
+
+	f.Invoke(invoker, invocation.NewRPCInvocation("GetUser", []interface{}{"1"}, nil))
+	f.Invoke(invoker, invocation.NewRPCInvocation("ListUsers", []interface{}{"1"}, nil))
+	f.Invoke(invoker, invocation.NewRPCInvocation("GetUser0", []interface{}{"1"}, nil))
+	result := f.Invoke(invoker, invocation.NewRPCInvocation("GetUser", []interface{}{"1"}, nil))
+	assert.Equal(t, "1", result.Result())
+	assert.Equal(t, int32(3), invoker.calls)
+
+	// the write invalidates the reads even if it fails
+	f.Invoke(invoker, invocation.NewRPCInvocation("UpdateUser", []interface{}{"error"}, nil))
+	assert.Equal(t, int32(4), invoker.calls)
+
+	// the next reads are fetched again, and cached again
+	f.Invoke(invoker, invocation.NewRPCInvocation("GetUser", []interface{}{"1"}, nil))
+	f.Invoke(invoker, invocation.NewRPCInvocation("ListUsers", []interface{}{"1"}, nil))
+	f.Invoke(invoker, invocation.NewRPCInvocation("GetUser", []interface{}{"1"}, nil))
+	assert.Equal(t, int32(6), invoker.calls)
+
+	// the reads out of the mapping are kept
+	f.Invoke(invoker, invocation.NewRPCInvocation("GetUser0", []interface{}{"1"}, nil))
+	assert.Equal(t, int32(6), invoker.calls)
+}
+
+func TestCacheFilter_InvalidateAcrossProviders(t *testing.T) {
+	// every provider has its own filter chain
+	f1, f2 := GetCacheFilter(), GetCacheFilter()
+	params := "group=providers&cache=true&methods.UpdateUser.cache.invalidate=GetUser"
+	invoker1, invoker2 := cacheInvokerAt(t, "192.168.1.1:20000", params), cacheInvokerAt(t, "192.168.1.2:20000", params)
+
+	// the read is cached by the reference, whichever provider serves it
+	f1.Invoke(invoker1, invocation.NewRPCInvocation("GetUser", []interface{}{"1"}, nil))
+	f2.Invoke(invoker2, invocation.NewRPCInvocation("GetUser", []interface{}{"1"}, nil))
+	assert.Equal(t, int32(1), invoker1.calls)
+	assert.Equal(t, int32(0), invoker2.calls)
+
+	// the write served by the other provider invalidates it
+	f2.Invoke(invoker2, invocation.NewRPCInvocation("UpdateUser", []interface{}{"1"}, nil))
+	f1.Invoke(invoker1, invocation.NewRPCInvocation("GetUser", []interface{}{"1"}, nil))
+	assert.Equal(t, int32(2), invoker1.calls)
+
+	// the references of the other services aren't affected
+	other := cacheInvoker(t, "group=providers0&cache=true")
+	f1.Invoke(other, invocation.NewRPCInvocation("GetUser", []interface{}{"1"}, nil))
+	assert.Equal(t, int32(1), other.calls)
+}
+
+func TestCacheFilter_Concurrent(t *testing.T) {
+	f := GetCacheFilter()
+	invoker := cacheInvoker(t, "group=concurrent&cache=lru&cache.size=10")
+
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				arg := string(rune('a' + (i+j)%10))
+				result := f.Invoke(invoker, invocation.NewRPCInvocation("GetUser", []interface{}{arg}, nil))
+				assert.Equal(t, arg, result.Result())
+			}
+		}(i)
+	}
+	wg.Wait()
+	assert.True(t, atomic.LoadInt32(&invoker.calls) < 100*100)
+}
+
+type cacheUser struct {
+	Name   string
+	Emails []string
+}
+
+// userInvoker fills the reply of the invocation like the dubbo invoker
+type userInvoker struct {
+	echoInvoker
+}
+
+func (ui *userInvoker) Invoke(invocation protocol.Invocation) protocol.Result {
+	atomic.AddInt32(&ui.calls, 1)
+	reply := invocation.Reply().(*cacheUser)
+	reply.Name = invocation.Arguments()[0].(string)
+	reply.Emails = []string{reply.Name + "@dubbo.io"}
+	return &protocol.RPCResult{Rest: reply}
+}
+
+// filterInvoker invokes the next invoker through the filter
+type filterInvoker struct {
+	protocol.Invoker
+	filter filter.Filter
+}
+
+func (fi *filterInvoker) Invoke(invocation protocol.Invocation) protocol.Result {
+	return fi.filter.Invoke(fi.Invoker, invocation)
+}
+
+type cacheUserConsumer struct {
+	GetUser func(ctx context.Context, name string) (*cacheUser, error)
+}
+
+func (c *cacheUserConsumer) Reference() string {
+	return "UserProvider"
+}
+
+func TestCacheFilter_Reply(t *testing.T) {
+	invoker := &userInvoker{echoInvoker: *cacheInvoker(t, "group=reply&cache=lru")}
+	consumer := &cacheUserConsumer{}
+	proxy.NewProxy(&filterInvoker{Invoker: invoker, filter: GetCacheFilter()}, nil, nil).Implement(consumer)
+
+	user, err := consumer.GetUser(context.TODO(), "dubbo")
+	assert.NoError(t, err)
+	assert.Equal(t, &cacheUser{Name: "dubbo", Emails: []string{"dubbo@dubbo.io"}}, user)
+
+	// the caller gets the cached value in its reply
+	cached, err := consumer.GetUser(context.TODO(), "dubbo")
+	assert.NoError(t, err)
+	assert.Equal(t, int32(1), invoker.calls)
+	assert.Equal(t, user, cached)
+
+	// the replies modified by the callers don't change the cache
+	user.Name = "user"
+	user.Emails[0] = "user@dubbo.io"
+	cached.Emails = append(cached.Emails, "cached@dubbo.io")
+	cached, err = consumer.GetUser(context.TODO(), "dubbo")
+	assert.NoError(t, err)
+	assert.Equal(t, int32(1), invoker.calls)
+	assert.Equal(t, &cacheUser{Name: "dubbo", Emails: []string{"dubbo@dubbo.io"}}, cached)
+}
+
+func TestDeepCopy(t *testing.T) {
+	type node struct {
+		Values map[string][]int
+		Next   *node
+		Any    interface{}
+		hidden *int
+	}
+	hidden := 1
+	n := &node{Values: map[string][]int{"a": {1, 2}}, Any: []string{"x"}, hidden: &hidden}
+	n.Next = n
+
+	c := deepCopy(n).(*node)
+	assert.Equal(t, []int{1, 2}, c.Values["a"])
+	assert.Equal(t, []string{"x"}, c.Any)
+	// the cycles are kept rather than copied endlessly
+	assert.True(t, c.Next == c)
+	assert.True(t, c != n)
+	// the unexported fields are shared
+	assert.True(t, c.hidden == n.hidden)
+
+	c.Values["a"][0] = 3
+	c.Any.([]string)[0] = "y"
+	assert.Equal(t, []int{1, 2}, n.Values["a"])
+	assert.Equal(t, []string{"x"}, n.Any)
+	assert.Nil(t, deepCopy(nil))
+	assert.Equal(t, "1", deepCopy("1"))
+}
@@ -0,0 +1,148 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package impl
+
+import (
+	"sync"
+	"time"
+)
+
+import (
+	perrors "github.com/pkg/errors"
+	"go.uber.org/atomic"
+)
+
+import (
+	"github.com/apache/dubbo-go/common/constant"
+	"github.com/apache/dubbo-go/common/extension"
+	"github.com/apache/dubbo-go/common/logger"
+	"github.com/apache/dubbo-go/filter"
+	"github.com/apache/dubbo-go/protocol"
+)
+
+const CONSUMER_TPS_LIMIT = "consumer_tps_limit"
+
+func init() {
+	extension.SetFilter(CONSUMER_TPS_LIMIT, GetConsumerTpsLimitFilter)
+}
+
+// ConsumerTpsLimitFilter limits the requests of every consumer to the methods of provider,
+// so one noisy consumer can not starve the others. The consumer is identified by its application name,
+// or its ip if the application is unknown.
+// eg:
+//
+//	consumer.tps.limit.rate: 100             // the default limit of every consumer in one interval
+//	consumer.tps.limit.rate.app-a: 1000      // the limit of the consumer application app-a
+//	consumer.tps.limit.rate.192.168.1.1: 10  // the limit of the consumer 192.168.1.1
+//	consumer.tps.limit.interval: 60000       // in milliseconds
+//
+// the windows expired or of the destroyed invokers are swept at most once an interval,
+// so the consumers gone away don't pile up.
+type ConsumerTpsLimitFilter struct {
+	windows   sync.Map // consumerWindowKey -> *tpsWindow
+	lastSweep atomic.Int64
+}
+
+type consumerWindowKey struct {
+	invoker  protocol.Invoker
+	method   string
+	consumer string
+}
+
+func (f *ConsumerTpsLimitFilter) Invoke(invoker protocol.Invoker, invocation protocol.Invocation) protocol.Result {
+	url := invoker.GetUrl()
+	consumer := invocation.AttachmentsByKey(constant.APPLICATION_KEY, "")
+	if consumer == "" {
+		consumer = invocation.AttachmentsByKey(constant.REMOTE_IP_KEY, "")
+	}
+
+	rate := url.GetParamInt(constant.CONSUMER_TPS_LIMIT_RATE_KEY+"."+consumer, 0)
+	if consumer == "" || rate == 0 {
+		rate = url.GetParamInt(constant.CONSUMER_TPS_LIMIT_RATE_KEY, constant.DEFAULT_CONSUMER_TPS_LIMIT_RATE)
+	}
+	if rate < 0 {
+		return invoker.Invoke(invocation)
+	}
+	interval := url.GetParamInt(constant.CONSUMER_TPS_LIMIT_INTERVAL_KEY, constant.DEFAULT_CONSUMER_TPS_LIMIT_INTERVAL)
+
+	now := time.Now()
+	f.sweep(now, time.Duration(interval)*time.Millisecond)
+	key := consumerWindowKey{invoker: invoker, method: invocation.MethodName(), consumer: consumer}
+	window, _ := f.windows.LoadOrStore(key, &tpsWindow{})
+	if !window.(*tpsWindow).allow(rate, time.Duration(interval)*time.Millisecond) {
+		logger.Warnf("the consumer %v invokes the method %v of service %v over the limit %v in %vms.",
+			consumer, invocation.MethodName(), url.ServiceKey(), rate, interval)
+		return &protocol.RPCResult{Err: perrors.Errorf("the consumer %v invokes the method %v of service %v over the limit %v in %vms",
+			consumer, invocation.MethodName(), url.ServiceKey(), rate, interval)}
+	}
+	return invoker.Invoke(invocation)
+}
+
+// sweep deletes the expired windows, which would be reset by the next request anyway,
+// and the windows of the destroyed invokers
+func (f *ConsumerTpsLimitFilter) sweep(now time.Time, interval time.Duration) {
+	last := f.lastSweep.Load()
+	if now.UnixNano()-last < int64(interval) || !f.lastSweep.CAS(last, now.UnixNano()) {
+		return
+	}
+	f.windows.Range(func(key, value interface{}) bool {
+		if !key.(consumerWindowKey).invoker.IsAvailable() || value.(*tpsWindow).expired(now) {
+			f.windows.Delete(key)
+		}
+		return true
+	})
+}
+
+func (f *ConsumerTpsLimitFilter) OnResponse(result protocol.Result, invoker protocol.Invoker, invocation protocol.Invocation) protocol.Result {
+	return result
+}
+
+func GetConsumerTpsLimitFilter() filter.Filter {
+	return &ConsumerTpsLimitFilter{}
+}
+
+// tpsWindow counts the requests in a fixed time window
+type tpsWindow struct {
+	lock     sync.Mutex
+	start    time.Time
+	interval time.Duration
+	count    int64
+}
+
+func (w *tpsWindow) allow(rate int64, interval time.Duration) bool {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	now := time.Now()
+	w.interval = interval
+	if now.Sub(w.start) >= interval {
+		w.start = now
+		w.count = 0
+	}
+	if w.count >= rate {
+		return false
+	}
+	w.count++
+	return true
+}
+
+func (w *tpsWindow) expired(now time.Time) bool {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	return now.Sub(w.start) >= w.interval
+}
@@ -0,0 +1,105 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package impl
+
+import (
+	"net/url"
+	"testing"
+	"time"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+import (
+	"github.com/apache/dubbo-go/common"
+	"github.com/apache/dubbo-go/common/constant"
+	"github.com/apache/dubbo-go/protocol"
+	"github.com/apache/dubbo-go/protocol/invocation"
+)
+
+func TestConsumerTpsLimitFilter_Invoke(t *testing.T) {
+	params := url.Values{}
+	params.Set(constant.CONSUMER_TPS_LIMIT_RATE_KEY, "2")
+	params.Set(constant.CONSUMER_TPS_LIMIT_RATE_KEY+".app-b", "5")
+	params.Set(constant.CONSUMER_TPS_LIMIT_INTERVAL_KEY, "60000")
+	invoker := protocol.NewBaseInvoker(*common.NewURLWithOptions(common.WithPath("UserProvider"), common.WithParams(params)))
+
+	filter := GetConsumerTpsLimitFilter()
+	invokeBy := func(attachments map[string]string) protocol.Result {
+		return filter.Invoke(invoker, invocation.NewRPCInvocation("GetUser", nil, attachments))
+	}
+
+	// app-a is limited by the default rate
+	for i := 0; i < 2; i++ {
+		assert.NoError(t, invokeBy(map[string]string{constant.APPLICATION_KEY: "app-a"}).Error())
+	}
+	assert.Error(t, invokeBy(map[string]string{constant.APPLICATION_KEY: "app-a"}).Error())
+
+	// app-b has its own limit, and isn't affected by app-a
+	for i := 0; i < 5; i++ {
+		assert.NoError(t, invokeBy(map[string]string{constant.APPLICATION_KEY: "app-b"}).Error())
+	}
+	assert.Error(t, invokeBy(map[string]string{constant.APPLICATION_KEY: "app-b"}).Error())
+
+	// the consumer is identified by its ip without application
+	for i := 0; i < 2; i++ {
+		assert.NoError(t, invokeBy(map[string]string{constant.REMOTE_IP_KEY: "192.168.1.1"}).Error())
+	}
+	assert.Error(t, invokeBy(map[string]string{constant.REMOTE_IP_KEY: "192.168.1.1"}).Error())
+	assert.NoError(t, invokeBy(map[string]string{constant.REMOTE_IP_KEY: "192.168.1.2"}).Error())
+}
+
+func TestConsumerTpsLimitFilter_NoLimit(t *testing.T) {
+	invoker := protocol.NewBaseInvoker(*common.NewURLWithOptions(common.WithPath("UserProvider"), common.WithParams(url.Values{})))
+	filter := GetConsumerTpsLimitFilter()
+	for i := 0; i < 100; i++ {
+		result := filter.Invoke(invoker, invocation.NewRPCInvocation("GetUser", nil, map[string]string{constant.APPLICATION_KEY: "app-a"}))
+		assert.NoError(t, result.Error())
+	}
+}
+
+func TestConsumerTpsLimitFilter_Sweep(t *testing.T) {
+	params := url.Values{}
+	params.Set(constant.CONSUMER_TPS_LIMIT_RATE_KEY, "1")
+	params.Set(constant.CONSUMER_TPS_LIMIT_INTERVAL_KEY, "50")
+	invoker := protocol.NewBaseInvoker(*common.NewURLWithOptions(common.WithPath("UserProvider"), common.WithParams(params)))
+	destroyed := protocol.NewBaseInvoker(*common.NewURLWithOptions(common.WithPath("OrderProvider"), common.WithParams(params)))
+
+	filter := GetConsumerTpsLimitFilter().(*ConsumerTpsLimitFilter)
+	windows := func() int {
+		n := 0
+		filter.windows.Range(func(key, value interface{}) bool {
+			n++
+			return true
+		})
+		return n
+	}
+	for _, consumer := range []string{"app-a", "app-b", "app-c"} {
+		assert.NoError(t, filter.Invoke(invoker, invocation.NewRPCInvocation("GetUser", nil, map[string]string{constant.APPLICATION_KEY: consumer})).Error())
+	}
+	assert.NoError(t, filter.Invoke(destroyed, invocation.NewRPCInvocation("GetOrder", nil, map[string]string{constant.APPLICATION_KEY: "app-a"})).Error())
+	destroyed.Destroy()
+	assert.Equal(t, 4, windows())
+
+	// the windows of the gone consumers and the destroyed invoker are swept by the next request after the interval
+	time.Sleep(60 * time.Millisecond)
+	assert.NoError(t, filter.Invoke(invoker, invocation.NewRPCInvocation("GetUser", nil, map[string]string{constant.APPLICATION_KEY: "app-a"})).Error())
+	assert.Equal(t, 1, windows())
+}
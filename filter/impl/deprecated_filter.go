@@ -0,0 +1,117 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package impl
+
+import (
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+)
+
+import (
+	"github.com/apache/dubbo-go/common/constant"
+	"github.com/apache/dubbo-go/common/extension"
+	"github.com/apache/dubbo-go/common/logger"
+	"github.com/apache/dubbo-go/filter"
+	"github.com/apache/dubbo-go/protocol"
+)
+
+const (
+	DEPRECATED         = "deprecated"
+	DEPRECATED_WARNING = "deprecated_warning"
+)
+
+// replaced by the tests
+var deprecationWarnf = logger.Warnf
+
+func init() {
+	extension.SetFilter(DEPRECATED, GetDeprecatedFilter)
+	extension.SetFilter(DEPRECATED_WARNING, GetDeprecatedWarningFilter)
+}
+
+// DeprecatedFilter is the provider filter which adds the deprecated attachment to the results of the deprecated methods,
+// the calls still succeed. eg:
+//
+//	methods.GetUser.deprecated: true
+//	methods.GetUser0.deprecated: use GetUser1 instead  // the message is sent to the consumers
+type DeprecatedFilter struct{}
+
+func (ef *DeprecatedFilter) Invoke(invoker protocol.Invoker, invocation protocol.Invocation) protocol.Result {
+	return invoker.Invoke(invocation)
+}
+
+func (ef *DeprecatedFilter) OnResponse(result protocol.Result, invoker protocol.Invoker, invocation protocol.Invocation) protocol.Result {
+	url := invoker.GetUrl()
+	methodName := invocation.MethodName()
+	deprecated := url.GetMethodParam(methodName, constant.DEPRECATED_KEY, url.GetParam(constant.DEPRECATED_KEY, ""))
+	if deprecated == "" {
+		return result
+	}
+	if b, err := strconv.ParseBool(deprecated); err == nil {
+		if !b {
+			return result
+		}
+		deprecated = fmt.Sprintf("the method %v of the service %v is deprecated", methodName, url.Service())
+	}
+	result.AddAttachment(constant.DEPRECATED_KEY, deprecated)
+	return result
+}
+
+func GetDeprecatedFilter() filter.Filter {
+	return &DeprecatedFilter{}
+}
+
+// DeprecatedWarningFilter is the consumer filter which logs the deprecated attachment of the results,
+// at most once in deprecated.log.interval milliseconds for every method.
+type DeprecatedWarningFilter struct {
+	lock       sync.Mutex
+	lastWarned map[string]time.Time // service key + method -> the last warning time
+}
+
+func (ef *DeprecatedWarningFilter) Invoke(invoker protocol.Invoker, invocation protocol.Invocation) protocol.Result {
+	return invoker.Invoke(invocation)
+}
+
+func (ef *DeprecatedWarningFilter) OnResponse(result protocol.Result, invoker protocol.Invoker, invocation protocol.Invocation) protocol.Result {
+	deprecated := result.Attachment(constant.DEPRECATED_KEY, "")
+	if deprecated == "" {
+		return result
+	}
+
+	url := invoker.GetUrl()
+	interval := time.Duration(url.GetParamInt(constant.DEPRECATED_LOG_INTERVAL_KEY, constant.DEFAULT_DEPRECATED_LOG_INTERVAL)) * time.Millisecond
+	key := url.ServiceKey() + "." + invocation.MethodName()
+	now := time.Now()
+
+	ef.lock.Lock()
+	last, ok := ef.lastWarned[key]
+	if ok && now.Sub(last) < interval {
+		ef.lock.Unlock()
+		return result
+	}
+	ef.lastWarned[key] = now
+	ef.lock.Unlock()
+
+	deprecationWarnf("the provider %v warns that: %v", url.Location, deprecated)
+	return result
+}
+
+func GetDeprecatedWarningFilter() filter.Filter {
+	return &DeprecatedWarningFilter{lastWarned: make(map[string]time.Time)}
+}
@@ -0,0 +1,89 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package impl
+
+import (
+	"net/url"
+	"testing"
+	"time"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+import (
+	"github.com/apache/dubbo-go/common"
+	"github.com/apache/dubbo-go/common/constant"
+	"github.com/apache/dubbo-go/common/logger"
+	"github.com/apache/dubbo-go/protocol"
+	"github.com/apache/dubbo-go/protocol/invocation"
+)
+
+func TestDeprecatedFilter_OnResponse(t *testing.T) {
+	params := url.Values{}
+	params.Set("methods.GetUser."+constant.DEPRECATED_KEY, "true")
+	params.Set("methods.GetUser0."+constant.DEPRECATED_KEY, "use GetUser1 instead")
+	invoker := protocol.NewBaseInvoker(*common.NewURLWithOptions(common.WithPath("UserProvider"), common.WithParams(params)))
+	filter := GetDeprecatedFilter()
+
+	for method, message := range map[string]string{
+		"GetUser":  "the method GetUser of the service UserProvider is deprecated",
+		"GetUser0": "use GetUser1 instead",
+		"GetUser1": "",
+	} {
+		inv := invocation.NewRPCInvocation(method, nil, nil)
+		result := filter.OnResponse(&protocol.RPCResult{Rest: "ok"}, invoker, inv)
+		// the call still succeeds
+		assert.NoError(t, result.Error())
+		assert.Equal(t, "ok", result.Result())
+		assert.Equal(t, message, result.Attachment(constant.DEPRECATED_KEY, ""), method)
+	}
+}
+
+func TestDeprecatedWarningFilter_OnResponse(t *testing.T) {
+	var warnings []string
+	deprecationWarnf = func(format string, args ...interface{}) {
+		warnings = append(warnings, args[1].(string))
+	}
+	defer func() {
+		deprecationWarnf = logger.Warnf
+	}()
+
+	params := url.Values{}
+	params.Set(constant.DEPRECATED_LOG_INTERVAL_KEY, "200")
+	invoker := protocol.NewBaseInvoker(*common.NewURLWithOptions(common.WithPath("UserProvider"), common.WithParams(params)))
+	filter := GetDeprecatedWarningFilter()
+	deprecatedResult := func() protocol.Result {
+		return &protocol.RPCResult{Rest: "ok", Attrs: map[string]string{constant.DEPRECATED_KEY: "use GetUser1 instead"}}
+	}
+
+	for i := 0; i < 10; i++ {
+		result := filter.OnResponse(deprecatedResult(), invoker, invocation.NewRPCInvocation("GetUser", nil, nil))
+		assert.NoError(t, result.Error())
+		assert.Equal(t, "ok", result.Result())
+	}
+	// the result without deprecation is not logged
+	filter.OnResponse(&protocol.RPCResult{Rest: "ok"}, invoker, invocation.NewRPCInvocation("GetUser1", nil, nil))
+	assert.Equal(t, []string{"use GetUser1 instead"}, warnings)
+
+	// logged again after the interval
+	time.Sleep(250 * time.Millisecond)
+	filter.OnResponse(deprecatedResult(), invoker, invocation.NewRPCInvocation("GetUser", nil, nil))
+	assert.Len(t, warnings, 2)
+}
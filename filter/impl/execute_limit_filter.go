@@ -0,0 +1,161 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package impl
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+)
+
+import (
+	perrors "github.com/pkg/errors"
+)
+
+import (
+	"github.com/apache/dubbo-go/common"
+	"github.com/apache/dubbo-go/common/config"
+	"github.com/apache/dubbo-go/common/constant"
+	"github.com/apache/dubbo-go/common/extension"
+	"github.com/apache/dubbo-go/common/logger"
+	"github.com/apache/dubbo-go/config_center"
+	"github.com/apache/dubbo-go/filter"
+	"github.com/apache/dubbo-go/protocol"
+	"github.com/apache/dubbo-go/remoting"
+)
+
+const EXECUTE_LIMIT = "execute"
+
+func init() {
+	extension.SetFilter(EXECUTE_LIMIT, GetExecuteLimitFilter)
+}
+
+// ExecuteLimitFilter limits the calls of every method executing at the same time on the provider.
+// The executing calls of a method are exposed by protocol.GetStatus(url, method).GetActive().
+// The limit is configured by the url, and can be adjusted at runtime by the config center, e.g.
+// the key com.ikurento.user.UserProvider.execute.limit in the group dubbo with the properties:
+//
+//	GetUser=10
+//	GetUser0=20
+//
+// the limits pushed by the config center take the place of the ones of the url, until they are deleted.
+type ExecuteLimitFilter struct {
+	subscribed sync.Map // service key -> struct{}
+
+	lock   sync.RWMutex
+	limits map[string]map[string]int64 // service key -> method -> dynamic limit
+}
+
+func (f *ExecuteLimitFilter) Invoke(invoker protocol.Invoker, invocation protocol.Invocation) protocol.Result {
+	url := invoker.GetUrl()
+	methodName := invocation.MethodName()
+	f.subscribe(url.ServiceKey())
+
+	limit := f.limit(url, methodName)
+	if limit <= 0 {
+		return invoker.Invoke(invocation)
+	}
+
+	status := protocol.GetStatus(url, methodName)
+	protocol.BeginCount(url, methodName)
+	defer protocol.EndCount(url, methodName)
+	if executing := status.GetActive(); int64(executing) > limit {
+		logger.Warnf("the method %v of service %v is executing %v calls, over the limit %v.",
+			methodName, url.ServiceKey(), executing-1, limit)
+		return &protocol.RPCResult{Err: perrors.Errorf("the method %v of service %v is executing %v calls, over the limit %v",
+			methodName, url.ServiceKey(), executing-1, limit)}
+	}
+	return invoker.Invoke(invocation)
+}
+
+func (f *ExecuteLimitFilter) OnResponse(result protocol.Result, invoker protocol.Invoker, invocation protocol.Invocation) protocol.Result {
+	return result
+}
+
+// limit returns the dynamic limit of the method if there is, or the one of the url
+func (f *ExecuteLimitFilter) limit(url common.URL, methodName string) int64 {
+	f.lock.RLock()
+	limit, ok := f.limits[url.ServiceKey()][methodName]
+	f.lock.RUnlock()
+	if ok {
+		return limit
+	}
+	return url.GetMethodParamInt64(methodName, constant.EXECUTE_LIMIT_KEY, url.GetMethodParamInt64(methodName, constant.EXECUTES_KEY, 0))
+}
+
+// subscribe the dynamic limits of the service from the config center once
+func (f *ExecuteLimitFilter) subscribe(serviceKey string) {
+	if _, loaded := f.subscribed.LoadOrStore(serviceKey, struct{}{}); loaded {
+		return
+	}
+	dynamicConfig := config.GetEnvInstance().GetDynamicConfiguration()
+	if dynamicConfig == nil {
+		return
+	}
+
+	key := serviceKey + constant.EXECUTE_LIMIT_SUFFIX
+	dynamicConfig.AddListener(key, f, config_center.WithGroup(config_center.DEFAULT_GROUP))
+	content, err := dynamicConfig.GetConfig(key, config_center.WithGroup(config_center.DEFAULT_GROUP))
+	if err != nil {
+		logger.Debugf("Get execute limits {%s} error, error message is %v", key, err)
+		return
+	}
+	if content != "" {
+		f.Process(&remoting.ConfigChangeEvent{Key: key, Value: content, ConfigType: remoting.EventTypeAdd})
+	}
+}
+
+// Process applies the execute limits pushed by the config center
+func (f *ExecuteLimitFilter) Process(event *remoting.ConfigChangeEvent) {
+	logger.Infof("execute limits changed, event: %v", event)
+	serviceKey := strings.TrimSuffix(event.Key, constant.EXECUTE_LIMIT_SUFFIX)
+
+	var limits map[string]int64
+	if event.ConfigType != remoting.EventTypeDel {
+		content, _ := event.Value.(string)
+		properties, err := (&config_center.DefaultConfigurationParser{}).Parse(content)
+		if err != nil {
+			logger.Errorf("Parse execute limits error, the limits are ignored, error message is %v", err)
+			return
+		}
+		limits = make(map[string]int64, len(properties))
+		for method, v := range properties {
+			limit, err := strconv.ParseInt(v, 10, 64)
+			if err != nil {
+				logger.Errorf("the execute limit %v of the method %v is not a number, it's ignored", v, method)
+				continue
+			}
+			limits[method] = limit
+		}
+	}
+
+	f.lock.Lock()
+	if f.limits == nil {
+		f.limits = make(map[string]map[string]int64)
+	}
+	if limits == nil {
+		delete(f.limits, serviceKey)
+	} else {
+		f.limits[serviceKey] = limits
+	}
+	f.lock.Unlock()
+}
+
+func GetExecuteLimitFilter() filter.Filter {
+	return &ExecuteLimitFilter{}
+}
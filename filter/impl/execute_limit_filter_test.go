@@ -0,0 +1,139 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package impl
+
+import (
+	"net/url"
+	"testing"
+	"time"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+import (
+	"github.com/apache/dubbo-go/common"
+	"github.com/apache/dubbo-go/common/constant"
+	"github.com/apache/dubbo-go/protocol"
+	"github.com/apache/dubbo-go/protocol/invocation"
+	"github.com/apache/dubbo-go/remoting"
+)
+
+// blockingInvoker blocks the calls until released
+type blockingInvoker struct {
+	*protocol.BaseInvoker
+	release chan struct{}
+}
+
+func (ivk *blockingInvoker) Invoke(invocation protocol.Invocation) protocol.Result {
+	<-ivk.release
+	return &protocol.RPCResult{}
+}
+
+func TestExecuteLimitFilter_Invoke(t *testing.T) {
+	params := url.Values{}
+	params.Set(constant.INTERFACE_KEY, "com.ikurento.user.ExecuteLimitProvider")
+	params.Set("methods.GetUser."+constant.EXECUTE_LIMIT_KEY, "3")
+	invokerUrl := common.NewURLWithOptions(common.WithPath("com.ikurento.user.ExecuteLimitProvider"), common.WithParams(params))
+	invoker := &blockingInvoker{BaseInvoker: protocol.NewBaseInvoker(*invokerUrl), release: make(chan struct{})}
+	status := protocol.GetStatus(*invokerUrl, "GetUser")
+
+	f := GetExecuteLimitFilter().(*ExecuteLimitFilter)
+	results := make(chan protocol.Result, 4)
+	invoke := func() {
+		results <- f.Invoke(invoker, invocation.NewRPCInvocation("GetUser", nil, nil))
+	}
+	waitExecuting := func(n int32) {
+		for i := 0; i < 100 && status.GetActive() != n; i++ {
+			time.Sleep(10 * time.Millisecond)
+		}
+		assert.Equal(t, n, status.GetActive())
+	}
+
+	go invoke()
+	go invoke()
+	waitExecuting(2)
+
+	// a lower limit is pushed at runtime, the calls beyond it are rejected
+	key := invokerUrl.ServiceKey() + constant.EXECUTE_LIMIT_SUFFIX
+	f.Process(&remoting.ConfigChangeEvent{Key: key, Value: "GetUser=2", ConfigType: remoting.EvnetTypeUpdate})
+	invoke()
+	assert.Error(t, (<-results).Error())
+	waitExecuting(2)
+
+	// the limit of the url applies again once the dynamic one is deleted
+	f.Process(&remoting.ConfigChangeEvent{Key: key, ConfigType: remoting.EventTypeDel})
+	go invoke()
+	waitExecuting(3)
+
+	close(invoker.release)
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, (<-results).Error())
+	}
+	waitExecuting(0)
+}
+
+// panicInvoker panics on the calls
+type panicInvoker struct {
+	*protocol.BaseInvoker
+}
+
+func (ivk *panicInvoker) Invoke(invocation protocol.Invocation) protocol.Result {
+	panic("invoke panic")
+}
+
+func TestExecuteLimitFilter_Executes(t *testing.T) {
+	params := url.Values{}
+	params.Set(constant.INTERFACE_KEY, "com.ikurento.user.ExecutesProvider")
+	params.Set(constant.EXECUTES_KEY, "1")
+	invokerUrl := common.NewURLWithOptions(common.WithPath("com.ikurento.user.ExecutesProvider"), common.WithParams(params))
+	invoker := &blockingInvoker{BaseInvoker: protocol.NewBaseInvoker(*invokerUrl), release: make(chan struct{})}
+	status := protocol.GetStatus(*invokerUrl, "GetUser")
+
+	f := GetExecuteLimitFilter()
+	done := make(chan struct{})
+	go func() {
+		f.Invoke(invoker, invocation.NewRPCInvocation("GetUser", nil, nil))
+		close(done)
+	}()
+	for i := 0; i < 100 && status.GetActive() != 1; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// the call over executes is rejected at once
+	assert.Error(t, f.Invoke(invoker, invocation.NewRPCInvocation("GetUser", nil, nil)).Error())
+	close(invoker.release)
+	<-done
+	assert.Equal(t, int32(0), status.GetActive())
+}
+
+func TestExecuteLimitFilter_Panic(t *testing.T) {
+	params := url.Values{}
+	params.Set(constant.INTERFACE_KEY, "com.ikurento.user.PanicProvider")
+	params.Set(constant.EXECUTE_LIMIT_KEY, "1")
+	invokerUrl := common.NewURLWithOptions(common.WithPath("com.ikurento.user.PanicProvider"), common.WithParams(params))
+	invoker := &panicInvoker{BaseInvoker: protocol.NewBaseInvoker(*invokerUrl)}
+
+	f := GetExecuteLimitFilter()
+	assert.Panics(t, func() {
+		f.Invoke(invoker, invocation.NewRPCInvocation("GetUser", nil, nil))
+	})
+	// the executing call is released
+	assert.Equal(t, int32(0), protocol.GetStatus(*invokerUrl, "GetUser").GetActive())
+}
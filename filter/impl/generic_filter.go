@@ -17,14 +17,11 @@
 
 package impl
 
-import (
-	"reflect"
-	"strings"
-)
 import (
 	hessian "github.com/apache/dubbo-go-hessian2"
 )
 import (
+	"github.com/apache/dubbo-go/common"
 	"github.com/apache/dubbo-go/common/constant"
 	"github.com/apache/dubbo-go/common/extension"
 	"github.com/apache/dubbo-go/filter"
@@ -40,7 +37,7 @@ func init() {
 	extension.SetFilter(GENERIC, GetGenericFilter)
 }
 
-//  when do a generic invoke, struct need to be map
+//  when do a generic invoke, struct need to be map, and the invocation is marked by the generic attachment
 
 type GenericFilter struct{}
 
@@ -50,7 +47,7 @@ func (ef *GenericFilter) Invoke(invoker protocol.Invoker, invocation protocol.In
 		var newParams []hessian.Object
 		if oldParams, ok := oldArguments[2].([]interface{}); ok {
 			for i := range oldParams {
-				newParams = append(newParams, hessian.Object(struct2MapAll(oldParams[i])))
+				newParams = append(newParams, hessian.Object(common.Generalize(oldParams[i])))
 			}
 		} else {
 			return invoker.Invoke(invocation)
@@ -60,8 +57,15 @@ func (ef *GenericFilter) Invoke(invoker protocol.Invoker, invocation protocol.In
 			oldArguments[1],
 			newParams,
 		}
-		newInvocation := invocation2.NewRPCInvocation(invocation.MethodName(), newArguments, invocation.Attachments())
-		newInvocation.SetReply(invocation.Reply())
+		builder := invocation2.NewInvocationBuilder(invocation.MethodName()).Arguments(newArguments...).
+			Reply(invocation.Reply()).Attachments(invocation.Attachments()).Attachment(constant.GENERIC_KEY, "true")
+		if rpcInvocation, ok := invocation.(*invocation2.RPCInvocation); ok {
+			builder.Context(rpcInvocation.Context())
+		}
+		newInvocation, err := builder.Build()
+		if err != nil {
+			return &protocol.RPCResult{Err: err}
+		}
 		return invoker.Invoke(newInvocation)
 	}
 	return invoker.Invoke(invocation)
@@ -74,52 +78,3 @@ func (ef *GenericFilter) OnResponse(result protocol.Result, invoker protocol.Inv
 func GetGenericFilter() filter.Filter {
 	return &GenericFilter{}
 }
-func struct2MapAll(obj interface{}) interface{} {
-	if obj == nil {
-		return obj
-	}
-	t := reflect.TypeOf(obj)
-	v := reflect.ValueOf(obj)
-	if t.Kind() == reflect.Struct {
-		result := make(map[string]interface{}, t.NumField())
-		for i := 0; i < t.NumField(); i++ {
-			if v.Field(i).Kind() == reflect.Struct {
-				if v.Field(i).CanInterface() {
-					setInMap(result, t.Field(i), struct2MapAll(v.Field(i).Interface()))
-				}
-			} else if v.Field(i).Kind() == reflect.Slice {
-				if v.Field(i).CanInterface() {
-					setInMap(result, t.Field(i), struct2MapAll(v.Field(i).Interface()))
-				}
-			} else {
-				if v.Field(i).CanInterface() {
-					setInMap(result, t.Field(i), v.Field(i).Interface())
-				}
-			}
-		}
-		return result
-	} else if t.Kind() == reflect.Slice {
-		value := reflect.ValueOf(obj)
-		var newTemps = make([]interface{}, 0, value.Len())
-		for i := 0; i < value.Len(); i++ {
-			newTemp := struct2MapAll(value.Index(i).Interface())
-			newTemps = append(newTemps, newTemp)
-		}
-		return newTemps
-	} else {
-		return obj
-	}
-}
-func setInMap(m map[string]interface{}, structField reflect.StructField, value interface{}) (result map[string]interface{}) {
-	result = m
-	if tagName := structField.Tag.Get("m"); tagName == "" {
-		result[headerAtoa(structField.Name)] = value
-	} else {
-		result[tagName] = value
-	}
-	return
-}
-func headerAtoa(a string) (b string) {
-	b = strings.ToLower(a[:1]) + a[1:]
-	return
-}
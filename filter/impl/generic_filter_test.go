@@ -18,72 +18,60 @@
 package impl
 
 import (
-	"reflect"
 	"testing"
 )
+
 import (
+	hessian "github.com/apache/dubbo-go-hessian2"
 	"github.com/stretchr/testify/assert"
 )
 
-func Test_struct2MapAll(t *testing.T) {
-	var testData struct {
-		AaAa string `m:"aaAa"`
-		BaBa string
-		CaCa struct {
-			AaAa string
-			BaBa string `m:"baBa"`
-			XxYy struct {
-				xxXx string `m:"xxXx"`
-				Xx   string `m:"xx"`
-			} `m:"xxYy"`
-		} `m:"caCa"`
-	}
-	testData.AaAa = "1"
-	testData.BaBa = "1"
-	testData.CaCa.BaBa = "2"
-	testData.CaCa.AaAa = "2"
-	testData.CaCa.XxYy.xxXx = "3"
-	testData.CaCa.XxYy.Xx = "3"
-	m := struct2MapAll(testData).(map[string]interface{})
-	assert.Equal(t, "1", m["aaAa"].(string))
-	assert.Equal(t, "1", m["baBa"].(string))
-	assert.Equal(t, "2", m["caCa"].(map[string]interface{})["aaAa"].(string))
-	assert.Equal(t, "3", m["caCa"].(map[string]interface{})["xxYy"].(map[string]interface{})["xx"].(string))
+import (
+	"github.com/apache/dubbo-go/common"
+	"github.com/apache/dubbo-go/common/constant"
+	"github.com/apache/dubbo-go/protocol"
+	"github.com/apache/dubbo-go/protocol/invocation"
+)
+
+// recordInvoker records the invocation it's invoked with
+type recordInvoker struct {
+	protocol.BaseInvoker
+	invocation protocol.Invocation
+}
 
-	assert.Equal(t, reflect.Map, reflect.TypeOf(m["caCa"]).Kind())
-	assert.Equal(t, reflect.Map, reflect.TypeOf(m["caCa"].(map[string]interface{})["xxYy"]).Kind())
+func (ri *recordInvoker) Invoke(invocation protocol.Invocation) protocol.Result {
+	ri.invocation = invocation
+	return &protocol.RPCResult{}
 }
 
-type testStruct struct {
-	AaAa string
-	BaBa string `m:"baBa"`
-	XxYy struct {
-		xxXx string `m:"xxXx"`
-		Xx   string `m:"xx"`
-	} `m:"xxYy"`
+type genericUser struct {
+	Id      string
+	Friends []*genericUser
 }
 
-func Test_struct2MapAll_Slice(t *testing.T) {
-	var testData struct {
-		AaAa string `m:"aaAa"`
-		BaBa string
-		CaCa []testStruct `m:"caCa"`
-	}
-	testData.AaAa = "1"
-	testData.BaBa = "1"
-	var tmp testStruct
-	tmp.BaBa = "2"
-	tmp.AaAa = "2"
-	tmp.XxYy.xxXx = "3"
-	tmp.XxYy.Xx = "3"
-	testData.CaCa = append(testData.CaCa, tmp)
-	m := struct2MapAll(testData).(map[string]interface{})
+func TestGenericFilter_Invoke(t *testing.T) {
+	invoker := &recordInvoker{BaseInvoker: *protocol.NewBaseInvoker(common.URL{})}
+	user := &genericUser{Id: "1", Friends: []*genericUser{{Id: "2"}}}
+	inv := invocation.NewRPCInvocation(constant.GENERIC, []interface{}{"GetUser", []string{"com.test.User", "java.lang.String"},
+		[]interface{}{user, nil}}, map[string]string{"key": "value"})
+	GetGenericFilter().Invoke(invoker, inv)
 
-	assert.Equal(t, "1", m["aaAa"].(string))
-	assert.Equal(t, "1", m["baBa"].(string))
-	assert.Equal(t, "2", m["caCa"].([]interface{})[0].(map[string]interface{})["aaAa"].(string))
-	assert.Equal(t, "3", m["caCa"].([]interface{})[0].(map[string]interface{})["xxYy"].(map[string]interface{})["xx"].(string))
+	// the arguments are generalized, and the invocation is marked as generic
+	args := invoker.invocation.Arguments()
+	assert.Equal(t, constant.GENERIC, invoker.invocation.MethodName())
+	assert.Equal(t, "GetUser", args[0])
+	assert.Equal(t, []string{"com.test.User", "java.lang.String"}, args[1])
+	params := args[2].([]hessian.Object)
+	assert.Equal(t, map[string]interface{}{
+		"id":      "1",
+		"friends": []interface{}{map[string]interface{}{"id": "2", "friends": nil}},
+	}, params[0])
+	assert.Nil(t, params[1])
+	assert.Equal(t, "true", invoker.invocation.AttachmentsByKey(constant.GENERIC_KEY, ""))
+	assert.Equal(t, "value", invoker.invocation.AttachmentsByKey("key", ""))
 
-	assert.Equal(t, reflect.Slice, reflect.TypeOf(m["caCa"]).Kind())
-	assert.Equal(t, reflect.Map, reflect.TypeOf(m["caCa"].([]interface{})[0].(map[string]interface{})["xxYy"]).Kind())
+	// the other invocations are passed through
+	inv = invocation.NewRPCInvocation("GetUser", []interface{}{user}, nil)
+	GetGenericFilter().Invoke(invoker, inv)
+	assert.Equal(t, inv, invoker.invocation)
 }
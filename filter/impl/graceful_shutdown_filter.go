@@ -0,0 +1,94 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package impl
+
+import (
+	"sync"
+)
+
+import (
+	"go.uber.org/atomic"
+)
+
+import (
+	"github.com/apache/dubbo-go/common/extension"
+	"github.com/apache/dubbo-go/filter"
+	"github.com/apache/dubbo-go/protocol"
+)
+
+const (
+	GRACEFUL_SHUTDOWN = "graceful_shutdown"
+	// the status code of the invocations rejected during the shutdown, the failover cluster retries it on
+	// another provider
+	SHUTTING_DOWN_CODE = "503"
+)
+
+var (
+	gracefulShutdownFilter     *GracefulShutdownFilter
+	gracefulShutdownFilterOnce sync.Once
+)
+
+func init() {
+	extension.SetFilter(GRACEFUL_SHUTDOWN, GetGracefulShutdownFilter)
+}
+
+// GracefulShutdownFilter counts the active invocations of the providers, and rejects the new ones once the
+// shutdown begins, so the in-flight invocations are able to complete before the protocols are destroyed.
+type GracefulShutdownFilter struct {
+	active    *atomic.Int64
+	rejecting *atomic.Bool
+}
+
+func NewGracefulShutdownFilter() *GracefulShutdownFilter {
+	return &GracefulShutdownFilter{
+		active:    atomic.NewInt64(0),
+		rejecting: atomic.NewBool(false),
+	}
+}
+
+func (gf *GracefulShutdownFilter) Invoke(invoker protocol.Invoker, invocation protocol.Invocation) protocol.Result {
+	gf.active.Inc()
+	defer gf.active.Dec()
+	if gf.rejecting.Load() {
+		return &protocol.RPCResult{Err: protocol.NewStatusError(SHUTTING_DOWN_CODE,
+			"the provider "+invoker.GetUrl().Location+" is shutting down")}
+	}
+	return invoker.Invoke(invocation)
+}
+
+func (gf *GracefulShutdownFilter) OnResponse(result protocol.Result, invoker protocol.Invoker, invocation protocol.Invocation) protocol.Result {
+	return result
+}
+
+// RejectNewInvocations makes the filter reject the invocations from now on
+func (gf *GracefulShutdownFilter) RejectNewInvocations() {
+	gf.rejecting.Store(true)
+}
+
+// ActiveInvocations returns the count of the invocations in progress
+func (gf *GracefulShutdownFilter) ActiveInvocations() int64 {
+	return gf.active.Load()
+}
+
+// GetGracefulShutdownFilter returns the filter shared by all the providers
+func GetGracefulShutdownFilter() filter.Filter {
+	gracefulShutdownFilterOnce.Do(func() {
+		gracefulShutdownFilter = NewGracefulShutdownFilter()
+	})
+	return gracefulShutdownFilter
+}
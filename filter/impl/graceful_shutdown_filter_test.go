@@ -0,0 +1,85 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package impl
+
+import (
+	"context"
+	"testing"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+import (
+	"github.com/apache/dubbo-go/common"
+	"github.com/apache/dubbo-go/protocol"
+	"github.com/apache/dubbo-go/protocol/invocation"
+)
+
+// startedBlockingInvoker signals the calls started and blocks them until released
+type startedBlockingInvoker struct {
+	protocol.BaseInvoker
+	started chan struct{}
+	release chan struct{}
+}
+
+func (bi *startedBlockingInvoker) Invoke(invocation protocol.Invocation) protocol.Result {
+	bi.started <- struct{}{}
+	<-bi.release
+	return &protocol.RPCResult{Rest: "done"}
+}
+
+func TestGracefulShutdownFilter_Invoke(t *testing.T) {
+	f := NewGracefulShutdownFilter()
+	url, _ := common.NewURL(context.TODO(), "dubbo://192.168.1.1:20000/com.ikurento.user.UserProvider")
+	invoker := &startedBlockingInvoker{
+		BaseInvoker: *protocol.NewBaseInvoker(url),
+		started:     make(chan struct{}),
+		release:     make(chan struct{}),
+	}
+	inv := invocation.NewRPCInvocation("GetUser", []interface{}{"1"}, nil)
+
+	results := make(chan protocol.Result)
+	go func() {
+		results <- f.Invoke(invoker, inv)
+	}()
+	<-invoker.started
+	assert.Equal(t, int64(1), f.ActiveInvocations())
+
+	// the new invocations are rejected with a retriable status error
+	f.RejectNewInvocations()
+	result := f.Invoke(invoker, inv)
+	assert.Error(t, result.Error())
+	statusErr, ok := result.Error().(protocol.StatusError)
+	assert.True(t, ok)
+	assert.Equal(t, SHUTTING_DOWN_CODE, statusErr.StatusCode())
+	assert.Contains(t, statusErr.Error(), "192.168.1.1:20000")
+	assert.Equal(t, int64(1), f.ActiveInvocations())
+
+	// the in-flight invocation completes
+	close(invoker.release)
+	result = <-results
+	assert.NoError(t, result.Error())
+	assert.Equal(t, "done", result.Result())
+	assert.Equal(t, int64(0), f.ActiveInvocations())
+}
+
+func TestGetGracefulShutdownFilter(t *testing.T) {
+	assert.True(t, GetGracefulShutdownFilter() == GetGracefulShutdownFilter())
+}
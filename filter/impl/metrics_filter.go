@@ -0,0 +1,97 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package impl
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+import (
+	"github.com/apache/dubbo-go/common"
+	"github.com/apache/dubbo-go/common/constant"
+	"github.com/apache/dubbo-go/common/extension"
+	"github.com/apache/dubbo-go/filter"
+	"github.com/apache/dubbo-go/metrics"
+	"github.com/apache/dubbo-go/protocol"
+)
+
+const METRICS = "metrics"
+
+func init() {
+	extension.SetFilter(METRICS, GetMetricsFilter)
+}
+
+// MetricsFilter reports the count, the errors and the response time of every invocation to the metric reporters
+// of the url, like prometheus. It's added to the filters of the provider and the consumer with metrics configured.
+type MetricsFilter struct {
+	role      common.RoleType
+	reporters []metrics.Reporter
+	prepared  bool
+}
+
+// Prepare registers the methods of the invoker to the reporters on export or refer, so their metrics are exported
+// before the first invocations. The methods of the urls from the registry are in the methods param only.
+func (f *MetricsFilter) Prepare(url common.URL, role common.RoleType) {
+	f.role = role
+	f.reporters = metricReporters(url)
+	f.prepared = true
+	if methods := url.GetParam(constant.METHODS_KEY, ""); len(url.Methods) == 0 && methods != "" {
+		url.Methods = strings.Split(methods, ",")
+	}
+	for _, reporter := range f.reporters {
+		reporter.Register(url, role)
+	}
+}
+
+func (f *MetricsFilter) Invoke(invoker protocol.Invoker, invocation protocol.Invocation) protocol.Result {
+	role, reporters := f.role, f.reporters
+	if !f.prepared {
+		url := invoker.GetUrl()
+		r, _ := strconv.Atoi(url.GetParam(constant.ROLE_KEY, strconv.Itoa(common.CONSUMER)))
+		role, reporters = common.RoleType(r), metricReporters(url)
+	}
+
+	start := time.Now()
+	result := invoker.Invoke(invocation)
+	cost := time.Since(start)
+	for _, reporter := range reporters {
+		reporter.Report(invoker.GetUrl(), role, invocation.MethodName(), cost, result.Error())
+	}
+	return result
+}
+
+func (f *MetricsFilter) OnResponse(result protocol.Result, invoker protocol.Invoker, invocation protocol.Invocation) protocol.Result {
+	return result
+}
+
+// metricReporters returns the reporters named in the metrics of the @url
+func metricReporters(url common.URL) []metrics.Reporter {
+	var reporters []metrics.Reporter
+	for _, name := range strings.Split(url.GetParam(constant.METRICS_KEY, ""), ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			reporters = append(reporters, extension.GetMetricReporter(name, url))
+		}
+	}
+	return reporters
+}
+
+func GetMetricsFilter() filter.Filter {
+	return &MetricsFilter{}
+}
@@ -0,0 +1,90 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package impl
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+import (
+	"github.com/apache/dubbo-go/common"
+	"github.com/apache/dubbo-go/common/extension"
+	"github.com/apache/dubbo-go/metrics"
+	"github.com/apache/dubbo-go/protocol"
+	"github.com/apache/dubbo-go/protocol/invocation"
+)
+
+type metricReport struct {
+	role       common.RoleType
+	methodName string
+	err        error
+}
+
+// mockMetricReporter records the registered methods and the reports
+type mockMetricReporter struct {
+	registered []string
+	reports    []metricReport
+}
+
+func (r *mockMetricReporter) Register(url common.URL, role common.RoleType) {
+	r.registered = append(r.registered, url.Methods...)
+}
+
+func (r *mockMetricReporter) Report(url common.URL, role common.RoleType, methodName string, cost time.Duration, err error) {
+	r.reports = append(r.reports, metricReport{role: role, methodName: methodName, err: err})
+}
+
+func TestMetricsFilter_Invoke(t *testing.T) {
+	reporter := &mockMetricReporter{}
+	extension.SetMetricReporter("mock", func(url common.URL) metrics.Reporter {
+		return reporter
+	})
+	url, err := common.NewURL(context.TODO(), "dubbo://192.168.1.1:20000/com.ikurento.user.UserProvider?metrics=mock&methods=GetUser,echo")
+	assert.NoError(t, err)
+	invoker := &echoInvoker{BaseInvoker: *protocol.NewBaseInvoker(url)}
+
+	f := GetMetricsFilter().(*MetricsFilter)
+	f.Prepare(url, common.PROVIDER)
+	assert.Equal(t, []string{"GetUser", "echo"}, reporter.registered)
+
+	result := f.Invoke(invoker, invocation.NewRPCInvocation("GetUser", []interface{}{"ok"}, nil))
+	assert.NoError(t, result.Error())
+	result = f.Invoke(invoker, invocation.NewRPCInvocation("echo", []interface{}{"error"}, nil))
+	assert.Error(t, result.Error())
+
+	assert.Equal(t, 2, len(reporter.reports))
+	assert.Equal(t, metricReport{role: common.PROVIDER, methodName: "GetUser"}, reporter.reports[0])
+	assert.Equal(t, common.RoleType(common.PROVIDER), reporter.reports[1].role)
+	assert.Equal(t, result.Error(), reporter.reports[1].err)
+}
+
+func TestMetricsFilter_NoReporter(t *testing.T) {
+	url, err := common.NewURL(context.TODO(), "dubbo://192.168.1.1:20000/com.ikurento.user.UserProvider")
+	assert.NoError(t, err)
+	invoker := &echoInvoker{BaseInvoker: *protocol.NewBaseInvoker(url)}
+
+	f := GetMetricsFilter()
+	result := f.Invoke(invoker, invocation.NewRPCInvocation("GetUser", []interface{}{"ok"}, nil))
+	assert.Equal(t, "ok", result.Result())
+}
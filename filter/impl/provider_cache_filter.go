@@ -0,0 +1,173 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package impl
+
+import (
+	"container/list"
+	"fmt"
+	"sync"
+	"time"
+)
+
+import (
+	"github.com/apache/dubbo-go/common"
+	"github.com/apache/dubbo-go/common/constant"
+	"github.com/apache/dubbo-go/common/extension"
+	"github.com/apache/dubbo-go/filter"
+	"github.com/apache/dubbo-go/protocol"
+	invocation_impl "github.com/apache/dubbo-go/protocol/invocation"
+)
+
+const PROVIDER_CACHE = "providercache"
+
+func init() {
+	extension.SetFilter(PROVIDER_CACHE, GetProviderCacheFilter)
+}
+
+// ProviderCacheFilter caches the results of the methods with provider.cache on the provider, keyed by the arguments,
+// so the identical requests of all the consumers are served by the cache rather than the service. Unlike the caches
+// of the consumers, a cached result is shared by the consumers, and it's invalidated by its ttl only.
+type ProviderCacheFilter struct {
+	caches sync.Map // service key.method -> *providerCache
+}
+
+func (f *ProviderCacheFilter) Invoke(invoker protocol.Invoker, invocation protocol.Invocation) protocol.Result {
+	url := invoker.GetUrl()
+	methodName := invocation.MethodName()
+	if !url.GetMethodParamBool(methodName, constant.PROVIDER_CACHE_KEY, false) {
+		return invoker.Invoke(invocation)
+	}
+
+	cache := f.cache(url, methodName)
+	key := argumentsCacheKey(invocation.Arguments())
+	if value, ok := cache.get(key, time.Now()); ok {
+		return &protocol.RPCResult{Rest: value}
+	}
+	result := invoker.Invoke(invocation)
+	if result.Error() != nil {
+		return result
+	}
+	if result.Result() != nil {
+		cache.put(key, result.Result(), time.Now())
+		return result
+	}
+	// the provider calls the service after the filters, its result is notified to the callback of the invocation,
+	// which is chained to the callback set by the other filters
+	if rpcInvocation, ok := invocation.(*invocation_impl.RPCInvocation); ok {
+		previous, chained := rpcInvocation.CallBack().(func(protocol.Result))
+		if !chained && rpcInvocation.CallBack() != nil {
+			return result
+		}
+		rpcInvocation.SetCallBack(func(result protocol.Result) {
+			if result.Error() == nil && result.Result() != nil {
+				cache.put(key, result.Result(), time.Now())
+			}
+			if previous != nil {
+				previous(result)
+			}
+		})
+	}
+	return result
+}
+
+func (f *ProviderCacheFilter) OnResponse(result protocol.Result, invoker protocol.Invoker, invocation protocol.Invocation) protocol.Result {
+	return result
+}
+
+// cache returns the cache of the method, it's created by the ttl and size of the @url once
+func (f *ProviderCacheFilter) cache(url common.URL, methodName string) *providerCache {
+	key := url.ServiceKey() + "." + methodName
+	if cache, ok := f.caches.Load(key); ok {
+		return cache.(*providerCache)
+	}
+	ttl := url.GetMethodParamInt64(methodName, constant.PROVIDER_CACHE_TTL_KEY, constant.DEFAULT_PROVIDER_CACHE_TTL)
+	size := url.GetMethodParamInt64(methodName, constant.PROVIDER_CACHE_SIZE_KEY, constant.DEFAULT_PROVIDER_CACHE_SIZE)
+	cache, _ := f.caches.LoadOrStore(key, newProviderCache(time.Duration(ttl)*time.Millisecond, int(size)))
+	return cache.(*providerCache)
+}
+
+// argumentsCacheKey is the key of the arguments, the pointers are compared by the values they point to
+func argumentsCacheKey(args []interface{}) string {
+	return fmt.Sprintf("%v", common.Generalize(args))
+}
+
+// providerCache is a LRU cache whose entries expire after the ttl
+type providerCache struct {
+	lock    sync.Mutex
+	ttl     time.Duration
+	size    int
+	entries map[string]*list.Element
+	// the entries of the recently used at the front
+	lru *list.List
+}
+
+type providerCacheEntry struct {
+	key    string
+	value  interface{}
+	expire time.Time
+}
+
+func newProviderCache(ttl time.Duration, size int) *providerCache {
+	return &providerCache{
+		ttl:     ttl,
+		size:    size,
+		entries: make(map[string]*list.Element),
+		lru:     list.New(),
+	}
+}
+
+func (c *providerCache) get(key string, now time.Time) (interface{}, bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	element, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	entry := element.Value.(*providerCacheEntry)
+	if !now.Before(entry.expire) {
+		c.lru.Remove(element)
+		delete(c.entries, key)
+		return nil, false
+	}
+	c.lru.MoveToFront(element)
+	return entry.value, true
+}
+
+// put caches the @value, the least recently used entry is evicted if the cache is full
+func (c *providerCache) put(key string, value interface{}, now time.Time) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if element, ok := c.entries[key]; ok {
+		entry := element.Value.(*providerCacheEntry)
+		entry.value, entry.expire = value, now.Add(c.ttl)
+		c.lru.MoveToFront(element)
+		return
+	}
+	c.entries[key] = c.lru.PushFront(&providerCacheEntry{key: key, value: value, expire: now.Add(c.ttl)})
+	for c.size > 0 && c.lru.Len() > c.size {
+		oldest := c.lru.Back()
+		c.lru.Remove(oldest)
+		delete(c.entries, oldest.Value.(*providerCacheEntry).key)
+	}
+}
+
+func GetProviderCacheFilter() filter.Filter {
+	return &ProviderCacheFilter{}
+}
@@ -0,0 +1,134 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package impl
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+import (
+	"github.com/apache/dubbo-go/common"
+	"github.com/apache/dubbo-go/common/constant"
+	"github.com/apache/dubbo-go/protocol"
+	"github.com/apache/dubbo-go/protocol/invocation"
+)
+
+// countingInvoker returns the number of its calls as the result
+type countingInvoker struct {
+	protocol.BaseInvoker
+	calls int
+	// the result is returned after the filters like the provider of dubbo
+	deferred bool
+}
+
+func (ci *countingInvoker) Invoke(invocation protocol.Invocation) protocol.Result {
+	ci.calls++
+	if ci.deferred {
+		return &protocol.RPCResult{}
+	}
+	return &protocol.RPCResult{Rest: ci.calls}
+}
+
+func providerCacheInvoker(t *testing.T, params string) *countingInvoker {
+	url, err := common.NewURL(context.TODO(), "dubbo://192.168.1.1:20000/com.ikurento.user.UserProvider?"+params)
+	assert.NoError(t, err)
+	return &countingInvoker{BaseInvoker: *protocol.NewBaseInvoker(url)}
+}
+
+func consumerInvocation(ip string, method string, args ...interface{}) *invocation.RPCInvocation {
+	return invocation.NewRPCInvocation(method, args, map[string]string{constant.REMOTE_IP_KEY: ip})
+}
+
+func TestProviderCacheFilter_SharedAcrossConsumers(t *testing.T) {
+	f := GetProviderCacheFilter()
+	invoker := providerCacheInvoker(t, "methods.GetUser.provider.cache=true")
+
+	result := f.Invoke(invoker, consumerInvocation("10.0.0.1", "GetUser", "1", &genericUser{Id: "1"}))
+	assert.Equal(t, 1, result.Result())
+
+	// the identical request from another consumer hits the cache
+	result = f.Invoke(invoker, consumerInvocation("10.0.0.2", "GetUser", "1", &genericUser{Id: "1"}))
+	assert.Equal(t, 1, result.Result())
+	assert.Equal(t, 1, invoker.calls)
+
+	result = f.Invoke(invoker, consumerInvocation("10.0.0.2", "GetUser", "1", &genericUser{Id: "2"}))
+	assert.Equal(t, 2, result.Result())
+
+	// the other methods aren't cached
+	f.Invoke(invoker, consumerInvocation("10.0.0.1", "GetUser0", "1"))
+	result = f.Invoke(invoker, consumerInvocation("10.0.0.2", "GetUser0", "1"))
+	assert.Equal(t, 4, result.Result())
+}
+
+func TestProviderCacheFilter_ServiceResult(t *testing.T) {
+	f := GetProviderCacheFilter()
+	invoker := providerCacheInvoker(t, "provider.cache=true")
+	invoker.deferred = true
+
+	// the result of the service called after the filters is cached by the callback,
+	// which is chained to the existing one
+	inv := consumerInvocation("10.0.0.1", "GetUser", "1")
+	var notified protocol.Result
+	inv.SetCallBack(func(result protocol.Result) {
+		notified = result
+	})
+	result := f.Invoke(invoker, inv)
+	assert.Nil(t, result.Result())
+	inv.CallBack().(func(protocol.Result))(&protocol.RPCResult{Rest: "user"})
+	assert.Equal(t, "user", notified.Result())
+
+	result = f.Invoke(invoker, consumerInvocation("10.0.0.2", "GetUser", "1"))
+	assert.Equal(t, "user", result.Result())
+	assert.Equal(t, 1, invoker.calls)
+}
+
+func TestProviderCache_TTL(t *testing.T) {
+	cache := newProviderCache(time.Second, 10)
+	now := time.Now()
+	cache.put("key", "value", now)
+	value, ok := cache.get("key", now.Add(500*time.Millisecond))
+	assert.True(t, ok)
+	assert.Equal(t, "value", value)
+
+	_, ok = cache.get("key", now.Add(time.Second))
+	assert.False(t, ok)
+	assert.Equal(t, 0, cache.lru.Len())
+}
+
+func TestProviderCache_Size(t *testing.T) {
+	cache := newProviderCache(time.Minute, 2)
+	now := time.Now()
+	cache.put("a", 1, now)
+	cache.put("b", 2, now)
+	// b is the least recently used
+	cache.get("a", now)
+	cache.put("c", 3, now)
+
+	_, ok := cache.get("b", now)
+	assert.False(t, ok)
+	_, ok = cache.get("a", now)
+	assert.True(t, ok)
+	_, ok = cache.get("c", now)
+	assert.True(t, ok)
+}
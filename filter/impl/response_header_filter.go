@@ -0,0 +1,75 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package impl
+
+import (
+	"strings"
+)
+
+import (
+	"github.com/apache/dubbo-go/common/constant"
+	"github.com/apache/dubbo-go/common/extension"
+	"github.com/apache/dubbo-go/filter"
+	"github.com/apache/dubbo-go/protocol"
+)
+
+const RESPONSE_HEADER = "response_header"
+
+func init() {
+	extension.SetFilter(RESPONSE_HEADER, GetResponseHeaderFilter)
+}
+
+// ResponseHeaderFilter is the provider filter which maps the configured attachments of the invocation or the result
+// to the http response headers, for the gateways bridging dubbo to http. The attachment is used as the header name
+// if the header is omitted, and the result attachment takes precedence. eg:
+//
+//	response.headers: trace_id:X-Trace-Id,tenant
+type ResponseHeaderFilter struct{}
+
+func (ef *ResponseHeaderFilter) Invoke(invoker protocol.Invoker, invocation protocol.Invocation) protocol.Result {
+	return invoker.Invoke(invocation)
+}
+
+func (ef *ResponseHeaderFilter) OnResponse(result protocol.Result, invoker protocol.Invoker, invocation protocol.Invocation) protocol.Result {
+	url := invoker.GetUrl()
+	mappings := url.GetMethodParam(invocation.MethodName(), constant.RESPONSE_HEADERS_KEY, url.GetParam(constant.RESPONSE_HEADERS_KEY, ""))
+	if mappings == "" {
+		return result
+	}
+
+	for _, mapping := range strings.Split(mappings, ",") {
+		key, header := mapping, mapping
+		if i := strings.Index(mapping, ":"); i >= 0 {
+			key, header = mapping[:i], mapping[i+1:]
+		}
+		key, header = strings.TrimSpace(key), strings.TrimSpace(header)
+		if key == "" || header == "" {
+			continue
+		}
+		value := result.Attachment(key, invocation.AttachmentsByKey(key, ""))
+		if value == "" {
+			continue
+		}
+		result.AddAttachment(constant.RESPONSE_HEADER_ATTACHMENT_PREFIX+header, value)
+	}
+	return result
+}
+
+func GetResponseHeaderFilter() filter.Filter {
+	return &ResponseHeaderFilter{}
+}
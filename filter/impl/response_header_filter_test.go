@@ -0,0 +1,61 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package impl
+
+import (
+	"net/url"
+	"testing"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+import (
+	"github.com/apache/dubbo-go/common"
+	"github.com/apache/dubbo-go/common/constant"
+	"github.com/apache/dubbo-go/protocol"
+	"github.com/apache/dubbo-go/protocol/invocation"
+)
+
+func TestResponseHeaderFilter_OnResponse(t *testing.T) {
+	params := url.Values{}
+	params.Set(constant.RESPONSE_HEADERS_KEY, "trace_id:X-Trace-Id, tenant ,missing")
+	params.Set("methods.GetUser0."+constant.RESPONSE_HEADERS_KEY, "tenant:X-Tenant")
+	invoker := protocol.NewBaseInvoker(*common.NewURLWithOptions(common.WithPath("UserProvider"), common.WithParams(params)))
+	filter := GetResponseHeaderFilter()
+
+	inv := invocation.NewRPCInvocation("GetUser", nil, map[string]string{"trace_id": "t-1", "tenant": "a"})
+	result := filter.OnResponse(&protocol.RPCResult{Rest: "ok", Attrs: map[string]string{"tenant": "b"}}, invoker, inv)
+	assert.Equal(t, "ok", result.Result())
+	assert.Equal(t, map[string]string{
+		"tenant":                     "b",
+		"response.header.X-Trace-Id": "t-1",
+		"response.header.tenant":     "b",
+	}, result.Attachments())
+
+	// the method config overrides the service config
+	inv = invocation.NewRPCInvocation("GetUser0", nil, map[string]string{"trace_id": "t-1", "tenant": "a"})
+	result = filter.OnResponse(&protocol.RPCResult{Rest: "ok"}, invoker, inv)
+	assert.Equal(t, map[string]string{"response.header.X-Tenant": "a"}, result.Attachments())
+
+	// nothing is mapped without the config
+	invoker = protocol.NewBaseInvoker(*common.NewURLWithOptions(common.WithPath("UserProvider")))
+	result = filter.OnResponse(&protocol.RPCResult{Rest: "ok"}, invoker, inv)
+	assert.Nil(t, result.Attachments())
+}
@@ -0,0 +1,151 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package impl
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+import (
+	"github.com/apache/dubbo-go/common"
+	"github.com/apache/dubbo-go/common/constant"
+	"github.com/apache/dubbo-go/common/extension"
+	"github.com/apache/dubbo-go/filter"
+)
+
+func init() {
+	extension.SetCache(constant.LRU_CACHE, func(url common.URL, methodName string) filter.Cache {
+		return newLruCache(int(url.GetMethodParamInt64(methodName, constant.CACHE_SIZE_KEY, constant.DEFAULT_CACHE_SIZE)))
+	})
+	extension.SetCache(constant.EXPIRING_CACHE, func(url common.URL, methodName string) filter.Cache {
+		ttl := url.GetMethodParamInt64(methodName, constant.CACHE_TTL_KEY, constant.DEFAULT_CACHE_TTL)
+		return newExpiringCache(time.Duration(ttl) * time.Millisecond)
+	})
+}
+
+// lruCache caches up to size results, the least recently used one is evicted for a new one
+type lruCache struct {
+	lock    sync.Mutex
+	size    int
+	entries map[string]*list.Element
+	// the entries of the recently used at the front
+	lru *list.List
+}
+
+type lruCacheEntry struct {
+	key   string
+	value interface{}
+}
+
+func newLruCache(size int) *lruCache {
+	if size <= 0 {
+		size = constant.DEFAULT_CACHE_SIZE
+	}
+	return &lruCache{
+		size:    size,
+		entries: make(map[string]*list.Element),
+		lru:     list.New(),
+	}
+}
+
+func (c *lruCache) Get(key string) (interface{}, bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	element, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	c.lru.MoveToFront(element)
+	return element.Value.(*lruCacheEntry).value, true
+}
+
+func (c *lruCache) Put(key string, value interface{}) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if element, ok := c.entries[key]; ok {
+		element.Value.(*lruCacheEntry).value = value
+		c.lru.MoveToFront(element)
+		return
+	}
+	c.entries[key] = c.lru.PushFront(&lruCacheEntry{key: key, value: value})
+	if c.lru.Len() > c.size {
+		oldest := c.lru.Back()
+		c.lru.Remove(oldest)
+		delete(c.entries, oldest.Value.(*lruCacheEntry).key)
+	}
+}
+
+// expiringCache caches the results for the ttl, the expired ones are swept on the puts once a ttl
+type expiringCache struct {
+	lock      sync.Mutex
+	ttl       time.Duration
+	entries   map[string]expiringCacheEntry
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+type expiringCacheEntry struct {
+	value  interface{}
+	expire time.Time
+}
+
+func newExpiringCache(ttl time.Duration) *expiringCache {
+	if ttl <= 0 {
+		ttl = constant.DEFAULT_CACHE_TTL * time.Millisecond
+	}
+	return &expiringCache{
+		ttl:       ttl,
+		entries:   make(map[string]expiringCacheEntry),
+		lastSweep: time.Now(),
+		now:       time.Now,
+	}
+}
+
+func (c *expiringCache) Get(key string) (interface{}, bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	entry, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	if !c.now().Before(entry.expire) {
+		delete(c.entries, key)
+		return nil, false
+	}
+	return entry.value, true
+}
+
+func (c *expiringCache) Put(key string, value interface{}) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	now := c.now()
+	if now.Sub(c.lastSweep) >= c.ttl {
+		for k, entry := range c.entries {
+			if !now.Before(entry.expire) {
+				delete(c.entries, k)
+			}
+		}
+		c.lastSweep = now
+	}
+	c.entries[key] = expiringCacheEntry{value: value, expire: now.Add(c.ttl)}
+}
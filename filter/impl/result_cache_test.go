@@ -0,0 +1,77 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package impl
+
+import (
+	"testing"
+	"time"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLruCache_Eviction(t *testing.T) {
+	cache := newLruCache(2)
+	cache.Put("a", 1)
+	cache.Put("b", 2)
+	// b is the least recently used
+	cache.Get("a")
+	cache.Put("c", 3)
+
+	_, ok := cache.Get("b")
+	assert.False(t, ok)
+	value, ok := cache.Get("a")
+	assert.True(t, ok)
+	assert.Equal(t, 1, value)
+	value, ok = cache.Get("c")
+	assert.True(t, ok)
+	assert.Equal(t, 3, value)
+	assert.Equal(t, 2, cache.lru.Len())
+}
+
+func TestExpiringCache_TTL(t *testing.T) {
+	cache := newExpiringCache(time.Second)
+	now := time.Now()
+	cache.now = func() time.Time { return now }
+	cache.Put("a", 1)
+
+	now = now.Add(500 * time.Millisecond)
+	value, ok := cache.Get("a")
+	assert.True(t, ok)
+	assert.Equal(t, 1, value)
+
+	now = now.Add(500 * time.Millisecond)
+	_, ok = cache.Get("a")
+	assert.False(t, ok)
+	assert.Empty(t, cache.entries)
+}
+
+func TestExpiringCache_Sweep(t *testing.T) {
+	cache := newExpiringCache(time.Second)
+	now := time.Now()
+	cache.now = func() time.Time { return now }
+	cache.lastSweep = now
+	cache.Put("a", 1)
+
+	// the expired entries are swept by the put after a ttl
+	now = now.Add(time.Second)
+	cache.Put("b", 2)
+	assert.Len(t, cache.entries, 1)
+	_, ok := cache.entries["b"]
+	assert.True(t, ok)
+}
@@ -0,0 +1,76 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package impl
+
+import (
+	"context"
+	"testing"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+import (
+	"github.com/apache/dubbo-go/common"
+	"github.com/apache/dubbo-go/common/constant"
+	"github.com/apache/dubbo-go/protocol"
+	"github.com/apache/dubbo-go/protocol/invocation"
+)
+
+func tokenInvocation(token string) *invocation.RPCInvocation {
+	attachments := map[string]string{constant.REMOTE_IP_KEY: "10.0.0.1"}
+	if token != "" {
+		attachments[constant.TOKEN_KEY] = token
+	}
+	return invocation.NewRPCInvocation("GetUser", []interface{}{"1"}, attachments)
+}
+
+func TestTokenFilter_Invoke(t *testing.T) {
+	f := GetTokenFilter()
+	url, _ := common.NewURL(context.TODO(), "dubbo://192.168.1.1:20000/com.ikurento.user.UserProvider?token=abc")
+	invoker := &countingInvoker{BaseInvoker: *protocol.NewBaseInvoker(url)}
+
+	result := f.Invoke(invoker, tokenInvocation("abc"))
+	assert.NoError(t, result.Error())
+	assert.Equal(t, 1, result.Result())
+
+	// the wrong token
+	result = f.Invoke(invoker, tokenInvocation("abd"))
+	assert.Error(t, result.Error())
+	assert.Contains(t, result.Error().Error(), "GetUser")
+	assert.Contains(t, result.Error().Error(), "com.ikurento.user.UserProvider")
+	assert.Contains(t, result.Error().Error(), "10.0.0.1")
+
+	// the missing token
+	result = f.Invoke(invoker, tokenInvocation(""))
+	assert.Error(t, result.Error())
+	assert.Equal(t, 1, invoker.calls)
+}
+
+func TestTokenFilter_NoToken(t *testing.T) {
+	f := GetTokenFilter()
+	url, _ := common.NewURL(context.TODO(), "dubbo://192.168.1.1:20000/com.ikurento.user.UserProvider")
+	invoker := &countingInvoker{BaseInvoker: *protocol.NewBaseInvoker(url)}
+
+	result := f.Invoke(invoker, tokenInvocation(""))
+	assert.NoError(t, result.Error())
+	result = f.Invoke(invoker, tokenInvocation("abc"))
+	assert.NoError(t, result.Error())
+	assert.Equal(t, 2, invoker.calls)
+}
@@ -0,0 +1,157 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package impl
+
+import (
+	"sync"
+	"time"
+)
+
+import (
+	perrors "github.com/pkg/errors"
+	"go.uber.org/atomic"
+)
+
+import (
+	"github.com/apache/dubbo-go/common"
+	"github.com/apache/dubbo-go/common/constant"
+	"github.com/apache/dubbo-go/common/extension"
+	"github.com/apache/dubbo-go/common/logger"
+	"github.com/apache/dubbo-go/filter"
+	"github.com/apache/dubbo-go/protocol"
+)
+
+const TPS_LIMIT = "tps"
+
+var (
+	ErrTpsLimitExceeded = perrors.New("tps limit exceeded")
+
+	// the rejections of a method are logged at most once in the period
+	tpsRejectionLogPeriod = time.Minute
+)
+
+func init() {
+	extension.SetFilter(TPS_LIMIT, GetTpsLimitFilter)
+}
+
+// TpsLimitFilter rejects the requests to the provider over the tps limit before they reach the service.
+// The limit of a method overrides the one of the service, and the strategy counting the requests is
+// pluggable by extension.SetTpsLimitStrategy. eg:
+//
+//	tps: 1000                       // the limit of every method of the service in one interval
+//	methods.GetUser.tps: 100        // the limit of the method GetUser
+//	tps.interval: 60000             // in milliseconds
+//	tps.limit.strategy: tokenBucket // fixedWindow by default
+//
+// the rejected requests fail with the cause ErrTpsLimitExceeded.
+type TpsLimitFilter struct {
+	limiters sync.Map // invoker -> *methodTpsLimiters
+}
+
+type tpsLimiter struct {
+	strategy filter.TpsLimitStrategy
+	rate     int64
+	interval int64
+	// the rejections since they are logged last time
+	rejected atomic.Int64
+	// the time in nanoseconds the rejections are logged last time
+	logged atomic.Int64
+}
+
+// methodTpsLimiters are the limiters of the methods of an invoker, the unlimited methods have nil limiters
+type methodTpsLimiters struct {
+	lock     sync.RWMutex
+	limiters map[string]*tpsLimiter
+}
+
+func (f *TpsLimitFilter) Invoke(invoker protocol.Invoker, invocation protocol.Invocation) protocol.Result {
+	limiter := f.limiter(invoker, invocation.MethodName())
+	if limiter == nil || limiter.strategy.IsAllowable() {
+		return invoker.Invoke(invocation)
+	}
+
+	url := invoker.GetUrl()
+	limiter.logRejected(url, invocation.MethodName())
+	return &protocol.RPCResult{Err: perrors.Wrapf(ErrTpsLimitExceeded, "the method %v of service %v is invoked over the tps limit %v in %vms",
+		invocation.MethodName(), url.ServiceKey(), limiter.rate, limiter.interval)}
+}
+
+func (f *TpsLimitFilter) OnResponse(result protocol.Result, invoker protocol.Invoker, invocation protocol.Invocation) protocol.Result {
+	return result
+}
+
+// limiter returns the limiter of the method, which is created once by the url of the invoker
+func (f *TpsLimitFilter) limiter(invoker protocol.Invoker, methodName string) *tpsLimiter {
+	value, ok := f.limiters.Load(invoker)
+	if !ok {
+		value, _ = f.limiters.LoadOrStore(invoker, &methodTpsLimiters{limiters: make(map[string]*tpsLimiter)})
+	}
+	methods := value.(*methodTpsLimiters)
+
+	methods.lock.RLock()
+	limiter, ok := methods.limiters[methodName]
+	methods.lock.RUnlock()
+	if ok {
+		return limiter
+	}
+
+	methods.lock.Lock()
+	defer methods.lock.Unlock()
+	if limiter, ok = methods.limiters[methodName]; !ok {
+		limiter = newTpsLimiter(invoker.GetUrl(), methodName)
+		methods.limiters[methodName] = limiter
+	}
+	return limiter
+}
+
+// logRejected counts the rejection, and logs the rejections in the last period
+func (l *tpsLimiter) logRejected(url common.URL, methodName string) {
+	l.rejected.Inc()
+	now := time.Now().UnixNano()
+	last := l.logged.Load()
+	if now-last < int64(tpsRejectionLogPeriod) || !l.logged.CAS(last, now) {
+		return
+	}
+	logger.Warnf("the method %v of service %v is invoked over the tps limit %v in %vms, %v requests are rejected.",
+		methodName, url.ServiceKey(), l.rate, l.interval, l.rejected.Swap(0))
+}
+
+func newTpsLimiter(url common.URL, methodName string) *tpsLimiter {
+	rate := url.GetMethodParamInt64(methodName, constant.TPS_LIMIT_RATE_KEY, 0)
+	if rate <= 0 {
+		return nil
+	}
+	interval := url.GetMethodParamInt64(methodName, constant.TPS_LIMIT_INTERVAL_KEY, constant.DEFAULT_TPS_LIMIT_INTERVAL)
+	strategy := url.GetMethodParam(methodName, constant.TPS_LIMIT_STRATEGY_KEY,
+		url.GetParam(constant.TPS_LIMIT_STRATEGY_KEY, constant.DEFAULT_TPS_LIMIT_STRATEGY))
+	// the strategy is checked on exporting the service, the unknown one isn't expected here
+	if !extension.HasTpsLimitStrategy(strategy) {
+		logger.Errorf("the tps limit strategy %v of the method %v of service %v is not existing, %v is used.",
+			strategy, methodName, url.ServiceKey(), constant.DEFAULT_TPS_LIMIT_STRATEGY)
+		strategy = constant.DEFAULT_TPS_LIMIT_STRATEGY
+	}
+	return &tpsLimiter{
+		strategy: extension.GetTpsLimitStrategy(strategy, rate, time.Duration(interval)*time.Millisecond),
+		rate:     rate,
+		interval: interval,
+	}
+}
+
+func GetTpsLimitFilter() filter.Filter {
+	return &TpsLimitFilter{}
+}
@@ -0,0 +1,140 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package impl
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+import (
+	perrors "github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+import (
+	"github.com/apache/dubbo-go/common"
+	"github.com/apache/dubbo-go/protocol"
+	"github.com/apache/dubbo-go/protocol/invocation"
+)
+
+// staticInvoker returns the same result for every call
+type staticInvoker struct {
+	protocol.BaseInvoker
+	result protocol.Result
+}
+
+func (si *staticInvoker) Invoke(invocation protocol.Invocation) protocol.Result {
+	return si.result
+}
+
+func tpsLimitInvoker(t *testing.T, params string) *staticInvoker {
+	url, err := common.NewURL(context.TODO(), "dubbo://192.168.1.1:20000/com.ikurento.user.UserProvider?"+params)
+	assert.NoError(t, err)
+	return &staticInvoker{BaseInvoker: *protocol.NewBaseInvoker(url), result: &protocol.RPCResult{Rest: "ok"}}
+}
+
+func TestTpsLimitFilter_Invoke(t *testing.T) {
+	f := GetTpsLimitFilter()
+	invoker := tpsLimitInvoker(t, "tps=2&methods.GetUser.tps=5")
+
+	// the limit of the method overrides the one of the service
+	for i := 0; i < 5; i++ {
+		assert.NoError(t, f.Invoke(invoker, invocation.NewRPCInvocation("GetUser", nil, nil)).Error())
+	}
+	err := f.Invoke(invoker, invocation.NewRPCInvocation("GetUser", nil, nil)).Error()
+	assert.Equal(t, ErrTpsLimitExceeded, perrors.Cause(err))
+	assert.Contains(t, err.Error(), "GetUser")
+
+	for i := 0; i < 2; i++ {
+		assert.NoError(t, f.Invoke(invoker, invocation.NewRPCInvocation("GetUser0", nil, nil)).Error())
+	}
+	assert.Equal(t, ErrTpsLimitExceeded, perrors.Cause(f.Invoke(invoker, invocation.NewRPCInvocation("GetUser0", nil, nil)).Error()))
+}
+
+func TestTpsLimitFilter_NoLimit(t *testing.T) {
+	f := GetTpsLimitFilter()
+	invoker := tpsLimitInvoker(t, "methods.GetUser.tps=1")
+	for i := 0; i < 100; i++ {
+		assert.NoError(t, f.Invoke(invoker, invocation.NewRPCInvocation("GetUser0", nil, nil)).Error())
+	}
+}
+
+func TestTpsLimitFilter_Strategy(t *testing.T) {
+	f := GetTpsLimitFilter().(*TpsLimitFilter)
+	invoker := tpsLimitInvoker(t, "tps=2&tps.limit.strategy=tokenBucket&methods.GetUser.tps.limit.strategy=slidingWindow")
+	assert.IsType(t, &tokenBucketTpsLimitStrategy{}, f.limiter(invoker, "GetUser0").strategy)
+	assert.IsType(t, &slidingWindowTpsLimitStrategy{}, f.limiter(invoker, "GetUser").strategy)
+
+	invoker = tpsLimitInvoker(t, "tps=2")
+	assert.IsType(t, &fixedWindowTpsLimitStrategy{}, f.limiter(invoker, "GetUser").strategy)
+
+	// the unknown strategy falls back to the default one rather than failing the invocation
+	invoker = tpsLimitInvoker(t, "tps=2&tps.limit.strategy=unknown")
+	assert.IsType(t, &fixedWindowTpsLimitStrategy{}, f.limiter(invoker, "GetUser").strategy)
+}
+
+func TestTpsLimitFilter_LogRejected(t *testing.T) {
+	f := GetTpsLimitFilter().(*TpsLimitFilter)
+	invoker := tpsLimitInvoker(t, "tps=1&tps.interval=3600000")
+	for i := 0; i < 100; i++ {
+		f.Invoke(invoker, invocation.NewRPCInvocation("GetUser", nil, nil))
+	}
+
+	// the first rejection is logged, and the rest are counted until the next period
+	limiter := f.limiter(invoker, "GetUser")
+	assert.Equal(t, int64(98), limiter.rejected.Load())
+	assert.NotEqual(t, int64(0), limiter.logged.Load())
+}
+
+func TestTpsLimitFilter_Concurrent(t *testing.T) {
+	f := GetTpsLimitFilter()
+	invoker := tpsLimitInvoker(t, "tps=100&tps.interval=3600000")
+
+	var (
+		wg       sync.WaitGroup
+		accepted int64
+	)
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				if f.Invoke(invoker, invocation.NewRPCInvocation("GetUser", nil, nil)).Error() == nil {
+					atomic.AddInt64(&accepted, 1)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int64(100), accepted)
+}
+
+func TestTpsLimitFilter_NoAllocation(t *testing.T) {
+	f := GetTpsLimitFilter()
+	invoker := tpsLimitInvoker(t, "tps=100000000")
+	inv := invocation.NewRPCInvocation("GetUser", nil, nil)
+	f.Invoke(invoker, inv)
+
+	allocs := testing.AllocsPerRun(1000, func() {
+		f.Invoke(invoker, inv)
+	})
+	assert.Equal(t, float64(0), allocs)
+}
@@ -0,0 +1,143 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package impl
+
+import (
+	"sync"
+	"time"
+)
+
+import (
+	"github.com/apache/dubbo-go/common/extension"
+	"github.com/apache/dubbo-go/filter"
+)
+
+const (
+	FIXED_WINDOW_TPS_LIMIT_STRATEGY   = "fixedWindow"
+	SLIDING_WINDOW_TPS_LIMIT_STRATEGY = "slidingWindow"
+	TOKEN_BUCKET_TPS_LIMIT_STRATEGY   = "tokenBucket"
+
+	// the number of the buckets the interval of the sliding window is divided into
+	slidingWindowBuckets = 10
+)
+
+func init() {
+	extension.SetTpsLimitStrategy(FIXED_WINDOW_TPS_LIMIT_STRATEGY, NewFixedWindowTpsLimitStrategy)
+	extension.SetTpsLimitStrategy(SLIDING_WINDOW_TPS_LIMIT_STRATEGY, NewSlidingWindowTpsLimitStrategy)
+	extension.SetTpsLimitStrategy(TOKEN_BUCKET_TPS_LIMIT_STRATEGY, NewTokenBucketTpsLimitStrategy)
+}
+
+// fixedWindowTpsLimitStrategy allows the rate requests in every interval starting from the first request,
+// the requests may burst to twice the rate around the boundary of the windows.
+type fixedWindowTpsLimitStrategy struct {
+	window   tpsWindow
+	rate     int64
+	interval time.Duration
+}
+
+func NewFixedWindowTpsLimitStrategy(rate int64, interval time.Duration) filter.TpsLimitStrategy {
+	return &fixedWindowTpsLimitStrategy{rate: rate, interval: interval}
+}
+
+func (s *fixedWindowTpsLimitStrategy) IsAllowable() bool {
+	return s.window.allow(s.rate, s.interval)
+}
+
+// slidingWindowTpsLimitStrategy allows the rate requests in any interval, which is tracked by the buckets
+// of a tenth of the interval.
+type slidingWindowTpsLimitStrategy struct {
+	lock   sync.Mutex
+	rate   int64
+	bucket int64 // in nanoseconds
+	counts [slidingWindowBuckets]int64
+	latest int64 // the index of the latest bucket since the epoch
+	total  int64
+}
+
+func NewSlidingWindowTpsLimitStrategy(rate int64, interval time.Duration) filter.TpsLimitStrategy {
+	bucket := int64(interval) / slidingWindowBuckets
+	if bucket <= 0 {
+		bucket = 1
+	}
+	return &slidingWindowTpsLimitStrategy{rate: rate, bucket: bucket}
+}
+
+func (s *slidingWindowTpsLimitStrategy) IsAllowable() bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	current := time.Now().UnixNano() / s.bucket
+	s.slide(current)
+	if s.total >= s.rate {
+		return false
+	}
+	s.counts[current%slidingWindowBuckets]++
+	s.total++
+	return true
+}
+
+// slide drops the counts of the buckets out of the window ending at the bucket @current
+func (s *slidingWindowTpsLimitStrategy) slide(current int64) {
+	if current-s.latest >= slidingWindowBuckets {
+		s.counts = [slidingWindowBuckets]int64{}
+		s.total = 0
+	} else {
+		for i := s.latest + 1; i <= current; i++ {
+			s.total -= s.counts[i%slidingWindowBuckets]
+			s.counts[i%slidingWindowBuckets] = 0
+		}
+	}
+	if current > s.latest {
+		s.latest = current
+	}
+}
+
+// tokenBucketTpsLimitStrategy refills the bucket of the rate tokens at the rate per interval, every request takes
+// a token. It allows the bursts up to the rate while keeping the average rate.
+type tokenBucketTpsLimitStrategy struct {
+	lock     sync.Mutex
+	rate     float64
+	interval float64 // in nanoseconds
+	tokens   float64
+	last     time.Time
+}
+
+func NewTokenBucketTpsLimitStrategy(rate int64, interval time.Duration) filter.TpsLimitStrategy {
+	return &tokenBucketTpsLimitStrategy{rate: float64(rate), interval: float64(interval), tokens: float64(rate), last: time.Now()}
+}
+
+func (s *tokenBucketTpsLimitStrategy) IsAllowable() bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	now := time.Now()
+	if s.interval > 0 {
+		s.tokens += float64(now.Sub(s.last)) * s.rate / s.interval
+	} else {
+		s.tokens = s.rate
+	}
+	if s.tokens > s.rate {
+		s.tokens = s.rate
+	}
+	s.last = now
+	if s.tokens < 1 {
+		return false
+	}
+	s.tokens--
+	return true
+}
@@ -0,0 +1,109 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package impl
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+import (
+	"github.com/apache/dubbo-go/common/extension"
+	"github.com/apache/dubbo-go/filter"
+)
+
+func TestTpsLimitStrategy_Concurrent(t *testing.T) {
+	for _, name := range []string{FIXED_WINDOW_TPS_LIMIT_STRATEGY, SLIDING_WINDOW_TPS_LIMIT_STRATEGY, TOKEN_BUCKET_TPS_LIMIT_STRATEGY} {
+		assert.Equal(t, int64(100), hammer(extension.GetTpsLimitStrategy(name, 100, time.Hour), 50, 100), name)
+	}
+}
+
+// hammer calls the @strategy by @n goroutines @times each, and returns the number of the allowed calls
+func hammer(strategy filter.TpsLimitStrategy, n int, times int) int64 {
+	var (
+		wg      sync.WaitGroup
+		allowed int64
+	)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < times; j++ {
+				if strategy.IsAllowable() {
+					atomic.AddInt64(&allowed, 1)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	return allowed
+}
+
+func TestFixedWindowTpsLimitStrategy_Reset(t *testing.T) {
+	strategy := NewFixedWindowTpsLimitStrategy(2, 200*time.Millisecond)
+	assert.True(t, strategy.IsAllowable())
+	assert.True(t, strategy.IsAllowable())
+	assert.False(t, strategy.IsAllowable())
+
+	time.Sleep(250 * time.Millisecond)
+	assert.True(t, strategy.IsAllowable())
+	assert.True(t, strategy.IsAllowable())
+	assert.False(t, strategy.IsAllowable())
+}
+
+func TestSlidingWindowTpsLimitStrategy_Reset(t *testing.T) {
+	strategy := NewSlidingWindowTpsLimitStrategy(2, 200*time.Millisecond)
+	assert.True(t, strategy.IsAllowable())
+	time.Sleep(150 * time.Millisecond)
+	assert.True(t, strategy.IsAllowable())
+	assert.False(t, strategy.IsAllowable())
+
+	// only the first request slides out of the window
+	time.Sleep(100 * time.Millisecond)
+	assert.True(t, strategy.IsAllowable())
+	assert.False(t, strategy.IsAllowable())
+
+	time.Sleep(250 * time.Millisecond)
+	assert.True(t, strategy.IsAllowable())
+	assert.True(t, strategy.IsAllowable())
+	assert.False(t, strategy.IsAllowable())
+}
+
+func TestTokenBucketTpsLimitStrategy_Refill(t *testing.T) {
+	strategy := NewTokenBucketTpsLimitStrategy(2, 200*time.Millisecond)
+	assert.True(t, strategy.IsAllowable())
+	assert.True(t, strategy.IsAllowable())
+	assert.False(t, strategy.IsAllowable())
+
+	// a token is refilled in a half of the interval
+	time.Sleep(120 * time.Millisecond)
+	assert.True(t, strategy.IsAllowable())
+	assert.False(t, strategy.IsAllowable())
+
+	// the bucket holds the rate tokens at most
+	time.Sleep(500 * time.Millisecond)
+	assert.True(t, strategy.IsAllowable())
+	assert.True(t, strategy.IsAllowable())
+	assert.False(t, strategy.IsAllowable())
+}
@@ -0,0 +1,197 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package impl
+
+import (
+	"strconv"
+	"sync"
+)
+
+import (
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/ext"
+	perrors "github.com/pkg/errors"
+)
+
+import (
+	"github.com/apache/dubbo-go/common"
+	"github.com/apache/dubbo-go/common/constant"
+	"github.com/apache/dubbo-go/common/extension"
+	"github.com/apache/dubbo-go/common/logger"
+	"github.com/apache/dubbo-go/filter"
+	"github.com/apache/dubbo-go/protocol"
+	invocation_impl "github.com/apache/dubbo-go/protocol/invocation"
+)
+
+const (
+	TRACING = "tracing"
+
+	tracingComponent  = "dubbo-go"
+	tracingServiceTag = "service"
+	tracingMethodTag  = "method"
+)
+
+func init() {
+	extension.SetFilter(TRACING, GetTracingFilter)
+}
+
+// TracingFilter traces the invocations by the global tracer of opentracing, e.g. jaeger.
+// On the consumer side, it starts a client span as the child of the span in the context of the invocation,
+// or in its attachments, and injects the span context into the attachments of a copy of the invocation for
+// the provider.
+// On the provider side, it extracts the span context from the attachments and starts a server span as its child,
+// which is carried to the service by the context of the invocation and finished once the service returns.
+type TracingFilter struct {
+	role     common.RoleType
+	prepared bool
+}
+
+func (f *TracingFilter) Prepare(url common.URL, role common.RoleType) {
+	f.role = role
+	f.prepared = true
+}
+
+func (f *TracingFilter) Invoke(invoker protocol.Invoker, invocation protocol.Invocation) protocol.Result {
+	role := f.role
+	if !f.prepared {
+		r, _ := strconv.Atoi(invoker.GetUrl().GetParam(constant.ROLE_KEY, strconv.Itoa(common.CONSUMER)))
+		role = common.RoleType(r)
+	}
+	if role == common.PROVIDER {
+		return f.serve(invoker, invocation)
+	}
+	return f.call(invoker, invocation)
+}
+
+func (f *TracingFilter) OnResponse(result protocol.Result, invoker protocol.Invoker, invocation protocol.Invocation) protocol.Result {
+	return result
+}
+
+// call traces the invocation of the consumer by a client span
+func (f *TracingFilter) call(invoker protocol.Invoker, invocation protocol.Invocation) protocol.Result {
+	tracer := opentracing.GlobalTracer()
+	url := invoker.GetUrl()
+	opts := []opentracing.StartSpanOption{ext.SpanKindRPCClient}
+	if parent := opentracing.SpanFromContext(invocation.Context()); parent != nil {
+		opts = append(opts, opentracing.ChildOf(parent.Context()))
+	} else if parent, err := tracer.Extract(opentracing.TextMap, opentracing.TextMapCarrier(invocation.Attachments())); err == nil {
+		opts = append(opts, opentracing.ChildOf(parent))
+	}
+	span := tracer.StartSpan(operationName(url, invocation), opts...)
+	setSpanTags(span, url, invocation, url.Location)
+
+	finish := finishSpanOnce(span)
+	defer func() {
+		if e := recover(); e != nil {
+			finish(perrors.Errorf("panic: %v", e))
+			panic(e)
+		}
+	}()
+
+	// the span is carried by a copy of the invocation, which is shared by the forked calls and the retries
+	if rpcInvocation, ok := invocation.(*invocation_impl.RPCInvocation); ok {
+		carrier := opentracing.TextMapCarrier{}
+		if err := tracer.Inject(span.Context(), opentracing.TextMap, carrier); err != nil {
+			logger.Warnf("inject the span context of %s error: %v", operationName(url, invocation), err)
+		}
+		attachments := make(map[string]string, len(rpcInvocation.Attachments())+len(carrier))
+		for k, v := range rpcInvocation.Attachments() {
+			attachments[k] = v
+		}
+		for k, v := range carrier {
+			attachments[k] = v
+		}
+		invocation = invocation_impl.NewRPCInvocationWithOptions(invocation_impl.WithMethodName(rpcInvocation.MethodName()),
+			invocation_impl.WithParameterTypes(rpcInvocation.ParameterTypes()), invocation_impl.WithArguments(rpcInvocation.Arguments()),
+			invocation_impl.WithReply(rpcInvocation.Reply()), invocation_impl.WithCallBack(rpcInvocation.CallBack()),
+			invocation_impl.WithInvoker(rpcInvocation.Invoker()), invocation_impl.WithAttachments(attachments),
+			invocation_impl.WithContext(opentracing.ContextWithSpan(rpcInvocation.Context(), span)))
+	}
+	result := invoker.Invoke(invocation)
+	finish(result.Error())
+	return result
+}
+
+// serve traces the invocation of the provider by a server span
+func (f *TracingFilter) serve(invoker protocol.Invoker, invocation protocol.Invocation) protocol.Result {
+	tracer := opentracing.GlobalTracer()
+	url := invoker.GetUrl()
+	// the span is the root one without the span context of the consumer
+	client, _ := tracer.Extract(opentracing.TextMap, opentracing.TextMapCarrier(invocation.Attachments()))
+	span := tracer.StartSpan(operationName(url, invocation), ext.RPCServerOption(client))
+	setSpanTags(span, url, invocation, invocation.AttachmentsByKey(constant.REMOTE_IP_KEY, ""))
+
+	finish := finishSpanOnce(span)
+	defer func() {
+		if e := recover(); e != nil {
+			finish(perrors.Errorf("panic: %v", e))
+			panic(e)
+		}
+	}()
+
+	rpcInvocation, ok := invocation.(*invocation_impl.RPCInvocation)
+	if ok {
+		rpcInvocation.SetContext(opentracing.ContextWithSpan(rpcInvocation.Context(), span))
+	}
+	result := invoker.Invoke(invocation)
+	// the provider calls the service after the filters unless they return the result,
+	// the span is finished by the callback of the invocation once the service returns
+	if !ok || result.Error() != nil || result.Result() != nil {
+		finish(result.Error())
+		return result
+	}
+	callback := rpcInvocation.CallBack()
+	rpcInvocation.SetCallBack(func(result protocol.Result) {
+		if cb, ok := callback.(func(protocol.Result)); ok {
+			cb(result)
+		}
+		finish(result.Error())
+	})
+	return result
+}
+
+func operationName(url common.URL, invocation protocol.Invocation) string {
+	return url.GetParam(constant.INTERFACE_KEY, url.Path) + "/" + invocation.MethodName()
+}
+
+func setSpanTags(span opentracing.Span, url common.URL, invocation protocol.Invocation, remoteAddress string) {
+	ext.Component.Set(span, tracingComponent)
+	span.SetTag(tracingServiceTag, url.GetParam(constant.INTERFACE_KEY, url.Path))
+	span.SetTag(tracingMethodTag, invocation.MethodName())
+	if remoteAddress != "" {
+		ext.PeerAddress.Set(span, remoteAddress)
+	}
+}
+
+// finishSpanOnce returns the func finishing the span with the error flag if @err isn't nil, only once
+func finishSpanOnce(span opentracing.Span) func(err error) {
+	var once sync.Once
+	return func(err error) {
+		once.Do(func() {
+			if err != nil {
+				ext.Error.Set(span, true)
+				span.LogKV("event", "error", "message", err.Error())
+			}
+			span.Finish()
+		})
+	}
+}
+
+func GetTracingFilter() filter.Filter {
+	return &TracingFilter{}
+}
@@ -0,0 +1,164 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package impl
+
+import (
+	"context"
+	"testing"
+)
+
+import (
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/ext"
+	"github.com/opentracing/opentracing-go/mocktracer"
+	"github.com/stretchr/testify/assert"
+)
+
+import (
+	"github.com/apache/dubbo-go/common"
+	"github.com/apache/dubbo-go/protocol"
+	"github.com/apache/dubbo-go/protocol/invocation"
+)
+
+func mockTracer() (*mocktracer.MockTracer, func()) {
+	tracer := mocktracer.New()
+	opentracing.SetGlobalTracer(tracer)
+	return tracer, func() { opentracing.SetGlobalTracer(opentracing.NoopTracer{}) }
+}
+
+func tracingInvoker(t *testing.T) *echoInvoker {
+	url, err := common.NewURL(context.TODO(), "dubbo://192.168.1.1:20000/com.ikurento.user.UserProvider?interface=com.ikurento.user.UserProvider")
+	assert.NoError(t, err)
+	return &echoInvoker{BaseInvoker: *protocol.NewBaseInvoker(url)}
+}
+
+func TestTracingFilter_ConsumerAndProvider(t *testing.T) {
+	tracer, reset := mockTracer()
+	defer reset()
+	invoker := tracingInvoker(t)
+	consumer := GetTracingFilter().(*TracingFilter)
+	consumer.Prepare(invoker.GetUrl(), common.CONSUMER)
+	provider := GetTracingFilter().(*TracingFilter)
+	provider.Prepare(invoker.GetUrl(), common.PROVIDER)
+
+	parent := tracer.StartSpan("parent")
+	inv := invocation.NewRPCInvocationWithOptions(invocation.WithMethodName("GetUser"), invocation.WithArguments([]interface{}{"1"}),
+		invocation.WithContext(opentracing.ContextWithSpan(context.Background(), parent)))
+	recorder := &recordingInvoker{Invoker: invoker}
+	consumer.Invoke(recorder, inv)
+
+	// the provider gets the attachments of the consumer
+	serverInv := invocation.NewRPCInvocation("GetUser", []interface{}{"error"}, recorder.invocations[0].Attachments())
+	provider.Invoke(invoker, serverInv)
+
+	spans := tracer.FinishedSpans()
+	assert.Equal(t, 2, len(spans))
+	client, server := spans[0], spans[1]
+	assert.Equal(t, parent.Context().(mocktracer.MockSpanContext).SpanID, client.ParentID)
+	assert.Equal(t, client.SpanContext.SpanID, server.ParentID)
+	assert.Equal(t, client.SpanContext.TraceID, server.SpanContext.TraceID)
+	assert.Equal(t, "com.ikurento.user.UserProvider/GetUser", client.OperationName)
+	assert.Equal(t, "client", string(client.Tag("span.kind").(ext.SpanKindEnum)))
+	assert.Equal(t, "server", string(server.Tag("span.kind").(ext.SpanKindEnum)))
+	assert.Equal(t, "com.ikurento.user.UserProvider", client.Tag("service"))
+	assert.Equal(t, "GetUser", client.Tag("method"))
+	assert.Equal(t, "192.168.1.1:20000", client.Tag("peer.address"))
+	assert.Nil(t, client.Tag("error"))
+	assert.Equal(t, true, server.Tag("error"))
+	// the service gets the server span by the context of the invocation
+	assert.Equal(t, server, opentracing.SpanFromContext(serverInv.Context()))
+}
+
+func TestTracingFilter_Retries(t *testing.T) {
+	tracer, reset := mockTracer()
+	defer reset()
+	invoker := tracingInvoker(t)
+	consumer := GetTracingFilter()
+
+	// the retries of the invocation are the children of the caller's span rather than the previous attempt's
+	parent := tracer.StartSpan("parent")
+	inv := invocation.NewRPCInvocationWithOptions(invocation.WithMethodName("GetUser"), invocation.WithArguments([]interface{}{"1"}),
+		invocation.WithContext(opentracing.ContextWithSpan(context.Background(), parent)))
+	recorder := &recordingInvoker{Invoker: invoker}
+	consumer.Invoke(recorder, inv)
+	consumer.Invoke(recorder, inv)
+
+	spans := tracer.FinishedSpans()
+	assert.Equal(t, 2, len(spans))
+	for _, span := range spans {
+		assert.Equal(t, parent.Context().(mocktracer.MockSpanContext).SpanID, span.ParentID)
+	}
+	assert.NotEqual(t, recorder.invocations[0].Attachments(), recorder.invocations[1].Attachments())
+	// the invocation of the caller is untouched
+	assert.Empty(t, inv.Attachments())
+	assert.Equal(t, parent, opentracing.SpanFromContext(inv.Context()))
+}
+
+func TestTracingFilter_ProviderCallback(t *testing.T) {
+	tracer, reset := mockTracer()
+	defer reset()
+	invoker := &nilResultInvoker{tracingInvoker(t)}
+	provider := GetTracingFilter().(*TracingFilter)
+	provider.Prepare(invoker.GetUrl(), common.PROVIDER)
+
+	called := false
+	inv := invocation.NewRPCInvocation("GetUser", nil, nil)
+	inv.SetCallBack(func(protocol.Result) { called = true })
+	provider.Invoke(invoker, inv)
+	// the service isn't called yet
+	assert.Equal(t, 0, len(tracer.FinishedSpans()))
+
+	inv.CallBack().(func(protocol.Result))(&protocol.RPCResult{Rest: "ok"})
+	assert.True(t, called)
+	assert.Equal(t, 1, len(tracer.FinishedSpans()))
+	assert.Nil(t, tracer.FinishedSpans()[0].Tag("error"))
+}
+
+func TestTracingFilter_Panic(t *testing.T) {
+	tracer, reset := mockTracer()
+	defer reset()
+	invoker := &panicInvoker{&tracingInvoker(t).BaseInvoker}
+	consumer := GetTracingFilter()
+
+	assert.Panics(t, func() {
+		consumer.Invoke(invoker, invocation.NewRPCInvocation("GetUser", nil, nil))
+	})
+	spans := tracer.FinishedSpans()
+	assert.Equal(t, 1, len(spans))
+	assert.Equal(t, true, spans[0].Tag("error"))
+}
+
+// recordingInvoker records the invocations it receives
+type recordingInvoker struct {
+	protocol.Invoker
+	invocations []protocol.Invocation
+}
+
+func (ri *recordingInvoker) Invoke(invocation protocol.Invocation) protocol.Result {
+	ri.invocations = append(ri.invocations, invocation)
+	return ri.Invoker.Invoke(invocation)
+}
+
+// nilResultInvoker returns the empty result like the exporter of the provider
+type nilResultInvoker struct {
+	*echoInvoker
+}
+
+func (ni *nilResultInvoker) Invoke(invocation protocol.Invocation) protocol.Result {
+	return &protocol.RPCResult{}
+}
@@ -0,0 +1,139 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package prometheus
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+import (
+	"github.com/apache/dubbo-go/common"
+	"github.com/apache/dubbo-go/common/constant"
+	"github.com/apache/dubbo-go/common/extension"
+	"github.com/apache/dubbo-go/common/logger"
+	"github.com/apache/dubbo-go/metrics"
+)
+
+const (
+	PROMETHEUS = "prometheus"
+
+	namespace = "dubbo"
+)
+
+var labelNames = []string{"side", "interface", "method", "version", "group"}
+
+func init() {
+	extension.SetMetricReporter(PROMETHEUS, newPrometheusReporter)
+}
+
+// PrometheusReporter exports the request counts, the error counts and the histograms of the response times of
+// the methods, labeled by the side, interface, method, version and group, on the http endpoint scraped by prometheus
+type PrometheusReporter struct {
+	requests *prometheus.CounterVec
+	errors   *prometheus.CounterVec
+	rt       *prometheus.HistogramVec
+	server   *http.Server
+	// the metrics of the labels, they're created once and shared by the invokers of the same method
+	children sync.Map // labels key -> *methodMetrics
+}
+
+type methodMetrics struct {
+	requests prometheus.Counter
+	errors   prometheus.Counter
+	rt       prometheus.Observer
+}
+
+func newPrometheusReporter(url common.URL) metrics.Reporter {
+	reporter := &PrometheusReporter{
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "requests_total",
+			Help:      "The number of the requests.",
+		}, labelNames),
+		errors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "requests_failed_total",
+			Help:      "The number of the failed requests.",
+		}, labelNames),
+		rt: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "request_duration_seconds",
+			Help:      "The response times of the requests in seconds.",
+			Buckets:   prometheus.DefBuckets,
+		}, labelNames),
+	}
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(reporter.requests, reporter.errors, reporter.rt)
+
+	mux := http.NewServeMux()
+	mux.Handle(url.GetParam(constant.METRICS_PATH_KEY, constant.DEFAULT_METRICS_PATH), promhttp.HandlerFor(registry, promhttp.HandlerOpts{}))
+	reporter.server = &http.Server{
+		Addr:    ":" + url.GetParam(constant.METRICS_PORT_KEY, constant.DEFAULT_METRICS_PORT),
+		Handler: mux,
+	}
+	go func() {
+		if err := reporter.server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			logger.Errorf("the prometheus metrics server on %s failed: %v", reporter.server.Addr, err)
+		}
+	}()
+	return reporter
+}
+
+func (r *PrometheusReporter) Register(url common.URL, role common.RoleType) {
+	for _, methodName := range url.Methods {
+		r.metrics(url, role, methodName)
+	}
+}
+
+func (r *PrometheusReporter) Report(url common.URL, role common.RoleType, methodName string, cost time.Duration, err error) {
+	m := r.metrics(url, role, methodName)
+	m.requests.Inc()
+	if err != nil {
+		m.errors.Inc()
+	}
+	m.rt.Observe(cost.Seconds())
+}
+
+// metrics returns the metrics of the method, so the labels are resolved once rather than on every report
+func (r *PrometheusReporter) metrics(url common.URL, role common.RoleType, methodName string) *methodMetrics {
+	values := []string{
+		role.Role(),
+		url.GetParam(constant.INTERFACE_KEY, url.Path),
+		methodName,
+		url.GetParam(constant.VERSION_KEY, ""),
+		url.GetParam(constant.GROUP_KEY, ""),
+	}
+	key := strings.Join(values, "/")
+	if m, ok := r.children.Load(key); ok {
+		return m.(*methodMetrics)
+	}
+	m, _ := r.children.LoadOrStore(key, &methodMetrics{
+		requests: r.requests.WithLabelValues(values...),
+		errors:   r.errors.WithLabelValues(values...),
+		rt:       r.rt.WithLabelValues(values...),
+	})
+	return m.(*methodMetrics)
+}
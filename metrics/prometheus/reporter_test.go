@@ -0,0 +1,105 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package prometheus
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+)
+
+import (
+	perrors "github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+import (
+	"github.com/apache/dubbo-go/common"
+	"github.com/apache/dubbo-go/common/extension"
+	"github.com/apache/dubbo-go/filter/impl"
+	"github.com/apache/dubbo-go/protocol"
+	"github.com/apache/dubbo-go/protocol/invocation"
+)
+
+// errorInvoker fails the invocations of the method "fail"
+type errorInvoker struct {
+	protocol.BaseInvoker
+}
+
+func (ei *errorInvoker) Invoke(invocation protocol.Invocation) protocol.Result {
+	if invocation.MethodName() == "fail" {
+		return &protocol.RPCResult{Err: perrors.New("fail")}
+	}
+	return &protocol.RPCResult{Rest: "ok"}
+}
+
+func freePort(t *testing.T) int {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	defer l.Close()
+	return l.Addr().(*net.TCPAddr).Port
+}
+
+// scrape gets the metrics from the endpoint, retrying until the server is up
+func scrape(t *testing.T, address string) string {
+	var err error
+	for i := 0; i < 50; i++ {
+		var resp *http.Response
+		if resp, err = http.Get(address); err == nil {
+			defer resp.Body.Close()
+			body, err := ioutil.ReadAll(resp.Body)
+			assert.NoError(t, err)
+			return string(body)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	assert.NoError(t, err)
+	return ""
+}
+
+func TestPrometheusReporter(t *testing.T) {
+	port := strconv.Itoa(freePort(t))
+	url, err := common.NewURL(context.TODO(), "dubbo://127.0.0.1:20000/com.ikurento.user.UserProvider?interface=com.ikurento.user.UserProvider"+
+		"&version=1.0.0&group=test&methods=GetUser,fail,idle&metrics=prometheus&metrics.port="+port+"&metrics.path=/dubbo/metrics")
+	assert.NoError(t, err)
+	// the filter reports to the shared reporter of the extension
+	reporter := extension.GetMetricReporter(PROMETHEUS, url)
+	defer reporter.(*PrometheusReporter).server.Close()
+
+	f := impl.GetMetricsFilter().(*impl.MetricsFilter)
+	f.Prepare(url, common.PROVIDER)
+	invoker := &errorInvoker{BaseInvoker: *protocol.NewBaseInvoker(url)}
+	f.Invoke(invoker, invocation.NewRPCInvocation("GetUser", nil, nil))
+	f.Invoke(invoker, invocation.NewRPCInvocation("GetUser", nil, nil))
+	f.Invoke(invoker, invocation.NewRPCInvocation("fail", nil, nil))
+
+	body := scrape(t, "http://127.0.0.1:"+port+"/dubbo/metrics")
+	labels := `group="test",interface="com.ikurento.user.UserProvider",method="%s",side="provider",version="1.0.0"`
+	assert.Contains(t, body, "dubbo_requests_total{"+fmt.Sprintf(labels, "GetUser")+"} 2")
+	assert.Contains(t, body, "dubbo_requests_failed_total{"+fmt.Sprintf(labels, "GetUser")+"} 0")
+	assert.Contains(t, body, "dubbo_requests_total{"+fmt.Sprintf(labels, "fail")+"} 1")
+	assert.Contains(t, body, "dubbo_requests_failed_total{"+fmt.Sprintf(labels, "fail")+"} 1")
+	assert.Contains(t, body, "dubbo_request_duration_seconds_count{"+fmt.Sprintf(labels, "GetUser")+"} 2")
+	// registered on export, even before the first invocation
+	assert.Contains(t, body, "dubbo_requests_total{"+fmt.Sprintf(labels, "idle")+"} 0")
+}
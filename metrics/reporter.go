@@ -0,0 +1,35 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package metrics
+
+import (
+	"time"
+)
+
+import (
+	"github.com/apache/dubbo-go/common"
+)
+
+// Reporter reports the metrics of the invocations to a monitoring system like prometheus
+type Reporter interface {
+	// Register prepares the metrics of the methods of the invoker of the @url on the @role side,
+	// so they are reported even before the first invocation
+	Register(url common.URL, role common.RoleType)
+	// Report records the invocation of the method on the invoker of the @url, which cost @cost and failed with @err if not nil
+	Report(url common.URL, role common.RoleType, methodName string, cost time.Duration, err error)
+}
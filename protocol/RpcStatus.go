@@ -21,6 +21,7 @@ package protocol
 import (
 	"sync"
 	"sync/atomic"
+	"time"
 )
 
 import (
@@ -31,14 +32,98 @@ var (
 	methodStatistics sync.Map // url -> { methodName : RpcStatus}
 )
 
+// the completed invocations recorded by a status decay by half once they reach the window,
+// so the statistics follow the recent invocations
+const rpcStatusDecayWindow = 1024
+
 type RpcStatus struct {
 	active int32
+
+	statsLock sync.Mutex
+	// the completed invocations, the failed ones and their total elapsed nanoseconds in the decaying window
+	total   int64
+	failed  int64
+	elapsed int64
+	// the total elapsed nanoseconds of the succeeded invocations in the decaying window
+	succeededElapsed int64
+
+	lock sync.Mutex
+	// closed when an invocation ends, to wake up the ones waiting for an active slot
+	released chan struct{}
 }
 
 func (rpc *RpcStatus) GetActive() int32 {
 	return atomic.LoadInt32(&rpc.active)
 }
 
+func (rpc *RpcStatus) GetTotal() int64 {
+	rpc.statsLock.Lock()
+	defer rpc.statsLock.Unlock()
+	return rpc.total
+}
+
+func (rpc *RpcStatus) GetFailed() int64 {
+	rpc.statsLock.Lock()
+	defer rpc.statsLock.Unlock()
+	return rpc.failed
+}
+
+// GetAverageElapsed is the average elapsed time of the completed invocations
+func (rpc *RpcStatus) GetAverageElapsed() time.Duration {
+	rpc.statsLock.Lock()
+	defer rpc.statsLock.Unlock()
+	if rpc.total == 0 {
+		return 0
+	}
+	return time.Duration(rpc.elapsed / rpc.total)
+}
+
+func (rpc *RpcStatus) GetSucceeded() int64 {
+	rpc.statsLock.Lock()
+	defer rpc.statsLock.Unlock()
+	return rpc.total - rpc.failed
+}
+
+// GetSucceededAverageElapsed is the average elapsed time of the succeeded invocations
+func (rpc *RpcStatus) GetSucceededAverageElapsed() time.Duration {
+	rpc.statsLock.Lock()
+	defer rpc.statsLock.Unlock()
+	succeeded := rpc.total - rpc.failed
+	if succeeded <= 0 {
+		return 0
+	}
+	return time.Duration(rpc.succeededElapsed / succeeded)
+}
+
+// GetFailureRate is the rate of the failed invocations in the completed ones
+func (rpc *RpcStatus) GetFailureRate() float64 {
+	rpc.statsLock.Lock()
+	defer rpc.statsLock.Unlock()
+	if rpc.total == 0 {
+		return 0
+	}
+	return float64(rpc.failed) / float64(rpc.total)
+}
+
+// record adds the completed invocation, the recorded ones decay by half once they reach the window
+func (rpc *RpcStatus) record(elapsed time.Duration, succeeded bool) {
+	rpc.statsLock.Lock()
+	defer rpc.statsLock.Unlock()
+	if rpc.total >= rpcStatusDecayWindow {
+		rpc.total /= 2
+		rpc.failed /= 2
+		rpc.elapsed /= 2
+		rpc.succeededElapsed /= 2
+	}
+	rpc.total++
+	rpc.elapsed += int64(elapsed)
+	if succeeded {
+		rpc.succeededElapsed += int64(elapsed)
+	} else {
+		rpc.failed++
+	}
+}
+
 func GetStatus(url common.URL, methodName string) *RpcStatus {
 	identifier := url.Key()
 	methodMap, found := methodStatistics.Load(identifier)
@@ -62,10 +147,52 @@ func BeginCount(url common.URL, methodName string) {
 	beginCount0(GetStatus(url, methodName))
 }
 
+// BeginCountWithLimit begins the invocation if the active ones of the method are under the @limit, or waits
+// for one of them to end until the @timeout. false is returned if the invocation isn't begun.
+func BeginCountWithLimit(url common.URL, methodName string, limit int32, timeout time.Duration) bool {
+	rpcStatus := GetStatus(url, methodName)
+	var deadline <-chan time.Time
+	for {
+		if active := atomic.LoadInt32(&rpcStatus.active); active < limit {
+			if atomic.CompareAndSwapInt32(&rpcStatus.active, active, active+1) {
+				return true
+			}
+			continue
+		}
+
+		rpcStatus.lock.Lock()
+		if rpcStatus.released == nil {
+			rpcStatus.released = make(chan struct{})
+		}
+		released := rpcStatus.released
+		rpcStatus.lock.Unlock()
+		// an invocation may end before the channel is got
+		if atomic.LoadInt32(&rpcStatus.active) < limit {
+			continue
+		}
+
+		if deadline == nil {
+			timer := time.NewTimer(timeout)
+			defer timer.Stop()
+			deadline = timer.C
+		}
+		select {
+		case <-released:
+		case <-deadline:
+			return false
+		}
+	}
+}
+
 func EndCount(url common.URL, methodName string) {
 	endCount0(GetStatus(url, methodName))
 }
 
+// RecordElapsed records the @elapsed time and the result of the completed invocation, for the load balance by score
+func RecordElapsed(url common.URL, methodName string, elapsed time.Duration, succeeded bool) {
+	GetStatus(url, methodName).record(elapsed, succeeded)
+}
+
 // private methods
 func beginCount0(rpcStatus *RpcStatus) {
 	atomic.AddInt32(&rpcStatus.active, 1)
@@ -73,4 +200,11 @@ func beginCount0(rpcStatus *RpcStatus) {
 
 func endCount0(rpcStatus *RpcStatus) {
 	atomic.AddInt32(&rpcStatus.active, -1)
+
+	rpcStatus.lock.Lock()
+	if rpcStatus.released != nil {
+		close(rpcStatus.released)
+		rpcStatus.released = nil
+	}
+	rpcStatus.lock.Unlock()
 }
@@ -0,0 +1,49 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package protocol
+
+import (
+	"testing"
+	"time"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+import (
+	"github.com/apache/dubbo-go/common"
+)
+
+func TestRpcStatus_Decay(t *testing.T) {
+	url := *common.NewURLWithOptions(common.WithPath("com.ikurento.user.DecayProvider"))
+	for i := 0; i < rpcStatusDecayWindow; i++ {
+		RecordElapsed(url, "GetUser", 10*time.Millisecond, false)
+	}
+	status := GetStatus(url, "GetUser")
+	assert.Equal(t, 1.0, status.GetFailureRate())
+
+	// the failures in the past decay by half, the recent successes dominate
+	for i := 0; i < rpcStatusDecayWindow; i++ {
+		RecordElapsed(url, "GetUser", time.Millisecond, true)
+	}
+	assert.Equal(t, int64(rpcStatusDecayWindow), status.GetTotal())
+	assert.True(t, status.GetFailureRate() < 0.5)
+	assert.True(t, status.GetAverageElapsed() < 5*time.Millisecond)
+	assert.Equal(t, time.Millisecond, status.GetSucceededAverageElapsed())
+}
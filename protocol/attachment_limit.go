@@ -0,0 +1,75 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package protocol
+
+import (
+	"sort"
+)
+
+import (
+	perrors "github.com/pkg/errors"
+)
+
+import (
+	"github.com/apache/dubbo-go/common"
+	"github.com/apache/dubbo-go/common/constant"
+	"github.com/apache/dubbo-go/common/logger"
+)
+
+// LimitAttachments applies the attachment.max.size of the @url to the attachments of the @invocation
+// before they are encoded. By the default reject policy an error is returned if they exceed the limit,
+// by the truncate policy the attachments are dropped in the reverse order of their keys until the rest fit.
+func LimitAttachments(url common.URL, invocation Invocation) error {
+	method := invocation.MethodName()
+	maxSize := url.GetMethodParamInt64(method, constant.ATTACHMENT_MAX_SIZE_KEY, 0)
+	if maxSize <= 0 {
+		return nil
+	}
+
+	attachments := invocation.Attachments()
+	size := int64(0)
+	for k, v := range attachments {
+		size += int64(len(k) + len(v))
+	}
+	if size <= maxSize {
+		return nil
+	}
+
+	policy := url.GetMethodParam(method, constant.ATTACHMENT_OVERSIZE_POLICY_KEY,
+		url.GetParam(constant.ATTACHMENT_OVERSIZE_POLICY_KEY, constant.ATTACHMENT_POLICY_REJECT))
+	if policy != constant.ATTACHMENT_POLICY_TRUNCATE {
+		return perrors.Errorf("the attachments of the method %v are %d bytes, exceed the %v %d bytes",
+			method, size, constant.ATTACHMENT_MAX_SIZE_KEY, maxSize)
+	}
+
+	keys := make([]string, 0, len(attachments))
+	for k := range attachments {
+		keys = append(keys, k)
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(keys)))
+	for _, k := range keys {
+		if size <= maxSize {
+			break
+		}
+		size -= int64(len(k) + len(attachments[k]))
+		delete(attachments, k)
+		logger.Warnf("the attachment %v of the method %v is truncated, the attachments exceed the %v %d bytes",
+			k, method, constant.ATTACHMENT_MAX_SIZE_KEY, maxSize)
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package protocol
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+import (
+	"github.com/apache/dubbo-go/common"
+)
+
+// attachmentInvocation is an invocation carrying the attachments only
+type attachmentInvocation struct {
+	Invocation
+	attachments map[string]string
+}
+
+func (inv *attachmentInvocation) MethodName() string {
+	return "GetUser"
+}
+
+func (inv *attachmentInvocation) Attachments() map[string]string {
+	return inv.attachments
+}
+
+func newAttachmentInvocation() *attachmentInvocation {
+	return &attachmentInvocation{attachments: map[string]string{
+		"a": strings.Repeat("x", 9),
+		"b": strings.Repeat("x", 9),
+		"c": strings.Repeat("x", 9),
+	}}
+}
+
+func TestLimitAttachments(t *testing.T) {
+	// unlimited by default
+	url, _ := common.NewURL(context.TODO(), "dubbo://192.168.1.1:20000/com.ikurento.user.UserProvider")
+	inv := newAttachmentInvocation()
+	assert.NoError(t, LimitAttachments(url, inv))
+	assert.Len(t, inv.attachments, 3)
+
+	// within the limit
+	url, _ = common.NewURL(context.TODO(), "dubbo://192.168.1.1:20000/com.ikurento.user.UserProvider?attachment.max.size=30")
+	assert.NoError(t, LimitAttachments(url, inv))
+	assert.Len(t, inv.attachments, 3)
+
+	// rejected by default
+	url, _ = common.NewURL(context.TODO(), "dubbo://192.168.1.1:20000/com.ikurento.user.UserProvider?attachment.max.size=25")
+	err := LimitAttachments(url, inv)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "30 bytes")
+	assert.Len(t, inv.attachments, 3)
+
+	// truncated until the rest fit
+	url, _ = common.NewURL(context.TODO(), "dubbo://192.168.1.1:20000/com.ikurento.user.UserProvider?attachment.max.size=25&"+
+		"attachment.oversize.policy=truncate")
+	assert.NoError(t, LimitAttachments(url, inv))
+	assert.Equal(t, map[string]string{"a": strings.Repeat("x", 9), "b": strings.Repeat("x", 9)}, inv.attachments)
+
+	// the method level config has priority
+	inv = newAttachmentInvocation()
+	url, _ = common.NewURL(context.TODO(), "dubbo://192.168.1.1:20000/com.ikurento.user.UserProvider?attachment.max.size=25&"+
+		"attachment.oversize.policy=reject&methods.GetUser.attachment.max.size=10&methods.GetUser.attachment.oversize.policy=truncate")
+	assert.NoError(t, LimitAttachments(url, inv))
+	assert.Equal(t, map[string]string{"a": strings.Repeat("x", 9)}, inv.attachments)
+}
@@ -0,0 +1,153 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dubbo
+
+import (
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+import (
+	"github.com/apache/dubbo-go/common"
+	"github.com/apache/dubbo-go/common/constant"
+)
+
+const (
+	// the number of the recent latencies kept for each method
+	latencyWindowSize = 200
+	// the p99 of a method is computed again once the number of latencies are added
+	latencyP99Interval = latencyWindowSize / 10
+)
+
+// latencyWindow is a ring of the recent latencies of a method
+type latencyWindow struct {
+	samples  []time.Duration
+	next     int
+	added    int // the latencies added since the p99 is computed
+	p99      time.Duration
+	computed bool
+}
+
+// add the @latency, the samples are returned if the p99 should be computed again
+func (w *latencyWindow) add(latency time.Duration) []time.Duration {
+	if len(w.samples) < latencyWindowSize {
+		w.samples = append(w.samples, latency)
+	} else {
+		w.samples[w.next] = latency
+		w.next = (w.next + 1) % latencyWindowSize
+	}
+	w.added++
+	if len(w.samples) < constant.DEFAULT_ADAPTIVE_TIMEOUT_SAMPLES || (w.computed && w.added < latencyP99Interval) {
+		return nil
+	}
+	w.added = 0
+	samples := make([]time.Duration, len(w.samples))
+	copy(samples, w.samples)
+	return samples
+}
+
+func p99(samples []time.Duration) time.Duration {
+	sort.Slice(samples, func(i, j int) bool { return samples[i] < samples[j] })
+	return samples[(len(samples)*99+99)/100-1]
+}
+
+// adaptiveTimeout tracks the latencies of the methods, and sets the deadline of a call
+// to a multiple of the recent p99 latency of the method, bounded by min and max. The p99 is computed
+// periodically by the recorded latencies, the timed out calls are recorded by their timeouts.
+// the zero value is ready to use.
+type adaptiveTimeout struct {
+	lock    sync.Mutex
+	windows map[string]*latencyWindow
+}
+
+func adaptiveTimeoutFactor(url common.URL, method string) float64 {
+	factor, err := strconv.ParseFloat(url.GetMethodParam(method, constant.ADAPTIVE_TIMEOUT_FACTOR_KEY,
+		url.GetParam(constant.ADAPTIVE_TIMEOUT_FACTOR_KEY, "0")), 64)
+	if err != nil {
+		return 0
+	}
+	return factor
+}
+
+func latencyKey(url common.URL, method string) string {
+	return url.ServiceKey() + "." + method
+}
+
+// timeout returns the deadline of the call of @method. the @fixed timeout is returned if the adaptive
+// timeout is disabled or there are not enough latency samples of the method yet.
+func (a *adaptiveTimeout) timeout(url common.URL, method string, fixed time.Duration) time.Duration {
+	factor := adaptiveTimeoutFactor(url, method)
+	if factor <= 0 {
+		return fixed
+	}
+
+	a.lock.Lock()
+	w, ok := a.windows[latencyKey(url, method)]
+	if !ok || !w.computed {
+		a.lock.Unlock()
+		return fixed
+	}
+	p99 := w.p99
+	a.lock.Unlock()
+
+	min := time.Duration(url.GetMethodParamInt(method, constant.ADAPTIVE_TIMEOUT_MIN_KEY,
+		url.GetParamInt(constant.ADAPTIVE_TIMEOUT_MIN_KEY, constant.DEFAULT_ADAPTIVE_TIMEOUT_MIN))) * time.Millisecond
+	max := time.Duration(url.GetMethodParamInt(method, constant.ADAPTIVE_TIMEOUT_MAX_KEY,
+		url.GetParamInt(constant.ADAPTIVE_TIMEOUT_MAX_KEY, int64(fixed/time.Millisecond)))) * time.Millisecond
+
+	timeout := time.Duration(float64(p99) * factor)
+	if timeout < min {
+		timeout = min
+	}
+	if timeout > max {
+		timeout = max
+	}
+	return timeout
+}
+
+// record adds the latency of a completed call of @method
+func (a *adaptiveTimeout) record(url common.URL, method string, latency time.Duration) {
+	if adaptiveTimeoutFactor(url, method) <= 0 {
+		return
+	}
+
+	key := latencyKey(url, method)
+	a.lock.Lock()
+	if a.windows == nil {
+		a.windows = make(map[string]*latencyWindow)
+	}
+	w, ok := a.windows[key]
+	if !ok {
+		w = &latencyWindow{}
+		a.windows[key] = w
+	}
+	samples := w.add(latency)
+	a.lock.Unlock()
+	if samples == nil {
+		return
+	}
+
+	// sorted out of the lock
+	computed := p99(samples)
+	a.lock.Lock()
+	w.p99 = computed
+	w.computed = true
+	a.lock.Unlock()
+}
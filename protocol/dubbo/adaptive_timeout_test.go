@@ -0,0 +1,101 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dubbo
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+import (
+	"github.com/apache/dubbo-go/common"
+)
+
+func TestAdaptiveTimeout(t *testing.T) {
+	url, err := common.NewURL(context.Background(),
+		"dubbo://127.0.0.1:20000/UserProvider?timeout.adaptive.factor=2&timeout.adaptive.min=50&timeout.adaptive.max=2000")
+	assert.NoError(t, err)
+	fixed := 3 * time.Second
+	var a adaptiveTimeout
+
+	// not enough samples yet
+	for i := 0; i < 10; i++ {
+		a.record(url, "GetUser", 100*time.Millisecond)
+	}
+	assert.Equal(t, fixed, a.timeout(url, "GetUser", fixed))
+
+	// 98 fast calls and 2 slow ones, the p99 is the slow latency
+	a = adaptiveTimeout{}
+	for i := 0; i < 98; i++ {
+		a.record(url, "GetUser", 10*time.Millisecond)
+	}
+	a.record(url, "GetUser", 300*time.Millisecond)
+	a.record(url, "GetUser", 300*time.Millisecond)
+	assert.Equal(t, 600*time.Millisecond, a.timeout(url, "GetUser", fixed))
+
+	// the old latencies leave the window as the method speeds up
+	for i := 0; i < latencyWindowSize; i++ {
+		a.record(url, "GetUser", 100*time.Millisecond)
+	}
+	assert.Equal(t, 200*time.Millisecond, a.timeout(url, "GetUser", fixed))
+
+	// the latencies are tracked per method
+	assert.Equal(t, fixed, a.timeout(url, "GetUser1", fixed))
+
+	// bounded by min and max
+	for i := 0; i < latencyWindowSize; i++ {
+		a.record(url, "GetUser", time.Millisecond)
+		a.record(url, "GetUser1", 5*time.Second)
+	}
+	assert.Equal(t, 50*time.Millisecond, a.timeout(url, "GetUser", fixed))
+	assert.Equal(t, 2*time.Second, a.timeout(url, "GetUser1", fixed))
+}
+
+func TestAdaptiveTimeout_Disabled(t *testing.T) {
+	url, err := common.NewURL(context.Background(), "dubbo://127.0.0.1:20000/UserProvider")
+	assert.NoError(t, err)
+	var a adaptiveTimeout
+	for i := 0; i < 100; i++ {
+		a.record(url, "GetUser", time.Millisecond)
+	}
+	assert.Equal(t, time.Second, a.timeout(url, "GetUser", time.Second))
+}
+
+func TestAdaptiveTimeout_Periodic(t *testing.T) {
+	url, err := common.NewURL(context.Background(), "dubbo://127.0.0.1:20000/UserProvider?timeout.adaptive.factor=2")
+	assert.NoError(t, err)
+	fixed := 3 * time.Second
+	var a adaptiveTimeout
+	for i := 0; i < latencyWindowSize; i++ {
+		a.record(url, "GetUser", 100*time.Millisecond)
+	}
+	assert.Equal(t, 200*time.Millisecond, a.timeout(url, "GetUser", fixed))
+
+	// the p99 is computed again once every interval, e.g. the timed out calls are recorded by the timeout
+	for i := 0; i < latencyP99Interval-1; i++ {
+		a.record(url, "GetUser", time.Second)
+	}
+	assert.Equal(t, 200*time.Millisecond, a.timeout(url, "GetUser", fixed))
+	a.record(url, "GetUser", time.Second)
+	assert.Equal(t, 2*time.Second, a.timeout(url, "GetUser", fixed))
+}
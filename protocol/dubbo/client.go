@@ -18,6 +18,9 @@
 package dubbo
 
 import (
+	"context"
+	"runtime/debug"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -43,6 +46,7 @@ var (
 	errInvalidCodecType  = perrors.New("illegal CodecType")
 	errInvalidAddress    = perrors.New("remote address invalid or empty")
 	errSessionNotExist   = perrors.New("session not exist")
+	errSessionClosed     = perrors.New("session closed before the response")
 	errClientClosed      = perrors.New("client closed")
 	errClientReadTimeout = perrors.New("client read timeout")
 
@@ -126,6 +130,7 @@ type Client struct {
 	conf     ClientConfig
 	pool     *gettyRPCClientPool
 	sequence atomic.Uint64
+	adaptive adaptiveTimeout
 
 	pendingResponses *sync.Map
 }
@@ -152,38 +157,65 @@ func NewClient(opt Options) *Client {
 
 // call one way
 func (c *Client) CallOneway(addr string, svcUrl common.URL, method string, args interface{}) error {
+	return c.CallOnewayContext(context.Background(), addr, svcUrl, method, args)
+}
 
-	return perrors.WithStack(c.call(CT_OneWay, addr, svcUrl, method, args, nil, nil))
+// CallOnewayContext is the same as CallOneway, but the attachments of the @ctx are sent with the call
+func (c *Client) CallOnewayContext(ctx context.Context, addr string, svcUrl common.URL, method string, args interface{}) error {
+	return perrors.WithStack(c.call(ctx, CT_OneWay, addr, svcUrl, method, args, nil, nil))
 }
 
 // if @reply is nil, the transport layer will get the response without notify the invoker.
 func (c *Client) Call(addr string, svcUrl common.URL, method string, args, reply interface{}) error {
+	return c.CallContext(context.Background(), addr, svcUrl, method, args, reply)
+}
+
+// CallContext is the same as Call, but the deadline of the @ctx overrides the configured timeout if it's sooner,
+// and it's propagated to the provider as the timeout of the call.
+func (c *Client) CallContext(ctx context.Context, addr string, svcUrl common.URL, method string, args, reply interface{}) error {
 
 	ct := CT_TwoWay
 	if reply == nil {
 		ct = CT_OneWay
 	}
 
-	return perrors.WithStack(c.call(ct, addr, svcUrl, method, args, reply, nil))
+	return perrors.WithStack(c.call(ctx, ct, addr, svcUrl, method, args, reply, nil))
 }
 
 func (c *Client) AsyncCall(addr string, svcUrl common.URL, method string, args interface{},
 	callback AsyncCallback, reply interface{}) error {
 
-	return perrors.WithStack(c.call(CT_TwoWay, addr, svcUrl, method, args, reply, callback))
+	return c.AsyncCallContext(context.Background(), addr, svcUrl, method, args, callback, reply)
+}
+
+// AsyncCallContext is the same as AsyncCall, but the call is canceled once the @ctx is done, its pending
+// response is dropped and the @callback gets the error of the @ctx. The response arriving later is discarded.
+func (c *Client) AsyncCallContext(ctx context.Context, addr string, svcUrl common.URL, method string, args interface{},
+	callback AsyncCallback, reply interface{}) error {
+
+	return perrors.WithStack(c.call(ctx, CT_TwoWay, addr, svcUrl, method, args, reply, callback))
 }
 
-func (c *Client) call(ct CallType, addr string, svcUrl common.URL, method string,
+func (c *Client) call(ctx context.Context, ct CallType, addr string, svcUrl common.URL, method string,
 	args, reply interface{}, callback AsyncCallback) error {
 
+	timeout, err := deadlineTimeout(ctx, c.adaptive.timeout(svcUrl, method, callTimeout(ctx, c.opts.RequestTimeout)))
+	if err != nil {
+		return err
+	}
+
 	p := &DubboPackage{}
 	p.Service.Path = strings.TrimPrefix(svcUrl.Path, "/")
 	p.Service.Interface = svcUrl.GetParam(constant.INTERFACE_KEY, "")
 	p.Service.Version = svcUrl.GetParam(constant.VERSION_KEY, "")
 	p.Service.Method = method
-	p.Service.Timeout = c.opts.RequestTimeout
+	p.Service.Timeout = timeout
 	p.Header.SerialID = byte(S_Dubbo)
 	p.Body = args
+	// the attachments of the invocation are sent with the arguments, e.g. the span context of the tracing
+	if attachments := callAttachments(ctx); len(attachments) > 0 {
+		p.Body = hessian.NewRequest(args, attachments)
+	}
 
 	var rsp *PendingResponse
 	if ct != CT_OneWay {
@@ -191,16 +223,100 @@ func (c *Client) call(ct CallType, addr string, svcUrl common.URL, method string
 		rsp = NewPendingResponse()
 		rsp.reply = reply
 		rsp.callback = callback
+		rsp.rawFallback, _ = strconv.ParseBool(svcUrl.GetMethodParam(method, constant.DECODE_FALLBACK_RAW_KEY,
+			svcUrl.GetParam(constant.DECODE_FALLBACK_RAW_KEY, "false")))
 	} else {
 		p.Header.Type = hessian.PackageRequest
 	}
 
+	if err := c.checkPayload(p); err != nil {
+		return perrors.WithStack(err)
+	}
+
+	replays := replayRetries(svcUrl, method)
+	for i := 0; ; i++ {
+		err := c.send(ctx, ct, addr, svcUrl, p, rsp)
+		if ct == CT_OneWay || callback != nil || perrors.Cause(err) != errSessionClosed || i >= replays {
+			return err
+		}
+
+		// the connection dropped before the response, replay the idempotent call on a new connection
+		logger.Warnf("the connection to %s dropped during the call of %s.%s, replay it %d/%d", addr, p.Service.Path, method, i+1, replays)
+		replay := NewPendingResponse()
+		replay.reply, replay.rawFallback = rsp.reply, rsp.rawFallback
+		rsp = replay
+	}
+}
+
+type callTimeoutKey struct{}
+
+// withCallTimeout overrides the request timeout of the calls with the @ctx
+func withCallTimeout(ctx context.Context, timeout time.Duration) context.Context {
+	return context.WithValue(ctx, callTimeoutKey{}, timeout)
+}
+
+// callTimeout returns the request timeout overridden by the @ctx, or the @fixed one
+func callTimeout(ctx context.Context, fixed time.Duration) time.Duration {
+	if timeout, ok := ctx.Value(callTimeoutKey{}).(time.Duration); ok && timeout > 0 {
+		return timeout
+	}
+	return fixed
+}
+
+type callAttachmentsKey struct{}
+
+// withCallAttachments sends the @attachments with the calls of the @ctx
+func withCallAttachments(ctx context.Context, attachments map[string]string) context.Context {
+	return context.WithValue(ctx, callAttachmentsKey{}, attachments)
+}
+
+// callAttachments returns a copy of the attachments of the @ctx, the encoder adds the service info to it
+func callAttachments(ctx context.Context) map[string]string {
+	attachments, _ := ctx.Value(callAttachmentsKey{}).(map[string]string)
+	if len(attachments) == 0 {
+		return nil
+	}
+	copied := make(map[string]string, len(attachments))
+	for k, v := range attachments {
+		copied[k] = v
+	}
+	return copied
+}
+
+// deadlineTimeout returns the sooner of the @timeout and the time left before the deadline of the @ctx,
+// an error is returned if the deadline is already exceeded.
+func deadlineTimeout(ctx context.Context, timeout time.Duration) (time.Duration, error) {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return timeout, nil
+	}
+	left := time.Until(deadline)
+	if left <= 0 {
+		return 0, perrors.WithStack(context.DeadlineExceeded)
+	}
+	if left < timeout {
+		return left, nil
+	}
+	return timeout, nil
+}
+
+// contextError returns the error of the done @ctx, its deadline is the timeout of the call
+func contextError(ctx context.Context) error {
+	if ctx.Err() == context.DeadlineExceeded {
+		return errClientReadTimeout
+	}
+	return ctx.Err()
+}
+
+// send writes the package @p to a session to @addr, and waits for the response if it's a two way call without callback.
+// The call is canceled once the @ctx is done.
+func (c *Client) send(ctx context.Context, ct CallType, addr string, svcUrl common.URL, p *DubboPackage, rsp *PendingResponse) error {
 	var (
 		err     error
 		session getty.Session
 		conn    *gettyRPCClient
 	)
-	conn, session, err = c.selectSession(addr)
+	conn, session, err = c.selectSession(addr, svcUrl)
 	if err != nil {
 		return perrors.WithStack(err)
 	}
@@ -209,25 +325,143 @@ func (c *Client) call(ct CallType, addr string, svcUrl common.URL, method string
 	}
 	defer c.pool.release(conn, err)
 
+	start := time.Now()
 	if err = c.transfer(session, p, rsp); err != nil {
 		return perrors.WithStack(err)
 	}
 
-	if ct == CT_OneWay || callback != nil {
+	if ct == CT_OneWay {
+		return nil
+	}
+	if rsp.callback != nil {
+		go c.watchAsync(ctx, p.Service.Timeout, rsp)
 		return nil
 	}
 
 	select {
-	case <-getty.GetTimeWheel().After(c.opts.RequestTimeout):
+	case <-getty.GetTimeWheel().After(p.Service.Timeout):
 		err = errClientReadTimeout
 		c.removePendingResponse(SequenceType(rsp.seq))
+		// the latency of the timed out call is the timeout at least
+		c.adaptive.record(svcUrl, p.Service.Method, p.Service.Timeout)
+	case <-ctx.Done():
+		err = contextError(ctx)
+		c.removePendingResponse(SequenceType(rsp.seq))
+		if ctx.Err() == context.DeadlineExceeded {
+			c.adaptive.record(svcUrl, p.Service.Method, time.Since(start))
+		}
 	case <-rsp.done:
 		err = rsp.err
+		if err != errSessionClosed {
+			c.adaptive.record(svcUrl, p.Service.Method, time.Since(start))
+		}
 	}
 
 	return perrors.WithStack(err)
 }
 
+// watchAsync fails the async call @rsp once the @timeout elapses or the @ctx is done before its response,
+// its pending response is dropped so that it doesn't leak.
+func (c *Client) watchAsync(ctx context.Context, timeout time.Duration, rsp *PendingResponse) {
+	var err error
+	select {
+	case <-rsp.done:
+		return
+	case <-getty.GetTimeWheel().After(timeout):
+		err = errClientReadTimeout
+	case <-ctx.Done():
+		err = contextError(ctx)
+	}
+	if c.removePendingResponse(SequenceType(rsp.seq)) == nil {
+		// the response has just arrived
+		return
+	}
+	rsp.err = perrors.WithStack(err)
+	c.completePendingResponse(rsp)
+}
+
+// completePendingResponse notifies the call waiting for the @rsp, and runs its callback if it's async.
+// A panic of the callback is logged rather than killing the read loop of the session.
+func (c *Client) completePendingResponse(rsp *PendingResponse) {
+	rsp.done <- struct{}{}
+	if rsp.callback == nil {
+		return
+	}
+	defer func() {
+		if e := recover(); e != nil {
+			logger.Errorf("the callback of the async call %d panic: %v\n%s", rsp.seq, e, debug.Stack())
+		}
+	}()
+	rsp.callback(rsp.GetCallResponse())
+}
+
+// replayRetries returns how many times the call of @method is replayed if the connection drops, 0 if it isn't idempotent
+func replayRetries(svcUrl common.URL, method string) int {
+	idempotent, _ := strconv.ParseBool(svcUrl.GetMethodParam(method, constant.IDEMPOTENT_KEY,
+		svcUrl.GetParam(constant.IDEMPOTENT_KEY, "false")))
+	if !idempotent {
+		return 0
+	}
+	return int(svcUrl.GetMethodParamInt64(method, constant.REPLAY_RETRIES_KEY, constant.DEFAULT_REPLAY_RETRIES))
+}
+
+// failPendingResponses fails the calls waiting for the responses from the closed @session
+func (c *Client) failPendingResponses(session getty.Session) {
+	if c.pendingResponses == nil {
+		return
+	}
+	c.pendingResponses.Range(func(key, value interface{}) bool {
+		pendingResponse := value.(*PendingResponse)
+		if pendingResponse.session != session || c.removePendingResponse(key.(SequenceType)) == nil {
+			return true
+		}
+		pendingResponse.err = errSessionClosed
+		c.completePendingResponse(pendingResponse)
+		return true
+	})
+}
+
+// checkPayload returns a descriptive error before sending if the encoded request exceeds the max_msg_len,
+// rather than failing obscurely when the provider reads it. The request is encoded only once, the encoded
+// one is sent.
+func (c *Client) checkPayload(p *DubboPackage) error {
+	maxLen := c.conf.GettySessionParam.MaxMsgLen
+	if maxLen <= 0 {
+		return nil
+	}
+	buf, err := p.Marshal()
+	if err != nil {
+		return perrors.WithStack(err)
+	}
+	if buf.Len() <= maxLen {
+		p.encoded = buf.Bytes()
+		return nil
+	}
+
+	// find out the largest argument
+	var (
+		argIndex = -1
+		argLen   int
+	)
+	body := p.Body
+	if req, ok := body.(*hessian.Request); ok {
+		body = req.Params
+	}
+	if args, ok := body.([]interface{}); ok {
+		for i, arg := range args {
+			encoder := hessian.NewEncoder()
+			if err := encoder.Encode(arg); err != nil {
+				continue
+			}
+			if l := len(encoder.Buffer()); l > argLen {
+				argIndex, argLen = i, l
+			}
+		}
+	}
+	return perrors.Errorf("the request of method %v of service %v is %d bytes, exceeds the max_msg_len %d bytes, "+
+		"the largest argument is argument[%d] of %d bytes", p.Service.Method, p.Service.Path, buf.Len(), maxLen, argIndex, argLen)
+}
+
 func (c *Client) Close() {
 	if c.pool != nil {
 		c.pool.close()
@@ -235,8 +469,15 @@ func (c *Client) Close() {
 	c.pool = nil
 }
 
-func (c *Client) selectSession(addr string) (*gettyRPCClient, getty.Session, error) {
-	rpcClient, err := c.pool.getGettyRpcClient(DUBBO, addr)
+// isAvailable returns false if the initial connection to the @addr failed with all the retries recently
+func (c *Client) isAvailable(addr string) bool {
+	pool := c.pool
+	return pool == nil || pool.isAvailable(addr)
+}
+
+func (c *Client) selectSession(addr string, svcUrl common.URL) (*gettyRPCClient, getty.Session, error) {
+	rpcClient, err := c.pool.getGettyRpcClient(DUBBO, addr, newConnectBackoff(svcUrl),
+		newTCPOptions(svcUrl, c.conf.GettySessionParam))
 	if err != nil {
 		return nil, nil, perrors.WithStack(err)
 	}
@@ -268,6 +509,7 @@ func (c *Client) transfer(session getty.Session, pkg *DubboPackage,
 	// cond1
 	if rsp != nil {
 		rsp.seq = sequence
+		rsp.session = session
 		c.addPendingResponse(rsp)
 	}
 
@@ -293,7 +535,10 @@ func (c *Client) removePendingResponse(seq SequenceType) *PendingResponse {
 	}
 	if presp, ok := c.pendingResponses.Load(seq); ok {
 		c.pendingResponses.Delete(seq)
-		return presp.(*PendingResponse)
+		// it may be removed concurrently, e.g. by the response and by the cancellation of the call
+		if pr := presp.(*PendingResponse); pr.claimed.CAS(false, true) {
+			return pr
+		}
 	}
 	return nil
 }
@@ -20,6 +20,11 @@ package dubbo
 import (
 	"bytes"
 	"context"
+	"fmt"
+	"io"
+	"net"
+	"runtime"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -29,10 +34,12 @@ import (
 	hessian "github.com/apache/dubbo-go-hessian2"
 	perrors "github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
+	"go.uber.org/atomic"
 )
 
 import (
 	"github.com/apache/dubbo-go/common"
+	"github.com/apache/dubbo-go/common/constant"
 	"github.com/apache/dubbo-go/protocol"
 )
 
@@ -118,6 +125,65 @@ func TestClient_Call(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, User{Id: "1", Name: ""}, *user)
 
+	// the nil arguments are received as nil, or the zero values of the non-pointer types
+	user = &User{}
+	err = c.Call("127.0.0.1:20000", url, "GetUser7", []interface{}{nil, nil}, user)
+	assert.NoError(t, err)
+	assert.Equal(t, User{Id: "", Name: "nil"}, *user)
+
+	user = &User{}
+	err = c.Call("127.0.0.1:20000", url, "GetUser7", []interface{}{"1", &User{Name: "username"}}, user)
+	assert.NoError(t, err)
+	assert.Equal(t, User{Id: "1", Name: "username"}, *user)
+
+	// destroy
+	proto.Destroy()
+}
+
+func TestClient_GenericCall(t *testing.T) {
+	proto, url := InitTest(t)
+
+	c := &Client{
+		pendingResponses: new(sync.Map),
+		conf:             *clientConf,
+		opts: Options{
+			ConnectTimeout: 3e9,
+			RequestTimeout: 10e9,
+		},
+	}
+	c.pool = newGettyRPCClientConnPool(c, clientConf.PoolSize, time.Duration(int(time.Second)*clientConf.PoolTTL))
+
+	// the nested structs and slices are generalized to maps, and realized by the provider
+	group := &UserGroup{
+		Name:    "group",
+		Owner:   &User{Id: "1", Name: "owner"},
+		Members: []User{{Id: "2", Name: "member"}, {Id: "3"}},
+	}
+	rsp := map[interface{}]interface{}{}
+	err := c.Call("127.0.0.1:20000", url, constant.GENERIC, []interface{}{"GetUser8", []string{"com.ikurento.user.UserGroup"},
+		[]interface{}{common.Generalize(group)}}, &rsp)
+	assert.NoError(t, err)
+	assert.Equal(t, "group", rsp["name"])
+	assert.Equal(t, "owner", rsp["owner"].(map[interface{}]interface{})["name"])
+	members := rsp["members"].([]interface{})
+	assert.Equal(t, 2, len(members))
+	assert.Equal(t, "member", members[0].(map[interface{}]interface{})["name"])
+	assert.Equal(t, "3", members[1].(map[interface{}]interface{})["id"])
+
+	// the nil arguments are kept
+	rsp = map[interface{}]interface{}{}
+	err = c.Call("127.0.0.1:20000", url, constant.GENERIC, []interface{}{"GetUser7", []string{"java.lang.String", "com.ikurento.user.User"},
+		[]interface{}{"1", nil}}, &rsp)
+	assert.NoError(t, err)
+	assert.Equal(t, "1", rsp["id"])
+	assert.Equal(t, "nil", rsp["name"])
+
+	rsp = map[interface{}]interface{}{}
+	err = c.Call("127.0.0.1:20000", url, constant.GENERIC, []interface{}{"GetUser7", []string{"java.lang.String", "com.ikurento.user.User"},
+		[]interface{}{"1", map[string]interface{}{"name": "username"}}}, &rsp)
+	assert.NoError(t, err)
+	assert.Equal(t, "username", rsp["name"])
+
 	// destroy
 	proto.Destroy()
 }
@@ -151,13 +217,430 @@ func TestClient_AsyncCall(t *testing.T) {
 	lock.Unlock()
 }
 
+func TestClient_AsyncCallContext(t *testing.T) {
+	proto, url := InitTest(t)
+	defer proto.Destroy()
+
+	c := &Client{
+		pendingResponses: new(sync.Map),
+		conf:             *clientConf,
+		opts: Options{
+			ConnectTimeout: 3e9,
+			RequestTimeout: 6e9,
+		},
+	}
+	c.pool = newGettyRPCClientConnPool(c, clientConf.PoolSize, time.Duration(int(time.Second)*clientConf.PoolTTL))
+	defer c.Close()
+
+	// the canceled call gets the error of the context, and its late response is discarded
+	ctx, cancel := context.WithCancel(context.Background())
+	responses := make(chan CallResponse, 2)
+	err := c.AsyncCallContext(ctx, "127.0.0.1:20000", url, "GetSlowUser", []interface{}{"1", "username"}, func(response CallResponse) {
+		responses <- response
+	}, &User{})
+	assert.NoError(t, err)
+	cancel()
+	select {
+	case response := <-responses:
+		assert.Equal(t, context.Canceled, perrors.Cause(response.Cause))
+	case <-time.After(time.Second):
+		assert.Fail(t, "the canceled call isn't called back")
+	}
+	assert.Equal(t, 0, pendingCount(c))
+	time.Sleep(600 * time.Millisecond)
+	assert.Len(t, responses, 0)
+
+	// the timed out call
+	ctx, cancel = context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	err = c.AsyncCallContext(ctx, "127.0.0.1:20000", url, "GetSlowUser", []interface{}{"1", "username"}, func(response CallResponse) {
+		responses <- response
+	}, &User{})
+	assert.NoError(t, err)
+	response := <-responses
+	assert.Equal(t, errClientReadTimeout, perrors.Cause(response.Cause))
+	assert.Equal(t, 0, pendingCount(c))
+
+	// the panic of the callback doesn't kill the read loop
+	done := make(chan struct{})
+	err = c.AsyncCall("127.0.0.1:20000", url, "GetUser", []interface{}{"1", "username"}, func(response CallResponse) {
+		close(done)
+		panic("callback")
+	}, &User{})
+	assert.NoError(t, err)
+	<-done
+	user := &User{}
+	err = c.Call("127.0.0.1:20000", url, "GetUser", []interface{}{"1", "username"}, user)
+	assert.NoError(t, err)
+	assert.Equal(t, User{Id: "1", Name: "username"}, *user)
+}
+
+func TestClient_AsyncCallNoLeak(t *testing.T) {
+	proto, url := InitTest(t)
+	defer proto.Destroy()
+
+	c := &Client{
+		pendingResponses: new(sync.Map),
+		conf:             *clientConf,
+		opts: Options{
+			ConnectTimeout: 3e9,
+			RequestTimeout: 6e9,
+		},
+	}
+	c.pool = newGettyRPCClientConnPool(c, clientConf.PoolSize, time.Duration(int(time.Second)*clientConf.PoolTTL))
+	defer c.Close()
+
+	// warm up the connections
+	assert.NoError(t, c.Call("127.0.0.1:20000", url, "GetUser", []interface{}{"1", "username"}, &User{}))
+	goroutines := runtime.NumGoroutine()
+
+	const calls = 10000
+	var (
+		wg       sync.WaitGroup
+		failures atomic.Int32
+	)
+	wg.Add(calls)
+	for i := 0; i < calls; i++ {
+		id := fmt.Sprintf("%d", i)
+		err := c.AsyncCall("127.0.0.1:20000", url, "GetUser", []interface{}{id, "username"}, func(response CallResponse) {
+			defer wg.Done()
+			if response.Cause != nil || response.Reply.(*User).Id != id {
+				failures.Inc()
+			}
+		}, &User{})
+		if !assert.NoError(t, err) {
+			wg.Done()
+		}
+	}
+	wg.Wait()
+
+	assert.Equal(t, int32(0), failures.Load())
+	assert.Equal(t, 0, pendingCount(c))
+	// the watchers of the calls exit with their responses
+	time.Sleep(100 * time.Millisecond)
+	assert.True(t, runtime.NumGoroutine() <= goroutines+10, "goroutines %d, before %d", runtime.NumGoroutine(), goroutines)
+}
+
+func pendingCount(c *Client) int {
+	count := 0
+	c.pendingResponses.Range(func(key, value interface{}) bool {
+		count++
+		return true
+	})
+	return count
+}
+
+func TestClient_CallPayloadTooLarge(t *testing.T) {
+	c := &Client{
+		pendingResponses: new(sync.Map),
+		conf: ClientConfig{
+			GettySessionParam: GettySessionParam{
+				MaxMsgLen: 1024,
+			},
+		},
+		opts: Options{
+			ConnectTimeout: 3e9,
+			RequestTimeout: 6e9,
+		},
+	}
+	url, err := common.NewURL(context.Background(), "dubbo://127.0.0.1:20000/UserProvider")
+	assert.NoError(t, err)
+
+	// the request is refused before connecting to the provider
+	user := &User{}
+	err = c.Call("127.0.0.1:20000", url, "GetUser", []interface{}{"1", strings.Repeat("username", 1024)}, user)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "GetUser")
+	assert.Contains(t, err.Error(), "argument[1]")
+}
+
+// newConnectClient returns a client whose connection attempt times out in 300ms
+func newConnectClient(t *testing.T) *Client {
+	conf := ClientConfig{
+		ConnectionNum:   1,
+		HeartbeatPeriod: "5s",
+		SessionTimeout:  "20s",
+		PoolTTL:         600,
+		PoolSize:        64,
+		GettySessionParam: GettySessionParam{
+			TcpNoDelay:      true,
+			TcpKeepAlive:    true,
+			KeepAlivePeriod: "120s",
+			TcpRBufSize:     262144,
+			TcpWBufSize:     65536,
+			PkgWQSize:       512,
+			TcpReadTimeout:  "4s",
+			TcpWriteTimeout: "5s",
+			WaitTimeout:     "1s",
+			MaxMsgLen:       1024,
+			SessionName:     "client",
+		},
+	}
+	assert.NoError(t, conf.CheckValidity())
+	c := &Client{
+		pendingResponses: new(sync.Map),
+		conf:             conf,
+		opts: Options{
+			ConnectTimeout: 3e8,
+			RequestTimeout: 6e9,
+		},
+	}
+	c.pool = newGettyRPCClientConnPool(c, conf.PoolSize, time.Duration(int(time.Second)*conf.PoolTTL))
+	return c
+}
+
+func TestClient_ConnectRetry(t *testing.T) {
+	c := newConnectClient(t)
+	defer c.Close()
+
+	// reserve a free port for the provider
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	addr := listener.Addr().String()
+	assert.NoError(t, listener.Close())
+
+	// fail immediately without retry
+	url, err := common.NewURL(context.Background(), "dubbo://"+addr+"/UserProvider")
+	assert.NoError(t, err)
+	_, _, err = c.selectSession(addr, url)
+	assert.Error(t, err)
+
+	// the provider accepts only after the first dial attempt failed
+	var (
+		lock     sync.Mutex
+		provider net.Listener
+	)
+	go func() {
+		time.Sleep(5e8)
+		l, err := net.Listen("tcp", addr)
+		if err != nil {
+			return
+		}
+		lock.Lock()
+		provider = l
+		lock.Unlock()
+		for {
+			if _, err := l.Accept(); err != nil {
+				return
+			}
+		}
+	}()
+	url, err = common.NewURL(context.Background(), "dubbo://"+addr+"/UserProvider?connect.retries=3&connect.backoff=400")
+	assert.NoError(t, err)
+	conn, session, err := c.selectSession(addr, url)
+	assert.NoError(t, err)
+	assert.NotNil(t, session)
+	c.pool.release(conn, nil)
+	// the later connections aren't retried
+	assert.True(t, c.pool.isConnected(addr))
+
+	lock.Lock()
+	assert.NoError(t, provider.Close())
+	lock.Unlock()
+}
+
+func TestClient_ConnectRetriesExhausted(t *testing.T) {
+	c := newConnectClient(t)
+	defer c.Close()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	addr := listener.Addr().String()
+	assert.NoError(t, listener.Close())
+
+	// the provider is unavailable for the max backoff after all the retries fail
+	url, err := common.NewURL(context.Background(), "dubbo://"+addr+"/UserProvider?connect.retries=1&connect.backoff=10&connect.max.backoff=500")
+	assert.NoError(t, err)
+	invoker := NewDubboInvoker(url, c)
+	assert.True(t, invoker.IsAvailable())
+	_, _, err = c.selectSession(addr, url)
+	assert.Error(t, err)
+	assert.False(t, invoker.IsAvailable())
+
+	time.Sleep(600 * time.Millisecond)
+	assert.True(t, invoker.IsAvailable())
+}
+
+// dropProxy forwards the connections to the target, and drops the connection carrying the next request once armed,
+// as if the connection broke during the call
+type dropProxy struct {
+	net.Listener
+	target string
+	armed  atomic.Bool
+}
+
+func newDropProxy(t *testing.T, target string) *dropProxy {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	p := &dropProxy{Listener: l, target: target}
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go p.forward(conn)
+		}
+	}()
+	return p
+}
+
+func (p *dropProxy) forward(conn net.Conn) {
+	defer conn.Close()
+	upstream, err := net.Dial("tcp", p.target)
+	if err != nil {
+		return
+	}
+	defer upstream.Close()
+	go func() {
+		io.Copy(conn, upstream)
+		conn.Close()
+	}()
+
+	buf := make([]byte, 64*1024)
+	for {
+		n, err := conn.Read(buf)
+		if err != nil || p.armed.CAS(true, false) {
+			return
+		}
+		if _, err = upstream.Write(buf[:n]); err != nil {
+			return
+		}
+	}
+}
+
+func TestClient_CallReplay(t *testing.T) {
+	proto, _ := InitTest(t)
+	defer proto.Destroy()
+	proxy := newDropProxy(t, "127.0.0.1:20000")
+	defer proxy.Close()
+	addr := proxy.Addr().String()
+
+	c := &Client{
+		pendingResponses: new(sync.Map),
+		conf:             *clientConf,
+		opts: Options{
+			ConnectTimeout: 3e9,
+			RequestTimeout: 6e9,
+		},
+	}
+	c.pool = newGettyRPCClientConnPool(c, clientConf.PoolSize, time.Duration(int(time.Second)*clientConf.PoolTTL))
+	defer c.Close()
+
+	// the call fails once the connection drops rather than waiting for the timeout
+	url, err := common.NewURL(context.Background(), "dubbo://"+addr+"/UserProvider?interface=com.ikurento.user.UserProvider")
+	assert.NoError(t, err)
+	proxy.armed.Store(true)
+	start := time.Now()
+	err = c.Call(addr, url, "GetUser", []interface{}{"1", "username"}, &User{})
+	assert.Equal(t, errSessionClosed, perrors.Cause(err))
+	assert.True(t, time.Since(start) < c.opts.RequestTimeout)
+
+	// the idempotent call is replayed on a new connection
+	url, err = common.NewURL(context.Background(), "dubbo://"+addr+"/UserProvider?interface=com.ikurento.user.UserProvider&idempotent=true")
+	assert.NoError(t, err)
+	proxy.armed.Store(true)
+	user := &User{}
+	err = c.Call(addr, url, "GetUser", []interface{}{"1", "username"}, user)
+	assert.NoError(t, err)
+	assert.Equal(t, User{Id: "1", Name: "username"}, *user)
+	assert.False(t, proxy.armed.Load())
+}
+
+func TestClient_CallContext(t *testing.T) {
+	proto, url := InitTest(t)
+	defer proto.Destroy()
+
+	c := &Client{
+		pendingResponses: new(sync.Map),
+		conf:             *clientConf,
+		opts: Options{
+			ConnectTimeout: 3e9,
+			RequestTimeout: 6e9,
+		},
+	}
+	c.pool = newGettyRPCClientConnPool(c, clientConf.PoolSize, time.Duration(int(time.Second)*clientConf.PoolTTL))
+	defer c.Close()
+
+	// the deadline of the context is sooner than the configured timeout
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	err := c.CallContext(ctx, "127.0.0.1:20000", url, "GetSlowUser", []interface{}{"1", "username"}, &User{})
+	assert.Equal(t, errClientReadTimeout, perrors.Cause(err))
+	assert.True(t, time.Since(start) < 400*time.Millisecond)
+
+	// the deadline is exceeded before the call
+	<-ctx.Done()
+	err = c.CallContext(ctx, "127.0.0.1:20000", url, "GetUser", []interface{}{"1", "username"}, &User{})
+	assert.Equal(t, context.DeadlineExceeded, perrors.Cause(err))
+
+	// the deadline of the context is clamped by the configured timeout
+	c.opts.RequestTimeout = 100 * time.Millisecond
+	ctx, cancel = context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	start = time.Now()
+	err = c.CallContext(ctx, "127.0.0.1:20000", url, "GetSlowUser", []interface{}{"1", "username"}, &User{})
+	assert.Equal(t, errClientReadTimeout, perrors.Cause(err))
+	assert.True(t, time.Since(start) < 400*time.Millisecond)
+
+	c.opts.RequestTimeout = 6e9
+	user := &User{}
+	err = c.CallContext(ctx, "127.0.0.1:20000", url, "GetUser", []interface{}{"1", "username"}, user)
+	assert.NoError(t, err)
+	assert.Equal(t, User{Id: "1", Name: "username"}, *user)
+}
+
+func TestDeadlineTimeout(t *testing.T) {
+	timeout, err := deadlineTimeout(context.Background(), time.Second)
+	assert.NoError(t, err)
+	assert.Equal(t, time.Second, timeout)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	timeout, err = deadlineTimeout(ctx, time.Second)
+	assert.NoError(t, err)
+	assert.True(t, timeout > 0 && timeout <= 100*time.Millisecond)
+
+	ctx, cancel = context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	timeout, err = deadlineTimeout(ctx, time.Second)
+	assert.NoError(t, err)
+	assert.Equal(t, time.Second, timeout)
+
+	ctx, cancel = context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancel()
+	_, err = deadlineTimeout(ctx, time.Second)
+	assert.Equal(t, context.DeadlineExceeded, perrors.Cause(err))
+}
+
+func TestCallTimeout(t *testing.T) {
+	assert.Equal(t, time.Second, callTimeout(context.Background(), time.Second))
+	ctx := withCallTimeout(context.Background(), 100*time.Millisecond)
+	assert.Equal(t, 100*time.Millisecond, callTimeout(ctx, time.Second))
+	// the deadline of the caller is still honored
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	timeout, err := deadlineTimeout(ctx, callTimeout(ctx, time.Second))
+	assert.NoError(t, err)
+	assert.True(t, timeout <= 10*time.Millisecond)
+}
+
+func TestConnectBackoff_Delay(t *testing.T) {
+	backoff := connectBackoff{retries: 5, backoff: 100 * time.Millisecond, maxBackoff: 300 * time.Millisecond}
+	assert.Equal(t, 100*time.Millisecond, backoff.delay(1))
+	assert.Equal(t, 200*time.Millisecond, backoff.delay(2))
+	assert.Equal(t, 300*time.Millisecond, backoff.delay(3))
+	assert.Equal(t, 300*time.Millisecond, backoff.delay(5))
+}
+
 func InitTest(t *testing.T) (protocol.Protocol, common.URL) {
 
 	hessian.RegisterPOJO(&User{})
 
 	methods, err := common.ServiceMap.Register("dubbo", &UserProvider{})
 	assert.NoError(t, err)
-	assert.Equal(t, "GetBigPkg,GetUser,GetUser0,GetUser1,GetUser2,GetUser3,GetUser4,GetUser5,GetUser6", methods)
+	assert.Equal(t, "GetBigPkg,GetSlowUser,GetUser,GetUser0,GetUser1,GetUser2,GetUser3,GetUser4,GetUser5,GetUser6,GetUser7,GetUser8,GetUser9", methods)
 
 	// config
 	SetClientConf(ClientConfig{
@@ -249,6 +732,13 @@ func (u *UserProvider) GetUser(ctx context.Context, req []interface{}, rsp *User
 	return nil
 }
 
+func (u *UserProvider) GetSlowUser(ctx context.Context, req []interface{}, rsp *User) error {
+	time.Sleep(500 * time.Millisecond)
+	rsp.Id = req[0].(string)
+	rsp.Name = req[1].(string)
+	return nil
+}
+
 func (u *UserProvider) GetUser0(id string, k *User, name string) (User, error) {
 	return User{Id: id, Name: name}, nil
 }
@@ -282,10 +772,54 @@ func (u *UserProvider) GetUser6(id int64) (*User, error) {
 	return &User{Id: "1"}, nil
 }
 
+func (u *UserProvider) GetUser7(id string, user *User) (*User, error) {
+	rsp := &User{Id: id, Name: "nil"}
+	if user != nil {
+		rsp.Name = user.Name
+	}
+	return rsp, nil
+}
+
+func (u *UserProvider) GetUser8(group *UserGroup) (*UserGroup, error) {
+	return group, nil
+}
+
+// onStreamRead observes the size of every read of the stream by GetUser9
+var onStreamRead = func(n int) {}
+
+// GetUser9 reads the stream, and returns its size and the sum of its bytes as the name
+func (u *UserProvider) GetUser9(id string, data io.Reader) (*User, error) {
+	buf := make([]byte, 64*1024)
+	var size, sum int64
+	for {
+		n, err := data.Read(buf)
+		if n > 0 {
+			onStreamRead(n)
+		}
+		for _, b := range buf[:n] {
+			sum += int64(b)
+		}
+		size += int64(n)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &User{Id: id, Name: fmt.Sprintf("%d:%d", size, sum)}, nil
+}
+
 func (u *UserProvider) Reference() string {
 	return "UserProvider"
 }
 
+type UserGroup struct {
+	Name    string
+	Owner   *User
+	Members []User
+}
+
 func (u User) JavaClassName() string {
 	return "com.ikurento.user.User"
 }
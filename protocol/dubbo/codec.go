@@ -20,13 +20,16 @@ package dubbo
 import (
 	"bufio"
 	"bytes"
+	"encoding/binary"
 	"fmt"
 	"time"
 )
 
 import (
 	"github.com/apache/dubbo-go-hessian2"
+	"github.com/dubbogo/getty"
 	perrors "github.com/pkg/errors"
+	"go.uber.org/atomic"
 )
 
 // serial ID
@@ -56,6 +59,9 @@ type DubboPackage struct {
 	Service hessian.Service
 	Body    interface{}
 	Err     error
+
+	// the request encoded in advance, it's sent with the id of the header patched
+	encoded []byte
 }
 
 func (p DubboPackage) String() string {
@@ -63,6 +69,11 @@ func (p DubboPackage) String() string {
 }
 
 func (p *DubboPackage) Marshal() (*bytes.Buffer, error) {
+	if p.encoded != nil {
+		binary.BigEndian.PutUint64(p.encoded[4:hessian.HEADER_LENGTH-4], uint64(p.Header.ID))
+		return bytes.NewBuffer(p.encoded), nil
+	}
+
 	codec := hessian.NewHessianCodec(nil)
 
 	pkg, err := codec.Write(p.Service, p.Header, p.Body)
@@ -74,6 +85,11 @@ func (p *DubboPackage) Marshal() (*bytes.Buffer, error) {
 }
 
 func (p *DubboPackage) Unmarshal(buf *bytes.Buffer, opts ...interface{}) error {
+	data := buf.Bytes()
+	// the reader of the codec is 16 bytes at least, so the partial header would be read as EOF
+	if len(data) < hessian.HEADER_LENGTH {
+		return perrors.WithStack(hessian.ErrHeaderNotEnough)
+	}
 	codec := hessian.NewHessianCodec(bufio.NewReaderSize(buf, buf.Len()))
 
 	// read header
@@ -82,6 +98,7 @@ func (p *DubboPackage) Unmarshal(buf *bytes.Buffer, opts ...interface{}) error {
 		return perrors.WithStack(err)
 	}
 
+	var rsp *PendingResponse
 	if len(opts) != 0 { // for client
 		client, ok := opts[0].(*Client)
 		if !ok {
@@ -90,15 +107,51 @@ func (p *DubboPackage) Unmarshal(buf *bytes.Buffer, opts ...interface{}) error {
 
 		pendingRsp, ok := client.pendingResponses.Load(SequenceType(p.Header.ID))
 		if !ok {
-			return perrors.Errorf("client.GetPendingResponse(%v) = nil", p.Header.ID)
+			// the call is canceled or timed out, the late response is decoded to be discarded
+			// rather than failing the session
+			p.Body = &hessian.Response{RspObj: new(interface{})}
 		} else {
-			p.Body = &hessian.Response{RspObj: pendingRsp.(*PendingResponse).reply}
+			rsp = pendingRsp.(*PendingResponse)
+			p.Body = &hessian.Response{RspObj: rsp.reply}
 		}
 	}
 
 	// read body
-	err = codec.ReadBody(p.Body)
-	return perrors.WithStack(err)
+	if rsp == nil || !rsp.rawFallback {
+		err = codec.ReadBody(p.Body)
+		return perrors.WithStack(err)
+	}
+
+	err = readBodySafely(codec, p.Body)
+	if err == nil || perrors.Cause(err) == hessian.ErrBodyNotEnough || len(data) < hessian.HEADER_LENGTH+p.Header.BodyLen {
+		return perrors.WithStack(err)
+	}
+	raw := make([]byte, p.Header.BodyLen)
+	copy(raw, data[hessian.HEADER_LENGTH:])
+	p.Body = &hessian.Response{}
+	p.Err = &DecodeFallbackError{Raw: raw, Cause: err}
+	return nil
+}
+
+// readBodySafely reads the body and turns the panic of decoding into the mismatched type into an error
+func readBodySafely(codec *hessian.HessianCodec, body interface{}) (err error) {
+	defer func() {
+		if e := recover(); e != nil {
+			err = perrors.Errorf("decode body panic: %v", e)
+		}
+	}()
+	return codec.ReadBody(body)
+}
+
+// DecodeFallbackError is returned for the method of decode.fallback.raw when the response can't be decoded
+// into the declared type, the raw serialized body of the response is kept.
+type DecodeFallbackError struct {
+	Raw   []byte
+	Cause error
+}
+
+func (e *DecodeFallbackError) Error() string {
+	return fmt.Sprintf("failed to decode the response, fall back to the raw body of %d bytes: %v", len(e.Raw), e.Cause)
 }
 
 ////////////////////////////////////////////
@@ -112,13 +165,20 @@ type PendingResponse struct {
 	readStart time.Time
 	callback  AsyncCallback
 	reply     interface{}
-	done      chan struct{}
+	// fall back to the raw body if the response can't be decoded into the reply
+	rawFallback bool
+	// the session the request is written to
+	session getty.Session
+	done    chan struct{}
+	// set by the one who removes it from the pending responses, so that it's completed only once
+	claimed atomic.Bool
 }
 
 func NewPendingResponse() *PendingResponse {
 	return &PendingResponse{
 		start: time.Now(),
-		done:  make(chan struct{}),
+		// buffered, so that the response doesn't block if the call has timed out meanwhile
+		done: make(chan struct{}, 1),
 	}
 }
 
@@ -18,6 +18,7 @@
 package dubbo
 
 import (
+	"sync"
 	"testing"
 	"time"
 )
@@ -72,3 +73,56 @@ func TestDubboPackage_MarshalAndUnmarshal(t *testing.T) {
 	assert.Equal(t, []interface{}{"a"}, pkgres.Body.([]interface{})[5])
 	assert.Equal(t, map[interface{}]interface{}{"group": "", "interface": "Service", "path": "path", "timeout": "1000"}, pkgres.Body.([]interface{})[6])
 }
+
+func TestDubboPackage_MarshalEncoded(t *testing.T) {
+	pkg := &DubboPackage{}
+	pkg.Body = []interface{}{"a"}
+	pkg.Header.Type = hessian.PackageRequest
+	pkg.Header.SerialID = byte(S_Dubbo)
+	pkg.Service.Path = "path"
+	pkg.Service.Method = "Method"
+	data, err := pkg.Marshal()
+	assert.NoError(t, err)
+
+	// the encoded request is sent with the id assigned later
+	pkg.encoded = data.Bytes()
+	pkg.Header.ID = 10086
+	data, err = pkg.Marshal()
+	assert.NoError(t, err)
+
+	pkgres := &DubboPackage{}
+	pkgres.Body = make([]interface{}, 7)
+	err = pkgres.Unmarshal(data)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(10086), pkgres.Header.ID)
+	assert.Equal(t, "Method", pkgres.Body.([]interface{})[3])
+	assert.Equal(t, []interface{}{"a"}, pkgres.Body.([]interface{})[5])
+}
+
+func TestDubboPackage_UnmarshalRawFallback(t *testing.T) {
+	pkg := &DubboPackage{}
+	pkg.Body = "not a user"
+	pkg.Header.Type = hessian.PackageResponse
+	pkg.Header.SerialID = byte(S_Dubbo)
+	pkg.Header.ID = 10087
+	pkg.Header.ResponseStatus = hessian.Response_OK
+	data, err := pkg.Marshal()
+	assert.NoError(t, err)
+	raw := append([]byte(nil), data.Bytes()[hessian.HEADER_LENGTH:]...)
+
+	client := &Client{pendingResponses: new(sync.Map)}
+	rsp := NewPendingResponse()
+	rsp.seq = 10087
+	rsp.reply = &User{}
+	rsp.rawFallback = true
+	client.addPendingResponse(rsp)
+
+	// the string can't be decoded into the user
+	pkgres := &DubboPackage{}
+	err = pkgres.Unmarshal(data, client)
+	assert.NoError(t, err)
+	fallback, ok := pkgres.Err.(*DecodeFallbackError)
+	assert.True(t, ok)
+	assert.Equal(t, raw, fallback.Raw)
+	assert.Error(t, fallback.Cause)
+}
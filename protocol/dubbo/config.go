@@ -25,6 +25,20 @@ import (
 	perrors "github.com/pkg/errors"
 )
 
+const (
+	// the duplicate request is rejected with a bad request response
+	DUPLICATE_REQUEST_ID_REJECT = "reject"
+	// the session of the duplicate request is closed
+	DUPLICATE_REQUEST_ID_CLOSE = "close"
+)
+
+const (
+	// the session of the slow consumer is closed
+	SLOW_CONSUMER_CLOSE = "close"
+	// the response to the slow consumer is dropped
+	SLOW_CONSUMER_DROP = "drop"
+)
+
 type (
 	GettySessionParam struct {
 		CompressEncoding bool   `default:"false" yaml:"compress_encoding" json:"compress_encoding,omitempty"`
@@ -52,11 +66,25 @@ type (
 		sessionTimeout time.Duration
 		SessionNumber  int `default:"1000" yaml:"session_number" json:"session_number,omitempty"`
 
+		// the behavior for the request with the same id as an in-flight request of the session, reject or close
+		DuplicateRequestId string `default:"reject" yaml:"duplicate_request_id" json:"duplicate_request_id,omitempty"`
+
+		// slow consumer: the response waits at most slow_consumer_write_wait when the write queue(pkg_wq_size)
+		// of the session is full, then the session is closed, or the response is dropped
+		SlowConsumerWriteWait string `default:"5s" yaml:"slow_consumer_write_wait" json:"slow_consumer_write_wait,omitempty"`
+		slowConsumerWriteWait time.Duration
+		SlowConsumerPolicy    string `default:"close" yaml:"slow_consumer_policy" json:"slow_consumer_policy,omitempty"`
+
 		// grpool
 		GrPoolSize  int `default:"0" yaml:"gr_pool_size" json:"gr_pool_size,omitempty"`
 		QueueLen    int `default:"0" yaml:"queue_len" json:"queue_len,omitempty"`
 		QueueNumber int `default:"0" yaml:"queue_number" json:"queue_number,omitempty"`
 
+		// priority dispatch: the requests are executed by priority_pool_size goroutines, the queued requests
+		// of higher priority attachment first. 0 means the requests aren't dispatched by priority.
+		PriorityPoolSize int `default:"0" yaml:"priority_pool_size" json:"priority_pool_size,omitempty"`
+		PriorityQueueLen int `default:"1024" yaml:"priority_queue_len" json:"priority_queue_len,omitempty"`
+
 		// session tcp parameters
 		GettySessionParam GettySessionParam `required:"true" yaml:"getty_session_param" json:"getty_session_param,omitempty"`
 	}
@@ -135,5 +163,25 @@ func (c *ServerConfig) CheckValidity() error {
 		return perrors.WithMessagef(err, "time.ParseDuration(SessionTimeout{%#v})", c.SessionTimeout)
 	}
 
+	switch c.DuplicateRequestId {
+	case "", DUPLICATE_REQUEST_ID_REJECT, DUPLICATE_REQUEST_ID_CLOSE:
+	default:
+		return perrors.Errorf("illegal DuplicateRequestId{%#v}, it should be %s or %s",
+			c.DuplicateRequestId, DUPLICATE_REQUEST_ID_REJECT, DUPLICATE_REQUEST_ID_CLOSE)
+	}
+
+	c.slowConsumerWriteWait = WritePkg_Timeout
+	if c.SlowConsumerWriteWait != "" {
+		if c.slowConsumerWriteWait, err = time.ParseDuration(c.SlowConsumerWriteWait); err != nil {
+			return perrors.WithMessagef(err, "time.ParseDuration(SlowConsumerWriteWait{%#v})", c.SlowConsumerWriteWait)
+		}
+	}
+	switch c.SlowConsumerPolicy {
+	case "", SLOW_CONSUMER_CLOSE, SLOW_CONSUMER_DROP:
+	default:
+		return perrors.Errorf("illegal SlowConsumerPolicy{%#v}, it should be %s or %s",
+			c.SlowConsumerPolicy, SLOW_CONSUMER_CLOSE, SLOW_CONSUMER_DROP)
+	}
+
 	return perrors.WithStack(c.GettySessionParam.CheckValidity())
 }
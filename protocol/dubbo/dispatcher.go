@@ -0,0 +1,127 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dubbo
+
+import (
+	"container/heap"
+	"sync"
+)
+
+type priorityTask struct {
+	priority int
+	seq      uint64
+	fn       func()
+}
+
+// priorityTasks is a heap of the tasks, the task of higher priority is popped first,
+// and the tasks of the same priority are popped in order.
+type priorityTasks []*priorityTask
+
+func (t priorityTasks) Len() int { return len(t) }
+
+func (t priorityTasks) Less(i, j int) bool {
+	if t[i].priority != t[j].priority {
+		return t[i].priority > t[j].priority
+	}
+	return t[i].seq < t[j].seq
+}
+
+func (t priorityTasks) Swap(i, j int) { t[i], t[j] = t[j], t[i] }
+
+func (t *priorityTasks) Push(x interface{}) { *t = append(*t, x.(*priorityTask)) }
+
+func (t *priorityTasks) Pop() interface{} {
+	old := *t
+	n := len(old)
+	task := old[n-1]
+	old[n-1] = nil
+	*t = old[:n-1]
+	return task
+}
+
+// priorityDispatcher executes the requests by @workers goroutines, the queued requests are ordered by
+// their priority, so the high priority requests are served ahead of the low priority ones under load.
+type priorityDispatcher struct {
+	lock     sync.Mutex
+	notEmpty *sync.Cond
+	notFull  *sync.Cond
+	tasks    priorityTasks
+	maxLen   int
+	seq      uint64
+	closed   bool
+	wg       sync.WaitGroup
+}
+
+func newPriorityDispatcher(workers int, queueLen int) *priorityDispatcher {
+	if queueLen <= 0 {
+		queueLen = 1
+	}
+	d := &priorityDispatcher{maxLen: queueLen}
+	d.notEmpty = sync.NewCond(&d.lock)
+	d.notFull = sync.NewCond(&d.lock)
+	d.wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go d.work()
+	}
+	return d
+}
+
+// dispatch queues the @fn, it blocks when the queue is full. false is returned if the dispatcher is closed.
+func (d *priorityDispatcher) dispatch(priority int, fn func()) bool {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	for !d.closed && len(d.tasks) >= d.maxLen {
+		d.notFull.Wait()
+	}
+	if d.closed {
+		return false
+	}
+	d.seq++
+	heap.Push(&d.tasks, &priorityTask{priority: priority, seq: d.seq, fn: fn})
+	d.notEmpty.Signal()
+	return true
+}
+
+func (d *priorityDispatcher) work() {
+	defer d.wg.Done()
+	for {
+		d.lock.Lock()
+		for !d.closed && len(d.tasks) == 0 {
+			d.notEmpty.Wait()
+		}
+		if len(d.tasks) == 0 {
+			d.lock.Unlock()
+			return
+		}
+		task := heap.Pop(&d.tasks).(*priorityTask)
+		d.notFull.Signal()
+		d.lock.Unlock()
+
+		task.fn()
+	}
+}
+
+// close stops accepting the requests, and waits for the queued ones to be executed
+func (d *priorityDispatcher) close() {
+	d.lock.Lock()
+	d.closed = true
+	d.notEmpty.Broadcast()
+	d.notFull.Broadcast()
+	d.lock.Unlock()
+	d.wg.Wait()
+}
@@ -0,0 +1,114 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dubbo
+
+import (
+	"sync"
+	"testing"
+)
+
+import (
+	"github.com/apache/dubbo-go-hessian2"
+	"github.com/stretchr/testify/assert"
+)
+
+import (
+	"github.com/apache/dubbo-go/common/constant"
+)
+
+func TestPriorityDispatcher(t *testing.T) {
+	d := newPriorityDispatcher(1, 16)
+
+	// saturate the only worker, so the following requests are queued
+	block := make(chan struct{})
+	started := make(chan struct{})
+	assert.True(t, d.dispatch(0, func() {
+		close(started)
+		<-block
+	}))
+	<-started
+
+	var (
+		lock  sync.Mutex
+		order []string
+	)
+	enqueue := func(name string, priority int) {
+		assert.True(t, d.dispatch(priority, func() {
+			lock.Lock()
+			order = append(order, name)
+			lock.Unlock()
+		}))
+	}
+	enqueue("low1", -1)
+	enqueue("normal1", 0)
+	enqueue("high1", 10)
+	enqueue("low2", -1)
+	enqueue("high2", 10)
+	enqueue("normal2", 0)
+
+	close(block)
+	d.close()
+	assert.Equal(t, []string{"high1", "high2", "normal1", "normal2", "low1", "low2"}, order)
+
+	// closed
+	assert.False(t, d.dispatch(0, func() {}))
+}
+
+func TestPriorityDispatcher_QueueFull(t *testing.T) {
+	d := newPriorityDispatcher(1, 1)
+
+	block := make(chan struct{})
+	started := make(chan struct{})
+	d.dispatch(0, func() {
+		close(started)
+		<-block
+	})
+	<-started
+	d.dispatch(0, func() {})
+
+	dispatched := make(chan struct{})
+	go func() {
+		d.dispatch(0, func() {})
+		close(dispatched)
+	}()
+	select {
+	case <-dispatched:
+		assert.Fail(t, "the request is dispatched when the queue is full")
+	default:
+	}
+
+	close(block)
+	<-dispatched
+	d.close()
+}
+
+func TestRequestPriority(t *testing.T) {
+	p := &DubboPackage{}
+	p.Body = map[string]interface{}{
+		"attachments": map[interface{}]interface{}{constant.PRIORITY_KEY: "5"},
+	}
+	assert.Equal(t, 5, requestPriority(p))
+
+	p.Body = map[string]interface{}{
+		"attachments": map[interface{}]interface{}{constant.PRIORITY_KEY: "high"},
+	}
+	assert.Equal(t, 0, requestPriority(p))
+
+	p.Body = &hessian.Response{}
+	assert.Equal(t, 0, requestPriority(p))
+}
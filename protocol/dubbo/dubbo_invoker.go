@@ -18,8 +18,11 @@
 package dubbo
 
 import (
+	"context"
+	"io"
 	"strconv"
 	"sync"
+	"time"
 )
 
 import (
@@ -34,7 +37,10 @@ import (
 	invocation_impl "github.com/apache/dubbo-go/protocol/invocation"
 )
 
-var Err_No_Reply = perrors.New("request need @reply")
+var (
+	Err_No_Reply        = perrors.New("request need @reply")
+	Err_Async_Streaming = perrors.New("the io.Reader arguments can't be streamed by the async calls")
+)
 
 type DubboInvoker struct {
 	protocol.BaseInvoker
@@ -64,20 +70,40 @@ func (di *DubboInvoker) Invoke(invocation protocol.Invocation) protocol.Result {
 		logger.Errorf("ParseBool - error: %v", err)
 		async = false
 	}
-	if async {
-		if callBack, ok := inv.CallBack().(func(response CallResponse)); ok {
-			result.Err = di.client.AsyncCall(url.Location, url, inv.MethodName(), inv.Arguments(), callBack, inv.Reply())
+	if err = protocol.LimitAttachments(url, inv); err != nil {
+		result.Err = err
+		return &result
+	}
+	streaming := hasStreamArgument(inv.Arguments())
+	if async && streaming {
+		result.Err = Err_Async_Streaming
+	} else if async {
+		if inv.Reply() == nil {
+			result.Err = di.client.CallOnewayContext(invocationContext(url, inv), url.Location, url, inv.MethodName(), inv.Arguments())
 		} else {
-			result.Err = di.client.CallOneway(url.Location, url, inv.MethodName(), inv.Arguments())
+			// the response is delivered to the callback and the future of the result later
+			future := newFuture()
+			err = di.client.AsyncCallContext(invocationContext(url, inv), url.Location, url, inv.MethodName(), inv.Arguments(),
+				asyncCallback(inv, inv.CallBack(), future), inv.Reply())
+			if err == nil {
+				return &AsyncResult{future: future}
+			}
+			result.Err = err
 		}
 	} else {
 		if inv.Reply() == nil {
 			result.Err = Err_No_Reply
+		} else if streaming {
+			result.Err = di.invokeStream(invocationContext(url, inv), url, inv)
 		} else {
-			result.Err = di.client.Call(url.Location, url, inv.MethodName(), inv.Arguments(), inv.Reply())
+			result.Err = di.client.CallContext(invocationContext(url, inv), url.Location, url, inv.MethodName(), inv.Arguments(), inv.Reply())
 		}
 	}
-	if result.Err == nil {
+	if fallback, ok := perrors.Cause(result.Err).(*DecodeFallbackError); ok {
+		logger.Warnf("%v, method: %v", fallback, inv.MethodName())
+		result.Err = nil
+		result.Rest = fallback.Raw
+	} else if result.Err == nil {
 		result.Rest = inv.Reply()
 	}
 	logger.Debugf("result.Err: %v, result.Rest: %v", result.Err, result.Rest)
@@ -85,6 +111,68 @@ func (di *DubboInvoker) Invoke(invocation protocol.Invocation) protocol.Result {
 	return &result
 }
 
+// invokeStream calls the provider with the io.Reader arguments of the @inv replaced by the streams, and sends
+// the data of the readers in chunks while the provider reads them.
+func (di *DubboInvoker) invokeStream(ctx context.Context, url common.URL, inv *invocation_impl.RPCInvocation) error {
+	args := make([]interface{}, len(inv.Arguments()))
+	ids := []string{}
+	readers := map[string]io.Reader{}
+	for i, arg := range inv.Arguments() {
+		args[i] = arg
+		if r, ok := arg.(io.Reader); ok {
+			id := newStreamID()
+			ids = append(ids, id)
+			readers[id] = r
+			args[i] = streamArgumentPrefix + id
+		}
+	}
+
+	var err error
+	done := make(chan struct{})
+	go func() {
+		err = di.client.CallContext(ctx, url.Location, url, inv.MethodName(), args, inv.Reply())
+		close(done)
+	}()
+	chunkSize := int(url.GetMethodParamInt64(inv.MethodName(), constant.STREAM_CHUNK_SIZE_KEY, constant.DEFAULT_STREAM_CHUNK_SIZE))
+	for _, id := range ids {
+		// the result of the call tells why the stream fails, e.g. the provider returns before reading it all
+		if streamErr := di.client.sendStream(ctx, url.Location, url, id, readers[id], chunkSize, done); streamErr != nil {
+			logger.Warnf("stream the argument of the method %s error: %v", inv.MethodName(), streamErr)
+			break
+		}
+	}
+	<-done
+	return err
+}
+
+// invocationContext returns the context of the @inv, with the timeout of its attachment in milliseconds if any,
+// and the attachments sent to the provider. The token of the provider @url, checked by its token filter,
+// is sent with a copy of the attachments, so the invocation shared by the retries isn't modified.
+func invocationContext(url common.URL, inv *invocation_impl.RPCInvocation) context.Context {
+	ctx := inv.Context()
+	if timeout, err := strconv.ParseInt(inv.AttachmentsByKey(constant.TIMEOUT_KEY, ""), 10, 64); err == nil && timeout > 0 {
+		ctx = withCallTimeout(ctx, time.Duration(timeout)*time.Millisecond)
+	}
+	attachments := inv.Attachments()
+	if token := url.GetParam(constant.TOKEN_KEY, ""); token != "" {
+		copied := make(map[string]string, len(attachments)+1)
+		for k, v := range attachments {
+			copied[k] = v
+		}
+		copied[constant.TOKEN_KEY] = token
+		attachments = copied
+	}
+	if len(attachments) > 0 {
+		ctx = withCallAttachments(ctx, attachments)
+	}
+	return ctx
+}
+
+// IsAvailable is false for a while after the initial connection to the provider failed with all the retries
+func (di *DubboInvoker) IsAvailable() bool {
+	return di.BaseInvoker.IsAvailable() && (di.client == nil || di.client.isAvailable(di.GetUrl().Location))
+}
+
 func (di *DubboInvoker) Destroy() {
 	if di.IsDestroyed() {
 		return
@@ -18,17 +18,25 @@
 package dubbo
 
 import (
+	"context"
 	"sync"
 	"testing"
 	"time"
 )
 
 import (
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/mocktracer"
+	perrors "github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
 )
 
 import (
+	"github.com/apache/dubbo-go/common"
 	"github.com/apache/dubbo-go/common/constant"
+	"github.com/apache/dubbo-go/filter"
+	"github.com/apache/dubbo-go/filter/impl"
+	"github.com/apache/dubbo-go/protocol"
 	"github.com/apache/dubbo-go/protocol/invocation"
 )
 
@@ -77,8 +85,202 @@ func TestDubboInvoker_Invoke(t *testing.T) {
 	res = invoker.Invoke(inv)
 	assert.EqualError(t, res.Error(), "request need @reply")
 
+	// the timeout of the attachment overrides the request timeout
+	inv = invocation.NewRPCInvocationWithOptions(invocation.WithMethodName("GetSlowUser"), invocation.WithArguments([]interface{}{"1", "username"}),
+		invocation.WithReply(&User{}), invocation.WithAttachments(map[string]string{constant.TIMEOUT_KEY: "100"}))
+	start := time.Now()
+	res = invoker.Invoke(inv)
+	assert.Equal(t, errClientReadTimeout, perrors.Cause(res.Error()))
+	assert.True(t, time.Since(start) < 400*time.Millisecond)
+
 	// destroy
 	lock.Lock()
 	proto.Destroy()
 	lock.Unlock()
 }
+
+func TestDubboInvoker_Async(t *testing.T) {
+	proto, url := InitTest(t)
+	defer proto.Destroy()
+
+	c := &Client{
+		pendingResponses: new(sync.Map),
+		conf:             *clientConf,
+		opts: Options{
+			ConnectTimeout: 3e9,
+			RequestTimeout: 6e9,
+		},
+	}
+	c.pool = newGettyRPCClientConnPool(c, clientConf.PoolSize, time.Duration(int(time.Second)*clientConf.PoolTTL))
+	invoker := NewDubboInvoker(url, c)
+	defer invoker.Destroy()
+
+	// the callback of the invocation runs before the future is completed
+	var order []string
+	inv := invocation.NewRPCInvocationWithOptions(invocation.WithMethodName("GetUser"), invocation.WithArguments([]interface{}{"1", "username"}),
+		invocation.WithReply(&User{}), invocation.WithAttachments(map[string]string{constant.ASYNC_KEY: "true"}),
+		invocation.WithCallBack(func(response CallResponse) {
+			order = append(order, "callback")
+		}))
+	res := invoker.Invoke(inv)
+	assert.NoError(t, res.Error())
+	assert.Nil(t, res.Result())
+	future := res.(*AsyncResult).Future()
+	reply, err := future.Get(context.Background())
+	order = append(order, "future")
+	assert.NoError(t, err)
+	assert.Equal(t, User{Id: "1", Name: "username"}, *reply.(*User))
+	assert.Equal(t, []string{"callback", "future"}, order)
+
+	// the async handler of the reference
+	handler := &asyncHandler{results: make(chan protocol.Result, 1)}
+	inv = invocation.NewRPCInvocationWithOptions(invocation.WithMethodName("GetUser"), invocation.WithArguments([]interface{}{"2", "username"}),
+		invocation.WithReply(&User{}), invocation.WithAttachments(map[string]string{constant.ASYNC_KEY: "true"}),
+		invocation.WithCallBack(handler))
+	res = invoker.Invoke(inv)
+	assert.NoError(t, res.Error())
+	result := <-handler.results
+	assert.NoError(t, result.Error())
+	assert.Equal(t, User{Id: "2", Name: "username"}, *result.Result().(*User))
+
+	// the invocation is canceled by its context
+	ctx, cancel := context.WithCancel(context.Background())
+	inv = invocation.NewRPCInvocationWithOptions(invocation.WithMethodName("GetSlowUser"), invocation.WithArguments([]interface{}{"3", "username"}),
+		invocation.WithReply(&User{}), invocation.WithAttachments(map[string]string{constant.ASYNC_KEY: "true"}))
+	inv.SetContext(ctx)
+	res = invoker.Invoke(inv)
+	assert.NoError(t, res.Error())
+	cancel()
+	_, err = res.(*AsyncResult).Future().Get(context.Background())
+	assert.Equal(t, context.Canceled, perrors.Cause(err))
+
+	// the future isn't completed before the deadline of the getter
+	ctx, cancel = context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	inv = invocation.NewRPCInvocationWithOptions(invocation.WithMethodName("GetSlowUser"), invocation.WithArguments([]interface{}{"4", "username"}),
+		invocation.WithReply(&User{}), invocation.WithAttachments(map[string]string{constant.ASYNC_KEY: "true"}))
+	res = invoker.Invoke(inv)
+	_, err = res.(*AsyncResult).Future().Get(ctx)
+	assert.Equal(t, context.DeadlineExceeded, perrors.Cause(err))
+	<-res.(*AsyncResult).Future().Done()
+}
+
+type asyncHandler struct {
+	results chan protocol.Result
+}
+
+func (h *asyncHandler) OnResult(invocation protocol.Invocation, result protocol.Result) {
+	h.results <- result
+}
+
+// TokenProvider is the service exported with a token
+type TokenProvider struct {
+}
+
+func (p *TokenProvider) GetUser(ctx context.Context, req []interface{}, rsp *User) error {
+	rsp.Id = req[0].(string)
+	return nil
+}
+
+func (p *TokenProvider) Reference() string {
+	return "TokenProvider"
+}
+
+func TestDubboInvoker_Token(t *testing.T) {
+	proto, _ := InitTest(t)
+	defer proto.Destroy()
+
+	_, err := common.ServiceMap.Register("dubbo", &TokenProvider{})
+	assert.NoError(t, err)
+	tokenUrl := "dubbo://127.0.0.1:20000/TokenProvider?interface=com.ikurento.user.TokenProvider&methods=GetUser&bean.name=TokenProvider&token="
+	url, err := common.NewURL(context.Background(), tokenUrl+"abc")
+	assert.NoError(t, err)
+	proto.Export(&filteredInvoker{Invoker: protocol.NewBaseInvoker(url), filter: impl.GetTokenFilter()})
+	client := NewClient(Options{ConnectTimeout: 3e9, RequestTimeout: 6e9})
+
+	// the token reaches the provider, the invocation isn't modified
+	user := &User{}
+	inv := invocation.NewRPCInvocationWithOptions(invocation.WithMethodName("GetUser"), invocation.WithArguments([]interface{}{"1"}),
+		invocation.WithReply(user))
+	res := NewDubboInvoker(url, client).Invoke(inv)
+	assert.NoError(t, res.Error())
+	assert.Equal(t, "1", user.Id)
+	assert.Equal(t, "", inv.AttachmentsByKey(constant.TOKEN_KEY, ""))
+
+	// the wrong token is rejected by the provider
+	wrongUrl, err := common.NewURL(context.Background(), tokenUrl+"xyz")
+	assert.NoError(t, err)
+	inv = invocation.NewRPCInvocationWithOptions(invocation.WithMethodName("GetUser"), invocation.WithArguments([]interface{}{"1"}),
+		invocation.WithReply(&User{}))
+	res = NewDubboInvoker(wrongUrl, client).Invoke(inv)
+	assert.Error(t, res.Error())
+	assert.Contains(t, res.Error().Error(), "invalid token")
+}
+
+// TracingProvider records the span in the context of the calls
+type TracingProvider struct {
+	span opentracing.Span
+}
+
+func (p *TracingProvider) GetUser(ctx context.Context, req []interface{}, rsp *User) error {
+	p.span = opentracing.SpanFromContext(ctx)
+	rsp.Id = req[0].(string)
+	rsp.Name = req[1].(string)
+	return nil
+}
+
+func (p *TracingProvider) Reference() string {
+	return "TracingProvider"
+}
+
+// filteredInvoker invokes the invoker through the filter
+type filteredInvoker struct {
+	protocol.Invoker
+	filter filter.Filter
+}
+
+func (fi *filteredInvoker) Invoke(invocation protocol.Invocation) protocol.Result {
+	return fi.filter.OnResponse(fi.filter.Invoke(fi.Invoker, invocation), fi.Invoker, invocation)
+}
+
+func TestDubboInvoker_Tracing(t *testing.T) {
+	proto, _ := InitTest(t)
+	defer proto.Destroy()
+	tracer := mocktracer.New()
+	opentracing.SetGlobalTracer(tracer)
+	defer opentracing.SetGlobalTracer(opentracing.NoopTracer{})
+
+	provider := &TracingProvider{}
+	_, err := common.ServiceMap.Register("dubbo", provider)
+	assert.NoError(t, err)
+	url, err := common.NewURL(context.Background(), "dubbo://127.0.0.1:20000/TracingProvider?"+
+		"interface=com.ikurento.user.TracingProvider&methods=GetUser&bean.name=TracingProvider")
+	assert.NoError(t, err)
+	serverFilter := impl.GetTracingFilter().(*impl.TracingFilter)
+	serverFilter.Prepare(url, common.PROVIDER)
+	proto.Export(&filteredInvoker{Invoker: protocol.NewBaseInvoker(url), filter: serverFilter})
+
+	clientFilter := impl.GetTracingFilter().(*impl.TracingFilter)
+	clientFilter.Prepare(url, common.CONSUMER)
+	invoker := &filteredInvoker{Invoker: NewDubboInvoker(url, NewClient(Options{ConnectTimeout: 3e9, RequestTimeout: 6e9})), filter: clientFilter}
+
+	parent := tracer.StartSpan("parent")
+	user := &User{}
+	inv := invocation.NewRPCInvocationWithOptions(invocation.WithMethodName("GetUser"), invocation.WithArguments([]interface{}{"1", "username"}),
+		invocation.WithReply(user), invocation.WithContext(opentracing.ContextWithSpan(context.Background(), parent)))
+	res := invoker.Invoke(inv)
+	assert.NoError(t, res.Error())
+	assert.Equal(t, User{Id: "1", Name: "username"}, *user)
+
+	// the server span is finished before the response
+	spans := tracer.FinishedSpans()
+	assert.Equal(t, 2, len(spans))
+	server, client := spans[0], spans[1]
+	assert.Equal(t, parent.Context().(mocktracer.MockSpanContext).SpanID, client.ParentID)
+	assert.Equal(t, client.SpanContext.SpanID, server.ParentID)
+	assert.Equal(t, client.SpanContext.TraceID, server.SpanContext.TraceID)
+	assert.Equal(t, "127.0.0.1:20000", client.Tag("peer.address"))
+	assert.Equal(t, "127.0.0.1", server.Tag("peer.address"))
+	// the service is called with the server span
+	assert.Equal(t, server, provider.span)
+}
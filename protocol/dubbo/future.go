@@ -0,0 +1,93 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dubbo
+
+import (
+	"context"
+)
+
+import (
+	perrors "github.com/pkg/errors"
+)
+
+import (
+	"github.com/apache/dubbo-go/protocol"
+)
+
+// Future is the pending response of an async call, it's completed once the response arrives, or the call fails,
+// e.g. it's canceled by its context.
+type Future struct {
+	done     chan struct{}
+	response CallResponse
+}
+
+func newFuture() *Future {
+	return &Future{done: make(chan struct{})}
+}
+
+func (f *Future) complete(response CallResponse) {
+	f.response = response
+	close(f.done)
+}
+
+// Done is closed once the future is completed
+func (f *Future) Done() <-chan struct{} {
+	return f.done
+}
+
+// Get waits until the future is completed or the @ctx is done, and returns the reply of the call
+func (f *Future) Get(ctx context.Context) (interface{}, error) {
+	select {
+	case <-f.done:
+		return f.response.Reply, f.response.Cause
+	case <-ctx.Done():
+		return nil, perrors.WithStack(ctx.Err())
+	}
+}
+
+// AsyncResult is the empty result returned immediately by the async invocation, the response is delivered
+// to its Future later.
+type AsyncResult struct {
+	protocol.RPCResult
+	future *Future
+}
+
+// Future returns the pending response of the async invocation
+func (r *AsyncResult) Future() *Future {
+	return r.future
+}
+
+// asyncCallback returns the callback of the async invocation @inv, which is the func(CallResponse) callback of
+// the invocation or the protocol.AsyncHandler of its reference, followed by the completion of the @future.
+func asyncCallback(inv protocol.Invocation, callBack interface{}, future *Future) AsyncCallback {
+	return func(response CallResponse) {
+		defer future.complete(response)
+		switch cb := callBack.(type) {
+		case func(response CallResponse):
+			cb(response)
+		case AsyncCallback:
+			cb(response)
+		case protocol.AsyncHandler:
+			result := &protocol.RPCResult{Err: response.Cause}
+			if response.Cause == nil {
+				result.Rest = response.Reply
+			}
+			cb.OnResult(inv, result)
+		}
+	}
+}
@@ -20,8 +20,10 @@ package dubbo
 import (
 	"context"
 	"fmt"
+	"net"
 	"net/url"
 	"reflect"
+	"strconv"
 	"sync"
 	"time"
 )
@@ -50,6 +52,8 @@ var (
 type rpcSession struct {
 	session getty.Session
 	reqNum  int32
+	// ids of the in-flight two way requests of the server session
+	inflight map[int64]struct{}
 }
 
 ////////////////////////////////////////////
@@ -72,11 +76,13 @@ func (h *RpcClientHandler) OnOpen(session getty.Session) error {
 func (h *RpcClientHandler) OnError(session getty.Session, err error) {
 	logger.Infof("session{%s} got error{%v}, will be closed.", session.Stat(), err)
 	h.conn.removeSession(session)
+	h.conn.pool.rpcClient.failPendingResponses(session)
 }
 
 func (h *RpcClientHandler) OnClose(session getty.Session) {
 	logger.Infof("session{%s} is closing......", session.Stat())
 	h.conn.removeSession(session)
+	h.conn.pool.rpcClient.failPendingResponses(session)
 }
 
 func (h *RpcClientHandler) OnMessage(session getty.Session, pkg interface{}) {
@@ -107,11 +113,7 @@ func (h *RpcClientHandler) OnMessage(session getty.Session, pkg interface{}) {
 		pendingResponse.err = p.Err
 	}
 
-	if pendingResponse.callback == nil {
-		pendingResponse.done <- struct{}{}
-	} else {
-		pendingResponse.callback(pendingResponse.GetCallResponse())
-	}
+	h.conn.pool.rpcClient.completePendingResponse(pendingResponse)
 }
 
 func (h *RpcClientHandler) OnCron(session getty.Session) {
@@ -136,17 +138,29 @@ func (h *RpcClientHandler) OnCron(session getty.Session) {
 ////////////////////////////////////////////
 
 type RpcServerHandler struct {
-	maxSessionNum  int
-	sessionTimeout time.Duration
-	sessionMap     map[getty.Session]*rpcSession
-	rwlock         sync.RWMutex
+	maxSessionNum      int
+	sessionTimeout     time.Duration
+	duplicateRequestId string
+	writeWait          time.Duration
+	slowConsumerPolicy string
+	dispatcher         *priorityDispatcher
+	sessionMap         map[getty.Session]*rpcSession
+	rwlock             sync.RWMutex
 }
 
-func NewRpcServerHandler(maxSessionNum int, sessionTimeout time.Duration) *RpcServerHandler {
+func NewRpcServerHandler(maxSessionNum int, sessionTimeout time.Duration, duplicateRequestId string,
+	writeWait time.Duration, slowConsumerPolicy string) *RpcServerHandler {
+
+	if writeWait <= 0 {
+		writeWait = WritePkg_Timeout
+	}
 	return &RpcServerHandler{
-		maxSessionNum:  maxSessionNum,
-		sessionTimeout: sessionTimeout,
-		sessionMap:     make(map[getty.Session]*rpcSession),
+		maxSessionNum:      maxSessionNum,
+		sessionTimeout:     sessionTimeout,
+		duplicateRequestId: duplicateRequestId,
+		writeWait:          writeWait,
+		slowConsumerPolicy: slowConsumerPolicy,
+		sessionMap:         make(map[getty.Session]*rpcSession),
 	}
 }
 
@@ -163,7 +177,7 @@ func (h *RpcServerHandler) OnOpen(session getty.Session) error {
 
 	logger.Infof("got session:%s", session.Stat())
 	h.rwlock.Lock()
-	h.sessionMap[session] = &rpcSession{session: session}
+	h.sessionMap[session] = &rpcSession{session: session, inflight: make(map[int64]struct{})}
 	h.rwlock.Unlock()
 	return nil
 }
@@ -203,19 +217,48 @@ func (h *RpcServerHandler) OnMessage(session getty.Session, pkg interface{}) {
 		return
 	}
 
+	// the calls reading the streams block until the chunks arrive, so they run on the task pool of the session
+	// rather than the dispatcher, which would be held up by them
+	if p.Service.Method == constant.STREAM || len(requestStreams(p)) > 0 {
+		if srvGrpool == nil {
+			logger.Errorf("session{%s} request{header: %#v} is rejected: %v", session.Stat(), p.Header, errStreamPool)
+			if p.Header.Type&hessian.PackageRequest_TwoWay != 0x00 {
+				p.Body = errStreamPool
+				h.reply(session, p, hessian.PackageResponse)
+			}
+			return
+		}
+		h.handleRequest(session, p)
+		return
+	}
+
+	if h.dispatcher != nil {
+		if !h.dispatcher.dispatch(requestPriority(p), func() { h.handleRequest(session, p) }) {
+			logger.Warnf("session{%s} the server is stopped, request{header: %#v} is dropped", session.Stat(), p.Header)
+		}
+		return
+	}
+	h.handleRequest(session, p)
+}
+
+func (h *RpcServerHandler) handleRequest(session getty.Session, p *DubboPackage) {
 	twoway := true
 	// not twoway
 	if p.Header.Type&hessian.PackageRequest_TwoWay == 0x00 {
 		twoway = false
 	}
+	if twoway {
+		if !h.beginRequest(session, p.Header.ID) {
+			h.rejectDuplicateRequest(session, p)
+			return
+		}
+		defer h.endRequest(session, p.Header.ID)
+	}
 
-	u := common.NewURLWithOptions(common.WithPath(p.Service.Path), common.WithParams(url.Values{}),
-		common.WithParamsValue(constant.GROUP_KEY, p.Service.Group),
-		common.WithParamsValue(constant.INTERFACE_KEY, p.Service.Interface),
-		common.WithParamsValue(constant.VERSION_KEY, p.Service.Version))
-	exporter, _ := dubboProtocol.ExporterMap().Load(u.ServiceKey())
+	serviceKey := requestServiceKey(p)
+	exporter, _ := dubboProtocol.ExporterMap().Load(serviceKey)
 	if exporter == nil {
-		err := fmt.Errorf("don't have this exporter, key: %s", u.ServiceKey())
+		err := fmt.Errorf("don't have this exporter, key: %s", serviceKey)
 		logger.Errorf(err.Error())
 		p.Header.ResponseStatus = hessian.Response_OK
 		p.Body = err
@@ -223,19 +266,40 @@ func (h *RpcServerHandler) OnMessage(session getty.Session, pkg interface{}) {
 		return
 	}
 	invoker := exporter.(protocol.Exporter).GetInvoker()
+	var inv *invocation.RPCInvocation
 	if invoker != nil {
-		result := invoker.Invoke(invocation.NewRPCInvocation(p.Service.Method, p.Body.(map[string]interface{})["args"].([]interface{}), map[string]string{
-			constant.PATH_KEY:      p.Service.Path,
-			constant.GROUP_KEY:     p.Service.Group,
-			constant.INTERFACE_KEY: p.Service.Interface,
-			constant.VERSION_KEY:   p.Service.Version,
-		}))
+		attachments := map[string]string{}
+		if reqAttachments, ok := p.Body.(map[string]interface{})["attachments"].(map[interface{}]interface{}); ok {
+			for k, v := range reqAttachments {
+				key, ok1 := k.(string)
+				value, ok2 := v.(string)
+				if ok1 && ok2 {
+					attachments[key] = value
+				}
+			}
+		}
+		attachments[constant.PATH_KEY] = p.Service.Path
+		attachments[constant.GROUP_KEY] = p.Service.Group
+		attachments[constant.INTERFACE_KEY] = p.Service.Interface
+		attachments[constant.VERSION_KEY] = p.Service.Version
+		if ip, _, err := net.SplitHostPort(session.RemoteAddr()); err == nil {
+			attachments[constant.REMOTE_IP_KEY] = ip
+		}
+		inv = invocation.NewRPCInvocation(p.Service.Method, p.Body.(map[string]interface{})["args"].([]interface{}), attachments)
+		result := invoker.Invoke(inv)
 		if err := result.Error(); err != nil {
 			p.Header.ResponseStatus = hessian.Response_OK
-			p.Body = err
+			p.Body = encodeResultError(invoker.GetUrl(), err)
 			h.reply(session, p, hessian.PackageResponse)
 			return
 		}
+		if p.Service.Method == constant.STREAM {
+			handleStreamChunk(p)
+			if twoway {
+				h.reply(session, p, hessian.PackageResponse)
+			}
+			return
+		}
 		if res := result.Result(); res != nil {
 			p.Header.ResponseStatus = hessian.Response_OK
 			p.Body = res
@@ -244,13 +308,130 @@ func (h *RpcServerHandler) OnMessage(session getty.Session, pkg interface{}) {
 		}
 	}
 
-	h.callService(p, nil)
+	nilAsZero := invoker != nil && invoker.GetUrl().GetParam(constant.NIL_ARGUMENT_KEY, "") == constant.NIL_ARGUMENT_ZERO
+	// the service gets the context of the invocation set by the filters, e.g. carrying the span of the tracing
+	var ctx context.Context
+	if inv != nil {
+		ctx = inv.Context()
+	}
+	// the streams of the request are opened by the call, and closed after it to fail the chunks left
+	for _, id := range requestStreams(p) {
+		defer streams.close(id, errStreamClosed)
+	}
+	h.callService(p, ctx, nilAsZero)
+	// the filters are notified of the result of the service by the callback of the invocation, e.g. to cache it
+	if inv != nil {
+		if callback, ok := inv.CallBack().(func(protocol.Result)); ok {
+			if err, ok := p.Body.(error); ok {
+				callback(&protocol.RPCResult{Err: err})
+			} else {
+				callback(&protocol.RPCResult{Rest: p.Body})
+			}
+		}
+	}
 	if !twoway {
 		return
 	}
+	if err, ok := p.Body.(error); ok && invoker != nil {
+		p.Body = encodeResultError(invoker.GetUrl(), err)
+	}
+	h.reply(session, p, hessian.PackageResponse)
+}
+
+func requestServiceKey(p *DubboPackage) string {
+	u := common.NewURLWithOptions(common.WithPath(p.Service.Path), common.WithParams(url.Values{}),
+		common.WithParamsValue(constant.GROUP_KEY, p.Service.Group),
+		common.WithParamsValue(constant.INTERFACE_KEY, p.Service.Interface),
+		common.WithParamsValue(constant.VERSION_KEY, p.Service.Version))
+	return u.ServiceKey()
+}
+
+// requestPriority returns the priority attachment of the request, the priority configured on the method
+// or the service of the provider is used if the request doesn't carry it.
+func requestPriority(p *DubboPackage) int {
+	if body, ok := p.Body.(map[string]interface{}); ok {
+		if attachments, ok := body["attachments"].(map[interface{}]interface{}); ok {
+			if value, ok := attachments[constant.PRIORITY_KEY].(string); ok {
+				if priority, err := strconv.Atoi(value); err == nil {
+					return priority
+				}
+				logger.Warnf("illegal priority attachment %v of the request %s.%s", value, p.Service.Path, p.Service.Method)
+			}
+		}
+	}
+	if dubboProtocol == nil {
+		return 0
+	}
+	exporter, _ := dubboProtocol.ExporterMap().Load(requestServiceKey(p))
+	if exporter == nil {
+		return 0
+	}
+	return int(exporter.(protocol.Exporter).GetInvoker().GetUrl().GetMethodParamInt64(p.Service.Method, constant.PRIORITY_KEY, 0))
+}
+
+// beginRequest marks the request in flight, false is returned if there is an in-flight request of the same id
+func (h *RpcServerHandler) beginRequest(session getty.Session, id int64) bool {
+	h.rwlock.Lock()
+	defer h.rwlock.Unlock()
+	rs, ok := h.sessionMap[session]
+	if !ok {
+		return true
+	}
+	if _, ok := rs.inflight[id]; ok {
+		return false
+	}
+	rs.inflight[id] = struct{}{}
+	return true
+}
+
+func (h *RpcServerHandler) endRequest(session getty.Session, id int64) {
+	h.rwlock.Lock()
+	defer h.rwlock.Unlock()
+	if rs, ok := h.sessionMap[session]; ok {
+		delete(rs.inflight, id)
+	}
+}
+
+// rejectDuplicateRequest responds the duplicate request with a bad request error, or closes the session
+// if the duplicate_request_id is close. The in-flight request of the same id isn't affected.
+func (h *RpcServerHandler) rejectDuplicateRequest(session getty.Session, p *DubboPackage) {
+	err := perrors.Errorf("duplicate request id %d, the request of the same id is in flight on the session", p.Header.ID)
+	logger.Warnf("session{%s} %v", session.Stat(), err)
+	if h.duplicateRequestId == DUPLICATE_REQUEST_ID_CLOSE {
+		h.closeSession(session)
+		return
+	}
+	p.Header.ResponseStatus = hessian.Response_BAD_REQUEST
+	p.Body = err
 	h.reply(session, p, hessian.PackageResponse)
 }
 
+// onSlowConsumer closes the session whose write queue is kept full by the slow consumer, so the responses to it
+// don't hold the goroutines serving the other sessions. The response is dropped if the policy is drop.
+func (h *RpcServerHandler) onSlowConsumer(session getty.Session) {
+	if h.slowConsumerPolicy == SLOW_CONSUMER_DROP {
+		logger.Warnf("session{%s} is blocked by the slow consumer, the response is dropped", session.Stat())
+		return
+	}
+	logger.Warnf("session{%s} is blocked by the slow consumer, close it", session.Stat())
+	h.closeSession(session)
+}
+
+func (h *RpcServerHandler) closeSession(session getty.Session) {
+	h.rwlock.Lock()
+	delete(h.sessionMap, session)
+	h.rwlock.Unlock()
+	session.Close()
+}
+
+// encodeResultError serializes the error result with its status code and context if the error.serialization is structured
+func encodeResultError(url common.URL, err error) error {
+	if url.GetParam(constant.ERROR_SERIALIZATION_KEY, "") == constant.STRUCTURED_ERROR_SERIALIZATION {
+		return protocol.EncodeError(err)
+	}
+	return err
+}
+
 func (h *RpcServerHandler) OnCron(session getty.Session) {
 	var (
 		flag   bool
@@ -276,7 +457,7 @@ func (h *RpcServerHandler) OnCron(session getty.Session) {
 	}
 }
 
-func (h *RpcServerHandler) callService(req *DubboPackage, ctx context.Context) {
+func (h *RpcServerHandler) callService(req *DubboPackage, ctx context.Context, nilAsZero bool) {
 
 	defer func() {
 		if e := recover(); e != nil {
@@ -303,6 +484,19 @@ func (h *RpcServerHandler) callService(req *DubboPackage, ctx context.Context) {
 	}
 	svc := svcIf.(*common.Service)
 	method := svc.Method()[req.Service.Method]
+	argv := req.Body.(map[string]interface{})["args"]
+	// the generic call of a service without $invoke is dispatched to the method it names
+	generic := method == nil && req.Service.Method == constant.GENERIC
+	if generic {
+		methodName, args, err := genericArguments(argv)
+		if err != nil {
+			logger.Errorf("illegal generic call: %v", err)
+			req.Header.ResponseStatus = hessian.Response_BAD_REQUEST
+			req.Body = err
+			return
+		}
+		method, argv = svc.Method()[methodName], args
+	}
 	if method == nil {
 		logger.Errorf("method not found!")
 		req.Header.ResponseStatus = hessian.Response_BAD_REQUEST
@@ -316,20 +510,28 @@ func (h *RpcServerHandler) callService(req *DubboPackage, ctx context.Context) {
 	}
 
 	// prepare argv
-	argv := req.Body.(map[string]interface{})["args"]
 	if (len(method.ArgsType()) == 1 || len(method.ArgsType()) == 2 && method.ReplyType() == nil) && method.ArgsType()[0].String() == "[]interface {}" {
 		in = append(in, reflect.ValueOf(argv))
 	} else {
 		for i := 0; i < len(argv.([]interface{})); i++ {
-			t := reflect.ValueOf(argv.([]interface{})[i])
-			if !t.IsValid() {
-				at := method.ArgsType()[i]
-				if at.Kind() == reflect.Ptr {
-					at = at.Elem()
-				}
-				t = reflect.New(at)
+			arg := argv.([]interface{})[i]
+			if v, ok := streamArgument(arg, method.ArgsType()[i]); ok {
+				in = append(in, v)
+				continue
+			}
+			if !generic || arg == nil {
+				in = append(in, method.SuiteArgument(i, arg, nilAsZero))
+				continue
+			}
+			// the generalized arguments are converted back to the types of the method
+			v, err := common.Realize(arg, method.ArgsType()[i])
+			if err != nil {
+				logger.Errorf("illegal generic argument %d of the method %s: %v", i, method.Method().Name, err)
+				req.Header.ResponseStatus = hessian.Response_BAD_REQUEST
+				req.Body = err
+				return
 			}
-			in = append(in, t)
+			in = append(in, v)
 		}
 	}
 
@@ -358,9 +560,30 @@ func (h *RpcServerHandler) callService(req *DubboPackage, ctx context.Context) {
 		} else {
 			req.Body = nil
 		}
+		if generic {
+			req.Body = common.Generalize(req.Body)
+		}
 	}
 }
 
+// genericArguments returns the method name and the arguments of a generic call, whose arguments are
+// the method name, the parameter types and the arguments.
+func genericArguments(argv interface{}) (string, []interface{}, error) {
+	args, ok := argv.([]interface{})
+	if !ok || len(args) != 3 {
+		return "", nil, perrors.Errorf("the generic call has %d arguments rather than 3", len(args))
+	}
+	methodName, ok := args[0].(string)
+	if !ok {
+		return "", nil, perrors.Errorf("the method name of the generic call is %T", args[0])
+	}
+	params, ok := args[2].([]interface{})
+	if !ok && args[2] != nil {
+		return "", nil, perrors.Errorf("the arguments of the generic call is %T", args[2])
+	}
+	return methodName, params, nil
+}
+
 func (h *RpcServerHandler) reply(session getty.Session, req *DubboPackage, tp hessian.PackageType) {
 	resp := &DubboPackage{
 		Header: hessian.DubboHeader{
@@ -377,7 +600,10 @@ func (h *RpcServerHandler) reply(session getty.Session, req *DubboPackage, tp he
 		resp.Body = nil
 	}
 
-	if err := session.WritePkg(resp, WritePkg_Timeout); err != nil {
+	if err := session.WritePkg(resp, h.writeWait); err != nil {
 		logger.Errorf("WritePkg error: %#v, %#v", perrors.WithStack(err), req.Header)
+		if perrors.Cause(err) == getty.ErrSessionBlocked {
+			h.onSlowConsumer(session)
+		}
 	}
 }
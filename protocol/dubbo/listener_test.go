@@ -0,0 +1,139 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dubbo
+
+import (
+	"testing"
+	"time"
+)
+
+import (
+	"github.com/apache/dubbo-go-hessian2"
+	"github.com/dubbogo/getty"
+	"github.com/stretchr/testify/assert"
+)
+
+// mockSession records the written packages, the other methods of getty.Session aren't used
+type mockSession struct {
+	getty.Session
+	pkgs   []interface{}
+	closed bool
+	// the write queue is full
+	blocked bool
+}
+
+func (s *mockSession) Stat() string {
+	return "mock session"
+}
+
+func (s *mockSession) WritePkg(pkg interface{}, timeout time.Duration) error {
+	if s.blocked {
+		time.Sleep(timeout)
+		return getty.ErrSessionBlocked
+	}
+	s.pkgs = append(s.pkgs, pkg)
+	return nil
+}
+
+func (s *mockSession) Close() {
+	s.closed = true
+}
+
+func twowayRequest(id int64) *DubboPackage {
+	return &DubboPackage{
+		Header: hessian.DubboHeader{
+			SerialID: byte(S_Dubbo),
+			Type:     hessian.PackageRequest | hessian.PackageRequest_TwoWay,
+			ID:       id,
+		},
+		Body: map[string]interface{}{},
+	}
+}
+
+func TestRpcServerHandler_DuplicateRequestId(t *testing.T) {
+	h := NewRpcServerHandler(10, time.Minute, DUPLICATE_REQUEST_ID_REJECT, time.Second, SLOW_CONSUMER_CLOSE)
+	session := &mockSession{}
+	assert.NoError(t, h.OnOpen(session))
+
+	// the first request of id 1 is in flight
+	assert.True(t, h.beginRequest(session, 1))
+	h.OnMessage(session, twowayRequest(1))
+	assert.Len(t, session.pkgs, 1)
+	rsp := session.pkgs[0].(*DubboPackage)
+	assert.Equal(t, int64(1), rsp.Header.ID)
+	assert.Equal(t, byte(hessian.Response_BAD_REQUEST), rsp.Header.ResponseStatus)
+	assert.Contains(t, rsp.Body.(error).Error(), "duplicate request id 1")
+	assert.False(t, session.closed)
+
+	// the id can be reused after the request is done
+	h.endRequest(session, 1)
+	assert.True(t, h.beginRequest(session, 1))
+	h.endRequest(session, 1)
+}
+
+func TestRpcServerHandler_DuplicateRequestIdClose(t *testing.T) {
+	h := NewRpcServerHandler(10, time.Minute, DUPLICATE_REQUEST_ID_CLOSE, time.Second, SLOW_CONSUMER_CLOSE)
+	session := &mockSession{}
+	assert.NoError(t, h.OnOpen(session))
+
+	assert.True(t, h.beginRequest(session, 1))
+	h.OnMessage(session, twowayRequest(1))
+	assert.Len(t, session.pkgs, 0)
+	assert.True(t, session.closed)
+}
+
+func TestServerConfig_DuplicateRequestId(t *testing.T) {
+	conf := ServerConfig{
+		SessionTimeout:     "60s",
+		DuplicateRequestId: "ignore",
+		GettySessionParam: GettySessionParam{
+			KeepAlivePeriod: "120s",
+			TcpReadTimeout:  "1s",
+			TcpWriteTimeout: "5s",
+			WaitTimeout:     "1s",
+		},
+	}
+	assert.Error(t, conf.CheckValidity())
+	conf.DuplicateRequestId = DUPLICATE_REQUEST_ID_CLOSE
+	assert.NoError(t, conf.CheckValidity())
+}
+
+func TestRpcServerHandler_SlowConsumer(t *testing.T) {
+	for policy, closed := range map[string]bool{SLOW_CONSUMER_CLOSE: true, SLOW_CONSUMER_DROP: false} {
+		h := NewRpcServerHandler(10, time.Minute, DUPLICATE_REQUEST_ID_REJECT, 100*time.Millisecond, policy)
+		slow := &mockSession{blocked: true}
+		normal := &mockSession{}
+		assert.NoError(t, h.OnOpen(slow))
+		assert.NoError(t, h.OnOpen(normal))
+
+		// the response to the slow consumer waits at most the write wait
+		start := time.Now()
+		h.reply(slow, twowayRequest(1), hessian.PackageResponse)
+		assert.True(t, time.Since(start) < time.Second)
+		assert.Equal(t, closed, slow.closed, policy)
+		h.rwlock.RLock()
+		_, ok := h.sessionMap[slow]
+		h.rwlock.RUnlock()
+		assert.Equal(t, !closed, ok, policy)
+
+		// the other consumer isn't affected
+		h.reply(normal, twowayRequest(1), hessian.PackageResponse)
+		assert.Len(t, normal.pkgs, 1)
+		assert.False(t, normal.closed)
+	}
+}
@@ -31,14 +31,18 @@ import (
 )
 
 import (
+	"github.com/apache/dubbo-go/common"
+	"github.com/apache/dubbo-go/common/constant"
 	"github.com/apache/dubbo-go/common/logger"
+	"github.com/apache/dubbo-go/protocol"
 )
 
 type gettyRPCClient struct {
-	once     sync.Once
-	protocol string
-	addr     string
-	created  int64 // zero, not create or be destroyed
+	once       sync.Once
+	protocol   string
+	addr       string
+	created    int64 // zero, not create or be destroyed
+	tcpOptions tcpOptions
 
 	pool *gettyRPCClientPool
 
@@ -49,13 +53,16 @@ type gettyRPCClient struct {
 
 var (
 	errClientPoolClosed = perrors.New("client pool closed")
+	// the addresses whose connections are lost, the next connection to them is a reconnection
+	lostAddrs sync.Map // addr -> struct{}
 )
 
-func newGettyRPCClientConn(pool *gettyRPCClientPool, protocol, addr string) (*gettyRPCClient, error) {
+func newGettyRPCClientConn(pool *gettyRPCClientPool, protocol, addr string, options tcpOptions) (*gettyRPCClient, error) {
 	c := &gettyRPCClient{
-		protocol: protocol,
-		addr:     addr,
-		pool:     pool,
+		protocol:   protocol,
+		addr:       addr,
+		pool:       pool,
+		tcpOptions: options,
 		gettyClient: getty.NewTCPClient(
 			getty.WithServerAddress(addr),
 			getty.WithConnectionNumber((int)(pool.rpcClient.conf.ConnectionNum)),
@@ -79,10 +86,62 @@ func newGettyRPCClientConn(pool *gettyRPCClientPool, protocol, addr string) (*ge
 	}
 	logger.Infof("client init ok")
 	c.created = time.Now().Unix()
+	connected(addr)
 
 	return c, nil
 }
 
+// connected records the reconnection to the @addr if its connections were lost, so it's warmed up again
+func connected(addr string) {
+	if _, ok := lostAddrs.Load(addr); ok {
+		lostAddrs.Delete(addr)
+		logger.Infof("reconnected to %s, it's warmed up again", addr)
+		protocol.SetReconnectTime(addr, time.Now())
+	}
+}
+
+// connectBackoff retries the initial connection to the provider, the backoff is doubled after each failure
+// and capped by maxBackoff, so that a transient network blip does not fail the invocation immediately.
+// The provider is unavailable for maxBackoff if all the retries fail, the later connections aren't retried.
+type connectBackoff struct {
+	retries    int
+	backoff    time.Duration
+	maxBackoff time.Duration
+}
+
+func newConnectBackoff(url common.URL) connectBackoff {
+	return connectBackoff{
+		retries:    int(url.GetParamInt(constant.CONNECT_RETRIES_KEY, constant.DEFAULT_CONNECT_RETRIES)),
+		backoff:    time.Duration(url.GetParamInt(constant.CONNECT_BACKOFF_KEY, constant.DEFAULT_CONNECT_BACKOFF)) * time.Millisecond,
+		maxBackoff: time.Duration(url.GetParamInt(constant.CONNECT_MAX_BACKOFF_KEY, constant.DEFAULT_CONNECT_MAX_BACKOFF)) * time.Millisecond,
+	}
+}
+
+// delay returns the backoff before the @retry(from 1) retry
+func (b connectBackoff) delay(retry int) time.Duration {
+	delay := b.backoff
+	for i := 1; i < retry && delay < b.maxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > b.maxBackoff {
+		delay = b.maxBackoff
+	}
+	return delay
+}
+
+func newGettyRPCClientConnWithBackoff(pool *gettyRPCClientPool, protocol, addr string, backoff connectBackoff,
+	options tcpOptions) (*gettyRPCClient, error) {
+	for retry := 1; ; retry++ {
+		c, err := newGettyRPCClientConn(pool, protocol, addr, options)
+		if err == nil || retry > backoff.retries {
+			return c, err
+		}
+		delay := backoff.delay(retry)
+		logger.Warnf("failed to connect to %s, retry %d/%d after %v, error: %v", addr, retry, backoff.retries, delay, err)
+		time.Sleep(delay)
+	}
+}
+
 func (c *gettyRPCClient) newSession(session getty.Session) error {
 	var (
 		ok      bool
@@ -99,13 +158,9 @@ func (c *gettyRPCClient) newSession(session getty.Session) error {
 		panic(fmt.Sprintf("%s, session.conn{%#v} is not tcp connection\n", session.Stat(), session.Conn()))
 	}
 
-	tcpConn.SetNoDelay(conf.GettySessionParam.TcpNoDelay)
-	tcpConn.SetKeepAlive(conf.GettySessionParam.TcpKeepAlive)
-	if conf.GettySessionParam.TcpKeepAlive {
-		tcpConn.SetKeepAlivePeriod(conf.GettySessionParam.keepAlivePeriod)
+	if err := c.tcpOptions.apply(tcpConn); err != nil {
+		logger.Warnf("%s, failed to set the tcp options: %v", session.Stat(), err)
 	}
-	tcpConn.SetReadBuffer(conf.GettySessionParam.TcpRBufSize)
-	tcpConn.SetWriteBuffer(conf.GettySessionParam.TcpWBufSize)
 
 	session.SetName(conf.GettySessionParam.SessionName)
 	session.SetMaxMsgLen(conf.GettySessionParam.MaxMsgLen)
@@ -160,15 +215,21 @@ func (c *gettyRPCClient) removeSession(session getty.Session) {
 		return
 	}
 
+	removed := false
 	for i, s := range c.sessions {
 		if s.session == session {
 			c.sessions = append(c.sessions[:i], c.sessions[i+1:]...)
 			logger.Debugf("delete session{%s}, its index{%d}", session.Stat(), i)
+			removed = true
 			break
 		}
 	}
 	logger.Infof("after remove session{%s}, left session number:%d", session.Stat(), len(c.sessions))
 	if len(c.sessions) == 0 {
+		// the last session is lost rather than closed with the client
+		if removed {
+			lostAddrs.Store(c.addr, struct{}{})
+		}
 		c.pool.Lock()
 		c.close() // -> pool.remove(c)
 		c.pool.Unlock()
@@ -251,14 +312,20 @@ type gettyRPCClientPool struct {
 
 	sync.Mutex
 	conns []*gettyRPCClient
+	// the addresses connected once, the connections to them aren't retried with backoff any more
+	connected map[string]struct{}
+	// the addresses failed to connect initially with all the retries, they're unavailable until the time
+	unavailable map[string]time.Time
 }
 
 func newGettyRPCClientConnPool(rpcClient *Client, size int, ttl time.Duration) *gettyRPCClientPool {
 	return &gettyRPCClientPool{
-		rpcClient: rpcClient,
-		size:      size,
-		ttl:       int64(ttl.Seconds()),
-		conns:     []*gettyRPCClient{},
+		rpcClient:   rpcClient,
+		size:        size,
+		ttl:         int64(ttl.Seconds()),
+		conns:       []*gettyRPCClient{},
+		connected:   make(map[string]struct{}),
+		unavailable: make(map[string]time.Time),
 	}
 }
 
@@ -272,8 +339,39 @@ func (p *gettyRPCClientPool) close() {
 	}
 }
 
-func (p *gettyRPCClientPool) getGettyRpcClient(protocol, addr string) (*gettyRPCClient, error) {
+func (p *gettyRPCClientPool) getGettyRpcClient(protocol, addr string, backoff connectBackoff, options tcpOptions) (*gettyRPCClient, error) {
+	conn, err := p.get(protocol, addr, options)
+	if conn != nil || err != nil {
+		return conn, err
+	}
+
+	// create new conn out of the lock, only the initial connection is retried
+	initial := !p.isConnected(addr)
+	if !initial {
+		backoff.retries = 0
+	}
+	conn, err = newGettyRPCClientConnWithBackoff(p, protocol, addr, backoff, options)
+
+	p.Lock()
+	defer p.Unlock()
+	if err != nil {
+		if initial && backoff.retries > 0 {
+			logger.Warnf("failed to connect to %s with %d retries, it's unavailable for %v", addr, backoff.retries, backoff.maxBackoff)
+			p.unavailable[addr] = time.Now().Add(backoff.maxBackoff)
+		}
+		return nil, err
+	}
+	if p.conns == nil {
+		conn.close()
+		return nil, errClientPoolClosed
+	}
+	p.connected[addr] = struct{}{}
+	delete(p.unavailable, addr)
+	return conn, nil
+}
 
+// get returns a pooled conn to the @addr with the same tcp @options if there is one
+func (p *gettyRPCClientPool) get(protocol, addr string, options tcpOptions) (*gettyRPCClient, error) {
 	p.Lock()
 	defer p.Unlock()
 	if p.conns == nil {
@@ -282,20 +380,36 @@ func (p *gettyRPCClientPool) getGettyRpcClient(protocol, addr string) (*gettyRPC
 
 	now := time.Now().Unix()
 
-	for len(p.conns) > 0 {
-		conn := p.conns[len(p.conns)-1]
-		p.conns = p.conns[:len(p.conns)-1]
-
+	for i := len(p.conns) - 1; i >= 0; i-- {
+		conn := p.conns[i]
 		if d := now - conn.created; d > p.ttl {
 			conn.close() // -> pool.remove(c)
 			continue
 		}
+		if conn.protocol != protocol || conn.addr != addr || conn.tcpOptions != options {
+			continue
+		}
+		p.conns = append(p.conns[:i], p.conns[i+1:]...)
 		conn.created = now //update created time
 
 		return conn, nil
 	}
-	// create new conn
-	return newGettyRPCClientConn(p, protocol, addr)
+	return nil, nil
+}
+
+func (p *gettyRPCClientPool) isConnected(addr string) bool {
+	p.Lock()
+	defer p.Unlock()
+	_, ok := p.connected[addr]
+	return ok
+}
+
+// isAvailable returns false if the initial connection to the @addr failed recently
+func (p *gettyRPCClientPool) isAvailable(addr string) bool {
+	p.Lock()
+	defer p.Unlock()
+	until, ok := p.unavailable[addr]
+	return !ok || time.Now().After(until)
 }
 
 func (p *gettyRPCClientPool) release(conn *gettyRPCClient, err error) {
@@ -0,0 +1,69 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package dubbo
+
+import (
+	"testing"
+	"time"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+import (
+	"github.com/apache/dubbo-go/protocol"
+)
+
+func TestConnectedAfterLost(t *testing.T) {
+	// the first connection isn't a reconnection
+	connected("192.168.3.1:20000")
+	assert.True(t, protocol.GetReconnectTime("192.168.3.1:20000").IsZero())
+
+	lostAddrs.Store("192.168.3.1:20000", struct{}{})
+	connected("192.168.3.1:20000")
+	reconnected := protocol.GetReconnectTime("192.168.3.1:20000")
+	assert.False(t, reconnected.IsZero())
+
+	// the reconnection is recorded once per loss
+	connected("192.168.3.1:20000")
+	assert.Equal(t, reconnected, protocol.GetReconnectTime("192.168.3.1:20000"))
+}
+
+func TestGettyRPCClientPool_Get(t *testing.T) {
+	pool := newGettyRPCClientConnPool(nil, 4, time.Minute)
+	options := tcpOptions{noDelay: true, keepAlive: true}
+	now := time.Now().Unix()
+	conn := &gettyRPCClient{protocol: DUBBO, addr: "192.168.3.1:20000", created: now, tcpOptions: options, pool: pool}
+	pool.release(conn, nil)
+
+	// the conns are pooled by the address and the tcp options
+	got, err := pool.get(DUBBO, "192.168.3.2:20000", options)
+	assert.NoError(t, err)
+	assert.Nil(t, got)
+	got, err = pool.get(DUBBO, "192.168.3.1:20000", tcpOptions{noDelay: false, keepAlive: true})
+	assert.NoError(t, err)
+	assert.Nil(t, got)
+	got, err = pool.get(DUBBO, "192.168.3.1:20000", options)
+	assert.NoError(t, err)
+	assert.Equal(t, conn, got)
+
+	// the conn is taken out of the pool
+	got, err = pool.get(DUBBO, "192.168.3.1:20000", options)
+	assert.NoError(t, err)
+	assert.Nil(t, got)
+}
@@ -32,6 +32,7 @@ import (
 	"github.com/apache/dubbo-go/common"
 	"github.com/apache/dubbo-go/common/constant"
 	"github.com/apache/dubbo-go/common/logger"
+	"github.com/apache/dubbo-go/protocol"
 )
 
 ////////////////////////////////////////////
@@ -62,7 +63,10 @@ func (p *RpcClientPackageHandler) Read(ss getty.Session, data []byte) (interface
 		return nil, 0, perrors.WithStack(err)
 	}
 
-	pkg.Err = pkg.Body.(*hessian.Response).Exception
+	if pkg.Err == nil {
+		// reconstruct the structured error of the provider
+		pkg.Err = protocol.DecodeError(pkg.Body.(*hessian.Response).Exception)
+	}
 	pkg.Body = pkg.Body.(*hessian.Response).RspObj
 
 	return pkg, hessian.HEADER_LENGTH + pkg.Header.BodyLen, nil
@@ -96,6 +96,7 @@ type Server struct {
 	conf       ServerConfig
 	tcpServer  getty.Server
 	rpcHandler *RpcServerHandler
+	tcpOptions tcpOptions
 }
 
 func NewServer() *Server {
@@ -104,7 +105,11 @@ func NewServer() *Server {
 		conf: *srvConf,
 	}
 
-	s.rpcHandler = NewRpcServerHandler(s.conf.SessionNumber, s.conf.sessionTimeout)
+	s.rpcHandler = NewRpcServerHandler(s.conf.SessionNumber, s.conf.sessionTimeout, s.conf.DuplicateRequestId,
+		s.conf.slowConsumerWriteWait, s.conf.SlowConsumerPolicy)
+	if s.conf.PriorityPoolSize > 0 {
+		s.rpcHandler.dispatcher = newPriorityDispatcher(s.conf.PriorityPoolSize, s.conf.PriorityQueueLen)
+	}
 
 	return s
 }
@@ -124,13 +129,9 @@ func (s *Server) newSession(session getty.Session) error {
 		panic(fmt.Sprintf("%s, session.conn{%#v} is not tcp connection\n", session.Stat(), session.Conn()))
 	}
 
-	tcpConn.SetNoDelay(conf.GettySessionParam.TcpNoDelay)
-	tcpConn.SetKeepAlive(conf.GettySessionParam.TcpKeepAlive)
-	if conf.GettySessionParam.TcpKeepAlive {
-		tcpConn.SetKeepAlivePeriod(conf.GettySessionParam.keepAlivePeriod)
+	if err := s.tcpOptions.apply(tcpConn); err != nil {
+		logger.Warnf("%s, failed to set the tcp options: %v", session.Stat(), err)
 	}
-	tcpConn.SetReadBuffer(conf.GettySessionParam.TcpRBufSize)
-	tcpConn.SetWriteBuffer(conf.GettySessionParam.TcpWBufSize)
 
 	session.SetName(conf.GettySessionParam.SessionName)
 	session.SetMaxMsgLen(conf.GettySessionParam.MaxMsgLen)
@@ -155,10 +156,16 @@ func (s *Server) Start(url common.URL) {
 	)
 
 	addr = url.Location
+	s.tcpOptions = newTCPOptions(url, s.conf.GettySessionParam)
 	tcpServer = getty.NewTCPServer(
 		getty.WithLocalAddress(addr),
 	)
 	tcpServer.RunEventLoop(s.newSession)
+	if listener, ok := tcpServer.Listener().(*net.TCPListener); ok {
+		if err := s.tcpOptions.applyListener(listener); err != nil {
+			logger.Warnf("failed to set the tcp options of the listener on %s: %v", addr, err)
+		}
+	}
 	logger.Debugf("s bind addr{%s} ok!", addr)
 	s.tcpServer = tcpServer
 
@@ -166,4 +173,7 @@ func (s *Server) Start(url common.URL) {
 
 func (s *Server) Stop() {
 	s.tcpServer.Close()
+	if s.rpcHandler.dispatcher != nil {
+		s.rpcHandler.dispatcher.close()
+	}
 }
@@ -0,0 +1,295 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dubbo
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"io"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+)
+
+import (
+	perrors "github.com/pkg/errors"
+)
+
+import (
+	"github.com/apache/dubbo-go/common"
+	"github.com/apache/dubbo-go/common/constant"
+	"github.com/apache/dubbo-go/common/logger"
+)
+
+// The io.Reader arguments of a call are streamed to the provider rather than buffered in the request. The call
+// carries the argument as "$stream:<id>", and the consumer sends the data of the reader by the $stream calls
+// with the arguments (id, chunk, eof, error) at the same time. The provider passes the reader of a pipe to the
+// io.Reader parameter of the method, and every chunk is acknowledged after the method reads it, so neither
+// side holds more than a chunk of the stream in memory.
+
+const streamArgumentPrefix = constant.STREAM + ":"
+
+var (
+	errStreamIdle    = perrors.New("the stream is idle for too long")
+	errStreamClosed  = perrors.New("the call reading the stream is finished")
+	errStreamUnknown = perrors.New("the stream isn't opened by any call")
+	errStreamPool    = perrors.New("the streams need the task pool of the server, set its gr_pool_size")
+
+	readerType = reflect.TypeOf((*io.Reader)(nil)).Elem()
+
+	// the stream is closed if no chunk arrives or the chunk isn't read in the idle timeout
+	streamIdleTimeout = 30 * time.Second
+	// the chunk arriving before the call waits for the call to open the stream in the open timeout
+	streamOpenTimeout = time.Second
+	// the closed streams linger to fail the late chunks fast
+	streamLinger = time.Minute
+
+	streams = newStreamRegistry()
+)
+
+func newStreamID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(b)
+}
+
+// hasStreamArgument checks if any of the @args is an io.Reader
+func hasStreamArgument(args []interface{}) bool {
+	for _, arg := range args {
+		if _, ok := arg.(io.Reader); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// sendStream sends the data of @r to the stream @id of the provider in chunks of @chunkSize bytes, each chunk is
+// acknowledged after the provider reads it. It stops once @done is closed, i.e. the call reading the stream returns.
+func (c *Client) sendStream(ctx context.Context, addr string, svcUrl common.URL, id string, r io.Reader,
+	chunkSize int, done <-chan struct{}) error {
+
+	buf := make([]byte, chunkSize)
+	for {
+		n, err := io.ReadFull(r, buf)
+		eof := err == io.EOF || err == io.ErrUnexpectedEOF
+		if err != nil && !eof {
+			// the provider fails to read the stream as well
+			if sendErr := c.sendChunk(ctx, addr, svcUrl, id, []byte{}, true, err.Error()); sendErr != nil {
+				logger.Warnf("send the error of the stream %s error: %v", id, sendErr)
+			}
+			return perrors.WithStack(err)
+		}
+		select {
+		case <-done:
+			return nil
+		default:
+		}
+		if err := c.sendChunk(ctx, addr, svcUrl, id, buf[:n], eof, ""); err != nil {
+			return err
+		}
+		if eof {
+			return nil
+		}
+	}
+}
+
+func (c *Client) sendChunk(ctx context.Context, addr string, svcUrl common.URL, id string, chunk []byte, eof bool, errMsg string) error {
+	var ack bool
+	return c.CallContext(ctx, addr, svcUrl, constant.STREAM, []interface{}{id, chunk, eof, errMsg}, &ack)
+}
+
+type streamPipe struct {
+	reader *io.PipeReader
+	writer *io.PipeWriter
+	idle   *time.Timer
+	err    error     // why the stream is closed
+	closed time.Time // zero if the stream is open
+}
+
+// touch delays the idle timeout of the stream
+func (p *streamPipe) touch() {
+	if p.idle != nil {
+		p.idle.Reset(streamIdleTimeout)
+	}
+}
+
+type streamRegistry struct {
+	lock  sync.Mutex
+	pipes map[string]*streamPipe
+	// signaled when a stream is opened
+	opened *sync.Cond
+}
+
+func newStreamRegistry() *streamRegistry {
+	s := &streamRegistry{pipes: make(map[string]*streamPipe)}
+	s.opened = sync.NewCond(&s.lock)
+	return s
+}
+
+// open returns the pipe of the stream @id, which is created by the call reading it
+func (s *streamRegistry) open(id string) *streamPipe {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	now := time.Now()
+	for key, p := range s.pipes {
+		if !p.closed.IsZero() && now.Sub(p.closed) > streamLinger {
+			delete(s.pipes, key)
+		}
+	}
+	p, ok := s.pipes[id]
+	if !ok {
+		reader, writer := io.Pipe()
+		p = &streamPipe{reader: reader, writer: writer}
+		p.idle = time.AfterFunc(streamIdleTimeout, func() {
+			s.close(id, errStreamIdle)
+		})
+		s.pipes[id] = p
+		s.opened.Broadcast()
+	}
+	return p
+}
+
+// lookup returns the pipe of the stream @id, it waits for the call to open it at most the @timeout.
+// nil is returned if the stream isn't opened.
+func (s *streamRegistry) lookup(id string, timeout time.Duration) *streamPipe {
+	deadline := time.Now().Add(timeout)
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	for {
+		if p, ok := s.pipes[id]; ok {
+			return p
+		}
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			return nil
+		}
+		timer := time.AfterFunc(remaining, func() {
+			s.lock.Lock()
+			s.opened.Broadcast()
+			s.lock.Unlock()
+		})
+		s.opened.Wait()
+		timer.Stop()
+	}
+}
+
+// close fails the reading and the writing of the stream @id with @err
+func (s *streamRegistry) close(id string, err error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	p, ok := s.pipes[id]
+	if !ok {
+		reader, writer := io.Pipe()
+		p = &streamPipe{reader: reader, writer: writer}
+		s.pipes[id] = p
+	}
+	if !p.closed.IsZero() {
+		return
+	}
+	if p.idle != nil {
+		p.idle.Stop()
+	}
+	p.writer.CloseWithError(err)
+	p.err = err
+	p.closed = time.Now()
+}
+
+// closeError returns why the stream @p is closed, or the @err of writing it
+func (s *streamRegistry) closeError(p *streamPipe, err error) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if p.err != nil {
+		return p.err
+	}
+	return err
+}
+
+// write writes the @chunk to the stream @id, it returns after the chunk is read.
+// The chunk of the stream not opened by any call is rejected.
+func (s *streamRegistry) write(id string, chunk []byte, eof bool, errMsg string) error {
+	p := s.lookup(id, streamOpenTimeout)
+	if p == nil {
+		return perrors.WithMessagef(errStreamUnknown, "stream %s", id)
+	}
+	p.touch()
+	if _, err := p.writer.Write(chunk); err != nil {
+		return perrors.WithStack(s.closeError(p, err))
+	}
+	p.touch()
+	if errMsg != "" {
+		p.writer.CloseWithError(perrors.New(errMsg))
+	} else if eof {
+		p.writer.Close()
+	}
+	return nil
+}
+
+// requestStreams returns the ids of the streams of the request @p
+func requestStreams(p *DubboPackage) []string {
+	body, ok := p.Body.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	args, _ := body["args"].([]interface{})
+	var ids []string
+	for _, arg := range args {
+		if s, ok := arg.(string); ok && strings.HasPrefix(s, streamArgumentPrefix) {
+			ids = append(ids, strings.TrimPrefix(s, streamArgumentPrefix))
+		}
+	}
+	return ids
+}
+
+// streamArgument returns the reader of the stream if the @arg is a stream and the @typ is an io.Reader interface
+func streamArgument(arg interface{}, typ reflect.Type) (reflect.Value, bool) {
+	s, ok := arg.(string)
+	if !ok || !strings.HasPrefix(s, streamArgumentPrefix) || typ.Kind() != reflect.Interface || !typ.Implements(readerType) {
+		return reflect.Value{}, false
+	}
+	return reflect.ValueOf(streams.open(strings.TrimPrefix(s, streamArgumentPrefix)).reader), true
+}
+
+// handleStreamChunk writes the chunk of the $stream request @p to its stream, the body of @p is set to the
+// acknowledgement after the chunk is read, or the error
+func handleStreamChunk(p *DubboPackage) {
+	body, _ := p.Body.(map[string]interface{})
+	args, _ := body["args"].([]interface{})
+	if len(args) != 4 {
+		p.Body = perrors.Errorf("illegal arguments of %s: %v", constant.STREAM, args)
+		return
+	}
+	id, ok1 := args[0].(string)
+	chunk, ok2 := args[1].([]byte)
+	eof, ok3 := args[2].(bool)
+	errMsg, ok4 := args[3].(string)
+	if !ok1 || !ok2 || !ok3 || !ok4 {
+		p.Body = perrors.Errorf("illegal arguments of %s: %v", constant.STREAM, args)
+		return
+	}
+	if err := streams.write(id, chunk, eof, errMsg); err != nil {
+		p.Body = err
+		return
+	}
+	p.Body = true
+}
@@ -0,0 +1,210 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dubbo
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sync"
+	"testing"
+	"time"
+)
+
+import (
+	perrors "github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+import (
+	"github.com/apache/dubbo-go/common/constant"
+	"github.com/apache/dubbo-go/protocol/invocation"
+)
+
+// streamSource generates the bytes of the stream on reading, and fails after the @failAt bytes if it's positive
+type streamSource struct {
+	lock     sync.Mutex
+	size     int64
+	produced int64
+	sum      int64
+	failAt   int64
+}
+
+func (s *streamSource) Read(b []byte) (int, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.failAt > 0 && s.produced >= s.failAt {
+		return 0, perrors.New("broken source")
+	}
+	left := s.size - s.produced
+	if left <= 0 {
+		return 0, io.EOF
+	}
+	if int64(len(b)) > left {
+		b = b[:left]
+	}
+	for i := range b {
+		b[i] = byte((s.produced + int64(i)) % 251)
+		s.sum += int64(b[i])
+	}
+	s.produced += int64(len(b))
+	return len(b), nil
+}
+
+func (s *streamSource) producedBytes() int64 {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.produced
+}
+
+func streamInvoker(t *testing.T) (*DubboInvoker, func()) {
+	// the streams are handled on the task pool of the server
+	conf := *srvConf
+	srvConf.GrPoolSize, srvConf.QueueLen, srvConf.QueueNumber = 8, 64, 2
+	SetServerGrpool()
+	proto, url := InitTest(t)
+	url.Params.Set(constant.STREAM_CHUNK_SIZE_KEY, "8192")
+	c := &Client{
+		pendingResponses: new(sync.Map),
+		conf:             *clientConf,
+		opts: Options{
+			ConnectTimeout: 3e9,
+			RequestTimeout: 6e9,
+		},
+	}
+	c.pool = newGettyRPCClientConnPool(c, clientConf.PoolSize, time.Duration(int(time.Second)*clientConf.PoolTTL))
+	return NewDubboInvoker(url, c), func() {
+		proto.Destroy()
+		srvGrpool.Close()
+		srvGrpool = nil
+		*srvConf = conf
+	}
+}
+
+func TestDubboInvoker_Stream(t *testing.T) {
+	invoker, destroy := streamInvoker(t)
+	defer destroy()
+
+	source := &streamSource{size: 1 << 20}
+	var (
+		lock                sync.Mutex
+		reads, maxRead      int
+		producedAtFirstRead int64
+	)
+	onStreamRead = func(n int) {
+		lock.Lock()
+		defer lock.Unlock()
+		if reads == 0 {
+			producedAtFirstRead = source.producedBytes()
+		}
+		reads++
+		if n > maxRead {
+			maxRead = n
+		}
+	}
+	defer func() { onStreamRead = func(n int) {} }()
+
+	user := &User{}
+	inv := invocation.NewRPCInvocationWithOptions(invocation.WithMethodName("GetUser9"),
+		invocation.WithArguments([]interface{}{"1", source}), invocation.WithReply(user))
+	res := invoker.Invoke(inv)
+	assert.NoError(t, res.Error())
+	assert.Equal(t, User{Id: "1", Name: fmt.Sprintf("%d:%d", source.size, source.sum)}, *user)
+
+	// the provider reads the stream by chunks before the consumer reads it all
+	lock.Lock()
+	defer lock.Unlock()
+	assert.True(t, producedAtFirstRead < source.size)
+	assert.True(t, reads >= 128)
+	assert.Equal(t, 8192, maxRead)
+}
+
+func TestDubboInvoker_StreamError(t *testing.T) {
+	invoker, destroy := streamInvoker(t)
+	defer destroy()
+
+	// the error of the consumer's reader fails the reading of the provider
+	inv := invocation.NewRPCInvocationWithOptions(invocation.WithMethodName("GetUser9"),
+		invocation.WithArguments([]interface{}{"1", &streamSource{size: 1 << 20, failAt: 1 << 16}}), invocation.WithReply(&User{}))
+	res := invoker.Invoke(inv)
+	assert.Error(t, res.Error())
+	assert.Contains(t, res.Error().Error(), "broken source")
+
+	// the async calls can't stream
+	inv = invocation.NewRPCInvocationWithOptions(invocation.WithMethodName("GetUser9"),
+		invocation.WithArguments([]interface{}{"1", &streamSource{size: 1}}),
+		invocation.WithAttachments(map[string]string{constant.ASYNC_KEY: "true"}))
+	res = invoker.Invoke(inv)
+	assert.Equal(t, Err_Async_Streaming, res.Error())
+}
+
+func TestStreamRegistry(t *testing.T) {
+	// the chunk arriving before the call waits for the reading
+	id := newStreamID()
+	written := make(chan error, 1)
+	go func() {
+		written <- streams.write(id, []byte("chunk"), true, "")
+	}()
+	select {
+	case <-written:
+		assert.Fail(t, "the chunk is acknowledged before it's read")
+	case <-time.After(100 * time.Millisecond):
+	}
+	data, err := ioutil.ReadAll(streams.open(id).reader)
+	assert.NoError(t, err)
+	assert.Equal(t, "chunk", string(data))
+	assert.NoError(t, <-written)
+
+	// the late chunks of the finished call fail fast
+	streams.close(id, errStreamClosed)
+	assert.Equal(t, errStreamClosed, perrors.Cause(streams.write(id, []byte("late"), false, "")))
+
+	// the chunk of the stream not opened by any call is rejected
+	timeout := streamOpenTimeout
+	streamOpenTimeout = 100 * time.Millisecond
+	defer func() { streamOpenTimeout = timeout }()
+	start := time.Now()
+	assert.Equal(t, errStreamUnknown, perrors.Cause(streams.write(newStreamID(), []byte("chunk"), false, "")))
+	assert.True(t, time.Since(start) < streamIdleTimeout)
+}
+
+func TestHandleStreamChunk(t *testing.T) {
+	// the illegal chunks are rejected
+	p := &DubboPackage{Body: "chunk"}
+	handleStreamChunk(p)
+	assert.Error(t, p.Body.(error))
+
+	p = &DubboPackage{Body: map[string]interface{}{"args": []interface{}{1, "chunk", true, ""}}}
+	handleStreamChunk(p)
+	assert.Error(t, p.Body.(error))
+}
+
+func TestStreamRegistry_Idle(t *testing.T) {
+	timeout := streamIdleTimeout
+	streamIdleTimeout = 100 * time.Millisecond
+	defer func() { streamIdleTimeout = timeout }()
+
+	// the reading fails if no chunk arrives
+	_, err := ioutil.ReadAll(streams.open(newStreamID()).reader)
+	assert.Equal(t, errStreamIdle, err)
+
+	// the chunk fails if it isn't read
+	id := newStreamID()
+	streams.open(id)
+	assert.Equal(t, errStreamIdle, perrors.Cause(streams.write(id, []byte("chunk"), false, "")))
+}
@@ -0,0 +1,97 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dubbo
+
+import (
+	"net"
+	"time"
+)
+
+import (
+	perrors "github.com/pkg/errors"
+)
+
+import (
+	"github.com/apache/dubbo-go/common"
+	"github.com/apache/dubbo-go/common/constant"
+)
+
+// tcpOptions are the socket options applied to the connections, the url params override the getty session config
+type tcpOptions struct {
+	noDelay         bool
+	keepAlive       bool
+	keepAlivePeriod time.Duration
+	sendBuffer      int
+	recvBuffer      int
+	// SO_REUSEADDR of the server listener is left as the system default unless tcp.reuseaddr is configured
+	reuseAddr    bool
+	setReuseAddr bool
+}
+
+func newTCPOptions(url common.URL, param GettySessionParam) tcpOptions {
+	options := tcpOptions{
+		noDelay:         url.GetParamBool(constant.TCP_NODELAY_KEY, param.TcpNoDelay),
+		keepAlive:       url.GetParamBool(constant.TCP_KEEPALIVE_KEY, param.TcpKeepAlive),
+		keepAlivePeriod: param.keepAlivePeriod,
+		sendBuffer:      int(url.GetParamInt(constant.TCP_SEND_BUFFER_KEY, int64(param.TcpWBufSize))),
+		recvBuffer:      int(url.GetParamInt(constant.TCP_RECV_BUFFER_KEY, int64(param.TcpRBufSize))),
+	}
+	if period := url.GetParamInt(constant.TCP_KEEPALIVE_PERIOD_KEY, 0); period > 0 {
+		options.keepAlivePeriod = time.Duration(period) * time.Millisecond
+	}
+	if reuseAddr := url.GetParam(constant.TCP_REUSEADDR_KEY, ""); reuseAddr != "" {
+		options.reuseAddr = url.GetParamBool(constant.TCP_REUSEADDR_KEY, false)
+		options.setReuseAddr = true
+	}
+	return options
+}
+
+func (o tcpOptions) apply(conn *net.TCPConn) error {
+	if err := conn.SetNoDelay(o.noDelay); err != nil {
+		return perrors.WithMessage(err, "SetNoDelay")
+	}
+	if err := conn.SetKeepAlive(o.keepAlive); err != nil {
+		return perrors.WithMessage(err, "SetKeepAlive")
+	}
+	if o.keepAlive && o.keepAlivePeriod > 0 {
+		if err := conn.SetKeepAlivePeriod(o.keepAlivePeriod); err != nil {
+			return perrors.WithMessage(err, "SetKeepAlivePeriod")
+		}
+	}
+	if o.recvBuffer > 0 {
+		if err := conn.SetReadBuffer(o.recvBuffer); err != nil {
+			return perrors.WithMessage(err, "SetReadBuffer")
+		}
+	}
+	if o.sendBuffer > 0 {
+		if err := conn.SetWriteBuffer(o.sendBuffer); err != nil {
+			return perrors.WithMessage(err, "SetWriteBuffer")
+		}
+	}
+	return nil
+}
+
+// applyListener applies the options of the listening socket to the server @listener
+func (o tcpOptions) applyListener(listener *net.TCPListener) error {
+	if o.setReuseAddr {
+		if err := setReuseAddr(listener, o.reuseAddr); err != nil {
+			return perrors.WithMessage(err, "setReuseAddr")
+		}
+	}
+	return nil
+}
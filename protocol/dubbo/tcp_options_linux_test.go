@@ -0,0 +1,177 @@
+//go:build linux
+// +build linux
+
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dubbo
+
+import (
+	"context"
+	"net"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+import (
+	"github.com/apache/dubbo-go/common"
+)
+
+func getsockopt(t *testing.T, conn syscall.Conn, level, opt int) int {
+	rawConn, err := conn.SyscallConn()
+	assert.NoError(t, err)
+	var (
+		value   int
+		sockErr error
+	)
+	assert.NoError(t, rawConn.Control(func(fd uintptr) {
+		value, sockErr = syscall.GetsockoptInt(int(fd), level, opt)
+	}))
+	assert.NoError(t, sockErr)
+	return value
+}
+
+func TestTCPOptions_Apply(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	defer listener.Close()
+	accepted := make(chan struct{})
+	go func() {
+		defer close(accepted)
+		if conn, err := listener.Accept(); err == nil {
+			defer conn.Close()
+			// the server side applies the same options to the accepted connections
+			options := tcpOptions{noDelay: true, keepAlive: true, keepAlivePeriod: 20 * time.Second}
+			assert.NoError(t, options.apply(conn.(*net.TCPConn)))
+			assert.Equal(t, 20, getsockopt(t, conn.(*net.TCPConn), syscall.IPPROTO_TCP, syscall.TCP_KEEPIDLE))
+		}
+	}()
+
+	conn, err := net.Dial("tcp", listener.Addr().String())
+	assert.NoError(t, err)
+	defer conn.Close()
+	tcpConn := conn.(*net.TCPConn)
+
+	options := tcpOptions{
+		noDelay:         false,
+		keepAlive:       true,
+		keepAlivePeriod: 30 * time.Second,
+		sendBuffer:      32768,
+		recvBuffer:      131072,
+	}
+	assert.NoError(t, options.apply(tcpConn))
+	assert.Equal(t, 0, getsockopt(t, tcpConn, syscall.IPPROTO_TCP, syscall.TCP_NODELAY))
+	assert.Equal(t, 1, getsockopt(t, tcpConn, syscall.SOL_SOCKET, syscall.SO_KEEPALIVE))
+	assert.Equal(t, 30, getsockopt(t, tcpConn, syscall.IPPROTO_TCP, syscall.TCP_KEEPIDLE))
+	// linux doubles the buffer sizes for the bookkeeping overhead
+	assert.Equal(t, 2*32768, getsockopt(t, tcpConn, syscall.SOL_SOCKET, syscall.SO_SNDBUF))
+	assert.Equal(t, 2*131072, getsockopt(t, tcpConn, syscall.SOL_SOCKET, syscall.SO_RCVBUF))
+
+	options = tcpOptions{noDelay: true}
+	assert.NoError(t, options.apply(tcpConn))
+	assert.Equal(t, 1, getsockopt(t, tcpConn, syscall.IPPROTO_TCP, syscall.TCP_NODELAY))
+	assert.Equal(t, 0, getsockopt(t, tcpConn, syscall.SOL_SOCKET, syscall.SO_KEEPALIVE))
+	<-accepted
+}
+
+func TestServer_ReuseAddr(t *testing.T) {
+	conf := srvConf
+	srvConf = &ServerConfig{}
+	defer func() {
+		srvConf = conf
+	}()
+
+	listenerReuseAddr := func(params string) int {
+		url, err := common.NewURL(context.Background(), "dubbo://127.0.0.1:0/UserProvider"+params)
+		assert.NoError(t, err)
+		server := NewServer()
+		server.Start(url)
+		defer server.Stop()
+		return getsockopt(t, server.tcpServer.Listener().(*net.TCPListener), syscall.SOL_SOCKET, syscall.SO_REUSEADDR)
+	}
+	// go enables SO_REUSEADDR of the listeners by default
+	assert.Equal(t, 1, listenerReuseAddr(""))
+	assert.Equal(t, 0, listenerReuseAddr("?tcp.reuseaddr=false"))
+	assert.Equal(t, 1, listenerReuseAddr("?tcp.reuseaddr=true"))
+}
+
+func TestClient_TCPOptions(t *testing.T) {
+	conf := ClientConfig{
+		ConnectionNum:   1,
+		HeartbeatPeriod: "5s",
+		SessionTimeout:  "20s",
+		PoolTTL:         600,
+		PoolSize:        64,
+		GettySessionParam: GettySessionParam{
+			TcpNoDelay:      true,
+			TcpKeepAlive:    true,
+			KeepAlivePeriod: "120s",
+			TcpRBufSize:     262144,
+			TcpWBufSize:     65536,
+			PkgWQSize:       512,
+			TcpReadTimeout:  "4s",
+			TcpWriteTimeout: "5s",
+			WaitTimeout:     "1s",
+			MaxMsgLen:       1024,
+			SessionName:     "client",
+		},
+	}
+	assert.NoError(t, conf.CheckValidity())
+	c := &Client{
+		pendingResponses: new(sync.Map),
+		conf:             conf,
+		opts: Options{
+			ConnectTimeout: 3e9,
+			RequestTimeout: 6e9,
+		},
+	}
+	c.pool = newGettyRPCClientConnPool(c, conf.PoolSize, time.Duration(int(time.Second)*conf.PoolTTL))
+	defer c.Close()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	defer listener.Close()
+	go func() {
+		for {
+			if _, err := listener.Accept(); err != nil {
+				return
+			}
+		}
+	}()
+
+	addr := listener.Addr().String()
+	url, err := common.NewURL(context.Background(), "dubbo://"+addr+"/UserProvider?tcp.nodelay=false&tcp.keepalive.period=60000"+
+		"&tcp.send.buffer=16384")
+	assert.NoError(t, err)
+	conn, session, err := c.selectSession(addr, url)
+	assert.NoError(t, err)
+	defer c.pool.release(conn, nil)
+
+	// the options are applied to the client connection
+	tcpConn := session.Conn().(*net.TCPConn)
+	assert.Equal(t, 0, getsockopt(t, tcpConn, syscall.IPPROTO_TCP, syscall.TCP_NODELAY))
+	assert.Equal(t, 1, getsockopt(t, tcpConn, syscall.SOL_SOCKET, syscall.SO_KEEPALIVE))
+	assert.Equal(t, 60, getsockopt(t, tcpConn, syscall.IPPROTO_TCP, syscall.TCP_KEEPIDLE))
+	assert.Equal(t, 2*16384, getsockopt(t, tcpConn, syscall.SOL_SOCKET, syscall.SO_SNDBUF))
+	assert.Equal(t, 2*262144, getsockopt(t, tcpConn, syscall.SOL_SOCKET, syscall.SO_RCVBUF))
+}
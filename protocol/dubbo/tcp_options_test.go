@@ -0,0 +1,70 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dubbo
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+import (
+	"github.com/apache/dubbo-go/common"
+)
+
+func TestNewTCPOptions(t *testing.T) {
+	param := GettySessionParam{
+		TcpNoDelay:      true,
+		TcpKeepAlive:    true,
+		keepAlivePeriod: 180 * time.Second,
+		TcpRBufSize:     262144,
+		TcpWBufSize:     65536,
+	}
+
+	// the getty session config by default
+	url, _ := common.NewURL(context.TODO(), "dubbo://127.0.0.1:20000/UserProvider")
+	assert.Equal(t, tcpOptions{
+		noDelay:         true,
+		keepAlive:       true,
+		keepAlivePeriod: 180 * time.Second,
+		sendBuffer:      65536,
+		recvBuffer:      262144,
+	}, newTCPOptions(url, param))
+
+	url, _ = common.NewURL(context.TODO(), "dubbo://127.0.0.1:20000/UserProvider?tcp.nodelay=false&tcp.keepalive.period=30000"+
+		"&tcp.send.buffer=32768&tcp.recv.buffer=131072&tcp.reuseaddr=true")
+	assert.Equal(t, tcpOptions{
+		noDelay:         false,
+		keepAlive:       true,
+		keepAlivePeriod: 30 * time.Second,
+		sendBuffer:      32768,
+		recvBuffer:      131072,
+		reuseAddr:       true,
+		setReuseAddr:    true,
+	}, newTCPOptions(url, param))
+
+	url, _ = common.NewURL(context.TODO(), "dubbo://127.0.0.1:20000/UserProvider?tcp.keepalive=false&tcp.reuseaddr=false")
+	options := newTCPOptions(url, param)
+	assert.False(t, options.keepAlive)
+	assert.False(t, options.reuseAddr)
+	assert.True(t, options.setReuseAddr)
+}
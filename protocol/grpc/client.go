@@ -0,0 +1,82 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package grpc
+
+import (
+	"context"
+	"crypto/tls"
+	"reflect"
+	"strconv"
+	"time"
+)
+
+import (
+	perrors "github.com/pkg/errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+)
+
+import (
+	"github.com/apache/dubbo-go/common"
+	"github.com/apache/dubbo-go/common/constant"
+)
+
+// Client is the connection to a gRPC provider and the client stub of its service
+type Client struct {
+	conn           *grpc.ClientConn
+	stub           interface{}
+	requestTimeout time.Duration
+}
+
+// NewClient connects to the provider of the @url lazily, with the TLS and the max message size of it
+func NewClient(url common.URL, requestTimeout time.Duration) (*Client, error) {
+	svc, err := getService(serviceName(url))
+	if err != nil {
+		return nil, err
+	}
+
+	size := int(url.GetParamInt(constant.GRPC_MAX_MESSAGE_SIZE_KEY, constant.DEFAULT_GRPC_MAX_MESSAGE_SIZE))
+	opts := []grpc.DialOption{
+		grpc.WithDefaultCallOptions(grpc.MaxCallRecvMsgSize(size), grpc.MaxCallSendMsgSize(size)),
+	}
+	if useTLS, _ := strconv.ParseBool(url.GetParam(constant.GRPC_TLS_KEY, "false")); useTLS {
+		creds := credentials.NewTLS(&tls.Config{})
+		if certFile := url.GetParam(constant.GRPC_TLS_CERT_FILE_KEY, ""); certFile != "" {
+			if creds, err = credentials.NewClientTLSFromFile(certFile, ""); err != nil {
+				return nil, perrors.WithStack(err)
+			}
+		}
+		opts = append(opts, grpc.WithTransportCredentials(creds))
+	} else {
+		opts = append(opts, grpc.WithInsecure())
+	}
+
+	conn, err := grpc.DialContext(context.Background(), url.Location, opts...)
+	if err != nil {
+		return nil, perrors.WithStack(err)
+	}
+	return &Client{
+		conn:           conn,
+		stub:           svc.newClient.Call([]reflect.Value{reflect.ValueOf(conn)})[0].Interface(),
+		requestTimeout: requestTimeout,
+	}, nil
+}
+
+func (c *Client) Close() error {
+	return perrors.WithStack(c.conn.Close())
+}
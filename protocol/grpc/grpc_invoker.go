@@ -0,0 +1,162 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package grpc
+
+import (
+	"context"
+	"reflect"
+	"strconv"
+	"sync"
+	"time"
+)
+
+import (
+	perrors "github.com/pkg/errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+import (
+	"github.com/apache/dubbo-go/common"
+	"github.com/apache/dubbo-go/common/constant"
+	"github.com/apache/dubbo-go/common/logger"
+	"github.com/apache/dubbo-go/protocol"
+	invocation_impl "github.com/apache/dubbo-go/protocol/invocation"
+)
+
+var errNoClient = perrors.New("the client of the gRPC service isn't created")
+
+// GrpcInvoker calls the method of the invocation by the client stub of the gRPC service, the arguments of the
+// invocation are the request messages. The attachments are sent as the metadata of the call, and the header and
+// the trailer of the response are the attachments of the result.
+type GrpcInvoker struct {
+	protocol.BaseInvoker
+	client      *Client
+	destroyLock sync.Mutex
+}
+
+func NewGrpcInvoker(url common.URL, client *Client) *GrpcInvoker {
+	return &GrpcInvoker{
+		BaseInvoker: *protocol.NewBaseInvoker(url),
+		client:      client,
+	}
+}
+
+func (gi *GrpcInvoker) Invoke(invocation protocol.Invocation) protocol.Result {
+	var result protocol.RPCResult
+
+	inv := invocation.(*invocation_impl.RPCInvocation)
+	url := gi.GetUrl()
+	if gi.client == nil {
+		result.Err = errNoClient
+		return &result
+	}
+	if result.Err = protocol.LimitAttachments(url, inv); result.Err != nil {
+		return &result
+	}
+	method := reflect.ValueOf(gi.client.stub).MethodByName(inv.MethodName())
+	if !method.IsValid() {
+		result.Err = perrors.Errorf("the method %s of the gRPC service %s isn't found", inv.MethodName(), serviceName(url))
+		return &result
+	}
+	// the generated method is func(ctx, request..., opts ...grpc.CallOption) (*Reply, error)
+	if method.Type().NumIn() != len(inv.Arguments())+2 || method.Type().NumOut() != 2 {
+		result.Err = perrors.Errorf("the method %s of the gRPC service %s can't be called with %d arguments",
+			inv.MethodName(), serviceName(url), len(inv.Arguments()))
+		return &result
+	}
+
+	ctx, cancel := gi.callContext(inv)
+	defer cancel()
+	var header, trailer metadata.MD
+	in := []reflect.Value{reflect.ValueOf(ctx)}
+	for i, arg := range inv.Arguments() {
+		if arg == nil {
+			in = append(in, reflect.Zero(method.Type().In(i+1)))
+		} else {
+			in = append(in, reflect.ValueOf(arg))
+		}
+	}
+	in = append(in, reflect.ValueOf(grpc.Header(&header)), reflect.ValueOf(grpc.Trailer(&trailer)))
+	outs := method.Call(in)
+
+	result.Attrs = attachments(header, trailer)
+	if err, ok := outs[1].Interface().(error); ok && err != nil {
+		result.Err = perrors.WithStack(err)
+		return &result
+	}
+	result.Rest = outs[0].Interface()
+	// the reply of the invocation is filled with the response
+	if reply := reflect.ValueOf(inv.Reply()); reply.IsValid() && reply.Type() == outs[0].Type() && reply.Kind() == reflect.Ptr && !outs[0].IsNil() {
+		reply.Elem().Set(outs[0].Elem())
+		result.Rest = inv.Reply()
+	}
+	logger.Debugf("result.Err: %v, result.Rest: %v", result.Err, result.Rest)
+
+	return &result
+}
+
+// callContext returns the context of the @inv with its attachments as the metadata, and the request timeout
+// of the attachment in milliseconds or of the client
+func (gi *GrpcInvoker) callContext(inv *invocation_impl.RPCInvocation) (context.Context, context.CancelFunc) {
+	ctx := inv.Context()
+	if attachments := inv.Attachments(); len(attachments) > 0 {
+		ctx = metadata.NewOutgoingContext(ctx, metadata.New(attachments))
+	}
+	timeout := gi.client.requestTimeout
+	if v, err := strconv.ParseInt(inv.AttachmentsByKey(constant.TIMEOUT_KEY, ""), 10, 64); err == nil && v > 0 {
+		timeout = time.Duration(v) * time.Millisecond
+	}
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// attachments returns the first values of the metadata of the response
+func attachments(mds ...metadata.MD) map[string]string {
+	attachments := map[string]string{}
+	for _, md := range mds {
+		for k, v := range md {
+			if len(v) > 0 {
+				attachments[k] = v[0]
+			}
+		}
+	}
+	return attachments
+}
+
+func (gi *GrpcInvoker) Destroy() {
+	if gi.IsDestroyed() {
+		return
+	}
+	gi.destroyLock.Lock()
+	defer gi.destroyLock.Unlock()
+
+	if gi.IsDestroyed() {
+		return
+	}
+
+	gi.BaseInvoker.Destroy()
+
+	if gi.client != nil {
+		if err := gi.client.Close(); err != nil {
+			logger.Warnf("close the gRPC client of %s error: %v", gi.GetUrl().Location, err)
+		}
+	}
+}
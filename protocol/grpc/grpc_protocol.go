@@ -0,0 +1,115 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package grpc
+
+import (
+	"sync"
+)
+
+import (
+	"github.com/apache/dubbo-go/common"
+	"github.com/apache/dubbo-go/common/extension"
+	"github.com/apache/dubbo-go/common/logger"
+	"github.com/apache/dubbo-go/config"
+	"github.com/apache/dubbo-go/protocol"
+)
+
+const GRPC = "grpc"
+
+func init() {
+	extension.SetProtocol(GRPC, GetProtocol)
+}
+
+var grpcProtocol *GrpcProtocol
+
+// GrpcProtocol exports and refers the gRPC services registered by RegisterService, the services are keyed by
+// their interfaces, which are the names of the gRPC services.
+type GrpcProtocol struct {
+	protocol.BaseProtocol
+	serverMap  map[string]*Server
+	serverLock sync.Mutex
+}
+
+func NewGrpcProtocol() *GrpcProtocol {
+	return &GrpcProtocol{
+		BaseProtocol: protocol.NewBaseProtocol(),
+		serverMap:    make(map[string]*Server),
+	}
+}
+
+func (gp *GrpcProtocol) Export(invoker protocol.Invoker) protocol.Exporter {
+	url := invoker.GetUrl()
+	serviceKey := serviceName(url)
+	if _, err := getService(serviceKey); err != nil {
+		panic("[GrpcProtocol] " + err.Error())
+	}
+
+	exporter := NewGrpcExporter(serviceKey, invoker, gp.ExporterMap())
+	gp.SetExporterMap(serviceKey, exporter)
+	logger.Infof("Export service: %s", url.String())
+
+	// start server
+	gp.openServer(url)
+
+	return exporter
+}
+
+func (gp *GrpcProtocol) Refer(url common.URL) protocol.Invoker {
+	client, err := NewClient(url, config.GetConsumerConfig().RequestTimeout)
+	if err != nil {
+		// no invoker without the client, e.g. the registry directory skips the provider
+		logger.Errorf("[GrpcProtocol] refer the service %s error: %v", url.String(), err)
+		return nil
+	}
+	invoker := NewGrpcInvoker(url, client)
+	gp.SetInvokers(invoker)
+	logger.Infof("Refer service: %s", url.String())
+	return invoker
+}
+
+func (gp *GrpcProtocol) Destroy() {
+	logger.Infof("grpcProtocol destroy.")
+
+	gp.BaseProtocol.Destroy()
+
+	// stop server
+	gp.serverLock.Lock()
+	defer gp.serverLock.Unlock()
+	for key, server := range gp.serverMap {
+		delete(gp.serverMap, key)
+		server.Stop()
+	}
+}
+
+func (gp *GrpcProtocol) openServer(url common.URL) {
+	gp.serverLock.Lock()
+	defer gp.serverLock.Unlock()
+	if _, ok := gp.serverMap[url.Location]; ok {
+		return
+	}
+	srv := NewServer(gp.ExporterMap())
+	gp.serverMap[url.Location] = srv
+	srv.Start(url)
+}
+
+func GetProtocol() protocol.Protocol {
+	if grpcProtocol == nil {
+		grpcProtocol = NewGrpcProtocol()
+	}
+	return grpcProtocol
+}
@@ -0,0 +1,170 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package grpc
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+import (
+	perrors "github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/metadata"
+)
+
+import (
+	_ "github.com/apache/dubbo-go/cluster/cluster_impl"
+	"github.com/apache/dubbo-go/cluster/directory"
+	"github.com/apache/dubbo-go/common"
+	"github.com/apache/dubbo-go/common/extension"
+	"github.com/apache/dubbo-go/common/proxy/proxy_factory"
+	"github.com/apache/dubbo-go/filter"
+	"github.com/apache/dubbo-go/protocol"
+	invocation_impl "github.com/apache/dubbo-go/protocol/invocation"
+	"github.com/apache/dubbo-go/protocol/protocolwrapper"
+)
+
+func init() {
+	RegisterService(&_Greeter_serviceDesc, NewGreeterClient)
+	extension.SetFilter("grpc_test_consumer", func() filter.Filter { return &consumerFilter{} })
+	extension.SetFilter("grpc_test_provider", func() filter.Filter { return &providerFilter{} })
+}
+
+// GreeterProvider is the implementation of the helloworld.Greeter, it greets with the tag of the metadata
+type GreeterProvider struct {
+}
+
+func (p *GreeterProvider) SayHello(ctx context.Context, req *HelloRequest) (*HelloReply, error) {
+	if req.Name == "error" {
+		return nil, perrors.New("greeting error")
+	}
+	md, _ := metadata.FromIncomingContext(ctx)
+	return &HelloReply{Message: "Hello " + req.Name + md.Get("tag")[0]}, nil
+}
+
+func (p *GreeterProvider) Reference() string {
+	return "GreeterProvider"
+}
+
+type GreeterConsumer struct {
+	SayHello func(ctx context.Context, req *HelloRequest) (*HelloReply, error)
+}
+
+func (c *GreeterConsumer) Reference() string {
+	return "GreeterConsumer"
+}
+
+// consumerFilter tags the invocations, and records the attachments of the results
+type consumerFilter struct {
+}
+
+var servedBy string
+
+func (f *consumerFilter) Invoke(invoker protocol.Invoker, invocation protocol.Invocation) protocol.Result {
+	invocation.(*invocation_impl.RPCInvocation).SetAttachments("tag", "!")
+	return invoker.Invoke(invocation)
+}
+
+func (f *consumerFilter) OnResponse(result protocol.Result, invoker protocol.Invoker, invocation protocol.Invocation) protocol.Result {
+	servedBy = result.Attachment("served.by", "")
+	return result
+}
+
+// providerFilter rejects the invocations without the tag, and adds the attachment to the results
+type providerFilter struct {
+}
+
+func (f *providerFilter) Invoke(invoker protocol.Invoker, invocation protocol.Invocation) protocol.Result {
+	if invocation.AttachmentsByKey("tag", "") == "" {
+		return &protocol.RPCResult{Err: perrors.New("no tag")}
+	}
+	return invoker.Invoke(invocation)
+}
+
+func (f *providerFilter) OnResponse(result protocol.Result, invoker protocol.Invoker, invocation protocol.Invocation) protocol.Result {
+	result.AddAttachment("served.by", "grpc")
+	return result
+}
+
+func TestGrpcProtocol_ExportAndRefer(t *testing.T) {
+	_, err := common.ServiceMap.Register(GRPC, &GreeterProvider{})
+	assert.NoError(t, err)
+	providerUrl, err := common.NewURL(context.Background(), "grpc://127.0.0.1:30000/GreeterProvider?"+
+		"interface=helloworld.Greeter&bean.name=GreeterProvider&service.filter=grpc_test_provider")
+	assert.NoError(t, err)
+	exporter := protocolwrapper.GetProtocol().Export(protocol.NewBaseInvoker(providerUrl))
+	defer GetProtocol().Destroy()
+	_, ok := GetProtocol().(*GrpcProtocol).ExporterMap().Load("helloworld.Greeter")
+	assert.True(t, ok)
+
+	// refer by the reference through the filter chain and the cluster
+	consumerUrl, err := common.NewURL(context.Background(), "grpc://127.0.0.1:30000/GreeterConsumer?"+
+		"interface=helloworld.Greeter&reference.filter=grpc_test_consumer&retries=0")
+	assert.NoError(t, err)
+	invoker := protocolwrapper.GetProtocol().Refer(consumerUrl)
+	clusterInvoker := extension.GetCluster("failover").Join(directory.NewStaticDirectory([]protocol.Invoker{invoker}))
+	consumer := &GreeterConsumer{}
+	proxy_factory.NewDefaultProxyFactory().GetProxy(clusterInvoker, &consumerUrl).Implement(consumer)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	reply, err := consumer.SayHello(ctx, &HelloRequest{Name: "dubbo"})
+	assert.NoError(t, err)
+	assert.Equal(t, "Hello dubbo!", reply.Message)
+	assert.Equal(t, "grpc", servedBy)
+
+	_, err = consumer.SayHello(ctx, &HelloRequest{Name: "error"})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "greeting error")
+
+	// the invocations without the tag are rejected by the filter of the provider
+	reply = &HelloReply{}
+	res := invoker.Invoke(newInvocation("SayHello", &HelloRequest{Name: "grpc"}, reply))
+	assert.NoError(t, res.Error())
+	assert.Equal(t, "Hello grpc!", reply.Message)
+	assert.Equal(t, "grpc", res.Attachment("served.by", ""))
+	res = GetProtocol().Refer(consumerUrl).Invoke(newInvocation("SayHello", &HelloRequest{Name: "dubbo"}, &HelloReply{}))
+	assert.Error(t, res.Error())
+	assert.Contains(t, res.Error().Error(), "no tag")
+
+	// the unknown methods
+	res = invoker.Invoke(newInvocation("SayGoodbye", &HelloRequest{Name: "dubbo"}, &HelloReply{}))
+	assert.Error(t, res.Error())
+
+	// the service isn't served after unexport
+	exporter.Unexport()
+	_, err = consumer.SayHello(ctx, &HelloRequest{Name: "dubbo"})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "isn't exported")
+}
+
+func TestGrpcProtocol_ReferError(t *testing.T) {
+	// the client of the unknown service isn't created
+	url, err := common.NewURL(context.Background(), "grpc://127.0.0.1:30001/UnknownConsumer?interface=helloworld.Unknown")
+	assert.NoError(t, err)
+	assert.Nil(t, GetProtocol().Refer(url))
+	assert.Nil(t, protocolwrapper.GetProtocol().Refer(url))
+}
+
+func newInvocation(methodName string, req *HelloRequest, reply *HelloReply) *invocation_impl.RPCInvocation {
+	return invocation_impl.NewRPCInvocationWithOptions(invocation_impl.WithMethodName(methodName),
+		invocation_impl.WithArguments([]interface{}{req}), invocation_impl.WithReply(reply),
+		invocation_impl.WithAttachments(map[string]string{}))
+}
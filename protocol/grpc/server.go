@@ -0,0 +1,177 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package grpc
+
+import (
+	"context"
+	"net"
+	"strconv"
+	"sync"
+)
+
+import (
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+import (
+	"github.com/apache/dubbo-go/common"
+	"github.com/apache/dubbo-go/common/constant"
+	"github.com/apache/dubbo-go/common/logger"
+	"github.com/apache/dubbo-go/protocol"
+	"github.com/apache/dubbo-go/protocol/invocation"
+)
+
+// Server serves the gRPC services exported by the grpc protocol on an address. The services are dispatched by
+// their exporters of the protocol rather than registered to the gRPC server, so they can be exported after it
+// starts. The unary calls run through the filter chains of the exporters before the services.
+type Server struct {
+	exporterMap *sync.Map
+	grpcServer  *grpc.Server
+}
+
+func NewServer(exporterMap *sync.Map) *Server {
+	return &Server{exporterMap: exporterMap}
+}
+
+// Start serves on the address of the @url, with the TLS and the max message size of it
+func (s *Server) Start(url common.URL) {
+	size := int(url.GetParamInt(constant.GRPC_MAX_MESSAGE_SIZE_KEY, constant.DEFAULT_GRPC_MAX_MESSAGE_SIZE))
+	opts := []grpc.ServerOption{
+		grpc.UnknownServiceHandler(s.handleStream),
+		grpc.MaxRecvMsgSize(size),
+		grpc.MaxSendMsgSize(size),
+	}
+	if tls, _ := strconv.ParseBool(url.GetParam(constant.GRPC_TLS_KEY, "false")); tls {
+		creds, err := credentials.NewServerTLSFromFile(url.GetParam(constant.GRPC_TLS_CERT_FILE_KEY, ""),
+			url.GetParam(constant.GRPC_TLS_KEY_FILE_KEY, ""))
+		if err != nil {
+			logger.Errorf("grpc server [%s] load the TLS certificate error: %v", url.Path, err)
+			return
+		}
+		opts = append(opts, grpc.Creds(creds))
+	}
+
+	listener, err := net.Listen("tcp", url.Location)
+	if err != nil {
+		logger.Errorf("grpc server [%s] start failed: %v", url.Path, err)
+		return
+	}
+	logger.Infof("grpc server start to listen on %s", listener.Addr())
+
+	s.grpcServer = grpc.NewServer(opts...)
+	go func() {
+		if err := s.grpcServer.Serve(listener); err != nil {
+			logger.Warnf("grpc server on %s stopped: %v", listener.Addr(), err)
+		}
+	}()
+}
+
+func (s *Server) Stop() {
+	if s.grpcServer != nil {
+		s.grpcServer.Stop()
+	}
+}
+
+// handleStream dispatches the call of the @stream to the method of the exported service
+func (s *Server) handleStream(_ interface{}, stream grpc.ServerStream) error {
+	fullMethod, ok := grpc.MethodFromServerStream(stream)
+	if !ok {
+		return status.Error(codes.Internal, "no method of the stream")
+	}
+	serviceName, methodName := splitMethod(fullMethod)
+	exporter, ok := s.exporterMap.Load(serviceName)
+	if !ok {
+		return status.Errorf(codes.Unimplemented, "the service %s isn't exported", serviceName)
+	}
+	invoker := exporter.(protocol.Exporter).GetInvoker()
+	svc, err := getService(serviceName)
+	if err != nil {
+		return status.Error(codes.Unimplemented, err.Error())
+	}
+	rpcService := common.ServiceMap.GetService(GRPC, invoker.GetUrl().GetParam(constant.BEAN_NAME_KEY, ""))
+	if rpcService == nil {
+		return status.Errorf(codes.Unimplemented, "the implementation of the service %s isn't found", serviceName)
+	}
+	impl := rpcService.Rcvr().Interface()
+
+	for _, method := range svc.desc.Methods {
+		if method.MethodName == methodName {
+			reply, err := method.Handler(impl, stream.Context(), stream.RecvMsg, intercept(invoker))
+			if err != nil {
+				return err
+			}
+			return stream.SendMsg(reply)
+		}
+	}
+	// the streams are served by the service directly
+	for _, desc := range svc.desc.Streams {
+		if desc.StreamName == methodName {
+			return desc.Handler(impl, stream)
+		}
+	}
+	return status.Errorf(codes.Unimplemented, "the method %s of the service %s isn't found", methodName, serviceName)
+}
+
+// intercept runs the unary call through the filter chain of the @invoker, the metadata of the call is the
+// attachments of the invocation, and the attachments of the result are sent back as the header.
+func intercept(invoker protocol.Invoker) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		attachments := map[string]string{}
+		if md, ok := metadata.FromIncomingContext(ctx); ok {
+			for k, v := range md {
+				if len(v) > 0 {
+					attachments[k] = v[0]
+				}
+			}
+		}
+		if p, ok := peer.FromContext(ctx); ok {
+			if ip, _, err := net.SplitHostPort(p.Addr.String()); err == nil {
+				attachments[constant.REMOTE_IP_KEY] = ip
+			}
+		}
+		_, methodName := splitMethod(info.FullMethod)
+		inv := invocation.NewRPCInvocationWithOptions(invocation.WithMethodName(methodName),
+			invocation.WithArguments([]interface{}{req}), invocation.WithAttachments(attachments), invocation.WithContext(ctx))
+
+		result := invoker.Invoke(inv)
+		if len(result.Attachments()) > 0 {
+			if err := grpc.SetHeader(ctx, metadata.New(result.Attachments())); err != nil {
+				logger.Warnf("set the header of the method %s error: %v", info.FullMethod, err)
+			}
+		}
+		if err := result.Error(); err != nil {
+			return nil, err
+		}
+		if res := result.Result(); res != nil {
+			return res, nil
+		}
+
+		// the service gets the context of the invocation set by the filters, e.g. carrying the span of the tracing
+		reply, err := handler(inv.Context(), req)
+		// the filters are notified of the result of the service by the callback of the invocation, e.g. to cache it
+		if callback, ok := inv.CallBack().(func(protocol.Result)); ok {
+			callback(&protocol.RPCResult{Rest: reply, Err: err})
+		}
+		return reply, err
+	}
+}
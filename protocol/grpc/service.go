@@ -0,0 +1,83 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package grpc
+
+import (
+	"reflect"
+	"strings"
+	"sync"
+)
+
+import (
+	perrors "github.com/pkg/errors"
+	"google.golang.org/grpc"
+)
+
+import (
+	"github.com/apache/dubbo-go/common"
+	"github.com/apache/dubbo-go/common/constant"
+)
+
+var (
+	servicesLock sync.RWMutex
+	services     = make(map[string]*service)
+)
+
+// service is a gRPC service of the generated code
+type service struct {
+	desc      *grpc.ServiceDesc
+	newClient reflect.Value
+}
+
+// RegisterService registers the gRPC service of the generated @desc, e.g. the _Greeter_serviceDesc, and the
+// constructor of its client stub @newClient, e.g. NewGreeterClient, so that the service is exported and referred
+// by the grpc protocol with its service name as the interface. The implementation of the service is the RPCService
+// of the provider.
+func RegisterService(desc *grpc.ServiceDesc, newClient interface{}) {
+	fn := reflect.ValueOf(newClient)
+	connType := reflect.TypeOf(&grpc.ClientConn{})
+	if fn.Kind() != reflect.Func || fn.Type().NumIn() != 1 || fn.Type().In(0) != connType || fn.Type().NumOut() != 1 {
+		panic("the client constructor of the gRPC service " + desc.ServiceName + " must be func(*grpc.ClientConn) Client")
+	}
+	servicesLock.Lock()
+	services[desc.ServiceName] = &service{desc: desc, newClient: fn}
+	servicesLock.Unlock()
+}
+
+func getService(serviceName string) (*service, error) {
+	servicesLock.RLock()
+	defer servicesLock.RUnlock()
+	if svc, ok := services[serviceName]; ok {
+		return svc, nil
+	}
+	return nil, perrors.Errorf("the gRPC service %s isn't registered", serviceName)
+}
+
+// serviceName returns the name of the gRPC service of the @url, which is its interface
+func serviceName(url common.URL) string {
+	return url.GetParam(constant.INTERFACE_KEY, strings.TrimPrefix(url.Path, "/"))
+}
+
+// splitMethod splits the full method name /service/method of gRPC
+func splitMethod(fullMethod string) (string, string) {
+	fullMethod = strings.TrimPrefix(fullMethod, "/")
+	if i := strings.LastIndex(fullMethod, "/"); i >= 0 {
+		return fullMethod[:i], fullMethod[i+1:]
+	}
+	return "", fullMethod
+}
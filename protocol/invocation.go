@@ -18,6 +18,7 @@
 package protocol
 
 import (
+	"context"
 	"reflect"
 )
 
@@ -29,4 +30,6 @@ type Invocation interface {
 	Attachments() map[string]string
 	AttachmentsByKey(string, string) string
 	Invoker() Invoker
+	// Context returns the context of the call, e.g. carrying the span of the tracing, it's never nil
+	Context() context.Context
 }
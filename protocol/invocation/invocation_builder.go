@@ -0,0 +1,121 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package invocation
+
+import (
+	"context"
+	"reflect"
+)
+
+import (
+	perrors "github.com/pkg/errors"
+)
+
+import (
+	"github.com/apache/dubbo-go/protocol"
+)
+
+/////////////////////////////
+// InvocationBuilder
+/////////////////////////////
+
+// InvocationBuilder builds a well-formed RPCInvocation by the fluent methods, e.g.
+//
+//	inv, err := NewInvocationBuilder("GetUser").Arguments("A001").Reply(&user).Attachment("token", "abc").Build()
+type InvocationBuilder struct {
+	invocation *RPCInvocation
+}
+
+func NewInvocationBuilder(methodName string) *InvocationBuilder {
+	return &InvocationBuilder{
+		invocation: &RPCInvocation{
+			methodName:  methodName,
+			arguments:   []interface{}{},
+			attachments: make(map[string]string),
+		},
+	}
+}
+
+func (b *InvocationBuilder) Arguments(arguments ...interface{}) *InvocationBuilder {
+	b.invocation.arguments = append(b.invocation.arguments, arguments...)
+	return b
+}
+
+func (b *InvocationBuilder) ParameterTypes(parameterTypes ...reflect.Type) *InvocationBuilder {
+	b.invocation.parameterTypes = append(b.invocation.parameterTypes, parameterTypes...)
+	return b
+}
+
+// Reply should be a pointer which the result is decoded into
+func (b *InvocationBuilder) Reply(reply interface{}) *InvocationBuilder {
+	b.invocation.reply = reply
+	return b
+}
+
+func (b *InvocationBuilder) CallBack(callBack interface{}) *InvocationBuilder {
+	b.invocation.callBack = callBack
+	return b
+}
+
+func (b *InvocationBuilder) Attachment(key string, value string) *InvocationBuilder {
+	b.invocation.attachments[key] = value
+	return b
+}
+
+// Attachments copies the @attachments into the invocation
+func (b *InvocationBuilder) Attachments(attachments map[string]string) *InvocationBuilder {
+	for k, v := range attachments {
+		b.invocation.attachments[k] = v
+	}
+	return b
+}
+
+// Context sets the context of the call, its deadline overrides the configured timeout if it's sooner
+func (b *InvocationBuilder) Context(ctx context.Context) *InvocationBuilder {
+	b.invocation.ctx = ctx
+	return b
+}
+
+func (b *InvocationBuilder) Invoker(invoker protocol.Invoker) *InvocationBuilder {
+	b.invocation.invoker = invoker
+	return b
+}
+
+// Build checks the method name, reply and parameter types, then returns the invocation
+func (b *InvocationBuilder) Build() (*RPCInvocation, error) {
+	inv := b.invocation
+	if inv.methodName == "" {
+		return nil, perrors.New("the method name of the invocation is empty")
+	}
+	if inv.reply != nil && reflect.TypeOf(inv.reply).Kind() != reflect.Ptr {
+		return nil, perrors.Errorf("the reply of the method %v must be a pointer, but it's %T", inv.methodName, inv.reply)
+	}
+	if len(inv.parameterTypes) > 0 {
+		if len(inv.parameterTypes) != len(inv.arguments) {
+			return nil, perrors.Errorf("the method %v has %d parameter types but %d arguments",
+				inv.methodName, len(inv.parameterTypes), len(inv.arguments))
+		}
+		for i, arg := range inv.arguments {
+			if arg != nil && !reflect.TypeOf(arg).AssignableTo(inv.parameterTypes[i]) {
+				return nil, perrors.Errorf("the argument[%d] of the method %v is %T, can not be assigned to %v",
+					i, inv.methodName, arg, inv.parameterTypes[i])
+			}
+		}
+	}
+	return inv, nil
+}
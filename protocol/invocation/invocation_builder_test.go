@@ -0,0 +1,69 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package invocation
+
+import (
+	"reflect"
+	"testing"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInvocationBuilder_Build(t *testing.T) {
+	var reply string
+	inv, err := NewInvocationBuilder("GetUser").
+		Arguments("A001", 18).
+		ParameterTypes(reflect.TypeOf(""), reflect.TypeOf(0)).
+		Reply(&reply).
+		Build()
+	assert.NoError(t, err)
+	assert.Equal(t, "GetUser", inv.MethodName())
+	assert.Equal(t, []interface{}{"A001", 18}, inv.Arguments())
+	assert.Equal(t, []reflect.Type{reflect.TypeOf(""), reflect.TypeOf(0)}, inv.ParameterTypes())
+	assert.Equal(t, &reply, inv.Reply())
+	assert.NotNil(t, inv.Attachments())
+	assert.Len(t, inv.Attachments(), 0)
+
+	attachments := map[string]string{"token": "abc"}
+	inv, err = NewInvocationBuilder("GetUser").
+		Attachments(attachments).
+		Attachment("timeout", "1000").
+		Build()
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{}, inv.Arguments())
+	assert.Equal(t, "abc", inv.AttachmentsByKey("token", ""))
+	assert.Equal(t, "1000", inv.AttachmentsByKey("timeout", ""))
+	// the attachments are copied
+	assert.Len(t, attachments, 1)
+}
+
+func TestInvocationBuilder_BuildIllegal(t *testing.T) {
+	_, err := NewInvocationBuilder("").Build()
+	assert.Error(t, err)
+
+	_, err = NewInvocationBuilder("GetUser").Reply("not a pointer").Build()
+	assert.Error(t, err)
+
+	_, err = NewInvocationBuilder("GetUser").Arguments("A001").ParameterTypes(reflect.TypeOf(""), reflect.TypeOf(0)).Build()
+	assert.Error(t, err)
+
+	_, err = NewInvocationBuilder("GetUser").Arguments(18).ParameterTypes(reflect.TypeOf("")).Build()
+	assert.Error(t, err)
+}
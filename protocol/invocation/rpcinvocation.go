@@ -18,6 +18,7 @@
 package invocation
 
 import (
+	"context"
 	"reflect"
 )
 
@@ -37,6 +38,8 @@ type RPCInvocation struct {
 	callBack       interface{}
 	attachments    map[string]string
 	invoker        protocol.Invoker
+	// the deadline of the context overrides the configured timeout of the call if it's sooner
+	ctx context.Context
 }
 
 func NewRPCInvocation(methodName string, arguments []interface{}, attachments map[string]string) *RPCInvocation {
@@ -105,6 +108,18 @@ func (r *RPCInvocation) SetInvoker() protocol.Invoker {
 	return r.invoker
 }
 
+// Context returns the context of the call, it's never nil
+func (r *RPCInvocation) Context() context.Context {
+	if r.ctx == nil {
+		return context.Background()
+	}
+	return r.ctx
+}
+
+func (r *RPCInvocation) SetContext(ctx context.Context) {
+	r.ctx = ctx
+}
+
 func (r *RPCInvocation) CallBack() interface{} {
 	return r.callBack
 }
@@ -155,6 +170,12 @@ func WithAttachments(attachments map[string]string) option {
 	}
 }
 
+func WithContext(ctx context.Context) option {
+	return func(invo *RPCInvocation) {
+		invo.ctx = ctx
+	}
+}
+
 func WithInvoker(invoker protocol.Invoker) option {
 	return func(invo *RPCInvocation) {
 		invo.invoker = invoker
@@ -21,12 +21,12 @@ import (
 	"bufio"
 	"bytes"
 	"context"
-	"fmt"
 	"io/ioutil"
 	"net"
 	"net/http"
 	"net/url"
 	"os"
+	"strconv"
 	"strings"
 	"sync/atomic"
 	"time"
@@ -39,6 +39,12 @@ import (
 import (
 	"github.com/apache/dubbo-go/common"
 	"github.com/apache/dubbo-go/common/constant"
+	"github.com/apache/dubbo-go/protocol"
+)
+
+const (
+	unixNetwork = "unix"
+	unixScheme  = "unix://"
 )
 
 //////////////////////////////////////////////
@@ -136,7 +142,7 @@ func (c *HTTPClient) Call(ctx context.Context, service common.URL, req *Request,
 		return perrors.WithStack(err)
 	}
 
-	rspBody, err := c.Do(service.Location, service.Path, httpHeader, reqBody)
+	rspBody, err := c.Do(transportAddress(service), service.Path, httpHeader, reqBody)
 	if err != nil {
 		return perrors.WithStack(err)
 	}
@@ -144,10 +150,29 @@ func (c *HTTPClient) Call(ctx context.Context, service common.URL, req *Request,
 	return perrors.WithStack(codec.Read(rspBody, rsp))
 }
 
+// transportAddress returns the address to dial or listen on of the @url
+func transportAddress(url common.URL) string {
+	return url.GetParam(constant.TRANSPORT_ADDRESS_KEY, url.Location)
+}
+
+// splitTransportAddress splits the unix:// address into the unix network and the socket path,
+// other addresses are on tcp.
+func splitTransportAddress(addr string) (network, address string) {
+	if strings.HasPrefix(addr, unixScheme) {
+		return unixNetwork, strings.TrimPrefix(addr, unixScheme)
+	}
+	return "tcp", addr
+}
+
 // !!The high level of complexity and the likelihood that the fasthttp client has not been extensively used
 // in production means that you would need to expect a very large benefit to justify the adoption of fasthttp today.
 func (c *HTTPClient) Do(addr, path string, httpHeader http.Header, body []byte) ([]byte, error) {
-	u := url.URL{Host: strings.TrimSuffix(addr, ":"), Path: path}
+	network, addr := splitTransportAddress(addr)
+	host := addr
+	if network == unixNetwork {
+		host = "localhost"
+	}
+	u := url.URL{Host: strings.TrimSuffix(host, ":"), Path: path}
 	httpReq, err := http.NewRequest("POST", u.String(), bytes.NewBuffer(body))
 	if err != nil {
 		return nil, perrors.WithStack(err)
@@ -160,7 +185,7 @@ func (c *HTTPClient) Do(addr, path string, httpHeader http.Header, body []byte)
 		return nil, perrors.WithStack(err)
 	}
 
-	tcpConn, err := net.DialTimeout("tcp", addr, c.options.HandshakeTimeout)
+	tcpConn, err := net.DialTimeout(network, addr, c.options.HandshakeTimeout)
 	if err != nil {
 		return nil, perrors.WithStack(err)
 	}
@@ -191,7 +216,7 @@ func (c *HTTPClient) Do(addr, path string, httpHeader http.Header, body []byte)
 	}
 
 	if httpRsp.StatusCode != http.StatusOK {
-		return nil, perrors.New(fmt.Sprintf("http status:%q, error string:%q", httpRsp.Status, string(b)))
+		return nil, perrors.WithStack(protocol.NewStatusError(strconv.Itoa(httpRsp.StatusCode), string(b)))
 	}
 
 	return b, nil
@@ -19,6 +19,11 @@ package jsonrpc
 
 import (
 	"context"
+	"io/ioutil"
+	"net/http"
+	neturl "net/url"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
@@ -155,6 +160,79 @@ func TestHTTPClient_Call(t *testing.T) {
 
 }
 
+type responseHeaderInvoker struct {
+	protocol.BaseInvoker
+}
+
+// returns the result attachments which are set by the response_header filter
+func (ivk *responseHeaderInvoker) Invoke(invocation protocol.Invocation) protocol.Result {
+	return &protocol.RPCResult{Attrs: map[string]string{
+		constant.RESPONSE_HEADER_ATTACHMENT_PREFIX + "X-Trace-Id": "t-1",
+		"tenant": "a",
+	}}
+}
+
+func TestHTTPServer_ResponseHeaders(t *testing.T) {
+	// registered by the other tests maybe
+	common.ServiceMap.Register("jsonrpc", &UserProvider{})
+
+	proto := GetProtocol()
+	url, err := common.NewURL(context.Background(), "jsonrpc://127.0.0.1:20002/UserProvider?interface=com.ikurento.user.UserProvider&side=provider")
+	assert.NoError(t, err)
+	proto.Export(&responseHeaderInvoker{BaseInvoker: *protocol.NewBaseInvoker(url)})
+	time.Sleep(time.Second * 2)
+	defer proto.Destroy()
+
+	body := `{"jsonrpc":"2.0","method":"GetUser","params":["1","username"],"id":1}`
+	req, err := http.NewRequest("POST", "http://127.0.0.1:20002/UserProvider", strings.NewReader(body))
+	assert.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+	req.Close = true
+	rsp, err := http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	defer rsp.Body.Close()
+
+	assert.Equal(t, 200, rsp.StatusCode)
+	assert.Equal(t, "t-1", rsp.Header.Get("X-Trace-Id"))
+	// the attachments without the prefix aren't the headers
+	assert.Equal(t, "", rsp.Header.Get("tenant"))
+}
+
+func TestHTTPClient_CallUnix(t *testing.T) {
+	// registered by the other tests maybe
+	common.ServiceMap.Register("jsonrpc", &UserProvider{})
+
+	dir, err := ioutil.TempDir("", "jsonrpc")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	sock := "unix://" + filepath.Join(dir, "jsonrpc.sock")
+
+	proto := GetProtocol()
+	url, err := common.NewURL(context.Background(), "jsonrpc://127.0.0.1:20003/UserProvider?interface=com.ikurento.user.UserProvider&side=provider&"+
+		constant.TRANSPORT_ADDRESS_KEY+"="+neturl.QueryEscape(sock))
+	assert.NoError(t, err)
+	assert.Equal(t, sock, url.GetParam(constant.TRANSPORT_ADDRESS_KEY, ""))
+	proto.Export(protocol.NewBaseInvoker(url))
+	time.Sleep(time.Second * 2)
+	defer proto.Destroy()
+
+	ctx := context.WithValue(context.Background(), constant.DUBBOGO_CTX_KEY, map[string]string{
+		"X-Proxy-Id": "dubbogo",
+		"X-Services": url.Path,
+		"X-Method":   "GetUser",
+	})
+	client := NewHTTPClient(&HTTPOptions{})
+	req := client.NewRequest(url, "GetUser", []interface{}{"1", "username"})
+	reply := &User{}
+	err = client.Call(ctx, url, req, reply)
+	assert.NoError(t, err)
+	assert.Equal(t, &User{Id: "1", Name: "username"}, reply)
+
+	// nothing listens on the tcp address
+	_, err = client.Do(url.Location, url.Path, http.Header{}, []byte("{}"))
+	assert.Error(t, err)
+}
+
 func (u *UserProvider) GetUser(ctx context.Context, req []interface{}, rsp *User) error {
 	rsp.Id = req[0].(string)
 	rsp.Name = req[1].(string)
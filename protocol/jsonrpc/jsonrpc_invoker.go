@@ -49,6 +49,9 @@ func (ji *JsonrpcInvoker) Invoke(invocation protocol.Invocation) protocol.Result
 
 	inv := invocation.(*invocation_impl.RPCInvocation)
 	url := ji.GetUrl()
+	if result.Err = protocol.LimitAttachments(url, inv); result.Err != nil {
+		return &result
+	}
 	req := ji.client.NewRequest(url, inv.MethodName(), inv.Arguments())
 	ctx := context.WithValue(context.Background(), constant.DUBBOGO_CTX_KEY, map[string]string{
 		"X-Proxy-Id": "dubbogo",
@@ -88,7 +88,8 @@ func (jp *JsonrpcProtocol) Destroy() {
 }
 
 func (jp *JsonrpcProtocol) openServer(url common.URL) {
-	_, ok := jp.serverMap[url.Location]
+	addr := transportAddress(url)
+	_, ok := jp.serverMap[addr]
 	if !ok {
 		_, ok := jp.ExporterMap().Load(strings.TrimPrefix(url.Path, "/"))
 		if !ok {
@@ -96,10 +97,10 @@ func (jp *JsonrpcProtocol) openServer(url common.URL) {
 		}
 
 		jp.serverLock.Lock()
-		_, ok = jp.serverMap[url.Location]
+		_, ok = jp.serverMap[addr]
 		if !ok {
 			srv := NewServer()
-			jp.serverMap[url.Location] = srv
+			jp.serverMap[addr] = srv
 			srv.Start(url)
 		}
 		jp.serverLock.Unlock()
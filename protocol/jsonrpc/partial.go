@@ -0,0 +1,248 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package jsonrpc
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+import (
+	"github.com/apache/dubbo-go/common"
+	"github.com/apache/dubbo-go/common/constant"
+	"github.com/apache/dubbo-go/common/logger"
+)
+
+var (
+	nullMessage       = json.RawMessage("null")
+	marshalerType     = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// partialResult returns the @result as is if it's serializable. Otherwise if serialize.partial of the method
+// is true, the serializable subset of the result is returned, and the unsupported fields are dropped with a warning.
+func partialResult(url common.URL, method string, result interface{}) interface{} {
+	if result == nil {
+		return result
+	}
+	partial, _ := strconv.ParseBool(url.GetMethodParam(method, constant.SERIALIZE_PARTIAL_KEY,
+		url.GetParam(constant.SERIALIZE_PARTIAL_KEY, "false")))
+	if !partial {
+		return result
+	}
+	if _, err := json.Marshal(result); err == nil {
+		return result
+	}
+
+	raw, dropped := marshalPartial(reflect.ValueOf(result), reflect.TypeOf(result).String())
+	logger.Warnf("the fields %v of the result of the method %v can't be serialized, they're dropped", dropped, method)
+	return raw
+}
+
+// marshalPartial marshals the serializable fields of the struct @v, and returns the paths of the dropped fields.
+// The values marshaled by themselves, e.g. time.Time, are marshaled as a whole.
+func marshalPartial(v reflect.Value, path string) (json.RawMessage, []string) {
+	for {
+		if (v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface) && v.IsNil() {
+			return nullMessage, nil
+		}
+		if v.Kind() != reflect.Interface && marshalsItself(v) {
+			return marshalValue(v, path)
+		}
+		if v.Kind() != reflect.Ptr && v.Kind() != reflect.Interface {
+			break
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return marshalValue(v, path)
+	}
+
+	var (
+		buf     bytes.Buffer
+		dropped []string
+	)
+	buf.WriteByte('{')
+	for _, field := range dominantFields(partialFields(v, path, 0)) {
+		if field.omitEmpty && isEmptyValue(field.value) {
+			continue
+		}
+		data, d := marshalPartial(field.value, field.path)
+		dropped = append(dropped, d...)
+		if data == nil {
+			continue
+		}
+		if buf.Len() > 1 {
+			buf.WriteByte(',')
+		}
+		key, _ := json.Marshal(field.name)
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(data)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), dropped
+}
+
+// marshalsItself returns whether the @v implements json.Marshaler or encoding.TextMarshaler
+func marshalsItself(v reflect.Value) bool {
+	t := v.Type()
+	if t.Implements(marshalerType) || t.Implements(textMarshalerType) {
+		return true
+	}
+	pt := reflect.PtrTo(t)
+	return v.CanAddr() && (pt.Implements(marshalerType) || pt.Implements(textMarshalerType))
+}
+
+func marshalValue(v reflect.Value, path string) (json.RawMessage, []string) {
+	value := v.Interface()
+	if v.CanAddr() {
+		// the methods of the pointer receiver are used as encoding/json does
+		value = v.Addr().Interface()
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, []string{path}
+	}
+	return data, nil
+}
+
+type partialField struct {
+	name      string
+	tagged    bool
+	omitEmpty bool
+	depth     int
+	value     reflect.Value
+	path      string
+}
+
+// partialFields returns the exported fields of the struct @v, the fields of the embedded structs are flattened
+// as encoding/json does
+func partialFields(v reflect.Value, path string, depth int) []partialField {
+	var fields []partialField
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, omitEmpty := jsonField(field)
+		if name == "-" {
+			continue
+		}
+		tagged := strings.Split(field.Tag.Get("json"), ",")[0] != ""
+		value := v.Field(i)
+		if field.Anonymous && !tagged {
+			ft := field.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				if value.Kind() == reflect.Ptr {
+					if value.IsNil() {
+						continue
+					}
+					value = value.Elem()
+				}
+				fields = append(fields, partialFields(value, path+"."+field.Name, depth+1)...)
+				continue
+			}
+		}
+		if field.PkgPath != "" {
+			// unexported
+			continue
+		}
+		fields = append(fields, partialField{name: name, tagged: tagged, omitEmpty: omitEmpty, depth: depth,
+			value: value, path: path + "." + field.Name})
+	}
+	return fields
+}
+
+// dominantFields drops the fields hidden by the others of the same name as encoding/json does, the shallower
+// field dominates, then the tagged one. The fields of the same name in the same depth are all dropped otherwise.
+func dominantFields(fields []partialField) []partialField {
+	byName := make(map[string][]int, len(fields))
+	for i, field := range fields {
+		byName[field.name] = append(byName[field.name], i)
+	}
+
+	dominant := make([]partialField, 0, len(fields))
+	for _, field := range fields {
+		indexes := byName[field.name]
+		if len(indexes) == 1 {
+			dominant = append(dominant, field)
+			continue
+		}
+		candidates := 0
+		tagged := 0
+		for _, j := range indexes {
+			if fields[j].depth == field.depth {
+				candidates++
+				if fields[j].tagged {
+					tagged++
+				}
+			} else if fields[j].depth < field.depth {
+				// hidden by a shallower one
+				candidates = -1
+				break
+			}
+		}
+		if candidates == 1 || (candidates > 1 && tagged == 1 && field.tagged) {
+			dominant = append(dominant, field)
+		}
+	}
+	return dominant
+}
+
+// jsonField returns the name of the field in json and whether it's omitted if empty
+func jsonField(field reflect.StructField) (string, bool) {
+	tag := field.Tag.Get("json")
+	if tag == "-" {
+		return tag, false
+	}
+	parts := strings.Split(tag, ",")
+	name := parts[0]
+	if name == "" {
+		name = field.Name
+	}
+	for _, opt := range parts[1:] {
+		if opt == "omitempty" {
+			return name, true
+		}
+	}
+	return name, false
+}
+
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool:
+		return !v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return v.Float() == 0
+	case reflect.Interface, reflect.Ptr:
+		return v.IsNil()
+	}
+	return false
+}
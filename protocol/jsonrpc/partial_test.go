@@ -0,0 +1,114 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package jsonrpc
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+import (
+	"github.com/apache/dubbo-go/common"
+)
+
+type partialAddress struct {
+	City   string
+	Notify func()
+}
+
+type partialUser struct {
+	Id      string          `json:"id"`
+	Name    string          `json:"name,omitempty"`
+	Events  chan int        `json:"events"`
+	Address *partialAddress `json:"address"`
+	age     int
+}
+
+func TestPartialResult(t *testing.T) {
+	user := &partialUser{Id: "1", Events: make(chan int), Address: &partialAddress{City: "Hangzhou", Notify: func() {}}, age: 18}
+	_, err := json.Marshal(user)
+	assert.Error(t, err)
+
+	// disabled
+	url, _ := common.NewURL(context.TODO(), "jsonrpc://127.0.0.1:20001/UserProvider")
+	assert.Equal(t, user, partialResult(url, "GetUser", user))
+
+	url, _ = common.NewURL(context.TODO(), "jsonrpc://127.0.0.1:20001/UserProvider?methods.GetUser.serialize.partial=true")
+	assert.Equal(t, user, partialResult(url, "GetUsers", user))
+	raw, ok := partialResult(url, "GetUser", user).(json.RawMessage)
+	assert.True(t, ok)
+
+	// the serializable fields round-trip
+	rsp := &partialUser{}
+	assert.NoError(t, json.Unmarshal(raw, rsp))
+	assert.Equal(t, "1", rsp.Id)
+	assert.Equal(t, "", rsp.Name)
+	assert.Nil(t, rsp.Events)
+	assert.Equal(t, "Hangzhou", rsp.Address.City)
+	assert.Nil(t, rsp.Address.Notify)
+	assert.Equal(t, `{"id":"1","address":{"City":"Hangzhou"}}`, string(raw))
+
+	// the unsupported fields are warned
+	_, dropped := marshalPartial(reflect.ValueOf(user), "partialUser")
+	assert.Equal(t, []string{"partialUser.Events", "partialUser.Address.Notify"}, dropped)
+}
+
+type partialAudit struct {
+	Created time.Time `json:"created"`
+	Creator string    `json:"creator"`
+}
+
+type partialOrder struct {
+	partialAudit
+	*partialAddress
+	Id     string `json:"id"`
+	City   string
+	Events chan int `json:"events"`
+}
+
+func TestPartialResultMarshalerAndEmbedded(t *testing.T) {
+	created := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	order := &partialOrder{
+		partialAudit:   partialAudit{Created: created, Creator: "admin"},
+		partialAddress: &partialAddress{City: "Hangzhou", Notify: func() {}},
+		Id:             "1",
+		City:           "Beijing",
+		Events:         make(chan int),
+	}
+	url, _ := common.NewURL(context.TODO(), "jsonrpc://127.0.0.1:20001/UserProvider?serialize.partial=true")
+	raw, ok := partialResult(url, "GetOrder", order).(json.RawMessage)
+	assert.True(t, ok)
+
+	// time.Time is marshaled by itself, and the fields of the embedded structs are flattened,
+	// the City of the embedded address is hidden by the one of the order
+	assert.Equal(t, `{"created":"2020-01-02T03:04:05Z","creator":"admin","id":"1","City":"Beijing"}`, string(raw))
+	rsp := &partialOrder{}
+	assert.NoError(t, json.Unmarshal(raw, rsp))
+	assert.True(t, created.Equal(rsp.Created))
+	assert.Equal(t, "admin", rsp.Creator)
+
+	_, dropped := marshalPartial(reflect.ValueOf(order), "partialOrder")
+	assert.Equal(t, []string{"partialOrder.partialAddress.Notify", "partialOrder.Events"}, dropped)
+}
@@ -25,9 +25,11 @@ import (
 	"io/ioutil"
 	"net"
 	"net/http"
+	"os"
 	"reflect"
 	"runtime"
 	"runtime/debug"
+	"strings"
 	"sync"
 	"time"
 )
@@ -215,7 +217,14 @@ func accept(listener net.Listener, fn func(net.Conn)) error {
 }
 
 func (s *Server) Start(url common.URL) {
-	listener, err := net.Listen("tcp", url.Location)
+	network, addr := splitTransportAddress(transportAddress(url))
+	if network == unixNetwork {
+		// remove the socket file left by the last run, or the listen fails
+		if err := os.Remove(addr); err != nil && !os.IsNotExist(err) {
+			logger.Warnf("remove the unix socket %s error: %v", addr, err)
+		}
+	}
+	listener, err := net.Listen(network, addr)
 	if err != nil {
 		logger.Errorf("jsonrpc server [%s] start failed: %v", url.Path, err)
 		return
@@ -329,6 +338,11 @@ func serveRequest(ctx context.Context,
 			constant.PATH_KEY:    path,
 			constant.VERSION_KEY: codec.req.Version,
 		}))
+		for k, v := range result.Attachments() {
+			if strings.HasPrefix(k, constant.RESPONSE_HEADER_ATTACHMENT_PREFIX) {
+				header[strings.TrimPrefix(k, constant.RESPONSE_HEADER_ATTACHMENT_PREFIX)] = v
+			}
+		}
 		if err := result.Error(); err != nil {
 			if errRsp := sendErrorResp(header, []byte(err.Error())); errRsp != nil {
 				logger.Warnf("Exporter: sendErrorResp(header:%#v, error:%v) = error:%s",
@@ -337,7 +351,7 @@ func serveRequest(ctx context.Context,
 			}
 		}
 		if res := result.Result(); res != nil {
-			rspStream, err := codec.Write("", res)
+			rspStream, err := codec.Write("", partialResult(invoker.GetUrl(), methodName, res))
 			if err != nil {
 				return perrors.WithStack(err)
 			}
@@ -364,19 +378,12 @@ func serveRequest(ctx context.Context,
 	}
 
 	// prepare argv
+	nilAsZero := invoker != nil && invoker.GetUrl().GetParam(constant.NIL_ARGUMENT_KEY, "") == constant.NIL_ARGUMENT_ZERO
 	if (len(method.ArgsType()) == 1 || len(method.ArgsType()) == 2 && method.ReplyType() == nil) && method.ArgsType()[0].String() == "[]interface {}" {
 		in = append(in, reflect.ValueOf(args))
 	} else {
 		for i := 0; i < len(args); i++ {
-			t := reflect.ValueOf(args[i])
-			if !t.IsValid() {
-				at := method.ArgsType()[i]
-				if at.Kind() == reflect.Ptr {
-					at = at.Elem()
-				}
-				t = reflect.New(at)
-			}
-			in = append(in, t)
+			in = append(in, method.SuiteArgument(i, args[i], nilAsZero))
 		}
 	}
 
@@ -412,6 +419,8 @@ func serveRequest(ctx context.Context,
 	if len(errMsg) != 0 {
 		code = 500
 		rspReply = invalidRequest
+	} else if invoker != nil {
+		rspReply = partialResult(invoker.GetUrl(), methodName, rspReply)
 	}
 	rspStream, err := codec.Write(errMsg, rspReply)
 	if err != nil {
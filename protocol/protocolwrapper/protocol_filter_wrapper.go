@@ -46,7 +46,7 @@ func (pfw *ProtocolFilterWrapper) Export(invoker protocol.Invoker) protocol.Expo
 	if pfw.protocol == nil {
 		pfw.protocol = extension.GetProtocol(invoker.GetUrl().Protocol)
 	}
-	invoker = buildInvokerChain(invoker, constant.SERVICE_FILTER_KEY)
+	invoker = buildInvokerChain(invoker, constant.SERVICE_FILTER_KEY, common.PROVIDER)
 	return pfw.protocol.Export(invoker)
 }
 
@@ -54,14 +54,18 @@ func (pfw *ProtocolFilterWrapper) Refer(url common.URL) protocol.Invoker {
 	if pfw.protocol == nil {
 		pfw.protocol = extension.GetProtocol(url.Protocol)
 	}
-	return buildInvokerChain(pfw.protocol.Refer(url), constant.REFERENCE_FILTER_KEY)
+	return buildInvokerChain(pfw.protocol.Refer(url), constant.REFERENCE_FILTER_KEY, common.CONSUMER)
 }
 
 func (pfw *ProtocolFilterWrapper) Destroy() {
 	pfw.protocol.Destroy()
 }
 
-func buildInvokerChain(invoker protocol.Invoker, key string) protocol.Invoker {
+func buildInvokerChain(invoker protocol.Invoker, key string, role common.RoleType) protocol.Invoker {
+	// the protocol may fail to refer
+	if invoker == nil {
+		return nil
+	}
 	filtName := invoker.GetUrl().Params.Get(key)
 	if filtName == "" {
 		return invoker
@@ -72,14 +76,26 @@ func buildInvokerChain(invoker protocol.Invoker, key string) protocol.Invoker {
 	// The order of filters is from left to right, so loading from right to left
 
 	for i := len(filtNames) - 1; i >= 0; i-- {
-		filter := extension.GetFilter(filtNames[i])
-		fi := &FilterInvoker{next: next, invoker: invoker, filter: filter}
+		flt := extension.GetFilter(filtNames[i])
+		if chainFilter, ok := flt.(filter.ChainFilter); ok {
+			chainFilter.Prepare(invoker.GetUrl(), role)
+		}
+		fi := &FilterInvoker{next: next, invoker: invoker, filter: flt}
 		next = fi
 	}
 
 	return next
 }
 
+// BuildFilterChain wraps the @invoker by the filter instances, the first filter is invoked first
+func BuildFilterChain(invoker protocol.Invoker, filters ...filter.Filter) protocol.Invoker {
+	next := invoker
+	for i := len(filters) - 1; i >= 0; i-- {
+		next = &FilterInvoker{next: next, invoker: invoker, filter: filters[i]}
+	}
+	return next
+}
+
 func GetProtocol() protocol.Protocol {
 	return &ProtocolFilterWrapper{}
 }
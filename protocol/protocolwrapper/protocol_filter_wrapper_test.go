@@ -30,6 +30,7 @@ import (
 	"github.com/apache/dubbo-go/common"
 	"github.com/apache/dubbo-go/common/constant"
 	"github.com/apache/dubbo-go/common/extension"
+	"github.com/apache/dubbo-go/filter"
 	"github.com/apache/dubbo-go/filter/impl"
 	"github.com/apache/dubbo-go/protocol"
 )
@@ -57,3 +58,41 @@ func TestProtocolFilterWrapper_Refer(t *testing.T) {
 	_, ok := invoker.(*FilterInvoker)
 	assert.True(t, ok)
 }
+
+// preparedFilter records the role it's prepared for
+type preparedFilter struct {
+	role common.RoleType
+}
+
+func (f *preparedFilter) Prepare(url common.URL, role common.RoleType) {
+	f.role = role
+}
+
+func (f *preparedFilter) Invoke(invoker protocol.Invoker, invocation protocol.Invocation) protocol.Result {
+	return invoker.Invoke(invocation)
+}
+
+func (f *preparedFilter) OnResponse(result protocol.Result, invoker protocol.Invoker, invocation protocol.Invocation) protocol.Result {
+	return result
+}
+
+func TestProtocolFilterWrapper_PrepareChainFilter(t *testing.T) {
+	filtProto := extension.GetProtocol(FILTER)
+	filtProto.(*ProtocolFilterWrapper).protocol = &protocol.BaseProtocol{}
+	var prepared []*preparedFilter
+	extension.SetFilter("prepared", func() filter.Filter {
+		f := &preparedFilter{role: -1}
+		prepared = append(prepared, f)
+		return f
+	})
+
+	u := common.NewURLWithOptions(
+		common.WithParams(url.Values{}),
+		common.WithParamsValue(constant.SERVICE_FILTER_KEY, "prepared"),
+		common.WithParamsValue(constant.REFERENCE_FILTER_KEY, "prepared"))
+	filtProto.Export(protocol.NewBaseInvoker(*u))
+	filtProto.Refer(*u)
+	assert.Equal(t, 2, len(prepared))
+	assert.Equal(t, common.RoleType(common.PROVIDER), prepared[0].role)
+	assert.Equal(t, common.RoleType(common.CONSUMER), prepared[1].role)
+}
@@ -0,0 +1,40 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package protocol
+
+import (
+	"sync"
+	"time"
+)
+
+var (
+	reconnectTimes sync.Map // location -> time.Time
+)
+
+// SetReconnectTime records the time @t the provider at the @location, like 192.168.1.1:20000, reconnected
+// after its connections were lost, the load balances warm it up again from the time.
+func SetReconnectTime(location string, t time.Time) {
+	reconnectTimes.Store(location, t)
+}
+
+// GetReconnectTime returns the last time the provider at the @location reconnected, zero if it never did
+func GetReconnectTime(location string) time.Time {
+	if t, ok := reconnectTimes.Load(location); ok {
+		return t.(time.Time)
+	}
+	return time.Time{}
+}
@@ -0,0 +1,97 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package protocol
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+import (
+	perrors "github.com/pkg/errors"
+)
+
+// the prefix of the structured error on the wire, the consumer reconstructs the RemoteError from it
+const remoteErrorPrefix = "dubbo-go-error:"
+
+// ContextError is the business error of the provider carrying some context of the failure, e.g. the order id.
+// The context is serialized with the structured error, so don't put any sensitive data in it.
+type ContextError interface {
+	error
+	ErrorContext() map[string]string
+}
+
+// RemoteError is the error of the provider reconstructed by the consumer from the structured error.
+// The stack of the provider is never serialized.
+type RemoteError struct {
+	Code    string            `json:"code,omitempty"`
+	Message string            `json:"message"`
+	Context map[string]string `json:"context,omitempty"`
+}
+
+func (e *RemoteError) Error() string {
+	return e.Message
+}
+
+func (e *RemoteError) StatusCode() string {
+	return e.Code
+}
+
+func (e *RemoteError) ErrorContext() map[string]string {
+	return e.Context
+}
+
+// EncodeError serializes the status code, message and context of @err into the message of the returned error.
+func EncodeError(err error) error {
+	if err == nil {
+		return nil
+	}
+	remoteErr := &RemoteError{Message: err.Error()}
+	cause := perrors.Cause(err)
+	if statusErr, ok := cause.(StatusError); ok {
+		remoteErr.Code = statusErr.StatusCode()
+	}
+	if contextErr, ok := cause.(ContextError); ok {
+		remoteErr.Context = contextErr.ErrorContext()
+	}
+
+	data, jsonErr := json.Marshal(remoteErr)
+	if jsonErr != nil {
+		return err
+	}
+	return perrors.New(remoteErrorPrefix + string(data))
+}
+
+// DecodeError reconstructs the RemoteError from the structured error, @err is returned if it's not structured.
+// The message of @err may be prefixed by the codec, e.g. "java exception:".
+func DecodeError(err error) error {
+	if err == nil {
+		return nil
+	}
+	msg := err.Error()
+	idx := strings.Index(msg, remoteErrorPrefix)
+	if idx < 0 {
+		return err
+	}
+
+	remoteErr := &RemoteError{}
+	if jsonErr := json.Unmarshal([]byte(msg[idx+len(remoteErrorPrefix):]), remoteErr); jsonErr != nil {
+		return err
+	}
+	return remoteErr
+}
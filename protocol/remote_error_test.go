@@ -0,0 +1,74 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package protocol
+
+import (
+	"errors"
+	"testing"
+)
+
+import (
+	perrors "github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+type orderError struct {
+	code    string
+	orderId string
+}
+
+func (e *orderError) Error() string {
+	return "the order is locked"
+}
+
+func (e *orderError) StatusCode() string {
+	return e.code
+}
+
+func (e *orderError) ErrorContext() map[string]string {
+	return map[string]string{"order.id": e.orderId}
+}
+
+func TestEncodeError_RoundTrip(t *testing.T) {
+	encoded := EncodeError(perrors.WithStack(&orderError{code: "ORDER_LOCKED", orderId: "1001"}))
+	// the codec may prefix the message of the exception
+	decoded := DecodeError(errors.New("java exception:" + encoded.Error()))
+
+	remoteErr, ok := decoded.(*RemoteError)
+	assert.True(t, ok)
+	assert.Equal(t, "ORDER_LOCKED", remoteErr.StatusCode())
+	assert.Equal(t, "the order is locked", remoteErr.Error())
+	assert.Equal(t, map[string]string{"order.id": "1001"}, remoteErr.ErrorContext())
+
+	statusErr, ok := decoded.(StatusError)
+	assert.True(t, ok)
+	assert.Equal(t, "ORDER_LOCKED", statusErr.StatusCode())
+}
+
+func TestDecodeError_Plain(t *testing.T) {
+	err := errors.New("just failed")
+	assert.Equal(t, err, DecodeError(err))
+	assert.Nil(t, DecodeError(nil))
+	assert.Nil(t, EncodeError(nil))
+
+	// the error without code
+	remoteErr, ok := DecodeError(EncodeError(errors.New("just failed"))).(*RemoteError)
+	assert.True(t, ok)
+	assert.Equal(t, "", remoteErr.StatusCode())
+	assert.Equal(t, "just failed", remoteErr.Error())
+}
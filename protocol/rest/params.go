@@ -0,0 +1,73 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package rest
+
+import (
+	"encoding/json"
+	"net/url"
+	"reflect"
+	"strconv"
+)
+
+import (
+	perrors "github.com/pkg/errors"
+)
+
+// formatParam formats the @arg as the variable of the path or the query param, it's the JSON value of the @arg,
+// unquoted if it's a string, e.g. the time.Time
+func formatParam(arg interface{}) (string, error) {
+	data, err := json.Marshal(arg)
+	if err != nil {
+		return "", perrors.WithStack(err)
+	}
+	if value, err := strconv.Unquote(string(data)); err == nil {
+		return value, nil
+	}
+	return string(data), nil
+}
+
+// parseParam parses the variable of the path or the query param @value formatted by formatParam into the @typ
+func parseParam(value string, typ reflect.Type) (reflect.Value, error) {
+	ptr := reflect.New(typ)
+	if typ.Kind() == reflect.String {
+		ptr.Elem().SetString(value)
+		return ptr.Elem(), nil
+	}
+	if err := json.Unmarshal([]byte(value), ptr.Interface()); err != nil {
+		// the unquoted strings
+		if err := json.Unmarshal([]byte(strconv.Quote(value)), ptr.Interface()); err != nil {
+			return reflect.Value{}, perrors.WithMessagef(err, "parse %q as %v", value, typ)
+		}
+	}
+	return ptr.Elem(), nil
+}
+
+// pathEscape escapes the variable of the path, including the "/"
+func pathEscape(value string) string {
+	return url.PathEscape(value)
+}
+
+// pathUnescape unescapes the segment of the path escaped by pathEscape
+func pathUnescape(segment string) (string, error) {
+	return url.PathUnescape(segment)
+}
+
+// queryEscape escapes the name or the value of the query param
+func queryEscape(value string) string {
+	return url.QueryEscape(value)
+}
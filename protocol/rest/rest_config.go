@@ -0,0 +1,116 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package rest
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+)
+
+import (
+	perrors "github.com/pkg/errors"
+)
+
+// the content type of the requests and the responses, the only one supported
+const APPLICATION_JSON = "application/json"
+
+var (
+	restConfigsLock sync.RWMutex
+	restConfigs     = make(map[string]*RestServiceConfig)
+)
+
+// RestServiceConfig maps the methods of a service to the HTTP requests, it's shared by the provider and the consumer
+type RestServiceConfig struct {
+	InterfaceName string `yaml:"interface" json:"interface,omitempty"`
+	// the base path of the methods
+	Path    string              `yaml:"path" json:"path,omitempty"`
+	Methods []*RestMethodConfig `yaml:"methods" json:"methods,omitempty"`
+}
+
+// RestMethodConfig maps a method to the HTTP requests of its HttpMethod to its Path, e.g. /users/{id}.
+// The arguments of the method are mapped by their indexes to the variables of the path, the query params and the
+// JSON body. The path variables and the query params are the JSON values of the arguments, unquoted if they're strings.
+type RestMethodConfig struct {
+	MethodName string `yaml:"name" json:"name,omitempty"`
+	Path       string `yaml:"path" json:"path,omitempty"`
+	// GET by default
+	HttpMethod  string         `yaml:"method" json:"method,omitempty"`
+	PathParams  map[int]string `yaml:"path_params" json:"path_params,omitempty"`
+	QueryParams map[int]string `yaml:"query_params" json:"query_params,omitempty"`
+	// the index of the argument in the body, nil if no argument is
+	Body *int `yaml:"body" json:"body,omitempty"`
+	// the content types of the response and the request body, only application/json is supported
+	Produces string `yaml:"produces" json:"produces,omitempty"`
+	Consumes string `yaml:"consumes" json:"consumes,omitempty"`
+}
+
+// SetRestServiceConfig sets the REST config of the service of the @config.InterfaceName, the methods out of it
+// aren't served or called by the rest protocol
+func SetRestServiceConfig(config *RestServiceConfig) error {
+	for _, method := range config.Methods {
+		if method.HttpMethod == "" {
+			method.HttpMethod = http.MethodGet
+		}
+		method.HttpMethod = strings.ToUpper(method.HttpMethod)
+		if method.Produces == "" {
+			method.Produces = APPLICATION_JSON
+		}
+		if method.Consumes == "" {
+			method.Consumes = APPLICATION_JSON
+		}
+		if method.Produces != APPLICATION_JSON || method.Consumes != APPLICATION_JSON {
+			return perrors.Errorf("the method %s of %s only supports %s", method.MethodName, config.InterfaceName, APPLICATION_JSON)
+		}
+		for index := range method.PathParams {
+			if method.Body != nil && index == *method.Body {
+				return perrors.Errorf("the argument %d of the method %s of %s is both in the path and the body",
+					index, method.MethodName, config.InterfaceName)
+			}
+		}
+	}
+	restConfigsLock.Lock()
+	restConfigs[config.InterfaceName] = config
+	restConfigsLock.Unlock()
+	return nil
+}
+
+// GetRestServiceConfig returns the REST config of the service of the @interfaceName
+func GetRestServiceConfig(interfaceName string) (*RestServiceConfig, error) {
+	restConfigsLock.RLock()
+	defer restConfigsLock.RUnlock()
+	if config, ok := restConfigs[interfaceName]; ok {
+		return config, nil
+	}
+	return nil, perrors.Errorf("the rest config of the service %s isn't set", interfaceName)
+}
+
+// method returns the config of the method @methodName
+func (c *RestServiceConfig) method(methodName string) *RestMethodConfig {
+	for _, method := range c.Methods {
+		if method.MethodName == methodName {
+			return method
+		}
+	}
+	return nil
+}
+
+// fullPath returns the path of the @method under the base path of the service
+func (c *RestServiceConfig) fullPath(method *RestMethodConfig) string {
+	return "/" + strings.Trim(strings.TrimSuffix(c.Path, "/")+"/"+strings.TrimPrefix(method.Path, "/"), "/")
+}
@@ -0,0 +1,231 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package rest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+import (
+	perrors "github.com/pkg/errors"
+)
+
+import (
+	"github.com/apache/dubbo-go/common"
+	"github.com/apache/dubbo-go/common/constant"
+	"github.com/apache/dubbo-go/common/logger"
+	"github.com/apache/dubbo-go/protocol"
+	invocation_impl "github.com/apache/dubbo-go/protocol/invocation"
+)
+
+// RestInvoker converts the invocations into the HTTP requests by the REST config of the service of its url, the
+// JSON response is decoded into the reply of the invocation, and the error response into the RemoteError.
+type RestInvoker struct {
+	protocol.BaseInvoker
+	config         *RestServiceConfig
+	transport      *http.Transport
+	client         *http.Client
+	requestTimeout time.Duration
+}
+
+func NewRestInvoker(url common.URL, config *RestServiceConfig, requestTimeout time.Duration) *RestInvoker {
+	transport := &http.Transport{Proxy: http.ProxyFromEnvironment}
+	return &RestInvoker{
+		BaseInvoker:    *protocol.NewBaseInvoker(url),
+		config:         config,
+		transport:      transport,
+		client:         &http.Client{Transport: transport},
+		requestTimeout: requestTimeout,
+	}
+}
+
+func (ri *RestInvoker) Invoke(invocation protocol.Invocation) protocol.Result {
+	var result protocol.RPCResult
+
+	inv := invocation.(*invocation_impl.RPCInvocation)
+	url := ri.GetUrl()
+	if ri.config == nil {
+		result.Err = perrors.Errorf("the rest config of the service %s isn't set", url.Service())
+		return &result
+	}
+	if result.Err = protocol.LimitAttachments(url, inv); result.Err != nil {
+		return &result
+	}
+	method := ri.config.method(inv.MethodName())
+	if method == nil {
+		result.Err = perrors.Errorf("the method %s of the service %s isn't in the rest config", inv.MethodName(), url.Service())
+		return &result
+	}
+
+	ctx, cancel := ri.callContext(inv)
+	defer cancel()
+	req, err := ri.newRequest(ctx, method, inv)
+	if err != nil {
+		result.Err = err
+		return &result
+	}
+	rsp, err := ri.client.Do(req)
+	if err != nil {
+		result.Err = perrors.WithStack(err)
+		return &result
+	}
+	defer rsp.Body.Close()
+
+	if header := rsp.Header.Get(ATTACHMENTS_HEADER); header != "" {
+		attachments := map[string]string{}
+		if err := json.Unmarshal([]byte(header), &attachments); err != nil {
+			logger.Warnf("parse the attachments of the method %s error: %v", inv.MethodName(), err)
+		}
+		result.Attrs = attachments
+	}
+	body, err := ioutil.ReadAll(rsp.Body)
+	if err != nil {
+		result.Err = perrors.WithStack(err)
+		return &result
+	}
+	if rsp.StatusCode < http.StatusOK || rsp.StatusCode >= http.StatusMultipleChoices {
+		result.Err = responseError(rsp, body)
+		return &result
+	}
+
+	result.Rest = inv.Reply()
+	if len(body) > 0 {
+		if inv.Reply() == nil {
+			var reply interface{}
+			result.Err = perrors.WithStack(json.Unmarshal(body, &reply))
+			result.Rest = reply
+		} else {
+			result.Err = perrors.WithStack(json.Unmarshal(body, inv.Reply()))
+		}
+	}
+	logger.Debugf("result.Err: %v, result.Rest: %v", result.Err, result.Rest)
+
+	return &result
+}
+
+// newRequest builds the HTTP request of the @inv by the @method config, the arguments are the variables of the
+// path, the query params and the JSON body
+func (ri *RestInvoker) newRequest(ctx context.Context, method *RestMethodConfig,
+	inv *invocation_impl.RPCInvocation) (*http.Request, error) {
+
+	args := inv.Arguments()
+	argument := func(index int) (interface{}, error) {
+		if index < 0 || index >= len(args) {
+			return nil, perrors.Errorf("the method %s has no argument %d", method.MethodName, index)
+		}
+		return args[index], nil
+	}
+
+	path := ri.config.fullPath(method)
+	for index, name := range method.PathParams {
+		arg, err := argument(index)
+		if err != nil {
+			return nil, err
+		}
+		value, err := formatParam(arg)
+		if err != nil {
+			return nil, err
+		}
+		path = strings.Replace(path, "{"+name+"}", pathEscape(value), -1)
+	}
+	var query []string
+	for index, name := range method.QueryParams {
+		arg, err := argument(index)
+		if err != nil {
+			return nil, err
+		}
+		if arg == nil {
+			continue
+		}
+		value, err := formatParam(arg)
+		if err != nil {
+			return nil, err
+		}
+		query = append(query, queryEscape(name)+"="+queryEscape(value))
+	}
+	rawURL := "http://" + ri.GetUrl().Location + path
+	if len(query) > 0 {
+		rawURL += "?" + strings.Join(query, "&")
+	}
+
+	var body io.Reader
+	if method.Body != nil {
+		arg, err := argument(*method.Body)
+		if err != nil {
+			return nil, err
+		}
+		data, err := json.Marshal(arg)
+		if err != nil {
+			return nil, perrors.WithStack(err)
+		}
+		body = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequest(method.HttpMethod, rawURL, body)
+	if err != nil {
+		return nil, perrors.WithStack(err)
+	}
+	req = req.WithContext(ctx)
+	if body != nil {
+		req.Header.Set("Content-Type", method.Consumes)
+	}
+	req.Header.Set("Accept", method.Produces)
+	if attachments := inv.Attachments(); len(attachments) > 0 {
+		data, err := json.Marshal(attachments)
+		if err != nil {
+			return nil, perrors.WithStack(err)
+		}
+		req.Header.Set(ATTACHMENTS_HEADER, string(data))
+	}
+	return req, nil
+}
+
+// callContext returns the context of the @inv with the request timeout of the attachment in milliseconds or of
+// the invoker
+func (ri *RestInvoker) callContext(inv *invocation_impl.RPCInvocation) (context.Context, context.CancelFunc) {
+	timeout := ri.requestTimeout
+	if v, err := strconv.ParseInt(inv.AttachmentsByKey(constant.TIMEOUT_KEY, ""), 10, 64); err == nil && v > 0 {
+		timeout = time.Duration(v) * time.Millisecond
+	}
+	if timeout <= 0 {
+		return context.WithCancel(inv.Context())
+	}
+	return context.WithTimeout(inv.Context(), timeout)
+}
+
+// responseError returns the RemoteError in the @body of the error response @rsp, or the status of it
+func responseError(rsp *http.Response, body []byte) error {
+	remoteErr := &protocol.RemoteError{}
+	if err := json.Unmarshal(body, remoteErr); err != nil || remoteErr.Message == "" {
+		remoteErr.Message = rsp.Status
+	}
+	return remoteErr
+}
+
+func (ri *RestInvoker) Destroy() {
+	ri.BaseInvoker.Destroy()
+	ri.transport.CloseIdleConnections()
+}
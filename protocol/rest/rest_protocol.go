@@ -0,0 +1,122 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package rest
+
+import (
+	"sync"
+)
+
+import (
+	"github.com/apache/dubbo-go/common"
+	"github.com/apache/dubbo-go/common/constant"
+	"github.com/apache/dubbo-go/common/extension"
+	"github.com/apache/dubbo-go/common/logger"
+	"github.com/apache/dubbo-go/config"
+	"github.com/apache/dubbo-go/protocol"
+)
+
+const REST = "rest"
+
+func init() {
+	extension.SetProtocol(REST, GetProtocol)
+}
+
+var restProtocol *RestProtocol
+
+// RestProtocol exports and refers the services by the REST configs set by SetRestServiceConfig, the services are
+// keyed by their interfaces. The services exported on the same address share the HTTP server.
+type RestProtocol struct {
+	protocol.BaseProtocol
+	serverMap  map[string]*Server
+	serverLock sync.Mutex
+}
+
+func NewRestProtocol() *RestProtocol {
+	return &RestProtocol{
+		BaseProtocol: protocol.NewBaseProtocol(),
+		serverMap:    make(map[string]*Server),
+	}
+}
+
+func (rp *RestProtocol) Export(invoker protocol.Invoker) protocol.Exporter {
+	url := invoker.GetUrl()
+	serviceKey := url.GetParam(constant.INTERFACE_KEY, "")
+	restConfig, err := GetRestServiceConfig(serviceKey)
+	if err != nil {
+		panic("[RestProtocol] " + err.Error())
+	}
+	service := common.ServiceMap.GetService(REST, url.GetParam(constant.BEAN_NAME_KEY, ""))
+	if service == nil {
+		panic("[RestProtocol] the implementation of the service " + serviceKey + " isn't found")
+	}
+
+	exporter := NewRestExporter(serviceKey, invoker, rp.ExporterMap())
+	rp.SetExporterMap(serviceKey, exporter)
+	logger.Infof("Export service: %s", url.String())
+
+	// start server
+	if err := rp.openServer(url).Deploy(serviceKey, restConfig, service); err != nil {
+		panic("[RestProtocol] " + err.Error())
+	}
+
+	return exporter
+}
+
+func (rp *RestProtocol) Refer(url common.URL) protocol.Invoker {
+	restConfig, err := GetRestServiceConfig(url.GetParam(constant.INTERFACE_KEY, ""))
+	if err != nil {
+		logger.Errorf("[RestProtocol] refer the service %s error: %v", url.String(), err)
+	}
+	invoker := NewRestInvoker(url, restConfig, config.GetConsumerConfig().RequestTimeout)
+	rp.SetInvokers(invoker)
+	logger.Infof("Refer service: %s", url.String())
+	return invoker
+}
+
+func (rp *RestProtocol) Destroy() {
+	logger.Infof("restProtocol destroy.")
+
+	rp.BaseProtocol.Destroy()
+
+	// stop server
+	rp.serverLock.Lock()
+	defer rp.serverLock.Unlock()
+	for key, server := range rp.serverMap {
+		delete(rp.serverMap, key)
+		server.Stop()
+	}
+}
+
+func (rp *RestProtocol) openServer(url common.URL) *Server {
+	rp.serverLock.Lock()
+	defer rp.serverLock.Unlock()
+	if srv, ok := rp.serverMap[url.Location]; ok {
+		return srv
+	}
+	srv := NewServer(rp.ExporterMap())
+	rp.serverMap[url.Location] = srv
+	srv.Start(url)
+	return srv
+}
+
+func GetProtocol() protocol.Protocol {
+	if restProtocol == nil {
+		restProtocol = NewRestProtocol()
+	}
+	return restProtocol
+}
@@ -0,0 +1,271 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package rest
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+import (
+	perrors "github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+import (
+	"github.com/apache/dubbo-go/common"
+	"github.com/apache/dubbo-go/common/constant"
+	"github.com/apache/dubbo-go/common/proxy/proxy_factory"
+	"github.com/apache/dubbo-go/filter"
+	"github.com/apache/dubbo-go/filter/impl"
+	"github.com/apache/dubbo-go/protocol"
+	invocation_impl "github.com/apache/dubbo-go/protocol/invocation"
+)
+
+func init() {
+	body := 0
+	err := SetRestServiceConfig(&RestServiceConfig{
+		InterfaceName: "com.ikurento.user.UserProvider",
+		Path:          "/v1/",
+		Methods: []*RestMethodConfig{
+			{MethodName: "GetUser", Path: "/users/{id}", PathParams: map[int]string{0: "id"},
+				QueryParams: map[int]string{1: "detail"}},
+			{MethodName: "GetUserByName", Path: "/users/name/{name}", PathParams: map[int]string{0: "name"}},
+			{MethodName: "CreateUser", Path: "/users", HttpMethod: "post", Body: &body},
+			{MethodName: "DeleteUser", Path: "/users/{id}", HttpMethod: http.MethodDelete, PathParams: map[int]string{0: "id"}},
+		},
+	})
+	if err != nil {
+		panic(err)
+	}
+}
+
+type Address struct {
+	City string
+}
+
+type User struct {
+	Id       int64
+	Name     string
+	Birthday time.Time
+	Address  *Address
+}
+
+type UserProvider struct {
+}
+
+func (p *UserProvider) GetUser(ctx context.Context, id int64, detail bool) (*User, error) {
+	if id == 0 {
+		return nil, perrors.New("user not found")
+	}
+	user := &User{Id: id, Name: "dubbo"}
+	if detail {
+		user.Address = &Address{City: "Hangzhou"}
+	}
+	return user, nil
+}
+
+func (p *UserProvider) GetUserByName(ctx context.Context, name string) (*User, error) {
+	return &User{Name: name}, nil
+}
+
+func (p *UserProvider) CreateUser(ctx context.Context, user *User) (*User, error) {
+	if user == nil {
+		return nil, perrors.New("no user")
+	}
+	user.Id = 1
+	return user, nil
+}
+
+func (p *UserProvider) DeleteUser(ctx context.Context, id int64) error {
+	return nil
+}
+
+func (p *UserProvider) Reference() string {
+	return "UserProvider"
+}
+
+type UserConsumer struct {
+	GetUser       func(ctx context.Context, id int64, detail bool) (*User, error)
+	GetUserByName func(ctx context.Context, name string) (*User, error)
+	CreateUser    func(ctx context.Context, user *User) (*User, error)
+	DeleteUser    func(ctx context.Context, id int64) error
+}
+
+func (c *UserConsumer) Reference() string {
+	return "UserConsumer"
+}
+
+func TestRestProtocol_ExportAndRefer(t *testing.T) {
+	_, err := common.ServiceMap.Register(REST, &UserProvider{})
+	assert.NoError(t, err)
+	providerUrl, err := common.NewURL(context.Background(), "rest://127.0.0.1:30010/UserProvider?"+
+		"interface=com.ikurento.user.UserProvider&bean.name=UserProvider&rest.read_timeout=5000&rest.max_body_size=1024")
+	assert.NoError(t, err)
+	exporter := GetProtocol().Export(protocol.NewBaseInvoker(providerUrl))
+	defer GetProtocol().Destroy()
+
+	// the timeouts of the server
+	httpServer := GetProtocol().(*RestProtocol).serverMap[providerUrl.Location].httpServer
+	assert.Equal(t, time.Duration(constant.DEFAULT_REST_READ_HEADER_TIMEOUT)*time.Millisecond, httpServer.ReadHeaderTimeout)
+	assert.Equal(t, 5*time.Second, httpServer.ReadTimeout)
+	assert.Equal(t, time.Duration(constant.DEFAULT_REST_IDLE_TIMEOUT)*time.Millisecond, httpServer.IdleTimeout)
+
+	consumerUrl, err := common.NewURL(context.Background(), "rest://127.0.0.1:30010/UserConsumer?"+
+		"interface=com.ikurento.user.UserProvider")
+	assert.NoError(t, err)
+	invoker := GetProtocol().Refer(consumerUrl)
+	consumer := &UserConsumer{}
+	proxy_factory.NewDefaultProxyFactory().GetProxy(invoker, &consumerUrl).Implement(consumer)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	// GET with the path variable and the query param
+	user, err := consumer.GetUser(ctx, 7, true)
+	assert.NoError(t, err)
+	assert.Equal(t, &User{Id: 7, Name: "dubbo", Address: &Address{City: "Hangzhou"}}, user)
+	user, err = consumer.GetUser(ctx, 7, false)
+	assert.NoError(t, err)
+	assert.Nil(t, user.Address)
+	user, err = consumer.GetUserByName(ctx, "dubbo/go")
+	assert.NoError(t, err)
+	assert.Equal(t, "dubbo/go", user.Name)
+
+	// POST with the JSON body
+	birthday := time.Date(2019, 8, 1, 12, 0, 0, 0, time.UTC)
+	user, err = consumer.CreateUser(ctx, &User{Name: "dubbo-go", Birthday: birthday, Address: &Address{City: "Beijing"}})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), user.Id)
+	assert.Equal(t, "dubbo-go", user.Name)
+	assert.True(t, birthday.Equal(user.Birthday))
+	assert.Equal(t, "Beijing", user.Address.City)
+
+	// the method without the reply
+	assert.NoError(t, consumer.DeleteUser(ctx, 7))
+
+	// the error of the service
+	_, err = consumer.GetUser(ctx, 0, false)
+	assert.Error(t, err)
+	remoteErr, ok := perrors.Cause(err).(*protocol.RemoteError)
+	assert.True(t, ok)
+	assert.Equal(t, "user not found", remoteErr.Message)
+
+	// the requests out of the routes
+	rsp, err := http.Get("http://127.0.0.1:30010/v1/users/abc")
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, rsp.StatusCode)
+	rsp.Body.Close()
+	rsp, err = http.Post("http://127.0.0.1:30010/v1/users", "text/plain", strings.NewReader("dubbo"))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusUnsupportedMediaType, rsp.StatusCode)
+	rsp.Body.Close()
+	largeBody := `{"name":"` + strings.Repeat("x", 1024) + `"}`
+	rsp, err = http.Post("http://127.0.0.1:30010/v1/users", APPLICATION_JSON, strings.NewReader(largeBody))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, rsp.StatusCode)
+	rsp.Body.Close()
+	// the body without the content length is limited while decoding
+	rsp, err = http.Post("http://127.0.0.1:30010/v1/users", APPLICATION_JSON, io.MultiReader(strings.NewReader(largeBody)))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, rsp.StatusCode)
+	rsp.Body.Close()
+	rsp, err = http.Post("http://127.0.0.1:30010/v1/users/7", APPLICATION_JSON, strings.NewReader("{}"))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusMethodNotAllowed, rsp.StatusCode)
+	rsp.Body.Close()
+	rsp, err = http.Get("http://127.0.0.1:30010/v2/users/7")
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusNotFound, rsp.StatusCode)
+	rsp.Body.Close()
+
+	// the methods out of the config
+	res := invoker.Invoke(invocation_impl.NewRPCInvocationWithOptions(invocation_impl.WithMethodName("UpdateUser"),
+		invocation_impl.WithArguments([]interface{}{&User{}})))
+	assert.Error(t, res.Error())
+
+	// the service isn't served after unexport
+	exporter.Unexport()
+	_, err = consumer.GetUser(ctx, 7, false)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "isn't exported")
+}
+
+// filteredInvoker invokes through the filter like the filter chain of the provider
+type filteredInvoker struct {
+	protocol.BaseInvoker
+	filter filter.Filter
+}
+
+func (fi *filteredInvoker) Invoke(invocation protocol.Invocation) protocol.Result {
+	return fi.filter.OnResponse(fi.filter.Invoke(&fi.BaseInvoker, invocation), fi, invocation)
+}
+
+func TestRestProtocol_ResponseHeaders(t *testing.T) {
+	common.ServiceMap.Register(REST, &UserProvider{})
+	providerUrl, err := common.NewURL(context.Background(), "rest://127.0.0.1:30011/UserProvider?"+
+		"interface=com.ikurento.user.UserProvider&bean.name=UserProvider&response.headers=trace_id:X-Trace-Id")
+	assert.NoError(t, err)
+	GetProtocol().Export(&filteredInvoker{
+		BaseInvoker: *protocol.NewBaseInvoker(providerUrl),
+		filter:      impl.GetResponseHeaderFilter(),
+	})
+	defer GetProtocol().Destroy()
+
+	req, err := http.NewRequest(http.MethodGet, "http://127.0.0.1:30011/v1/users/7", nil)
+	assert.NoError(t, err)
+	req.Header.Set(ATTACHMENTS_HEADER, `{"trace_id":"t-1","tenant":"a"}`)
+	rsp, err := http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	defer rsp.Body.Close()
+
+	// the attachments mapped by the response_header filter are the response headers
+	assert.Equal(t, http.StatusOK, rsp.StatusCode)
+	assert.Equal(t, "t-1", rsp.Header.Get("X-Trace-Id"))
+	assert.Equal(t, "", rsp.Header.Get("tenant"))
+	assert.NotContains(t, rsp.Header.Get(ATTACHMENTS_HEADER), constant.RESPONSE_HEADER_ATTACHMENT_PREFIX)
+}
+
+func TestSetRestServiceConfig(t *testing.T) {
+	body := 0
+	err := SetRestServiceConfig(&RestServiceConfig{
+		InterfaceName: "com.ikurento.user.Conflict",
+		Methods: []*RestMethodConfig{
+			{MethodName: "UpdateUser", Path: "/users/{id}", PathParams: map[int]string{0: "id"}, Body: &body},
+		},
+	})
+	assert.Error(t, err)
+
+	err = SetRestServiceConfig(&RestServiceConfig{
+		InterfaceName: "com.ikurento.user.Xml",
+		Methods:       []*RestMethodConfig{{MethodName: "GetUser", Path: "/users", Produces: "application/xml"}},
+	})
+	assert.Error(t, err)
+
+	_, err = GetRestServiceConfig("com.ikurento.user.Unknown")
+	assert.Error(t, err)
+	config, err := GetRestServiceConfig("com.ikurento.user.UserProvider")
+	assert.NoError(t, err)
+	assert.Equal(t, http.MethodPost, config.method("CreateUser").HttpMethod)
+	assert.Equal(t, APPLICATION_JSON, config.method("CreateUser").Consumes)
+	assert.Equal(t, "/v1/users/{id}", config.fullPath(config.method("GetUser")))
+}
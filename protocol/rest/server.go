@@ -0,0 +1,384 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package rest
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+)
+
+import (
+	perrors "github.com/pkg/errors"
+)
+
+import (
+	"github.com/apache/dubbo-go/common"
+	"github.com/apache/dubbo-go/common/constant"
+	"github.com/apache/dubbo-go/common/logger"
+	"github.com/apache/dubbo-go/protocol"
+	"github.com/apache/dubbo-go/protocol/invocation"
+)
+
+// the header carrying the attachments of the invocation and the result as a JSON object, the keys of the
+// attachments are case sensitive unlike the headers
+const ATTACHMENTS_HEADER = "Dubbo-Attachments"
+
+// route is the path of a method of the service exported as serviceKey
+type route struct {
+	serviceKey string
+	config     *RestMethodConfig
+	// the segments of the full path, the {name} segments are the variables
+	segments []string
+}
+
+// match returns the variables of the @segments of the request path if they match the route
+func (r *route) match(segments []string) (map[string]string, bool) {
+	if len(segments) != len(r.segments) {
+		return nil, false
+	}
+	vars := make(map[string]string)
+	for i, segment := range r.segments {
+		if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+			vars[segment[1:len(segment)-1]] = segments[i]
+		} else if segment != segments[i] {
+			return nil, false
+		}
+	}
+	return vars, true
+}
+
+// Server serves the services exported by the rest protocol on an address, the requests are routed by the paths of
+// the REST configs of the services to their exporters, and run through the filter chains before the services.
+type Server struct {
+	exporterMap *sync.Map
+	routesLock  sync.RWMutex
+	routes      []*route
+	httpServer  *http.Server
+	// the max size in bytes of the request bodies
+	maxBodySize int64
+}
+
+func NewServer(exporterMap *sync.Map) *Server {
+	return &Server{exporterMap: exporterMap, maxBodySize: constant.DEFAULT_REST_MAX_BODY_SIZE}
+}
+
+// Start serves on the address of the @url
+func (s *Server) Start(url common.URL) {
+	listener, err := net.Listen("tcp", url.Location)
+	if err != nil {
+		logger.Errorf("rest server [%s] start failed: %v", url.Path, err)
+		return
+	}
+	logger.Infof("rest server start to listen on %s", listener.Addr())
+
+	s.maxBodySize = url.GetParamInt(constant.REST_MAX_BODY_SIZE_KEY, constant.DEFAULT_REST_MAX_BODY_SIZE)
+	s.httpServer = &http.Server{
+		Handler:           s,
+		ReadHeaderTimeout: restTimeout(url, constant.REST_READ_HEADER_TIMEOUT_KEY, constant.DEFAULT_REST_READ_HEADER_TIMEOUT),
+		ReadTimeout:       restTimeout(url, constant.REST_READ_TIMEOUT_KEY, constant.DEFAULT_REST_READ_TIMEOUT),
+		IdleTimeout:       restTimeout(url, constant.REST_IDLE_TIMEOUT_KEY, constant.DEFAULT_REST_IDLE_TIMEOUT),
+	}
+	go func() {
+		if err := s.httpServer.Serve(listener); err != nil && err != http.ErrServerClosed {
+			logger.Warnf("rest server on %s stopped: %v", listener.Addr(), err)
+		}
+	}()
+}
+
+// restTimeout returns the timeout in milliseconds of the @key of the @url
+func restTimeout(url common.URL, key string, defaultValue int64) time.Duration {
+	return time.Duration(url.GetParamInt(key, defaultValue)) * time.Millisecond
+}
+
+func (s *Server) Stop() {
+	if s.httpServer != nil {
+		if err := s.httpServer.Close(); err != nil {
+			logger.Warnf("stop the rest server error: %v", err)
+		}
+	}
+}
+
+// Deploy routes the paths of the methods of the @config to the service exported as @serviceKey, which is
+// implemented by the @service
+func (s *Server) Deploy(serviceKey string, config *RestServiceConfig, service *common.Service) error {
+	routes := make([]*route, 0, len(config.Methods))
+	for _, methodConfig := range config.Methods {
+		method := service.Method()[methodConfig.MethodName]
+		if method == nil {
+			return perrors.Errorf("the method %s of the service %s isn't found", methodConfig.MethodName, serviceKey)
+		}
+		numArgs := len(method.ArgsType())
+		indexes := []int{}
+		for index := range methodConfig.PathParams {
+			indexes = append(indexes, index)
+		}
+		for index := range methodConfig.QueryParams {
+			indexes = append(indexes, index)
+		}
+		if methodConfig.Body != nil {
+			indexes = append(indexes, *methodConfig.Body)
+		}
+		for _, index := range indexes {
+			if index < 0 || index >= numArgs {
+				return perrors.Errorf("the method %s of the service %s has no argument %d",
+					methodConfig.MethodName, serviceKey, index)
+			}
+		}
+		routes = append(routes, &route{
+			serviceKey: serviceKey,
+			config:     methodConfig,
+			segments:   strings.Split(config.fullPath(methodConfig), "/"),
+		})
+	}
+
+	s.routesLock.Lock()
+	defer s.routesLock.Unlock()
+	for _, r := range routes {
+		for _, deployed := range s.routes {
+			if deployed.config.HttpMethod == r.config.HttpMethod && reflect.DeepEqual(deployed.segments, r.segments) &&
+				deployed.serviceKey != serviceKey {
+				return perrors.Errorf("the path %s %s is deployed by the service %s",
+					r.config.HttpMethod, strings.Join(r.segments, "/"), deployed.serviceKey)
+			}
+		}
+	}
+	s.undeploy(serviceKey)
+	s.routes = append(s.routes, routes...)
+	return nil
+}
+
+// undeploy removes the routes of the service exported as @serviceKey, the caller holds the routesLock
+func (s *Server) undeploy(serviceKey string) {
+	routes := s.routes[:0]
+	for _, r := range s.routes {
+		if r.serviceKey != serviceKey {
+			routes = append(routes, r)
+		}
+	}
+	s.routes = routes
+}
+
+// route returns the route of the @req and the variables of its path, the status is 404 if no path matches and 405
+// if no method of the matching paths does.
+func (s *Server) route(req *http.Request) (*route, map[string]string, int) {
+	segments := strings.Split(req.URL.EscapedPath(), "/")
+	for i, segment := range segments {
+		if unescaped, err := pathUnescape(segment); err == nil {
+			segments[i] = unescaped
+		}
+	}
+
+	s.routesLock.RLock()
+	defer s.routesLock.RUnlock()
+	status := http.StatusNotFound
+	for _, r := range s.routes {
+		vars, ok := r.match(segments)
+		if !ok {
+			continue
+		}
+		if r.config.HttpMethod == req.Method {
+			return r, vars, http.StatusOK
+		}
+		status = http.StatusMethodNotAllowed
+	}
+	return nil, nil, status
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	r, vars, status := s.route(req)
+	if r == nil {
+		writeError(w, status, perrors.New(http.StatusText(status)))
+		return
+	}
+	// the routes are kept after unexporting until the service is exported again
+	exporter, ok := s.exporterMap.Load(r.serviceKey)
+	if !ok {
+		writeError(w, http.StatusNotFound, perrors.Errorf("the service %s isn't exported", r.serviceKey))
+		return
+	}
+	invoker := exporter.(protocol.Exporter).GetInvoker()
+	svc := common.ServiceMap.GetService(REST, invoker.GetUrl().GetParam(constant.BEAN_NAME_KEY, ""))
+	if svc == nil {
+		writeError(w, http.StatusNotFound, perrors.Errorf("the implementation of the service %s isn't found", r.serviceKey))
+		return
+	}
+	method := svc.Method()[r.config.MethodName]
+
+	args, status, err := bindArguments(w, req, r.config, method, vars, s.maxBodySize)
+	if err != nil {
+		writeError(w, status, err)
+		return
+	}
+	attachments := map[string]string{}
+	if header := req.Header.Get(ATTACHMENTS_HEADER); header != "" {
+		if err := json.Unmarshal([]byte(header), &attachments); err != nil {
+			writeError(w, http.StatusBadRequest, perrors.WithMessage(err, "parse the attachments"))
+			return
+		}
+	}
+	if ip, _, err := net.SplitHostPort(req.RemoteAddr); err == nil {
+		attachments[constant.REMOTE_IP_KEY] = ip
+	}
+	inv := invocation.NewRPCInvocationWithOptions(invocation.WithMethodName(r.config.MethodName),
+		invocation.WithArguments(args), invocation.WithAttachments(attachments), invocation.WithContext(req.Context()))
+
+	result := invoker.Invoke(inv)
+	writeAttachments(w, result.Attachments())
+	if err := result.Error(); err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	reply := result.Result()
+	if reply == nil {
+		reply, err = call(inv.Context(), svc, method, inv.Arguments())
+		// the filters are notified of the result of the service by the callback of the invocation, e.g. to cache it
+		if callback, ok := inv.CallBack().(func(protocol.Result)); ok {
+			callback(&protocol.RPCResult{Rest: reply, Err: err})
+		}
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+	}
+
+	if method.ReplyType() == nil {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	data, err := json.Marshal(reply)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, perrors.WithMessage(err, "marshal the reply"))
+		return
+	}
+	w.Header().Set("Content-Type", r.config.Produces)
+	if _, err := w.Write(data); err != nil {
+		logger.Warnf("write the reply of the method %s of the service %s error: %v", r.config.MethodName, r.serviceKey, err)
+	}
+}
+
+// writeAttachments writes the result @attachments with the response header prefix, e.g. set by the response_header
+// filter, as the response headers without the prefix, and the others in the attachments header
+func writeAttachments(w http.ResponseWriter, attachments map[string]string) {
+	others := make(map[string]string, len(attachments))
+	for k, v := range attachments {
+		if strings.HasPrefix(k, constant.RESPONSE_HEADER_ATTACHMENT_PREFIX) {
+			w.Header().Set(strings.TrimPrefix(k, constant.RESPONSE_HEADER_ATTACHMENT_PREFIX), v)
+			continue
+		}
+		others[k] = v
+	}
+	if len(others) > 0 {
+		if data, err := json.Marshal(others); err == nil {
+			w.Header().Set(ATTACHMENTS_HEADER, string(data))
+		}
+	}
+}
+
+// bindArguments binds the arguments of the @method from the path variables @vars, the query params and the body of
+// the @req by the @config, the unbound arguments are the zero values. The body over @maxBodySize is rejected.
+// The status is returned with the error.
+func bindArguments(w http.ResponseWriter, req *http.Request, config *RestMethodConfig, method *common.MethodType,
+	vars map[string]string, maxBodySize int64) ([]interface{}, int, error) {
+
+	argsType := method.ArgsType()
+	args := make([]interface{}, len(argsType))
+	for i, typ := range argsType {
+		args[i] = reflect.Zero(typ).Interface()
+	}
+	for index, name := range config.PathParams {
+		value, err := parseParam(vars[name], argsType[index])
+		if err != nil {
+			return nil, http.StatusBadRequest, perrors.WithMessagef(err, "the path variable %s", name)
+		}
+		args[index] = value.Interface()
+	}
+	query := req.URL.Query()
+	for index, name := range config.QueryParams {
+		if _, ok := query[name]; !ok {
+			continue
+		}
+		value, err := parseParam(query.Get(name), argsType[index])
+		if err != nil {
+			return nil, http.StatusBadRequest, perrors.WithMessagef(err, "the query param %s", name)
+		}
+		args[index] = value.Interface()
+	}
+	if config.Body != nil && req.ContentLength != 0 {
+		mediaType, _, err := mime.ParseMediaType(req.Header.Get("Content-Type"))
+		if err != nil || mediaType != config.Consumes {
+			return nil, http.StatusUnsupportedMediaType,
+				perrors.Errorf("the content type %q isn't %s", req.Header.Get("Content-Type"), config.Consumes)
+		}
+		if req.ContentLength > maxBodySize {
+			return nil, http.StatusRequestEntityTooLarge, perrors.Errorf("the body is larger than %d bytes", maxBodySize)
+		}
+		body := reflect.New(argsType[*config.Body])
+		err = json.NewDecoder(http.MaxBytesReader(w, req.Body, maxBodySize)).Decode(body.Interface())
+		if err != nil && err != io.EOF {
+			return nil, http.StatusBadRequest, perrors.WithMessage(err, "decode the body")
+		}
+		if err == nil {
+			args[*config.Body] = body.Elem().Interface()
+		}
+	}
+	return args, http.StatusOK, nil
+}
+
+// call calls the @method of the @svc with the @ctx if it takes one
+func call(ctx context.Context, svc *common.Service, method *common.MethodType, args []interface{}) (interface{}, error) {
+	in := []reflect.Value{svc.Rcvr()}
+	if method.CtxType() != nil {
+		in = append(in, method.SuiteContext(ctx))
+	}
+	for i, arg := range args {
+		in = append(in, method.SuiteArgument(i, arg, false))
+	}
+	returnValues := method.Method().Func.Call(in)
+
+	var reply interface{}
+	retErr := returnValues[len(returnValues)-1].Interface()
+	if len(returnValues) == 2 {
+		reply = returnValues[0].Interface()
+	}
+	if retErr != nil {
+		return reply, retErr.(error)
+	}
+	return reply, nil
+}
+
+// writeError writes the @err as the JSON of the RemoteError with its status code, message and context
+func writeError(w http.ResponseWriter, status int, err error) {
+	remoteErr, ok := protocol.DecodeError(protocol.EncodeError(err)).(*protocol.RemoteError)
+	if !ok {
+		remoteErr = &protocol.RemoteError{Message: err.Error()}
+	}
+	data, _ := json.Marshal(remoteErr)
+	w.Header().Set("Content-Type", APPLICATION_JSON)
+	w.WriteHeader(status)
+	if _, err := w.Write(data); err != nil {
+		logger.Warnf("write the error of the rest request error: %v", err)
+	}
+}
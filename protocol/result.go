@@ -63,6 +63,9 @@ func (r *RPCResult) Attachments() map[string]string {
 }
 
 func (r *RPCResult) AddAttachment(key, value string) {
+	if r.Attrs == nil {
+		r.Attrs = make(map[string]string)
+	}
 	r.Attrs[key] = value
 }
 
@@ -0,0 +1,56 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package protocol
+
+// RetryableError is the error annotated with whether the failed invocation is worth retrying,
+// e.g. by the failfast cluster, so the callers or the upstream clusters can decide to retry it.
+type RetryableError interface {
+	error
+	Retryable() bool
+}
+
+type retryableError struct {
+	err       error
+	retryable bool
+}
+
+// NewRetryableError annotates the @err with whether it's @retryable, its message is kept
+func NewRetryableError(err error, retryable bool) RetryableError {
+	return &retryableError{
+		err:       err,
+		retryable: retryable,
+	}
+}
+
+func (e *retryableError) Retryable() bool {
+	return e.retryable
+}
+
+func (e *retryableError) Error() string {
+	return e.err.Error()
+}
+
+// Cause returns the annotated error, for perrors.Cause
+func (e *retryableError) Cause() error {
+	return e.err
+}
+
+// Unwrap returns the annotated error, for errors.Is and errors.As
+func (e *retryableError) Unwrap() error {
+	return e.err
+}
@@ -0,0 +1,50 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package protocol
+
+import (
+	"fmt"
+)
+
+// StatusError is returned by protocols carrying a status code in their response,
+// e.g. the http status of jsonrpc. cluster invokers use the code to decide
+// whether a failed invocation is worth retrying on another provider.
+type StatusError interface {
+	error
+	StatusCode() string
+}
+
+type statusError struct {
+	code string
+	msg  string
+}
+
+func NewStatusError(code string, msg string) StatusError {
+	return &statusError{
+		code: code,
+		msg:  msg,
+	}
+}
+
+func (e *statusError) StatusCode() string {
+	return e.code
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("status code:%s, error string:%s", e.code, e.msg)
+}
@@ -0,0 +1,100 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package directory
+
+import (
+	"github.com/apache/dubbo-go/common"
+	"github.com/apache/dubbo-go/common/config"
+	"github.com/apache/dubbo-go/common/constant"
+	"github.com/apache/dubbo-go/common/logger"
+	"github.com/apache/dubbo-go/config_center"
+	"github.com/apache/dubbo-go/protocol"
+	"github.com/apache/dubbo-go/remoting"
+)
+
+// configuredInvoker is the invoker with the url overridden by the configurator rule
+type configuredInvoker struct {
+	protocol.Invoker
+	url common.URL
+}
+
+func (ivk *configuredInvoker) GetUrl() common.URL {
+	return ivk.url
+}
+
+func (dir *registryDirectory) configuratorKey() string {
+	return dir.GetUrl().SubURL.ServiceKey() + constant.CONFIGURATORS_SUFFIX
+}
+
+// subscribe the configurator rules of the service from the config center
+func (dir *registryDirectory) subscribeConfigurators() {
+	dynamicConfig := config.GetEnvInstance().GetDynamicConfiguration()
+	if dynamicConfig == nil {
+		return
+	}
+
+	key := dir.configuratorKey()
+	dynamicConfig.AddListener(key, dir, config_center.WithGroup(config_center.DEFAULT_GROUP))
+	content, err := dynamicConfig.GetConfig(key, config_center.WithGroup(config_center.DEFAULT_GROUP))
+	if err != nil {
+		logger.Debugf("Get configurator rule {%s} error, error message is %v", key, err)
+		return
+	}
+	if content != "" {
+		dir.Process(&remoting.ConfigChangeEvent{Key: key, Value: content, ConfigType: remoting.EventTypeAdd})
+	}
+}
+
+func (dir *registryDirectory) unsubscribeConfigurators() {
+	if dynamicConfig := config.GetEnvInstance().GetDynamicConfiguration(); dynamicConfig != nil {
+		dynamicConfig.RemoveListener(dir.configuratorKey(), dir, config_center.WithGroup(config_center.DEFAULT_GROUP))
+	}
+}
+
+// Process applies the configurator rule pushed by the config center to the cached invokers
+func (dir *registryDirectory) Process(event *remoting.ConfigChangeEvent) {
+	logger.Infof("configurator rule changed, event: %v", event)
+
+	var rule *config_center.ConfiguratorRule
+	if event.ConfigType != remoting.EventTypeDel {
+		content, _ := event.Value.(string)
+		var err error
+		if rule, err = config_center.ParseConfiguratorRule(content); err != nil {
+			logger.Errorf("Parse configurator rule error, the rule is ignored, error message is %v", err)
+			return
+		}
+	}
+
+	dir.listenerLock.Lock()
+	dir.configuratorRule = rule
+	dir.listenerLock.Unlock()
+	dir.refreshCacheInvokers()
+}
+
+// configure returns nil if the provider is disabled by the configurator rule
+func (dir *registryDirectory) configure(invoker protocol.Invoker) protocol.Invoker {
+	url, ok := dir.configuratorRule.Configure(invoker.GetUrl())
+	if !ok {
+		return invoker
+	}
+	if url.GetParamBool(constant.DISABLED_KEY, false) {
+		logger.Infof("provider {%s} is disabled by the configurator rule", url.Key())
+		return nil
+	}
+	return &configuredInvoker{Invoker: invoker, url: url}
+}
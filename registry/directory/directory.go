@@ -18,7 +18,9 @@
 package directory
 
 import (
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -27,11 +29,14 @@ import (
 )
 
 import (
+	"github.com/apache/dubbo-go/cluster"
 	"github.com/apache/dubbo-go/cluster/directory"
+	_ "github.com/apache/dubbo-go/cluster/router"
 	"github.com/apache/dubbo-go/common"
 	"github.com/apache/dubbo-go/common/constant"
 	"github.com/apache/dubbo-go/common/extension"
 	"github.com/apache/dubbo-go/common/logger"
+	"github.com/apache/dubbo-go/config_center"
 	"github.com/apache/dubbo-go/protocol"
 	"github.com/apache/dubbo-go/protocol/protocolwrapper"
 	"github.com/apache/dubbo-go/registry"
@@ -55,6 +60,14 @@ type registryDirectory struct {
 	serviceType      string
 	registry         registry.Registry
 	cacheInvokersMap *sync.Map //use sync.map
+	configuratorRule *config_center.ConfiguratorRule
+	metadataCache    *metadataCache
+	cachedProviders  *sync.Map // the providers loaded from the metadata cache and not confirmed by the registry
+	eventVersion     int64     // the version assigned to the last received event without version
+	notifyLock       sync.Mutex
+	orderedNotify    bool
+	providerVersions map[string]int64 // the version of the last applied event of the providers
+	routerChain      *cluster.RouterChain
 	Options
 }
 
@@ -69,14 +82,21 @@ func NewRegistryDirectory(url *common.URL, registry registry.Registry, opts ...O
 	if url.SubURL == nil {
 		return nil, perrors.Errorf("url is invalid, suburl can not be nil")
 	}
-	return &registryDirectory{
+	dir := &registryDirectory{
 		BaseDirectory:    directory.NewBaseDirectory(url),
 		cacheInvokers:    []protocol.Invoker{},
 		cacheInvokersMap: &sync.Map{},
+		cachedProviders:  &sync.Map{},
 		serviceType:      url.SubURL.Service(),
 		registry:         registry,
+		orderedNotify:    url.GetParamBool(constant.REGISTRY_ORDERED_NOTIFY_KEY, true),
+		providerVersions: make(map[string]int64),
+		routerChain:      newRouterChain(url.SubURL),
 		Options:          options,
-	}, nil
+	}
+	dir.subscribeConfigurators()
+	dir.loadCachedProviders()
+	return dir, nil
 }
 
 //subscribe from registry
@@ -105,6 +125,10 @@ func (dir *registryDirectory) Subscribe(url common.URL) {
 				time.Sleep(time.Duration(RegistryConnDelay) * time.Second)
 				return
 			} else {
+				if serviceEvent.Version == 0 {
+					// the events are applied asynchronously, so they're ordered on receipt
+					serviceEvent.Version = atomic.AddInt64(&dir.eventVersion, 1)
+				}
 				logger.Infof("update begin, service event: %v", serviceEvent.String())
 				go dir.update(serviceEvent)
 			}
@@ -124,10 +148,33 @@ func (dir *registryDirectory) update(res *registry.ServiceEvent) {
 
 	logger.Debugf("update service name: %s!", res.Service)
 
+	if dir.orderedNotify {
+		dir.notifyLock.Lock()
+		defer dir.notifyLock.Unlock()
+		if !dir.acceptEvent(res) {
+			logger.Warnf("the stale service event %v is ignored", res)
+			return
+		}
+	}
 	dir.refreshInvokers(res)
 }
 
+// acceptEvent records the version of the event unless a newer event of the provider has been applied.
+// The events without version are always accepted.
+func (dir *registryDirectory) acceptEvent(res *registry.ServiceEvent) bool {
+	if res.Version == 0 {
+		return true
+	}
+	key := res.Service.Key()
+	if version, ok := dir.providerVersions[key]; ok && version > res.Version {
+		return false
+	}
+	dir.providerVersions[key] = res.Version
+	return true
+}
+
 func (dir *registryDirectory) refreshInvokers(res *registry.ServiceEvent) {
+	dir.saveProvider(res)
 
 	switch res.Action {
 	case remoting.EventTypeAdd:
@@ -141,11 +188,13 @@ func (dir *registryDirectory) refreshInvokers(res *registry.ServiceEvent) {
 		return
 	}
 
-	newInvokers := dir.toGroupInvokers()
+	dir.refreshCacheInvokers()
+}
 
+func (dir *registryDirectory) refreshCacheInvokers() {
 	dir.listenerLock.Lock()
 	defer dir.listenerLock.Unlock()
-	dir.cacheInvokers = newInvokers
+	dir.cacheInvokers = dir.toGroupInvokers()
 }
 
 func (dir *registryDirectory) toGroupInvokers() []protocol.Invoker {
@@ -155,9 +204,12 @@ func (dir *registryDirectory) toGroupInvokers() []protocol.Invoker {
 	groupInvokersList := []protocol.Invoker{}
 
 	dir.cacheInvokersMap.Range(func(key, value interface{}) bool {
-		newInvokersList = append(newInvokersList, value.(protocol.Invoker))
+		if invoker := dir.configure(value.(protocol.Invoker)); invoker != nil {
+			newInvokersList = append(newInvokersList, invoker)
+		}
 		return true
 	})
+	newInvokersList = dir.subset(newInvokersList)
 
 	for _, invoker := range newInvokersList {
 		group := invoker.GetUrl().GetParam(constant.GROUP_KEY, "")
@@ -176,8 +228,8 @@ func (dir *registryDirectory) toGroupInvokers() []protocol.Invoker {
 	} else {
 		for _, invokers := range groupInvokersMap {
 			staticDir := directory.NewStaticDirectory(invokers)
-			cluster := extension.GetCluster(dir.GetUrl().SubURL.GetParam(constant.CLUSTER_KEY, constant.DEFAULT_CLUSTER))
-			groupInvokersList = append(groupInvokersList, cluster.Join(staticDir))
+			clusterExtension := extension.GetCluster(cluster.GetClusterName(*dir.GetUrl().SubURL))
+			groupInvokersList = append(groupInvokersList, clusterExtension.Join(staticDir))
 		}
 	}
 
@@ -186,7 +238,10 @@ func (dir *registryDirectory) toGroupInvokers() []protocol.Invoker {
 
 func (dir *registryDirectory) uncacheInvoker(url common.URL) {
 	logger.Debugf("service will be deleted in cache invokers: invokers key is  %s!", url.Key())
-	dir.cacheInvokersMap.Delete(url.Key())
+	if value, ok := dir.cacheInvokersMap.Load(url.Key()); ok {
+		dir.cacheInvokersMap.Delete(url.Key())
+		sharedInvokers.release(dir.GetUrl().SubURL, value.(protocol.Invoker).GetUrl())
+	}
 }
 
 func (dir *registryDirectory) cacheInvoker(url common.URL) {
@@ -194,10 +249,11 @@ func (dir *registryDirectory) cacheInvoker(url common.URL) {
 	//check the url's protocol is equal to the protocol which is configured in reference config or referenceUrl is not care about protocol
 	if url.Protocol == referenceUrl.Protocol || referenceUrl.Protocol == "" {
 		url = common.MergeUrl(url, referenceUrl)
+		dir.confirmCachedProvider(url)
 
 		if _, ok := dir.cacheInvokersMap.Load(url.Key()); !ok {
 			logger.Debugf("service will be added in cache invokers: invokers key is  %s!", url.Key())
-			newInvoker := extension.GetProtocol(protocolwrapper.FILTER).Refer(url)
+			newInvoker := sharedInvokers.acquire(referenceUrl, url, extension.GetProtocol(protocolwrapper.FILTER).Refer)
 			if newInvoker != nil {
 				dir.cacheInvokersMap.Store(url.Key(), newInvoker)
 			}
@@ -205,9 +261,36 @@ func (dir *registryDirectory) cacheInvoker(url common.URL) {
 	}
 }
 
+// newRouterChain creates the routers of the reference @url by their factories, the ones failed to create are skipped
+func newRouterChain(url *common.URL) *cluster.RouterChain {
+	var routers []cluster.Router
+	for _, name := range strings.Split(url.GetParam(constant.ROUTER_KEY, constant.DEFAULT_ROUTERS), ",") {
+		if name = strings.TrimSpace(name); name == "" {
+			continue
+		}
+		if !extension.HasRouterFactory(name) {
+			logger.Errorf("the router %s of %s is not existing, make sure you have import the package", name, url.Service())
+			continue
+		}
+		r, err := extension.GetRouterFactory(name).Router(url)
+		if err != nil {
+			logger.Errorf("create the router %s of %s error: %v", name, url.Service(), err)
+			continue
+		}
+		routers = append(routers, r)
+	}
+	return cluster.NewRouterChain(routers...)
+}
+
 //select the protocol invokers from the directory
 func (dir *registryDirectory) List(invocation protocol.Invocation) []protocol.Invoker {
-	//TODO:router
+	return dir.routerChain.Route(dir.invokers(), *dir.GetUrl().SubURL, invocation)
+}
+
+// invokers returns the cached invokers, the slice is replaced rather than modified on refresh
+func (dir *registryDirectory) invokers() []protocol.Invoker {
+	dir.listenerLock.Lock()
+	defer dir.listenerLock.Unlock()
 	return dir.cacheInvokers
 }
 
@@ -215,7 +298,7 @@ func (dir *registryDirectory) IsAvailable() bool {
 	if !dir.BaseDirectory.IsAvailable() {
 		return dir.BaseDirectory.IsAvailable()
 	} else {
-		for _, ivk := range dir.cacheInvokers {
+		for _, ivk := range dir.invokers() {
 			if ivk.IsAvailable() {
 				return true
 			}
@@ -227,9 +310,19 @@ func (dir *registryDirectory) IsAvailable() bool {
 func (dir *registryDirectory) Destroy() {
 	//TODO:unregister & unsubscribe
 	dir.BaseDirectory.Destroy(func() {
-		for _, ivk := range dir.cacheInvokers {
-			ivk.Destroy()
-		}
+		dir.unsubscribeConfigurators()
+		// the invokers may be shared with the directories of other registries,
+		// they are destroyed when no directory refers them any more.
+		dir.cacheInvokersMap.Range(func(key, value interface{}) bool {
+			dir.cacheInvokersMap.Delete(key)
+			sharedInvokers.release(dir.GetUrl().SubURL, value.(protocol.Invoker).GetUrl())
+			return true
+		})
+		dir.listenerLock.Lock()
 		dir.cacheInvokers = []protocol.Invoker{}
+		dir.listenerLock.Unlock()
+		if dir.metadataCache != nil {
+			dir.metadataCache.flush()
+		}
 	})
 }
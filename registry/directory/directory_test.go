@@ -19,8 +19,12 @@ package directory
 
 import (
 	"context"
+	"io/ioutil"
 	"net/url"
+	"os"
+	"path/filepath"
 	"strconv"
+	"sync/atomic"
 	"testing"
 	"time"
 )
@@ -34,6 +38,7 @@ import (
 	"github.com/apache/dubbo-go/common"
 	"github.com/apache/dubbo-go/common/constant"
 	"github.com/apache/dubbo-go/common/extension"
+	"github.com/apache/dubbo-go/protocol"
 	"github.com/apache/dubbo-go/protocol/invocation"
 	"github.com/apache/dubbo-go/protocol/protocolwrapper"
 	"github.com/apache/dubbo-go/registry"
@@ -44,16 +49,59 @@ func TestSubscribe(t *testing.T) {
 	registryDirectory, _ := normalRegistryDir()
 
 	time.Sleep(1e9)
-	assert.Len(t, registryDirectory.cacheInvokers, 3)
+	assert.Len(t, registryDirectory.invokers(), 3)
 }
 
 func TestSubscribe_Delete(t *testing.T) {
 	registryDirectory, mockRegistry := normalRegistryDir()
 	time.Sleep(1e9)
-	assert.Len(t, registryDirectory.cacheInvokers, 3)
+	assert.Len(t, registryDirectory.invokers(), 3)
 	mockRegistry.MockEvent(&registry.ServiceEvent{Action: remoting.EventTypeDel, Service: *common.NewURLWithOptions(common.WithPath("TEST0"), common.WithProtocol("dubbo"))})
 	time.Sleep(1e9)
-	assert.Len(t, registryDirectory.cacheInvokers, 2)
+	assert.Len(t, registryDirectory.invokers(), 2)
+}
+
+func TestSubscribe_StaleEvent(t *testing.T) {
+	registryDirectory := orderedRegistryDir(true)
+	provider := *common.NewURLWithOptions(common.WithPath("TEST0"), common.WithProtocol("dubbo"))
+
+	// the provider deleted before is added again by the newer event
+	registryDirectory.update(&registry.ServiceEvent{Action: remoting.EventTypeAdd, Service: provider, Version: 2})
+	registryDirectory.update(&registry.ServiceEvent{Action: remoting.EventTypeDel, Service: provider, Version: 1})
+	assert.Len(t, registryDirectory.invokers(), 1)
+
+	// the stale add doesn't bring back the provider deleted by the newer event
+	registryDirectory.update(&registry.ServiceEvent{Action: remoting.EventTypeDel, Service: provider, Version: 4})
+	registryDirectory.update(&registry.ServiceEvent{Action: remoting.EventTypeAdd, Service: provider, Version: 3})
+	assert.Len(t, registryDirectory.invokers(), 0)
+
+	// the versions are tracked by provider
+	other := *common.NewURLWithOptions(common.WithPath("TEST1"), common.WithProtocol("dubbo"))
+	registryDirectory.update(&registry.ServiceEvent{Action: remoting.EventTypeAdd, Service: other, Version: 1})
+	assert.Len(t, registryDirectory.invokers(), 1)
+}
+
+func TestSubscribe_StaleEventUnordered(t *testing.T) {
+	registryDirectory := orderedRegistryDir(false)
+	provider := *common.NewURLWithOptions(common.WithPath("TEST0"), common.WithProtocol("dubbo"))
+
+	registryDirectory.update(&registry.ServiceEvent{Action: remoting.EventTypeAdd, Service: provider, Version: 2})
+	registryDirectory.update(&registry.ServiceEvent{Action: remoting.EventTypeDel, Service: provider, Version: 1})
+	assert.Len(t, registryDirectory.invokers(), 0)
+}
+
+func TestSubscribe_EventVersion(t *testing.T) {
+	registryDirectory, mockRegistry := normalRegistryDir()
+	time.Sleep(1e9)
+	assert.Equal(t, int64(3), atomic.LoadInt64(&registryDirectory.eventVersion))
+
+	// the event with the version of the registry keeps it
+	provider := *common.NewURLWithOptions(common.WithPath("TEST0"), common.WithProtocol("dubbo"))
+	registryDirectory.update(&registry.ServiceEvent{Action: remoting.EventTypeDel, Service: provider, Version: 100})
+	mockRegistry.MockEvent(&registry.ServiceEvent{Action: remoting.EventTypeAdd, Service: provider, Version: 99})
+	time.Sleep(1e9)
+	assert.Len(t, registryDirectory.invokers(), 2)
+	assert.Equal(t, int64(3), atomic.LoadInt64(&registryDirectory.eventVersion))
 }
 
 func TestSubscribe_InvalidUrl(t *testing.T) {
@@ -77,35 +125,37 @@ func TestSubscribe_Group(t *testing.T) {
 	go registryDirectory.Subscribe(*common.NewURLWithOptions(common.WithPath("testservice")))
 
 	//for group1
-	urlmap := url.Values{}
-	urlmap.Set(constant.GROUP_KEY, "group1")
-	urlmap.Set(constant.CLUSTER_KEY, "failover") //to test merge url
 	for i := 0; i < 3; i++ {
+		// the params of every event are merged with the reference asynchronously, so they aren't shared
+		urlmap := url.Values{}
+		urlmap.Set(constant.GROUP_KEY, "group1")
+		urlmap.Set(constant.CLUSTER_KEY, "failover") //to test merge url
 		mockRegistry.(*registry.MockRegistry).MockEvent(&registry.ServiceEvent{Action: remoting.EventTypeAdd, Service: *common.NewURLWithOptions(common.WithPath("TEST"+strconv.FormatInt(int64(i), 10)), common.WithProtocol("dubbo"),
 			common.WithParams(urlmap))})
 	}
 	//for group2
-	urlmap2 := url.Values{}
-	urlmap2.Set(constant.GROUP_KEY, "group2")
-	urlmap2.Set(constant.CLUSTER_KEY, "failover") //to test merge url
 	for i := 0; i < 3; i++ {
+		// the params of every event are merged with the reference asynchronously, so they aren't shared
+		urlmap2 := url.Values{}
+		urlmap2.Set(constant.GROUP_KEY, "group2")
+		urlmap2.Set(constant.CLUSTER_KEY, "failover") //to test merge url
 		mockRegistry.(*registry.MockRegistry).MockEvent(&registry.ServiceEvent{Action: remoting.EventTypeAdd, Service: *common.NewURLWithOptions(common.WithPath("TEST"+strconv.FormatInt(int64(i), 10)), common.WithProtocol("dubbo"),
 			common.WithParams(urlmap2))})
 	}
 
 	time.Sleep(1e9)
-	assert.Len(t, registryDirectory.cacheInvokers, 2)
+	assert.Len(t, registryDirectory.invokers(), 2)
 }
 
 func Test_Destroy(t *testing.T) {
 	registryDirectory, _ := normalRegistryDir()
 
 	time.Sleep(1e9)
-	assert.Len(t, registryDirectory.cacheInvokers, 3)
+	assert.Len(t, registryDirectory.invokers(), 3)
 	assert.Equal(t, true, registryDirectory.IsAvailable())
 
 	registryDirectory.Destroy()
-	assert.Len(t, registryDirectory.cacheInvokers, 0)
+	assert.Len(t, registryDirectory.invokers(), 0)
 	assert.Equal(t, false, registryDirectory.IsAvailable())
 }
 
@@ -118,6 +168,80 @@ func Test_List(t *testing.T) {
 
 }
 
+func Test_ListTag(t *testing.T) {
+	registryDirectory, mockRegistry := normalRegistryDir()
+	mockRegistry.MockEvent(&registry.ServiceEvent{Action: remoting.EventTypeAdd, Service: *common.NewURLWithOptions(common.WithPath("SHADOW"),
+		common.WithProtocol("dubbo"), common.WithParams(url.Values{}), common.WithParamsValue(constant.TAG_KEY, "shadow"))})
+
+	time.Sleep(1e9)
+	assert.Len(t, registryDirectory.invokers(), 4)
+	for _, ivk := range registryDirectory.List(&invocation.RPCInvocation{}) {
+		assert.Equal(t, "", ivk.GetUrl().GetParam(constant.TAG_KEY, ""))
+	}
+	invokers := registryDirectory.List(invocation.NewRPCInvocationWithOptions(invocation.WithAttachments(map[string]string{constant.TAG_KEY: "shadow"})))
+	assert.Len(t, invokers, 1)
+	assert.Equal(t, "shadow", invokers[0].GetUrl().GetParam(constant.TAG_KEY, ""))
+}
+
+func Test_RouterChain(t *testing.T) {
+	tagged, _ := common.NewURL(context.TODO(), "dubbo://127.0.0.1:20001?dubbo.tag=shadow")
+	untagged, _ := common.NewURL(context.TODO(), "dubbo://127.0.0.1:20002")
+	invokers := []protocol.Invoker{protocol.NewBaseInvoker(tagged), protocol.NewBaseInvoker(untagged)}
+
+	// the tag router isolates the tagged providers by default
+	url, _ := common.NewURL(context.TODO(), "dubbo://127.0.0.1:20000")
+	assert.Len(t, newRouterChain(&url).Route(invokers, url, &invocation.RPCInvocation{}), 1)
+
+	// the routers of the reference
+	url, _ = common.NewURL(context.TODO(), "dubbo://127.0.0.1:20000?router=health_check")
+	assert.Len(t, newRouterChain(&url).Route(invokers, url, &invocation.RPCInvocation{}), 2)
+
+	// the unknown routers are skipped
+	url, _ = common.NewURL(context.TODO(), "dubbo://127.0.0.1:20000?router=unknown,tag")
+	assert.Len(t, newRouterChain(&url).Route(invokers, url, &invocation.RPCInvocation{}), 1)
+}
+
+func Test_MultiRegistrySharedInvokers(t *testing.T) {
+	extension.SetProtocol(protocolwrapper.FILTER, protocolwrapper.NewMockProtocolFilter)
+
+	suburl, _ := common.NewURL(context.TODO(), "dubbo://127.0.0.1:20000")
+	var registryDirectories []*registryDirectory
+	for _, address := range []string{"mock://127.0.0.1:1111", "mock://127.0.0.2:1111"} {
+		regurl, _ := common.NewURL(context.TODO(), address)
+		// the reference url is shared by all the registries of the reference
+		regurl.SubURL = &suburl
+		mockRegistry, _ := registry.NewMockRegistry(&common.URL{})
+		registryDirectory, _ := NewRegistryDirectory(&regurl, mockRegistry)
+		registryDirectories = append(registryDirectories, registryDirectory)
+
+		go registryDirectory.Subscribe(*common.NewURLWithOptions(common.WithPath("testservice")))
+		// the providers SHARED1 and SHARED2 are surfaced by both the registries
+		for i := len(registryDirectories) - 1; i < len(registryDirectories)+2; i++ {
+			mockRegistry.(*registry.MockRegistry).MockEvent(&registry.ServiceEvent{Action: remoting.EventTypeAdd, Service: *common.NewURLWithOptions(common.WithPath("SHARED"+strconv.FormatInt(int64(i), 10)), common.WithProtocol("dubbo"))})
+		}
+	}
+
+	time.Sleep(1e9)
+	uniqueInvokers := map[protocol.Invoker]struct{}{}
+	for _, registryDirectory := range registryDirectories {
+		assert.Len(t, registryDirectory.invokers(), 3)
+		for _, invoker := range registryDirectory.invokers() {
+			uniqueInvokers[invoker] = struct{}{}
+		}
+	}
+	assert.Len(t, uniqueInvokers, 4)
+
+	// the shared invokers are still available until all the registry directories are destroyed
+	registryDirectories[0].Destroy()
+	for _, invoker := range registryDirectories[1].invokers() {
+		assert.True(t, invoker.IsAvailable())
+	}
+	registryDirectories[1].Destroy()
+	for invoker := range uniqueInvokers {
+		assert.False(t, invoker.IsAvailable())
+	}
+}
+
 func normalRegistryDir() (*registryDirectory, *registry.MockRegistry) {
 	extension.SetProtocol(protocolwrapper.FILTER, protocolwrapper.NewMockProtocolFilter)
 
@@ -133,3 +257,220 @@ func normalRegistryDir() (*registryDirectory, *registry.MockRegistry) {
 	}
 	return registryDirectory, mockRegistry.(*registry.MockRegistry)
 }
+
+func orderedRegistryDir(ordered bool) *registryDirectory {
+	extension.SetProtocol(protocolwrapper.FILTER, protocolwrapper.NewMockProtocolFilter)
+
+	url, _ := common.NewURL(context.TODO(), "mock://127.0.0.1:1111?registry.ordered.notify="+strconv.FormatBool(ordered))
+	suburl, _ := common.NewURL(context.TODO(), "dubbo://127.0.0.1:20000")
+	url.SubURL = &suburl
+	mockRegistry, _ := registry.NewMockRegistry(&common.URL{})
+	registryDirectory, _ := NewRegistryDirectory(&url, mockRegistry)
+	return registryDirectory
+}
+
+func Test_ConfiguratorDisable(t *testing.T) {
+	extension.SetProtocol(protocolwrapper.FILTER, protocolwrapper.NewMockProtocolFilter)
+
+	url, _ := common.NewURL(context.TODO(), "mock://127.0.0.1:1111")
+	suburl, _ := common.NewURL(context.TODO(), "dubbo://127.0.0.1:20000/com.ikurento.user.UserProvider")
+	url.SubURL = &suburl
+	mockRegistry, _ := registry.NewMockRegistry(&common.URL{})
+	registryDirectory, _ := NewRegistryDirectory(&url, mockRegistry)
+
+	go registryDirectory.Subscribe(*common.NewURLWithOptions(common.WithPath("testservice")))
+	for i := 0; i < 3; i++ {
+		mockRegistry.(*registry.MockRegistry).MockEvent(&registry.ServiceEvent{Action: remoting.EventTypeAdd, Service: *common.NewURLWithOptions(common.WithPath("com.ikurento.user.UserProvider"), common.WithProtocol("dubbo"), common.WithIp("192.168.1."+strconv.Itoa(i)), common.WithPort("20000"))})
+	}
+	time.Sleep(1e9)
+	assert.Len(t, registryDirectory.List(&invocation.RPCInvocation{}), 3)
+
+	registryDirectory.Process(&remoting.ConfigChangeEvent{Key: "com.ikurento.user.UserProvider.configurators", ConfigType: remoting.EventTypeAdd, Value: `
+scope: service
+key: com.ikurento.user.UserProvider
+configs:
+  - addresses: [192.168.1.1:20000]
+    side: consumer
+    parameters:
+      disabled: true
+  - addresses: [192.168.1.2]
+    parameters:
+      weight: 200
+`})
+	invokers := registryDirectory.List(&invocation.RPCInvocation{})
+	assert.Len(t, invokers, 2)
+	for _, invoker := range invokers {
+		assert.NotEqual(t, "192.168.1.1", invoker.GetUrl().Ip)
+		if invoker.GetUrl().Ip == "192.168.1.2" {
+			assert.Equal(t, int64(200), invoker.GetUrl().GetParamInt(constant.WEIGHT_KEY, 0))
+		}
+	}
+
+	// the provider is enabled again after the rule is deleted
+	registryDirectory.Process(&remoting.ConfigChangeEvent{Key: "com.ikurento.user.UserProvider.configurators", ConfigType: remoting.EventTypeDel})
+	assert.Len(t, registryDirectory.List(&invocation.RPCInvocation{}), 3)
+}
+
+func subsetRegistryDir(order []int) *registryDirectory {
+	extension.SetProtocol(protocolwrapper.FILTER, protocolwrapper.NewMockProtocolFilter)
+
+	regurl, _ := common.NewURL(context.TODO(), "mock://127.0.0.1:1111")
+	suburl, _ := common.NewURL(context.TODO(), "dubbo://127.0.0.1:20000/com.ikurento.user.UserProvider?subset.size=3&subset.id=consumer-a")
+	regurl.SubURL = &suburl
+	mockRegistry, _ := registry.NewMockRegistry(&common.URL{})
+	registryDirectory, _ := NewRegistryDirectory(&regurl, mockRegistry)
+
+	go registryDirectory.Subscribe(*common.NewURLWithOptions(common.WithPath("testservice")))
+	for _, i := range order {
+		mockRegistry.(*registry.MockRegistry).MockEvent(&registry.ServiceEvent{Action: remoting.EventTypeAdd, Service: *common.NewURLWithOptions(common.WithPath("com.ikurento.user.UserProvider"), common.WithProtocol("dubbo"), common.WithParams(url.Values{}), common.WithIp("192.168.1."+strconv.Itoa(i)), common.WithPort("20000"))})
+	}
+	time.Sleep(1e9)
+	return registryDirectory
+}
+
+func subsetAddresses(invokers []protocol.Invoker) map[string]struct{} {
+	addresses := map[string]struct{}{}
+	for _, invoker := range invokers {
+		addresses[invoker.GetUrl().Location] = struct{}{}
+	}
+	return addresses
+}
+
+func Test_Subset(t *testing.T) {
+	registryDirectory := subsetRegistryDir([]int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9})
+	subset := subsetAddresses(registryDirectory.List(&invocation.RPCInvocation{}))
+	assert.Len(t, subset, 3)
+
+	// the same consumer gets the same subset after restart, whatever the order of the providers is
+	restarted := subsetRegistryDir([]int{9, 8, 7, 6, 5, 4, 3, 2, 1, 0})
+	assert.Equal(t, subset, subsetAddresses(restarted.List(&invocation.RPCInvocation{})))
+}
+
+func Test_SelectSubset(t *testing.T) {
+	invokers := []protocol.Invoker{}
+	for i := 0; i < 10; i++ {
+		url := common.NewURLWithOptions(common.WithProtocol("dubbo"), common.WithIp("192.168.1."+strconv.Itoa(i)), common.WithPort("20000"))
+		invokers = append(invokers, protocol.NewBaseInvoker(*url))
+	}
+	subset := subsetAddresses(selectSubset(invokers, "consumer-a", 3))
+	assert.Len(t, subset, 3)
+	assert.Equal(t, subset, subsetAddresses(selectSubset(invokers, "consumer-a", 3)))
+
+	// the subset only changes by the removed provider
+	var removed string
+	for address := range subset {
+		removed = address
+		break
+	}
+	remains := []protocol.Invoker{}
+	for _, invoker := range invokers {
+		if invoker.GetUrl().Location != removed {
+			remains = append(remains, invoker)
+		}
+	}
+	newSubset := subsetAddresses(selectSubset(remains, "consumer-a", 3))
+	assert.Len(t, newSubset, 3)
+	for address := range subset {
+		if address != removed {
+			assert.Contains(t, newSubset, address)
+		}
+	}
+
+	// the consumers spread over the providers
+	used := map[string]struct{}{}
+	for i := 0; i < 20; i++ {
+		for address := range subsetAddresses(selectSubset(invokers, "consumer-"+strconv.Itoa(i), 3)) {
+			used[address] = struct{}{}
+		}
+	}
+	assert.True(t, len(used) > 3)
+}
+
+func cachedRegistryDir(file string) (*registryDirectory, *registry.MockRegistry) {
+	extension.SetProtocol(protocolwrapper.FILTER, protocolwrapper.NewMockProtocolFilter)
+
+	url, _ := common.NewURL(context.TODO(), "mock://127.0.0.1:1111?registry.cache.file="+file+"&registry.cache.expire=500")
+	suburl, _ := common.NewURL(context.TODO(), "dubbo://127.0.0.1:20000/com.ikurento.user.UserProvider")
+	url.SubURL = &suburl
+	mockRegistry, _ := registry.NewMockRegistry(&common.URL{})
+	registryDirectory, _ := NewRegistryDirectory(&url, mockRegistry)
+	go registryDirectory.Subscribe(*common.NewURLWithOptions(common.WithPath("testservice")))
+	return registryDirectory, mockRegistry.(*registry.MockRegistry)
+}
+
+func cachedProviderEvent(i int, weight string) *registry.ServiceEvent {
+	return &registry.ServiceEvent{Action: remoting.EventTypeAdd, Service: *common.NewURLWithOptions(
+		common.WithPath("com.ikurento.user.UserProvider"), common.WithProtocol("dubbo"),
+		common.WithIp("192.168.1."+strconv.Itoa(i)), common.WithPort("20000"), common.WithParams(url.Values{}),
+		common.WithParamsValue(constant.WEIGHT_KEY, weight))}
+}
+
+// restart clears the metadata caches in memory, so they're loaded from the files again
+func restartMetadataCaches() {
+	metadataCaches = &metadataCacheMap{caches: make(map[string]*metadataCache)}
+}
+
+func Test_MetadataCache(t *testing.T) {
+	dir, err := ioutil.TempDir("", "registry-cache")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "dubbo-registry.cache")
+
+	registryDirectory, mockRegistry := cachedRegistryDir(file)
+	assert.Len(t, registryDirectory.List(&invocation.RPCInvocation{}), 0)
+	for i := 0; i < 3; i++ {
+		mockRegistry.MockEvent(cachedProviderEvent(i, "100"))
+	}
+	time.Sleep(1e9)
+	assert.Len(t, registryDirectory.List(&invocation.RPCInvocation{}), 3)
+	registryDirectory.Destroy()
+
+	// the second startup refers the cached providers before the registry pushes them
+	restartMetadataCaches()
+	registryDirectory, mockRegistry = cachedRegistryDir(file)
+	assert.Len(t, registryDirectory.List(&invocation.RPCInvocation{}), 3)
+
+	// the changed metadata is picked up, and the providers not confirmed by the registry expire
+	mockRegistry.MockEvent(cachedProviderEvent(0, "200"))
+	mockRegistry.MockEvent(cachedProviderEvent(1, "100"))
+	time.Sleep(1e9)
+	invokers := registryDirectory.List(&invocation.RPCInvocation{})
+	assert.Len(t, invokers, 2)
+	for _, invoker := range invokers {
+		assert.NotEqual(t, "192.168.1.2", invoker.GetUrl().Ip)
+		if invoker.GetUrl().Ip == "192.168.1.0" {
+			assert.Equal(t, int64(200), invoker.GetUrl().GetParamInt(constant.WEIGHT_KEY, 0))
+		}
+	}
+	registryDirectory.Destroy()
+
+	restartMetadataCaches()
+	registryDirectory, _ = cachedRegistryDir(file)
+	invokers = registryDirectory.List(&invocation.RPCInvocation{})
+	assert.Len(t, invokers, 2)
+	for _, invoker := range invokers {
+		if invoker.GetUrl().Ip == "192.168.1.0" {
+			assert.Equal(t, int64(200), invoker.GetUrl().GetParamInt(constant.WEIGHT_KEY, 0))
+		}
+	}
+	registryDirectory.Destroy()
+}
+
+func Test_MetadataCacheSaveDelayed(t *testing.T) {
+	dir, err := ioutil.TempDir("", "registry-cache")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "dubbo-registry.cache")
+
+	// the changes aren't saved on the path of the registry events, but together after the delay
+	cache := (&metadataCacheMap{caches: make(map[string]*metadataCache)}).get(file)
+	for i := 0; i < 3; i++ {
+		cache.put("com.ikurento.user.UserProvider", cachedProviderEvent(i, "100").Service)
+	}
+	_, err = os.Stat(file)
+	assert.True(t, os.IsNotExist(err))
+
+	time.Sleep(2 * metadataCacheSaveDelay)
+	restarted := (&metadataCacheMap{caches: make(map[string]*metadataCache)}).get(file)
+	assert.Len(t, restarted.providers("com.ikurento.user.UserProvider"), 3)
+}
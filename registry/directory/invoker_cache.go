@@ -0,0 +1,98 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package directory
+
+import (
+	"sync"
+)
+
+import (
+	"github.com/apache/dubbo-go/cluster/router"
+	"github.com/apache/dubbo-go/common"
+	"github.com/apache/dubbo-go/common/logger"
+	"github.com/apache/dubbo-go/protocol"
+)
+
+// the same provider may be surfaced by every registry of a multi-registry reference,
+// all the registry directories of the reference share one invoker(and its connection) of the provider.
+var sharedInvokers = &invokerCache{invokers: make(map[sharedInvokerKey]*sharedInvoker)}
+
+type sharedInvokerKey struct {
+	referenceUrl *common.URL
+	// key of the provider, includes its address, interface, group and version
+	providerKey string
+}
+
+type sharedInvoker struct {
+	invoker protocol.Invoker
+	refs    int
+}
+
+type invokerCache struct {
+	lock     sync.Mutex
+	invokers map[sharedInvokerKey]*sharedInvoker
+}
+
+// acquire returns the shared invoker of the provider @url, the invoker is referred by @refer if not existing.
+func (c *invokerCache) acquire(referenceUrl *common.URL, url common.URL, refer func(common.URL) protocol.Invoker) protocol.Invoker {
+	key := sharedInvokerKey{referenceUrl: referenceUrl, providerKey: url.Key()}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if shared, ok := c.invokers[key]; ok {
+		shared.refs++
+		logger.Debugf("service is shared by registries: invokers key is  %s, refs %d!", key.providerKey, shared.refs)
+		return shared.invoker
+	}
+
+	invoker := refer(url)
+	if invoker == nil {
+		return nil
+	}
+	c.invokers[key] = &sharedInvoker{invoker: invoker, refs: 1}
+	return invoker
+}
+
+// release decreases the refs of the shared invoker, it's destroyed when no registry directory refers it.
+func (c *invokerCache) release(referenceUrl *common.URL, url common.URL) {
+	key := sharedInvokerKey{referenceUrl: referenceUrl, providerKey: url.Key()}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	shared, ok := c.invokers[key]
+	if !ok {
+		return
+	}
+	shared.refs--
+	if shared.refs <= 0 {
+		delete(c.invokers, key)
+		shared.invoker.Destroy()
+		c.removeHealth(url)
+	}
+}
+
+// removeHealth removes the health of the provider @url if no reference refers it any more
+func (c *invokerCache) removeHealth(url common.URL) {
+	providerKey := url.Key()
+	for key := range c.invokers {
+		if key.providerKey == providerKey {
+			return
+		}
+	}
+	router.RemoveHealth(url)
+}
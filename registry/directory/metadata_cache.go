@@ -0,0 +1,240 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package directory
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+import (
+	perrors "github.com/pkg/errors"
+)
+
+import (
+	"github.com/apache/dubbo-go/common"
+	"github.com/apache/dubbo-go/common/constant"
+	"github.com/apache/dubbo-go/common/logger"
+	"github.com/apache/dubbo-go/protocol"
+	"github.com/apache/dubbo-go/registry"
+	"github.com/apache/dubbo-go/remoting"
+)
+
+// the metadata caches of the files, shared by the registry directories
+var metadataCaches = &metadataCacheMap{caches: make(map[string]*metadataCache)}
+
+type metadataCacheMap struct {
+	lock   sync.Mutex
+	caches map[string]*metadataCache
+}
+
+// the changes of the metadata caches are saved together after the delay, off the path of the registry events
+var metadataCacheSaveDelay = 200 * time.Millisecond
+
+// metadataCache keeps the provider urls of the services fetched from the registry in a local file,
+// so a restarted consumer doesn't wait for the registry to refer the providers.
+type metadataCache struct {
+	lock     sync.Mutex
+	file     string
+	services map[string]map[string]string // service key -> provider key -> provider url
+	// the changes are pending to be saved
+	dirty bool
+
+	// serializes the writes of the file
+	saveLock sync.Mutex
+}
+
+// get returns the metadata cache of the @file, which is loaded at the first time
+func (m *metadataCacheMap) get(file string) *metadataCache {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if cache, ok := m.caches[file]; ok {
+		return cache
+	}
+
+	cache := &metadataCache{file: file, services: make(map[string]map[string]string)}
+	if err := cache.load(); err != nil {
+		logger.Warnf("load the registry cache file %v, error: %v", file, err)
+	}
+	m.caches[file] = cache
+	return cache
+}
+
+func (c *metadataCache) load() error {
+	content, err := ioutil.ReadFile(c.file)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return perrors.WithStack(err)
+	}
+	return perrors.WithStack(json.Unmarshal(content, &c.services))
+}
+
+// changed schedules the save of the changes, it's called with the lock held
+func (c *metadataCache) changed() {
+	if c.dirty {
+		return
+	}
+	c.dirty = true
+	time.AfterFunc(metadataCacheSaveDelay, c.flush)
+}
+
+// flush saves the pending changes
+func (c *metadataCache) flush() {
+	c.saveLock.Lock()
+	defer c.saveLock.Unlock()
+
+	c.lock.Lock()
+	if !c.dirty {
+		c.lock.Unlock()
+		return
+	}
+	c.dirty = false
+	content, err := json.Marshal(c.services)
+	c.lock.Unlock()
+
+	if err == nil {
+		err = c.save(content)
+	}
+	if err != nil {
+		logger.Warnf("save the registry cache file %v, error: %v", c.file, err)
+	}
+}
+
+// save writes to a temporary file and renames it, so the file is never half written
+func (c *metadataCache) save(content []byte) error {
+	if err := os.MkdirAll(filepath.Dir(c.file), 0755); err != nil {
+		return perrors.WithStack(err)
+	}
+	tmp := c.file + ".tmp"
+	if err := ioutil.WriteFile(tmp, content, 0644); err != nil {
+		return perrors.WithStack(err)
+	}
+	return perrors.WithStack(os.Rename(tmp, c.file))
+}
+
+func (c *metadataCache) providers(service string) []string {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	urls := make([]string, 0, len(c.services[service]))
+	for _, url := range c.services[service] {
+		urls = append(urls, url)
+	}
+	return urls
+}
+
+func (c *metadataCache) put(service string, url common.URL) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	providers, ok := c.services[service]
+	if !ok {
+		providers = make(map[string]string)
+		c.services[service] = providers
+	}
+	if providers[url.Key()] == url.String() {
+		return
+	}
+	providers[url.Key()] = url.String()
+	c.changed()
+}
+
+func (c *metadataCache) remove(service string, url common.URL) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if _, ok := c.services[service][url.Key()]; !ok {
+		return
+	}
+	delete(c.services[service], url.Key())
+	c.changed()
+}
+
+// loadCachedProviders refers the cached providers of the service at once, they're replaced when the registry
+// pushes them with the changed metadata, and removed if the registry doesn't push them in registry.cache.expire.
+func (dir *registryDirectory) loadCachedProviders() {
+	file := dir.GetUrl().GetParam(constant.REGISTRY_CACHE_FILE_KEY, "")
+	if file == "" {
+		return
+	}
+	dir.metadataCache = metadataCaches.get(file)
+
+	for _, provider := range dir.metadataCache.providers(dir.GetUrl().SubURL.ServiceKey()) {
+		url, err := common.NewURL(context.Background(), provider)
+		if err != nil {
+			logger.Warnf("illegal provider url %v in the registry cache file %v: %v", provider, file, err)
+			continue
+		}
+		dir.cacheInvoker(url)
+		dir.cachedProviders.Store(url.Key(), url)
+	}
+	dir.refreshCacheInvokers()
+
+	expire := time.Duration(dir.GetUrl().GetParamInt(constant.REGISTRY_CACHE_EXPIRE_KEY, constant.DEFAULT_REGISTRY_CACHE_EXPIRE)) * time.Millisecond
+	time.AfterFunc(expire, dir.expireCachedProviders)
+}
+
+// saveProvider updates the provider of the registry event in the cache file
+func (dir *registryDirectory) saveProvider(res *registry.ServiceEvent) {
+	if dir.metadataCache == nil {
+		return
+	}
+	switch res.Action {
+	case remoting.EventTypeAdd:
+		dir.metadataCache.put(dir.GetUrl().SubURL.ServiceKey(), res.Service)
+	case remoting.EventTypeDel:
+		dir.metadataCache.remove(dir.GetUrl().SubURL.ServiceKey(), res.Service)
+	}
+}
+
+// confirmCachedProvider is called when the registry pushes the provider, the cached invoker is removed
+// if the metadata of the provider is changed, so the provider is referred again.
+func (dir *registryDirectory) confirmCachedProvider(url common.URL) {
+	if _, ok := dir.cachedProviders.Load(url.Key()); !ok {
+		return
+	}
+	dir.cachedProviders.Delete(url.Key())
+	if value, ok := dir.cacheInvokersMap.Load(url.Key()); ok && value.(protocol.Invoker).GetUrl().String() != url.String() {
+		logger.Infof("the metadata of the cached provider %v is changed", url.Key())
+		dir.uncacheInvoker(url)
+	}
+}
+
+func (dir *registryDirectory) expireCachedProviders() {
+	// destroyed
+	if !dir.BaseDirectory.IsAvailable() {
+		return
+	}
+	expired := false
+	dir.cachedProviders.Range(func(key, value interface{}) bool {
+		url := value.(common.URL)
+		logger.Infof("the cached provider %v isn't confirmed by the registry, remove it", key)
+		dir.cachedProviders.Delete(key)
+		dir.uncacheInvoker(url)
+		dir.metadataCache.remove(dir.GetUrl().SubURL.ServiceKey(), url)
+		expired = true
+		return true
+	})
+	if expired {
+		dir.refreshCacheInvokers()
+	}
+}
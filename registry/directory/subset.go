@@ -0,0 +1,76 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package directory
+
+import (
+	"hash/fnv"
+	"sort"
+)
+
+import (
+	"github.com/apache/dubbo-go/common/constant"
+	"github.com/apache/dubbo-go/common/utils"
+	"github.com/apache/dubbo-go/protocol"
+)
+
+// subset returns the providers which the consumer talks to if subset.size is configured in the reference url.
+// The consumer is identified by subset.id, or its local ip by default.
+func (dir *registryDirectory) subset(invokers []protocol.Invoker) []protocol.Invoker {
+	referenceUrl := dir.GetUrl().SubURL
+	size := int(referenceUrl.GetParamInt(constant.SUBSET_SIZE_KEY, 0))
+	if size <= 0 || size >= len(invokers) {
+		return invokers
+	}
+	id := referenceUrl.GetParam(constant.SUBSET_ID_KEY, "")
+	if id == "" {
+		id, _ = utils.GetLocalIP()
+	}
+	return selectSubset(invokers, id, size)
+}
+
+// selectSubset chooses @size providers for the consumer @id by rendezvous hashing of the provider addresses,
+// so the subset of a consumer is stable across restarts given the same providers, the subsets of
+// the consumers are shuffled across the providers, and only the consumers of a removed provider change subsets.
+func selectSubset(invokers []protocol.Invoker, id string, size int) []protocol.Invoker {
+	type scoredInvoker struct {
+		invoker protocol.Invoker
+		address string
+		score   uint64
+	}
+	scored := make([]scoredInvoker, 0, len(invokers))
+	for _, invoker := range invokers {
+		address := invoker.GetUrl().Location
+		h := fnv.New64a()
+		h.Write([]byte(id))
+		h.Write([]byte{'/'})
+		h.Write([]byte(address))
+		scored = append(scored, scoredInvoker{invoker: invoker, address: address, score: h.Sum64()})
+	}
+	sort.Slice(scored, func(i, j int) bool {
+		if scored[i].score != scored[j].score {
+			return scored[i].score > scored[j].score
+		}
+		return scored[i].address < scored[j].address
+	})
+
+	subset := make([]protocol.Invoker, 0, size)
+	for _, s := range scored[:size] {
+		subset = append(subset, s.invoker)
+	}
+	return subset
+}
@@ -1,7 +1,6 @@
 package etcdv3
 
 import (
-	"context"
 	"strings"
 )
 
@@ -11,6 +10,8 @@ import (
 
 import (
 	"github.com/apache/dubbo-go/common"
+	"github.com/apache/dubbo-go/common/constant"
+	"github.com/apache/dubbo-go/common/extension"
 	"github.com/apache/dubbo-go/common/logger"
 	"github.com/apache/dubbo-go/registry"
 	"github.com/apache/dubbo-go/remoting"
@@ -19,10 +20,15 @@ import (
 type dataListener struct {
 	interestedURL []*common.URL
 	listener      remoting.ConfigurationListener
+	serializer    registry.DataSerializer
 }
 
 func NewRegistryDataListener(listener remoting.ConfigurationListener) *dataListener {
-	return &dataListener{listener: listener, interestedURL: []*common.URL{}}
+	return &dataListener{
+		listener:      listener,
+		interestedURL: []*common.URL{},
+		serializer:    extension.GetRegistryDataSerializer(constant.DEFAULT_KEY),
+	}
 }
 
 func (l *dataListener) AddInterestedURL(url *common.URL) {
@@ -32,9 +38,9 @@ func (l *dataListener) AddInterestedURL(url *common.URL) {
 func (l *dataListener) DataChange(eventType remoting.Event) bool {
 
 	url := eventType.Path[strings.Index(eventType.Path, "/providers/")+len("/providers/"):]
-	serviceURL, err := common.NewURL(context.Background(), url)
+	serviceURL, err := l.serializer.Decode(url)
 	if err != nil {
-		logger.Warnf("Listen NewURL(r{%s}) = error{%v}", eventType.Path, err)
+		logger.Warnf("Listen Decode(r{%s}) = error{%v}", eventType.Path, err)
 		return false
 	}
 
@@ -22,6 +22,7 @@ import (
 	"github.com/apache/dubbo-go/common/logger"
 	"github.com/apache/dubbo-go/common/utils"
 	"github.com/apache/dubbo-go/registry"
+	_ "github.com/apache/dubbo-go/registry/serializer"
 	"github.com/apache/dubbo-go/remoting/etcdv3"
 )
 
@@ -53,6 +54,8 @@ type etcdV3Registry struct {
 
 	wg   sync.WaitGroup // wg+done for etcd client restart
 	done chan struct{}
+
+	serializer registry.DataSerializer
 }
 
 func (r *etcdV3Registry) Client() *etcdv3.Client {
@@ -108,6 +111,7 @@ func newETCDV3Registry(url *common.URL) (registry.Registry, error) {
 		done:     make(chan struct{}),
 		services: make(map[string]common.URL),
 	}
+	r.serializer = extension.GetRegistryDataSerializer(url.GetParam(constant.REGISTRY_DATA_SERIALIZER_KEY, constant.DEFAULT_KEY))
 
 	if err := etcdv3.ValidateClient(
 		r,
@@ -124,6 +128,7 @@ func newETCDV3Registry(url *common.URL) (registry.Registry, error) {
 	r.listener = etcdv3.NewEventListener(r.client)
 	r.configListener = NewConfigurationListener(r)
 	r.dataListener = NewRegistryDataListener(r.configListener)
+	r.dataListener.serializer = r.serializer
 
 	return r, nil
 }
@@ -230,7 +235,11 @@ func (r *etcdV3Registry) registerConsumer(svc common.URL) error {
 	params.Add("category", (common.RoleType(common.CONSUMER)).String())
 	params.Add("dubbo", "dubbogo-consumer-"+constant.Version)
 
-	encodedURL := url.QueryEscape(fmt.Sprintf("consumer://%s%s?%s", localIP, svc.Path, params.Encode()))
+	rawURL := fmt.Sprintf("consumer://%s%s?%s", localIP, svc.Path, params.Encode())
+	encodedURL, err := r.serializer.Encode(rawURL)
+	if err != nil {
+		return perrors.WithMessagef(err, "encode the url %s", rawURL)
+	}
 	dubboPath := fmt.Sprintf("/dubbo/%s/%s", svc.Service(), (common.RoleType(common.CONSUMER)).String())
 	if err := r.client.Create(path.Join(dubboPath, encodedURL), ""); err != nil {
 		return perrors.WithMessagef(err, "create k/v in etcd (path:%s, url:%s)", dubboPath, encodedURL)
@@ -249,6 +258,7 @@ func (r *etcdV3Registry) registerProvider(svc common.URL) error {
 		urlPath    string
 		encodedURL string
 		dubboPath  string
+		err        error
 	)
 
 	providersNode := fmt.Sprintf("/dubbo/%s/%s", svc.Service(), common.DubboNodes[common.PROVIDER])
@@ -282,7 +292,11 @@ func (r *etcdV3Registry) registerProvider(svc common.URL) error {
 
 	urlPath = svc.Path
 
-	encodedURL = url.QueryEscape(fmt.Sprintf("%s://%s%s?%s", svc.Protocol, host, urlPath, params.Encode()))
+	rawURL := fmt.Sprintf("%s://%s%s?%s", svc.Protocol, host, urlPath, params.Encode())
+	encodedURL, err = r.serializer.Encode(rawURL)
+	if err != nil {
+		return perrors.WithMessagef(err, "encode the url %s", rawURL)
+	}
 	dubboPath = fmt.Sprintf("/dubbo/%s/%s", svc.Service(), (common.RoleType(common.PROVIDER)).String())
 
 	if err := r.client.Create(path.Join(dubboPath, encodedURL), ""); err != nil {
@@ -39,8 +39,11 @@ func init() {
 type ServiceEvent struct {
 	Action  remoting.EventType
 	Service common.URL
+	// the order of the event, the greater is the newer. It's assigned by the directory on receipt
+	// if the registry doesn't order the events.
+	Version int64
 }
 
 func (e ServiceEvent) String() string {
-	return fmt.Sprintf("ServiceEvent{Action{%s}, Path{%s}}", e.Action, e.Service)
+	return fmt.Sprintf("ServiceEvent{Action{%s}, Path{%s}, Version{%d}}", e.Action, e.Service, e.Version)
 }
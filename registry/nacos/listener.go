@@ -30,6 +30,7 @@ type nacosListener struct {
 	instanceMap    map[string]model.Instance
 	cacheLock      sync.Mutex
 	done           chan struct{}
+	closeOnce      sync.Once
 	subscribeParam *vo.SubscribeParam
 }
 
@@ -194,6 +195,8 @@ func (nl *nacosListener) Next() (*registry.ServiceEvent, error) {
 }
 
 func (nl *nacosListener) Close() {
-	nl.stopListen()
-	close(nl.done)
+	nl.closeOnce.Do(func() {
+		nl.stopListen()
+		close(nl.done)
+	})
 }
@@ -5,6 +5,7 @@ import (
 	"net"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 )
 import (
@@ -19,6 +20,7 @@ import (
 	"github.com/apache/dubbo-go/common"
 	"github.com/apache/dubbo-go/common/constant"
 	"github.com/apache/dubbo-go/common/extension"
+	"github.com/apache/dubbo-go/common/logger"
 	"github.com/apache/dubbo-go/common/utils"
 	"github.com/apache/dubbo-go/registry"
 )
@@ -35,6 +37,14 @@ func init() {
 type nacosRegistry struct {
 	*common.URL
 	namingClient naming_client.INamingClient
+
+	cltLock   sync.Mutex
+	services  map[string]common.URL // registered urls, keyed by url.Key()
+	listeners []*nacosListener
+
+	done chan struct{}
+	once sync.Once
+	wg   sync.WaitGroup
 }
 
 func getNacosConfig(url *common.URL) (map[string]interface{}, error) {
@@ -71,6 +81,9 @@ func getNacosConfig(url *common.URL) (map[string]interface{}, error) {
 	clientConfig.CacheDir = url.GetParam(constant.NACOS_CACHE_DIR_KEY, "")
 	clientConfig.LogDir = url.GetParam(constant.NACOS_LOG_DIR_KEY, "")
 	clientConfig.Endpoint = url.GetParam(constant.NACOS_ENDPOINT, "")
+	clientConfig.NamespaceId = url.GetParam(constant.NACOS_NAMESPACE_ID, "")
+	clientConfig.Username = url.Username
+	clientConfig.Password = url.Password
 	clientConfig.NotLoadCacheAtStart = true
 	configMap["clientConfig"] = clientConfig
 
@@ -86,11 +99,19 @@ func newNacosRegistry(url *common.URL) (registry.Registry, error) {
 	if err != nil {
 		return nil, err
 	}
-	registry := nacosRegistry{
+	return newNacosRegistryWithClient(url, client), nil
+}
+
+func newNacosRegistryWithClient(url *common.URL, client naming_client.INamingClient) *nacosRegistry {
+	r := &nacosRegistry{
 		URL:          url,
 		namingClient: client,
+		services:     make(map[string]common.URL),
+		done:         make(chan struct{}),
 	}
-	return &registry, nil
+	r.wg.Add(1)
+	go r.keepRegistered()
+	return r
 }
 
 func getCategory(url common.URL) string {
@@ -146,7 +167,30 @@ func createRegisterParam(url common.URL, serviceName string) vo.RegisterInstance
 	return instance
 }
 
+func createDeregisterParam(url common.URL, serviceName string) vo.DeregisterInstanceParam {
+	instance := createRegisterParam(url, serviceName)
+	return vo.DeregisterInstanceParam{
+		Ip:          instance.Ip,
+		Port:        instance.Port,
+		ServiceName: serviceName,
+		Ephemeral:   instance.Ephemeral,
+	}
+}
+
 func (nr *nacosRegistry) Register(url common.URL) error {
+	if !nr.IsAvailable() {
+		return perrors.New("nacos registry is destroyed")
+	}
+	if err := nr.register(url); err != nil {
+		return err
+	}
+	nr.cltLock.Lock()
+	nr.services[url.Key()] = url
+	nr.cltLock.Unlock()
+	return nil
+}
+
+func (nr *nacosRegistry) register(url common.URL) error {
 	serviceName := getServiceName(url)
 	param := createRegisterParam(url, serviceName)
 	isRegistry, err := nr.namingClient.RegisterInstance(param)
@@ -159,8 +203,109 @@ func (nr *nacosRegistry) Register(url common.URL) error {
 	return nil
 }
 
+// Unregister removes the instance of url from nacos.
+func (nr *nacosRegistry) Unregister(url common.URL) error {
+	nr.cltLock.Lock()
+	delete(nr.services, url.Key())
+	nr.cltLock.Unlock()
+	return nr.unregister(url)
+}
+
+func (nr *nacosRegistry) unregister(url common.URL) error {
+	serviceName := getServiceName(url)
+	isDeregistry, err := nr.namingClient.DeregisterInstance(createDeregisterParam(url, serviceName))
+	if err != nil {
+		return err
+	}
+	if !isDeregistry {
+		return perrors.New("deregistry [" + serviceName + "] from nacos failed")
+	}
+	return nil
+}
+
 func (nr *nacosRegistry) Subscribe(conf common.URL) (registry.Listener, error) {
-	return NewNacosListener(conf, nr.namingClient)
+	if !nr.IsAvailable() {
+		return nil, perrors.New("nacos registry is destroyed")
+	}
+	listener, err := NewNacosListener(conf, nr.namingClient)
+	if err != nil {
+		return nil, err
+	}
+	nr.cltLock.Lock()
+	nr.listeners = append(nr.listeners, listener)
+	nr.cltLock.Unlock()
+	return listener, nil
+}
+
+// Unsubscribe closes all the listeners subscribed for conf.
+func (nr *nacosRegistry) Unsubscribe(conf common.URL) {
+	nr.cltLock.Lock()
+	var closed []*nacosListener
+	listeners := nr.listeners[:0]
+	for _, l := range nr.listeners {
+		if l.listenUrl.Key() == conf.Key() {
+			closed = append(closed, l)
+			continue
+		}
+		listeners = append(listeners, l)
+	}
+	nr.listeners = listeners
+	nr.cltLock.Unlock()
+
+	for _, l := range closed {
+		l.Close()
+	}
+}
+
+// keepRegistered re-registers the urls whose instances are lost, e.g. after nacos restarts.
+func (nr *nacosRegistry) keepRegistered() {
+	defer nr.wg.Done()
+
+	interval := nr.GetParamInt(constant.NACOS_CHECK_INTERVAL_KEY, constant.DEFAULT_NACOS_CHECK_INTERVAL)
+	ticker := time.NewTicker(time.Duration(interval) * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-nr.done:
+			return
+		case <-ticker.C:
+			nr.recoverRegistered()
+		}
+	}
+}
+
+func (nr *nacosRegistry) recoverRegistered() {
+	nr.cltLock.Lock()
+	services := make([]common.URL, 0, len(nr.services))
+	for _, url := range nr.services {
+		services = append(services, url)
+	}
+	nr.cltLock.Unlock()
+
+	for _, url := range services {
+		serviceName := getServiceName(url)
+		service, err := nr.namingClient.GetService(vo.GetServiceParam{ServiceName: serviceName})
+		if err != nil {
+			logger.Warnf("get service [%s] from nacos error: %v", serviceName, err)
+			continue
+		}
+		param := createRegisterParam(url, serviceName)
+		registered := false
+		for _, host := range service.Hosts {
+			if host.Ip == param.Ip && host.Port == param.Port {
+				registered = true
+				break
+			}
+		}
+		if registered {
+			continue
+		}
+		if err = nr.register(url); err != nil {
+			logger.Warnf("re-register [%s] to nacos error: %v", serviceName, err)
+			continue
+		}
+		logger.Infof("re-register [%s] to nacos", serviceName)
+	}
 }
 
 func (nr *nacosRegistry) GetUrl() common.URL {
@@ -168,9 +313,34 @@ func (nr *nacosRegistry) GetUrl() common.URL {
 }
 
 func (nr *nacosRegistry) IsAvailable() bool {
-	return true
+	select {
+	case <-nr.done:
+		return false
+	default:
+		return true
+	}
 }
 
+// Destroy closes the listeners and deregisters all the registered urls.
 func (nr *nacosRegistry) Destroy() {
-	return
+	nr.once.Do(func() {
+		close(nr.done)
+		nr.wg.Wait()
+
+		nr.cltLock.Lock()
+		listeners := nr.listeners
+		services := nr.services
+		nr.listeners = nil
+		nr.services = make(map[string]common.URL)
+		nr.cltLock.Unlock()
+
+		for _, l := range listeners {
+			l.Close()
+		}
+		for _, url := range services {
+			if err := nr.unregister(url); err != nil {
+				logger.Errorf("deregister [%s] from nacos error: %v", url.Key(), err)
+			}
+		}
+	})
 }
@@ -22,6 +22,7 @@ import (
 )
 
 import (
+	"github.com/apache/dubbo-go/cluster"
 	"github.com/apache/dubbo-go/common"
 	"github.com/apache/dubbo-go/common/constant"
 	"github.com/apache/dubbo-go/common/extension"
@@ -33,7 +34,8 @@ import (
 )
 
 var (
-	regProtocol *registryProtocol
+	regProtocol     *registryProtocol
+	regProtocolOnce sync.Once
 )
 
 type registryProtocol struct {
@@ -93,9 +95,9 @@ func (proto *registryProtocol) Refer(url common.URL) protocol.Invoker {
 	go directory.Subscribe(*serviceUrl)
 
 	//new cluster invoker
-	cluster := extension.GetCluster(serviceUrl.GetParam(constant.CLUSTER_KEY, constant.DEFAULT_CLUSTER))
+	clusterExtension := extension.GetCluster(cluster.GetClusterName(*serviceUrl))
 
-	invoker := cluster.Join(directory)
+	invoker := clusterExtension.Join(directory)
 	proto.invokers = append(proto.invokers, invoker)
 	return invoker
 }
@@ -148,6 +150,12 @@ func (proto *registryProtocol) Destroy() {
 		return true
 	})
 
+	proto.DestroyRegistries()
+}
+
+// DestroyRegistries unregisters the providers and the consumers by destroying the registries, the exporters
+// and the invokers are kept until the protocol is destroyed.
+func (proto *registryProtocol) DestroyRegistries() {
 	proto.registries.Range(func(key, value interface{}) bool {
 		reg := value.(registry.Registry)
 		if reg.IsAvailable() {
@@ -174,11 +182,13 @@ func (*registryProtocol) getProviderUrl(invoker protocol.Invoker) common.URL {
 	return *url.SubURL
 }
 
+// GetProtocol returns the registry protocol shared by the services and references, so all the registries
+// are reachable by the graceful shutdown.
 func GetProtocol() protocol.Protocol {
-	if regProtocol != nil {
-		return regProtocol
-	}
-	return newRegistryProtocol()
+	regProtocolOnce.Do(func() {
+		regProtocol = newRegistryProtocol()
+	})
+	return regProtocol
 }
 
 type wrappedInvoker struct {
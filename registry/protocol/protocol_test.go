@@ -186,3 +186,37 @@ func TestDestry(t *testing.T) {
 	})
 	assert.Equal(t, count2, 0)
 }
+
+func TestDestroyRegistries(t *testing.T) {
+	regProtocol := newRegistryProtocol()
+	referNormal(t, regProtocol)
+	exporterNormal(t, regProtocol)
+
+	var regs []registry.Registry
+	regProtocol.registries.Range(func(key, value interface{}) bool {
+		regs = append(regs, value.(registry.Registry))
+		return true
+	})
+	assert.Len(t, regs, 1)
+
+	regProtocol.DestroyRegistries()
+	assert.False(t, regs[0].IsAvailable())
+	var count int
+	regProtocol.registries.Range(func(key, value interface{}) bool {
+		count++
+		return true
+	})
+	assert.Equal(t, count, 0)
+
+	// the exporters are kept until the protocol is destroyed
+	var count2 int
+	regProtocol.bounds.Range(func(key, value interface{}) bool {
+		count2++
+		return true
+	})
+	assert.Equal(t, count2, 1)
+}
+
+func TestGetProtocol(t *testing.T) {
+	assert.True(t, GetProtocol() == GetProtocol())
+}
@@ -36,3 +36,11 @@ type Listener interface {
 	Next() (*ServiceEvent, error)
 	Close()
 }
+
+// Extension - DataSerializer
+// DataSerializer encodes the url of the service into the data of the registry node, and decodes the node data
+type DataSerializer interface {
+	// Encode the raw url string of the service registered
+	Encode(rawURL string) (string, error)
+	Decode(data string) (common.URL, error)
+}
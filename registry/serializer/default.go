@@ -0,0 +1,49 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package serializer
+
+import (
+	"context"
+	"net/url"
+)
+
+import (
+	"github.com/apache/dubbo-go/common"
+	"github.com/apache/dubbo-go/common/constant"
+	"github.com/apache/dubbo-go/common/extension"
+	"github.com/apache/dubbo-go/registry"
+)
+
+func init() {
+	extension.SetRegistryDataSerializer(constant.DEFAULT_KEY, NewDefaultDataSerializer)
+}
+
+// DefaultDataSerializer keeps the url query escaped in the registry node, which dubbo java reads too
+type DefaultDataSerializer struct{}
+
+func NewDefaultDataSerializer() registry.DataSerializer {
+	return &DefaultDataSerializer{}
+}
+
+func (s *DefaultDataSerializer) Encode(rawURL string) (string, error) {
+	return url.QueryEscape(rawURL), nil
+}
+
+func (s *DefaultDataSerializer) Decode(data string) (common.URL, error) {
+	return common.NewURL(context.Background(), data)
+}
@@ -0,0 +1,96 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package serializer
+
+import (
+	"encoding/base64"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+import (
+	"github.com/apache/dubbo-go/common"
+	"github.com/apache/dubbo-go/common/constant"
+	"github.com/apache/dubbo-go/common/extension"
+	"github.com/apache/dubbo-go/registry"
+)
+
+const rawURL = "dubbo://192.168.1.1:20000/com.ikurento.user.UserProvider?anyhost=true&category=providers" +
+	"&interface=com.ikurento.user.UserProvider&methods=GetUser%2CGetUsers&side=provider"
+
+// base64DataSerializer keeps the url base64 encoded, and reads the default format too
+type base64DataSerializer struct{}
+
+func (s *base64DataSerializer) Encode(rawURL string) (string, error) {
+	return "b64:" + base64.URLEncoding.EncodeToString([]byte(rawURL)), nil
+}
+
+func (s *base64DataSerializer) Decode(data string) (common.URL, error) {
+	if !strings.HasPrefix(data, "b64:") {
+		return NewDefaultDataSerializer().Decode(data)
+	}
+	raw, err := base64.URLEncoding.DecodeString(strings.TrimPrefix(data, "b64:"))
+	if err != nil {
+		return common.URL{}, err
+	}
+	return NewDefaultDataSerializer().Decode(url.QueryEscape(string(raw)))
+}
+
+func TestDefaultDataSerializer(t *testing.T) {
+	serializer := extension.GetRegistryDataSerializer(constant.DEFAULT_KEY)
+	data, err := serializer.Encode(rawURL)
+	assert.NoError(t, err)
+	assert.Equal(t, url.QueryEscape(rawURL), data)
+
+	u, err := serializer.Decode(data)
+	assert.NoError(t, err)
+	assert.Equal(t, "192.168.1.1", u.Ip)
+	assert.Equal(t, "20000", u.Port)
+	assert.Equal(t, "GetUser,GetUsers", u.GetParam("methods", ""))
+}
+
+func TestCustomDataSerializer(t *testing.T) {
+	extension.SetRegistryDataSerializer("base64", func() registry.DataSerializer {
+		return &base64DataSerializer{}
+	})
+	serializer := extension.GetRegistryDataSerializer("base64")
+	defaultSerializer := extension.GetRegistryDataSerializer(constant.DEFAULT_KEY)
+
+	data, err := serializer.Encode(rawURL)
+	assert.NoError(t, err)
+	assert.False(t, strings.Contains(data, "/"))
+	u, err := serializer.Decode(data)
+	assert.NoError(t, err)
+
+	// the same url as the default reader decodes from the default format
+	defaultData, _ := defaultSerializer.Encode(rawURL)
+	expected, err := defaultSerializer.Decode(defaultData)
+	assert.NoError(t, err)
+	assert.True(t, u.URLEqual(expected))
+	assert.Equal(t, expected.Params, u.Params)
+
+	// the data of the default format is still readable
+	u, err = serializer.Decode(defaultData)
+	assert.NoError(t, err)
+	assert.Equal(t, expected.Params, u.Params)
+}
@@ -18,7 +18,6 @@
 package zookeeper
 
 import (
-	"context"
 	"strings"
 )
 import (
@@ -26,6 +25,8 @@ import (
 )
 import (
 	"github.com/apache/dubbo-go/common"
+	"github.com/apache/dubbo-go/common/constant"
+	"github.com/apache/dubbo-go/common/extension"
 	"github.com/apache/dubbo-go/common/logger"
 	"github.com/apache/dubbo-go/registry"
 	"github.com/apache/dubbo-go/remoting"
@@ -35,10 +36,15 @@ import (
 type RegistryDataListener struct {
 	interestedURL []*common.URL
 	listener      remoting.ConfigurationListener
+	serializer    registry.DataSerializer
 }
 
 func NewRegistryDataListener(listener remoting.ConfigurationListener) *RegistryDataListener {
-	return &RegistryDataListener{listener: listener, interestedURL: []*common.URL{}}
+	return &RegistryDataListener{
+		listener:      listener,
+		interestedURL: []*common.URL{},
+		serializer:    extension.GetRegistryDataSerializer(constant.DEFAULT_KEY),
+	}
 }
 func (l *RegistryDataListener) AddInterestedURL(url *common.URL) {
 	l.interestedURL = append(l.interestedURL, url)
@@ -52,9 +58,9 @@ func (l *RegistryDataListener) DataChange(eventType remoting.Event) bool {
 		return false
 	}
 	url := eventType.Path[index+len("/providers/"):]
-	serviceURL, err := common.NewURL(context.TODO(), url)
+	serviceURL, err := l.serializer.Decode(url)
 	if err != nil {
-		logger.Errorf("Listen NewURL(r{%s}) = error{%v} eventType.Path={%v}", url, err, eventType.Path)
+		logger.Errorf("Listen Decode(r{%s}) = error{%v} eventType.Path={%v}", url, err, eventType.Path)
 		return false
 	}
 	for _, v := range l.interestedURL {
@@ -40,6 +40,7 @@ import (
 	"github.com/apache/dubbo-go/common/logger"
 	"github.com/apache/dubbo-go/common/utils"
 	"github.com/apache/dubbo-go/registry"
+	_ "github.com/apache/dubbo-go/registry/serializer"
 	"github.com/apache/dubbo-go/remoting/zookeeper"
 )
 
@@ -81,6 +82,7 @@ type zkRegistry struct {
 	//for provider
 	zkPath map[string]int // key = protocol://ip:port/interface
 
+	serializer registry.DataSerializer
 }
 
 func newZkRegistry(url *common.URL) (registry.Registry, error) {
@@ -96,6 +98,7 @@ func newZkRegistry(url *common.URL) (registry.Registry, error) {
 		services: make(map[string]common.URL),
 		zkPath:   make(map[string]int),
 	}
+	r.serializer = extension.GetRegistryDataSerializer(url.GetParam(constant.REGISTRY_DATA_SERIALIZER_KEY, constant.DEFAULT_KEY))
 
 	err = zookeeper.ValidateZookeeperClient(r, zookeeper.WithZkName(RegistryZkClient))
 	if err != nil {
@@ -108,6 +111,7 @@ func newZkRegistry(url *common.URL) (registry.Registry, error) {
 	r.listener = zookeeper.NewZkEventListener(r.client)
 	r.configListener = NewRegistryConfigurationListener(r.client, r)
 	r.dataListener = NewRegistryDataListener(r.configListener)
+	r.dataListener.serializer = r.serializer
 
 	return r, nil
 }
@@ -133,6 +137,7 @@ func newMockZkRegistry(url *common.URL, opts ...zookeeper.Option) (*zk.TestClust
 		services: make(map[string]common.URL),
 		zkPath:   make(map[string]int),
 	}
+	r.serializer = extension.GetRegistryDataSerializer(url.GetParam(constant.REGISTRY_DATA_SERIALIZER_KEY, constant.DEFAULT_KEY))
 
 	c, r.client, _, err = zookeeper.NewMockZookeeperClient("test", 15*time.Second, opts...)
 	if err != nil {
@@ -144,6 +149,7 @@ func newMockZkRegistry(url *common.URL, opts ...zookeeper.Option) (*zk.TestClust
 	r.listener = zookeeper.NewZkEventListener(r.client)
 	r.configListener = NewRegistryConfigurationListener(r.client, r)
 	r.dataListener = NewRegistryDataListener(r.configListener)
+	r.dataListener.serializer = r.serializer
 
 	return c, r, nil
 }
@@ -319,7 +325,6 @@ func (r *zkRegistry) register(c common.URL) error {
 		}
 
 		rawURL = fmt.Sprintf("%s://%s%s?%s", c.Protocol, host, c.Path, params.Encode())
-		encodedURL = url.QueryEscape(rawURL)
 
 		// Print your own registration service providers.
 		dubboPath = fmt.Sprintf("/dubbo/%s/%s", c.Service(), (common.RoleType(common.PROVIDER)).String())
@@ -349,7 +354,6 @@ func (r *zkRegistry) register(c common.URL) error {
 		params.Add("dubbo", "dubbogo-consumer-"+constant.Version)
 
 		rawURL = fmt.Sprintf("consumer://%s%s?%s", localIP, c.Path, params.Encode())
-		encodedURL = url.QueryEscape(rawURL)
 
 		dubboPath = fmt.Sprintf("/dubbo/%s/%s", c.Service(), (common.RoleType(common.CONSUMER)).String())
 		logger.Debugf("consumer path:%s, url:%s", dubboPath, rawURL)
@@ -358,6 +362,10 @@ func (r *zkRegistry) register(c common.URL) error {
 		return perrors.Errorf("@c{%v} type is not referencer or provider", c)
 	}
 
+	encodedURL, err = r.serializer.Encode(rawURL)
+	if err != nil {
+		return perrors.WithMessagef(err, "encode the url %s", rawURL)
+	}
 	err = r.registerTempZookeeperNode(dubboPath, encodedURL)
 
 	if err != nil {